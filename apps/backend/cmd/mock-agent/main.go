@@ -58,6 +58,15 @@ var _ acp.Agent = (*mockAgent)(nil)
 func main() {
 	model := parseModelFlag()
 
+	// Replay mode: re-emit a recorded ACP session instead of scripted output
+	if path := parseReplayFromArgs(os.Args); path != "" {
+		if err := runReplay(path, os.Args); err != nil {
+			_, _ = fmt.Fprintf(logOutput, "mock-agent: replay failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// TUI mode: simple terminal UI for passthrough/PTY testing
 	if parseTUIFlag() {
 		resumeID := parseResumeFlag()
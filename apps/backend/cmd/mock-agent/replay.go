@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
+)
+
+// runReplay plays a recorded ACP session (see KANDEV_ACP_RECORD_DIR) as the
+// agent side of the connection instead of the scripted mock responses.
+func runReplay(path string, args []string) error {
+	entries, err := acprecord.Load(path)
+	if err != nil {
+		return fmt.Errorf("load recording: %w", err)
+	}
+	speed, err := parseReplaySpeedFromArgs(args)
+	if err != nil {
+		return err
+	}
+	maxDelay, err := parseReplayMaxDelayFromArgs(args)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(logOutput, "mock-agent: replaying %s (%d entries, speed %g)\n", path, len(entries), speed)
+	replayer := acprecord.NewReplayer(entries, acprecord.ReplayOptions{
+		Speed:    speed,
+		MaxDelay: maxDelay,
+		Log:      logOutput,
+		Stderr:   logOutput,
+	})
+	return replayer.Run(context.Background(), os.Stdin, os.Stdout)
+}
+
+// parseReplayFromArgs extracts the --replay recording path from the given
+// args slice.
+func parseReplayFromArgs(args []string) string {
+	return flagValueFromArgs(args, "--replay")
+}
+
+// parseReplaySpeedFromArgs extracts --replay-speed: 1 keeps the recorded
+// timing, 10 replays ten times faster and 0 drops the delays.
+func parseReplaySpeedFromArgs(args []string) (float64, error) {
+	raw := flagValueFromArgs(args, "--replay-speed")
+	if raw == "" {
+		return 1, nil
+	}
+	speed, err := strconv.ParseFloat(raw, 64)
+	if err != nil || speed < 0 {
+		return 0, fmt.Errorf("invalid --replay-speed %q", raw)
+	}
+	return speed, nil
+}
+
+// parseReplayMaxDelayFromArgs extracts --replay-max-delay, a Go duration
+// that caps every pause between agent frames.
+func parseReplayMaxDelayFromArgs(args []string) (time.Duration, error) {
+	raw := flagValueFromArgs(args, "--replay-max-delay")
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid --replay-max-delay %q", raw)
+	}
+	return d, nil
+}
+
+// flagValueFromArgs returns the value of "--name value" or "--name=value".
+func flagValueFromArgs(args []string, name string) string {
+	for i, arg := range args[1:] {
+		if arg == name && i+1 < len(args)-1 {
+			return args[i+2]
+		}
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
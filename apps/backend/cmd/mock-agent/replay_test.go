@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReplayFromArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "no flag returns empty", args: []string{"mock-agent"}, want: ""},
+		{name: "separate flag and value", args: []string{"mock-agent", "--replay", "s.jsonl"}, want: "s.jsonl"},
+		{name: "equals syntax", args: []string{"mock-agent", "--replay=s.jsonl"}, want: "s.jsonl"},
+		{name: "flag as last arg without value", args: []string{"mock-agent", "--replay"}, want: ""},
+		{name: "speed flag is not the path", args: []string{"mock-agent", "--replay-speed", "2"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseReplayFromArgs(tt.args); got != tt.want {
+				t.Errorf("parseReplayFromArgs(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplayTimingFromArgs(t *testing.T) {
+	speed, err := parseReplaySpeedFromArgs([]string{"mock-agent"})
+	if err != nil || speed != 1 {
+		t.Errorf("default speed = %v, %v; want 1", speed, err)
+	}
+	speed, err = parseReplaySpeedFromArgs([]string{"mock-agent", "--replay-speed=0"})
+	if err != nil || speed != 0 {
+		t.Errorf("speed 0 = %v, %v; want 0", speed, err)
+	}
+	if _, err := parseReplaySpeedFromArgs([]string{"mock-agent", "--replay-speed", "fast"}); err == nil {
+		t.Error("expected error for non-numeric speed")
+	}
+
+	d, err := parseReplayMaxDelayFromArgs([]string{"mock-agent", "--replay-max-delay", "250ms"})
+	if err != nil || d != 250*time.Millisecond {
+		t.Errorf("max delay = %v, %v; want 250ms", d, err)
+	}
+	if _, err := parseReplayMaxDelayFromArgs([]string{"mock-agent", "--replay-max-delay=-1s"}); err == nil {
+		t.Error("expected error for negative max delay")
+	}
+}
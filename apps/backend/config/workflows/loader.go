@@ -55,6 +55,10 @@ type stepEventsYAML struct {
 	OnHeartbeat         []actionYAML `yaml:"on_heartbeat,omitempty"`
 	OnBudgetAlert       []actionYAML `yaml:"on_budget_alert,omitempty"`
 	OnAgentError        []actionYAML `yaml:"on_agent_error,omitempty"`
+
+	// Step SLA: on_timeout fires once the timeout elapses.
+	Timeout   *models.StepTimeout `yaml:"timeout,omitempty"`
+	OnTimeout []actionYAML        `yaml:"on_timeout,omitempty"`
 }
 
 // actionYAML is the YAML-friendly representation of a step action.
@@ -198,9 +202,15 @@ func convertStageType(stage string) (models.StageType, error) {
 // templates honest while letting the engine reject malformed actions at
 // compile time.
 var validGenericAction = map[string]bool{
+	string(models.GenericActionMoveToNext):                 true,
+	string(models.GenericActionMoveToPrevious):             true,
+	string(models.GenericActionMoveToStep):                 true,
 	string(models.GenericActionQueueRun):                   true,
 	string(models.GenericActionClearDecisions):             true,
 	string(models.GenericActionQueueRunForEachParticipant): true,
+	string(models.GenericActionRunCheck):                   true,
+	string(models.GenericActionNotify):                     true,
+	string(models.GenericActionCreateChildTask):            true,
 }
 
 // Valid action types for each event trigger.
@@ -213,6 +223,8 @@ var (
 		string(models.OnEnterClearDecisions):             true,
 		string(models.OnEnterQueueRunForEachParticipant): true,
 		string(models.OnEnterQueueRun):                   true,
+		string(models.OnEnterRunCheck):                   true,
+		string(models.OnEnterFanOut):                     true,
 	}
 	validOnTurnStart = map[string]bool{
 		string(models.OnTurnStartMoveToNext):     true,
@@ -224,6 +236,7 @@ var (
 		string(models.OnTurnCompleteMoveToPrevious):  true,
 		string(models.OnTurnCompleteMoveToStep):      true,
 		string(models.OnTurnCompleteDisablePlanMode): true,
+		string(models.OnTurnCompleteRunCheck):        true,
 	}
 	validOnExit = map[string]bool{
 		string(models.OnExitDisablePlanMode): true,
@@ -246,6 +259,14 @@ func convertEvents(e stepEventsYAML) (models.StepEvents, error) {
 				return events, fmt.Errorf("on_enter set_session_mode requires a non-empty string \"mode\" config")
 			}
 		}
+		if err := validateRunCheckYAML("on_enter", a); err != nil {
+			return events, err
+		}
+		if a.Type == string(models.OnEnterFanOut) {
+			if err := models.ValidateFanOutConfig(a.Config); err != nil {
+				return events, fmt.Errorf("on_enter: %w", err)
+			}
+		}
 		events.OnEnter = append(events.OnEnter, models.OnEnterAction{
 			Type:   models.OnEnterActionType(a.Type),
 			Config: a.Config,
@@ -264,6 +285,9 @@ func convertEvents(e stepEventsYAML) (models.StepEvents, error) {
 		if !validOnTurnComplete[a.Type] {
 			return events, fmt.Errorf("invalid on_turn_complete action type %q", a.Type)
 		}
+		if err := validateRunCheckYAML("on_turn_complete", a); err != nil {
+			return events, err
+		}
 		events.OnTurnComplete = append(events.OnTurnComplete, models.OnTurnCompleteAction{
 			Type:   models.OnTurnCompleteActionType(a.Type),
 			Config: a.Config,
@@ -281,9 +305,29 @@ func convertEvents(e stepEventsYAML) (models.StepEvents, error) {
 	if err := convertGenericTriggers(e, &events); err != nil {
 		return events, err
 	}
+	events.Timeout = e.Timeout
+	if err := models.ValidateStepTimeout(events); err != nil {
+		return events, err
+	}
+	if err := models.ValidateTransitionGuards(events); err != nil {
+		return events, err
+	}
 	return events, nil
 }
 
+// validateRunCheckYAML rejects run_check actions without a command — the
+// engine would otherwise drop them at compile time and the gate would
+// silently never run.
+func validateRunCheckYAML(trigger string, a actionYAML) error {
+	if a.Type != "run_check" {
+		return nil
+	}
+	if command, _ := a.Config[models.RunCheckCommandConfigKey].(string); strings.TrimSpace(command) == "" {
+		return fmt.Errorf("%s run_check requires a non-empty string \"command\" config", trigger)
+	}
+	return nil
+}
+
 // convertGenericTriggers translates the YAML actions under the seven
 // Phase 2 (ADR-0004) event-driven triggers into models.GenericAction lists.
 func convertGenericTriggers(e stepEventsYAML, events *models.StepEvents) error {
@@ -299,12 +343,16 @@ func convertGenericTriggers(e stepEventsYAML, events *models.StepEvents) error {
 		{"on_heartbeat", e.OnHeartbeat, &events.OnHeartbeat},
 		{"on_budget_alert", e.OnBudgetAlert, &events.OnBudgetAlert},
 		{"on_agent_error", e.OnAgentError, &events.OnAgentError},
+		{"on_timeout", e.OnTimeout, &events.OnTimeout},
 	}
 	for _, m := range mappings {
 		for _, a := range m.in {
 			if !validGenericAction[a.Type] {
 				return fmt.Errorf("invalid %s action type %q", m.name, a.Type)
 			}
+			if err := validateRunCheckYAML(m.name, a); err != nil {
+				return err
+			}
 			*m.out = append(*m.out, models.GenericAction{
 				Type:   models.GenericActionType(a.Type),
 				Config: a.Config,
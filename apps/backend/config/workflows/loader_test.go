@@ -132,6 +132,119 @@ func TestConvertEvents_SetSessionModeRejectsMissingMode(t *testing.T) {
 	}
 }
 
+// TestConvertEvents_RunCheck verifies run_check is accepted on on_turn_complete
+// with a command and rejected without one.
+func TestConvertEvents_RunCheck(t *testing.T) {
+	const yamlDoc = `
+on_turn_complete:
+  - type: run_check
+    config:
+      command: make test
+      timeout_seconds: 300
+`
+	var e stepEventsYAML
+	if err := yaml.Unmarshal([]byte(yamlDoc), &e); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	events, err := convertEvents(e)
+	if err != nil {
+		t.Fatalf("convertEvents returned error: %v", err)
+	}
+	if len(events.OnTurnComplete) != 1 || events.OnTurnComplete[0].Type != models.OnTurnCompleteRunCheck {
+		t.Fatalf("unexpected on_turn_complete actions: %+v", events.OnTurnComplete)
+	}
+
+	var missing stepEventsYAML
+	if err := yaml.Unmarshal([]byte("on_turn_complete:\n  - type: run_check\n"), &missing); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	if _, err := convertEvents(missing); err == nil {
+		t.Fatal("expected convertEvents to reject run_check without a command")
+	}
+}
+
+// TestConvertEvents_FanOut verifies fan_out is accepted on on_enter and a
+// judge join without a judge profile is rejected.
+func TestConvertEvents_FanOut(t *testing.T) {
+	const yamlDoc = `
+on_enter:
+  - type: fan_out
+    config:
+      agent_profile_ids: [claude, codex]
+      join: judge
+      judge_agent_profile_id: opus
+`
+	var e stepEventsYAML
+	if err := yaml.Unmarshal([]byte(yamlDoc), &e); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	events, err := convertEvents(e)
+	if err != nil {
+		t.Fatalf("convertEvents returned error: %v", err)
+	}
+	if len(events.OnEnter) != 1 || events.OnEnter[0].Type != models.OnEnterFanOut {
+		t.Fatalf("unexpected on_enter actions: %+v", events.OnEnter)
+	}
+
+	var noJudge stepEventsYAML
+	const noJudgeDoc = "on_enter:\n  - type: fan_out\n    config:\n      agent_profile_ids: [claude, codex]\n      join: judge\n"
+	if err := yaml.Unmarshal([]byte(noJudgeDoc), &noJudge); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	if _, err := convertEvents(noJudge); err == nil {
+		t.Fatal("expected convertEvents to reject a judge join without a judge profile")
+	}
+}
+
+func TestConvertEvents_RejectsInvalidGuardExpression(t *testing.T) {
+	const yamlDoc = `
+on_turn_complete:
+  - type: move_to_next
+    config:
+      if: review.blocking_findings == 0 &&
+`
+	var e stepEventsYAML
+	if err := yaml.Unmarshal([]byte(yamlDoc), &e); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	if _, err := convertEvents(e); err == nil {
+		t.Fatal("expected convertEvents to reject a malformed if expression")
+	}
+}
+
+func TestConvertEvents_StepTimeout(t *testing.T) {
+	const yamlDoc = `
+timeout:
+  after_minutes: 2880
+on_timeout:
+  - type: notify
+    config:
+      title: Review is stale
+  - type: move_to_step
+    config:
+      step_id: triage
+`
+	var e stepEventsYAML
+	if err := yaml.Unmarshal([]byte(yamlDoc), &e); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	events, err := convertEvents(e)
+	if err != nil {
+		t.Fatalf("convertEvents returned error: %v", err)
+	}
+	if events.Timeout == nil || events.Timeout.AfterMinutes != 2880 || len(events.OnTimeout) != 2 {
+		t.Fatalf("unexpected timeout events: %+v", events)
+	}
+
+	var orphan stepEventsYAML
+	if err := yaml.Unmarshal([]byte("on_timeout:\n  - type: notify\n"), &orphan); err != nil {
+		t.Fatalf("unmarshal yaml: %v", err)
+	}
+	if _, err := convertEvents(orphan); err == nil {
+		t.Fatal("expected convertEvents to reject on_timeout without a timeout")
+	}
+}
+
 func TestLoadTemplates_EachHasStartStep(t *testing.T) {
 	templates, err := LoadTemplates()
 	if err != nil {
@@ -11,37 +11,13 @@ import (
 	"fmt"
 	"io"
 	"sync"
+
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
 )
 
 // Frame is a single JSON-RPC 2.0 message — request, response, or notification.
-// We keep it as a generic map because the whole point of this tool is to
-// capture whatever the agent actually sends, including fields the SDK would
-// drop or normalize.
-type Frame map[string]any
-
-// ID returns the request/response id as-is (may be nil for notifications,
-// float64 for numeric ids, or string for string ids — ACP uses integers).
-func (f Frame) ID() any {
-	return f["id"]
-}
-
-// Method returns the method name if this is a request or notification, or "".
-func (f Frame) Method() string {
-	m, _ := f["method"].(string)
-	return m
-}
-
-// IsResponse reports whether the frame has a result or error field (and
-// therefore a matching request id).
-func (f Frame) IsResponse() bool {
-	if _, ok := f["result"]; ok {
-		return true
-	}
-	if _, ok := f["error"]; ok {
-		return true
-	}
-	return false
-}
+// It is defined by the acprecord package, which owns the JSONL format.
+type Frame = acprecord.Frame
 
 // Framer writes JSON-RPC frames to a child's stdin and reads frames from the
 // child's stdout. It is NOT thread-safe for concurrent writes on the same
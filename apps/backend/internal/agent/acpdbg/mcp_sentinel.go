@@ -9,6 +9,8 @@ import (
 	"net/http/httptest"
 	"sync"
 	"time"
+
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
 )
 
 const (
@@ -21,7 +23,7 @@ const (
 // acpdbg. It records protocol milestones without recording MCP payloads.
 // Absence of a milestone is an unobserved result, not an agent failure.
 type MCPSentinel struct {
-	recorder *Recorder
+	recorder *acprecord.Recorder
 	server   *httptest.Server
 
 	mu          sync.Mutex
@@ -45,7 +47,7 @@ type MCPSentinelSummary struct {
 
 // NewMCPSentinel starts an in-process sentinel. Call Close after session/new
 // has returned; the server never exposes a host project endpoint or credentials.
-func NewMCPSentinel(recorder *Recorder) *MCPSentinel {
+func NewMCPSentinel(recorder *acprecord.Recorder) *MCPSentinel {
 	s := &MCPSentinel{recorder: recorder, connections: make(map[string]*sentinelConnection)}
 	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
 	_ = recorder.Meta("mcp_sentinel_started", map[string]any{"target": s.server.URL + "/mcp"})
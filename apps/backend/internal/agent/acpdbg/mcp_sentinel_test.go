@@ -7,12 +7,13 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
 	"github.com/stretchr/testify/require"
 )
 
 func TestMCPSentinelRecordsOnlyProtocolMilestones(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "sentinel.jsonl")
-	recorder, err := NewRecorder(path)
+	recorder, err := acprecord.NewRecorder(path)
 	require.NoError(t, err)
 	t.Cleanup(func() { _ = recorder.Close() })
 	sentinel := NewMCPSentinel(recorder)
@@ -12,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
 )
 
 // RunConfig is passed to Run. Command is the argv to spawn; the env is
@@ -37,7 +39,7 @@ type RunConfig struct {
 // framer. Runner.Close always finalizes the recorder and kills any
 // still-running child.
 type Runner struct {
-	rec    *Recorder
+	rec    *acprecord.Recorder
 	cfg    RunConfig
 	tmpDir string // non-empty if we allocated the workdir ourselves
 
@@ -87,7 +89,7 @@ func NewRunner(ctx context.Context, jsonlPath string, cfg RunConfig) (*Runner, e
 		return nil, errors.New("command is empty")
 	}
 
-	rec, err := NewRecorder(jsonlPath)
+	rec, err := acprecord.NewRecorder(jsonlPath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,93 @@
+package agents
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/kandev/kandev/internal/agent/usage"
+	"github.com/kandev/kandev/pkg/agent"
+)
+
+//go:embed logos/openai_compatible_light.svg
+var openAICompatibleLogoLight []byte
+
+//go:embed logos/openai_compatible_dark.svg
+var openAICompatibleLogoDark []byte
+
+// openAICompatibleCmd is a placeholder command. The agent runs inside
+// agentctl, so nothing is executed, but the launch path requires a command.
+const openAICompatibleCmd = "openai-compatible"
+
+var _ Agent = (*OpenAICompatible)(nil)
+
+// OpenAICompatible is the built-in agent that calls an OpenAI-compatible chat
+// completions endpoint (Ollama, vLLM, LiteLLM, llama.cpp server, ...)
+// directly from agentctl. It needs no CLI install: the endpoint, API key and
+// model come from OPENAI_BASE_URL, OPENAI_API_KEY and OPENAI_MODEL in the
+// profile environment.
+type OpenAICompatible struct{}
+
+func NewOpenAICompatible() *OpenAICompatible {
+	return &OpenAICompatible{}
+}
+
+func (a *OpenAICompatible) ID() string          { return "openai-compatible" }
+func (a *OpenAICompatible) Name() string        { return "OpenAI-Compatible Agent" }
+func (a *OpenAICompatible) DisplayName() string { return "OpenAI-Compatible" }
+func (a *OpenAICompatible) Description() string {
+	return "Built-in agent that talks to any OpenAI-compatible chat completions API with tool calling."
+}
+func (a *OpenAICompatible) Enabled() bool     { return true }
+func (a *OpenAICompatible) DisplayOrder() int { return 22 }
+
+func (a *OpenAICompatible) Logo(v LogoVariant) []byte {
+	if v == LogoDark {
+		return openAICompatibleLogoDark
+	}
+	return openAICompatibleLogoLight
+}
+
+// IsInstalled always reports the agent as available: it ships with agentctl.
+func (a *OpenAICompatible) IsInstalled(_ context.Context) (*DiscoveryResult, error) {
+	return &DiscoveryResult{Available: true, SupportsMCP: true}, nil
+}
+
+func (a *OpenAICompatible) BuildCommand(opts CommandOptions) Command {
+	return Cmd(openAICompatibleCmd).Build()
+}
+
+func (a *OpenAICompatible) Runtime() *RuntimeConfig {
+	return &RuntimeConfig{
+		Cmd:            Cmd(openAICompatibleCmd).Build(),
+		WorkingDir:     "{workspace}",
+		Env:            map[string]string{},
+		ResourceLimits: ResourceLimits{MemoryMB: 1024, CPUCores: 1.0, Timeout: time.Hour},
+		Protocol:       agent.ProtocolOpenAI,
+		SessionConfig: SessionConfig{
+			// The conversation lives in agentctl memory, so a resumed task
+			// gets its history injected into the first prompt instead.
+			HistoryContextInjection: true,
+		},
+	}
+}
+
+func (a *OpenAICompatible) RemoteAuth() *RemoteAuth {
+	return &RemoteAuth{
+		Methods: []RemoteAuthMethod{
+			{
+				Type:      "env",
+				EnvVar:    "OPENAI_API_KEY",
+				SetupHint: "API key for the endpoint; leave unset for local servers that need none",
+			},
+		},
+	}
+}
+
+func (a *OpenAICompatible) InstallScript() string { return "" }
+
+func (a *OpenAICompatible) PermissionSettings() map[string]PermissionSetting {
+	return emptyPermSettings
+}
+
+func (a *OpenAICompatible) BillingType() usage.BillingType { return defaultBillingType() }
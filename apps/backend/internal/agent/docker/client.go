@@ -12,6 +12,7 @@ import (
 	"net"
 	"net/netip"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,6 +41,9 @@ type ContainerConfig struct {
 	Labels       map[string]string
 	AutoRemove   bool
 	PortBindings []PortBindingConfig
+	UsernsMode   string   // User namespace mode (e.g. Podman's "keep-id"); empty = engine default
+	SecurityOpt  []string // Security options (e.g. "label=disable")
+	ExtraHosts   []string // Additional /etc/hosts entries ("name:ip" or "name:host-gateway")
 }
 
 // PortBindingConfig describes a container port to publish on the Docker host.
@@ -51,9 +55,11 @@ type PortBindingConfig struct {
 
 // MountConfig holds mount configuration.
 type MountConfig struct {
-	Source   string // Host path
+	Type     string // "bind" (default) or "volume"
+	Source   string // Host path, or volume name for volume mounts
 	Target   string // Container path
 	ReadOnly bool
+	Relabel  string // SELinux relabel flag: "z" (shared), "Z" (private) or empty
 }
 
 // ContainerInfo holds information about a running container.
@@ -68,6 +74,7 @@ type ContainerInfo struct {
 	ExitCode   int
 	Health     string
 	Labels     map[string]string
+	Env        []string // Config env; only set by GetContainerInfo
 }
 
 // Client wraps the Docker client.
@@ -168,6 +175,22 @@ func (c *Client) PullImage(ctx context.Context, imageName string) error {
 // The caller is responsible for closing the returned reader.
 func (c *Client) BuildImage(ctx context.Context, dockerfile string, tag string, buildArgs map[string]*string) (io.ReadCloser, error) {
 	c.logger.Info("Building image", zap.String("tag", tag))
+	buildContext, err := createDockerfileTar(dockerfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build context: %w", err)
+	}
+	return c.startBuild(ctx, buildContext, client.ImageBuildOptions{
+		Tags:       []string{tag},
+		BuildArgs:  buildArgs,
+		Dockerfile: "Dockerfile",
+		Remove:     true,
+	})
+}
+
+// startBuild sends buildContext to the daemon while holding an image-build
+// activity lease, which is released when the returned output is drained or
+// closed.
+func (c *Client) startBuild(ctx context.Context, buildContext io.Reader, opts client.ImageBuildOptions) (io.ReadCloser, error) {
 	c.mu.RLock()
 	coordinator := c.activity
 	builder := c.builder
@@ -176,30 +199,19 @@ func (c *Client) BuildImage(ctx context.Context, dockerfile string, tag string,
 		builder = c.cli
 	}
 	var activityLease *activity.TaskLease
-	var err error
 	if coordinator != nil {
+		var err error
 		activityLease, err = coordinator.AcquireTask(ctx, activity.KindDockerImageBuild)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	buildContext, err := createDockerfileTar(dockerfile)
-	if err != nil {
-		activityLease.Release()
-		return nil, fmt.Errorf("failed to create build context: %w", err)
-	}
-
-	resp, err := builder.ImageBuild(ctx, buildContext, client.ImageBuildOptions{
-		Tags:       []string{tag},
-		BuildArgs:  buildArgs,
-		Dockerfile: "Dockerfile",
-		Remove:     true,
-	})
+	resp, err := builder.ImageBuild(ctx, buildContext, opts)
 	if err != nil {
 		activityLease.Release()
-		c.logger.Error("Failed to build image", zap.String("tag", tag), zap.Error(err))
-		return nil, fmt.Errorf("failed to build image %s: %w", tag, err)
+		c.logger.Error("Failed to build image", zap.Strings("tags", opts.Tags), zap.Error(err))
+		return nil, fmt.Errorf("failed to build image %s: %w", strings.Join(opts.Tags, ", "), err)
 	}
 
 	return &activityReadCloser{ReadCloser: resp.Body, lease: activityLease}, nil
@@ -259,16 +271,7 @@ func (c *Client) CreateContainer(ctx context.Context, cfg ContainerConfig) (stri
 		zap.String("image", cfg.Image),
 	)
 
-	// Build mounts
-	mounts := make([]mount.Mount, 0, len(cfg.Mounts))
-	for _, m := range cfg.Mounts {
-		mounts = append(mounts, mount.Mount{
-			Type:     mount.TypeBind,
-			Source:   m.Source,
-			Target:   m.Target,
-			ReadOnly: m.ReadOnly,
-		})
-	}
+	mounts, binds := buildContainerMounts(cfg.Mounts)
 
 	// Container configuration
 	exposedPorts, portBindings, err := buildDockerPortBindings(cfg.PortBindings)
@@ -288,9 +291,13 @@ func (c *Client) CreateContainer(ctx context.Context, cfg ContainerConfig) (stri
 	// Host configuration
 	hostCfg := &container.HostConfig{
 		Mounts:       mounts,
+		Binds:        binds,
 		NetworkMode:  container.NetworkMode(cfg.NetworkMode),
 		AutoRemove:   cfg.AutoRemove,
 		PortBindings: portBindings,
+		UsernsMode:   container.UsernsMode(cfg.UsernsMode),
+		SecurityOpt:  cfg.SecurityOpt,
+		ExtraHosts:   cfg.ExtraHosts,
 		Resources: container.Resources{
 			Memory:   cfg.Memory,
 			CPUQuota: cfg.CPUQuota,
@@ -314,6 +321,35 @@ func (c *Client) CreateContainer(ctx context.Context, cfg ContainerConfig) (stri
 	return resp.ID, nil
 }
 
+// buildContainerMounts splits mounts into typed bind mounts and legacy
+// "src:dst:opts" binds. The mounts API has no SELinux relabel option, so
+// mounts that need one go through Binds, which Podman honors.
+func buildContainerMounts(configs []MountConfig) ([]mount.Mount, []string) {
+	mounts := make([]mount.Mount, 0, len(configs))
+	var binds []string
+	for _, m := range configs {
+		if m.Relabel != "" {
+			opts := "rw," + m.Relabel
+			if m.ReadOnly {
+				opts = "ro," + m.Relabel
+			}
+			binds = append(binds, m.Source+":"+m.Target+":"+opts)
+			continue
+		}
+		mountType := mount.TypeBind
+		if m.Type != "" {
+			mountType = mount.Type(m.Type)
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mountType,
+			Source:   m.Source,
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+	return mounts, binds
+}
+
 func buildDockerPortBindings(bindings []PortBindingConfig) (network.PortSet, network.PortMap, error) {
 	if len(bindings) == 0 {
 		return nil, nil, nil
@@ -417,6 +453,20 @@ func (c *Client) RemoveContainer(ctx context.Context, containerID string, force
 	return nil
 }
 
+// RenameContainer gives a container a new name.
+func (c *Client) RenameContainer(ctx context.Context, containerID, newName string) error {
+	c.logger.Info("Renaming container",
+		zap.String("container_id", containerID),
+		zap.String("new_name", newName),
+	)
+
+	if _, err := c.cli.ContainerRename(ctx, containerID, client.ContainerRenameOptions{NewName: newName}); err != nil {
+		c.logger.Error("Failed to rename container", zap.String("container_id", containerID), zap.Error(err))
+		return fmt.Errorf("failed to rename container %s: %w", containerID, err)
+	}
+	return nil
+}
+
 func (c *Client) containerRemover() containerRemover {
 	if c.remover != nil {
 		return c.remover
@@ -467,6 +517,7 @@ func (c *Client) GetContainerInfo(ctx context.Context, containerID string) (*Con
 	if inspect.Config != nil {
 		info.Image = inspect.Config.Image
 		info.Labels = inspect.Config.Labels
+		info.Env = inspect.Config.Env
 	}
 	applyContainerState(info, inspect.State)
 
@@ -726,16 +777,7 @@ func (c *Client) CreateContainerInteractive(ctx context.Context, cfg ContainerCo
 		zap.String("image", cfg.Image),
 	)
 
-	// Build mounts
-	mounts := make([]mount.Mount, 0, len(cfg.Mounts))
-	for _, m := range cfg.Mounts {
-		mounts = append(mounts, mount.Mount{
-			Type:     mount.TypeBind,
-			Source:   m.Source,
-			Target:   m.Target,
-			ReadOnly: m.ReadOnly,
-		})
-	}
+	mounts, binds := buildContainerMounts(cfg.Mounts)
 
 	// Container configuration with stdin attached. Mirror CreateContainer's
 	// handling of ContainerConfig fields — including Entrypoint — so the same
@@ -763,9 +805,13 @@ func (c *Client) CreateContainerInteractive(ctx context.Context, cfg ContainerCo
 	// Host configuration
 	hostCfg := &container.HostConfig{
 		Mounts:       mounts,
+		Binds:        binds,
 		NetworkMode:  container.NetworkMode(cfg.NetworkMode),
 		AutoRemove:   cfg.AutoRemove,
 		PortBindings: portBindings,
+		UsernsMode:   container.UsernsMode(cfg.UsernsMode),
+		SecurityOpt:  cfg.SecurityOpt,
+		ExtraHosts:   cfg.ExtraHosts,
 		Resources: container.Resources{
 			Memory:   cfg.Memory,
 			CPUQuota: cfg.CPUQuota,
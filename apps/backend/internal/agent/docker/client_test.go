@@ -130,3 +130,31 @@ func TestParseHostPort(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildContainerMounts_RelabeledMountsBecomeBinds(t *testing.T) {
+	mounts, binds := buildContainerMounts([]MountConfig{
+		{Source: "/host/session", Target: "/root/.claude", Relabel: "z"},
+		{Source: "/host/agentctl", Target: "/usr/local/bin/agentctl", ReadOnly: true},
+		{Source: "/host/repo", Target: "/host/repo", ReadOnly: true, Relabel: "Z"},
+	})
+
+	if len(mounts) != 1 || mounts[0].Source != "/host/agentctl" || !mounts[0].ReadOnly {
+		t.Fatalf("mounts = %+v, want only the unlabeled agentctl mount", mounts)
+	}
+	want := []string{"/host/session:/root/.claude:rw,z", "/host/repo:/host/repo:ro,Z"}
+	if len(binds) != len(want) {
+		t.Fatalf("binds = %v, want %v", binds, want)
+	}
+	for i := range want {
+		if binds[i] != want[i] {
+			t.Errorf("binds[%d] = %q, want %q", i, binds[i], want[i])
+		}
+	}
+}
+
+func TestBuildContainerMounts_NoRelabelKeepsBindsNil(t *testing.T) {
+	_, binds := buildContainerMounts([]MountConfig{{Source: "/a", Target: "/b"}})
+	if binds != nil {
+		t.Errorf("binds = %v, want nil", binds)
+	}
+}
@@ -0,0 +1,166 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/client"
+	"go.uber.org/zap"
+)
+
+// DirectoryBuild describes an image build from a host directory.
+type DirectoryBuild struct {
+	ContextDir string            // Host directory sent as the build context
+	Dockerfile string            // Dockerfile path inside ContextDir, slash-separated
+	Tag        string            // Tag applied to the built image
+	BuildArgs  map[string]string // --build-arg values
+	Target     string            // Multi-stage target; empty builds the last stage
+}
+
+// InspectImageEnv returns the environment configured in a local image.
+// found is false when the image is not present locally.
+func (c *Client) InspectImageEnv(ctx context.Context, ref string) (env []string, found bool, err error) {
+	resp, err := c.cli.ImageInspect(ctx, ref)
+	if cerrdefs.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+	if resp.Config != nil {
+		env = resp.Config.Env
+	}
+	return env, true, nil
+}
+
+// BuildImageFromDirectory builds an image from a host directory and waits
+// for the build to finish. The directory's .git is not sent to the daemon.
+func (c *Client) BuildImageFromDirectory(ctx context.Context, build DirectoryBuild) error {
+	c.logger.Info("Building image from directory",
+		zap.String("tag", build.Tag),
+		zap.String("context", build.ContextDir))
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeDirectoryTar(pw, build.ContextDir))
+	}()
+	defer func() { _ = pr.Close() }()
+
+	buildArgs := make(map[string]*string, len(build.BuildArgs))
+	for key, value := range build.BuildArgs {
+		buildArgs[key] = &value
+	}
+	output, err := c.startBuild(ctx, pr, client.ImageBuildOptions{
+		Tags:       []string{build.Tag},
+		BuildArgs:  buildArgs,
+		Dockerfile: build.Dockerfile,
+		Target:     build.Target,
+		Remove:     true,
+	})
+	if err != nil {
+		return err
+	}
+	return WaitForBuild(output)
+}
+
+// WaitForBuild drains and closes build output, returning the build's error
+// message when the daemon reports one. A failed build still returns HTTP 200,
+// so the stream is the only place the failure shows up.
+func WaitForBuild(output io.ReadCloser) error {
+	defer func() { _ = output.Close() }()
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lastLines []string
+	for scanner.Scan() {
+		var msg struct {
+			Stream string `json:"stream"`
+			Error  string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Error != "" {
+			if len(lastLines) > 0 {
+				return fmt.Errorf("image build failed: %s\n%s", msg.Error, strings.Join(lastLines, "\n"))
+			}
+			return fmt.Errorf("image build failed: %s", msg.Error)
+		}
+		if line := strings.TrimSpace(msg.Stream); line != "" {
+			lastLines = append(lastLines, line)
+			if len(lastLines) > 10 {
+				lastLines = lastLines[1:]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading image build output: %w", err)
+	}
+	return nil
+}
+
+// writeDirectoryTar writes dir as a tar stream. Symlinks are stored as
+// links rather than followed, so a link can't pull host files outside dir
+// into the build context.
+func writeDirectoryTar(w io.Writer, dir string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() && info.Mode()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileTo(tw, path)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive build context %s: %w", dir, err)
+	}
+	return tw.Close()
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return errors.Join(err, f.Close())
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWriteDirectoryTarSkipsGit(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		".devcontainer/Dockerfile": "FROM debian:12\n",
+		"go.mod":                   "module x\n",
+		".git/HEAD":                "ref: refs/heads/main\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeDirectoryTar(&buf, dir); err != nil {
+		t.Fatalf("writeDirectoryTar: %v", err)
+	}
+	var names []string
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Name == "link" && (header.Typeflag != tar.TypeSymlink || header.Linkname != "/etc/passwd") {
+			t.Errorf("symlink archived as %+v, want a link entry", header)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	want := []string{".devcontainer", ".devcontainer/Dockerfile", "go.mod", "link"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("archived %v, want %v", names, want)
+	}
+}
+
+func TestWaitForBuild(t *testing.T) {
+	ok := io.NopCloser(strings.NewReader(`{"stream":"Step 1/1 : FROM debian:12\n"}` + "\n" + `{"aux":{"ID":"sha256:abc"}}` + "\n"))
+	if err := WaitForBuild(ok); err != nil {
+		t.Fatalf("WaitForBuild() = %v, want nil", err)
+	}
+
+	failed := io.NopCloser(strings.NewReader(`{"stream":"Step 2/2 : RUN false\n"}` + "\n" +
+		`{"errorDetail":{"code":1},"error":"The command '/bin/sh -c false' returned a non-zero code: 1"}` + "\n"))
+	err := WaitForBuild(failed)
+	if err == nil || !strings.Contains(err.Error(), "non-zero code") || !strings.Contains(err.Error(), "RUN false") {
+		t.Fatalf("WaitForBuild() = %v, want the daemon error with recent output", err)
+	}
+}
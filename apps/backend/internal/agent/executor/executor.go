@@ -19,6 +19,8 @@ const (
 	NameRemoteDocker      = agentruntime.RuntimeRemoteDocker
 	NameSprites           = agentruntime.RuntimeSprites
 	NameSSH               = agentruntime.RuntimeSSH
+	NameKubernetes        = agentruntime.RuntimeKubernetes
+	NamePodman            = agentruntime.RuntimePodman
 )
 
 // ExecutorTypeToBackend maps an ExecutorType to its corresponding executor Name.
@@ -36,6 +38,10 @@ func ExecutorTypeToBackend(execType models.ExecutorType) Name {
 		return NameSprites
 	case models.ExecutorTypeSSH:
 		return NameSSH
+	case models.ExecutorTypeKubernetes:
+		return NameKubernetes
+	case models.ExecutorTypePodman:
+		return NamePodman
 	case models.ExecutorTypeMockRemote:
 		return NameStandalone
 	default:
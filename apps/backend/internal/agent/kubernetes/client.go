@@ -0,0 +1,85 @@
+// Package kubernetes connects the Kubernetes executor to a cluster: a
+// client-go clientset resolved from the backend's configuration, plus a way
+// to reach a port on an agent pod.
+package kubernetes
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kandev/kandev/internal/common/config"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+// Connect modes, see config.KubernetesConfig.ConnectMode.
+const (
+	ConnectModeProxy  = "proxy"
+	ConnectModeDirect = "direct"
+)
+
+// Client is a clientset for one cluster with the namespace pods go to when
+// the caller names none and how PodEndpoint reaches a pod port.
+type Client struct {
+	clientset.Interface
+
+	restConfig  *rest.Config
+	namespace   string
+	connectMode string
+	logger      *logger.Logger
+}
+
+// NewClient connects using the executor's configuration. The kubeconfig is
+// resolved like kubectl does: the configured file, else $KUBECONFIG or
+// ~/.kube/config, else the in-cluster service account. Credential plugins
+// that need a terminal are not run.
+func NewClient(cfg config.KubernetesConfig, log *logger.Logger) (*Client, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = cfg.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: cfg.Context}
+	overrides.Context.Namespace = cfg.Namespace
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	restConfig, err := loader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	namespace, _, err := loader.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("resolve namespace: %w", err)
+	}
+	cs, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClientForClientset(cs, namespace, cfg.ConnectMode, log)
+	client.restConfig = restConfig
+	return client, nil
+}
+
+// NewClientForClientset wraps an existing clientset, e.g. client-go's fake
+// one in tests. Without a REST config the proxy connect mode cannot
+// port-forward, so callers without one use ConnectModeDirect.
+func NewClientForClientset(cs clientset.Interface, namespace, connectMode string, log *logger.Logger) *Client {
+	if namespace == "" {
+		namespace = "default"
+	}
+	if connectMode == "" {
+		connectMode = ConnectModeProxy
+	}
+	return &Client{
+		Interface:   cs,
+		namespace:   namespace,
+		connectMode: connectMode,
+		logger:      log.WithFields(zap.String("component", "kubernetes-client")),
+	}
+}
+
+// Namespace is the namespace pods go to when the caller names none.
+func (c *Client) Namespace() string { return c.namespace }
+
+// ConnectMode is how PodEndpoint reaches a pod port.
+func (c *Client) ConnectMode() string { return c.connectMode }
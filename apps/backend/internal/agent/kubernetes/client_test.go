@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kandev/kandev/internal/common/config"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example:6443
+- name: prod
+  cluster:
+    server: https://prod.example:6443
+users:
+- name: dev
+  user:
+    token: dev-token
+- name: prod
+  user:
+    token: prod-token
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+    namespace: dev-agents
+- name: prod
+  context:
+    cluster: prod
+    user: prod
+`
+
+func newTestLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger(logger.LoggingConfig{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	return log
+}
+
+func writeKubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatalf("write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestNewClient_ResolvesKubeconfigContext(t *testing.T) {
+	path := writeKubeconfig(t)
+	cases := []struct {
+		name          string
+		cfg           config.KubernetesConfig
+		wantHost      string
+		wantToken     string
+		wantNamespace string
+	}{
+		{
+			name:          "current context",
+			cfg:           config.KubernetesConfig{Kubeconfig: path},
+			wantHost:      "https://dev.example:6443",
+			wantToken:     "dev-token",
+			wantNamespace: "dev-agents",
+		},
+		{
+			name:          "named context without namespace",
+			cfg:           config.KubernetesConfig{Kubeconfig: path, Context: "prod"},
+			wantHost:      "https://prod.example:6443",
+			wantToken:     "prod-token",
+			wantNamespace: "default",
+		},
+		{
+			name:          "configured namespace wins",
+			cfg:           config.KubernetesConfig{Kubeconfig: path, Namespace: "agents"},
+			wantHost:      "https://dev.example:6443",
+			wantToken:     "dev-token",
+			wantNamespace: "agents",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewClient(tc.cfg, newTestLogger(t))
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			if c.restConfig.Host != tc.wantHost || c.restConfig.BearerToken != tc.wantToken {
+				t.Errorf("rest config = %s %q, want %s %q", c.restConfig.Host, c.restConfig.BearerToken, tc.wantHost, tc.wantToken)
+			}
+			if c.Namespace() != tc.wantNamespace {
+				t.Errorf("namespace = %q, want %q", c.Namespace(), tc.wantNamespace)
+			}
+			if c.ConnectMode() != ConnectModeProxy {
+				t.Errorf("connect mode = %q, want %q", c.ConnectMode(), ConnectModeProxy)
+			}
+		})
+	}
+}
+
+func TestNewClient_UnknownContext(t *testing.T) {
+	_, err := NewClient(config.KubernetesConfig{Kubeconfig: writeKubeconfig(t), Context: "staging"}, newTestLogger(t))
+	if err == nil {
+		t.Fatal("expected an error for a context missing from the kubeconfig")
+	}
+}
+
+func TestPodEndpoint_Direct(t *testing.T) {
+	c := NewClientForClientset(k8sfake.NewClientset(), "", ConnectModeDirect, newTestLogger(t))
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.7"},
+	}
+	host, port, closer, err := c.PodEndpoint(pod, 39429)
+	if err != nil {
+		t.Fatalf("PodEndpoint: %v", err)
+	}
+	if host != "10.0.0.7" || port != 39429 {
+		t.Errorf("endpoint = %s:%d, want 10.0.0.7:39429", host, port)
+	}
+	if err := closer.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	pod.Status.PodIP = ""
+	if _, _, _, err := c.PodEndpoint(pod, 39429); err == nil {
+		t.Error("expected an error for a pod without an IP")
+	}
+}
+
+func TestPodEndpoint_ProxyNeedsClusterConnection(t *testing.T) {
+	c := NewClientForClientset(k8sfake.NewClientset(), "", "", newTestLogger(t))
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"}}
+	if _, _, _, err := c.PodEndpoint(pod, 39429); err == nil {
+		t.Fatal("expected proxy mode without a REST config to fail")
+	}
+}
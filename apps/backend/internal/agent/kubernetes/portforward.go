@@ -0,0 +1,121 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// PodEndpoint returns a host:port the backend can dial to reach port on pod,
+// and a closer releasing whatever it took to get there. In direct mode that
+// is the pod IP; in proxy mode a local listener forwarding through the API
+// server.
+func (c *Client) PodEndpoint(pod *corev1.Pod, port int) (string, int, io.Closer, error) {
+	if c.connectMode == ConnectModeDirect {
+		if pod.Status.PodIP == "" {
+			return "", 0, nil, fmt.Errorf("pod %s has no IP yet", pod.Name)
+		}
+		return pod.Status.PodIP, port, nopCloser{}, nil
+	}
+	fwd, err := c.ForwardPort(pod.Namespace, pod.Name, port)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return "127.0.0.1", fwd.LocalPort(), fwd, nil
+}
+
+// PortForward listens on a local port and forwards every connection to a
+// pod port through the API server, as kubectl port-forward does.
+type PortForward struct {
+	localPort int
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ForwardPort starts forwarding a local port on 127.0.0.1 to port on the
+// named pod. Close stops it.
+func (c *Client) ForwardPort(namespace, pod string, port int) (*PortForward, error) {
+	if c.restConfig == nil {
+		return nil, errors.New("port-forward needs a cluster connection; use the direct connect mode")
+	}
+	if namespace == "" {
+		namespace = c.namespace
+	}
+	dialer, err := c.portForwardDialer(namespace, pod)
+	if err != nil {
+		return nil, fmt.Errorf("port-forward to %s/%s:%d: %w", namespace, pod, port, err)
+	}
+	stop, ready := make(chan struct{}), make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"},
+		[]string{"0:" + strconv.Itoa(port)}, stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("port-forward to %s/%s:%d: %w", namespace, pod, port, err)
+	}
+
+	f := &PortForward{stop: stop, done: make(chan struct{})}
+	forwardErr := make(chan error, 1)
+	go func() {
+		defer close(f.done)
+		err := forwarder.ForwardPorts()
+		if err != nil {
+			c.logger.Debug("port-forward ended", zap.String("pod", pod), zap.Int("port", port), zap.Error(err))
+		}
+		forwardErr <- err
+	}()
+	select {
+	case <-ready:
+	case err := <-forwardErr:
+		return nil, fmt.Errorf("port-forward to %s/%s:%d: %w", namespace, pod, port, err)
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		_ = f.Close()
+		return nil, fmt.Errorf("port-forward to %s/%s:%d: no local port: %w", namespace, pod, port, err)
+	}
+	f.localPort = int(ports[0].Local)
+	return f, nil
+}
+
+// portForwardDialer dials the pod's portforward subresource. Like kubectl,
+// it tries WebSockets first and falls back to SPDY on API servers, or
+// proxies, that do not upgrade to them.
+func (c *Client) portForwardDialer(namespace, pod string) (httpstream.Dialer, error) {
+	u := c.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward").URL()
+	transport, upgrader, err := spdy.RoundTripperFor(c.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	spdyDialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, u)
+	wsDialer, err := portforward.NewSPDYOverWebsocketDialer(u, c.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return portforward.NewFallbackDialer(wsDialer, spdyDialer, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	}), nil
+}
+
+// LocalPort is the local port being forwarded.
+func (f *PortForward) LocalPort() int { return f.localPort }
+
+// Close stops listening and drops every forwarded connection.
+func (f *PortForward) Close() error {
+	f.closeOnce.Do(func() { close(f.stop) })
+	<-f.done
+	return nil
+}
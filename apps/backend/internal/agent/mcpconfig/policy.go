@@ -7,7 +7,7 @@ import "github.com/kandev/kandev/internal/agent/executor"
 // until we add explicit executor policies for their networks.
 func DefaultPolicyForRuntime(runtimeName executor.Name) Policy {
 	switch runtimeName {
-	case executor.NameLocal, executor.NameStandalone, executor.NameDocker:
+	case executor.NameLocal, executor.NameStandalone, executor.NameDocker, executor.NamePodman:
 		return Policy{
 			AllowStdio:          true,
 			AllowHTTP:           true,
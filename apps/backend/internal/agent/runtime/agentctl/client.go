@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentctl/tracing"
 	"github.com/kandev/kandev/internal/agentctl/types"
 	"github.com/kandev/kandev/internal/agentctl/types/streams"
@@ -457,6 +458,45 @@ func (c *Client) SetMcpProviders(ctx context.Context, providers []string) error
 	return nil
 }
 
+// SetPermissionPolicy replaces the permission policy agentctl evaluates
+// before forwarding a permission request. A nil policy clears it.
+func (c *Client) SetPermissionPolicy(ctx context.Context, policy *permpolicy.Policy) error {
+	ctx, span := tracing.TraceHTTPRequest(ctx, "PUT", "/api/v1/agent/permission-policy", c.executionID)
+	defer span.End()
+
+	body, err := json.Marshal(struct {
+		Policy *permpolicy.Policy `json:"policy"`
+	}{Policy: policy})
+	if err != nil {
+		tracing.TraceHTTPResponse(span, 0, err)
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/agent/permission-policy", bytes.NewReader(body))
+	if err != nil {
+		tracing.TraceHTTPResponse(span, 0, err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		tracing.TraceHTTPResponse(span, 0, err)
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := readResponseBody(resp)
+		httpErr := fmt.Errorf("set permission policy failed with status %d: %s", resp.StatusCode, string(respBody))
+		tracing.TraceHTTPResponse(span, resp.StatusCode, httpErr)
+		return httpErr
+	}
+
+	tracing.TraceHTTPResponse(span, resp.StatusCode, nil)
+	return nil
+}
+
 // SetPluginTools replaces the plugin-contributed MCP catalog on agentctl.
 func (c *Client) SetPluginTools(ctx context.Context, snapshot plugintools.Snapshot) error {
 	ctx, span := tracing.TraceHTTPRequest(ctx, "PUT", "/api/v1/mcp/plugin-tools", c.executionID)
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrCheckpointNotFound is returned when the workspace has no checkpoint
+	// with the requested ID.
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	// ErrCheckpointBusy is returned when a git operation holds the workspace.
+	ErrCheckpointBusy = errors.New("another git operation is in progress")
+	// ErrCheckpointUnavailable is returned when the workspace has no git
+	// repository to snapshot.
+	ErrCheckpointUnavailable = errors.New("workspace has no git repository")
+	// ErrCheckpointSameWorkspace is returned when a checkpoint is applied to
+	// the workspace it was taken in.
+	ErrCheckpointSameWorkspace = errors.New("checkpoint belongs to this workspace")
+)
+
+// WorkspaceCheckpoint is a snapshot of every repository in the workspace,
+// taken at the start of an agent turn. Its ID is the turn ID.
+type WorkspaceCheckpoint struct {
+	ID           string                 `json:"id"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Repositories []CheckpointRepository `json:"repositories"`
+}
+
+// CheckpointRepository is one repository's part of a workspace checkpoint.
+type CheckpointRepository struct {
+	Repo   string `json:"repo,omitempty"`
+	Commit string `json:"commit"`
+	Head   string `json:"head,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// CreateCheckpoint snapshots the workspace, including untracked files, under
+// id. Creating an existing checkpoint again is a no-op.
+func (c *Client) CreateCheckpoint(ctx context.Context, id string) (*WorkspaceCheckpoint, error) {
+	var result WorkspaceCheckpoint
+	if err := c.checkpointRequest(ctx, http.MethodPost, "/api/v1/checkpoints", "create checkpoint",
+		map[string]string{"id": id}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListCheckpoints returns the workspace's checkpoints, oldest first.
+func (c *Client) ListCheckpoints(ctx context.Context) ([]WorkspaceCheckpoint, error) {
+	var result struct {
+		Checkpoints []WorkspaceCheckpoint `json:"checkpoints"`
+	}
+	if err := c.checkpointRequest(ctx, http.MethodGet, "/api/v1/checkpoints", "list checkpoints", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Checkpoints, nil
+}
+
+// RestoreCheckpoint rewinds the workspace files to checkpoint id and deletes
+// the discard checkpoints.
+func (c *Client) RestoreCheckpoint(ctx context.Context, id string, discard []string) (*WorkspaceCheckpoint, error) {
+	var result WorkspaceCheckpoint
+	payload := struct {
+		ID      string   `json:"id"`
+		Discard []string `json:"discard,omitempty"`
+	}{ID: id, Discard: discard}
+	if err := c.checkpointRequest(ctx, http.MethodPost, "/api/v1/checkpoints/restore", "restore checkpoint",
+		payload, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ApplyCheckpoint seeds the workspace with checkpoint id, taken in another
+// worktree of the same repository. Applying it again is a no-op.
+func (c *Client) ApplyCheckpoint(ctx context.Context, id string) (*WorkspaceCheckpoint, error) {
+	var result WorkspaceCheckpoint
+	if err := c.checkpointRequest(ctx, http.MethodPost, "/api/v1/checkpoints/apply", "apply checkpoint",
+		map[string]string{"id": id}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) checkpointRequest(ctx context.Context, method, path, operation string, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := readResponseBody(resp)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrCheckpointNotFound, string(respBody))
+	case resp.StatusCode == http.StatusConflict:
+		return ErrCheckpointBusy
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return ErrCheckpointUnavailable
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return ErrCheckpointSameWorkspace
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("%s failed with status %d: %s", operation, resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", operation, err)
+	}
+	return nil
+}
@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
 	"github.com/kandev/kandev/internal/common/logger"
 	"github.com/kandev/kandev/internal/common/subproc"
 	mcpprofile "github.com/kandev/kandev/internal/mcp/profile"
@@ -86,6 +88,14 @@ type CreateInstanceRequest struct {
 	// workspace. Agentctl permits file operations through links only beneath
 	// these roots.
 	WorkspaceSourceRoots []string `json:"workspace_source_roots,omitempty"`
+	// ResourceLimits confines the agent and workspace processes to a
+	// per-session cgroup on Linux hosts. Set only for local and worktree
+	// executors; containers carry their own limits.
+	ResourceLimits *cgroup.Limits `json:"resource_limits,omitempty"`
+	// PermissionPolicy answers matching permission requests inside agentctl
+	// before they reach the UI: the workflow step's rules, then the agent
+	// profile's.
+	PermissionPolicy *permpolicy.Policy `json:"permission_policy,omitempty"`
 }
 
 // CreateInstanceResponse contains the result of creating a new agent instance.
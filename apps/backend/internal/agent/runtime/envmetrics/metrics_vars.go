@@ -1,5 +1,5 @@
-// Package envmetrics publishes the environment-override-precedence expvar
-// counter. It is a neutral package, following the convention in
+// Package envmetrics publishes the environment-override-precedence and
+// session resource-limit expvar counters. It is a neutral package, following the convention in
 // internal/workflow/signalmetrics, so the environment resolver stays a pure
 // function with no logging or metrics dependency of its own.
 package envmetrics
@@ -14,6 +14,11 @@ import (
 // origin. It is the AC-24 `environment_override_applied_total` counter.
 var environmentOverrideApplied = expvar.NewMap("environment_override_applied_total")
 
+// environmentResourceLimitHit counts agent sessions reaching a cgroup limit
+// on a local or worktree executor, labelled by limit ("memory", "pids", or
+// "unavailable" when the limits could not be applied at all).
+var environmentResourceLimitHit = expvar.NewMap("environment_resource_limit_hit_total")
+
 // metricLabel builds a "k1=v1;k2=v2" label string for an expvar map key,
 // matching the convention in internal/workflow/signalmetrics/metrics_vars.go
 // so a downstream Prometheus translation splits on the same delimiters.
@@ -37,3 +42,8 @@ func metricLabel(pairs ...string) string {
 func RecordOverrideApplied(winningOrigin, losingOrigin string) {
 	environmentOverrideApplied.Add(metricLabel("winning_origin", winningOrigin, "losing_origin", losingOrigin), 1)
 }
+
+// RecordResourceLimitHit counts one session reaching its resource limit.
+func RecordResourceLimitHit(limit string) {
+	environmentResourceLimitHit.Add(metricLabel("limit", limit), 1)
+}
@@ -58,3 +58,15 @@ func TestRecordOverrideApplied(t *testing.T) {
 		t.Errorf("counter delta = %d, want 1", after-before)
 	}
 }
+
+func TestRecordResourceLimitHit(t *testing.T) {
+	label := metricLabel("limit", "pids")
+
+	before := readCounter(t, environmentResourceLimitHit, label)
+	RecordResourceLimitHit("pids")
+	after := readCounter(t, environmentResourceLimitHit, label)
+
+	if after-before != 1 {
+		t.Errorf("counter delta = %d, want 1", after-before)
+	}
+}
@@ -82,11 +82,23 @@ func waitBrokerReachabilityProcess(
 	ctx context.Context,
 	client brokerAgentctlProcessClient,
 	process *agentctl.ProcessInfo,
+) ([]byte, error) {
+	return waitAgentctlProcess(ctx, client, process, 15*time.Second, "broker reachability process")
+}
+
+// waitAgentctlProcess polls process until it finishes and returns its output.
+// name labels the timeout error.
+func waitAgentctlProcess(
+	ctx context.Context,
+	client brokerAgentctlProcessClient,
+	process *agentctl.ProcessInfo,
+	timeout time.Duration,
+	name string,
 ) ([]byte, error) {
 	if process == nil {
 		return nil, errors.New("agentctl returned no process")
 	}
-	deadline := time.NewTimer(15 * time.Second)
+	deadline := time.NewTimer(timeout)
 	defer deadline.Stop()
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
@@ -98,7 +110,7 @@ func waitBrokerReachabilityProcess(
 		case <-ctx.Done():
 			return nil, context.Cause(ctx)
 		case <-deadline.C:
-			return nil, errors.New("broker reachability process timed out")
+			return nil, fmt.Errorf("%s timed out", name)
 		case <-ticker.C:
 			var err error
 			process, err = client.GetProcess(ctx, process.ID, true)
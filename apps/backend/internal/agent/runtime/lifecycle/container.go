@@ -17,6 +17,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/agents"
 	"github.com/kandev/kandev/internal/agent/docker"
 	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	commonconfig "github.com/kandev/kandev/internal/common/config"
 	"github.com/kandev/kandev/internal/common/constants"
 	"github.com/kandev/kandev/internal/common/logger"
@@ -57,6 +58,7 @@ type ContainerConfig struct {
 	McpMode                        string
 	McpProviders                   []string
 	McpProfile                     *mcpprofile.Context
+	PermissionPolicy               *permpolicy.Policy
 	PrepareScript                  string // Script to run inside container before agent starts (e.g., clone repo)
 	ImageTagOverride               string // If set, replaces the agent runtime's default image (e.g. profile.config.image_tag)
 	LocalClonePath                 string // Host path for file:// repository clone URLs; mounted read-only at the same path.
@@ -70,6 +72,17 @@ type ContainerConfig struct {
 	RemoteContributions      map[string]models.RemoteContribution
 	ContributionDestinations map[string]models.ContributionDestination
 	ComparisonTargets        map[string]models.ComparisonTarget
+
+	// ExtraEnv, ExtraMounts and ExtraPortBindings are appended to the
+	// generated container settings; the repository's devcontainer.json
+	// supplies them.
+	ExtraEnv          []string
+	ExtraMounts       []docker.MountConfig
+	ExtraPortBindings []docker.PortBindingConfig
+	// ExtraLabels are added to the container labels (warm pool bookkeeping).
+	ExtraLabels map[string]string
+	// ExtraHosts are added to the container's /etc/hosts (egress proxy).
+	ExtraHosts []string
 }
 
 func boolPtr(v bool) *bool {
@@ -118,6 +131,7 @@ func buildContainerCreateInstanceRequest(
 		RemoteContributions:      config.RemoteContributions,
 		ContributionDestinations: config.ContributionDestinations,
 		ComparisonTargets:        config.ComparisonTargets,
+		PermissionPolicy:         config.PermissionPolicy,
 	}
 }
 
@@ -139,6 +153,10 @@ type ContainerManager struct {
 	// binary. When it returns "" without error, no mock-agent mount is added
 	// (production case). Used by Docker E2E tests.
 	resolveMockAgentBinary func() (string, error)
+	// tuneContainer adjusts the final container config for engines that need
+	// more than the Docker defaults (Podman user namespaces, SELinux labels).
+	// Nil for plain Docker.
+	tuneContainer func(*docker.ContainerConfig)
 }
 
 // NewContainerManager creates a new ContainerManager. kandevHomeDir is the
@@ -185,34 +203,16 @@ type LaunchResult struct {
 // and the backend retrieves it via POST /auth/handshake.
 func (cm *ContainerManager) LaunchContainer(ctx context.Context, config ContainerConfig) (*LaunchResult, error) {
 	baseCtx := preparationContext(ctx)
-	launchCtx, launchCancel := withLaunchPhaseTimeout(baseCtx)
-	defer launchCancel()
-
-	// Generate bootstrap nonce (NOT the auth token — agentctl generates that)
-	nonce, err := generateBootstrapNonce()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate bootstrap nonce: %w", err)
-	}
-	config.BootstrapNonce = nonce
-
-	containerID, containerIP, controlHost, controlPort, err := cm.createAndStartContainer(launchCtx, config)
+	started, err := cm.startHealthyContainer(baseCtx, &config)
 	if err != nil {
 		return nil, err
 	}
-
-	// Create ControlClient (no auth token yet — handshake hasn't happened)
-	ctl := agentctl.NewControlClient(controlHost, controlPort, cm.logger)
-
-	// Wait for agentctl to be healthy
-	if err := cm.waitForHealth(baseCtx, ctl); err != nil {
-		cm.removeContainerBestEffort(containerID)
-		return nil, fmt.Errorf("agentctl health check failed: %w", err)
-	}
+	containerID, containerIP, ctl := started.containerID, started.containerIP, started.ctl
 
 	// Perform handshake: nonce → token
 	handshakeCtx, handshakeCancel := withLaunchPhaseTimeout(baseCtx)
 	defer handshakeCancel()
-	authToken, err := ctl.Handshake(handshakeCtx, nonce)
+	authToken, err := ctl.Handshake(handshakeCtx, config.BootstrapNonce)
 	if err != nil {
 		cm.removeContainerBestEffort(containerID)
 		return nil, fmt.Errorf("agentctl handshake failed: %w", err)
@@ -233,10 +233,58 @@ func (cm *ContainerManager) LaunchContainer(ctx context.Context, config Containe
 		ContainerID:    containerID,
 		Client:         client,
 		AuthToken:      authToken,
-		BootstrapNonce: nonce,
+		BootstrapNonce: config.BootstrapNonce,
 	}, nil
 }
 
+// LaunchIdleContainer creates and starts a container and waits for agentctl
+// to become healthy, but neither handshakes nor creates an agent instance.
+// The bootstrap nonce stays unconsumed so whoever claims the container later,
+// possibly after a backend restart, can still exchange it for the auth token.
+func (cm *ContainerManager) LaunchIdleContainer(ctx context.Context, config ContainerConfig) (*LaunchResult, error) {
+	started, err := cm.startHealthyContainer(preparationContext(ctx), &config)
+	if err != nil {
+		return nil, err
+	}
+	cm.logger.Info("idle docker container launched",
+		zap.String("container_id", started.containerID),
+		zap.String("instance_id", config.InstanceID))
+	return &LaunchResult{ContainerID: started.containerID, BootstrapNonce: config.BootstrapNonce}, nil
+}
+
+type startedContainer struct {
+	containerID string
+	containerIP string
+	ctl         *agentctl.ControlClient
+}
+
+// startHealthyContainer generates the bootstrap nonce (NOT the auth token —
+// agentctl generates that), creates and starts the container and waits for
+// agentctl to report healthy. The container is removed on failure.
+func (cm *ContainerManager) startHealthyContainer(baseCtx context.Context, config *ContainerConfig) (*startedContainer, error) {
+	launchCtx, launchCancel := withLaunchPhaseTimeout(baseCtx)
+	defer launchCancel()
+
+	nonce, err := generateBootstrapNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate bootstrap nonce: %w", err)
+	}
+	config.BootstrapNonce = nonce
+
+	containerID, containerIP, controlHost, controlPort, err := cm.createAndStartContainer(launchCtx, *config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create ControlClient (no auth token yet — handshake hasn't happened)
+	ctl := agentctl.NewControlClient(controlHost, controlPort, cm.logger)
+	if err := cm.waitForHealth(baseCtx, ctl); err != nil {
+		cm.removeContainerBestEffort(containerID)
+		return nil, fmt.Errorf("agentctl health check failed: %w", err)
+	}
+	return &startedContainer{containerID: containerID, containerIP: containerIP, ctl: ctl}, nil
+}
+
 // createAndStartContainer builds, creates, and starts a Docker container.
 func (cm *ContainerManager) createAndStartContainer(
 	ctx context.Context, config ContainerConfig,
@@ -504,50 +552,17 @@ func (cm *ContainerManager) buildContainerConfig(config ContainerConfig) (docker
 		env = append(env, "KANDEV_PREPARE_SCRIPT="+config.PrepareScript)
 	}
 
-	// We always launch agentctl as the container's main process and fan out the
-	// agent subprocess from there via the agentctl HTTP API. This frees user-built
-	// images from needing to bake an ENTRYPOINT or know which agent to run — they
-	// only need a runtime that supports the agent CLI (typically node + git).
-	//
-	// The agent's BuildCommand result intentionally stops being passed here;
-	// agentctl receives the agent command later via the CreateInstance API.
-	//
-	// Prepare runs in a subshell so its `set -e` (most prepare scripts opt in)
-	// can't kill the bootstrap before exec'ing agentctl. If prepare fails, we
-	// still bring agentctl up so the host can connect, surface the failure, and
-	// the user can debug from the Executor Settings popover.
-	//
-	prepareTimeout := formatCoreutilsTimeout(constants.SetupScriptTimeout)
-	//nolint:dupword // shell branches contain repeated `fi` tokens.
-	bootstrap := []string{
-		"sh", "-c",
-		`if [ -n "${KANDEV_GITHUB_CREDENTIAL_BROKER_URL:-}" ] && [ -n "${KANDEV_GITHUB_CREDENTIAL_LEASE:-}" ]; then
-` + brokerReachabilityScript + `
-  probe_rc=$?
-  if [ "$probe_rc" -ne 0 ]; then
-    exit "$probe_rc"
-  fi
-fi
-if [ -n "$KANDEV_PREPARE_SCRIPT" ]; then
-	  timeout -s TERM -k 1s ` + prepareTimeout + ` sh -c 'eval "$KANDEV_PREPARE_SCRIPT"'
-  prep_rc=$?
-  if [ "$prep_rc" -ne 0 ]; then
-    echo "[kandev-bootstrap] prepare script failed (exit $prep_rc); starting agentctl anyway so the host can connect and the user can debug via Executor Settings" >&2
-  fi
-fi
-exec /usr/local/bin/agentctl`,
-	}
-
 	containerCfg := docker.ContainerConfig{
 		Name:         containerName,
 		Image:        imageName,
-		Entrypoint:   bootstrap,
+		Entrypoint:   agentctlBootstrapCommand(),
 		Cmd:          nil,
-		Env:          env,
+		Env:          append(env, config.ExtraEnv...),
 		WorkingDir:   cm.expandMountSource(rt.WorkingDir, containerWorkspacePath),
-		Mounts:       mounts,
-		PortBindings: dockerAgentctlPortBindings(),
+		Mounts:       append(mounts, config.ExtraMounts...),
+		PortBindings: append(dockerAgentctlPortBindings(), config.ExtraPortBindings...),
 		NetworkMode:  cm.networkName,
+		ExtraHosts:   config.ExtraHosts,
 		Memory:       memoryBytes,
 		CPUQuota:     cpuQuota,
 		Labels: map[string]string{
@@ -564,6 +579,9 @@ exec /usr/local/bin/agentctl`,
 	if scope := os.Getenv(e2eDockerScopeEnv); scope != "" {
 		containerCfg.Labels[e2eDockerScopeLabel] = scope
 	}
+	for key, value := range config.ExtraLabels {
+		containerCfg.Labels[key] = value
+	}
 
 	if config.ExecutorProfileID != "" {
 		containerCfg.Labels["kandev.executor_profile_id"] = config.ExecutorProfileID
@@ -575,10 +593,51 @@ exec /usr/local/bin/agentctl`,
 	if config.ProfileInfo != nil && config.ProfileInfo.ProfileID != "" {
 		containerCfg.Labels["kandev.profile_id"] = config.ProfileInfo.ProfileID
 	}
+	if cm.tuneContainer != nil {
+		cm.tuneContainer(&containerCfg)
+	}
 
 	return containerCfg, nil
 }
 
+// agentctlBootstrapCommand is the entrypoint for containers that run
+// agentctl as their main process: an optional broker reachability probe, the
+// KANDEV_PREPARE_SCRIPT prepare step, then exec agentctl.
+func agentctlBootstrapCommand() []string {
+	// We always launch agentctl as the container's main process and fan out the
+	// agent subprocess from there via the agentctl HTTP API. This frees user-built
+	// images from needing to bake an ENTRYPOINT or know which agent to run — they
+	// only need a runtime that supports the agent CLI (typically node + git).
+	//
+	// The agent's BuildCommand result intentionally stops being passed here;
+	// agentctl receives the agent command later via the CreateInstance API.
+	//
+	// Prepare runs in a subshell so its `set -e` (most prepare scripts opt in)
+	// can't kill the bootstrap before exec'ing agentctl. If prepare fails, we
+	// still bring agentctl up so the host can connect, surface the failure, and
+	// the user can debug from the Executor Settings popover.
+	prepareTimeout := formatCoreutilsTimeout(constants.SetupScriptTimeout)
+	//nolint:dupword // shell branches contain repeated `fi` tokens.
+	return []string{
+		"sh", "-c",
+		`if [ -n "${KANDEV_GITHUB_CREDENTIAL_BROKER_URL:-}" ] && [ -n "${KANDEV_GITHUB_CREDENTIAL_LEASE:-}" ]; then
+` + brokerReachabilityScript + `
+  probe_rc=$?
+  if [ "$probe_rc" -ne 0 ]; then
+    exit "$probe_rc"
+  fi
+fi
+if [ -n "$KANDEV_PREPARE_SCRIPT" ]; then
+	  timeout -s TERM -k 1s ` + prepareTimeout + ` sh -c 'eval "$KANDEV_PREPARE_SCRIPT"'
+  prep_rc=$?
+  if [ "$prep_rc" -ne 0 ]; then
+    echo "[kandev-bootstrap] prepare script failed (exit $prep_rc); starting agentctl anyway so the host can connect and the user can debug via Executor Settings" >&2
+  fi
+fi
+exec /usr/local/bin/agentctl`,
+	}
+}
+
 // formatCoreutilsTimeout converts a Go duration to the single-unit format
 // accepted by GNU timeout. time.Duration.String can emit compound values such
 // as "10m0s", which GNU timeout rejects as an invalid interval.
@@ -659,6 +718,12 @@ func (cm *ContainerManager) expandMountSource(source, workspacePath string) stri
 
 // buildEnvVars builds environment variables for the container
 func (cm *ContainerManager) buildEnvVars(config ContainerConfig) ([]string, error) {
+	return containerEnvVars(config)
+}
+
+// containerEnvVars builds the KEY=VALUE environment agentctl expects inside
+// an agent container. Shared by the Docker and Kubernetes executors.
+func containerEnvVars(config ContainerConfig) ([]string, error) {
 	if err := validateAgentctlStartupConfig(config.AgentctlStartupConfig); err != nil {
 		return nil, fmt.Errorf("invalid agentctl startup configuration: %w", err)
 	}
@@ -9,7 +9,7 @@ func DefaultPrepareScript(executorType string) string {
 		return defaultLocalPrepareScript
 	case "worktree":
 		return defaultWorktreePrepareScript
-	case "local_docker", "remote_docker":
+	case "local_docker", "remote_docker", "kubernetes", "podman":
 		return defaultDockerPrepareScript
 	case "sprites":
 		return defaultSpritesPrepareScript
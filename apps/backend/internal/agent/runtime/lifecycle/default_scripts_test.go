@@ -192,7 +192,7 @@ func runIn(t *testing.T, dir string, cmd string, args ...string) []byte {
 // (KandevBranchCheckoutPostlude) so old stored profiles and the current
 // default can never disagree about how the feature branch is materialised.
 func TestDefaultPrepareScripts_NoInlineFeatureBranchCheckout(t *testing.T) {
-	executors := []string{"local_docker", "remote_docker", "sprites"}
+	executors := []string{"local_docker", "remote_docker", "kubernetes", "podman", "sprites"}
 	forbidden := []string{
 		`if [ -n {{worktree.branch}} ] && [ {{worktree.branch}} != {{repository.branch}} ]; then`,
 		`git checkout -B {{worktree.branch}} origin/{{worktree.branch}}`,
@@ -12,25 +12,39 @@ import (
 
 // DockerPreparer prepares a Docker-based execution environment.
 // Steps: validate Docker → pull/build image (if needed).
+// It also serves Podman, which runs the same containers.
 type DockerPreparer struct {
 	logger *logger.Logger
+	name   string
+	engine string // display name used in step labels
 }
 
 // NewDockerPreparer creates a new DockerPreparer.
 func NewDockerPreparer(log *logger.Logger) *DockerPreparer {
 	return &DockerPreparer{
 		logger: log.WithFields(zap.String("component", "docker-preparer")),
+		name:   "docker",
+		engine: "Docker",
 	}
 }
 
-func (p *DockerPreparer) Name() string { return "docker" }
+// NewPodmanPreparer creates a DockerPreparer for the Podman executor.
+func NewPodmanPreparer(log *logger.Logger) *DockerPreparer {
+	return &DockerPreparer{
+		logger: log.WithFields(zap.String("component", "podman-preparer")),
+		name:   "podman",
+		engine: "Podman",
+	}
+}
+
+func (p *DockerPreparer) Name() string { return p.name }
 
 func (p *DockerPreparer) Prepare(ctx context.Context, req *EnvPrepareRequest, onProgress PrepareProgressCallback) (*EnvPrepareResult, error) {
 	start := time.Now()
 	var steps []PrepareStep
 
 	// Step 1: Validate Docker availability
-	step := beginStep("Validate Docker")
+	step := beginStep("Validate " + p.engine)
 	reportProgress(onProgress, step, 0, 1)
 	completeStepSuccess(&step)
 	steps = append(steps, step)
@@ -55,3 +55,25 @@ func TestDockerPreparer_ReusesExistingTaskBranch(t *testing.T) {
 		t.Fatalf("WorktreeBranch = %q, want existing branch", result.WorktreeBranch)
 	}
 }
+
+func TestPodmanPreparer_NamesPodmanStep(t *testing.T) {
+	preparer := NewPodmanPreparer(newTestLogger())
+	if preparer.Name() != "podman" {
+		t.Fatalf("Name() = %q, want podman", preparer.Name())
+	}
+
+	result, err := preparer.Prepare(context.Background(), &EnvPrepareRequest{
+		TaskID:       "task-123",
+		TaskTitle:    "Rootless Fix",
+		ExecutorType: executor.NamePodman,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Prepare returned error: %v", err)
+	}
+	if len(result.Steps) != 1 || result.Steps[0].Name != "Validate Podman" {
+		t.Fatalf("Steps = %+v, want a single Validate Podman step", result.Steps)
+	}
+	if result.WorktreeBranch == "" {
+		t.Fatal("expected Podman preparer to choose a task branch")
+	}
+}
@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+// KubernetesPreparer prepares a Kubernetes-based execution environment.
+// The pod itself clones the repository, so there is nothing to do on the
+// host beyond resolving the task branch.
+type KubernetesPreparer struct {
+	logger *logger.Logger
+}
+
+// NewKubernetesPreparer creates a new KubernetesPreparer.
+func NewKubernetesPreparer(log *logger.Logger) *KubernetesPreparer {
+	return &KubernetesPreparer{
+		logger: log.WithFields(zap.String("component", "kubernetes-preparer")),
+	}
+}
+
+func (p *KubernetesPreparer) Name() string { return "kubernetes" }
+
+func (p *KubernetesPreparer) Prepare(ctx context.Context, req *EnvPrepareRequest, onProgress PrepareProgressCallback) (*EnvPrepareResult, error) {
+	start := time.Now()
+
+	step := beginStep("Validate Kubernetes")
+	reportProgress(onProgress, step, 0, 1)
+	completeStepSuccess(&step)
+	reportProgress(onProgress, step, 0, 1)
+
+	return &EnvPrepareResult{
+		Success:        true,
+		Steps:          []PrepareStep{step},
+		WorkspacePath:  req.WorkspacePath,
+		Duration:       time.Since(start),
+		WorktreeBranch: nonWorktreeTaskBranch(req),
+	}, nil
+}
@@ -238,6 +238,9 @@ type AgentStreamEventData struct {
 
 	// MCPAttachmentAttempt starts a new backend-owned MCP evidence timeline.
 	MCPAttachmentAttempt *streams.MCPAttachmentAttempt `json:"mcp_attachment_attempt,omitempty"`
+
+	// ResourceLimit reports the session reaching one of its cgroup limits.
+	ResourceLimit *streams.ResourceLimitNotice `json:"resource_limit,omitempty"`
 }
 
 // AgentStreamEventPayload is the payload for agent stream events (WebSocket streaming).
@@ -425,6 +428,9 @@ type PermissionRequestEventPayload struct {
 	Options       []PermissionOption     `json:"options"`
 	ActionType    string                 `json:"action_type"`
 	ActionDetails map[string]interface{} `json:"action_details,omitempty"`
+	// PermissionPolicy is set when agentctl's permission policy already
+	// answered the request; nothing is waiting for a human.
+	PermissionPolicy *streams.PermissionPolicyDecision `json:"permission_policy,omitempty"`
 }
 
 // ShellOutputEventPayload is the payload for shell output events.
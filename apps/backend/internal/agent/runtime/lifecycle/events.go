@@ -243,6 +243,7 @@ func (p *EventPublisher) PublishAgentStreamEvent(execution *AgentExecution, even
 		PlanContent:             event.PlanContent,
 		MCPAttachment:           event.MCPAttachment,
 		MCPAttachmentAttempt:    event.MCPAttachmentAttempt,
+		ResourceLimit:           event.ResourceLimit,
 	}
 
 	// Build agent event message payload
@@ -460,6 +461,8 @@ func (p *EventPublisher) PublishPermissionRequest(execution *AgentExecution, eve
 		Options:       options,
 		ActionType:    event.ActionType,
 		ActionDetails: event.ActionDetails,
+
+		PermissionPolicy: event.PermissionPolicy,
 	}
 
 	busEvent := bus.NewEvent(events.PermissionRequestReceived, "agent-manager", payload)
@@ -11,6 +11,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/agents"
 	"github.com/kandev/kandev/internal/agent/executor"
 	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentctl/server/process"
 	"github.com/kandev/kandev/internal/agentruntime"
 	commonconfig "github.com/kandev/kandev/internal/common/config"
@@ -202,6 +203,37 @@ const (
 	MetadataKeySpriteCreatedAt          = "sprite_created_at"
 	MetadataKeyLocalPort                = "local_port"
 
+	// MetadataKeyDevcontainer enables building Docker executor containers
+	// from the repository's devcontainer.json: "true"/"auto" searches the
+	// default locations, any other value is a path relative to the repo.
+	MetadataKeyDevcontainer = "devcontainer"
+
+	// Warm pool profile settings: how many idle instances to keep per
+	// repository and base branch, how long one may sit idle (a Go duration),
+	// and an optional comma-separated list of repositories to pool.
+	MetadataKeyWarmPoolSize         = "warm_pool_size"
+	MetadataKeyWarmPoolMaxIdle      = "warm_pool_max_idle"
+	MetadataKeyWarmPoolRepositories = "warm_pool_repositories"
+	// MetadataKeyWarmInstanceID records the warm pool instance an execution
+	// was claimed from; its session dir is named after it.
+	MetadataKeyWarmInstanceID = "warm_instance_id"
+
+	// Egress policy profile settings: the policy mode ("allowlist" or
+	// "model_api_only"), the extra domains an allowlist permits, and an
+	// optional replacement for the default model API domains. Domain lists
+	// are comma or newline separated.
+	MetadataKeyEgressPolicy          = "egress_policy"
+	MetadataKeyEgressAllowedDomains  = "egress_allowed_domains"
+	MetadataKeyEgressModelAPIDomains = "egress_model_api_domains"
+
+	// Session resource limit profile settings for local and worktree
+	// executors: memory in MiB, CPU in cores (fractions allowed) and the
+	// maximum number of processes. Enforced by agentctl through a cgroup v2
+	// group per session on Linux.
+	MetadataKeyMemoryLimitMB = "memory_limit_mb"
+	MetadataKeyCPULimitCores = "cpu_limit_cores"
+	MetadataKeyPidsLimit     = "pids_limit"
+
 	// MetadataKeyModelOverride holds a user-requested model that overrides the
 	// agent profile's configured model on the next launch. Set by SetSessionModel
 	// for passthrough sessions, which restart the PTY to apply the new --model.
@@ -233,6 +265,22 @@ const (
 	// host can use different shells; flows into req.Metadata via the
 	// standard executor-config merge in buildLaunchMetadata.
 	MetadataKeySSHShell = "ssh_shell"
+
+	// Kubernetes runtime metadata keys. The pod, namespace and claim names
+	// are recorded per session so a resume finds the same pod; the rest are
+	// per-profile pod settings (see KubernetesExecutor.buildPod).
+	MetadataKeyKubernetesPodName         = "kubernetes_pod_name"
+	MetadataKeyKubernetesNamespace       = "kubernetes_namespace"
+	MetadataKeyKubernetesPVCName         = "kubernetes_pvc_name"
+	MetadataKeyKubernetesWorkspaceVolume = "kubernetes_workspace_volume"
+	MetadataKeyKubernetesPVCSize         = "kubernetes_pvc_size"
+	MetadataKeyKubernetesStorageClass    = "kubernetes_storage_class"
+	MetadataKeyKubernetesCPURequest      = "kubernetes_cpu_request"
+	MetadataKeyKubernetesCPULimit        = "kubernetes_cpu_limit"
+	MetadataKeyKubernetesMemoryRequest   = "kubernetes_memory_request"
+	MetadataKeyKubernetesMemoryLimit     = "kubernetes_memory_limit"
+	MetadataKeyKubernetesNodeSelector    = "kubernetes_node_selector"
+	MetadataKeyKubernetesServiceAccount  = "kubernetes_service_account"
 )
 
 // persistentMetadataKeys lists metadata keys carried forward from a previous
@@ -263,6 +311,11 @@ var persistentMetadataKeys = map[string]bool{
 	MetadataKeySSHIdentityFile:       true,
 	MetadataKeySSHShell:              true,
 
+	// Kubernetes runtime
+	MetadataKeyKubernetesPodName:   true,
+	MetadataKeyKubernetesNamespace: true,
+	MetadataKeyKubernetesPVCName:   true,
+
 	// Executor type marker
 	MetadataKeyIsRemote: true,
 
@@ -279,6 +332,14 @@ var persistentMetadataKeys = map[string]bool{
 	"sprites_network_policy_rules":      true,
 	MetadataKeyExecutorProfileID:        true,
 	MetadataKeyImageTagOverride:         true,
+	MetadataKeyDevcontainer:             true,
+	MetadataKeyWarmInstanceID:           true,
+	MetadataKeyEgressPolicy:             true,
+	MetadataKeyEgressAllowedDomains:     true,
+	MetadataKeyEgressModelAPIDomains:    true,
+	MetadataKeyMemoryLimitMB:            true,
+	MetadataKeyCPULimitCores:            true,
+	MetadataKeyPidsLimit:                true,
 	MetadataKeyContainerID:              true,
 	MetadataKeyWorktreeBranch:           true,
 	MetadataKeyRemoteContributions:      true,
@@ -448,8 +509,9 @@ type ExecutorCreateRequest struct {
 	McpMode                  string       // MCP tool mode: "task" (default), "config", or "office"
 	McpProviders             []string     // Normalized provider capabilities attached to the task
 	McpProfile               *mcpprofile.Context
-	AuthToken                string // Previously handshaken agentctl token for reconnects
-	BootstrapNonce           string // Stored nonce for re-handshake after container restart
+	PermissionPolicy         *permpolicy.Policy // Step and profile rules agentctl evaluates before asking a human
+	AuthToken                string             // Previously handshaken agentctl token for reconnects
+	BootstrapNonce           string             // Stored nonce for re-handshake after container restart
 	AgentctlStartupConfig    commonconfig.AgentctlStartupConfig
 
 	// OnProgress is an optional callback for streaming preparation progress.
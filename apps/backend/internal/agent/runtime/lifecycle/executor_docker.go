@@ -19,6 +19,7 @@ import (
 	"github.com/kandev/kandev/internal/agentctl/server/process"
 	"github.com/kandev/kandev/internal/common/config"
 	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/egress"
 	"github.com/kandev/kandev/internal/scriptengine"
 )
 
@@ -77,6 +78,11 @@ type DockerExecutor struct {
 	kandevHomeDir string
 	logger        *logger.Logger
 
+	// engine carries what differs when the same executor drives a
+	// Docker-compatible engine other than Docker (see NewPodmanExecutor).
+	// The zero value is plain Docker.
+	engine containerEngine
+
 	// newClientFunc creates the Docker client. Defaults to docker.NewClient.
 	// Override in tests to simulate failures.
 	newClientFunc   func(config.DockerConfig, *logger.Logger) (*docker.Client, error)
@@ -90,6 +96,29 @@ type DockerExecutor struct {
 	docker       *docker.Client
 	containerMgr *ContainerManager
 	activity     *activity.Coordinator
+
+	// warmPool keeps pre-provisioned containers for profiles that set
+	// warm_pool_size. Nil in tests that build the executor by hand.
+	warmPool *warmPool
+
+	// egressProxy enforces profiles' egress policies; egressCredentials maps
+	// instance IDs to the proxy credential their container was given.
+	egressMu          sync.Mutex
+	egressProxy       *egress.Proxy
+	egressCredentials map[string]string
+}
+
+// containerEngine describes the Docker-compatible engine a DockerExecutor
+// talks to.
+type containerEngine struct {
+	name         executor.Name
+	executorType string // selects DefaultPrepareScript
+	networkName  string
+	// hostAlias is the name containers resolve to the host. Empty maps
+	// host.docker.internal to the engine's host gateway.
+	hostAlias string
+	// tuneContainer is handed to the ContainerManager; see its field doc.
+	tuneContainer func(*docker.ContainerConfig)
 }
 
 // NewDockerExecutor creates a new Docker runtime.
@@ -98,13 +127,15 @@ type DockerExecutor struct {
 // directory used to host per-container agent session dirs (the replacement
 // for host home bind mounts that were leaking host state into containers).
 func NewDockerExecutor(cfg config.DockerConfig, kandevHomeDir string, log *logger.Logger) *DockerExecutor {
-	return &DockerExecutor{
+	exec := &DockerExecutor{
 		cfg:             cfg,
 		kandevHomeDir:   kandevHomeDir,
 		logger:          log.WithFields(zap.String("runtime", "docker")),
 		newClientFunc:   docker.NewClient,
 		brokerPreflight: runBrokerReachabilityViaAgentctl,
 	}
+	exec.warmPool = newWarmPool(exec, exec.logger)
+	return exec
 }
 
 // ensureClient lazily creates the Docker client and ContainerManager.
@@ -120,12 +151,13 @@ func (r *DockerExecutor) ensureClient() (*docker.Client, *ContainerManager, erro
 
 	cli, err := r.newClientFunc(r.cfg, r.logger)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create docker client: %w", err)
+		return nil, nil, fmt.Errorf("failed to create %s client: %w", r.Name(), err)
 	}
 	cli.SetActivityCoordinator(r.activity)
 
 	r.docker = cli
-	r.containerMgr = NewContainerManager(cli, "", r.kandevHomeDir, r.logger)
+	r.containerMgr = NewContainerManager(cli, r.engine.networkName, r.kandevHomeDir, r.logger)
+	r.containerMgr.tuneContainer = r.engine.tuneContainer
 	r.initialized = true
 
 	return r.docker, r.containerMgr, nil
@@ -153,6 +185,9 @@ func (r *DockerExecutor) ContainerMgr() *ContainerManager {
 }
 
 func (r *DockerExecutor) Name() executor.Name {
+	if r.engine.name != "" {
+		return r.engine.name
+	}
 	return executor.NameDocker
 }
 
@@ -177,7 +212,7 @@ func (r *DockerExecutor) CreateInstance(ctx context.Context, req *ExecutorCreate
 	}
 	dockerClient, containerMgr, err := r.ensureClient()
 	if err != nil {
-		return nil, fmt.Errorf("docker unavailable: %w", err)
+		return nil, fmt.Errorf("%s unavailable: %w", r.Name(), err)
 	}
 
 	if req.OnProgress != nil {
@@ -187,15 +222,27 @@ func (r *DockerExecutor) CreateInstance(ctx context.Context, req *ExecutorCreate
 	if reconnected, ok := r.tryReconnect(baseCtx, dockerClient, req); ok {
 		return reconnected, nil
 	}
+	if warm := r.warmPool.claim(baseCtx, req); warm != nil {
+		return warm, nil
+	}
 
 	r.seedSessionDir(baseCtx, req)
 
+	devcontainerCfg, err := r.prepareDevcontainer(ctx, dockerClient, req)
+	if err != nil {
+		return nil, err
+	}
 	containerCfg, err := r.buildContainerLaunchConfig(req)
 	if err != nil {
 		return nil, fmt.Errorf("build container launch config: %w", err)
 	}
+	devcontainerCfg.apply(&containerCfg)
+	if err := r.applyEgress(req, &containerCfg); err != nil {
+		return nil, err
+	}
 	result, err := containerMgr.LaunchContainer(ctx, containerCfg)
 	if err != nil {
+		r.releaseEgress(req.InstanceID)
 		return nil, fmt.Errorf("failed to launch container: %w", err)
 	}
 
@@ -288,6 +335,7 @@ func (r *DockerExecutor) buildContainerLaunchConfig(req *ExecutorCreateRequest)
 		McpServers:                     req.McpServers,
 		McpProviders:                   req.McpProviders,
 		McpProfile:                     req.McpProfile,
+		PermissionPolicy:               req.PermissionPolicy,
 		PrepareScript:                  prepareScript,
 		ImageTagOverride:               getMetadataString(req.Metadata, MetadataKeyImageTagOverride),
 		LocalClonePath:                 localCloneMountPath(req.Metadata),
@@ -300,14 +348,6 @@ func (r *DockerExecutor) buildContainerLaunchConfig(req *ExecutorCreateRequest)
 }
 
 func (r *DockerExecutor) buildCreatedInstance(req *ExecutorCreateRequest, result *LaunchResult, containerIP string) *ExecutorInstance {
-	metadata := map[string]interface{}{
-		MetadataKeyIsRemote: true,
-	}
-	if worktreeID := getMetadataString(req.Metadata, MetadataKeyWorktreeID); worktreeID != "" {
-		metadata["worktree_id"] = worktreeID
-		metadata["worktree_path"] = dockerWorkspacePath
-		metadata["worktree_branch"] = getMetadataString(req.Metadata, MetadataKeyWorktreeBranch)
-	}
 	return &ExecutorInstance{
 		InstanceID:     req.InstanceID,
 		TaskID:         req.TaskID,
@@ -317,12 +357,24 @@ func (r *DockerExecutor) buildCreatedInstance(req *ExecutorCreateRequest, result
 		ContainerID:    result.ContainerID,
 		ContainerIP:    containerIP,
 		WorkspacePath:  dockerWorkspacePath,
-		Metadata:       metadata,
+		Metadata:       createdInstanceMetadata(req),
 		AuthToken:      result.AuthToken,
 		BootstrapNonce: result.BootstrapNonce,
 	}
 }
 
+func createdInstanceMetadata(req *ExecutorCreateRequest) map[string]interface{} {
+	metadata := map[string]interface{}{
+		MetadataKeyIsRemote: true,
+	}
+	if worktreeID := getMetadataString(req.Metadata, MetadataKeyWorktreeID); worktreeID != "" {
+		metadata["worktree_id"] = worktreeID
+		metadata["worktree_path"] = dockerWorkspacePath
+		metadata["worktree_branch"] = getMetadataString(req.Metadata, MetadataKeyWorktreeBranch)
+	}
+	return metadata
+}
+
 // reconnectToContainer attempts to reconnect to an existing Docker container
 // from a previous execution. Returns the reconnected instance if successful.
 func (r *DockerExecutor) reconnectToContainer(ctx context.Context, dockerClient *docker.Client, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
@@ -334,9 +386,13 @@ func (r *DockerExecutor) reconnectToContainer(ctx context.Context, dockerClient
 	if err != nil {
 		return nil, err
 	}
+	if err := r.restoreEgress(req, info.Env); err != nil {
+		return nil, err
+	}
 
 	conn, err := r.bringupAgentctl(ctx, dockerClient, info.ID, containerIP, req)
 	if err != nil {
+		r.releaseEgress(req.InstanceID)
 		return nil, err
 	}
 
@@ -586,6 +642,7 @@ func buildReconnectCreateInstanceRequest(req *ExecutorCreateRequest, instanceID
 		McpServers:               req.McpServers,
 		McpProviders:             req.McpProviders,
 		McpProfile:               req.McpProfile,
+		PermissionPolicy:         req.PermissionPolicy,
 		SessionID:                req.SessionID,
 		TaskID:                   req.TaskID,
 		DisableAskQuestion:       disableAskQuestion,
@@ -688,6 +745,11 @@ func (r *DockerExecutor) StopInstance(ctx context.Context, instance *ExecutorIns
 	teardownContainer := shouldTeardownDockerContainer(instance.StopReason)
 	if shouldRunExecutorCleanup(instance.StopReason) && r.kandevHomeDir != "" && instance.InstanceID != "" {
 		CleanupAgentSessionDir(InstanceSessionRoot(r.kandevHomeDir, instance.InstanceID), r.logger)
+		// A container claimed from the warm pool mounts the session dir it
+		// was provisioned with.
+		if warmID := getMetadataString(instance.Metadata, MetadataKeyWarmInstanceID); warmID != "" {
+			CleanupAgentSessionDir(InstanceSessionRoot(r.kandevHomeDir, warmID), r.logger)
+		}
 	}
 
 	if instance.ContainerID == "" {
@@ -706,10 +768,11 @@ func (r *DockerExecutor) StopInstance(ctx context.Context, instance *ExecutorIns
 			zap.String("stop_reason", instance.StopReason))
 		return nil
 	}
+	r.releaseEgress(instance.InstanceID)
 
 	dockerClient, containerMgr, err := r.ensureClient()
 	if err != nil {
-		return fmt.Errorf("docker unavailable: %w", err)
+		return fmt.Errorf("%s unavailable: %w", r.Name(), err)
 	}
 
 	cleanupCtx, cancel := dockerCleanupContext(ctx, instance.AgentStopFailed)
@@ -764,17 +827,21 @@ func dockerCleanupContext(ctx context.Context, agentStopFailed bool) (context.Co
 	return ctx, func() {}
 }
 
-func (r *DockerExecutor) RecoverInstances(_ context.Context) ([]*ExecutorInstance, error) {
-	// No-op: Docker client is initialized lazily on first use.
-	// If no session has used Docker yet, there's nothing to recover.
-	// Running containers from a previous backend process will be detected
-	// when the user navigates to that session (via EnsureWorkspaceExecutionForSession).
+func (r *DockerExecutor) RecoverInstances(ctx context.Context) ([]*ExecutorInstance, error) {
+	// Running agent containers from a previous backend process are not
+	// returned here: they are detected when the user navigates to that
+	// session (via EnsureWorkspaceExecutionForSession). Only idle warm pool
+	// containers are adopted back into the pool.
+	r.recoverWarmPool(ctx)
 	return nil, nil
 }
 
-// Close closes the Docker client if it was initialized.
-// Safe to call even if the client was never created.
+// Close stops the warm pool's background work and closes the Docker client
+// if it was initialized. Safe to call even if the client was never created.
 func (r *DockerExecutor) Close() error {
+	// Before taking mu: in-flight provisioning goes through ensureClient.
+	r.warmPool.close()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -806,14 +873,31 @@ func (r *DockerExecutor) IsAlwaysResumable() bool         { return true }
 // so simply updating DefaultPrepareScript wouldn't reach those users. The
 // postlude runs after the user's prepare script and is idempotent.
 func (r *DockerExecutor) resolvePrepareScript(req *ExecutorCreateRequest) (string, error) {
+	executorType := r.engine.executorType
+	if executorType == "" {
+		executorType = "local_docker"
+	}
+	return resolveContainerPrepareScript(req, executorType)
+}
+
+// resolveContainerPrepareScript resolves the prepare script for executors
+// that clone into dockerWorkspacePath and start agentctl from the container
+// bootstrap. executorType selects the DefaultPrepareScript used when the
+// profile has no setup script.
+func resolveContainerPrepareScript(req *ExecutorCreateRequest, executorType string) (string, error) {
 	script := getMetadataString(req.Metadata, MetadataKeySetupScript)
 	if script == "" {
-		script = DefaultPrepareScript("local_docker")
+		script = DefaultPrepareScript(executorType)
 	}
 	if script == "" {
 		return "", nil
 	}
-	script += KandevBranchCheckoutPostlude()
+	return resolveContainerScript(req, script+KandevBranchCheckoutPostlude())
+}
+
+// resolveContainerScript appends the task's contribution setup to script and
+// resolves its placeholders for a container workspace at dockerWorkspacePath.
+func resolveContainerScript(req *ExecutorCreateRequest, script string) (string, error) {
 	if binding, ok := req.RemoteContributions[""]; ok {
 		contributionScript, err := scriptengine.RemoteContributionSetupScript(&binding)
 		if err != nil {
@@ -846,7 +930,7 @@ func (r *DockerExecutor) resolvePrepareScript(req *ExecutorCreateRequest) (strin
 			getGitRemoteURL,
 			injectGitHubTokenIntoCloneURL,
 		)).
-		// Docker image has agents and agentctl pre-installed;
+		// Container images have agents and agentctl pre-installed;
 		// resolve these to empty so stored scripts with these placeholders don't break.
 		// The entrypoint handles agentctl startup, so install/start must be no-ops.
 		WithProvider(scriptengine.AgentInstallProvider(nil)).
@@ -0,0 +1,207 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agent/docker"
+	"github.com/kandev/kandev/internal/devcontainer"
+)
+
+// devcontainerImageAPI is the part of the Docker client the devcontainer
+// image build needs. Narrowed so tests can fake the daemon.
+type devcontainerImageAPI interface {
+	InspectImageEnv(ctx context.Context, ref string) ([]string, bool, error)
+	PullImage(ctx context.Context, ref string) error
+	BuildImage(ctx context.Context, dockerfile, tag string, buildArgs map[string]*string) (io.ReadCloser, error)
+	BuildImageFromDirectory(ctx context.Context, build docker.DirectoryBuild) error
+}
+
+// devcontainerLaunch is what a repository's devcontainer.json adds to a
+// container launch.
+type devcontainerLaunch struct {
+	Image        string
+	Env          []string
+	Mounts       []docker.MountConfig
+	PortBindings []docker.PortBindingConfig
+	// LifecycleScript runs postCreateCommand/postStartCommand; it is appended
+	// to the prepare script so it runs after the repository is cloned.
+	LifecycleScript string
+}
+
+// devcontainerSetting reads MetadataKeyDevcontainer. "true" or "auto" look
+// for devcontainer.json in the default locations and quietly fall back to
+// the agent image when there is none; any other non-empty value is a config
+// path relative to the repository, which must exist.
+func devcontainerSetting(metadata map[string]interface{}) (configPath string, auto, enabled bool) {
+	value := strings.TrimSpace(getMetadataString(metadata, MetadataKeyDevcontainer))
+	switch strings.ToLower(value) {
+	case "", "false", "off":
+		return "", false, false
+	case boolStringTrue, "auto":
+		return "", true, true
+	}
+	return value, false, true
+}
+
+// prepareDevcontainer builds (or reuses) the image described by the task
+// repository's devcontainer.json and resolves its env, mounts and forwarded
+// ports. Returns nil when devcontainer support is off for the profile or,
+// in auto mode, when the repository has no devcontainer.json.
+func (r *DockerExecutor) prepareDevcontainer(ctx context.Context, images devcontainerImageAPI, req *ExecutorCreateRequest) (*devcontainerLaunch, error) {
+	configPath, auto, enabled := devcontainerSetting(req.Metadata)
+	if !enabled {
+		return nil, nil
+	}
+	repoPath := getMetadataString(req.Metadata, MetadataKeyRepositoryPath)
+	if repoPath == "" {
+		if auto {
+			return nil, nil
+		}
+		return nil, errors.New("devcontainer: the task has no local repository to read devcontainer.json from")
+	}
+	cfg, err := devcontainer.Load(repoPath, configPath)
+	if auto && errors.Is(err, devcontainer.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("devcontainer: %w", err)
+	}
+
+	var extraInstall []string
+	if req.AgentConfig != nil {
+		extraInstall = append(extraInstall, req.AgentConfig.InstallScript())
+	}
+	plan, err := cfg.Plan(extraInstall...)
+	if err != nil {
+		return nil, fmt.Errorf("devcontainer: %w", err)
+	}
+
+	step := beginStep("Build devcontainer image")
+	step.Command = plan.Image
+	reportProgress(req.OnProgress, step, 0, 0)
+	imageEnv, cached, err := ensureDevcontainerImage(ctx, images, plan)
+	if err != nil {
+		completeStepError(&step, err.Error())
+		reportProgress(req.OnProgress, step, 0, 0)
+		return nil, fmt.Errorf("devcontainer: %w", err)
+	}
+	if cached {
+		step.Output = "Reusing cached image " + plan.Image
+	}
+	completeStepSuccess(&step)
+	reportProgress(req.OnProgress, step, 0, 0)
+	r.logger.Info("devcontainer image ready",
+		zap.String("instance_id", req.InstanceID),
+		zap.String("config", cfg.Path),
+		zap.String("image", plan.Image),
+		zap.Bool("cached", cached))
+
+	return resolveDevcontainerLaunch(cfg, plan.Image, repoPath, imageEnv)
+}
+
+// ensureDevcontainerImage makes plan.Image available locally and returns its
+// configured environment. cached reports that the final image already
+// existed, so nothing was pulled or built.
+func ensureDevcontainerImage(ctx context.Context, images devcontainerImageAPI, plan *devcontainer.ImagePlan) (env []string, cached bool, err error) {
+	if env, found, err := images.InspectImageEnv(ctx, plan.Image); err != nil || found {
+		return env, found, err
+	}
+	_, baseFound, err := images.InspectImageEnv(ctx, plan.BaseImage)
+	if err != nil {
+		return nil, false, err
+	}
+	if !baseFound {
+		if plan.Build != nil {
+			err = images.BuildImageFromDirectory(ctx, docker.DirectoryBuild{
+				ContextDir: plan.Build.ContextDir,
+				Dockerfile: plan.Build.Dockerfile,
+				Tag:        plan.Build.Tag,
+				BuildArgs:  plan.Build.Args,
+				Target:     plan.Build.Target,
+			})
+		} else {
+			err = images.PullImage(ctx, plan.Pull)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if plan.Layer != "" {
+		output, err := images.BuildImage(ctx, plan.Layer, plan.Image, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := docker.WaitForBuild(output); err != nil {
+			return nil, false, fmt.Errorf("install devcontainer features: %w", err)
+		}
+	}
+	env, found, err := images.InspectImageEnv(ctx, plan.Image)
+	if err == nil && !found {
+		err = fmt.Errorf("image %s missing after build", plan.Image)
+	}
+	return env, false, err
+}
+
+// resolveDevcontainerLaunch expands the config's variables against the host
+// repository and the image environment. Forwarded ports that collide with
+// the agentctl ports are skipped; the rest are published on loopback like
+// agentctl's own.
+func resolveDevcontainerLaunch(cfg *devcontainer.Config, image, repoPath string, imageEnv []string) (*devcontainerLaunch, error) {
+	vars := devcontainer.Variables{
+		LocalWorkspaceFolder:     repoPath,
+		ContainerWorkspaceFolder: dockerWorkspacePath,
+		ContainerEnv:             envListToMap(imageEnv),
+	}
+	mounts, err := cfg.ResolvedMounts(vars)
+	if err != nil {
+		return nil, fmt.Errorf("devcontainer: %w", err)
+	}
+	launch := &devcontainerLaunch{
+		Image:           image,
+		Env:             cfg.Env(vars),
+		LifecycleScript: cfg.LifecycleScript(dockerWorkspacePath),
+	}
+	for _, m := range mounts {
+		launch.Mounts = append(launch.Mounts, docker.MountConfig{
+			Type:     m.Type,
+			Source:   m.Source,
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+	for _, port := range cfg.LocalForwardPorts() {
+		if port == AgentCtlPort || (port >= dockerAgentctlInstancePortBase && port <= dockerAgentctlInstancePortMax) {
+			continue
+		}
+		launch.PortBindings = append(launch.PortBindings, newDockerPortBinding(port))
+	}
+	return launch, nil
+}
+
+func envListToMap(env []string) map[string]string {
+	out := make(map[string]string, len(env))
+	for _, kv := range env {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// apply folds the devcontainer settings into a container launch config.
+func (l *devcontainerLaunch) apply(cfg *ContainerConfig) {
+	if l == nil {
+		return
+	}
+	cfg.ImageTagOverride = l.Image
+	cfg.ExtraEnv = append(cfg.ExtraEnv, l.Env...)
+	cfg.ExtraMounts = append(cfg.ExtraMounts, l.Mounts...)
+	cfg.ExtraPortBindings = append(cfg.ExtraPortBindings, l.PortBindings...)
+	cfg.PrepareScript += l.LifecycleScript
+}
@@ -0,0 +1,153 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kandev/kandev/internal/agent/docker"
+)
+
+type fakeDevcontainerImages struct {
+	images map[string][]string // ref -> env
+	calls  []string
+}
+
+func (f *fakeDevcontainerImages) InspectImageEnv(_ context.Context, ref string) ([]string, bool, error) {
+	env, ok := f.images[ref]
+	return env, ok, nil
+}
+
+func (f *fakeDevcontainerImages) PullImage(_ context.Context, ref string) error {
+	f.calls = append(f.calls, "pull "+ref)
+	f.images[ref] = []string{"PATH=/usr/local/bin:/usr/bin"}
+	return nil
+}
+
+func (f *fakeDevcontainerImages) BuildImage(_ context.Context, dockerfile, tag string, _ map[string]*string) (io.ReadCloser, error) {
+	f.calls = append(f.calls, "layer "+tag)
+	f.images[tag] = []string{"PATH=/usr/local/bin:/usr/bin"}
+	return io.NopCloser(strings.NewReader(`{"stream":"done"}`)), nil
+}
+
+func (f *fakeDevcontainerImages) BuildImageFromDirectory(_ context.Context, build docker.DirectoryBuild) error {
+	f.calls = append(f.calls, "build "+build.Tag)
+	f.images[build.Tag] = nil
+	return nil
+}
+
+func writeRepoDevcontainer(t *testing.T, content string) string {
+	t.Helper()
+	repo := t.TempDir()
+	dir := filepath.Join(repo, ".devcontainer")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "devcontainer.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestPrepareDevcontainer_BuildsOnceAndResolvesLaunch(t *testing.T) {
+	repo := writeRepoDevcontainer(t, `{
+  // comments are allowed
+  "image": "mcr.microsoft.com/devcontainers/base:debian",
+  "features": {"ghcr.io/devcontainers/features/go:1": {}},
+  "containerEnv": {"PATH": "${containerEnv:PATH}:/workspace/bin"},
+  "mounts": ["source=${localWorkspaceFolder}/.cache,target=/root/.cache,type=bind"],
+  "forwardPorts": [3000, `+strconv.Itoa(AgentCtlPort)+`, "db:5432"],
+  "postStartCommand": "make dev-deps",
+}`)
+	images := &fakeDevcontainerImages{images: map[string][]string{}}
+	r := &DockerExecutor{logger: newTestDockerLogger()}
+	var steps []PrepareStep
+	req := &ExecutorCreateRequest{
+		InstanceID: "inst-1",
+		Metadata: map[string]interface{}{
+			MetadataKeyDevcontainer:   "true",
+			MetadataKeyRepositoryPath: repo,
+		},
+		OnProgress: func(step PrepareStep, _, _ int) { steps = append(steps, step) },
+	}
+
+	launch, err := r.prepareDevcontainer(context.Background(), images, req)
+	if err != nil {
+		t.Fatalf("prepareDevcontainer: %v", err)
+	}
+	if len(images.calls) != 2 || !strings.HasPrefix(images.calls[0], "pull mcr.microsoft.com") || images.calls[1] != "layer "+launch.Image {
+		t.Fatalf("calls = %v, want a pull then the feature layer", images.calls)
+	}
+	if len(launch.Env) != 1 || launch.Env[0] != "PATH=/usr/local/bin:/usr/bin:/workspace/bin" {
+		t.Errorf("Env = %v", launch.Env)
+	}
+	if len(launch.Mounts) != 1 || launch.Mounts[0].Source != repo+"/.cache" || launch.Mounts[0].Type != "bind" {
+		t.Errorf("Mounts = %+v", launch.Mounts)
+	}
+	if len(launch.PortBindings) != 1 || launch.PortBindings[0].ContainerPort != 3000 || launch.PortBindings[0].HostIP != "127.0.0.1" {
+		t.Errorf("PortBindings = %+v, want only 3000 on loopback", launch.PortBindings)
+	}
+	if !strings.Contains(launch.LifecycleScript, "make dev-deps") {
+		t.Errorf("LifecycleScript = %q", launch.LifecycleScript)
+	}
+	if last := steps[len(steps)-1]; last.Status != PrepareStepCompleted || last.Output != "" {
+		t.Errorf("last step = %+v, want a completed fresh build", last)
+	}
+
+	images.calls = nil
+	again, err := r.prepareDevcontainer(context.Background(), images, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images.calls) != 0 || again.Image != launch.Image {
+		t.Errorf("second launch calls = %v, want the cached image %s reused", images.calls, launch.Image)
+	}
+
+	cfg := ContainerConfig{PrepareScript: "git clone x /workspace"}
+	again.apply(&cfg)
+	if cfg.ImageTagOverride != launch.Image || !strings.HasPrefix(cfg.PrepareScript, "git clone x /workspace\n") {
+		t.Errorf("apply() = %+v", cfg)
+	}
+}
+
+func TestPrepareDevcontainer_Modes(t *testing.T) {
+	r := &DockerExecutor{logger: newTestDockerLogger()}
+	images := &fakeDevcontainerImages{images: map[string][]string{}}
+	empty := t.TempDir()
+
+	cases := []struct {
+		name    string
+		setting string
+		repo    string
+		wantErr bool
+	}{
+		{name: "off", setting: "", repo: empty},
+		{name: "auto_without_config", setting: "auto", repo: empty},
+		{name: "auto_without_repository", setting: "true", repo: ""},
+		{name: "explicit_path_missing", setting: ".devcontainer/ci/devcontainer.json", repo: empty, wantErr: true},
+		{name: "explicit_path_without_repository", setting: ".devcontainer/devcontainer.json", repo: "", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			launch, err := r.prepareDevcontainer(context.Background(), images, &ExecutorCreateRequest{
+				Metadata: map[string]interface{}{
+					MetadataKeyDevcontainer:   tc.setting,
+					MetadataKeyRepositoryPath: tc.repo,
+				},
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if launch != nil {
+				t.Errorf("launch = %+v, want nil", launch)
+			}
+		})
+	}
+	if len(images.calls) != 0 {
+		t.Errorf("calls = %v, want no image work", images.calls)
+	}
+}
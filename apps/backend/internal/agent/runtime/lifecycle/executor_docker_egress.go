@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kandev/kandev/internal/egress"
+	"github.com/kandev/kandev/internal/githubauth"
+)
+
+const (
+	// dockerHostAlias is mapped to the engine's host gateway so containers
+	// can reach the egress proxy the backend runs.
+	dockerHostAlias = "host.docker.internal"
+	egressNoProxy   = "localhost,127.0.0.1,::1"
+)
+
+var egressProxyEnvKeys = []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"}
+
+var errEgressProxyUnavailable = errors.New("the executor profile sets an egress policy but the egress proxy is not available")
+
+// SetEgressProxy enables egress policies. Without a proxy, profiles that set
+// egress_policy fail to launch instead of running unrestricted.
+func (r *DockerExecutor) SetEgressProxy(proxy *egress.Proxy) {
+	r.egressMu.Lock()
+	defer r.egressMu.Unlock()
+	r.egressProxy = proxy
+}
+
+// egressPolicy resolves the profile's egress policy for req. ok is false
+// when the profile sets none.
+func egressPolicy(req *ExecutorCreateRequest) (policy egress.Policy, ok bool, err error) {
+	mode, err := egress.ParseMode(getMetadataString(req.Metadata, MetadataKeyEgressPolicy))
+	if err != nil || mode == egress.ModeOff {
+		return egress.Policy{}, false, err
+	}
+	// The prepare script clones from the repository's git host and git
+	// fetches credentials from the broker, so both stay reachable.
+	required := []string{
+		getMetadataString(req.Metadata, "repository_clone_url"),
+		req.Env[githubauth.CredentialBrokerURLEnv],
+	}
+	return egress.NewPolicy(mode,
+		egress.ParseDomains(getMetadataString(req.Metadata, MetadataKeyEgressModelAPIDomains)),
+		egress.ParseDomains(getMetadataString(req.Metadata, MetadataKeyEgressAllowedDomains)),
+		required,
+	), true, nil
+}
+
+// applyEgress registers a new container for req with the egress proxy and
+// points it at the proxy through the standard proxy variables.
+func (r *DockerExecutor) applyEgress(req *ExecutorCreateRequest, cfg *ContainerConfig) error {
+	policy, ok, err := egressPolicy(req)
+	if err != nil || !ok {
+		return err
+	}
+	credential, err := egress.NewCredential()
+	if err != nil {
+		return err
+	}
+	port, err := r.registerEgress(req, credential, policy)
+	if err != nil {
+		return err
+	}
+	host := r.engine.hostAlias
+	if host == "" {
+		host = dockerHostAlias
+		cfg.ExtraHosts = append(cfg.ExtraHosts, dockerHostAlias+":host-gateway")
+	}
+	proxyURL := egress.ProxyURL(host, port, credential)
+	for _, key := range egressProxyEnvKeys {
+		cfg.ExtraEnv = append(cfg.ExtraEnv, key+"="+proxyURL)
+	}
+	cfg.ExtraEnv = append(cfg.ExtraEnv, "NO_PROXY="+egressNoProxy, "no_proxy="+egressNoProxy)
+	return nil
+}
+
+// restoreEgress re-registers a reconnected container's proxy credential,
+// read back from its environment, under the profile's current policy. The
+// container is not reused when its proxy setup no longer matches the
+// profile, i.e. the policy was turned on or off after it was created.
+func (r *DockerExecutor) restoreEgress(req *ExecutorCreateRequest, env []string) error {
+	credential := egress.CredentialFromProxyURL(envValue(env, "HTTPS_PROXY"))
+	policy, ok, err := egressPolicy(req)
+	switch {
+	case err != nil:
+		return err
+	case !ok && credential == "":
+		return nil
+	case !ok:
+		return errors.New("container was created under an egress policy the profile no longer sets")
+	case credential == "":
+		return errors.New("container was created before the profile set an egress policy")
+	}
+	_, err = r.registerEgress(req, credential, policy)
+	return err
+}
+
+func (r *DockerExecutor) registerEgress(req *ExecutorCreateRequest, credential string, policy egress.Policy) (int, error) {
+	r.egressMu.Lock()
+	defer r.egressMu.Unlock()
+	if r.egressProxy == nil {
+		return 0, errEgressProxyUnavailable
+	}
+	port, err := r.egressProxy.Register(credential, egress.Route{
+		TaskID:     req.TaskID,
+		SessionID:  req.SessionID,
+		InstanceID: req.InstanceID,
+		Policy:     policy,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("register with egress proxy: %w", err)
+	}
+	if r.egressCredentials == nil {
+		r.egressCredentials = make(map[string]string)
+	}
+	r.egressCredentials[req.InstanceID] = credential
+	return port, nil
+}
+
+// releaseEgress drops the proxy route of instanceID's container.
+func (r *DockerExecutor) releaseEgress(instanceID string) {
+	r.egressMu.Lock()
+	defer r.egressMu.Unlock()
+	credential, ok := r.egressCredentials[instanceID]
+	if !ok {
+		return
+	}
+	delete(r.egressCredentials, instanceID)
+	if r.egressProxy != nil {
+		r.egressProxy.Unregister(credential)
+	}
+}
@@ -0,0 +1,126 @@
+package lifecycle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/egress"
+)
+
+func egressRequest(policy string) *ExecutorCreateRequest {
+	return &ExecutorCreateRequest{
+		InstanceID: "inst-1",
+		TaskID:     "task-1",
+		SessionID:  "session-1",
+		Metadata: map[string]interface{}{
+			MetadataKeyEgressPolicy:         policy,
+			MetadataKeyEgressAllowedDomains: "registry.npmjs.org",
+			"repository_clone_url":          "https://git.example.com/acme/api.git",
+		},
+	}
+}
+
+func newEgressTestExecutor(t *testing.T) *DockerExecutor {
+	t.Helper()
+	log, _ := logger.NewLogger(logger.LoggingConfig{Level: "error", Format: "json"})
+	proxy := egress.NewProxy("127.0.0.1:0", log, nil)
+	t.Cleanup(func() { _ = proxy.Close() })
+	r := &DockerExecutor{}
+	r.SetEgressProxy(proxy)
+	return r
+}
+
+func TestEgressPolicy(t *testing.T) {
+	if _, ok, err := egressPolicy(egressRequest("")); ok || err != nil {
+		t.Fatalf("unset policy: ok=%v err=%v, want off", ok, err)
+	}
+	if _, _, err := egressPolicy(egressRequest("deny_everything")); err == nil {
+		t.Fatal("unknown policy: want error")
+	}
+	policy, ok, err := egressPolicy(egressRequest(string(egress.ModeModelAPIOnly)))
+	if !ok || err != nil {
+		t.Fatalf("model_api_only: ok=%v err=%v", ok, err)
+	}
+	if !policy.Allows("git.example.com") || !policy.Allows("api.anthropic.com") || policy.Allows("registry.npmjs.org") {
+		t.Fatalf("model_api_only allowed = %v", policy.Allowed())
+	}
+}
+
+func TestApplyEgress_InjectsProxy(t *testing.T) {
+	r := newEgressTestExecutor(t)
+	var cfg ContainerConfig
+	if err := r.applyEgress(egressRequest(string(egress.ModeAllowlist)), &cfg); err != nil {
+		t.Fatalf("applyEgress: %v", err)
+	}
+	if len(cfg.ExtraHosts) != 1 || cfg.ExtraHosts[0] != "host.docker.internal:host-gateway" {
+		t.Fatalf("ExtraHosts = %v", cfg.ExtraHosts)
+	}
+	proxyURL := envValue(cfg.ExtraEnv, "HTTPS_PROXY")
+	if !strings.HasPrefix(proxyURL, "http://kandev:") || !strings.Contains(proxyURL, "@host.docker.internal:") {
+		t.Fatalf("HTTPS_PROXY = %q", proxyURL)
+	}
+	if envValue(cfg.ExtraEnv, "http_proxy") != proxyURL || envValue(cfg.ExtraEnv, "NO_PROXY") != egressNoProxy {
+		t.Fatalf("ExtraEnv = %v", cfg.ExtraEnv)
+	}
+	if r.egressCredentials["inst-1"] != egress.CredentialFromProxyURL(proxyURL) {
+		t.Fatalf("credential not tracked for inst-1: %v", r.egressCredentials)
+	}
+
+	r.releaseEgress("inst-1")
+	if _, ok := r.egressCredentials["inst-1"]; ok {
+		t.Fatal("credential still tracked after release")
+	}
+}
+
+func TestApplyEgress_PodmanHostAlias(t *testing.T) {
+	r := newEgressTestExecutor(t)
+	r.engine.hostAlias = "host.containers.internal"
+	var cfg ContainerConfig
+	if err := r.applyEgress(egressRequest(string(egress.ModeAllowlist)), &cfg); err != nil {
+		t.Fatalf("applyEgress: %v", err)
+	}
+	if len(cfg.ExtraHosts) != 0 || !strings.Contains(envValue(cfg.ExtraEnv, "HTTPS_PROXY"), "@host.containers.internal:") {
+		t.Fatalf("ExtraHosts = %v, ExtraEnv = %v", cfg.ExtraHosts, cfg.ExtraEnv)
+	}
+}
+
+func TestApplyEgress_FailsClosedWithoutProxy(t *testing.T) {
+	r := &DockerExecutor{}
+	var cfg ContainerConfig
+	if err := r.applyEgress(egressRequest(string(egress.ModeAllowlist)), &cfg); !errors.Is(err, errEgressProxyUnavailable) {
+		t.Fatalf("applyEgress = %v, want errEgressProxyUnavailable", err)
+	}
+	if err := r.applyEgress(egressRequest(""), &cfg); err != nil || len(cfg.ExtraEnv) != 0 {
+		t.Fatalf("no policy: err=%v env=%v", err, cfg.ExtraEnv)
+	}
+}
+
+func TestRestoreEgress(t *testing.T) {
+	proxied := []string{"PATH=/usr/bin", "HTTPS_PROXY=" + egress.ProxyURL(dockerHostAlias, 39430, "cred-1")}
+	tests := []struct {
+		name           string
+		policy         string
+		env            []string
+		wantErr        bool
+		wantCredential string
+	}{
+		{name: "no policy, no proxy", policy: "", env: []string{"PATH=/usr/bin"}},
+		{name: "policy kept", policy: string(egress.ModeAllowlist), env: proxied, wantCredential: "cred-1"},
+		{name: "policy turned off", policy: "", env: proxied, wantErr: true},
+		{name: "policy turned on", policy: string(egress.ModeAllowlist), env: []string{"PATH=/usr/bin"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newEgressTestExecutor(t)
+			err := r.restoreEgress(egressRequest(tt.policy), tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restoreEgress = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := r.egressCredentials["inst-1"]; got != tt.wantCredential {
+				t.Fatalf("registered credential = %q, want %q", got, tt.wantCredential)
+			}
+		})
+	}
+}
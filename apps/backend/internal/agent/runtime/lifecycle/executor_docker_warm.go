@@ -0,0 +1,238 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agent/docker"
+	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	agentctltypes "github.com/kandev/kandev/internal/agentctl/types"
+)
+
+// Warm pool containers carry these labels. The pool label holds the executor
+// name so Docker and Podman pointed at the same engine never adopt each
+// other's containers.
+const (
+	warmPoolLabel        = "kandev.warm_pool"
+	warmPoolKeyLabel     = "kandev.warm_pool_key"
+	warmPoolExpiresLabel = "kandev.warm_pool_expires_at"
+)
+
+// warmKey keys a request by what its container is built from; see
+// warmRequestKey. Without a kandev home there is no session dir to seed.
+func (r *DockerExecutor) warmKey(req *ExecutorCreateRequest) string {
+	if r.kandevHomeDir == "" {
+		return ""
+	}
+	return warmRequestKey(r.Name(), req)
+}
+
+// provisionWarm launches an idle container for template: the repository is
+// cloned at the base branch and the setup script has run, but agentctl has
+// not been handshaken, so the bootstrap nonce in its environment stays valid
+// for the claim.
+func (r *DockerExecutor) provisionWarm(ctx context.Context, warm *warmInstance, template *ExecutorCreateRequest) error {
+	dockerClient, containerMgr, err := r.ensureClient()
+	if err != nil {
+		return fmt.Errorf("%s unavailable: %w", r.Name(), err)
+	}
+	req := *template
+	req.InstanceID = warm.id
+	r.seedSessionDir(ctx, &req)
+
+	devcontainerCfg, err := r.prepareDevcontainer(ctx, dockerClient, &req)
+	if err != nil {
+		return err
+	}
+	containerCfg, err := r.buildContainerLaunchConfig(&req)
+	if err != nil {
+		return fmt.Errorf("build container launch config: %w", err)
+	}
+	devcontainerCfg.apply(&containerCfg)
+	containerCfg.ExtraLabels = map[string]string{
+		warmPoolLabel:        string(r.Name()),
+		warmPoolKeyLabel:     warm.key,
+		warmPoolExpiresLabel: warm.expiresAt.UTC().Format(time.RFC3339),
+	}
+	result, err := containerMgr.LaunchIdleContainer(ctx, containerCfg)
+	if err != nil {
+		CleanupAgentSessionDir(InstanceSessionRoot(r.kandevHomeDir, warm.id), r.logger)
+		return fmt.Errorf("failed to launch warm container: %w", err)
+	}
+	warm.containerID = result.ContainerID
+	warm.bootstrapNonce = result.BootstrapNonce
+	return nil
+}
+
+// claimWarm hands a warm container to req. The container is renamed to the
+// name a fresh launch for req would get, which also marks it claimed for
+// recovery; agentctl is handshaken with the stored nonce and the per-task
+// steps skipped at provisioning (base refresh, feature branch checkout,
+// contribution remotes) run before the instance is returned.
+func (r *DockerExecutor) claimWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	step := beginStep("Claim warm container")
+	reportProgress(req.OnProgress, step, 0, 0)
+	instance, err := r.bindWarm(ctx, warm, req)
+	if err != nil {
+		step.Warning = "Warm container unavailable, launching a new one"
+		step.WarningDetail = err.Error()
+		completeStepSkipped(&step)
+	} else {
+		completeStepSuccess(&step)
+	}
+	reportProgress(req.OnProgress, step, 0, 0)
+	return instance, err
+}
+
+func (r *DockerExecutor) bindWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	if len(req.InstanceID) < 8 {
+		return nil, fmt.Errorf("instance ID too short: %s", req.InstanceID)
+	}
+	dockerClient, _, err := r.ensureClient()
+	if err != nil {
+		return nil, fmt.Errorf("%s unavailable: %w", r.Name(), err)
+	}
+	info, containerIP, err := r.ensureContainerRunning(ctx, dockerClient, warm.containerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := dockerClient.RenameContainer(ctx, info.ID, "kandev-agent-"+req.InstanceID[:8]); err != nil {
+		return nil, err
+	}
+
+	// Re-seed so the agent sees the host's current auth files, not the ones
+	// copied when the container was provisioned.
+	seedReq := *req
+	seedReq.InstanceID = warm.id
+	r.seedSessionDir(ctx, &seedReq)
+
+	claimReq := *req
+	claimReq.PreviousExecutionID = ""
+	claimReq.AuthToken = ""
+	claimReq.BootstrapNonce = warm.bootstrapNonce
+	conn, err := r.bringupAgentctl(ctx, dockerClient, info.ID, containerIP, &claimReq)
+	if err != nil {
+		return nil, err
+	}
+	client := agentctl.NewClient(conn.instanceHost, conn.instancePort, r.logger,
+		agentctl.WithExecutionID(req.InstanceID),
+		agentctl.WithSessionID(req.SessionID),
+		agentctl.WithAuthToken(conn.authToken))
+	if err := runWarmClaimScript(ctx, client, req); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	metadata := createdInstanceMetadata(req)
+	metadata[MetadataKeyContainerID] = info.ID
+	metadata[MetadataKeyWarmInstanceID] = warm.id
+	return &ExecutorInstance{
+		InstanceID:     req.InstanceID,
+		TaskID:         req.TaskID,
+		SessionID:      req.SessionID,
+		RuntimeName:    r.Name(),
+		Client:         client,
+		ContainerID:    info.ID,
+		ContainerIP:    containerIP,
+		WorkspacePath:  dockerWorkspacePath,
+		Metadata:       metadata,
+		AuthToken:      conn.authToken,
+		BootstrapNonce: warm.bootstrapNonce,
+	}, nil
+}
+
+func runWarmClaimScript(ctx context.Context, client brokerAgentctlProcessClient, req *ExecutorCreateRequest) error {
+	script, err := resolveContainerScript(req, "set -e\n"+warmBaseRefreshScript+KandevBranchCheckoutPostlude())
+	if err != nil {
+		return err
+	}
+	process, err := client.StartProcess(ctx, agentctl.StartProcessRequest{
+		SessionID: req.SessionID,
+		Kind:      agentctltypes.ProcessKindCustom,
+		Command:   script,
+	})
+	if err != nil {
+		return fmt.Errorf("start warm claim script: %w", err)
+	}
+	output, err := waitAgentctlProcess(ctx, client, process, warmClaimScriptTimeout, "warm claim script")
+	if err != nil {
+		return fmt.Errorf("warm claim script: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (r *DockerExecutor) discardWarm(ctx context.Context, warm *warmInstance) {
+	if warm.containerID != "" {
+		if dockerClient, _, err := r.ensureClient(); err == nil {
+			if err := dockerClient.RemoveContainer(ctx, warm.containerID, true); err != nil {
+				r.logger.Warn("failed to remove warm container",
+					zap.String("warm_instance_id", warm.id),
+					zap.String("container_id", warm.containerID),
+					zap.Error(err))
+			}
+		}
+	}
+	CleanupAgentSessionDir(InstanceSessionRoot(r.kandevHomeDir, warm.id), r.logger)
+}
+
+// recoverWarmPool adopts the idle warm containers a previous backend process
+// left running. Claimed containers were renamed and are skipped; stopped or
+// expired ones are removed.
+func (r *DockerExecutor) recoverWarmPool(ctx context.Context) {
+	if r.warmPool == nil || r.kandevHomeDir == "" {
+		return
+	}
+	dockerClient, _, err := r.ensureClient()
+	if err != nil {
+		r.logger.Debug("skipping warm pool recovery", zap.Error(err))
+		return
+	}
+	labels := map[string]string{warmPoolLabel: string(r.Name())}
+	if scope := os.Getenv(e2eDockerScopeEnv); scope != "" {
+		labels[e2eDockerScopeLabel] = scope
+	}
+	containers, err := dockerClient.ListContainers(ctx, labels)
+	if err != nil {
+		r.logger.Warn("failed to list warm pool containers", zap.Error(err))
+		return
+	}
+	for _, c := range containers {
+		warm := warmInstanceFromLabels(c)
+		if warm == nil || c.Name != "kandev-agent-"+warm.id[:8] {
+			continue
+		}
+		info, err := dockerClient.GetContainerInfo(ctx, c.ID)
+		if err == nil && info.State == containerStateRunning {
+			warm.bootstrapNonce = envValue(info.Env, "AGENTCTL_BOOTSTRAP_NONCE")
+		}
+		if warm.bootstrapNonce == "" {
+			r.discardWarm(ctx, warm)
+			continue
+		}
+		r.warmPool.adopt(warm)
+	}
+}
+
+func warmInstanceFromLabels(c docker.ContainerInfo) *warmInstance {
+	id := c.Labels["kandev.instance_id"]
+	key := c.Labels[warmPoolKeyLabel]
+	expiresAt, err := time.Parse(time.RFC3339, c.Labels[warmPoolExpiresLabel])
+	if len(id) < 8 || key == "" || err != nil {
+		return nil
+	}
+	return &warmInstance{id: id, key: key, expiresAt: expiresAt, containerID: c.ID}
+}
+
+func envValue(env []string, key string) string {
+	for _, entry := range env {
+		if value, ok := strings.CutPrefix(entry, key+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/kandev/kandev/internal/agent/agents"
+	"github.com/kandev/kandev/internal/agent/docker"
+	"github.com/kandev/kandev/internal/githubauth"
+)
+
+func warmKeyRequest(env map[string]string, setupScript string) *ExecutorCreateRequest {
+	return &ExecutorCreateRequest{
+		AgentConfig: agents.NewMockAgent(),
+		Env:         env,
+		Metadata: map[string]interface{}{
+			MetadataKeyExecutorProfileID: "prof-1",
+			"repository_clone_url":       "https://github.com/kdlbs/kandev.git",
+			MetadataKeyBaseBranch:        "main",
+			MetadataKeySetupScript:       setupScript,
+			MetadataKeyWorktreeBranch:    "feature/task",
+		},
+	}
+}
+
+func TestDockerWarmKey(t *testing.T) {
+	r := &DockerExecutor{kandevHomeDir: t.TempDir()}
+	base := r.warmKey(warmKeyRequest(map[string]string{"GH_TOKEN": "a"}, "make setup"))
+	if base == "" {
+		t.Fatal("expected a poolable request")
+	}
+
+	sameKeysOtherValues := warmKeyRequest(map[string]string{"GH_TOKEN": "b"}, "make setup")
+	sameKeysOtherValues.Metadata[MetadataKeyWorktreeBranch] = "feature/other"
+	if got := r.warmKey(sameKeysOtherValues); got != base {
+		t.Errorf("key changed with env values or task branch: %s != %s", got, base)
+	}
+	if got := r.warmKey(warmKeyRequest(map[string]string{"GH_TOKEN": "a"}, "make other")); got == base {
+		t.Error("key did not change with the setup script")
+	}
+	if got := r.warmKey(warmKeyRequest(map[string]string{"GH_TOKEN": "a", "EXTRA": "1"}, "make setup")); got == base {
+		t.Error("key did not change with the env key set")
+	}
+}
+
+func TestDockerWarmKey_NotPoolable(t *testing.T) {
+	r := &DockerExecutor{kandevHomeDir: t.TempDir()}
+	tests := map[string]*ExecutorCreateRequest{
+		"task branch in setup": warmKeyRequest(nil, "git checkout {{worktree.branch}}"),
+		"credential broker lease": warmKeyRequest(map[string]string{
+			githubauth.CredentialBrokerURLEnv: "http://broker",
+			githubauth.CredentialLeaseEnv:     "lease",
+		}, ""),
+		"no agent": {Metadata: map[string]interface{}{}},
+	}
+	egressReq := warmKeyRequest(nil, "make setup")
+	egressReq.Metadata[MetadataKeyEgressPolicy] = "allowlist"
+	tests["egress policy"] = egressReq
+	for name, req := range tests {
+		if got := r.warmKey(req); got != "" {
+			t.Errorf("%s: key = %q, want not poolable", name, got)
+		}
+	}
+	if got := (&DockerExecutor{}).warmKey(warmKeyRequest(nil, "")); got != "" {
+		t.Errorf("without kandev home: key = %q, want not poolable", got)
+	}
+}
+
+func TestWarmInstanceFromLabels(t *testing.T) {
+	c := docker.ContainerInfo{
+		ID: "ctr",
+		Labels: map[string]string{
+			"kandev.instance_id": "0123456789abcdef",
+			warmPoolKeyLabel:     "key",
+			warmPoolExpiresLabel: "2030-01-02T03:04:05Z",
+		},
+	}
+	warm := warmInstanceFromLabels(c)
+	if warm == nil || warm.id != "0123456789abcdef" || warm.key != "key" || warm.containerID != "ctr" {
+		t.Fatalf("warmInstanceFromLabels = %+v", warm)
+	}
+	delete(c.Labels, warmPoolExpiresLabel)
+	if warm := warmInstanceFromLabels(c); warm != nil {
+		t.Fatalf("missing expiry: got %+v, want nil", warm)
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	env := []string{"A=1", "AGENTCTL_BOOTSTRAP_NONCE=abc=def", "B="}
+	if got := envValue(env, "AGENTCTL_BOOTSTRAP_NONCE"); got != "abc=def" {
+		t.Errorf("envValue = %q, want abc=def", got)
+	}
+	if got := envValue(env, "MISSING"); got != "" {
+		t.Errorf("envValue(MISSING) = %q, want empty", got)
+	}
+}
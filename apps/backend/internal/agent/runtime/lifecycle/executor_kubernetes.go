@@ -0,0 +1,591 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kandev/kandev/internal/agent/executor"
+	"github.com/kandev/kandev/internal/agent/kubernetes"
+	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agentctl/server/process"
+	"github.com/kandev/kandev/internal/common/config"
+	"github.com/kandev/kandev/internal/common/constants"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+const (
+	kubernetesStopGracePeriodSeconds = int64(30)
+	kubernetesPodPollInterval        = time.Second
+	kubernetesReconnectHealthRetries = 20
+)
+
+// KubernetesExecutor implements ExecutorBackend by running agentctl as the
+// main process of one Pod per task session. The workspace lives on an
+// emptyDir or, when the profile asks for it, a per-task-environment PVC.
+// The API client is created lazily on first use.
+type KubernetesExecutor struct {
+	cfg    config.KubernetesConfig
+	logger *logger.Logger
+
+	// newClientFunc creates the API client. Tests wrap client-go's fake
+	// clientset.
+	newClientFunc func(config.KubernetesConfig, *logger.Logger) (*kubernetes.Client, error)
+	pollInterval  time.Duration
+
+	mu          sync.Mutex
+	initialized bool
+	client      *kubernetes.Client
+	// tunnels holds the port-forwards opened for each instance, keyed by
+	// instance ID, so StopInstance and Close can release them.
+	tunnels map[string][]io.Closer
+}
+
+// NewKubernetesExecutor creates a new Kubernetes runtime. The cluster is not
+// contacted until the first instance is created or recovered.
+func NewKubernetesExecutor(cfg config.KubernetesConfig, log *logger.Logger) *KubernetesExecutor {
+	return &KubernetesExecutor{
+		cfg:           cfg,
+		logger:        log.WithFields(zap.String("runtime", "kubernetes")),
+		newClientFunc: kubernetes.NewClient,
+		pollInterval:  kubernetesPodPollInterval,
+		tunnels:       make(map[string][]io.Closer),
+	}
+}
+
+// ensureClient lazily creates the API client, retrying on later calls if
+// the kubeconfig could not be loaded.
+func (r *KubernetesExecutor) ensureClient() (*kubernetes.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.initialized {
+		return r.client, nil
+	}
+	cli, err := r.newClientFunc(r.cfg, r.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	r.client = cli
+	r.initialized = true
+	return r.client, nil
+}
+
+func (r *KubernetesExecutor) Name() executor.Name {
+	return executor.NameKubernetes
+}
+
+func (r *KubernetesExecutor) HealthCheck(_ context.Context) error {
+	// No-op: cluster access is checked lazily when CreateInstance is called.
+	return nil
+}
+
+func (r *KubernetesExecutor) CreateInstance(ctx context.Context, req *ExecutorCreateRequest) (instance *ExecutorInstance, err error) {
+	baseCtx := preparationContext(ctx)
+	if err := validateAgentctlStartupConfig(req.AgentctlStartupConfig); err != nil {
+		return nil, fmt.Errorf("invalid agentctl startup configuration: %w", err)
+	}
+	if _, err := validateRemoteContributions(req.RemoteContributions); err != nil {
+		return nil, err
+	}
+	if _, err := validateContributionDestinations(req.ContributionDestinations); err != nil {
+		return nil, err
+	}
+	if _, err := validateComparisonTargets(req.ComparisonTargets); err != nil {
+		return nil, err
+	}
+	cli, err := r.ensureClient()
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes unavailable: %w", err)
+	}
+
+	if req.OnProgress != nil {
+		defer reportCreateInstanceProgress(req, &err)()
+	}
+
+	if req.PreviousExecutionID != "" {
+		reconnected, reconnectErr := r.reconnectToPod(baseCtx, cli, req)
+		if reconnectErr == nil {
+			return reconnected, nil
+		}
+		r.logger.Info("could not reconnect to previous pod, creating new one",
+			zap.String("previous_execution_id", req.PreviousExecutionID),
+			zap.Error(reconnectErr))
+	}
+
+	return r.launchPod(baseCtx, cli, req)
+}
+
+// launchPod creates the pod and its env secret (and PVC, when configured),
+// then brings agentctl up through the same nonce handshake the Docker
+// executor uses.
+func (r *KubernetesExecutor) launchPod(ctx context.Context, cli *kubernetes.Client, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	nonce, err := generateBootstrapNonce()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := r.buildPodPlan(req, cli.Namespace(), nonce)
+	if err != nil {
+		return nil, fmt.Errorf("build pod spec: %w", err)
+	}
+	core := cli.CoreV1()
+	if plan.pvc != nil {
+		_, err := core.PersistentVolumeClaims(plan.namespace).Create(ctx, plan.pvc, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create workspace volume claim: %w", err)
+		}
+	}
+	if _, err := core.Secrets(plan.namespace).Create(ctx, plan.secret, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create pod env secret: %w", err)
+	}
+	pod, err := core.Pods(plan.namespace).Create(ctx, plan.pod, metav1.CreateOptions{})
+	if err != nil {
+		r.deletePodResources(plan.namespace, "", plan.secret.Name, 0)
+		return nil, fmt.Errorf("failed to create pod: %w", err)
+	}
+	// Tie the secret's lifetime to the pod so it cannot outlive it.
+	if err := setKubernetesSecretOwner(ctx, cli, plan.namespace, plan.secret.Name, pod); err != nil {
+		r.logger.Warn("failed to set pod as env secret owner",
+			zap.String("pod", pod.Name), zap.Error(err))
+	}
+
+	instance, err := r.connectNewPod(ctx, cli, req, plan, nonce)
+	if err != nil {
+		r.closeTunnels(req.InstanceID)
+		r.deletePodResources(plan.namespace, plan.pod.Name, plan.secret.Name, 0)
+		return nil, err
+	}
+	r.logger.Info("kubernetes instance created",
+		zap.String("instance_id", req.InstanceID),
+		zap.String("namespace", plan.namespace),
+		zap.String("pod", plan.pod.Name),
+		zap.String("pod_ip", instance.ContainerIP))
+	return instance, nil
+}
+
+func (r *KubernetesExecutor) connectNewPod(
+	ctx context.Context,
+	cli *kubernetes.Client,
+	req *ExecutorCreateRequest,
+	plan *kubernetesPodPlan,
+	nonce string,
+) (*ExecutorInstance, error) {
+	launchCtx, cancel := withLaunchPhaseTimeout(ctx)
+	defer cancel()
+
+	pod, err := r.waitForPodRunning(launchCtx, cli, plan.namespace, plan.pod.Name)
+	if err != nil {
+		return nil, err
+	}
+	controlHost, controlPort, err := r.openEndpoint(req.InstanceID, cli, pod, AgentCtlPort)
+	if err != nil {
+		return nil, err
+	}
+	ctl := agentctl.NewControlClient(controlHost, controlPort, r.logger)
+	retries := int(constants.AgentLaunchTimeout / agentctlHealthRetryDelay)
+	if err := waitForAgentctlHealthWith(launchCtx, ctl, retries, agentctlHealthRetryDelay); err != nil {
+		return nil, fmt.Errorf("agentctl health check failed: %w", err)
+	}
+	authToken, err := ctl.Handshake(launchCtx, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("agentctl handshake failed: %w", err)
+	}
+	resp, err := ctl.CreateInstance(launchCtx, buildReconnectCreateInstanceRequest(req, req.InstanceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance in pod: %w", err)
+	}
+	instanceHost, instancePort, err := r.openEndpoint(req.InstanceID, cli, pod, resp.Port)
+	if err != nil {
+		return nil, err
+	}
+	client := agentctl.NewClient(instanceHost, instancePort, r.logger,
+		agentctl.WithExecutionID(req.InstanceID),
+		agentctl.WithSessionID(req.SessionID),
+		agentctl.WithAuthToken(authToken))
+
+	instance := r.buildInstance(req, pod, plan.pvcName, client)
+	instance.AuthToken = authToken
+	instance.BootstrapNonce = nonce
+	return instance, nil
+}
+
+func (r *KubernetesExecutor) buildInstance(req *ExecutorCreateRequest, pod *corev1.Pod, pvcName string, client *agentctl.Client) *ExecutorInstance {
+	metadata := map[string]interface{}{
+		MetadataKeyIsRemote:            true,
+		MetadataKeyContainerID:         pod.Name,
+		MetadataKeyKubernetesPodName:   pod.Name,
+		MetadataKeyKubernetesNamespace: pod.Namespace,
+	}
+	if pvcName != "" {
+		metadata[MetadataKeyKubernetesPVCName] = pvcName
+	}
+	if worktreeID := getMetadataString(req.Metadata, MetadataKeyWorktreeID); worktreeID != "" {
+		metadata["worktree_id"] = worktreeID
+		metadata["worktree_path"] = dockerWorkspacePath
+		metadata["worktree_branch"] = getMetadataString(req.Metadata, MetadataKeyWorktreeBranch)
+	}
+	return &ExecutorInstance{
+		InstanceID:    req.InstanceID,
+		TaskID:        req.TaskID,
+		SessionID:     req.SessionID,
+		RuntimeName:   r.Name(),
+		Client:        client,
+		ContainerID:   pod.Name,
+		ContainerIP:   pod.Status.PodIP,
+		WorkspacePath: dockerWorkspacePath,
+		Metadata:      metadata,
+	}
+}
+
+// reconnectToPod reattaches to the pod of a previous execution. Pods run
+// with restartPolicy Never, so a pod that is not Running cannot come back
+// and the caller falls through to a fresh launch. agentctl never restarts
+// inside a live pod, so the stored auth token stays valid.
+func (r *KubernetesExecutor) reconnectToPod(ctx context.Context, cli *kubernetes.Client, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	namespace := kubernetesNamespaceFromMetadata(req.Metadata, cli.Namespace())
+	name, err := resolveReconnectPodName(req)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := cli.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod %s/%s: %w", namespace, name, err)
+	}
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return nil, fmt.Errorf("pod %s/%s is %s, not %s", namespace, name, pod.Status.Phase, corev1.PodRunning)
+	}
+
+	controlHost, controlPort, err := r.openEndpoint(req.InstanceID, cli, pod, AgentCtlPort)
+	if err != nil {
+		return nil, err
+	}
+	ctl := agentctl.NewControlClient(controlHost, controlPort, r.logger,
+		agentctl.WithControlAuthToken(req.AuthToken))
+	if err := waitForAgentctlHealthWith(ctx, ctl, kubernetesReconnectHealthRetries, agentctlHealthRetryDelay); err != nil {
+		r.closeTunnels(req.InstanceID)
+		return nil, fmt.Errorf("agentctl not healthy in pod %s: %w", name, err)
+	}
+
+	instanceID := reconnectInstanceID(req, req.PreviousExecutionID)
+	instancePort, reusingProcess, err := r.findPodInstance(ctx, cli, ctl, pod, req, instanceID)
+	if err != nil {
+		r.closeTunnels(req.InstanceID)
+		return nil, fmt.Errorf("failed to find instance in pod %s: %w", name, err)
+	}
+	instanceHost, resolvedPort, err := r.openEndpoint(req.InstanceID, cli, pod, instancePort)
+	if err != nil {
+		r.closeTunnels(req.InstanceID)
+		return nil, err
+	}
+	client := agentctl.NewClient(instanceHost, resolvedPort, r.logger,
+		agentctl.WithExecutionID(req.InstanceID),
+		agentctl.WithSessionID(req.SessionID),
+		agentctl.WithAuthToken(req.AuthToken))
+
+	r.logger.Info("reconnected to existing pod",
+		zap.String("namespace", namespace),
+		zap.String("pod", name),
+		zap.Bool("reusing_process", reusingProcess))
+
+	instance := r.buildInstance(req, pod, getMetadataString(req.Metadata, MetadataKeyKubernetesPVCName), client)
+	instance.Metadata["reuse_existing_process"] = reusingProcess
+	return instance, nil
+}
+
+// findPodInstance returns the port of the agentctl instance to attach to,
+// creating one when the previous instance is gone, and whether its agent
+// subprocess is still running.
+func (r *KubernetesExecutor) findPodInstance(
+	ctx context.Context,
+	cli *kubernetes.Client,
+	ctl reconnectControlClient,
+	pod *corev1.Pod,
+	req *ExecutorCreateRequest,
+	instanceID string,
+) (int, bool, error) {
+	existing, err := ctl.GetInstance(ctx, instanceID)
+	if err != nil && isAgentctlAuthError(err) {
+		return 0, false, err
+	}
+	if err != nil || existing == nil || existing.Port <= 0 {
+		return createReconnectInstance(ctx, ctl, req, instanceID)
+	}
+	host, port, err := r.openEndpoint(req.InstanceID, cli, pod, existing.Port)
+	if err != nil {
+		return 0, false, err
+	}
+	client := agentctl.NewClient(host, port, r.logger, agentctl.WithAuthToken(req.AuthToken))
+	defer client.Close()
+	status, statusErr := client.GetStatus(ctx)
+	return existing.Port, statusErr == nil && status != nil && status.IsAgentRunning(), nil
+}
+
+func resolveReconnectPodName(req *ExecutorCreateRequest) (string, error) {
+	if req == nil {
+		return "", fmt.Errorf("executor create request is nil")
+	}
+	if name := strings.TrimSpace(getMetadataString(req.Metadata, MetadataKeyKubernetesPodName)); name != "" {
+		return name, nil
+	}
+	if name := strings.TrimSpace(getMetadataString(req.Metadata, MetadataKeyContainerID)); name != "" {
+		return name, nil
+	}
+	if req.PreviousExecutionID == "" {
+		return "", fmt.Errorf("no previous execution to reconnect to")
+	}
+	return kubernetesPodName(req.PreviousExecutionID), nil
+}
+
+// waitForPodRunning polls until the pod is Running, failing fast when it
+// terminates or a container is stuck on an error the kubelet will not fix
+// by itself (bad image, missing secret).
+func (r *KubernetesExecutor) waitForPodRunning(ctx context.Context, cli *kubernetes.Client, namespace, name string) (*corev1.Pod, error) {
+	for {
+		pod, err := cli.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod %s/%s: %w", namespace, name, err)
+		}
+		if pod.Status.Phase == corev1.PodRunning {
+			return pod, nil
+		}
+		if reason := podStartFailure(pod); reason != "" {
+			return nil, fmt.Errorf("pod %s/%s failed to start: %s", namespace, name, reason)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("pod %s/%s not running (phase %s): %w", namespace, name, pod.Status.Phase, ctx.Err())
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// kubernetesFatalWaitingReasons are container waiting reasons that mean
+// the pod will not start without a spec change.
+var kubernetesFatalWaitingReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+func podStartFailure(pod *corev1.Pod) string {
+	switch phase := strings.ToLower(string(pod.Status.Phase)); pod.Status.Phase {
+	case corev1.PodFailed, corev1.PodSucceeded:
+		if pod.Status.Message != "" {
+			return fmt.Sprintf("pod %s: %s", phase, pod.Status.Message)
+		}
+		return "pod " + phase
+	}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if w := cs.State.Waiting; w != nil && kubernetesFatalWaitingReasons[w.Reason] {
+			return fmt.Sprintf("container %s: %s: %s", cs.Name, w.Reason, w.Message)
+		}
+		if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+			return fmt.Sprintf("container %s exited with code %d: %s", cs.Name, t.ExitCode, t.Reason)
+		}
+	}
+	return ""
+}
+
+// openEndpoint makes port on pod reachable from the backend and records any
+// tunnel it opened against instanceID.
+func (r *KubernetesExecutor) openEndpoint(instanceID string, cli *kubernetes.Client, pod *corev1.Pod, port int) (string, int, error) {
+	host, localPort, closer, err := cli.PodEndpoint(pod, port)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to reach port %d on pod %s: %w", port, pod.Name, err)
+	}
+	r.mu.Lock()
+	r.tunnels[instanceID] = append(r.tunnels[instanceID], closer)
+	r.mu.Unlock()
+	return host, localPort, nil
+}
+
+func (r *KubernetesExecutor) closeTunnels(instanceID string) {
+	r.mu.Lock()
+	closers := r.tunnels[instanceID]
+	delete(r.tunnels, instanceID)
+	r.mu.Unlock()
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
+
+// deletePodResources removes a pod and its env secret on a detached
+// context, for rollback and cleanup paths that must not be cut short by the
+// caller's cancellation.
+func (r *KubernetesExecutor) deletePodResources(namespace, podName, secretName string, gracePeriodSeconds int64) {
+	cli, err := r.ensureClient()
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if podName != "" {
+		if err := deleteKubernetesPod(ctx, cli, namespace, podName, gracePeriodSeconds); err != nil {
+			r.logger.Warn("failed to delete pod", zap.String("pod", podName), zap.Error(err))
+		}
+	}
+	if secretName != "" {
+		if err := deleteKubernetesSecret(ctx, cli, namespace, secretName); err != nil {
+			r.logger.Warn("failed to delete pod env secret", zap.String("secret", secretName), zap.Error(err))
+		}
+	}
+}
+
+func (r *KubernetesExecutor) StopInstance(ctx context.Context, instance *ExecutorInstance, force bool) error {
+	if instance == nil {
+		return nil
+	}
+	r.closeTunnels(instance.InstanceID)
+
+	podName := instance.ContainerID
+	if podName == "" {
+		podName = getMetadataString(instance.Metadata, MetadataKeyKubernetesPodName)
+	}
+	if podName == "" {
+		return nil // No pod to stop
+	}
+
+	// Same policy as Docker: a plain stop after agentctl stopped cleanly keeps
+	// the pod (and its workspace) around for a fast resume.
+	if !force && !instance.AgentStopFailed && !shouldTeardownDockerContainer(instance.StopReason) {
+		r.logger.Info("preserving pod after agent stop",
+			zap.String("pod", podName),
+			zap.String("instance_id", instance.InstanceID),
+			zap.String("stop_reason", instance.StopReason))
+		return nil
+	}
+
+	cli, err := r.ensureClient()
+	if err != nil {
+		return fmt.Errorf("kubernetes unavailable: %w", err)
+	}
+	cleanupCtx, cancel := dockerCleanupContext(ctx, instance.AgentStopFailed)
+	defer cancel()
+
+	// Pods cannot be stopped and started again, so every teardown deletes
+	// the pod. Only a PVC-backed workspace survives stale cleanup.
+	namespace := kubernetesNamespaceFromMetadata(instance.Metadata, cli.Namespace())
+	grace := kubernetesStopGracePeriodSeconds
+	if force {
+		grace = 0
+	}
+	if err := deleteKubernetesPod(cleanupCtx, cli, namespace, podName, grace); err != nil {
+		return fmt.Errorf("failed to delete pod: %w", err)
+	}
+	if err := deleteKubernetesSecret(cleanupCtx, cli, namespace, kubernetesEnvSecretName(podName)); err != nil {
+		r.logger.Warn("failed to delete pod env secret", zap.String("pod", podName), zap.Error(err))
+	}
+	if pvc := getMetadataString(instance.Metadata, MetadataKeyKubernetesPVCName); pvc != "" && shouldRunExecutorCleanup(instance.StopReason) {
+		err := cli.CoreV1().PersistentVolumeClaims(namespace).Delete(cleanupCtx, pvc, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete workspace volume claim: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecoverInstances garbage-collects managed pods in the default namespace
+// that terminated while the backend was down. Running pods are left alone:
+// like Docker containers, they are reattached when their session resumes.
+func (r *KubernetesExecutor) RecoverInstances(ctx context.Context) ([]*ExecutorInstance, error) {
+	cli, err := r.ensureClient()
+	if err != nil {
+		r.logger.Warn("skipping kubernetes pod recovery", zap.Error(err))
+		return nil, nil
+	}
+	pods, err := cli.CoreV1().Pods(cli.Namespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(kubernetesManagedSelector()).String(),
+	})
+	if err != nil {
+		r.logger.Warn("failed to list kandev pods", zap.Error(err))
+		return nil, nil
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		r.logger.Info("removing terminated kandev pod",
+			zap.String("pod", pod.Name),
+			zap.String("phase", string(pod.Status.Phase)))
+		r.deletePodResources(pod.Namespace, pod.Name, kubernetesEnvSecretName(pod.Name), 0)
+	}
+	return nil, nil
+}
+
+// deleteKubernetesPod deletes the named pod, giving its containers
+// gracePeriodSeconds to exit (0 kills them immediately). A pod that is
+// already gone is not an error.
+func deleteKubernetesPod(ctx context.Context, cli *kubernetes.Client, namespace, name string, gracePeriodSeconds int64) error {
+	err := cli.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// deleteKubernetesSecret deletes the named secret. A secret that is already
+// gone is not an error.
+func deleteKubernetesSecret(ctx context.Context, cli *kubernetes.Client, namespace, name string) error {
+	err := cli.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// setKubernetesSecretOwner makes pod the owner of the named secret, so
+// Kubernetes deletes the secret along with the pod.
+func setKubernetesSecretOwner(ctx context.Context, cli *kubernetes.Client, namespace, name string, pod *corev1.Pod) error {
+	patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"ownerReferences": []metav1.OwnerReference{{
+		APIVersion: "v1", Kind: "Pod", Name: pod.Name, UID: pod.UID,
+	}}}})
+	if err != nil {
+		return err
+	}
+	_, err = cli.CoreV1().Secrets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// Close releases every open port-forward. Pods are left running.
+func (r *KubernetesExecutor) Close() error {
+	r.mu.Lock()
+	tunnels := r.tunnels
+	r.tunnels = make(map[string][]io.Closer)
+	r.mu.Unlock()
+	for _, closers := range tunnels {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+	}
+	return nil
+}
+
+// GetInteractiveRunner returns nil; passthrough mode is not supported in pods.
+func (r *KubernetesExecutor) GetInteractiveRunner() *process.InteractiveRunner {
+	return nil
+}
+
+func (r *KubernetesExecutor) RequiresCloneURL() bool          { return true }
+func (r *KubernetesExecutor) ShouldApplyPreferredShell() bool { return false }
+func (r *KubernetesExecutor) IsAlwaysResumable() bool         { return true }
+
+// resolvePrepareScript builds the prepare script the pod bootstrap runs to
+// clone the repository into the workspace volume.
+func (r *KubernetesExecutor) resolvePrepareScript(req *ExecutorCreateRequest) (string, error) {
+	return resolveContainerPrepareScript(req, "kubernetes")
+}
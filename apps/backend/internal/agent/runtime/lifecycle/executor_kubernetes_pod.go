@@ -0,0 +1,400 @@
+package lifecycle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kandev/kandev/internal/agent/executor"
+)
+
+const (
+	kubernetesPodNamePrefix     = "kandev-agent-"
+	kubernetesPVCNamePrefix     = "kandev-ws-"
+	kubernetesAgentContainer    = "agent"
+	kubernetesAgentctlInit      = "install-agentctl"
+	kubernetesWorkspaceVolume   = "workspace"
+	kubernetesAgentctlVolume    = "kandev-bin"
+	kubernetesAgentctlMountPath = "/kandev-bin"
+	kubernetesDefaultPVCSize    = "10Gi"
+
+	kubernetesWorkspaceEmptyDir = "emptyDir"
+	kubernetesWorkspacePVC      = "pvc"
+
+	kubernetesLabelManaged = "kandev.managed"
+	kubernetesLabelRuntime = "kandev.runtime"
+)
+
+// kubernetesPodSettings are the per-profile pod settings read from launch
+// metadata (see the MetadataKeyKubernetes* keys).
+type kubernetesPodSettings struct {
+	namespace       string
+	serviceAccount  string
+	workspaceVolume string
+	pvcSize         string
+	storageClass    string
+	nodeSelector    map[string]string
+	requests        map[string]string
+	limits          map[string]string
+}
+
+// kubernetesPodPlan is everything launchPod creates for one instance.
+type kubernetesPodPlan struct {
+	namespace string
+	pod       *corev1.Pod
+	secret    *corev1.Secret
+	pvc       *corev1.PersistentVolumeClaim // nil for emptyDir workspaces
+	pvcName   string
+}
+
+func kubernetesPodName(instanceID string) string {
+	return kubernetesPodNamePrefix + kubernetesDNSLabel(instanceID, 63-len(kubernetesPodNamePrefix))
+}
+
+func kubernetesEnvSecretName(podName string) string {
+	return podName + "-env"
+}
+
+// kubernetesPVCName names the workspace claim after the task environment,
+// so every session of a task mounts the same workspace.
+func kubernetesPVCName(req *ExecutorCreateRequest) string {
+	id := req.TaskEnvironmentID
+	if id == "" {
+		id = req.TaskID
+	}
+	return kubernetesPVCNamePrefix + kubernetesDNSLabel(id, 63-len(kubernetesPVCNamePrefix))
+}
+
+func kubernetesManagedSelector() map[string]string {
+	return map[string]string{
+		kubernetesLabelManaged: boolStringTrue,
+		kubernetesLabelRuntime: string(executor.NameKubernetes),
+	}
+}
+
+func kubernetesNamespaceFromMetadata(metadata map[string]interface{}, fallback string) string {
+	if ns := strings.TrimSpace(getMetadataString(metadata, MetadataKeyKubernetesNamespace)); ns != "" {
+		return ns
+	}
+	return fallback
+}
+
+// kubernetesDNSLabel lowercases s and replaces anything outside [a-z0-9-]
+// so it can be used in an object name.
+func kubernetesDNSLabel(s string, maxLen int) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	out := b.String()
+	if len(out) > maxLen {
+		out = out[:maxLen]
+	}
+	return strings.Trim(out, "-")
+}
+
+// kubernetesLabelValue makes s a valid label value: at most 63 characters
+// of [A-Za-z0-9._-], starting and ending alphanumeric.
+func kubernetesLabelValue(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	out := b.String()
+	if len(out) > 63 {
+		out = out[:63]
+	}
+	return strings.Trim(out, "-_.")
+}
+
+func kubernetesPodSettingsFromMetadata(metadata map[string]interface{}, defaultNamespace string) (kubernetesPodSettings, error) {
+	s := kubernetesPodSettings{
+		namespace:       kubernetesNamespaceFromMetadata(metadata, defaultNamespace),
+		serviceAccount:  strings.TrimSpace(getMetadataString(metadata, MetadataKeyKubernetesServiceAccount)),
+		workspaceVolume: strings.TrimSpace(getMetadataString(metadata, MetadataKeyKubernetesWorkspaceVolume)),
+		pvcSize:         strings.TrimSpace(getMetadataString(metadata, MetadataKeyKubernetesPVCSize)),
+		storageClass:    strings.TrimSpace(getMetadataString(metadata, MetadataKeyKubernetesStorageClass)),
+		requests:        map[string]string{},
+		limits:          map[string]string{},
+	}
+	switch s.workspaceVolume {
+	case "":
+		s.workspaceVolume = kubernetesWorkspaceEmptyDir
+	case kubernetesWorkspaceEmptyDir, kubernetesWorkspacePVC:
+	default:
+		return s, fmt.Errorf("%s must be %q or %q, got %q",
+			MetadataKeyKubernetesWorkspaceVolume, kubernetesWorkspaceEmptyDir, kubernetesWorkspacePVC, s.workspaceVolume)
+	}
+	if s.pvcSize == "" {
+		s.pvcSize = kubernetesDefaultPVCSize
+	}
+	for key, dst := range map[string]map[string]string{
+		MetadataKeyKubernetesCPURequest:    s.requests,
+		MetadataKeyKubernetesCPULimit:      s.limits,
+		MetadataKeyKubernetesMemoryRequest: s.requests,
+		MetadataKeyKubernetesMemoryLimit:   s.limits,
+	} {
+		if v := strings.TrimSpace(getMetadataString(metadata, key)); v != "" {
+			resource := "cpu"
+			if strings.Contains(key, "memory") {
+				resource = "memory"
+			}
+			dst[resource] = v
+		}
+	}
+	selector, err := parseKubernetesNodeSelector(getMetadataString(metadata, MetadataKeyKubernetesNodeSelector))
+	if err != nil {
+		return s, err
+	}
+	s.nodeSelector = selector
+	return s, nil
+}
+
+// parseKubernetesNodeSelector parses "key=value,key=value".
+func parseKubernetesNodeSelector(raw string) (map[string]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	out := make(map[string]string)
+	for _, term := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(term), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s: expected key=value, got %q", MetadataKeyKubernetesNodeSelector, term)
+		}
+		out[key] = strings.TrimSpace(value)
+	}
+	return out, nil
+}
+
+// buildPodPlan renders the pod, env secret and optional PVC for req.
+func (r *KubernetesExecutor) buildPodPlan(req *ExecutorCreateRequest, defaultNamespace, nonce string) (*kubernetesPodPlan, error) {
+	if req.AgentConfig == nil || req.AgentConfig.Runtime() == nil {
+		return nil, fmt.Errorf("agent runtime configuration is required")
+	}
+	settings, err := kubernetesPodSettingsFromMetadata(req.Metadata, defaultNamespace)
+	if err != nil {
+		return nil, err
+	}
+	env, err := r.buildPodEnv(req, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	podName := kubernetesPodName(req.InstanceID)
+	labels := kubernetesManagedSelector()
+	for key, value := range map[string]string{
+		"kandev.instance_id":         req.InstanceID,
+		"kandev.task_id":             req.TaskID,
+		"kandev.session_id":          req.SessionID,
+		"kandev.task_environment_id": req.TaskEnvironmentID,
+		"kandev.executor_profile_id": getMetadataString(req.Metadata, MetadataKeyExecutorProfileID),
+	} {
+		if v := kubernetesLabelValue(value); v != "" {
+			labels[key] = v
+		}
+	}
+	plan := &kubernetesPodPlan{
+		namespace: settings.namespace,
+		secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: kubernetesEnvSecretName(podName), Namespace: settings.namespace, Labels: labels},
+			Type:       corev1.SecretTypeOpaque,
+			StringData: env,
+		},
+	}
+
+	workspace := corev1.Volume{
+		Name:         kubernetesWorkspaceVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}
+	if settings.workspaceVolume == kubernetesWorkspacePVC {
+		plan.pvcName = kubernetesPVCName(req)
+		plan.pvc, err = buildKubernetesPVC(plan.pvcName, req.TaskEnvironmentID, settings)
+		if err != nil {
+			return nil, err
+		}
+		workspace.VolumeSource = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: plan.pvcName},
+		}
+	}
+	agent, err := r.buildAgentContainer(req, podName, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.pod = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        podName,
+			Namespace:   settings.namespace,
+			Labels:      labels,
+			Annotations: map[string]string{"kandev.task_title": req.TaskTitle},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: settings.serviceAccount,
+			NodeSelector:       settings.nodeSelector,
+			RestartPolicy:      corev1.RestartPolicyNever,
+			Containers:         []corev1.Container{agent},
+			Volumes:            []corev1.Volume{workspace},
+		},
+	}
+	r.addAgentctlInitContainer(plan.pod)
+	return plan, nil
+}
+
+func buildKubernetesPVC(name, taskEnvironmentID string, settings kubernetesPodSettings) (*corev1.PersistentVolumeClaim, error) {
+	storage, err := kubernetesResourceList(map[string]string{string(corev1.ResourceStorage): settings.pvcSize})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", MetadataKeyKubernetesPVCSize, err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: settings.namespace, Labels: kubernetesManagedSelector()},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources:   corev1.VolumeResourceRequirements{Requests: storage},
+		},
+	}
+	if envID := kubernetesLabelValue(taskEnvironmentID); envID != "" {
+		pvc.Labels["kandev.task_environment_id"] = envID
+	}
+	if settings.storageClass != "" {
+		storageClass := settings.storageClass
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	return pvc, nil
+}
+
+// kubernetesResourceList parses resource quantities ("500m", "2Gi").
+func kubernetesResourceList(values map[string]string) (corev1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	list := make(corev1.ResourceList, len(values))
+	for name, value := range values {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quantity %q: %w", name, value, err)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+func (r *KubernetesExecutor) buildAgentContainer(req *ExecutorCreateRequest, podName string, settings kubernetesPodSettings) (corev1.Container, error) {
+	rt := req.AgentConfig.Runtime()
+	image := rt.Image
+	if rt.Tag != "" {
+		image = fmt.Sprintf("%s:%s", rt.Image, rt.Tag)
+	}
+	if override := getMetadataString(req.Metadata, MetadataKeyImageTagOverride); override != "" {
+		image = override
+	}
+
+	// Agent resource limits are the default; profile values win.
+	limits := make(map[string]string, 2)
+	if rt.ResourceLimits.CPUCores > 0 {
+		limits["cpu"] = strconv.FormatFloat(rt.ResourceLimits.CPUCores, 'f', -1, 64)
+	}
+	if rt.ResourceLimits.MemoryMB > 0 {
+		limits["memory"] = fmt.Sprintf("%dMi", rt.ResourceLimits.MemoryMB)
+	}
+	for k, v := range settings.limits {
+		limits[k] = v
+	}
+	limitList, err := kubernetesResourceList(limits)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+	requestList, err := kubernetesResourceList(settings.requests)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+
+	workingDir := strings.ReplaceAll(rt.WorkingDir, "{workspace}", dockerWorkspacePath)
+	if workingDir == "" {
+		workingDir = dockerWorkspacePath
+	}
+	return corev1.Container{
+		Name:       kubernetesAgentContainer,
+		Image:      image,
+		Command:    agentctlBootstrapCommand(),
+		WorkingDir: workingDir,
+		Ports:      []corev1.ContainerPort{{Name: "agentctl", ContainerPort: AgentCtlPort}},
+		EnvFrom: []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: kubernetesEnvSecretName(podName)},
+			},
+		}},
+		Resources:    corev1.ResourceRequirements{Requests: requestList, Limits: limitList},
+		VolumeMounts: []corev1.VolumeMount{{Name: kubernetesWorkspaceVolume, MountPath: dockerWorkspacePath}},
+	}, nil
+}
+
+// addAgentctlInitContainer copies agentctl out of the configured agentctl
+// image into a shared emptyDir and mounts it over /usr/local/bin/agentctl,
+// so agent images do not have to ship it. Without an agentctl image the
+// agent image must already contain it.
+func (r *KubernetesExecutor) addAgentctlInitContainer(pod *corev1.Pod) {
+	if r.cfg.AgentctlImage == "" {
+		return
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name:         kubernetesAgentctlVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:         kubernetesAgentctlInit,
+		Image:        r.cfg.AgentctlImage,
+		Command:      []string{"cp", remoteAgentctlExecutablePath, kubernetesAgentctlMountPath + "/agentctl"},
+		VolumeMounts: []corev1.VolumeMount{{Name: kubernetesAgentctlVolume, MountPath: kubernetesAgentctlMountPath}},
+	})
+	agent := &pod.Spec.Containers[0]
+	agent.VolumeMounts = append(agent.VolumeMounts, corev1.VolumeMount{
+		Name:      kubernetesAgentctlVolume,
+		MountPath: remoteAgentctlExecutablePath,
+		SubPath:   "agentctl",
+		ReadOnly:  true,
+	})
+}
+
+// buildPodEnv renders the container environment the Docker executor would
+// pass, for the pod's env secret.
+func (r *KubernetesExecutor) buildPodEnv(req *ExecutorCreateRequest, nonce string) (map[string]string, error) {
+	prepareScript, err := r.resolvePrepareScript(req)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := containerEnvVars(ContainerConfig{
+		AgentConfig:           req.AgentConfig,
+		TaskID:                req.TaskID,
+		InstanceID:            req.InstanceID,
+		Credentials:           req.Env,
+		BootstrapNonce:        nonce,
+		AgentctlStartupConfig: req.AgentctlStartupConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if prepareScript != "" {
+		entries = append(entries, "KANDEV_PREPARE_SCRIPT="+prepareScript)
+	}
+	env := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
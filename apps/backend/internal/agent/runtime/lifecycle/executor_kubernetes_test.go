@@ -0,0 +1,348 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kandev/kandev/internal/agent/kubernetes"
+	"github.com/kandev/kandev/internal/common/config"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+// newTestKubernetesExecutor talks to cs in direct connect mode, so pod
+// endpoints resolve to the pod IP without a port-forward.
+func newTestKubernetesExecutor(t *testing.T, cs *k8sfake.Clientset, cfg config.KubernetesConfig) *KubernetesExecutor {
+	t.Helper()
+	log, err := logger.NewLogger(logger.LoggingConfig{Level: "error", Format: "json"})
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	r := NewKubernetesExecutor(cfg, log)
+	r.newClientFunc = func(config.KubernetesConfig, *logger.Logger) (*kubernetes.Client, error) {
+		return kubernetes.NewClientForClientset(cs, "default", kubernetes.ConnectModeDirect, log), nil
+	}
+	r.pollInterval = time.Millisecond
+	return r
+}
+
+// kubernetesWrites renders the list and write calls made through cs, e.g.
+// "delete pods agents/kandev-agent-1 grace=30". Reads are left out.
+func kubernetesWrites(cs *k8sfake.Clientset) []string {
+	var out []string
+	for _, action := range cs.Actions() {
+		target := action.GetNamespace() + "/"
+		switch a := action.(type) {
+		case k8stesting.CreateAction:
+			obj, err := meta.Accessor(a.GetObject())
+			if err != nil {
+				continue
+			}
+			target += obj.GetName()
+		case k8stesting.DeleteAction:
+			target += a.GetName()
+			if grace := a.GetDeleteOptions().GracePeriodSeconds; grace != nil {
+				target += fmt.Sprintf(" grace=%d", *grace)
+			}
+		case k8stesting.PatchAction:
+			target += a.GetName()
+		case k8stesting.ListAction:
+			target = action.GetNamespace() + " " + a.GetListRestrictions().Labels.String()
+		default:
+			continue
+		}
+		out = append(out, action.GetVerb()+" "+action.GetResource().Resource+" "+target)
+	}
+	return out
+}
+
+// quantities renders a resource list as strings for comparison.
+func quantities(list corev1.ResourceList) map[string]string {
+	out := make(map[string]string, len(list))
+	for name, q := range list {
+		out[string(name)] = q.String()
+	}
+	return out
+}
+
+func newKubernetesCreateRequest(metadata map[string]interface{}) *ExecutorCreateRequest {
+	return &ExecutorCreateRequest{
+		InstanceID:        "3F2A9C1E-aaaa-bbbb-cccc-000000000001",
+		TaskID:            "task-1",
+		TaskTitle:         "Fix the flaky test",
+		SessionID:         "session-1",
+		TaskEnvironmentID: "env-1",
+		AgentConfig:       newConfigStubAgent(),
+		Env:               map[string]string{"ANTHROPIC_API_KEY": "sk-test"},
+		Metadata:          metadata,
+	}
+}
+
+func TestKubernetesBuildPodPlan_ProfileSettings(t *testing.T) {
+	r := newTestKubernetesExecutor(t, k8sfake.NewClientset(), config.KubernetesConfig{AgentctlImage: "ghcr.io/kandev/agentctl:v1"})
+	req := newKubernetesCreateRequest(map[string]interface{}{
+		MetadataKeyKubernetesNamespace:       "agents",
+		MetadataKeyKubernetesServiceAccount:  "kandev-agent",
+		MetadataKeyKubernetesWorkspaceVolume: "pvc",
+		MetadataKeyKubernetesPVCSize:         "20Gi",
+		MetadataKeyKubernetesStorageClass:    "fast",
+		MetadataKeyKubernetesCPURequest:      "250m",
+		MetadataKeyKubernetesMemoryLimit:     "4Gi",
+		MetadataKeyKubernetesNodeSelector:    "pool=agents, arch=amd64",
+		MetadataKeyImageTagOverride:          "registry.local/agent:dev",
+		MetadataKeyExecutorProfileID:         "prof-1",
+	})
+
+	plan, err := r.buildPodPlan(req, "default", "nonce-1")
+	if err != nil {
+		t.Fatalf("buildPodPlan: %v", err)
+	}
+	pod := plan.pod
+	if pod.Name != "kandev-agent-3f2a9c1e-aaaa-bbbb-cccc-000000000001" || pod.Namespace != "agents" {
+		t.Fatalf("pod = %s/%s", pod.Namespace, pod.Name)
+	}
+	if pod.Spec.ServiceAccountName != "kandev-agent" || pod.Spec.RestartPolicy != "Never" {
+		t.Errorf("spec = %+v", pod.Spec)
+	}
+	if want := map[string]string{"pool": "agents", "arch": "amd64"}; !reflect.DeepEqual(pod.Spec.NodeSelector, want) {
+		t.Errorf("node selector = %v, want %v", pod.Spec.NodeSelector, want)
+	}
+	assertLabel(t, pod.Labels, "kandev.managed", "true")
+	assertLabel(t, pod.Labels, "kandev.runtime", "kubernetes")
+	assertLabel(t, pod.Labels, "kandev.task_environment_id", "env-1")
+	assertLabel(t, pod.Labels, "kandev.executor_profile_id", "prof-1")
+
+	agent := pod.Spec.Containers[0]
+	if agent.Image != "registry.local/agent:dev" {
+		t.Errorf("image = %q, want the profile override", agent.Image)
+	}
+	if want := map[string]string{"cpu": "250m"}; !reflect.DeepEqual(quantities(agent.Resources.Requests), want) {
+		t.Errorf("requests = %v, want %v", agent.Resources.Requests, want)
+	}
+	if want := map[string]string{"cpu": "500m", "memory": "4Gi"}; !reflect.DeepEqual(quantities(agent.Resources.Limits), want) {
+		t.Errorf("limits = %v, want %v", agent.Resources.Limits, want)
+	}
+	if agent.EnvFrom[0].SecretRef.Name != plan.secret.Name {
+		t.Errorf("envFrom = %+v, want secret %s", agent.EnvFrom, plan.secret.Name)
+	}
+
+	if plan.pvc == nil || plan.pvcName != "kandev-ws-env-1" {
+		t.Fatalf("pvc = %+v (%s)", plan.pvc, plan.pvcName)
+	}
+	if storage := plan.pvc.Spec.Resources.Requests[corev1.ResourceStorage]; *plan.pvc.Spec.StorageClassName != "fast" || storage.String() != "20Gi" {
+		t.Errorf("pvc spec = %+v", plan.pvc.Spec)
+	}
+	if pod.Spec.Volumes[0].PersistentVolumeClaim == nil || pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName != "kandev-ws-env-1" {
+		t.Errorf("workspace volume = %+v", pod.Spec.Volumes[0])
+	}
+
+	if len(pod.Spec.InitContainers) != 1 || pod.Spec.InitContainers[0].Image != "ghcr.io/kandev/agentctl:v1" {
+		t.Fatalf("init containers = %+v", pod.Spec.InitContainers)
+	}
+	mounted := false
+	for _, m := range agent.VolumeMounts {
+		mounted = mounted || (m.MountPath == "/usr/local/bin/agentctl" && m.SubPath == "agentctl")
+	}
+	if !mounted {
+		t.Errorf("agentctl not mounted into agent container: %+v", agent.VolumeMounts)
+	}
+
+	env := plan.secret.StringData
+	if env["AGENTCTL_BOOTSTRAP_NONCE"] != "nonce-1" || env["ANTHROPIC_API_KEY"] != "sk-test" || env["KANDEV_INSTANCE_ID"] != req.InstanceID {
+		t.Errorf("secret env missing launch values: %v", env)
+	}
+	if !strings.Contains(env["KANDEV_PREPARE_SCRIPT"], "git") {
+		t.Errorf("KANDEV_PREPARE_SCRIPT = %q, want the default clone script", env["KANDEV_PREPARE_SCRIPT"])
+	}
+}
+
+func TestKubernetesBuildPodPlan_Defaults(t *testing.T) {
+	r := newTestKubernetesExecutor(t, k8sfake.NewClientset(), config.KubernetesConfig{})
+	plan, err := r.buildPodPlan(newKubernetesCreateRequest(nil), "default", "nonce-1")
+	if err != nil {
+		t.Fatalf("buildPodPlan: %v", err)
+	}
+	if plan.namespace != "default" || plan.pvc != nil {
+		t.Errorf("namespace/pvc = %s/%v, want default namespace and no pvc", plan.namespace, plan.pvc)
+	}
+	spec := plan.pod.Spec
+	if spec.Volumes[0].EmptyDir == nil || len(spec.InitContainers) != 0 {
+		t.Errorf("volumes/init = %+v / %+v", spec.Volumes, spec.InitContainers)
+	}
+	agent := spec.Containers[0]
+	if agent.Image != "kandev/multi-agent:latest" || agent.WorkingDir != "/workspace" {
+		t.Errorf("image/workdir = %s %s", agent.Image, agent.WorkingDir)
+	}
+	if want := map[string]string{"cpu": "500m", "memory": "256Mi"}; !reflect.DeepEqual(quantities(agent.Resources.Limits), want) {
+		t.Errorf("limits = %v, want the agent's runtime limits %v", agent.Resources.Limits, want)
+	}
+	if !reflect.DeepEqual(agent.Command, agentctlBootstrapCommand()) {
+		t.Error("agent container must run the shared agentctl bootstrap")
+	}
+}
+
+func TestKubernetesPodSettings_RejectsInvalidValues(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"volume":        {MetadataKeyKubernetesWorkspaceVolume: "hostPath"},
+		"node selector": {MetadataKeyKubernetesNodeSelector: "pool"},
+	}
+	for name, metadata := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := kubernetesPodSettingsFromMetadata(metadata, "default"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestKubernetesBuildPodPlan_RejectsInvalidQuantities(t *testing.T) {
+	r := newTestKubernetesExecutor(t, k8sfake.NewClientset(), config.KubernetesConfig{})
+	cases := map[string]map[string]interface{}{
+		"cpu limit": {MetadataKeyKubernetesCPULimit: "lots"},
+		"pvc size": {
+			MetadataKeyKubernetesWorkspaceVolume: "pvc",
+			MetadataKeyKubernetesPVCSize:         "ten gigs",
+		},
+	}
+	for name, metadata := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := r.buildPodPlan(newKubernetesCreateRequest(metadata), "default", "nonce-1"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestKubernetesStopInstance(t *testing.T) {
+	cases := []struct {
+		name   string
+		reason string
+		force  bool
+		want   []string
+	}{
+		{name: "plain stop preserves pod", want: nil},
+		{
+			name:   "stale cleanup deletes pod but keeps workspace",
+			reason: stopReasonStaleExecutionCleanup,
+			want:   []string{"delete pods agents/kandev-agent-1 grace=30", "delete secrets agents/kandev-agent-1-env"},
+		},
+		{
+			name:   "task deletion removes workspace claim",
+			reason: StopReasonTaskDeleted,
+			want: []string{
+				"delete pods agents/kandev-agent-1 grace=30",
+				"delete secrets agents/kandev-agent-1-env",
+				"delete persistentvolumeclaims agents/kandev-ws-env-1",
+			},
+		},
+		{
+			name:  "force kills immediately",
+			force: true,
+			want:  []string{"delete pods agents/kandev-agent-1 grace=0", "delete secrets agents/kandev-agent-1-env"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := k8sfake.NewClientset()
+			r := newTestKubernetesExecutor(t, cs, config.KubernetesConfig{})
+			err := r.StopInstance(context.Background(), &ExecutorInstance{
+				InstanceID:  "inst-1",
+				ContainerID: "kandev-agent-1",
+				StopReason:  tc.reason,
+				Metadata: map[string]interface{}{
+					MetadataKeyKubernetesNamespace: "agents",
+					MetadataKeyKubernetesPVCName:   "kandev-ws-env-1",
+				},
+			}, tc.force)
+			if err != nil {
+				t.Fatalf("StopInstance: %v", err)
+			}
+			if got := kubernetesWrites(cs); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("calls = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesRecoverInstances_RemovesTerminatedPods(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase, labels map[string]string) runtime.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	cs := k8sfake.NewClientset(
+		pod("done", corev1.PodSucceeded, kubernetesManagedSelector()),
+		pod("crashed", corev1.PodFailed, kubernetesManagedSelector()),
+		pod("live", corev1.PodRunning, kubernetesManagedSelector()),
+		pod("unmanaged", corev1.PodFailed, nil),
+	)
+	r := newTestKubernetesExecutor(t, cs, config.KubernetesConfig{})
+
+	recovered, err := r.RecoverInstances(context.Background())
+	if err != nil || recovered != nil {
+		t.Fatalf("RecoverInstances = %v, %v", recovered, err)
+	}
+	remaining, err := cs.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list pods: %v", err)
+	}
+	var names []string
+	for _, p := range remaining.Items {
+		names = append(names, p.Name)
+	}
+	if want := []string{"live", "unmanaged"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("remaining pods = %v, want %v", names, want)
+	}
+	calls := kubernetesWrites(cs)
+	if calls[0] != "list pods default kandev.managed=true,kandev.runtime=kubernetes" {
+		t.Errorf("list call = %q", calls[0])
+	}
+	for _, want := range []string{"delete secrets default/done-env", "delete secrets default/crashed-env"} {
+		if !containsExactString(calls, want) {
+			t.Errorf("calls %q missing %q", calls, want)
+		}
+	}
+}
+
+func TestKubernetesCreateInstance_RollsBackWhenPodCannotStart(t *testing.T) {
+	cs := k8sfake.NewClientset()
+	cs.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		pod.Status = corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "agent",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}},
+			}},
+		}
+		return false, nil, nil
+	})
+	r := newTestKubernetesExecutor(t, cs, config.KubernetesConfig{})
+
+	_, err := r.CreateInstance(context.Background(), newKubernetesCreateRequest(nil))
+	if err == nil || !strings.Contains(err.Error(), "ImagePullBackOff") {
+		t.Fatalf("CreateInstance err = %v, want ImagePullBackOff", err)
+	}
+	podName := "kandev-agent-3f2a9c1e-aaaa-bbbb-cccc-000000000001"
+	want := []string{
+		"create secrets default/" + podName + "-env",
+		"create pods default/" + podName,
+		"patch secrets default/" + podName + "-env",
+		"delete pods default/" + podName + " grace=0",
+		"delete secrets default/" + podName + "-env",
+	}
+	if got := kubernetesWrites(cs); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package lifecycle
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agent/docker"
+	"github.com/kandev/kandev/internal/agent/executor"
+	"github.com/kandev/kandev/internal/common/config"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+// PodmanExecutor runs agents in Podman containers through Podman's
+// Docker-compatible API socket. Launch, reconnect and cleanup are the Docker
+// executor's; Podman only changes how containers are created — user
+// namespace mapping, SELinux relabeling of bind mounts and the network mode.
+//
+// Rootless Podman puts containers in a user network namespace whose IPs the
+// host cannot route to, so agentctl is reached through the loopback ports the
+// container manager always publishes (see dockerAgentctlPortBindings).
+type PodmanExecutor struct {
+	*DockerExecutor
+}
+
+// NewPodmanExecutor creates a new Podman runtime. Like the Docker runtime,
+// the API client is created lazily on first use.
+func NewPodmanExecutor(cfg config.PodmanConfig, kandevHomeDir string, log *logger.Logger) *PodmanExecutor {
+	dockerCfg := config.DockerConfig{
+		Enabled:        cfg.Enabled,
+		Host:           cfg.Host,
+		APIVersion:     cfg.APIVersion,
+		DefaultNetwork: cfg.Network,
+	}
+	exec := NewDockerExecutor(dockerCfg, kandevHomeDir, log)
+	exec.logger = log.WithFields(zap.String("runtime", string(executor.NamePodman)))
+	exec.warmPool = newWarmPool(exec, exec.logger)
+	exec.engine = containerEngine{
+		name:          executor.NamePodman,
+		executorType:  string(executor.NamePodman),
+		networkName:   cfg.Network,
+		hostAlias:     "host.containers.internal",
+		tuneContainer: podmanContainerTuner(cfg),
+	}
+	return &PodmanExecutor{DockerExecutor: exec}
+}
+
+// podmanContainerTuner applies the Podman-specific parts of cfg to a
+// container config built by the ContainerManager.
+func podmanContainerTuner(cfg config.PodmanConfig) func(*docker.ContainerConfig) {
+	return func(c *docker.ContainerConfig) {
+		c.UsernsMode = cfg.UserNS
+		switch cfg.SELinuxLabel {
+		case "shared", "private":
+			for i := range c.Mounts {
+				c.Mounts[i].Relabel = podmanRelabelFlag(c.Mounts[i], cfg.SELinuxLabel)
+			}
+		case "disable":
+			c.SecurityOpt = append(c.SecurityOpt, "label=disable")
+		}
+	}
+}
+
+// podmanRelabelFlag picks the SELinux relabel flag for one bind mount.
+//
+// Mounts whose source and target are the same path are the user's own
+// repositories (local clone sources, a worktree's main .git dir); relabeling
+// them would rewrite labels across the user's checkout, so they are left
+// alone and hosts that deny access should use "disable". Read-only mounts
+// such as the agentctl binary are shared by every agent container, so they
+// always get the shared label — a private one would lock out the others.
+// Named volumes are labeled by Podman itself.
+func podmanRelabelFlag(m docker.MountConfig, mode string) string {
+	if m.Source == m.Target || m.Type == "volume" {
+		return ""
+	}
+	if mode == "private" && !m.ReadOnly {
+		return "Z"
+	}
+	return "z"
+}
@@ -0,0 +1,114 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kandev/kandev/internal/agent/docker"
+	"github.com/kandev/kandev/internal/agent/executor"
+	"github.com/kandev/kandev/internal/common/config"
+)
+
+func newTestPodmanConfig() config.PodmanConfig {
+	return config.PodmanConfig{
+		Enabled:      true,
+		Host:         "unix:///run/user/1000/podman/podman.sock",
+		Network:      "pasta",
+		UserNS:       "keep-id",
+		SELinuxLabel: "shared",
+	}
+}
+
+func TestNewPodmanExecutor(t *testing.T) {
+	exec := NewPodmanExecutor(newTestPodmanConfig(), t.TempDir(), newTestDockerLogger())
+
+	if exec.Name() != executor.NamePodman {
+		t.Errorf("Name() = %q, want %q", exec.Name(), executor.NamePodman)
+	}
+	if exec.cfg.Host != "unix:///run/user/1000/podman/podman.sock" {
+		t.Errorf("client host = %q, want the Podman socket", exec.cfg.Host)
+	}
+	if !exec.RequiresCloneURL() || !exec.IsAlwaysResumable() {
+		t.Error("Podman should keep the Docker executor's clone-inside and resume semantics")
+	}
+}
+
+func TestPodmanExecutor_ContainerManagerUsesPodmanSettings(t *testing.T) {
+	exec := NewPodmanExecutor(newTestPodmanConfig(), t.TempDir(), newTestDockerLogger())
+
+	cm := exec.ContainerMgr()
+	if cm == nil {
+		t.Fatal("expected container manager")
+	}
+	if cm.networkName != "pasta" {
+		t.Errorf("networkName = %q, want pasta", cm.networkName)
+	}
+	if cm.tuneContainer == nil {
+		t.Fatal("expected Podman container tuning to be wired into the container manager")
+	}
+}
+
+func TestPodmanExecutor_UnavailableErrorNamesPodman(t *testing.T) {
+	exec := NewPodmanExecutor(newTestPodmanConfig(), "", newTestDockerLogger())
+	exec.newClientFunc = failingClientFactory("no socket")
+
+	_, err := exec.CreateInstance(context.Background(), &ExecutorCreateRequest{InstanceID: "inst-1"})
+	if err == nil || !strings.Contains(err.Error(), "podman unavailable") {
+		t.Fatalf("CreateInstance error = %v, want podman unavailable", err)
+	}
+}
+
+func TestPodmanExecutor_DefaultPrepareScript(t *testing.T) {
+	exec := NewPodmanExecutor(newTestPodmanConfig(), "", newTestDockerLogger())
+
+	script, err := exec.resolvePrepareScript(&ExecutorCreateRequest{Metadata: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("resolvePrepareScript: %v", err)
+	}
+	if !strings.Contains(script, "/workspace") {
+		t.Errorf("expected the container prepare script, got %q", script)
+	}
+}
+
+func TestPodmanContainerTuner(t *testing.T) {
+	mounts := func() []docker.MountConfig {
+		return []docker.MountConfig{
+			{Source: "/home/u/.kandev/sessions/abc/claude", Target: "/root/.claude"},
+			{Source: "/home/u/.kandev/bin/agentctl", Target: "/usr/local/bin/agentctl", ReadOnly: true},
+			{Source: "/home/u/src/repo", Target: "/home/u/src/repo", ReadOnly: true},
+		}
+	}
+	cases := []struct {
+		name        string
+		label       string
+		wantRelabel []string
+		wantSecOpt  []string
+	}{
+		{name: "shared", label: "shared", wantRelabel: []string{"z", "z", ""}},
+		{name: "private keeps shared label on read-only mounts", label: "private", wantRelabel: []string{"Z", "z", ""}},
+		{name: "disable", label: "disable", wantRelabel: []string{"", "", ""}, wantSecOpt: []string{"label=disable"}},
+		{name: "none", label: "none", wantRelabel: []string{"", "", ""}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestPodmanConfig()
+			cfg.SELinuxLabel = tc.label
+			c := &docker.ContainerConfig{Mounts: mounts()}
+
+			podmanContainerTuner(cfg)(c)
+
+			if c.UsernsMode != "keep-id" {
+				t.Errorf("UsernsMode = %q, want keep-id", c.UsernsMode)
+			}
+			for i, want := range tc.wantRelabel {
+				if got := c.Mounts[i].Relabel; got != want {
+					t.Errorf("mount %s relabel = %q, want %q", c.Mounts[i].Target, got, want)
+				}
+			}
+			if strings.Join(c.SecurityOpt, ",") != strings.Join(tc.wantSecOpt, ",") {
+				t.Errorf("SecurityOpt = %v, want %v", c.SecurityOpt, tc.wantSecOpt)
+			}
+		})
+	}
+}
@@ -50,6 +50,7 @@ const (
 	spritesAgentctlPort  = 8765
 	spritesWorkspacePath = "/workspace"
 	spritesNamePrefix    = "kandev-"
+	spritesTokenEnv      = "SPRITES_API_TOKEN"
 	// spriteStepTimeout caps cheap one-shot API calls (create sprite, reconnect,
 	// network policy). These are pure JSON round-trips; if they exceed 2 minutes
 	// the API is unhealthy and retrying longer won't help.
@@ -84,6 +85,9 @@ type SpritesExecutor struct {
 	mu               sync.RWMutex
 	proxies          map[string]*SpritesProxySession
 	tokens           map[string]string // cached API tokens per instance
+	// warmPool keeps provisioned sandboxes for profiles that set
+	// warm_pool_size. Nil in tests that build the executor by hand.
+	warmPool *warmPool
 }
 
 // NewSpritesExecutor creates a new Sprites runtime.
@@ -94,7 +98,7 @@ func NewSpritesExecutor(
 	agentctlPort int,
 	log *logger.Logger,
 ) *SpritesExecutor {
-	return &SpritesExecutor{
+	exec := &SpritesExecutor{
 		secretStore:      secretStore,
 		agentList:        agentList,
 		agentctlResolver: resolver,
@@ -103,6 +107,8 @@ func NewSpritesExecutor(
 		proxies:          make(map[string]*SpritesProxySession),
 		tokens:           make(map[string]string),
 	}
+	exec.warmPool = newWarmPool(exec, exec.logger)
+	return exec
 }
 
 func (r *SpritesExecutor) Name() executor.Name {
@@ -131,7 +137,7 @@ func (r *SpritesExecutor) CreateInstance(ctx context.Context, req *ExecutorCreat
 	if _, err := validateContributionDestinations(req.ContributionDestinations); err != nil {
 		return nil, err
 	}
-	token := req.Env["SPRITES_API_TOKEN"]
+	token := req.Env[spritesTokenEnv]
 	if token == "" {
 		return nil, fmt.Errorf("SPRITES_API_TOKEN not set in execution environment (configure it in the executor profile)")
 	}
@@ -141,6 +147,11 @@ func (r *SpritesExecutor) CreateInstance(ctx context.Context, req *ExecutorCreat
 	r.mu.Unlock()
 
 	reconnect := spritesShouldReconnect(req)
+	if !reconnect {
+		if warm := r.warmPool.claim(baseCtx, req); warm != nil {
+			return warm, nil
+		}
+	}
 	spriteName := r.resolveSpriteName(req, reconnect)
 	client := sprites.New(token, sprites.WithDisableControl())
 	progressPlan := newSpritesProgressPlan(reconnect)
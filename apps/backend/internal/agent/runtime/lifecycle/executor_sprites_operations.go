@@ -304,7 +304,17 @@ func (r *SpritesExecutor) resolvePrepareScript(req *ExecutorCreateRequest) (stri
 	if script == "" {
 		return "", nil
 	}
-	script += KandevBranchCheckoutPostlude()
+	taskSteps, err := spritesTaskSteps(req)
+	if err != nil {
+		return "", err
+	}
+	return r.resolveSpriteScript(req, script+taskSteps), nil
+}
+
+// spritesTaskSteps returns the kandev-managed steps that follow the prepare
+// script: the feature branch checkout and the contribution remotes.
+func spritesTaskSteps(req *ExecutorCreateRequest) (string, error) {
+	script := KandevBranchCheckoutPostlude()
 	if binding, ok := req.RemoteContributions[""]; ok {
 		contributionScript, err := scriptengine.RemoteContributionSetupScript(&binding)
 		if err != nil {
@@ -319,7 +329,11 @@ func (r *SpritesExecutor) resolvePrepareScript(req *ExecutorCreateRequest) (stri
 		}
 		script += destinationScript
 	}
+	return script, nil
+}
 
+// resolveSpriteScript fills the placeholders of a script run inside a sprite.
+func (r *SpritesExecutor) resolveSpriteScript(req *ExecutorCreateRequest, script string) string {
 	installScripts := r.collectAgentInstallScripts(req)
 
 	resolver := scriptengine.NewResolver().
@@ -342,7 +356,7 @@ func (r *SpritesExecutor) resolvePrepareScript(req *ExecutorCreateRequest) (stri
 			injectGitHubTokenIntoCloneURL,
 		))
 
-	return resolver.Resolve(script), nil
+	return resolver.Resolve(script)
 }
 
 // collectAgentInstallScripts extracts agent IDs from the executor profile metadata
@@ -459,6 +473,7 @@ func spriteCreateInstanceRequest(req *ExecutorCreateRequest) agentctl.CreateInst
 		RemoteContributions:      req.RemoteContributions,
 		ContributionDestinations: req.ContributionDestinations,
 		ComparisonTargets:        req.ComparisonTargets,
+		PermissionPolicy:         req.PermissionPolicy,
 		Env:                      cloneStringMap(req.Env),
 	}
 }
@@ -0,0 +1,140 @@
+package lifecycle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	sprites "github.com/superfly/sprites-go"
+	"go.uber.org/zap"
+)
+
+// warmKey also covers the API token, hashed, so a sandbox is only served to
+// launches of the account that owns it. Office sessions upload per-agent
+// skill files into the checkout and always take the normal path.
+func (r *SpritesExecutor) warmKey(req *ExecutorCreateRequest) string {
+	token := req.Env[spritesTokenEnv]
+	if token == "" || getMetadataString(req.Metadata, MetadataKeySkillManifestJSON) != "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return warmRequestKey(r.Name(), req, hex.EncodeToString(sum[:]))
+}
+
+// provisionWarm creates an idle sandbox for template: agentctl and the
+// credentials are uploaded, the prepare script has cloned the base branch
+// and agentctl is healthy, but no agent instance exists yet.
+func (r *SpritesExecutor) provisionWarm(ctx context.Context, warm *warmInstance, template *ExecutorCreateRequest) error {
+	req := *template
+	req.InstanceID = warm.id
+	client := sprites.New(req.Env[spritesTokenEnv], sprites.WithDisableControl())
+	name := spritesNamePrefix + warm.id[:12]
+	sprite, err := r.createSprite(ctx, client, name)
+	if err != nil {
+		return err
+	}
+	err = r.stepSetupEnvironment(ctx, sprite, &req, false, func(spritesStepKey, PrepareStep) {})
+	if err == nil {
+		healthCtx, cancel := withLaunchPhaseTimeout(ctx)
+		err = r.waitForHealth(healthCtx, sprite)
+		cancel()
+	}
+	if err != nil {
+		if destroyErr := sprite.Destroy(); destroyErr != nil {
+			r.logger.Warn("failed to destroy warm sprite", zap.String(MetadataKeySpriteName, name), zap.Error(destroyErr))
+		}
+		return err
+	}
+	warm.spriteName = name
+	return nil
+}
+
+// claimWarm hands a warm sandbox to req. The sandbox keeps its name, which
+// the instance metadata records for resume; credentials are uploaded again,
+// the per-task steps skipped at provisioning (base refresh, feature branch
+// checkout, contribution remotes) run, and the agent instance is created.
+func (r *SpritesExecutor) claimWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	step := beginStep("Claim warm sandbox")
+	reportProgress(req.OnProgress, step, 0, 0)
+	instance, err := r.bindWarm(ctx, warm, req)
+	if err != nil {
+		step.Warning = "Warm sandbox unavailable, provisioning a new one"
+		step.WarningDetail = err.Error()
+		completeStepSkipped(&step)
+	} else {
+		completeStepSuccess(&step)
+	}
+	reportProgress(req.OnProgress, step, 0, 0)
+	return instance, err
+}
+
+func (r *SpritesExecutor) bindWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	client := sprites.New(req.Env[spritesTokenEnv], sprites.WithDisableControl())
+	launchCtx, cancel := withLaunchPhaseTimeout(ctx)
+	defer cancel()
+	sprite, err := r.reconnectSprite(launchCtx, client, warm.spriteName)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.uploadCredentials(ctx, sprite, req, nil); err != nil {
+		r.logger.Warn("failed to upload credentials to warm sprite (non-fatal)", zap.Error(err))
+	}
+	if err := r.runWarmClaimScript(launchCtx, sprite, req); err != nil {
+		return nil, err
+	}
+	if err := r.waitForHealth(launchCtx, sprite); err != nil {
+		return nil, err
+	}
+	instancePort, err := r.createAgentInstance(launchCtx, sprite, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.applyNetworkPolicy(launchCtx, client, warm.spriteName, req); err != nil {
+		r.logger.Warn("failed to apply network policy from profile", zap.Error(err))
+	}
+	localPort, err := r.setupPortForwarding(launchCtx, sprite, warm.spriteName, req.InstanceID, instancePort)
+	if err != nil {
+		return nil, err
+	}
+	instance := r.buildInstanceResult(req, warm.spriteName, sprite, localPort, instancePort, false)
+	instance.Metadata[MetadataKeyWarmInstanceID] = warm.id
+	return instance, nil
+}
+
+func (r *SpritesExecutor) runWarmClaimScript(ctx context.Context, sprite *sprites.Sprite, req *ExecutorCreateRequest) error {
+	taskSteps, err := spritesTaskSteps(req)
+	if err != nil {
+		return err
+	}
+	script := r.resolveSpriteScript(req, "set -e\n"+warmBaseRefreshScript+taskSteps)
+	stepCtx, cancel := context.WithTimeout(ctx, warmClaimScriptTimeout)
+	defer cancel()
+	cmd := sprite.CommandContext(stepCtx, "bash", "-c", script)
+	cmd.Env = r.buildSpriteEnv(req.Env, req.AgentctlStartupConfig)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("warm claim script: %w: %s", err, lastLines(strings.TrimSpace(string(output)), spriteOutputMaxLines))
+	}
+	return nil
+}
+
+func (r *SpritesExecutor) discardWarm(_ context.Context, warm *warmInstance) {
+	if warm.template == nil || warm.spriteName == "" {
+		return
+	}
+	sprite := sprites.New(warm.template.Env[spritesTokenEnv]).Sprite(warm.spriteName)
+	if err := sprite.Destroy(); err != nil {
+		r.logger.Warn("failed to destroy warm sprite",
+			zap.String("warm_instance_id", warm.id),
+			zap.String(MetadataKeySpriteName, warm.spriteName),
+			zap.Error(err))
+	}
+}
+
+// Close destroys the idle warm sandboxes; a later backend process cannot
+// find them without a profile's API token.
+func (r *SpritesExecutor) Close() error {
+	r.warmPool.closeAndDiscard()
+	return nil
+}
@@ -0,0 +1,22 @@
+package lifecycle
+
+import "testing"
+
+func TestSpritesWarmKey(t *testing.T) {
+	r := &SpritesExecutor{}
+	base := r.warmKey(warmKeyRequest(map[string]string{spritesTokenEnv: "token-a"}, "make setup"))
+	if base == "" {
+		t.Fatal("expected a poolable request")
+	}
+	if got := r.warmKey(warmKeyRequest(map[string]string{spritesTokenEnv: "token-b"}, "make setup")); got == "" || got == base {
+		t.Errorf("key = %q, want a different key for another account", got)
+	}
+	if got := r.warmKey(warmKeyRequest(nil, "make setup")); got != "" {
+		t.Errorf("without an API token: key = %q, want not poolable", got)
+	}
+	office := warmKeyRequest(map[string]string{spritesTokenEnv: "token-a"}, "make setup")
+	office.Metadata[MetadataKeySkillManifestJSON] = `{"Skills":[]}`
+	if got := r.warmKey(office); got != "" {
+		t.Errorf("office session: key = %q, want not poolable", got)
+	}
+}
@@ -68,6 +68,9 @@ type SSHExecutor struct {
 	stopRemote       func(context.Context, *ssh.Client, string, int) error
 	closeClient      func(*ssh.Client) error
 	cleanupScript    func(context.Context, *ssh.Client, string, map[string]interface{}, map[string]string, SSHRemotePlatform, string, string) error
+	// warmPool keeps prepared task checkouts for profiles that set
+	// warm_pool_size. Nil in tests that build the executor by hand.
+	warmPool *warmPool
 
 	mu       sync.Mutex
 	sessions map[string]*sshSessionState // keyed by ExecutorInstance.InstanceID
@@ -92,6 +95,7 @@ func NewSSHExecutor(
 	executor.stopRemote = stopRemoteAgentctl
 	executor.closeClient = func(client *ssh.Client) error { return client.Close() }
 	executor.cleanupScript = executor.runCleanupScript
+	executor.warmPool = newWarmPool(executor, executor.logger)
 	return executor
 }
 
@@ -108,6 +112,8 @@ func (r *SSHExecutor) HealthCheck(_ context.Context) error {
 // session-by-session via StopInstance; Close is the shutdown safety net for
 // sessions whose StopInstance didn't run (e.g. a hard kandev exit).
 func (r *SSHExecutor) Close() error {
+	r.warmPool.closeAndDiscard()
+
 	r.mu.Lock()
 	states := make([]*sshSessionState, 0, len(r.sessions))
 	for id, s := range r.sessions {
@@ -206,22 +212,20 @@ func (r *SSHExecutor) CreateInstance(ctx context.Context, req *ExecutorCreateReq
 	if _, err := validateContributionDestinations(req.ContributionDestinations); err != nil {
 		return nil, fmt.Errorf("ssh: validate contribution destinations: %w", err)
 	}
+	if warm := r.warmPool.claim(baseCtx, req); warm != nil {
+		return warm, nil
+	}
 
-	target, err := r.targetFromMetadata(req.Metadata)
+	target, client, err := r.connect(baseCtx, req)
 	if err != nil {
 		return nil, err
 	}
-	client, err := dialSSH(baseCtx, target)
-	if err != nil {
-		return nil, fmt.Errorf("ssh: connect to %s@%s: %w", target.User, target.Host, err)
-	}
 	released := false
 	defer func() {
 		if !released {
 			_ = client.Close()
 		}
 	}()
-	r.report(req.OnProgress, "Connecting to SSH host", PrepareStepCompleted, "")
 	if err := r.preflightGitHubCredentialBroker(baseCtx, client, req, SSHRemotePlatform{}); err != nil {
 		return nil, err
 	}
@@ -240,6 +244,39 @@ func (r *SSHExecutor) CreateInstance(ctx context.Context, req *ExecutorCreateReq
 	if err := r.runPrepareScript(baseCtx, client, taskDir, req, platform, agentctlBin); err != nil {
 		return nil, err
 	}
+	instance, err := r.launchInTaskDir(baseCtx, client, target, agentctlBin, platform, workdir, taskDir, req)
+	if err != nil {
+		return nil, err
+	}
+	released = true // ownership transferred to session state; released on StopInstance
+	return instance, nil
+}
+
+// connect dials the configured host for req.
+func (r *SSHExecutor) connect(ctx context.Context, req *ExecutorCreateRequest) (*SSHTarget, *ssh.Client, error) {
+	target, err := r.targetFromMetadata(req.Metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := dialSSH(ctx, target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ssh: connect to %s@%s: %w", target.User, target.Host, err)
+	}
+	r.report(req.OnProgress, "Connecting to SSH host", PrepareStepCompleted, "")
+	return target, client, nil
+}
+
+// launchInTaskDir starts the session's agentctl in a prepared task dir and
+// tracks the session. On success the session state owns client.
+func (r *SSHExecutor) launchInTaskDir(
+	baseCtx context.Context,
+	client *ssh.Client,
+	target *SSHTarget,
+	agentctlBin string,
+	platform SSHRemotePlatform,
+	workdir, taskDir string,
+	req *ExecutorCreateRequest,
+) (*ExecutorInstance, error) {
 	launchCtx, launchCancel := withLaunchPhaseTimeout(baseCtx)
 	defer launchCancel()
 	if err := r.verifyPrimaryCheckout(launchCtx, client, taskDir, req, platform); err != nil {
@@ -272,8 +309,6 @@ func (r *SSHExecutor) CreateInstance(ctx context.Context, req *ExecutorCreateReq
 		platform:      platform,
 	}
 	r.mu.Unlock()
-	released = true // ownership transferred to session state; released on StopInstance
-
 	return r.buildInstance(req, target, fwd, taskDir, sessionDir, port, pid, workdir, authToken), nil
 }
 
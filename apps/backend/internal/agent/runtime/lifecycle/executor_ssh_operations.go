@@ -639,6 +639,7 @@ func buildSSHCreateInstanceRequest(
 		RemoteContributions:      req.RemoteContributions,
 		ContributionDestinations: req.ContributionDestinations,
 		ComparisonTargets:        req.ComparisonTargets,
+		PermissionPolicy:         req.PermissionPolicy,
 		Env:                      sshRemoteContributionEnv(req, agentctlBin),
 	}
 }
@@ -52,7 +52,17 @@ func (r *SSHExecutor) resolvePrepareScript(req *ExecutorCreateRequest, workspace
 	if script == "" {
 		return "", nil
 	}
-	script += KandevBranchCheckoutPostlude()
+	taskSteps, err := sshTaskSteps(req, workspacePath)
+	if err != nil {
+		return "", err
+	}
+	return r.resolveSSHScript(req, workspacePath, agentctlBin, script+taskSteps)
+}
+
+// sshTaskSteps returns the kandev-managed steps that follow the prepare
+// script: the feature branch checkout and the contribution remotes.
+func sshTaskSteps(req *ExecutorCreateRequest, workspacePath string) (string, error) {
+	script := KandevBranchCheckoutPostlude()
 	if binding, ok := req.RemoteContributions[""]; ok {
 		targetURL := getMetadataString(req.Metadata, "repository_clone_url")
 		if targetURL == "" {
@@ -67,7 +77,7 @@ func (r *SSHExecutor) resolvePrepareScript(req *ExecutorCreateRequest, workspace
 		}
 		script += "\n" + destinationScript
 	}
-	return r.resolveSSHScript(req, workspacePath, agentctlBin, script)
+	return script, nil
 }
 
 func (r *SSHExecutor) resolveSSHScript(req *ExecutorCreateRequest, workspacePath, agentctlBin, script string) (string, error) {
@@ -151,26 +161,40 @@ func (r *SSHExecutor) runPrepareScript(ctx context.Context, client *ssh.Client,
 	if err != nil {
 		return err
 	}
+	return r.runRemoteScript(ctx, client, req, platform, agentctlBin,
+		"prepare script", "Running prepare script", script, constants.SetupScriptTimeout)
+}
+
+// runRemoteScript runs a resolved script with the launch environment on
+// stdin and reports it as stepName.
+func (r *SSHExecutor) runRemoteScript(
+	ctx context.Context,
+	client *ssh.Client,
+	req *ExecutorCreateRequest,
+	platform SSHRemotePlatform,
+	agentctlBin, what, stepName, script string,
+	timeout time.Duration,
+) error {
 	if script == "" {
 		return nil
 	}
 	env := sshRemoteContributionEnv(req, agentctlBin)
 	envScript, err := buildSSHEnvInitScript(env)
 	if err != nil {
-		return fmt.Errorf("ssh: prepare script environment: %w", err)
+		return fmt.Errorf("ssh: %s environment: %w", what, err)
 	}
-	r.report(req.OnProgress, "Running prepare script", PrepareStepRunning, "")
-	stepCtx, cancel := context.WithTimeout(preparationContext(ctx), constants.SetupScriptTimeout)
+	r.report(req.OnProgress, stepName, PrepareStepRunning, "")
+	stepCtx, cancel := context.WithTimeout(preparationContext(ctx), timeout)
 	defer cancel()
 	shell := sshShellForRemote(req.Metadata, platform)
 	command := WrapLoginShell(shell, sshScriptWithEnvironment(script))
 	stdout, stderr, runErr := runSSHCommandStdin(stepCtx, client, command, strings.NewReader(envScript))
 	output := redactSSHScriptOutput(stdout+"\n"+stderr, env)
 	if runErr != nil {
-		r.report(req.OnProgress, "Running prepare script", PrepareStepFailed, output)
-		return fmt.Errorf("ssh: prepare script failed")
+		r.report(req.OnProgress, stepName, PrepareStepFailed, output)
+		return fmt.Errorf("ssh: %s failed", what)
 	}
-	r.report(req.OnProgress, "Running prepare script", PrepareStepCompleted, output)
+	r.report(req.OnProgress, stepName, PrepareStepCompleted, output)
 	return nil
 }
 
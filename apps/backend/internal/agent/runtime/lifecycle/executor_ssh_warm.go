@@ -0,0 +1,229 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// Warm SSH checkouts live next to the task dirs as <tasks>/.warm-<id>. The
+// sibling <dir>.expires file holds the unix expiry and marks the checkout
+// unclaimed; claiming links the task dir to the checkout and removes it, so
+// the checkout keeps the path its setup script ran in.
+const (
+	sshWarmDirPrefix    = ".warm-"
+	sshWarmMarkerSuffix = ".expires"
+)
+
+// sshWarmClaimCommand links the task dir to an unclaimed checkout. It exits
+// with sshWarmGoneExit when the checkout was swept and sshWarmTaskDirExit
+// when the task already has a dir.
+const (
+	sshWarmGoneExit     = 3
+	sshWarmTaskDirExit  = 4
+	sshWarmClaimCommand = `[ -f %[1]s ] || exit %[4]d
+if [ -e %[2]s ] || [ -L %[2]s ]; then exit %[5]d; fi
+ln -s %[3]s %[2]s && rm -f %[1]s`
+)
+
+// sshWarmSweepScript removes unclaimed warm checkouts whose expiry passed,
+// e.g. ones left behind by a backend that exited without draining its pool.
+const sshWarmSweepScript = `now=$(date +%%s)
+for marker in %s/` + sshWarmDirPrefix + `*` + sshWarmMarkerSuffix + `; do
+  [ -f "$marker" ] || continue
+  [ "$(cat "$marker")" -lt "$now" ] 2>/dev/null || continue
+  rm -rf "${marker%%` + sshWarmMarkerSuffix + `}" "$marker"
+done`
+
+// sshWarmKeyMetadataKeys select the host, account and workdir a warm
+// checkout lives on.
+var sshWarmKeyMetadataKeys = []string{
+	MetadataKeySSHHost,
+	MetadataKeySSHHostAlias,
+	MetadataKeySSHPort,
+	MetadataKeySSHUser,
+	MetadataKeySSHIdentitySource,
+	MetadataKeySSHIdentityFile,
+	MetadataKeySSHProxyJump,
+	MetadataKeySSHHostFingerprint,
+	MetadataKeySSHWorkdirRoot,
+	MetadataKeySSHShell,
+}
+
+func (r *SSHExecutor) warmKey(req *ExecutorCreateRequest) string {
+	values := make([]string, 0, 2*len(sshWarmKeyMetadataKeys))
+	for _, key := range sshWarmKeyMetadataKeys {
+		values = append(values, key, getMetadataString(req.Metadata, key))
+	}
+	return warmRequestKey(r.Name(), req, values...)
+}
+
+// provisionWarm prepares an idle checkout for template on its host: the
+// prepare script has run against the base branch and the checkout is
+// verified, but no agentctl is started.
+func (r *SSHExecutor) provisionWarm(ctx context.Context, warm *warmInstance, template *ExecutorCreateRequest) error {
+	req := *template
+	req.InstanceID = warm.id
+	_, client, err := r.connect(ctx, &req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.closeSSHClient(client) }()
+
+	agentctlBin, platform, err := r.prepareRemoteHost(ctx, client, &req)
+	if err != nil {
+		return err
+	}
+	root, err := expandRemoteHome(ctx, client, r.workdirRoot(req.Metadata))
+	if err != nil {
+		return err
+	}
+	tasksDir := root + "/tasks"
+	if _, stderr, err := runSSHCommand(ctx, client, "sh -c "+shellQuote(fmt.Sprintf(sshWarmSweepScript, shellQuote(tasksDir)))); err != nil {
+		r.logger.Debug("failed to sweep expired warm checkouts", zap.String("stderr", stderr), zap.Error(err))
+	}
+
+	dir := tasksDir + "/" + sshWarmDirPrefix + warm.id
+	marker := dir + sshWarmMarkerSuffix
+	// The marker goes first so a half-prepared checkout is swept too.
+	mkdir := fmt.Sprintf("mkdir -p %s && printf %%s %d > %s && mkdir -p %s",
+		shellQuote(tasksDir), warm.expiresAt.Unix(), shellQuote(marker), shellQuote(dir))
+	if _, _, err := runSSHCommand(ctx, client, mkdir); err != nil {
+		return fmt.Errorf("ssh: create warm checkout dir: %w", err)
+	}
+	warm.remoteDir = dir
+
+	r.maybeUploadCredentials(ctx, client, &req, platform)
+	err = r.runPrepareScript(ctx, client, dir, &req, platform, agentctlBin)
+	if err == nil {
+		err = r.verifyPrimaryCheckout(ctx, client, dir, &req, platform)
+	}
+	if err != nil {
+		removeSSHWarmDir(ctx, client, dir)
+		return err
+	}
+	return nil
+}
+
+// claimWarm hands a warm checkout to req. The task dir becomes a link to the
+// checkout, the per-task steps skipped at provisioning (base refresh,
+// feature branch checkout, contribution remotes) run, and the session's
+// agentctl is started as on a fresh launch. A task that already has a task
+// dir keeps it; the checkout then stays in the pool.
+func (r *SSHExecutor) claimWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	step := beginStep("Claim warm checkout")
+	reportProgress(req.OnProgress, step, 0, 0)
+	instance, err := r.bindWarm(ctx, warm, req)
+	switch {
+	case errors.Is(err, errWarmInstanceUnfit):
+		completeStepSkipped(&step)
+	case err != nil:
+		step.Warning = "Warm checkout unavailable, preparing a new one"
+		step.WarningDetail = err.Error()
+		completeStepSkipped(&step)
+	default:
+		completeStepSuccess(&step)
+	}
+	reportProgress(req.OnProgress, step, 0, 0)
+	return instance, err
+}
+
+func (r *SSHExecutor) bindWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	target, client, err := r.connect(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	released := false
+	defer func() {
+		if !released {
+			_ = r.closeSSHClient(client)
+		}
+	}()
+
+	agentctlBin, platform, err := r.prepareRemoteHost(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+	workdir := r.workdirRoot(req.Metadata)
+	taskDir, err := linkSSHWarmTaskDir(ctx, client, warm, workdir, sshTaskDirName(req))
+	if err != nil {
+		return nil, err
+	}
+	r.maybeUploadCredentials(ctx, client, req, platform)
+	taskSteps, err := sshTaskSteps(req, taskDir)
+	if err != nil {
+		return nil, err
+	}
+	script, err := r.resolveSSHScript(req, taskDir, agentctlBin, warmBaseRefreshScript+taskSteps)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.runRemoteScript(ctx, client, req, platform, agentctlBin,
+		"warm claim script", "Refreshing warm checkout", script, warmClaimScriptTimeout); err != nil {
+		return nil, err
+	}
+	instance, err := r.launchInTaskDir(ctx, client, target, agentctlBin, platform, workdir, taskDir, req)
+	if err != nil {
+		return nil, err
+	}
+	released = true // ownership transferred to session state; released on StopInstance
+	instance.Metadata[MetadataKeyWarmInstanceID] = warm.id
+	return instance, nil
+}
+
+// linkSSHWarmTaskDir points the task dir named taskDirName at the warm
+// checkout and marks the checkout claimed. It returns errWarmInstanceUnfit
+// when the task dir already exists.
+func linkSSHWarmTaskDir(ctx context.Context, client *ssh.Client, warm *warmInstance, workdir, taskDirName string) (string, error) {
+	if taskDirName == "" || warm.remoteDir == "" {
+		return "", errors.New("ssh: warm checkout has no task dir")
+	}
+	root, err := expandRemoteHome(ctx, client, workdir)
+	if err != nil {
+		return "", err
+	}
+	taskDir := root + "/tasks/" + taskDirName
+	if path.Dir(taskDir) != path.Dir(warm.remoteDir) {
+		return "", fmt.Errorf("ssh: warm checkout %s is outside %s", warm.remoteDir, path.Dir(taskDir))
+	}
+	claim := fmt.Sprintf(sshWarmClaimCommand,
+		shellQuote(warm.remoteDir+sshWarmMarkerSuffix), shellQuote(taskDir), shellQuote(path.Base(warm.remoteDir)),
+		sshWarmGoneExit, sshWarmTaskDirExit)
+	_, stderr, err := runSSHCommand(ctx, client, "sh -c "+shellQuote(claim))
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return taskDir, nil
+	case errors.As(err, &exitErr) && exitErr.ExitStatus() == sshWarmTaskDirExit:
+		return "", errWarmInstanceUnfit
+	case errors.As(err, &exitErr) && exitErr.ExitStatus() == sshWarmGoneExit:
+		return "", fmt.Errorf("ssh: warm checkout %s is gone", warm.remoteDir)
+	}
+	return "", fmt.Errorf("ssh: claim warm checkout: %w: %s", err, stderr)
+}
+
+// discardWarm removes an unclaimed warm checkout. A claimed one belongs to
+// its task and is left alone.
+func (r *SSHExecutor) discardWarm(ctx context.Context, warm *warmInstance) {
+	if warm.template == nil || warm.remoteDir == "" {
+		return
+	}
+	_, client, err := r.connect(ctx, warm.template)
+	if err != nil {
+		r.logger.Warn("failed to connect to remove warm checkout",
+			zap.String("warm_instance_id", warm.id), zap.Error(err))
+		return
+	}
+	defer func() { _ = r.closeSSHClient(client) }()
+	removeSSHWarmDir(ctx, client, warm.remoteDir)
+}
+
+func removeSSHWarmDir(ctx context.Context, client *ssh.Client, dir string) {
+	marker := shellQuote(dir + sshWarmMarkerSuffix)
+	_, _, _ = runSSHCommand(ctx, client, "sh -c "+shellQuote(
+		"if [ -f "+marker+" ]; then rm -rf "+shellQuote(dir)+" "+marker+"; fi"))
+}
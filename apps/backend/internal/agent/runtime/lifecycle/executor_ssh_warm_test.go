@@ -0,0 +1,50 @@
+package lifecycle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSSHWarmKey(t *testing.T) {
+	r := &SSHExecutor{}
+	sshRequest := func(host string) *ExecutorCreateRequest {
+		req := warmKeyRequest(map[string]string{"GH_TOKEN": "a"}, "make setup")
+		req.Metadata[MetadataKeySSHHost] = host
+		req.Metadata[MetadataKeySSHUser] = "dev"
+		return req
+	}
+	base := r.warmKey(sshRequest("build-1"))
+	if base == "" {
+		t.Fatal("expected a poolable request")
+	}
+	if got := r.warmKey(sshRequest("build-1")); got != base {
+		t.Errorf("key changed for the same host: %s != %s", got, base)
+	}
+	if got := r.warmKey(sshRequest("build-2")); got == base {
+		t.Error("key did not change with the host")
+	}
+	if got := (&DockerExecutor{kandevHomeDir: t.TempDir()}).warmKey(sshRequest("build-1")); got == base {
+		t.Error("ssh and docker share a key")
+	}
+	if got := r.warmKey(warmKeyRequest(nil, "git checkout {{worktree.branch}}")); got != "" {
+		t.Errorf("task branch in setup: key = %q, want not poolable", got)
+	}
+}
+
+func TestSSHWarmScripts(t *testing.T) {
+	sweep := fmt.Sprintf(sshWarmSweepScript, "'/home/dev/.kandev/tasks'")
+	for _, want := range []string{"date +%s", "'/home/dev/.kandev/tasks'/.warm-*.expires", `"${marker%.expires}"`} {
+		if !strings.Contains(sweep, want) {
+			t.Errorf("sweep script missing %q:\n%s", want, sweep)
+		}
+	}
+	claim := fmt.Sprintf(sshWarmClaimCommand, "m", "t", "b", sshWarmGoneExit, sshWarmTaskDirExit)
+	want := "[ -f m ] || exit 3\nif [ -e t ] || [ -L t ]; then exit 4; fi\nln -s b t && rm -f m"
+	if claim != want {
+		t.Errorf("claim command = %q, want %q", claim, want)
+	}
+	if strings.Contains(sweep+claim, "%!") {
+		t.Errorf("bad format verbs:\n%s\n%s", sweep, claim)
+	}
+}
@@ -3,6 +3,8 @@ package lifecycle
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -10,6 +12,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/agents"
 	"github.com/kandev/kandev/internal/agent/executor"
 	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
 	"github.com/kandev/kandev/internal/agentctl/server/process"
 	"github.com/kandev/kandev/internal/common/logger"
 	"github.com/kandev/kandev/internal/common/subproc"
@@ -117,9 +120,31 @@ func buildStandaloneCreateInstanceRequest(
 		ContributionDestinations: req.ContributionDestinations,
 		ComparisonTargets:        req.ComparisonTargets,
 		WorkspaceSourceRoots:     req.WorkspaceSourceRoots,
+		ResourceLimits:           resourceLimitsFromMetadata(req.Metadata),
+		PermissionPolicy:         req.PermissionPolicy,
 	}
 }
 
+// resourceLimitsFromMetadata reads the session resource limits from the
+// profile config in metadata. Missing, malformed and non-positive values are
+// unlimited; nil means no limit is set.
+func resourceLimitsFromMetadata(metadata map[string]interface{}) *cgroup.Limits {
+	var limits cgroup.Limits
+	if mb, err := strconv.ParseInt(strings.TrimSpace(getMetadataString(metadata, MetadataKeyMemoryLimitMB)), 10, 64); err == nil && mb > 0 {
+		limits.MemoryBytes = mb * 1024 * 1024
+	}
+	if cores, err := strconv.ParseFloat(strings.TrimSpace(getMetadataString(metadata, MetadataKeyCPULimitCores)), 64); err == nil && cores > 0 {
+		limits.CPUCores = cores
+	}
+	if pids, err := strconv.ParseInt(strings.TrimSpace(getMetadataString(metadata, MetadataKeyPidsLimit)), 10, 64); err == nil && pids > 0 {
+		limits.Pids = pids
+	}
+	if limits.IsZero() {
+		return nil
+	}
+	return &limits
+}
+
 func (r *StandaloneExecutor) CreateInstance(ctx context.Context, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
 	if err := r.waitForReady(ctx); err != nil {
 		return nil, err
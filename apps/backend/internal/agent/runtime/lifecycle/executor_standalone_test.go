@@ -325,3 +325,32 @@ func TestBuildStandaloneCreateInstanceRequestMapsEveryField(t *testing.T) {
 		t.Fatalf("WorkspaceSourceRoots = %v", got.WorkspaceSourceRoots)
 	}
 }
+
+func TestResourceLimitsFromMetadata(t *testing.T) {
+	got := resourceLimitsFromMetadata(map[string]interface{}{
+		MetadataKeyMemoryLimitMB: "2048",
+		MetadataKeyCPULimitCores: " 1.5 ",
+		MetadataKeyPidsLimit:     "512",
+	})
+	if got == nil || got.MemoryBytes != 2048<<20 || got.CPUCores != 1.5 || got.Pids != 512 {
+		t.Fatalf("limits = %+v", got)
+	}
+
+	got = resourceLimitsFromMetadata(map[string]interface{}{
+		MetadataKeyMemoryLimitMB: "lots",
+		MetadataKeyPidsLimit:     "256",
+	})
+	if got == nil || got.MemoryBytes != 0 || got.Pids != 256 {
+		t.Fatalf("malformed memory limit: limits = %+v", got)
+	}
+
+	for _, metadata := range []map[string]interface{}{
+		nil,
+		{MetadataKeyMemoryLimitMB: ""},
+		{MetadataKeyCPULimitCores: "0", MetadataKeyPidsLimit: "-1"},
+	} {
+		if got := resourceLimitsFromMetadata(metadata); got != nil {
+			t.Errorf("resourceLimitsFromMetadata(%v) = %+v, want nil", metadata, got)
+		}
+	}
+}
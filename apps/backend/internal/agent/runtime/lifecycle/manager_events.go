@@ -9,6 +9,7 @@ import (
 	"go.uber.org/zap"
 
 	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agent/runtime/envmetrics"
 	"github.com/kandev/kandev/internal/agentctl/tracing"
 	"github.com/kandev/kandev/internal/agentctl/types/streams"
 	"github.com/kandev/kandev/internal/events"
@@ -699,6 +700,27 @@ func (m *Manager) handlePromptHandoffEvent(
 	)
 }
 
+// handleResourceLimitEvent counts a session cgroup limit hit and forwards the
+// notice for the session timeline. Like attachment diagnostics it is not
+// agent activity.
+func (m *Manager) handleResourceLimitEvent(execution *AgentExecution, event agentctl.AgentEvent) {
+	if event.ResourceLimit == nil {
+		return
+	}
+	limit := event.ResourceLimit.Limit
+	if event.ResourceLimit.Error != "" {
+		limit = "unavailable"
+	}
+	envmetrics.RecordResourceLimitHit(limit)
+	m.logger.Warn("agent session hit a resource limit",
+		zap.String("execution_id", execution.ID),
+		zap.String("session_id", execution.SessionID),
+		zap.String("limit", limit),
+		zap.Bool("killed", event.ResourceLimit.Killed),
+		zap.String("error", event.ResourceLimit.Error))
+	m.eventPublisher.PublishAgentStreamEvent(execution, event)
+}
+
 // handleAgentEvent processes incoming agent events from the agent
 func (m *Manager) handleAgentEvent(execution *AgentExecution, event agentctl.AgentEvent) {
 	if event.Type == streams.EventTypeMCPAttachment {
@@ -721,6 +743,10 @@ func (m *Manager) handleAgentEvent(execution *AgentExecution, event agentctl.Age
 		m.eventPublisher.PublishAgentStreamEvent(execution, event)
 		return
 	}
+	if event.Type == streams.EventTypeResourceLimit {
+		m.handleResourceLimitEvent(execution, event)
+		return
+	}
 	if event.PromptGeneration == 0 || (event.Type != toolStatusComplete && event.Type != "error") {
 		m.recordActivity(execution, event)
 	}
@@ -17,6 +17,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/executor"
 	"github.com/kandev/kandev/internal/agent/runtime/activity"
 	"github.com/kandev/kandev/internal/agent/settings/cliflags"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentruntime"
 	"github.com/kandev/kandev/internal/common/subproc"
 	"github.com/kandev/kandev/internal/events"
@@ -876,6 +877,7 @@ func (m *Manager) launchBuildExecutorRequest(ctx context.Context, executionID st
 		McpMode:                        reqWithWorktree.McpMode,
 		McpProviders:                   reqWithWorktree.McpProviders,
 		McpProfile:                     reqWithWorktree.McpProfile,
+		PermissionPolicy:               launchPermissionPolicy(reqWithWorktree, profileInfo),
 		AuthToken:                      m.revealRuntimeSecret(ctx, metadata, MetadataKeyAuthTokenSecret),
 		BootstrapNonce:                 m.revealRuntimeSecret(ctx, metadata, MetadataKeyBootstrapNonceSecret),
 		AgentctlStartupConfig:          m.agentctlStartupConfig,
@@ -1237,6 +1239,9 @@ func (m *Manager) promoteWorkspaceExecution(ctx context.Context, execution *Agen
 		if err != nil {
 			return nil, err
 		}
+		if err := m.pushLaunchPermissionPolicy(sharedCtx, execution, req, profileInfo); err != nil {
+			return nil, err
+		}
 		agentConfig, ok := m.registry.Get(agentTypeName)
 		if !ok {
 			return nil, fmt.Errorf("agent type %q not found in registry", agentTypeName)
@@ -1823,6 +1828,65 @@ func (m *Manager) SetMcpProvidersForSession(ctx context.Context, sessionID strin
 	return nil
 }
 
+// SetPermissionPolicyForSession replaces the permission rules of a live
+// session's agentctl with the given workflow step rules followed by the rules
+// of the session's agent profile. A nil step policy leaves only the profile's
+// rules. It is a no-op when the session has no execution.
+func (m *Manager) SetPermissionPolicyForSession(ctx context.Context, sessionID string, stepPolicy *permpolicy.Policy) error {
+	if sessionID == "" {
+		return fmt.Errorf("session_id is required")
+	}
+	execution, exists := m.GetExecutionBySessionID(sessionID)
+	if !exists || execution == nil {
+		m.logger.Debug("permission policy refresh skipped: no execution for session",
+			zap.String("session_id", sessionID))
+		return nil
+	}
+	if execution.agentctl == nil {
+		return fmt.Errorf("execution %q has no agentctl client", execution.ID)
+	}
+	var profileInfo *AgentProfileInfo
+	if execution.AgentProfileID != "" && m.profileResolver != nil {
+		info, err := m.profileResolver.ResolveProfile(ctx, execution.AgentProfileID)
+		if err != nil {
+			return fmt.Errorf("resolve profile for permission policy: %w", err)
+		}
+		profileInfo = info
+	}
+	policy := launchPermissionPolicy(&LaunchRequest{StepPermissionPolicy: stepPolicy}, profileInfo)
+	if err := execution.agentctl.SetPermissionPolicy(ctx, policy); err != nil {
+		return fmt.Errorf("set permission policy for session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// launchPermissionPolicy combines the workflow step's rules with the agent
+// profile's rules. Step rules come first so their names win ties.
+func launchPermissionPolicy(req *LaunchRequest, profileInfo *AgentProfileInfo) *permpolicy.Policy {
+	var profilePolicy *permpolicy.Policy
+	if profileInfo != nil {
+		profilePolicy = profileInfo.PermissionPolicy
+	}
+	return permpolicy.Combine(req.StepPermissionPolicy, profilePolicy)
+}
+
+// pushLaunchPermissionPolicy sends the launch's permission rules to the
+// agentctl of a workspace-only execution, which was created before the
+// agent profile was known.
+func (m *Manager) pushLaunchPermissionPolicy(ctx context.Context, execution *AgentExecution, req *LaunchRequest, profileInfo *AgentProfileInfo) error {
+	policy := launchPermissionPolicy(req, profileInfo)
+	if policy.IsEmpty() {
+		return nil
+	}
+	if execution.agentctl == nil {
+		return fmt.Errorf("execution %q has no agentctl client for permission policy promotion", execution.ID)
+	}
+	if err := execution.agentctl.SetPermissionPolicy(ctx, policy); err != nil {
+		return fmt.Errorf("set permission policy during workspace execution promotion: %w", err)
+	}
+	return nil
+}
+
 // SetPluginToolsForAllExecutions pushes a complete revisioned catalog to each
 // live agentctl. One unavailable execution does not prevent the others from
 // converging; stale delivery is rejected by agentctl's snapshot revision.
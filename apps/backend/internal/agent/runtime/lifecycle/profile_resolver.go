@@ -94,6 +94,7 @@ func (r *StoreProfileResolver) ResolveProfile(ctx context.Context, profileID str
 		CLIFlags:                   profile.CLIFlags,
 		CommandPrefix:              profile.CommandPrefix,
 		EnvVars:                    profile.EnvVars,
+		PermissionPolicy:           profile.PermissionPolicy,
 		CLIPassthrough:             profile.CLIPassthrough,
 		NativeSessionResume:        nativeSessionResume,
 		SupportsMCP:                agent.SupportsMCP,
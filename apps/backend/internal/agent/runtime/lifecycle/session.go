@@ -1512,6 +1512,10 @@ func (sm *SessionManager) triggerPrompt(
 	promptGeneration uint64,
 	steer bool,
 ) error {
+	if !steer {
+		sm.seedForkWorkspace(ctx, execution)
+		sm.captureTurnCheckpoint(ctx, execution)
+	}
 	err := sm.dispatchPrompt(ctx, execution, prompt, attachments, promptGeneration, steer)
 	if err == nil {
 		return nil
@@ -0,0 +1,77 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+)
+
+// turnCheckpointTimeout bounds the workspace snapshot taken before a turn.
+// A checkpoint is a convenience for rewinding; it must never hold up the
+// prompt for long.
+const turnCheckpointTimeout = 10 * time.Second
+
+// MetadataKeyForkCheckpoint names the checkpoint a forked session's workspace
+// is seeded from before its first prompt. It arrives through the task
+// metadata (models.MetaKeyForkCheckpoint).
+const MetadataKeyForkCheckpoint = "fork_checkpoint_id"
+
+// captureTurnCheckpoint snapshots the workspace before a prompt starts a new
+// turn, keyed by the turn ID, so the session can later be rewound to this
+// point. Failures are logged and the prompt proceeds without a checkpoint.
+func (sm *SessionManager) captureTurnCheckpoint(ctx context.Context, execution *AgentExecution) {
+	turnID := execution.promptTurnIDSnapshot()
+	if turnID == "" || execution.agentctl == nil {
+		return
+	}
+	checkpointCtx, cancel := context.WithTimeout(ctx, turnCheckpointTimeout)
+	defer cancel()
+	if _, err := execution.agentctl.CreateCheckpoint(checkpointCtx, turnID); err != nil {
+		log := sm.logger.Warn
+		if errors.Is(err, agentctl.ErrCheckpointUnavailable) {
+			log = sm.logger.Debug
+		}
+		log("failed to capture workspace checkpoint",
+			zap.String("execution_id", execution.ID),
+			zap.String("turn_id", turnID),
+			zap.Error(err))
+	}
+}
+
+// seedForkWorkspace applies the checkpoint a forked session was created from
+// to its workspace, so the agent starts where the source session was. It only
+// works when the fork's workspace shares a repository with the source, as
+// sibling worktrees do; otherwise the fork starts from its base branch. The
+// attempt is made once per execution and failures are logged.
+func (sm *SessionManager) seedForkWorkspace(ctx context.Context, execution *AgentExecution) {
+	checkpointID := execution.metadataString(MetadataKeyForkCheckpoint)
+	if checkpointID == "" || execution.agentctl == nil {
+		return
+	}
+	execution.deleteMetadataValues(MetadataKeyForkCheckpoint)
+	seedCtx, cancel := context.WithTimeout(ctx, turnCheckpointTimeout)
+	defer cancel()
+	_, err := execution.agentctl.ApplyCheckpoint(seedCtx, checkpointID)
+	switch {
+	case err == nil:
+		sm.logger.Info("seeded forked workspace from checkpoint",
+			zap.String("execution_id", execution.ID),
+			zap.String("checkpoint_id", checkpointID))
+	case errors.Is(err, agentctl.ErrCheckpointNotFound),
+		errors.Is(err, agentctl.ErrCheckpointSameWorkspace),
+		errors.Is(err, agentctl.ErrCheckpointUnavailable):
+		sm.logger.Info("forked workspace not seeded",
+			zap.String("execution_id", execution.ID),
+			zap.String("checkpoint_id", checkpointID),
+			zap.Error(err))
+	default:
+		sm.logger.Warn("failed to seed forked workspace",
+			zap.String("execution_id", execution.ID),
+			zap.String("checkpoint_id", checkpointID),
+			zap.Error(err))
+	}
+}
@@ -14,6 +14,7 @@ import (
 	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
 	runtimeenv "github.com/kandev/kandev/internal/agent/runtime/environment"
 	settingsmodels "github.com/kandev/kandev/internal/agent/settings/models"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentctl/types/streams"
 	"github.com/kandev/kandev/internal/agentruntime"
 	"github.com/kandev/kandev/internal/common/ports"
@@ -876,6 +877,9 @@ type LaunchRequest struct {
 	McpMode             string            // MCP tool mode: "task" (default), "config", or "office"
 	McpProviders        []string          // Normalized provider capabilities attached to the task
 	McpProfile          *mcpprofile.Context
+	// StepPermissionPolicy holds the permission rules of the workflow step
+	// the session runs in. They are evaluated ahead of the profile's rules.
+	StepPermissionPolicy *permpolicy.Policy
 
 	// Environment preparation
 	SetupScript string // Setup script to run before agent starts
@@ -1011,6 +1015,8 @@ type AgentProfileInfo struct {
 	CommandPrefix string
 	// EnvVars are user-configured environment variables for this profile.
 	EnvVars []settingsmodels.ProfileEnvVar
+	// PermissionPolicy holds the profile's allow/deny/ask permission rules.
+	PermissionPolicy *permpolicy.Policy
 
 	// Deprecated: legacy permission fields, no longer consulted by the launch
 	// path. Kept so existing call sites compile during the transition.
@@ -0,0 +1,494 @@
+package lifecycle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agent/executor"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+const (
+	defaultWarmPoolMaxIdle = 30 * time.Minute
+	maxWarmPoolSize        = 8
+	warmPoolReapInterval   = time.Minute
+	warmPoolDiscardTimeout = time.Minute
+	warmClaimScriptTimeout = 2 * time.Minute
+)
+
+// warmBaseRefreshScript fast-forwards a warm clone to the current tip of the
+// base branch, which may have moved since the clone was made.
+const warmBaseRefreshScript = `
+# ---- kandev-managed: refresh the warm clone's base branch ----
+(
+  cd {{workspace.path}} 2>/dev/null && [ -d .git ] && [ -n {{repository.branch}} ] || exit 0
+  git fetch origin {{repository.branch}} && git merge --ff-only FETCH_HEAD
+) >/dev/null 2>&1 || true
+`
+
+// errWarmInstanceUnfit is returned by claimWarm when a healthy warm instance
+// cannot serve this particular request. The pool keeps the instance.
+var errWarmInstanceUnfit = errors.New("warm instance does not fit the request")
+
+// warmKeyMetadataKeys are the request metadata that shape an instance before
+// the agent starts: image, clone, setup script and seeded agent config.
+var warmKeyMetadataKeys = []string{
+	MetadataKeyExecutorProfileID,
+	MetadataKeyImageTagOverride,
+	MetadataKeyDevcontainer,
+	MetadataKeyRepositoryPath,
+	"repository_clone_url",
+	MetadataKeyBaseBranch,
+	MetadataKeyBaseBranches,
+	MetadataKeySetupScript,
+	MetadataKeyRepoSetupScript,
+	MetadataKeyGitUserName,
+	MetadataKeyGitUserEmail,
+	MetadataKeyAgentConfigBundles,
+}
+
+// warmRequestKey hashes everything a warm instance of runtime name was built
+// from, plus the executor's own extra values. Env values are left out so
+// the key never carries secrets; only the key set counts. Setup scripts that
+// depend on the task's branch, requests using a per-session credential
+// broker lease and profiles with an egress policy (whose proxy route is per
+// task) cannot be pooled.
+func warmRequestKey(name executor.Name, req *ExecutorCreateRequest, extra ...string) string {
+	if req.AgentConfig == nil || hasManagedGitCredentialBrokerEnv(req.Env) ||
+		getMetadataString(req.Metadata, MetadataKeyEgressPolicy) != "" {
+		return ""
+	}
+	setup := getMetadataString(req.Metadata, MetadataKeySetupScript) +
+		getMetadataString(req.Metadata, MetadataKeyRepoSetupScript)
+	if strings.Contains(setup, "{{worktree.branch}}") || strings.Contains(setup, "{{worktree.id}}") {
+		return ""
+	}
+
+	h := sha256.New()
+	write := func(values ...string) {
+		for _, v := range values {
+			h.Write([]byte(v))
+			h.Write([]byte{0})
+		}
+	}
+	write(string(name), req.AgentConfig.ID(), req.Protocol, fmt.Sprintf("%+v", req.AgentctlStartupConfig))
+	for _, key := range warmKeyMetadataKeys {
+		write(key, fmt.Sprint(req.Metadata[key]))
+	}
+	envKeys := make([]string, 0, len(req.Env))
+	for key := range req.Env {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+	write(envKeys...)
+	write(extra...)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// warmPoolSettings is a profile's warm pool configuration.
+type warmPoolSettings struct {
+	size         int
+	maxIdle      time.Duration
+	repositories []string
+}
+
+// warmPoolSettingsFromMetadata reads the pool settings from the profile
+// config in metadata. ok is false when the profile keeps no pool.
+func warmPoolSettingsFromMetadata(metadata map[string]interface{}) (warmPoolSettings, bool) {
+	size, err := strconv.Atoi(strings.TrimSpace(getMetadataString(metadata, MetadataKeyWarmPoolSize)))
+	if err != nil || size <= 0 {
+		return warmPoolSettings{}, false
+	}
+	settings := warmPoolSettings{size: min(size, maxWarmPoolSize), maxIdle: defaultWarmPoolMaxIdle}
+	maxIdle := strings.TrimSpace(getMetadataString(metadata, MetadataKeyWarmPoolMaxIdle))
+	if d, err := time.ParseDuration(maxIdle); err == nil && d > 0 {
+		settings.maxIdle = d
+	}
+	for _, repo := range strings.Split(getMetadataString(metadata, MetadataKeyWarmPoolRepositories), ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			settings.repositories = append(settings.repositories, repo)
+		}
+	}
+	return settings, true
+}
+
+// targets reports whether the repository in metadata is pooled. An entry
+// matches the repository path, its base name, the clone URL, or the
+// trailing "owner/repo" of the clone URL. No entries pools everything.
+func (s warmPoolSettings) targets(metadata map[string]interface{}) bool {
+	if len(s.repositories) == 0 {
+		return true
+	}
+	repoPath := getMetadataString(metadata, MetadataKeyRepositoryPath)
+	cloneURL := strings.TrimSuffix(getMetadataString(metadata, "repository_clone_url"), ".git")
+	for _, repo := range s.repositories {
+		repo = strings.TrimSuffix(repo, ".git")
+		switch {
+		case repoPath != "" && (repo == repoPath || repo == path.Base(repoPath)):
+			return true
+		case cloneURL != "" && (repo == cloneURL || strings.HasSuffix(cloneURL, "/"+repo)):
+			return true
+		}
+	}
+	return false
+}
+
+// warmInstance is an idle, pre-provisioned instance waiting to be claimed.
+type warmInstance struct {
+	id        string
+	key       string
+	expiresAt time.Time
+
+	// template is the request the instance was provisioned from. Remote
+	// executors reach the instance again through it (host, API token).
+	// Instances adopted after a restart have none.
+	template *ExecutorCreateRequest
+
+	containerID    string
+	bootstrapNonce string
+	remoteDir      string
+	spriteName     string
+}
+
+// warmProvisioner is implemented by executors that can keep pre-provisioned
+// instances. The pool does the bookkeeping; the executor owns the runtime.
+type warmProvisioner interface {
+	// warmKey identifies which warm instances can serve req, or returns ""
+	// when req cannot be served from a pool.
+	warmKey(req *ExecutorCreateRequest) string
+	// provisionWarm starts an idle instance for template and records its
+	// runtime handles on warm.
+	provisionWarm(ctx context.Context, warm *warmInstance, template *ExecutorCreateRequest) error
+	// claimWarm binds warm to req and returns the running instance. It
+	// returns errWarmInstanceUnfit to leave warm in the pool.
+	claimWarm(ctx context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error)
+	discardWarm(ctx context.Context, warm *warmInstance)
+}
+
+type warmPoolTarget struct {
+	template *ExecutorCreateRequest
+	settings warmPoolSettings
+}
+
+// warmPool keeps idle instances per compatibility key. Requests for a key the
+// pool has seen before are recorded as its template; replenishing provisions
+// from that template in the background. Taking an instance is atomic under mu,
+// so two launches never claim the same one.
+type warmPool struct {
+	provisioner warmProvisioner
+	logger      *logger.Logger
+	now         func() time.Time
+
+	mu      sync.Mutex
+	idle    map[string][]*warmInstance
+	pending map[string]int
+	targets map[string]warmPoolTarget
+	reaping bool
+	closed  bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWarmPool(provisioner warmProvisioner, log *logger.Logger) *warmPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &warmPool{
+		provisioner: provisioner,
+		logger:      log.WithFields(zap.String("component", "warm-pool")),
+		now:         time.Now,
+		idle:        make(map[string][]*warmInstance),
+		pending:     make(map[string]int),
+		targets:     make(map[string]warmPoolTarget),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// claim returns a warm instance bound to req, or nil when the profile keeps
+// no pool or none is ready; the caller then launches normally. Either way
+// the pool is topped up again in the background.
+func (p *warmPool) claim(ctx context.Context, req *ExecutorCreateRequest) *ExecutorInstance {
+	if p == nil {
+		return nil
+	}
+	key := p.provisioner.warmKey(req)
+	if key == "" {
+		return nil
+	}
+	settings, ok := warmPoolSettingsFromMetadata(req.Metadata)
+	if !ok || !settings.targets(req.Metadata) {
+		p.drain(key)
+		return nil
+	}
+	p.remember(key, req, settings)
+	defer p.replenish(key)
+
+	warm := p.take(key)
+	if warm == nil {
+		return nil
+	}
+	instance, err := p.provisioner.claimWarm(ctx, warm, req)
+	if errors.Is(err, errWarmInstanceUnfit) {
+		p.restore(warm)
+		return nil
+	}
+	if err != nil {
+		p.logger.Warn("failed to claim warm instance, launching a new one",
+			zap.String("warm_instance_id", warm.id),
+			zap.String("instance_id", req.InstanceID),
+			zap.Error(err))
+		p.discard(warm)
+		return nil
+	}
+	p.logger.Info("claimed warm instance",
+		zap.String("warm_instance_id", warm.id),
+		zap.String("instance_id", req.InstanceID))
+	return instance
+}
+
+// adopt adds a warm instance that survived a backend restart. It is served
+// to matching launches, but the pool only replenishes its key once a launch
+// has supplied a template.
+func (p *warmPool) adopt(warm *warmInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || !warm.expiresAt.After(p.now()) {
+		p.discardLocked(warm)
+		return
+	}
+	p.idle[warm.key] = append(p.idle[warm.key], warm)
+	p.startReaperLocked()
+}
+
+// close stops provisioning and reaping. Idle instances are left running so
+// the next backend process can adopt them.
+func (p *warmPool) close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.cancel()
+	p.wg.Wait()
+}
+
+// closeAndDiscard is close for executors that cannot adopt instances after
+// a restart: idle instances are torn down instead of left running.
+func (p *warmPool) closeAndDiscard() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	for key, instances := range p.idle {
+		for _, warm := range instances {
+			p.discardLocked(warm)
+		}
+		delete(p.idle, key)
+	}
+	p.closed = true
+	p.mu.Unlock()
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *warmPool) remember(key string, req *ExecutorCreateRequest, settings warmPoolSettings) {
+	template := warmTemplate(req)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.targets[key] = warmPoolTarget{template: template, settings: settings}
+}
+
+// drain forgets key and discards its idle instances, e.g. after the profile
+// turned its pool off.
+func (p *warmPool) drain(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.targets[key]; !ok && len(p.idle[key]) == 0 {
+		return
+	}
+	delete(p.targets, key)
+	for _, warm := range p.idle[key] {
+		p.discardLocked(warm)
+	}
+	delete(p.idle, key)
+}
+
+// take removes and returns the first unexpired idle instance for key.
+func (p *warmPool) take(key string) *warmInstance {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for len(p.idle[key]) > 0 {
+		warm := p.idle[key][0]
+		p.idle[key] = p.idle[key][1:]
+		if warm.expiresAt.After(now) {
+			return warm
+		}
+		p.discardLocked(warm)
+	}
+	return nil
+}
+
+// restore puts an unclaimed instance back at the front of its key's queue.
+func (p *warmPool) restore(warm *warmInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		p.discardLocked(warm)
+		return
+	}
+	p.idle[warm.key] = append([]*warmInstance{warm}, p.idle[warm.key]...)
+}
+
+// replenish provisions instances until key has its configured number idle
+// or in flight.
+func (p *warmPool) replenish(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replenishLocked(key)
+}
+
+func (p *warmPool) replenishLocked(key string) {
+	target, ok := p.targets[key]
+	if !ok || p.closed {
+		return
+	}
+	for n := target.settings.size - len(p.idle[key]) - p.pending[key]; n > 0; n-- {
+		p.pending[key]++
+		warm := &warmInstance{
+			id:        uuid.New().String(),
+			key:       key,
+			expiresAt: p.now().Add(target.settings.maxIdle),
+			template:  target.template,
+		}
+		p.wg.Add(1)
+		go p.provision(warm, target.template)
+	}
+	p.startReaperLocked()
+}
+
+func (p *warmPool) provision(warm *warmInstance, template *ExecutorCreateRequest) {
+	defer p.wg.Done()
+	err := p.provisioner.provisionWarm(p.ctx, warm, template)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending[warm.key]--
+	if err != nil {
+		// No retry here: the next launch for this key tries again, so a
+		// broken template cannot spin the pool.
+		p.logger.Warn("failed to provision warm instance",
+			zap.String("warm_instance_id", warm.id), zap.Error(err))
+		return
+	}
+	if _, ok := p.targets[warm.key]; !ok || p.closed {
+		p.discardLocked(warm)
+		return
+	}
+	p.idle[warm.key] = append(p.idle[warm.key], warm)
+	p.logger.Info("warm instance ready", zap.String("warm_instance_id", warm.id))
+}
+
+func (p *warmPool) startReaperLocked() {
+	if p.reaping || p.closed {
+		return
+	}
+	p.reaping = true
+	p.wg.Add(1)
+	go p.reapLoop()
+}
+
+func (p *warmPool) reapLoop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(warmPoolReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.reap()
+		}
+	}
+}
+
+// reap discards expired idle instances and provisions their replacements,
+// so an idle clone is never older than the profile's max idle age.
+func (p *warmPool) reap() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for key, instances := range p.idle {
+		kept := instances[:0]
+		for _, warm := range instances {
+			if warm.expiresAt.After(now) {
+				kept = append(kept, warm)
+			} else {
+				p.discardLocked(warm)
+			}
+		}
+		p.idle[key] = kept
+		p.replenishLocked(key)
+	}
+}
+
+func (p *warmPool) discard(warm *warmInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.discardLocked(warm)
+}
+
+// discardLocked tears warm down in the background. close waits for teardowns
+// started before it so a shutdown does not leave a half-removed container.
+func (p *warmPool) discardLocked(warm *warmInstance) {
+	discard := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), warmPoolDiscardTimeout)
+		defer cancel()
+		p.provisioner.discardWarm(ctx, warm)
+	}
+	if p.closed {
+		go discard()
+		return
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		discard()
+	}()
+}
+
+// warmTemplate copies the parts of req that describe the environment rather
+// than the task, so provisioning from it clones the base branch, runs the
+// setup script and leaves the per-task steps to the claim.
+func warmTemplate(req *ExecutorCreateRequest) *ExecutorCreateRequest {
+	metadata := make(map[string]interface{}, len(req.Metadata))
+	for key, value := range req.Metadata {
+		switch key {
+		case MetadataKeyWorktreeID, MetadataKeyWorktreeBranch, MetadataKeyContainerID,
+			MetadataKeyRemoteContributions, MetadataKeyContributionDestinations, MetadataKeyComparisonTargets:
+			continue
+		}
+		metadata[key] = value
+	}
+	return &ExecutorCreateRequest{
+		Protocol:              req.Protocol,
+		AgentConfig:           req.AgentConfig,
+		Env:                   cloneStringMap(req.Env),
+		Metadata:              metadata,
+		AgentctlStartupConfig: req.AgentctlStartupConfig,
+	}
+}
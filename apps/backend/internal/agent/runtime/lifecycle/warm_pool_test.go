@@ -0,0 +1,293 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+type fakeWarmProvisioner struct {
+	key string
+
+	mu          sync.Mutex
+	provisioned []*warmInstance
+	claimed     []*warmInstance
+	discarded   []*warmInstance
+	claimErr    error
+}
+
+func (f *fakeWarmProvisioner) warmKey(*ExecutorCreateRequest) string { return f.key }
+
+func (f *fakeWarmProvisioner) provisionWarm(_ context.Context, warm *warmInstance, _ *ExecutorCreateRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	warm.containerID = "ctr-" + warm.id
+	f.provisioned = append(f.provisioned, warm)
+	return nil
+}
+
+func (f *fakeWarmProvisioner) claimWarm(_ context.Context, warm *warmInstance, req *ExecutorCreateRequest) (*ExecutorInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.claimErr != nil {
+		return nil, f.claimErr
+	}
+	f.claimed = append(f.claimed, warm)
+	return &ExecutorInstance{InstanceID: req.InstanceID, ContainerID: warm.containerID}, nil
+}
+
+func (f *fakeWarmProvisioner) discardWarm(_ context.Context, warm *warmInstance) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.discarded = append(f.discarded, warm)
+}
+
+func (f *fakeWarmProvisioner) counts() (provisioned, claimed, discarded int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.provisioned), len(f.claimed), len(f.discarded)
+}
+
+func newTestWarmPool(t *testing.T, provisioner *fakeWarmProvisioner) *warmPool {
+	t.Helper()
+	log, _ := logger.NewLogger(logger.LoggingConfig{Level: "error", Format: "json"})
+	p := newWarmPool(provisioner, log)
+	t.Cleanup(p.close)
+	return p
+}
+
+func waitWarmIdle(t *testing.T, p *warmPool, key string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		p.mu.Lock()
+		got := len(p.idle[key])
+		p.mu.Unlock()
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("idle instances = %d, want %d", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func waitWarmDiscarded(t *testing.T, provisioner *fakeWarmProvisioner, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, _, got := provisioner.counts()
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("discarded = %d, want %d", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func warmPoolRequest(instanceID string, metadata map[string]interface{}) *ExecutorCreateRequest {
+	return &ExecutorCreateRequest{InstanceID: instanceID, Metadata: metadata}
+}
+
+func TestWarmPoolSettingsFromMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		wantOK   bool
+		want     warmPoolSettings
+	}{
+		{name: "unset", metadata: map[string]interface{}{}},
+		{name: "zero", metadata: map[string]interface{}{MetadataKeyWarmPoolSize: "0"}},
+		{name: "invalid", metadata: map[string]interface{}{MetadataKeyWarmPoolSize: "two"}},
+		{
+			name:     "defaults",
+			metadata: map[string]interface{}{MetadataKeyWarmPoolSize: "2"},
+			wantOK:   true,
+			want:     warmPoolSettings{size: 2, maxIdle: defaultWarmPoolMaxIdle},
+		},
+		{
+			name: "capped size and custom idle",
+			metadata: map[string]interface{}{
+				MetadataKeyWarmPoolSize:         "100",
+				MetadataKeyWarmPoolMaxIdle:      "10m",
+				MetadataKeyWarmPoolRepositories: " kandev , acme/api ,",
+			},
+			wantOK: true,
+			want:   warmPoolSettings{size: maxWarmPoolSize, maxIdle: 10 * time.Minute, repositories: []string{"kandev", "acme/api"}},
+		},
+		{
+			name:     "bad idle falls back",
+			metadata: map[string]interface{}{MetadataKeyWarmPoolSize: "1", MetadataKeyWarmPoolMaxIdle: "-5m"},
+			wantOK:   true,
+			want:     warmPoolSettings{size: 1, maxIdle: defaultWarmPoolMaxIdle},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := warmPoolSettingsFromMetadata(tt.metadata)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.size != tt.want.size || got.maxIdle != tt.want.maxIdle || len(got.repositories) != len(tt.want.repositories) {
+				t.Fatalf("settings = %+v, want %+v", got, tt.want)
+			}
+			for i := range got.repositories {
+				if got.repositories[i] != tt.want.repositories[i] {
+					t.Fatalf("repositories = %v, want %v", got.repositories, tt.want.repositories)
+				}
+			}
+		})
+	}
+}
+
+func TestWarmPoolSettingsTargets(t *testing.T) {
+	metadata := map[string]interface{}{
+		MetadataKeyRepositoryPath: "/home/me/src/kandev",
+		"repository_clone_url":    "https://github.com/kdlbs/kandev.git",
+	}
+	for _, tt := range []struct {
+		repos []string
+		want  bool
+	}{
+		{repos: nil, want: true},
+		{repos: []string{"kandev"}, want: true},
+		{repos: []string{"/home/me/src/kandev"}, want: true},
+		{repos: []string{"kdlbs/kandev"}, want: true},
+		{repos: []string{"https://github.com/kdlbs/kandev"}, want: true},
+		{repos: []string{"other", "kdlbs/kandev.git"}, want: true},
+		{repos: []string{"andev"}, want: false},
+		{repos: []string{"other"}, want: false},
+	} {
+		settings := warmPoolSettings{repositories: tt.repos}
+		if got := settings.targets(metadata); got != tt.want {
+			t.Errorf("targets(%v) = %v, want %v", tt.repos, got, tt.want)
+		}
+	}
+}
+
+func TestWarmPool_ClaimMissReplenishesThenHits(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k"}
+	p := newTestWarmPool(t, provisioner)
+	metadata := map[string]interface{}{MetadataKeyWarmPoolSize: "2", MetadataKeyWorktreeBranch: "feature/x"}
+
+	if got := p.claim(context.Background(), warmPoolRequest("inst-1", metadata)); got != nil {
+		t.Fatalf("first claim = %+v, want miss", got)
+	}
+	waitWarmIdle(t, p, "k", 2)
+
+	got := p.claim(context.Background(), warmPoolRequest("inst-2", metadata))
+	if got == nil || got.InstanceID != "inst-2" {
+		t.Fatalf("second claim = %+v, want instance inst-2", got)
+	}
+	waitWarmIdle(t, p, "k", 2)
+	if provisioned, claimed, _ := provisioner.counts(); provisioned != 3 || claimed != 1 {
+		t.Fatalf("provisioned=%d claimed=%d, want 3 and 1", provisioned, claimed)
+	}
+
+	p.mu.Lock()
+	template := p.targets["k"].template
+	p.mu.Unlock()
+	if template.InstanceID != "" || template.Metadata[MetadataKeyWorktreeBranch] != nil {
+		t.Fatalf("template carries task state: %+v", template)
+	}
+}
+
+func TestWarmPool_TakeSkipsExpired(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k"}
+	p := newTestWarmPool(t, provisioner)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	p.idle["k"] = []*warmInstance{
+		{id: "old", key: "k", expiresAt: now.Add(-time.Second)},
+		{id: "fresh", key: "k", expiresAt: now.Add(time.Minute)},
+	}
+
+	if warm := p.take("k"); warm == nil || warm.id != "fresh" {
+		t.Fatalf("take = %+v, want fresh", warm)
+	}
+	if warm := p.take("k"); warm != nil {
+		t.Fatalf("take on empty pool = %+v, want nil", warm)
+	}
+	waitWarmDiscarded(t, provisioner, 1)
+}
+
+func TestWarmPool_ClaimFailureDiscardsAndMisses(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k", claimErr: errors.New("agentctl gone")}
+	p := newTestWarmPool(t, provisioner)
+	p.idle["k"] = []*warmInstance{{id: "w1", key: "k", expiresAt: time.Now().Add(time.Minute)}}
+
+	metadata := map[string]interface{}{MetadataKeyWarmPoolSize: "1"}
+	if got := p.claim(context.Background(), warmPoolRequest("inst-1", metadata)); got != nil {
+		t.Fatalf("claim = %+v, want fallback nil", got)
+	}
+	waitWarmIdle(t, p, "k", 1)
+	waitWarmDiscarded(t, provisioner, 1)
+}
+
+func TestWarmPool_DisabledProfileDrains(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k"}
+	p := newTestWarmPool(t, provisioner)
+	p.claim(context.Background(), warmPoolRequest("inst-1", map[string]interface{}{MetadataKeyWarmPoolSize: "1"}))
+	waitWarmIdle(t, p, "k", 1)
+
+	if got := p.claim(context.Background(), warmPoolRequest("inst-2", map[string]interface{}{MetadataKeyWarmPoolSize: "0"})); got != nil {
+		t.Fatalf("claim with pool off = %+v, want nil", got)
+	}
+	waitWarmIdle(t, p, "k", 0)
+	waitWarmDiscarded(t, provisioner, 1)
+	if _, claimed, _ := provisioner.counts(); claimed != 0 {
+		t.Fatalf("claimed = %d, want 0", claimed)
+	}
+}
+
+func TestWarmPool_AdoptDiscardsExpired(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k"}
+	p := newTestWarmPool(t, provisioner)
+	p.adopt(&warmInstance{id: "stale", key: "k", expiresAt: time.Now().Add(-time.Minute)})
+	p.adopt(&warmInstance{id: "live", key: "k", expiresAt: time.Now().Add(time.Minute)})
+
+	waitWarmIdle(t, p, "k", 1)
+	waitWarmDiscarded(t, provisioner, 1)
+}
+
+func TestWarmPool_UnfitClaimKeepsInstance(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k", claimErr: errWarmInstanceUnfit}
+	p := newTestWarmPool(t, provisioner)
+	p.idle["k"] = []*warmInstance{{id: "w1", key: "k", expiresAt: time.Now().Add(time.Minute)}}
+
+	metadata := map[string]interface{}{MetadataKeyWarmPoolSize: "1"}
+	if got := p.claim(context.Background(), warmPoolRequest("inst-1", metadata)); got != nil {
+		t.Fatalf("claim = %+v, want fallback nil", got)
+	}
+	waitWarmIdle(t, p, "k", 1)
+	if warm := p.take("k"); warm == nil || warm.id != "w1" {
+		t.Fatalf("idle instance = %+v, want w1 kept", warm)
+	}
+	if provisioned, _, discarded := provisioner.counts(); provisioned != 0 || discarded != 0 {
+		t.Fatalf("provisioned=%d discarded=%d, want the instance reused", provisioned, discarded)
+	}
+}
+
+func TestWarmPool_CloseAndDiscardTearsDownIdle(t *testing.T) {
+	provisioner := &fakeWarmProvisioner{key: "k"}
+	p := newTestWarmPool(t, provisioner)
+	p.claim(context.Background(), warmPoolRequest("inst-1", map[string]interface{}{MetadataKeyWarmPoolSize: "2"}))
+	waitWarmIdle(t, p, "k", 2)
+
+	p.closeAndDiscard()
+	if _, _, discarded := provisioner.counts(); discarded != 2 {
+		t.Fatalf("discarded = %d, want both idle instances", discarded)
+	}
+	for _, warm := range provisioner.discarded {
+		if warm.template == nil {
+			t.Fatalf("warm instance %s has no template to reach it with", warm.id)
+		}
+	}
+}
@@ -49,6 +49,7 @@ var (
 	ErrCommandRequired                      = errors.New("command is required")
 	ErrInvalidProfileEnvVars                = errors.New("invalid profile env vars")
 	ErrInvalidCommandPrefix                 = errors.New("invalid command prefix")
+	ErrInvalidPermissionPolicy              = errors.New("invalid permission policy")
 	ErrUnknownMCPStrategy                   = errors.New("unknown MCP strategy")
 	ErrNotCustomTUIAgent                    = errors.New("agent is not a custom TUI agent")
 	ErrDynamicAgentRoutingDisabled          = errors.New("dynamic agent routing is disabled")
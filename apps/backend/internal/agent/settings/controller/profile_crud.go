@@ -19,6 +19,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/settings/models"
 	"github.com/kandev/kandev/internal/agent/settings/profileconfig"
 	"github.com/kandev/kandev/internal/agent/settings/store"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/secrets"
 )
 
@@ -44,7 +45,9 @@ type CreateProfileRequest struct {
 	// CommandPrefix is an optional launcher prefix prepended to the agent
 	// command (e.g. "greywall --"). Shell-tokenised at launch time.
 	CommandPrefix string
-	Dynamic       *dto.DynamicAgentProfileDTO
+	// PermissionPolicy holds allow/deny/ask rules for permission requests.
+	PermissionPolicy *permpolicy.Policy
+	Dynamic          *dto.DynamicAgentProfileDTO
 }
 
 func (c *Controller) CreateProfile(ctx context.Context, req CreateProfileRequest) (*dto.AgentProfileDTO, error) {
@@ -81,6 +84,9 @@ func (c *Controller) CreateProfile(ctx context.Context, req CreateProfileRequest
 	if err := validateCommandPrefix(req.CommandPrefix); err != nil {
 		return nil, err
 	}
+	if err := validatePermissionPolicy(req.PermissionPolicy); err != nil {
+		return nil, err
+	}
 	profile := &models.AgentProfile{
 		AgentID:          req.AgentID,
 		Name:             req.Name,
@@ -97,6 +103,7 @@ func (c *Controller) CreateProfile(ctx context.Context, req CreateProfileRequest
 		CLIFlags:         cliFlags,
 		EnvVars:          envVarsFromDTO(req.EnvVars),
 		CommandPrefix:    strings.TrimSpace(req.CommandPrefix),
+		PermissionPolicy: normalizePermissionPolicy(req.PermissionPolicy),
 		UserModified:     true,
 	}
 	if err := c.repo.CreateAgentProfile(ctx, profile); err != nil {
@@ -341,8 +348,11 @@ type UpdateProfileRequest struct {
 	// CommandPrefix replaces the value when non-nil. Nil means "leave
 	// unchanged" — the UI always sends the desired value on save.
 	CommandPrefix *string
-	Dynamic       *dto.DynamicAgentProfileDTO
-	Force         bool
+	// PermissionPolicy replaces the rules when non-nil; a policy without
+	// rules clears them. Nil means "leave unchanged".
+	PermissionPolicy *permpolicy.Policy
+	Dynamic          *dto.DynamicAgentProfileDTO
+	Force            bool
 }
 
 func enabledOnlyUpdate(req UpdateProfileRequest) bool {
@@ -350,7 +360,7 @@ func enabledOnlyUpdate(req UpdateProfileRequest) bool {
 		req.FallbackModel == nil && req.AutoFallback == nil && req.Mode == nil &&
 		req.ConfigOptions == nil && req.AllowIndexing == nil && req.AutoApprove == nil &&
 		req.CLIPassthrough == nil && req.CLIFlags == nil && req.EnvVars == nil &&
-		req.CommandPrefix == nil && req.Dynamic == nil
+		req.CommandPrefix == nil && req.PermissionPolicy == nil && req.Dynamic == nil
 }
 
 func (c *Controller) UpdateProfile(ctx context.Context, req UpdateProfileRequest) (*dto.AgentProfileDTO, error) {
@@ -458,6 +468,12 @@ func (c *Controller) UpdateProfile(ctx context.Context, req UpdateProfileRequest
 		}
 		profile.CommandPrefix = strings.TrimSpace(*req.CommandPrefix)
 	}
+	if req.PermissionPolicy != nil {
+		if err := validatePermissionPolicy(req.PermissionPolicy); err != nil {
+			return nil, err
+		}
+		profile.PermissionPolicy = normalizePermissionPolicy(req.PermissionPolicy)
+	}
 	profile.UserModified = true
 	if dynamic != nil {
 		result, handled, atomicErr := c.updateDynamicProfileAtomically(
@@ -638,6 +654,7 @@ func duplicateClone(source *models.AgentProfile) *models.AgentProfile {
 		CLIFlags:                   cloneCLIFlags(source.CLIFlags),
 		EnvVars:                    cloneEnvVars(source.EnvVars),
 		CommandPrefix:              source.CommandPrefix,
+		PermissionPolicy:           clonePermissionPolicy(source.PermissionPolicy),
 		UserModified:               true,
 		Enabled:                    source.Enabled,
 		WorkspaceID:                source.WorkspaceID,
@@ -675,6 +692,17 @@ func cloneCLIFlags(in []models.CLIFlag) []models.CLIFlag {
 	return out
 }
 
+// clonePermissionPolicy returns a copy of the profile's permission rules so
+// the duplicated profile never shares slice memory with the source.
+func clonePermissionPolicy(in *permpolicy.Policy) *permpolicy.Policy {
+	if in.IsEmpty() {
+		return nil
+	}
+	rules := make([]permpolicy.Rule, len(in.Rules))
+	copy(rules, in.Rules)
+	return &permpolicy.Policy{Rules: rules}
+}
+
 // cloneEnvVars returns a copy of the profile's env-var list (secret
 // references included) so the duplicated profile never shares slice memory
 // with the source.
@@ -775,6 +803,22 @@ func validateCommandPrefix(prefix string) error {
 	return nil
 }
 
+func validatePermissionPolicy(policy *permpolicy.Policy) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPermissionPolicy, err)
+	}
+	return nil
+}
+
+// normalizePermissionPolicy stores a policy without rules as nil so an
+// emptied policy reads back the same as one that was never set.
+func normalizePermissionPolicy(policy *permpolicy.Policy) *permpolicy.Policy {
+	if policy.IsEmpty() {
+		return nil
+	}
+	return policy
+}
+
 func (c *Controller) DeleteProfile(ctx context.Context, id string, force bool) (*dto.AgentProfileDTO, error) {
 	profile, err := c.repo.GetAgentProfile(ctx, id)
 	if err != nil {
@@ -1136,6 +1180,7 @@ func toProfileDTO(profile *models.AgentProfile) dto.AgentProfileDTO {
 		CLIPassthrough:   profile.CLIPassthrough,
 		Enabled:          profile.Enabled,
 		CommandPrefix:    profile.CommandPrefix,
+		PermissionPolicy: profile.PermissionPolicy,
 		UserModified:     profile.UserModified,
 		WorkspaceID:      profile.WorkspaceID,
 		CreatedAt:        profile.CreatedAt,
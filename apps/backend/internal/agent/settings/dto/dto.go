@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/kandev/kandev/internal/agent/mcpconfig"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 )
 
 type AgentProfileDTO struct {
@@ -34,7 +35,10 @@ type AgentProfileDTO struct {
 	// CommandPrefix is an optional launcher prefix prepended to the agent
 	// command (e.g. "greywall --"). Shell-tokenised at launch time.
 	CommandPrefix string `json:"command_prefix,omitempty"`
-	UserModified  bool   `json:"user_modified"`
+	// PermissionPolicy holds the allow/deny/ask rules agentctl applies to
+	// the agent's permission requests before they reach the UI.
+	PermissionPolicy *permpolicy.Policy `json:"permission_policy,omitempty"`
+	UserModified     bool               `json:"user_modified"`
 	// WorkspaceID scopes the profile to an office workspace. Empty for
 	// shallow kanban-only profiles. Surfaced so consumers (e.g. test
 	// cleanup helpers) can distinguish office-owned profiles from
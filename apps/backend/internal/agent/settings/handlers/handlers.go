@@ -13,6 +13,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/mcpconfig"
 	"github.com/kandev/kandev/internal/agent/settings/controller"
 	"github.com/kandev/kandev/internal/agent/settings/dto"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/common/httpmw"
 	"github.com/kandev/kandev/internal/common/logger"
 	ws "github.com/kandev/kandev/pkg/websocket"
@@ -525,19 +526,20 @@ func (h *Handlers) httpUpdateProfileMcpConfig(c *gin.Context) {
 }
 
 type createProfileRequest struct {
-	Name           string                      `json:"name"`
-	Model          string                      `json:"model"`
-	FallbackModel  string                      `json:"fallback_model,omitempty"`
-	AutoFallback   bool                        `json:"auto_fallback"`
-	Mode           string                      `json:"mode,omitempty"`
-	ConfigOptions  map[string]string           `json:"config_options,omitempty"`
-	AllowIndexing  bool                        `json:"allow_indexing"`
-	AutoApprove    bool                        `json:"auto_approve"`
-	CLIPassthrough bool                        `json:"cli_passthrough"`
-	CLIFlags       []dto.CLIFlagDTO            `json:"cli_flags,omitempty"`
-	EnvVars        []dto.ProfileEnvVarDTO      `json:"env_vars,omitempty"`
-	CommandPrefix  string                      `json:"command_prefix,omitempty"`
-	Dynamic        *dto.DynamicAgentProfileDTO `json:"dynamic,omitempty"`
+	Name             string                      `json:"name"`
+	Model            string                      `json:"model"`
+	FallbackModel    string                      `json:"fallback_model,omitempty"`
+	AutoFallback     bool                        `json:"auto_fallback"`
+	Mode             string                      `json:"mode,omitempty"`
+	ConfigOptions    map[string]string           `json:"config_options,omitempty"`
+	AllowIndexing    bool                        `json:"allow_indexing"`
+	AutoApprove      bool                        `json:"auto_approve"`
+	CLIPassthrough   bool                        `json:"cli_passthrough"`
+	CLIFlags         []dto.CLIFlagDTO            `json:"cli_flags,omitempty"`
+	EnvVars          []dto.ProfileEnvVarDTO      `json:"env_vars,omitempty"`
+	CommandPrefix    string                      `json:"command_prefix,omitempty"`
+	PermissionPolicy *permpolicy.Policy          `json:"permission_policy,omitempty"`
+	Dynamic          *dto.DynamicAgentProfileDTO `json:"dynamic,omitempty"`
 }
 
 func (h *Handlers) httpCreateProfile(c *gin.Context) {
@@ -551,20 +553,21 @@ func (h *Handlers) httpCreateProfile(c *gin.Context) {
 		return
 	}
 	resp, err := h.controller.CreateProfile(c.Request.Context(), controller.CreateProfileRequest{
-		AgentID:        c.Param("id"),
-		Name:           body.Name,
-		Model:          body.Model,
-		FallbackModel:  body.FallbackModel,
-		AutoFallback:   body.AutoFallback,
-		Mode:           body.Mode,
-		ConfigOptions:  body.ConfigOptions,
-		AllowIndexing:  body.AllowIndexing,
-		AutoApprove:    body.AutoApprove,
-		CLIPassthrough: body.CLIPassthrough,
-		CLIFlags:       body.CLIFlags,
-		EnvVars:        body.EnvVars,
-		CommandPrefix:  body.CommandPrefix,
-		Dynamic:        body.Dynamic,
+		AgentID:          c.Param("id"),
+		Name:             body.Name,
+		Model:            body.Model,
+		FallbackModel:    body.FallbackModel,
+		AutoFallback:     body.AutoFallback,
+		Mode:             body.Mode,
+		ConfigOptions:    body.ConfigOptions,
+		AllowIndexing:    body.AllowIndexing,
+		AutoApprove:      body.AutoApprove,
+		CLIPassthrough:   body.CLIPassthrough,
+		CLIFlags:         body.CLIFlags,
+		EnvVars:          body.EnvVars,
+		CommandPrefix:    body.CommandPrefix,
+		PermissionPolicy: body.PermissionPolicy,
+		Dynamic:          body.Dynamic,
 	})
 	if err != nil {
 		if errors.Is(err, controller.ErrDynamicAgentRoutingDisabled) {
@@ -578,7 +581,8 @@ func (h *Handlers) httpCreateProfile(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if errors.Is(err, controller.ErrInvalidProfileEnvVars) || errors.Is(err, controller.ErrInvalidCommandPrefix) {
+		if errors.Is(err, controller.ErrInvalidProfileEnvVars) || errors.Is(err, controller.ErrInvalidCommandPrefix) ||
+			errors.Is(err, controller.ErrInvalidPermissionPolicy) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -591,20 +595,21 @@ func (h *Handlers) httpCreateProfile(c *gin.Context) {
 }
 
 type updateProfileRequest struct {
-	Name           *string                     `json:"name,omitempty"`
-	Model          *string                     `json:"model,omitempty"`
-	FallbackModel  *string                     `json:"fallback_model,omitempty"`
-	AutoFallback   *bool                       `json:"auto_fallback,omitempty"`
-	Mode           *string                     `json:"mode,omitempty"`
-	ConfigOptions  *map[string]string          `json:"config_options,omitempty"`
-	AllowIndexing  *bool                       `json:"allow_indexing,omitempty"`
-	AutoApprove    *bool                       `json:"auto_approve,omitempty"`
-	CLIPassthrough *bool                       `json:"cli_passthrough,omitempty"`
-	Enabled        *bool                       `json:"enabled,omitempty"`
-	CLIFlags       *[]dto.CLIFlagDTO           `json:"cli_flags,omitempty"`
-	EnvVars        *[]dto.ProfileEnvVarDTO     `json:"env_vars,omitempty"`
-	CommandPrefix  *string                     `json:"command_prefix,omitempty"`
-	Dynamic        *dto.DynamicAgentProfileDTO `json:"dynamic,omitempty"`
+	Name             *string                     `json:"name,omitempty"`
+	Model            *string                     `json:"model,omitempty"`
+	FallbackModel    *string                     `json:"fallback_model,omitempty"`
+	AutoFallback     *bool                       `json:"auto_fallback,omitempty"`
+	Mode             *string                     `json:"mode,omitempty"`
+	ConfigOptions    *map[string]string          `json:"config_options,omitempty"`
+	AllowIndexing    *bool                       `json:"allow_indexing,omitempty"`
+	AutoApprove      *bool                       `json:"auto_approve,omitempty"`
+	CLIPassthrough   *bool                       `json:"cli_passthrough,omitempty"`
+	Enabled          *bool                       `json:"enabled,omitempty"`
+	CLIFlags         *[]dto.CLIFlagDTO           `json:"cli_flags,omitempty"`
+	EnvVars          *[]dto.ProfileEnvVarDTO     `json:"env_vars,omitempty"`
+	CommandPrefix    *string                     `json:"command_prefix,omitempty"`
+	PermissionPolicy *permpolicy.Policy          `json:"permission_policy,omitempty"`
+	Dynamic          *dto.DynamicAgentProfileDTO `json:"dynamic,omitempty"`
 }
 
 func (h *Handlers) httpUpdateProfile(c *gin.Context) {
@@ -618,29 +623,31 @@ func (h *Handlers) httpUpdateProfile(c *gin.Context) {
 		return
 	}
 	resp, err := h.controller.UpdateProfile(c.Request.Context(), controller.UpdateProfileRequest{
-		ID:             c.Param("id"),
-		Name:           body.Name,
-		Model:          body.Model,
-		FallbackModel:  body.FallbackModel,
-		AutoFallback:   body.AutoFallback,
-		Mode:           body.Mode,
-		ConfigOptions:  body.ConfigOptions,
-		AllowIndexing:  body.AllowIndexing,
-		AutoApprove:    body.AutoApprove,
-		CLIPassthrough: body.CLIPassthrough,
-		Enabled:        body.Enabled,
-		CLIFlags:       body.CLIFlags,
-		EnvVars:        body.EnvVars,
-		CommandPrefix:  body.CommandPrefix,
-		Dynamic:        body.Dynamic,
-		Force:          c.Query("force") == queryTrue,
+		ID:               c.Param("id"),
+		Name:             body.Name,
+		Model:            body.Model,
+		FallbackModel:    body.FallbackModel,
+		AutoFallback:     body.AutoFallback,
+		Mode:             body.Mode,
+		ConfigOptions:    body.ConfigOptions,
+		AllowIndexing:    body.AllowIndexing,
+		AutoApprove:      body.AutoApprove,
+		CLIPassthrough:   body.CLIPassthrough,
+		Enabled:          body.Enabled,
+		CLIFlags:         body.CLIFlags,
+		EnvVars:          body.EnvVars,
+		CommandPrefix:    body.CommandPrefix,
+		PermissionPolicy: body.PermissionPolicy,
+		Dynamic:          body.Dynamic,
+		Force:            c.Query("force") == queryTrue,
 	})
 	if err != nil {
 		if err == controller.ErrAgentProfileNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "agent profile not found"})
 			return
 		}
-		if errors.Is(err, controller.ErrInvalidProfileEnvVars) || errors.Is(err, controller.ErrInvalidCommandPrefix) {
+		if errors.Is(err, controller.ErrInvalidProfileEnvVars) || errors.Is(err, controller.ErrInvalidCommandPrefix) ||
+			errors.Is(err, controller.ErrInvalidPermissionPolicy) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	agentusage "github.com/kandev/kandev/internal/agent/usage"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	taskmodels "github.com/kandev/kandev/internal/task/models"
 )
 
@@ -113,6 +114,11 @@ type AgentProfile struct {
 	// Stored as a JSON-encoded TEXT column; settings repo handles conversion.
 	EnvVars []ProfileEnvVar `json:"env_vars,omitempty" db:"-"`
 
+	// PermissionPolicy holds allow/deny/ask rules agentctl applies to the
+	// agent's permission requests before asking a human. Stored as a
+	// JSON-encoded TEXT column; empty when the profile has no rules.
+	PermissionPolicy *permpolicy.Policy `json:"permission_policy,omitempty" db:"-"`
+
 	// AutoApprove enables Kandev agentctl-side ACP permission auto-approval at
 	// launch (AGENTCTL_AUTO_APPROVE_PERMISSIONS). DangerouslySkipPermissions is
 	// a deprecated legacy column retained so existing rows load cleanly.
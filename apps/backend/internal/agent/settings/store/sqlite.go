@@ -15,6 +15,7 @@ import (
 
 	"github.com/kandev/kandev/internal/agent/settings/models"
 	"github.com/kandev/kandev/internal/agent/settings/profileconfig"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/common/logger"
 	"github.com/kandev/kandev/internal/db"
 	"github.com/kandev/kandev/internal/db/dialect"
@@ -189,6 +190,8 @@ func (r *sqliteRepository) initSchema() error {
 	// recreates agent_profiles would otherwise lose columns added before it.
 	r.migrate.Apply("agent_profiles.fallback_model", `ALTER TABLE agent_profiles ADD COLUMN fallback_model TEXT NOT NULL DEFAULT ''`)
 	r.migrate.Apply("agent_profiles.auto_fallback", `ALTER TABLE agent_profiles ADD COLUMN auto_fallback INTEGER NOT NULL DEFAULT 0`)
+	// Permission policy: empty means the profile has no rules.
+	r.migrate.Apply("agent_profiles.permission_policy", `ALTER TABLE agent_profiles ADD COLUMN permission_policy TEXT NOT NULL DEFAULT ''`)
 
 	return nil
 }
@@ -863,6 +866,10 @@ func (r *sqliteRepository) insertAgentProfile(ctx context.Context, execer profil
 	if err != nil {
 		return err
 	}
+	permissionPolicyJSON, err := permissionPolicyToJSON(profile.PermissionPolicy)
+	if err != nil {
+		return err
+	}
 	enrich, err := enrichmentValues(profile)
 	if err != nil {
 		return err
@@ -878,7 +885,7 @@ func (r *sqliteRepository) insertAgentProfile(ctx context.Context, execer profil
 			max_concurrent_sessions, cooldown_sec, skip_idle_runs,
 			consecutive_failures, failure_threshold,
 			executor_preference, budget_monthly_cents, settings, permissions,
-			command_prefix, fallback_model, auto_fallback
+			command_prefix, fallback_model, auto_fallback, permission_policy
 		) VALUES (
 			?, ?, ?, ?, ?, ?, ?,
 			?, ?, ?, ?,
@@ -889,7 +896,7 @@ func (r *sqliteRepository) insertAgentProfile(ctx context.Context, execer profil
 			?, ?, ?,
 			?, ?,
 			?, ?, ?, ?,
-			?, ?, ?
+			?, ?, ?, ?
 		)
 	`),
 		profile.ID, profile.AgentID, profile.Name, profile.AgentDisplayName, profile.Model,
@@ -906,6 +913,7 @@ func (r *sqliteRepository) insertAgentProfile(ctx context.Context, execer profil
 		profile.CommandPrefix,
 		profile.FallbackModel,
 		dialect.BoolToInt(profile.AutoFallback),
+		permissionPolicyJSON,
 	)
 	return err
 }
@@ -1111,6 +1119,18 @@ func envVarsToJSON(envVars []models.ProfileEnvVar) (string, error) {
 	return string(data), nil
 }
 
+// permissionPolicyToJSON stores a profile without rules as an empty string.
+func permissionPolicyToJSON(policy *permpolicy.Policy) (string, error) {
+	if policy.IsEmpty() {
+		return "", nil
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", fmt.Errorf("marshal permission_policy: %w", err)
+	}
+	return string(data), nil
+}
+
 func (r *sqliteRepository) UpdateAgentProfile(ctx context.Context, profile *models.AgentProfile) error {
 	return r.updateAgentProfile(ctx, r.db, profile)
 }
@@ -1125,6 +1145,10 @@ func (r *sqliteRepository) updateAgentProfile(ctx context.Context, execer profil
 	if err != nil {
 		return err
 	}
+	permissionPolicyJSON, err := permissionPolicyToJSON(profile.PermissionPolicy)
+	if err != nil {
+		return err
+	}
 	enrich, err := enrichmentValues(profile)
 	if err != nil {
 		return err
@@ -1141,7 +1165,8 @@ func (r *sqliteRepository) updateAgentProfile(ctx context.Context, execer profil
 			consecutive_failures = ?, failure_threshold = ?,
 			executor_preference = ?,
 			budget_monthly_cents = ?, settings = ?, permissions = ?,
-			command_prefix = ?, fallback_model = ?, auto_fallback = ?
+			command_prefix = ?, fallback_model = ?, auto_fallback = ?,
+			permission_policy = ?
 		WHERE id = ? AND deleted_at IS NULL
 	`), profile.AgentID, profile.Name, profile.AgentDisplayName, profile.Model,
 		nullableString(profile.Mode), nullableString(profile.MigratedFrom),
@@ -1158,6 +1183,7 @@ func (r *sqliteRepository) updateAgentProfile(ctx context.Context, execer profil
 		profile.CommandPrefix,
 		profile.FallbackModel,
 		dialect.BoolToInt(profile.AutoFallback),
+		permissionPolicyJSON,
 		profile.ID)
 	if err != nil {
 		return err
@@ -1228,7 +1254,8 @@ const agentProfileSelectColumns = `
 		COALESCE(budget_monthly_cents, 0),
 		COALESCE(settings, '{}'), COALESCE(permissions, '{}'),
 		COALESCE(command_prefix, ''),
-		COALESCE(fallback_model, ''), COALESCE(auto_fallback, 0)
+		COALESCE(fallback_model, ''), COALESCE(auto_fallback, 0),
+		COALESCE(permission_policy, '')
 	FROM agent_profiles`
 
 func (r *sqliteRepository) GetAgentProfile(ctx context.Context, id string) (*models.AgentProfile, error) {
@@ -1409,6 +1436,7 @@ func scanAgentProfile(scanner interface {
 	var skipIdleRuns int
 	var failureThreshold int
 	var autoFallback int
+	var permissionPolicyRaw string
 	if err := scanner.Scan(
 		&profile.ID,
 		&profile.AgentID,
@@ -1451,6 +1479,7 @@ func scanAgentProfile(scanner interface {
 		&profile.CommandPrefix,
 		&profile.FallbackModel,
 		&autoFallback,
+		&permissionPolicyRaw,
 	); err != nil {
 		return nil, err
 	}
@@ -1485,6 +1514,11 @@ func scanAgentProfile(scanner interface {
 			return nil, fmt.Errorf("failed to parse env_vars for profile %s: %w", profile.ID, err)
 		}
 	}
+	if permissionPolicyRaw != "" {
+		if err := json.Unmarshal([]byte(permissionPolicyRaw), &profile.PermissionPolicy); err != nil {
+			return nil, fmt.Errorf("failed to parse permission_policy for profile %s: %w", profile.ID, err)
+		}
+	}
 	// When cli_flags is NULL the caller (GetAgentProfile / ListAgentProfiles)
 	// runs applyLegacyBackfill to seed the list scoped to the owning agent.
 	// Leaving CLIFlags as nil here is deliberate: non-nil vs nil discriminates
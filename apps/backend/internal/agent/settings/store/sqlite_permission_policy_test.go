@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kandev/kandev/internal/agent/settings/models"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+func TestAgentProfilePermissionPolicy_Roundtrip(t *testing.T) {
+	repo := newFreshRepo(t)
+	ctx := context.Background()
+
+	if err := repo.CreateAgent(ctx, &models.Agent{Name: "claude-acp-policy-test"}); err != nil {
+		t.Fatalf("CreateAgent: %v", err)
+	}
+	agent, err := repo.GetAgentByName(ctx, "claude-acp-policy-test")
+	if err != nil {
+		t.Fatalf("GetAgentByName: %v", err)
+	}
+
+	profile := &models.AgentProfile{
+		AgentID:          agent.ID,
+		Name:             "with-policy",
+		AgentDisplayName: "Claude",
+		Model:            "default",
+		PermissionPolicy: &permpolicy.Policy{Rules: []permpolicy.Rule{
+			{Name: "edits in repo", Decision: permpolicy.DecisionAllow, ActionTypes: []streams.PermissionActionType{streams.ActionTypeFileWrite}, Location: permpolicy.LocationInside},
+			{Name: "no rm -rf", Decision: permpolicy.DecisionDeny, Command: `\brm\s+-rf\b`},
+		}},
+	}
+	if err := repo.CreateAgentProfile(ctx, profile); err != nil {
+		t.Fatalf("CreateAgentProfile: %v", err)
+	}
+
+	got, err := repo.GetAgentProfile(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("GetAgentProfile: %v", err)
+	}
+	if got.PermissionPolicy == nil || len(got.PermissionPolicy.Rules) != 2 {
+		t.Fatalf("permission_policy: got %+v", got.PermissionPolicy)
+	}
+	rule := got.PermissionPolicy.Rules[1]
+	if rule.Name != "no rm -rf" || rule.Decision != permpolicy.DecisionDeny || rule.Command != `\brm\s+-rf\b` {
+		t.Fatalf("unexpected second rule: %+v", rule)
+	}
+
+	got.PermissionPolicy = nil
+	if err := repo.UpdateAgentProfile(ctx, got); err != nil {
+		t.Fatalf("UpdateAgentProfile: %v", err)
+	}
+	cleared, err := repo.GetAgentProfile(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("GetAgentProfile after update: %v", err)
+	}
+	if cleared.PermissionPolicy != nil {
+		t.Fatalf("after clearing: %+v", cleared.PermissionPolicy)
+	}
+}
@@ -1,4 +1,10 @@
-package acpdbg
+// Package acprecord records ACP JSON-RPC exchanges to JSONL files and replays
+// them. The format is the one the acpdbg CLI writes: one Entry per line,
+// carrying the raw frame as it crossed the wire and a timestamp. agentctl
+// records live sessions in the same format (see Tap), and Replayer re-emits a
+// recording as the agent side of a connection, so a session captured against a
+// real agent can be reproduced deterministically in tests without credentials.
+package acprecord
 
 import (
 	"encoding/json"
@@ -9,13 +15,48 @@ import (
 	"time"
 )
 
+// Frame is a single JSON-RPC 2.0 message — request, response, or notification.
+// We keep it as a generic map because the whole point of recording is to
+// capture whatever the agent actually sends, including fields the SDK would
+// drop or normalize.
+type Frame map[string]any
+
+// ID returns the request/response id as-is (may be nil for notifications,
+// float64 for numeric ids, or string for string ids — ACP uses integers).
+func (f Frame) ID() any {
+	return f["id"]
+}
+
+// Method returns the method name if this is a request or notification, or "".
+func (f Frame) Method() string {
+	m, _ := f["method"].(string)
+	return m
+}
+
+// IsResponse reports whether the frame has a result or error field (and
+// therefore a matching request id).
+func (f Frame) IsResponse() bool {
+	if _, ok := f["result"]; ok {
+		return true
+	}
+	if _, ok := f["error"]; ok {
+		return true
+	}
+	return false
+}
+
+// IsRequest reports whether the frame is a request: it has a method and an id.
+func (f Frame) IsRequest() bool {
+	return f.Method() != "" && f.ID() != nil
+}
+
 // Direction is the JSONL entry kind.
 type Direction string
 
 const (
-	DirMeta     Direction = "meta"     // start/close/timeout markers from acpdbg itself
-	DirSent     Direction = "sent"     // frames we wrote to the child's stdin
-	DirReceived Direction = "received" // frames we read from the child's stdout
+	DirMeta     Direction = "meta"     // start/close/timeout markers from the recorder itself
+	DirSent     Direction = "sent"     // frames the client wrote to the agent's stdin
+	DirReceived Direction = "received" // frames the client read from the agent's stdout
 	DirStderr   Direction = "stderr"   // lines captured from the child's stderr (opt-in)
 )
 
@@ -46,12 +87,13 @@ type Recorder struct {
 }
 
 // NewRecorder creates a JSONL file at the given path, creating parent
-// directories as needed. Overwrites any existing file at the path.
+// directories as needed. Overwrites any existing file at the path. Both are
+// owner-only: recordings carry full prompt, file and tool-call content.
 func NewRecorder(path string) (*Recorder, error) {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
 		return nil, fmt.Errorf("mkdir %s: %w", filepath.Dir(path), err)
 	}
-	f, err := os.Create(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
 	if err != nil {
 		return nil, fmt.Errorf("create %s: %w", path, err)
 	}
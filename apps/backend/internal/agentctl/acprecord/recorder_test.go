@@ -1,4 +1,4 @@
-package acpdbg
+package acprecord
 
 import (
 	"bufio"
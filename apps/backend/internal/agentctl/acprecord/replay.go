@@ -0,0 +1,335 @@
+package acprecord
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// maxReplayLineBytes bounds a single recorded or live line.
+const maxReplayLineBytes = 10 * 1024 * 1024
+
+// Load reads a JSONL recording.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ReadEntries(f)
+}
+
+// ReadEntries parses JSONL recording entries, skipping blank lines.
+func ReadEntries(r io.Reader) ([]Entry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineBytes)
+	var entries []Entry
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReplayOptions controls the timing and diagnostics of a replay.
+type ReplayOptions struct {
+	// Speed divides the recorded delay before each agent frame: 1 keeps the
+	// original timing, 10 replays ten times faster, 0 (or less) drops the
+	// delays altogether.
+	Speed float64
+	// MaxDelay caps each delay after scaling, so long model pauses do not
+	// stall a test. Zero means no cap.
+	MaxDelay time.Duration
+	// Log receives a line for every divergence between the live client and
+	// the recording. Optional.
+	Log io.Writer
+	// Stderr receives the agent's recorded stderr lines. Optional.
+	Stderr io.Writer
+}
+
+// Replayer plays the agent side of a recorded ACP exchange.
+//
+// Agent frames are re-emitted in the recorded order and with the recorded
+// spacing. The replay waits at every point where the recording shows the
+// client sending a request or answering an agent request (a permission prompt,
+// for example), so the live client drives the exchange exactly as the original
+// one did. Responses carry the live client's request ids; agent-initiated
+// requests keep their recorded ids. Client notifications such as
+// session/cancel are not waited for, since their effect is already part of the
+// recorded agent frames.
+//
+// When the live client sends a request the recording has at a later point,
+// the replay skips ahead to it. A request the recording does not contain is
+// answered with a JSON-RPC error. Both are reported to ReplayOptions.Log.
+type Replayer struct {
+	steps []replayStep
+	opts  ReplayOptions
+}
+
+type replayStep struct {
+	entry Entry
+	delay time.Duration
+}
+
+// NewReplayer prepares a replay of the given recording entries.
+func NewReplayer(entries []Entry, opts ReplayOptions) *Replayer {
+	steps := make([]replayStep, 0, len(entries))
+	var prev time.Time
+	for _, e := range entries {
+		if e.Direction == DirMeta {
+			continue
+		}
+		step := replayStep{entry: e}
+		if ts, err := time.Parse(time.RFC3339Nano, e.TS); err == nil {
+			if !prev.IsZero() && ts.After(prev) {
+				step.delay = ts.Sub(prev)
+			}
+			prev = ts
+		}
+		steps = append(steps, step)
+	}
+	return &Replayer{steps: steps, opts: opts}
+}
+
+// Run replays the recording, reading the client's frames from in and writing
+// the agent's frames to out. After the recording is exhausted it answers
+// further requests with an error until in is closed. It returns nil when the
+// client closes in, or the context error when ctx ends first.
+func (r *Replayer) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	done := make(chan struct{})
+	defer close(done)
+	s := &replaySession{
+		opts: r.opts,
+		out:  out,
+		live: readFrames(in, done),
+		ids:  map[string]any{},
+	}
+	for i := 0; i < len(r.steps); i++ {
+		step := r.steps[i]
+		var err error
+		switch step.entry.Direction {
+		case DirReceived:
+			if err = sleepContext(ctx, r.scaledDelay(step.delay)); err == nil {
+				err = s.emit(step.entry.Frame)
+			}
+		case DirSent:
+			if step.entry.Frame.IsRequest() || step.entry.Frame.IsResponse() {
+				i, err = s.await(ctx, r.steps, i)
+			}
+		case DirStderr:
+			if r.opts.Stderr != nil {
+				_, _ = fmt.Fprintln(r.opts.Stderr, step.entry.Line)
+			}
+		}
+		if errors.Is(err, errClientClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err := s.drain(ctx)
+	if errors.Is(err, errClientClosed) {
+		return nil
+	}
+	return err
+}
+
+func (r *Replayer) scaledDelay(d time.Duration) time.Duration {
+	if r.opts.Speed <= 0 {
+		return 0
+	}
+	d = time.Duration(float64(d) / r.opts.Speed)
+	if r.opts.MaxDelay > 0 && d > r.opts.MaxDelay {
+		d = r.opts.MaxDelay
+	}
+	return d
+}
+
+var errClientClosed = errors.New("client closed the connection")
+
+// replaySession is the state of one Run.
+type replaySession struct {
+	opts ReplayOptions
+	out  io.Writer
+	live <-chan Frame
+	// ids maps recorded client request ids to the live ids of the matching
+	// requests, until the response is emitted.
+	ids map[string]any
+}
+
+// await blocks until the live client sends the frame the recording expects
+// at steps[i], and returns the index of the step it matched.
+func (s *replaySession) await(ctx context.Context, steps []replayStep, i int) (int, error) {
+	want := steps[i].entry.Frame
+	for {
+		var frame Frame
+		select {
+		case <-ctx.Done():
+			return i, ctx.Err()
+		case f, ok := <-s.live:
+			if !ok {
+				return i, errClientClosed
+			}
+			frame = f
+		}
+		switch {
+		case frame.IsResponse():
+			if want.IsResponse() && idKey(frame.ID()) == idKey(want.ID()) {
+				return i, nil
+			}
+			s.logf("ignoring response to agent request %v", frame.ID())
+		case frame.IsRequest():
+			j := findRequest(steps, i, frame.Method())
+			if j < 0 {
+				s.logf("request %s is not in the recording", frame.Method())
+				if err := s.reject(frame, "replay: request "+frame.Method()+" is not in the recording"); err != nil {
+					return i, err
+				}
+				continue
+			}
+			if j > i {
+				s.logf("skipped %d recorded steps to match request %s", j-i, frame.Method())
+			}
+			s.ids[idKey(steps[j].entry.Frame.ID())] = frame.ID()
+			return j, nil
+		}
+	}
+}
+
+// drain answers requests that arrive after the recording is exhausted.
+func (s *replaySession) drain(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case frame, ok := <-s.live:
+			if !ok {
+				return errClientClosed
+			}
+			if frame.IsRequest() {
+				s.logf("request %s arrived after the recording ended", frame.Method())
+				if err := s.reject(frame, "replay: the recording has ended"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// emit writes a recorded agent frame, giving responses the id of the live
+// request they answer.
+func (s *replaySession) emit(frame Frame) error {
+	if frame.IsResponse() {
+		key := idKey(frame.ID())
+		liveID, ok := s.ids[key]
+		if !ok {
+			s.logf("dropping response %v: the live client never sent its request", frame.ID())
+			return nil
+		}
+		delete(s.ids, key)
+		rewritten := make(Frame, len(frame))
+		for k, v := range frame {
+			rewritten[k] = v
+		}
+		rewritten["id"] = liveID
+		frame = rewritten
+	}
+	return s.write(frame)
+}
+
+func (s *replaySession) reject(request Frame, message string) error {
+	return s.write(Frame{
+		"jsonrpc": "2.0",
+		"id":      request.ID(),
+		"error":   map[string]any{"code": -32603, "message": message},
+	})
+}
+
+func (s *replaySession) write(frame Frame) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("marshal frame: %w", err)
+	}
+	if _, err := s.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write frame: %w", err)
+	}
+	return nil
+}
+
+func (s *replaySession) logf(format string, args ...any) {
+	if s.opts.Log != nil {
+		_, _ = fmt.Fprintf(s.opts.Log, "replay: "+format+"\n", args...)
+	}
+}
+
+// findRequest returns the index of the first recorded client request with
+// the given method at or after from, or -1.
+func findRequest(steps []replayStep, from int, method string) int {
+	for j := from; j < len(steps); j++ {
+		f := steps[j].entry.Frame
+		if steps[j].entry.Direction == DirSent && f.IsRequest() && f.Method() == method {
+			return j
+		}
+	}
+	return -1
+}
+
+// readFrames parses the client's frames until in is closed or done is
+// closed. Lines that are not JSON objects are skipped.
+func readFrames(in io.Reader, done <-chan struct{}) <-chan Frame {
+	frames := make(chan Frame)
+	go func() {
+		defer close(frames)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxReplayLineBytes)
+		for scanner.Scan() {
+			var frame Frame
+			if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil || frame == nil {
+				continue
+			}
+			select {
+			case frames <- frame:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return frames
+}
+
+// idKey makes JSON-RPC ids comparable across the recording and the live
+// connection: numbers decode as float64 on both sides, strings stay strings.
+func idKey(id any) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,208 @@
+package acprecord
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedSession is a small prompt turn with a permission request, written
+// the way Tap records it. The agent's first session/update arrives 5s after
+// the prompt, so an unscaled replay would be obviously slow.
+const recordedSession = `
+{"ts":"2026-01-01T00:00:00.000Z","direction":"meta","event":"start","meta":{"agent_id":"claude"}}
+{"ts":"2026-01-01T00:00:00.000Z","direction":"sent","frame":{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}}
+{"ts":"2026-01-01T00:00:00.100Z","direction":"received","frame":{"jsonrpc":"2.0","id":0,"result":{"protocolVersion":1}}}
+{"ts":"2026-01-01T00:00:00.200Z","direction":"sent","frame":{"jsonrpc":"2.0","id":1,"method":"session/new","params":{}}}
+{"ts":"2026-01-01T00:00:00.300Z","direction":"received","frame":{"jsonrpc":"2.0","id":1,"result":{"sessionId":"s1"}}}
+{"ts":"2026-01-01T00:00:00.400Z","direction":"sent","frame":{"jsonrpc":"2.0","id":2,"method":"session/prompt","params":{"sessionId":"s1"}}}
+{"ts":"2026-01-01T00:00:05.400Z","direction":"received","frame":{"jsonrpc":"2.0","method":"session/update","params":{"sessionId":"s1","update":{"sessionUpdate":"tool_call"}}}}
+{"ts":"2026-01-01T00:00:05.500Z","direction":"stderr","line":"agent: asking for permission"}
+{"ts":"2026-01-01T00:00:05.500Z","direction":"received","frame":{"jsonrpc":"2.0","id":7,"method":"session/request_permission","params":{"sessionId":"s1"}}}
+{"ts":"2026-01-01T00:00:09.000Z","direction":"sent","frame":{"jsonrpc":"2.0","id":7,"result":{"outcome":{"outcome":"selected","optionId":"allow"}}}}
+{"ts":"2026-01-01T00:00:09.100Z","direction":"received","frame":{"jsonrpc":"2.0","method":"session/update","params":{"sessionId":"s1","update":{"sessionUpdate":"tool_call_update"}}}}
+{"ts":"2026-01-01T00:00:09.200Z","direction":"received","frame":{"jsonrpc":"2.0","id":2,"result":{"stopReason":"end_turn"}}}
+`
+
+// replayClient drives a Replayer over pipes, standing in for the orchestrator.
+type replayClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	done   chan error
+	log    *bytes.Buffer
+	stderr *bytes.Buffer
+}
+
+func startReplay(t *testing.T, recording string, opts ReplayOptions) *replayClient {
+	t.Helper()
+	entries, err := ReadEntries(strings.NewReader(recording))
+	if err != nil {
+		t.Fatalf("read entries: %v", err)
+	}
+	c := &replayClient{t: t, done: make(chan error, 1), log: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	opts.Log, opts.Stderr = c.log, c.stderr
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c.in, c.out = inW, bufio.NewReader(outR)
+	go func() {
+		err := NewReplayer(entries, opts).Run(context.Background(), inR, outW)
+		_ = outW.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() {
+		_ = inW.Close()
+		_ = outR.Close()
+	})
+	return c
+}
+
+func (c *replayClient) send(frame string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, frame+"\n"); err != nil {
+		c.t.Fatalf("send %s: %v", frame, err)
+	}
+}
+
+func (c *replayClient) next() Frame {
+	c.t.Helper()
+	line, err := c.out.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("read frame: %v", err)
+	}
+	var f Frame
+	if err := json.Unmarshal(line, &f); err != nil {
+		c.t.Fatalf("decode frame %q: %v", line, err)
+	}
+	return f
+}
+
+func (c *replayClient) finish() {
+	c.t.Helper()
+	_ = c.in.Close()
+	select {
+	case err := <-c.done:
+		if err != nil {
+			c.t.Fatalf("replay returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("replay did not stop after the client closed")
+	}
+}
+
+func TestReplayer_ReplaysSessionWithLiveIDs(t *testing.T) {
+	c := startReplay(t, recordedSession, ReplayOptions{Speed: 0})
+
+	c.send(`{"jsonrpc":"2.0","id":100,"method":"initialize","params":{}}`)
+	if f := c.next(); idKey(f.ID()) != idKey(float64(100)) || !f.IsResponse() {
+		t.Fatalf("initialize response = %v, want id 100", f)
+	}
+	c.send(`{"jsonrpc":"2.0","id":101,"method":"session/new","params":{}}`)
+	if f := c.next(); idKey(f.ID()) != idKey(float64(101)) {
+		t.Fatalf("session/new response = %v, want id 101", f)
+	}
+	c.send(`{"jsonrpc":"2.0","id":102,"method":"session/prompt","params":{"sessionId":"s1"}}`)
+	if f := c.next(); f.Method() != "session/update" {
+		t.Fatalf("expected session/update, got %v", f)
+	}
+	perm := c.next()
+	if perm.Method() != "session/request_permission" || idKey(perm.ID()) != idKey(float64(7)) {
+		t.Fatalf("expected permission request with recorded id 7, got %v", perm)
+	}
+
+	// Nothing else may be emitted until the permission is answered.
+	next := make(chan Frame, 1)
+	go func() { next <- c.next() }()
+	select {
+	case f := <-next:
+		t.Fatalf("frame emitted before the permission answer: %v", f)
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.send(`{"jsonrpc":"2.0","id":7,"result":{"outcome":{"outcome":"selected","optionId":"allow"}}}`)
+	if f := <-next; f.Method() != "session/update" {
+		t.Fatalf("expected tool_call_update, got %v", f)
+	}
+	if f := c.next(); idKey(f.ID()) != idKey(float64(102)) || f["result"] == nil {
+		t.Fatalf("prompt response = %v, want result for id 102", f)
+	}
+	c.finish()
+
+	if !strings.Contains(c.stderr.String(), "asking for permission") {
+		t.Errorf("recorded stderr not replayed: %q", c.stderr.String())
+	}
+}
+
+func TestReplayer_ScalesAndCapsDelays(t *testing.T) {
+	r := NewReplayer(nil, ReplayOptions{Speed: 10, MaxDelay: 200 * time.Millisecond})
+	if got := r.scaledDelay(time.Second); got != 100*time.Millisecond {
+		t.Errorf("scaled delay = %v, want 100ms", got)
+	}
+	if got := r.scaledDelay(5 * time.Second); got != 200*time.Millisecond {
+		t.Errorf("capped delay = %v, want 200ms", got)
+	}
+	if got := (&Replayer{}).scaledDelay(time.Second); got != 0 {
+		t.Errorf("speed 0 delay = %v, want 0", got)
+	}
+
+	entries, err := ReadEntries(strings.NewReader(recordedSession))
+	if err != nil {
+		t.Fatalf("read entries: %v", err)
+	}
+	steps := NewReplayer(entries, ReplayOptions{}).steps
+	if len(steps) != 11 {
+		t.Fatalf("expected meta entries to be skipped, got %d steps", len(steps))
+	}
+	if steps[5].delay != 5*time.Second {
+		t.Errorf("delay before first update = %v, want 5s", steps[5].delay)
+	}
+}
+
+func TestReplayer_SkipsAheadToMatchingRequest(t *testing.T) {
+	c := startReplay(t, recordedSession, ReplayOptions{Speed: 0})
+
+	// A client that reuses an existing session jumps straight to the prompt.
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"session/prompt","params":{"sessionId":"s1"}}`)
+	if f := c.next(); f.Method() != "session/update" {
+		t.Fatalf("expected session/update after skip, got %v", f)
+	}
+	if !strings.Contains(c.log.String(), "skipped 4 recorded steps") {
+		t.Errorf("skip not logged: %q", c.log.String())
+	}
+}
+
+func TestReplayer_RejectsUnknownRequests(t *testing.T) {
+	c := startReplay(t, recordedSession, ReplayOptions{Speed: 0})
+
+	c.send(`{"jsonrpc":"2.0","id":"x","method":"session/load","params":{}}`)
+	f := c.next()
+	if f["error"] == nil || f.ID() != "x" {
+		t.Fatalf("expected error response for id x, got %v", f)
+	}
+	c.finish()
+}
+
+func TestReplayer_AnswersRequestsAfterRecordingEnds(t *testing.T) {
+	c := startReplay(t, recordedSession, ReplayOptions{Speed: 0})
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"session/prompt","params":{}}`)
+	for {
+		if f := c.next(); f.Method() == "session/request_permission" {
+			break
+		}
+	}
+	c.send(`{"jsonrpc":"2.0","id":7,"result":{}}`)
+	_ = c.next() // tool_call_update
+	_ = c.next() // prompt response
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"session/prompt","params":{}}`)
+	if f := c.next(); f["error"] == nil {
+		t.Fatalf("expected error after recording end, got %v", f)
+	}
+	c.finish()
+}
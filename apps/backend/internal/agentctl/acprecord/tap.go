@@ -0,0 +1,69 @@
+package acprecord
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxTapLineBytes bounds a buffered partial frame, matching the largest
+// frame the acpdbg framer accepts.
+const maxTapLineBytes = 10 * 1024 * 1024
+
+// Tap wraps the stdio pipes of an agent subprocess so that every frame the
+// client writes to stdin is recorded as sent and every frame it reads from
+// stdout as received. Recording never affects the connection: a frame that
+// cannot be recorded is missing from the recording, not from the stream.
+func Tap(rec *Recorder, stdin io.Writer, stdout io.Reader) (io.Writer, io.Reader) {
+	sent := &frameWriter{rec: rec, dir: DirSent}
+	received := &frameWriter{rec: rec, dir: DirReceived}
+	return io.MultiWriter(stdin, sent), io.TeeReader(stdout, received)
+}
+
+// frameWriter splits a newline-delimited JSON-RPC byte stream into frames
+// and records them. It never returns an error, so io.MultiWriter and
+// io.TeeReader keep passing data through when the recording fails.
+type frameWriter struct {
+	rec *Recorder
+	dir Direction
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *frameWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.record(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxTapLineBytes {
+		_ = w.rec.Meta("oversized_frame", map[string]any{"direction": w.dir, "bytes": len(w.buf)})
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+func (w *frameWriter) record(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	var frame Frame
+	if err := json.Unmarshal(line, &frame); err != nil {
+		_ = w.rec.Meta("unparsed_line", map[string]any{"direction": w.dir, "line": string(line)})
+		return
+	}
+	if w.dir == DirSent {
+		_ = w.rec.Sent(frame)
+	} else {
+		_ = w.rec.Received(frame)
+	}
+}
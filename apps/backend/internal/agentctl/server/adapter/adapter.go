@@ -59,6 +59,15 @@ type OneShotAdapter interface {
 	IsOneShot() bool
 }
 
+// InProcessAdapter is an optional interface implemented by adapters that run
+// the agent loop inside agentctl instead of a subprocess. The process manager
+// hands them the agent environment and the workspace operations their tools
+// use before the session is created.
+type InProcessAdapter interface {
+	SetEnvironment(env []string)
+	SetWorkspace(ws shared.Workspace)
+}
+
 // OneShotConfig holds command configuration for one-shot adapters that manage
 // their own subprocess lifecycle. The adapter spawns a new process per prompt.
 type OneShotConfig struct {
@@ -4,18 +4,22 @@ import (
 	"fmt"
 
 	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/acp"
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/openai"
 	"github.com/kandev/kandev/internal/common/logger"
 	"github.com/kandev/kandev/pkg/agent"
 )
 
 // NewAdapter creates a new protocol adapter based on the specified protocol type.
-// Only ACP is supported; non-ACP variants were removed in the ACP-first migration.
+// ACP covers every subprocess agent; the only other protocol is the built-in
+// OpenAI-compatible agent, which runs inside agentctl.
 func NewAdapter(protocol agent.Protocol, cfg *Config, log *logger.Logger) (AgentAdapter, error) {
 	sharedCfg := cfg.ToSharedConfig()
 
 	switch protocol {
 	case agent.ProtocolACP:
 		return newACPAdapterWrapper(acp.NewAdapter(sharedCfg, log)), nil
+	case agent.ProtocolOpenAI:
+		return &openaiAdapterWrapper{Adapter: openai.NewAdapter(sharedCfg, log)}, nil
 	default:
 		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
 	}
@@ -37,3 +41,16 @@ func (w *acpAdapterWrapper) GetAgentInfo() *AgentInfo {
 	}
 	return &AgentInfo{Name: info.Name, Version: info.Version}
 }
+
+// openaiAdapterWrapper wraps openai.Adapter to implement AgentAdapter.
+type openaiAdapterWrapper struct {
+	*openai.Adapter
+}
+
+func (w *openaiAdapterWrapper) GetAgentInfo() *AgentInfo {
+	info := w.Adapter.GetAgentInfo()
+	if info == nil {
+		return nil
+	}
+	return &AgentInfo{Name: info.Name, Version: info.Version}
+}
@@ -0,0 +1,359 @@
+// Package openai implements a built-in agent that talks directly to an
+// OpenAI-compatible chat completions API (Ollama, vLLM, LiteLLM, llama.cpp
+// server, ...). Unlike the other transports there is no agent subprocess:
+// the tool-calling loop runs inside agentctl, and the model's function calls
+// are served by agentctl's own workspace operations and the MCP servers of
+// the session.
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/internal/agentctl/types"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+// Environment variables read from the agent environment.
+const (
+	EnvBaseURL = "OPENAI_BASE_URL"
+	EnvAPIKey  = "OPENAI_API_KEY"
+	EnvModel   = "OPENAI_MODEL"
+)
+
+// DefaultBaseURL is used when OPENAI_BASE_URL is unset: a local Ollama.
+const DefaultBaseURL = "http://localhost:11434/v1"
+
+const (
+	agentName = "openai-compatible"
+	// modelListTimeout bounds the model catalog request made when a session
+	// starts, so an unreachable endpoint does not hold up the launch.
+	modelListTimeout = 10 * time.Second
+	// cancelJoinTimeout bounds how long Cancel waits for the running turn to
+	// observe its cancelled context.
+	cancelJoinTimeout = 10 * time.Second
+	updatesBufferSize = 256
+)
+
+// Re-export types needed by external packages.
+type (
+	PermissionRequest  = types.PermissionRequest
+	PermissionResponse = types.PermissionResponse
+	PermissionHandler  = types.PermissionHandler
+	AgentEvent         = streams.AgentEvent
+)
+
+// AgentInfo contains information about the agent.
+type AgentInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Adapter runs the agent loop against an OpenAI-compatible endpoint. It is
+// one-shot in the process manager's sense: no subprocess is started, and the
+// workspace and environment are handed over through SetWorkspace and
+// SetEnvironment before the session is created.
+type Adapter struct {
+	cfg    *shared.Config
+	logger *logger.Logger
+
+	mu                sync.RWMutex
+	env               map[string]string
+	workspace         shared.Workspace
+	client            *client
+	sessionID         string
+	model             string
+	models            []streams.SessionModelInfo
+	tools             *toolSet
+	history           []chatMessage
+	permissionHandler PermissionHandler
+	// alwaysAllowed holds the tools the user allowed for the rest of the
+	// session with an "allow always" answer.
+	alwaysAllowed map[string]bool
+	turn          *activeTurn
+	attachments   *shared.AttachmentManager
+	closed        bool
+
+	updatesCh chan AgentEvent
+	closedCh  chan struct{}
+}
+
+// activeTurn is the prompt currently being answered.
+type activeTurn struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAdapter creates an adapter for the built-in OpenAI-compatible agent.
+func NewAdapter(cfg *shared.Config, log *logger.Logger) *Adapter {
+	return &Adapter{
+		cfg:           cfg,
+		logger:        log.WithFields(zap.String("adapter", "openai"), zap.String("agent_id", cfg.AgentID)),
+		env:           map[string]string{},
+		alwaysAllowed: map[string]bool{},
+		attachments:   shared.NewAttachmentManager(cfg.WorkDir, log.Zap()),
+		updatesCh:     make(chan AgentEvent, updatesBufferSize),
+		closedCh:      make(chan struct{}),
+	}
+}
+
+// IsOneShot reports that the process manager must not start a subprocess.
+func (a *Adapter) IsOneShot() bool { return true }
+
+// SetEnvironment hands over the agent environment, which carries the
+// endpoint settings (OPENAI_BASE_URL, OPENAI_API_KEY, OPENAI_MODEL).
+func (a *Adapter) SetEnvironment(env []string) {
+	parsed := make(map[string]string, len(env))
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			parsed[key] = value
+		}
+	}
+	a.mu.Lock()
+	a.env = parsed
+	a.mu.Unlock()
+}
+
+// SetWorkspace hands over the workspace the built-in tools operate on.
+func (a *Adapter) SetWorkspace(ws shared.Workspace) {
+	a.mu.Lock()
+	a.workspace = ws
+	a.mu.Unlock()
+}
+
+// PrepareEnvironment is a no-op: there is no subprocess to configure.
+func (a *Adapter) PrepareEnvironment() (map[string]string, error) { return nil, nil }
+
+// PrepareCommandArgs is a no-op: there is no subprocess to configure.
+func (a *Adapter) PrepareCommandArgs() []string { return nil }
+
+// Connect is a no-op: there is no subprocess to connect to.
+func (a *Adapter) Connect(_ io.Writer, _ io.Reader) error { return nil }
+
+// Initialize creates the API client from the agent environment.
+func (a *Adapter) Initialize(_ context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	baseURL := a.env[EnvBaseURL]
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	a.client = newClient(baseURL, a.env[EnvAPIKey])
+	a.model = a.env[EnvModel]
+	a.logger.Info("openai-compatible agent initialized",
+		zap.String("base_url", baseURL),
+		zap.String("model", a.model))
+	return nil
+}
+
+// GetAgentInfo returns information about the agent.
+func (a *Adapter) GetAgentInfo() *AgentInfo {
+	name := a.cfg.AgentName
+	if name == "" {
+		name = agentName
+	}
+	return &AgentInfo{Name: name}
+}
+
+// NewSession starts a conversation: it loads the model catalog and connects
+// the session's MCP servers, whose tools are offered next to the built-in
+// ones.
+func (a *Adapter) NewSession(ctx context.Context, mcpServers []types.McpServer) (string, error) {
+	a.mu.RLock()
+	c := a.client
+	a.mu.RUnlock()
+	if c == nil {
+		return "", fmt.Errorf("adapter not initialized")
+	}
+
+	sessionID := uuid.New().String()
+	listCtx, cancel := context.WithTimeout(ctx, modelListTimeout)
+	modelIDs, err := c.listModels(listCtx)
+	cancel()
+	if err != nil {
+		a.logger.Warn("failed to list models", zap.Error(err))
+	}
+	mcpTools := connectMCPServers(ctx, mcpServers, a.logger)
+
+	a.mu.Lock()
+	a.sessionID = sessionID
+	a.attachments.SetSessionID(sessionID)
+	if a.model == "" && len(modelIDs) > 0 {
+		a.model = modelIDs[0]
+	}
+	a.models = sessionModels(modelIDs, a.model)
+	if a.tools != nil {
+		a.tools.close()
+	}
+	a.tools = newToolSet(a.cfg.WorkDir, mcpTools)
+	a.history = nil
+	a.alwaysAllowed = map[string]bool{}
+	state := a.sessionModelStateLocked()
+	a.mu.Unlock()
+
+	a.logger.Info("session created",
+		zap.String("session_id", sessionID),
+		zap.Int("models", len(modelIDs)),
+		zap.Int("mcp_tools", len(mcpTools)))
+	a.sendUpdate(AgentEvent{
+		Type:           streams.EventTypeSessionModels,
+		SessionID:      sessionID,
+		CurrentModelID: state.CurrentModelID,
+		SessionModels:  state.Models,
+	})
+	return sessionID, nil
+}
+
+// LoadSession is not supported: the conversation lives in agentctl memory,
+// so a resumed task gets its history as context in the first prompt instead.
+func (a *Adapter) LoadSession(_ context.Context, _ string, _ []types.McpServer) error {
+	return errors.New("the openai-compatible agent does not support session resume")
+}
+
+// SetModel switches the model used from the next request on.
+func (a *Adapter) SetModel(_ context.Context, modelID string) error {
+	if modelID == "" {
+		return errors.New("model is required")
+	}
+	a.mu.Lock()
+	a.model = modelID
+	a.models = sessionModels(modelIDsOf(a.models), modelID)
+	sessionID := a.sessionID
+	state := a.sessionModelStateLocked()
+	a.mu.Unlock()
+
+	a.sendUpdate(AgentEvent{
+		Type:           streams.EventTypeSessionModels,
+		SessionID:      sessionID,
+		CurrentModelID: state.CurrentModelID,
+		SessionModels:  state.Models,
+	})
+	return nil
+}
+
+// GetSessionModelState returns the model catalog and the current model.
+func (a *Adapter) GetSessionModelState() *streams.SessionModelState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.sessionID == "" {
+		return nil
+	}
+	return a.sessionModelStateLocked()
+}
+
+func (a *Adapter) sessionModelStateLocked() *streams.SessionModelState {
+	return &streams.SessionModelState{
+		CurrentModelID: a.model,
+		Models:         append([]streams.SessionModelInfo(nil), a.models...),
+	}
+}
+
+// sessionModels builds the catalog from the served model IDs, keeping the
+// current model listed even when the endpoint did not report it.
+func sessionModels(ids []string, current string) []streams.SessionModelInfo {
+	models := make([]streams.SessionModelInfo, 0, len(ids)+1)
+	seen := false
+	for _, id := range ids {
+		seen = seen || id == current
+		models = append(models, streams.SessionModelInfo{ModelID: id, Name: id})
+	}
+	if current != "" && !seen {
+		models = append(models, streams.SessionModelInfo{ModelID: current, Name: current})
+	}
+	return models
+}
+
+func modelIDsOf(models []streams.SessionModelInfo) []string {
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		ids = append(ids, model.ModelID)
+	}
+	return ids
+}
+
+// Cancel stops the running turn. The turn then completes with stop reason
+// "cancelled".
+func (a *Adapter) Cancel(_ context.Context) error {
+	a.mu.RLock()
+	turn := a.turn
+	a.mu.RUnlock()
+	if turn == nil {
+		return nil
+	}
+	turn.cancel()
+	select {
+	case <-turn.done:
+		return nil
+	case <-time.After(cancelJoinTimeout):
+		return errors.New("the running turn did not stop after cancel")
+	}
+}
+
+// Updates returns the channel that receives agent events.
+func (a *Adapter) Updates() <-chan AgentEvent { return a.updatesCh }
+
+// GetSessionID returns the current session ID.
+func (a *Adapter) GetSessionID() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.sessionID
+}
+
+// GetOperationID returns "": turns have no provider-side identifier.
+func (a *Adapter) GetOperationID() string { return "" }
+
+// SetPermissionHandler sets the handler asked before tools that change the
+// workspace or run commands.
+func (a *Adapter) SetPermissionHandler(handler PermissionHandler) {
+	a.mu.Lock()
+	a.permissionHandler = handler
+	a.mu.Unlock()
+}
+
+// RequiresProcessKill returns false: there is no subprocess.
+func (a *Adapter) RequiresProcessKill() bool { return false }
+
+// Close cancels the running turn and disconnects the MCP servers.
+func (a *Adapter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.closedCh)
+	turn := a.turn
+	tools := a.tools
+	a.tools = nil
+	a.mu.Unlock()
+
+	if turn != nil {
+		turn.cancel()
+		<-turn.done
+	}
+	if tools != nil {
+		tools.close()
+	}
+	a.attachments.Cleanup()
+	return nil
+}
+
+// sendUpdate delivers an event unless the adapter is closed.
+func (a *Adapter) sendUpdate(event AgentEvent) {
+	event.NormalizedPayload = event.NormalizedPayload.Snapshot()
+	shared.LogNormalizedEvent(shared.ProtocolOpenAI, a.cfg.AgentID, event.SessionID, &event)
+	select {
+	case a.updatesCh <- event:
+	case <-a.closedCh:
+	}
+}
@@ -0,0 +1,390 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+// stubServer is a minimal OpenAI-compatible endpoint. Each chat request is
+// answered with the next scripted list of stream chunks.
+type stubServer struct {
+	t       *testing.T
+	mu      sync.Mutex
+	replies [][]string
+	// block makes chat requests wait until the client goes away.
+	block    bool
+	requests []chatRequest
+	auth     []string
+}
+
+func newStubServer(t *testing.T, replies ...[]string) (*stubServer, *httptest.Server) {
+	stub := &stubServer{t: t, replies: replies}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/models":
+		_, _ = io.WriteString(w, `{"object":"list","data":[{"id":"zeta"},{"id":"alpha"}]}`)
+	case "/v1/chat/completions":
+		s.serveChat(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *stubServer) serveChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	block := s.block
+	var chunks []string
+	if len(s.replies) > 0 {
+		chunks, s.replies = s.replies[0], s.replies[1:]
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	if block {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		return
+	}
+	for _, chunk := range chunks {
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+	}
+	_, _ = io.WriteString(w, "data: [DONE]\n\n")
+}
+
+func (s *stubServer) chatRequests() []chatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]chatRequest(nil), s.requests...)
+}
+
+func textChunk(text string) string {
+	return fmt.Sprintf(`{"choices":[{"delta":{"content":%q}}]}`, text)
+}
+
+func toolCallChunk(index int, id, name, arguments string) string {
+	return fmt.Sprintf(`{"choices":[{"delta":{"tool_calls":[{"index":%d,"id":%q,"function":{"name":%q,"arguments":%q}}]}}]}`,
+		index, id, name, arguments)
+}
+
+const finishChunk = `{"choices":[{"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":5,"total_tokens":17}}`
+
+// fakeWorkspace keeps files in memory and records shell commands.
+type fakeWorkspace struct {
+	mu       sync.Mutex
+	files    map[string]string
+	commands []string
+}
+
+var _ shared.Workspace = (*fakeWorkspace)(nil)
+
+func (w *fakeWorkspace) ReadFile(path string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	content, ok := w.files[path]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return content, nil
+}
+
+func (w *fakeWorkspace) WriteFile(path, content string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files[path] = content
+	return nil
+}
+
+func (w *fakeWorkspace) FindFiles(query string, _ int) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var paths []string
+	for path := range w.files {
+		if strings.Contains(path, query) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func (w *fakeWorkspace) SearchContent(context.Context, string, int) ([]streams.WorkspaceContentSearchResult, error) {
+	return nil, nil
+}
+
+func (w *fakeWorkspace) GitStatus(context.Context) ([]streams.GitStatusUpdate, error) {
+	return nil, nil
+}
+
+func (w *fakeWorkspace) GitCommit(context.Context, string, string) (string, error) {
+	return "", nil
+}
+
+func (w *fakeWorkspace) RunShell(_ context.Context, command string) (shared.ShellResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.commands = append(w.commands, command)
+	return shared.ShellResult{Output: "ok\n"}, nil
+}
+
+func newTestAdapter(t *testing.T, baseURL string, ws shared.Workspace, env ...string) *Adapter {
+	t.Helper()
+	log, err := logger.NewLogger(logger.LoggingConfig{Level: "error", Format: "json"})
+	require.NoError(t, err)
+	a := NewAdapter(&shared.Config{AgentID: "openai-compatible", WorkDir: t.TempDir()}, log)
+	t.Cleanup(func() { _ = a.Close() })
+	a.SetEnvironment(append([]string{EnvBaseURL + "=" + baseURL + "/v1"}, env...))
+	a.SetWorkspace(ws)
+	require.NoError(t, a.Initialize(context.Background()))
+	_, err = a.NewSession(context.Background(), nil)
+	require.NoError(t, err)
+	return a
+}
+
+// drainEvents returns the events queued so far.
+func drainEvents(a *Adapter) []AgentEvent {
+	var events []AgentEvent
+	for {
+		select {
+		case event := <-a.Updates():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func eventsOfType(events []AgentEvent, eventType string) []AgentEvent {
+	var out []AgentEvent
+	for _, event := range events {
+		if event.Type == eventType {
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+func respondWith(optionID string) PermissionHandler {
+	return func(context.Context, *PermissionRequest) (*PermissionResponse, error) {
+		return &PermissionResponse{OptionID: optionID}, nil
+	}
+}
+
+func TestPrompt_StreamsTextAndCompletes(t *testing.T) {
+	stub, server := newStubServer(t, []string{textChunk("Hello"), textChunk(" world"), finishChunk})
+	a := newTestAdapter(t, server.URL, &fakeWorkspace{files: map[string]string{}}, EnvAPIKey+"=secret")
+
+	require.NoError(t, a.Prompt(context.Background(), "hi", nil, 3))
+	events := drainEvents(a)
+
+	models := eventsOfType(events, streams.EventTypeSessionModels)
+	require.Len(t, models, 1)
+	assert.Equal(t, "alpha", models[0].CurrentModelID, "first listed model is used when OPENAI_MODEL is unset")
+
+	chunks := eventsOfType(events, streams.EventTypeMessageChunk)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "Hello", chunks[0].Text)
+	assert.Equal(t, " world", chunks[1].Text)
+
+	complete := eventsOfType(events, streams.EventTypeComplete)
+	require.Len(t, complete, 1)
+	assert.Equal(t, uint64(3), complete[0].PromptGeneration)
+	assert.Equal(t, stopReasonEndTurn, complete[0].Data["stop_reason"])
+	require.NotNil(t, complete[0].Usage)
+	assert.Equal(t, int64(12), complete[0].Usage.InputTokens)
+	assert.Equal(t, int64(5), complete[0].Usage.OutputTokens)
+
+	requests := stub.chatRequests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "alpha", requests[0].Model)
+	assert.True(t, requests[0].Stream)
+	assert.NotEmpty(t, requests[0].Tools)
+	require.Len(t, requests[0].Messages, 2)
+	assert.Equal(t, "system", requests[0].Messages[0].Role)
+	assert.Equal(t, "hi", requests[0].Messages[1].Content)
+	assert.Equal(t, "Bearer secret", stub.auth[0])
+}
+
+func TestPrompt_KeepsHistoryAcrossTurns(t *testing.T) {
+	stub, server := newStubServer(t,
+		[]string{textChunk("first"), finishChunk},
+		[]string{textChunk("second"), finishChunk},
+	)
+	a := newTestAdapter(t, server.URL, &fakeWorkspace{files: map[string]string{}}, EnvModel+"=custom")
+
+	require.NoError(t, a.Prompt(context.Background(), "one", nil, 1))
+	require.NoError(t, a.Prompt(context.Background(), "two", nil, 2))
+
+	requests := stub.chatRequests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "custom", requests[1].Model)
+	roles := make([]string, 0, len(requests[1].Messages))
+	for _, message := range requests[1].Messages {
+		roles = append(roles, message.Role)
+	}
+	assert.Equal(t, []string{"system", "user", "assistant", "user"}, roles)
+}
+
+func TestPrompt_ExecutesToolCalls(t *testing.T) {
+	stub, server := newStubServer(t,
+		[]string{
+			toolCallChunk(0, "call_1", "read_file", `{"path":`),
+			toolCallChunk(0, "", "", `"main.go"}`),
+			`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		},
+		[]string{textChunk("It prints hello."), finishChunk},
+	)
+	ws := &fakeWorkspace{files: map[string]string{"main.go": "package main\n\nfunc main() { println(\"hello\") }"}}
+	a := newTestAdapter(t, server.URL, ws)
+
+	require.NoError(t, a.Prompt(context.Background(), "what does main do?", nil, 1))
+	events := drainEvents(a)
+
+	calls := eventsOfType(events, streams.EventTypeToolCall)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "call_1", calls[0].ToolCallID)
+	assert.Equal(t, "in_progress", calls[0].ToolStatus)
+	require.NotNil(t, calls[0].NormalizedPayload)
+	assert.Equal(t, "main.go", calls[0].NormalizedPayload.ReadFile().FilePath)
+
+	updates := eventsOfType(events, streams.EventTypeToolUpdate)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "complete", updates[0].ToolStatus)
+
+	requests := stub.chatRequests()
+	require.Len(t, requests, 2)
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, "tool", last.Role)
+	assert.Equal(t, "call_1", last.ToolCallID)
+	assert.Contains(t, last.Content, `println("hello")`)
+}
+
+func TestPrompt_DeniedPermissionSkipsTool(t *testing.T) {
+	stub, server := newStubServer(t,
+		[]string{toolCallChunk(0, "call_1", "run_shell", `{"command":"rm -rf build"}`)},
+		[]string{textChunk("Okay, I will not."), finishChunk},
+	)
+	ws := &fakeWorkspace{files: map[string]string{}}
+	a := newTestAdapter(t, server.URL, ws)
+	a.SetPermissionHandler(respondWith(optionRejectOnce))
+
+	require.NoError(t, a.Prompt(context.Background(), "clean up", nil, 1))
+	events := drainEvents(a)
+
+	assert.Empty(t, ws.commands)
+	updates := eventsOfType(events, streams.EventTypeToolUpdate)
+	require.Len(t, updates, 1)
+	assert.Equal(t, "error", updates[0].ToolStatus)
+
+	requests := stub.chatRequests()
+	require.Len(t, requests, 2)
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	assert.Equal(t, "Error: "+errPermissionDenied.Error(), last.Content)
+}
+
+func TestPrompt_AllowAlwaysCoversLaterCalls(t *testing.T) {
+	_, server := newStubServer(t,
+		[]string{toolCallChunk(0, "call_1", "run_shell", `{"command":"make"}`)},
+		[]string{toolCallChunk(0, "call_2", "run_shell", `{"command":"make test"}`)},
+		[]string{textChunk("Built and tested."), finishChunk},
+	)
+	ws := &fakeWorkspace{files: map[string]string{}}
+	a := newTestAdapter(t, server.URL, ws)
+	asked := 0
+	a.SetPermissionHandler(func(context.Context, *PermissionRequest) (*PermissionResponse, error) {
+		asked++
+		return &PermissionResponse{OptionID: optionAllowAlways}, nil
+	})
+
+	require.NoError(t, a.Prompt(context.Background(), "build it", nil, 1))
+
+	assert.Equal(t, 1, asked)
+	assert.Equal(t, []string{"make", "make test"}, ws.commands)
+}
+
+func TestCancel_CompletesTurnAsCancelled(t *testing.T) {
+	stub, server := newStubServer(t)
+	stub.block = true
+	a := newTestAdapter(t, server.URL, &fakeWorkspace{files: map[string]string{}})
+
+	done := make(chan error, 1)
+	go func() { done <- a.Prompt(context.Background(), "take your time", nil, 1) }()
+	require.Eventually(t, func() bool { return len(stub.chatRequests()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, a.Cancel(context.Background()))
+	require.NoError(t, <-done)
+
+	complete := eventsOfType(drainEvents(a), streams.EventTypeComplete)
+	require.Len(t, complete, 1)
+	assert.Equal(t, stopReasonCancelled, complete[0].Data["stop_reason"])
+}
+
+func TestPrompt_RejectsConcurrentTurns(t *testing.T) {
+	stub, server := newStubServer(t)
+	stub.block = true
+	a := newTestAdapter(t, server.URL, &fakeWorkspace{files: map[string]string{}})
+
+	done := make(chan error, 1)
+	go func() { done <- a.Prompt(context.Background(), "first", nil, 1) }()
+	require.Eventually(t, func() bool { return len(stub.chatRequests()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	assert.Error(t, a.Prompt(context.Background(), "second", nil, 2))
+	require.NoError(t, a.Cancel(context.Background()))
+	require.NoError(t, <-done)
+}
+
+func TestEditFileTool(t *testing.T) {
+	ws := &fakeWorkspace{files: map[string]string{"a.txt": "one two two"}}
+	edit := editFileTool()
+	payload := edit.plan(toolArgs{"path": "a.txt"}).payload
+
+	_, err := edit.run(context.Background(), ws, toolArgs{"path": "a.txt", "old_string": "two", "new_string": "2"}, payload)
+	assert.ErrorContains(t, err, "matches 2 times")
+
+	_, err = edit.run(context.Background(), ws, toolArgs{"path": "a.txt", "old_string": "two", "new_string": "2", "replace_all": true}, payload)
+	require.NoError(t, err)
+	assert.Equal(t, "one 2 2", ws.files["a.txt"])
+}
+
+func TestMCPFunctionName(t *testing.T) {
+	assert.Equal(t, "kandev__create_task", mcpFunctionName("kandev", "create_task"))
+	assert.Equal(t, "my_server__do_it_now", mcpFunctionName("my server", "do.it/now"))
+	assert.Len(t, mcpFunctionName("server", strings.Repeat("x", 100)), maxFunctionNameLength)
+}
+
+func TestTruncateOutput(t *testing.T) {
+	short, truncated := truncateOutput("small")
+	assert.Equal(t, "small", short)
+	assert.False(t, truncated)
+
+	long, truncated := truncateOutput(strings.Repeat("é", maxToolOutputBytes))
+	assert.True(t, truncated)
+	assert.True(t, strings.HasSuffix(long, "[output truncated]"))
+	assert.NotContains(t, long, "�")
+}
@@ -0,0 +1,336 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxErrorBodyBytes bounds how much of a failed response is read for its
+// error message.
+const maxErrorBodyBytes = 64 << 10
+
+// maxStreamLineBytes bounds a single server-sent event line. Tool-call
+// argument deltas are small, but some servers send a whole message per event.
+const maxStreamLineBytes = 8 << 20
+
+// chatMessage is one entry of the chat completions conversation. Content is
+// a string, or a []contentPart for user messages that carry images.
+type chatMessage struct {
+	Role       string     `json:"role"`
+	Content    any        `json:"content"`
+	ToolCalls  []toolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type toolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function functionCall `json:"function"`
+}
+
+type functionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// toolDefinition advertises a function the model may call.
+type toolDefinition struct {
+	Type     string       `json:"type"`
+	Function functionSpec `json:"function"`
+}
+
+type functionSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type chatRequest struct {
+	Model         string           `json:"model"`
+	Messages      []chatMessage    `json:"messages"`
+	Tools         []toolDefinition `json:"tools,omitempty"`
+	Stream        bool             `json:"stream"`
+	StreamOptions *streamOptions   `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"`
+			Reasoning        string `json:"reasoning"`
+			ToolCalls        []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+// completion is one assembled streamed response.
+type completion struct {
+	Content      string
+	ToolCalls    []toolCall
+	FinishReason string
+	Usage        *chatUsage
+}
+
+// streamHandler receives text as it streams in.
+type streamHandler struct {
+	onText      func(string)
+	onReasoning func(string)
+}
+
+// client talks to an OpenAI-compatible HTTP API.
+type client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+func newClient(baseURL, apiKey string) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		// No client timeout: a turn streams for as long as the model
+		// generates, and local servers may load the model on first use.
+		// Cancellation comes from the request context.
+		http: &http.Client{},
+	}
+}
+
+func (c *client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// listModels returns the model IDs served by the endpoint, sorted.
+func (c *client) listModels(ctx context.Context) ([]string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("decode model list: %w", err)
+	}
+	ids := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		if model.ID != "" {
+			ids = append(ids, model.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// streamChat sends a streaming chat completion request and assembles the
+// response, reporting text and reasoning deltas as they arrive.
+func (c *client) streamChat(ctx context.Context, request chatRequest, handler streamHandler) (*completion, error) {
+	request.Stream = true
+	request.StreamOptions = &streamOptions{IncludeUsage: true}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	return readStream(resp.Body, handler)
+}
+
+// readStream parses a chat completions server-sent event stream.
+func readStream(body io.Reader, handler streamHandler) (*completion, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxStreamLineBytes)
+	acc := &streamAccumulator{}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			if message := streamErrorMessage(data); message != "" {
+				return nil, errors.New(message)
+			}
+			return nil, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if message := streamErrorMessage(data); message != "" && len(chunk.Choices) == 0 {
+			return nil, errors.New(message)
+		}
+		acc.add(&chunk, handler)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return acc.result(), nil
+}
+
+// streamAccumulator assembles content and indexed tool-call deltas.
+type streamAccumulator struct {
+	content      strings.Builder
+	calls        []toolCall
+	finishReason string
+	usage        *chatUsage
+}
+
+func (a *streamAccumulator) add(chunk *streamChunk, handler streamHandler) {
+	if chunk.Usage != nil {
+		a.usage = chunk.Usage
+	}
+	for _, choice := range chunk.Choices {
+		delta := choice.Delta
+		if delta.Content != "" {
+			a.content.WriteString(delta.Content)
+			if handler.onText != nil {
+				handler.onText(delta.Content)
+			}
+		}
+		reasoning := delta.ReasoningContent
+		if reasoning == "" {
+			reasoning = delta.Reasoning
+		}
+		if reasoning != "" && handler.onReasoning != nil {
+			handler.onReasoning(reasoning)
+		}
+		for _, tc := range delta.ToolCalls {
+			for len(a.calls) <= tc.Index {
+				a.calls = append(a.calls, toolCall{Type: "function"})
+			}
+			call := &a.calls[tc.Index]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			call.Function.Name += tc.Function.Name
+			call.Function.Arguments += tc.Function.Arguments
+		}
+		if choice.FinishReason != "" {
+			a.finishReason = choice.FinishReason
+		}
+	}
+}
+
+func (a *streamAccumulator) result() *completion {
+	calls := make([]toolCall, 0, len(a.calls))
+	for i, call := range a.calls {
+		if call.Function.Name == "" {
+			continue
+		}
+		if call.ID == "" {
+			call.ID = fmt.Sprintf("call_%d", i)
+		}
+		calls = append(calls, call)
+	}
+	return &completion{
+		Content:      a.content.String(),
+		ToolCalls:    calls,
+		FinishReason: a.finishReason,
+		Usage:        a.usage,
+	}
+}
+
+// responseError turns a non-200 response into an error carrying the
+// server's message when it sent one.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if message := streamErrorMessage(string(body)); message != "" {
+		return fmt.Errorf("%s: %s", resp.Status, message)
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return fmt.Errorf("%s: %s", resp.Status, text)
+	}
+	return errors.New(resp.Status)
+}
+
+// streamErrorMessage extracts {"error": {"message": ...}} or
+// {"error": "..."} from a response body or stream event.
+func streamErrorMessage(data string) string {
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(data), &envelope); err != nil || len(envelope.Error) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(envelope.Error, &text); err == nil {
+		return text
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(envelope.Error, &detail); err == nil {
+		return detail.Message
+	}
+	return ""
+}
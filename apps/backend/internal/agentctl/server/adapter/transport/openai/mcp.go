@@ -0,0 +1,223 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	mcptransport "github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/internal/agentctl/types"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+const (
+	// kandevMCPServerName is the MCP server agentctl serves itself. Its tools
+	// (tasks, plans, questions to the user) run without a permission prompt,
+	// as they do for ACP agents.
+	kandevMCPServerName = "kandev"
+	mcpProtocolVersion  = "2025-06-18"
+	mcpConnectTimeout   = 15 * time.Second
+	// maxFunctionNameLength is the function name limit of the OpenAI API.
+	maxFunctionNameLength = 64
+)
+
+var invalidFunctionNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// mcpServerConn is a connected MCP server.
+type mcpServerConn struct {
+	name      string
+	client    *mcpclient.Client
+	closeOnce sync.Once
+}
+
+func (s *mcpServerConn) close() {
+	s.closeOnce.Do(func() { _ = s.client.Close() })
+}
+
+// mcpTool is a tool of a connected MCP server, exposed as <server>__<tool>.
+type mcpTool struct {
+	server *mcpServerConn
+	tool   *tool
+}
+
+// connectMCPServers connects the session's HTTP and SSE MCP servers and
+// lists their tools. A server that fails to connect is skipped. Stdio servers
+// are not started: the built-in agent has no subprocess to own them.
+func connectMCPServers(ctx context.Context, servers []types.McpServer, log *logger.Logger) []*mcpTool {
+	var tools []*mcpTool
+	for _, server := range preferredMCPServers(servers) {
+		connectCtx, cancel := context.WithTimeout(ctx, mcpConnectTimeout)
+		conn, listed, err := connectMCPServer(connectCtx, server)
+		cancel()
+		if err != nil {
+			log.Warn("failed to connect MCP server",
+				zap.String("server", server.Name),
+				zap.String("type", server.Type),
+				zap.Error(err))
+			continue
+		}
+		for _, mcpDef := range listed {
+			tools = append(tools, &mcpTool{server: conn, tool: newMCPTool(conn, mcpDef)})
+		}
+	}
+	return tools
+}
+
+// preferredMCPServers keeps one entry per server name, preferring the HTTP
+// transport: agentctl offers its own server over both HTTP and SSE.
+func preferredMCPServers(servers []types.McpServer) []types.McpServer {
+	var out []types.McpServer
+	index := map[string]int{}
+	for _, server := range servers {
+		if server.URL == "" || !isRemoteMCPType(server.Type) {
+			continue
+		}
+		if i, ok := index[server.Name]; ok {
+			if isHTTPMCPType(server.Type) && !isHTTPMCPType(out[i].Type) {
+				out[i] = server
+			}
+			continue
+		}
+		index[server.Name] = len(out)
+		out = append(out, server)
+	}
+	return out
+}
+
+func isRemoteMCPType(kind string) bool {
+	return kind == "sse" || isHTTPMCPType(kind)
+}
+
+func isHTTPMCPType(kind string) bool {
+	return kind == "http" || kind == "streamable_http"
+}
+
+func connectMCPServer(ctx context.Context, server types.McpServer) (*mcpServerConn, []mcp.Tool, error) {
+	var c *mcpclient.Client
+	var err error
+	if isHTTPMCPType(server.Type) {
+		c, err = mcpclient.NewStreamableHttpClient(server.URL, mcptransport.WithHTTPHeaders(server.Headers))
+	} else {
+		c, err = mcpclient.NewSSEMCPClient(server.URL, mcptransport.WithHeaders(server.Headers))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	conn := &mcpServerConn{name: server.Name, client: c}
+	// The SSE transport keeps its event stream on the Start context, so it
+	// must outlive this call; the stream ends when the client is closed.
+	if err := c.Start(context.WithoutCancel(ctx)); err != nil {
+		conn.close()
+		return nil, nil, fmt.Errorf("start: %w", err)
+	}
+	if _, err := c.Initialize(ctx, mcp.InitializeRequest{Params: mcp.InitializeParams{
+		ProtocolVersion: mcpProtocolVersion,
+		Capabilities:    mcp.ClientCapabilities{},
+		ClientInfo:      mcp.Implementation{Name: "kandev", Version: "1.0"},
+	}}); err != nil {
+		conn.close()
+		return nil, nil, fmt.Errorf("initialize: %w", err)
+	}
+	listed, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		conn.close()
+		return nil, nil, fmt.Errorf("list tools: %w", err)
+	}
+	return conn, listed.Tools, nil
+}
+
+func newMCPTool(conn *mcpServerConn, def mcp.Tool) *tool {
+	name := mcpFunctionName(conn.name, def.Name)
+	return &tool{
+		definition: functionTool(name, def.Description, string(mcpInputSchema(def))),
+		plan: func(args toolArgs) toolPlan {
+			plan := toolPlan{
+				title:   conn.name + ": " + def.Name,
+				payload: streams.NewMCPTool(def.Name, map[string]any(args)),
+			}
+			if conn.name != kandevMCPServerName {
+				plan.permission = &permissionAsk{
+					actionType: types.ActionTypeMCPTool,
+					details:    map[string]any{"server": conn.name, "tool": def.Name, "arguments": map[string]any(args)},
+				}
+			}
+			return plan
+		},
+		run: func(ctx context.Context, _ shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			result, err := conn.client.CallTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+				Name:      def.Name,
+				Arguments: map[string]any(args),
+			}})
+			if err != nil {
+				return "", err
+			}
+			text, _ := truncateOutput(mcpResultText(result))
+			payload.Generic().Output = text
+			if result.IsError {
+				if text == "" {
+					text = "the tool reported an error"
+				}
+				return "", errors.New(text)
+			}
+			return text, nil
+		},
+	}
+}
+
+// mcpFunctionName builds a function name within the API's character set and
+// length limit.
+func mcpFunctionName(server, toolName string) string {
+	name := invalidFunctionNameChars.ReplaceAllString(server+"__"+toolName, "_")
+	if len(name) > maxFunctionNameLength {
+		name = name[:maxFunctionNameLength]
+	}
+	return name
+}
+
+// mcpInputSchema returns the tool's JSON schema, whichever form the server
+// used.
+func mcpInputSchema(def mcp.Tool) json.RawMessage {
+	raw, err := json.Marshal(def)
+	if err == nil {
+		var parsed struct {
+			InputSchema json.RawMessage `json:"inputSchema"`
+		}
+		if json.Unmarshal(raw, &parsed) == nil && len(parsed.InputSchema) > 0 {
+			return parsed.InputSchema
+		}
+	}
+	return json.RawMessage(`{"type":"object","properties":{}}`)
+}
+
+// mcpResultText joins the text content of a tool result; other content is
+// summarised by type.
+func mcpResultText(result *mcp.CallToolResult) string {
+	parts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			parts = append(parts, c.Text)
+		case *mcp.TextContent:
+			parts = append(parts, c.Text)
+		default:
+			parts = append(parts, fmt.Sprintf("[%T content]", content))
+		}
+	}
+	if len(parts) == 0 && result.StructuredContent != nil {
+		if raw, err := json.Marshal(result.StructuredContent); err == nil {
+			parts = append(parts, string(raw))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
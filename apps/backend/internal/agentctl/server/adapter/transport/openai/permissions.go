@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// Permission option IDs offered for tools that change the workspace, run
+// commands or call third-party MCP tools.
+const (
+	optionAllowOnce   = "allow_once"
+	optionAllowAlways = "allow_always"
+	optionRejectOnce  = "reject_once"
+)
+
+// errPermissionDenied is returned to the model when the user rejects a call.
+var errPermissionDenied = errors.New("the user denied this tool call")
+
+func permissionOptions() []streams.PermissionOption {
+	return []streams.PermissionOption{
+		{OptionID: optionAllowOnce, Name: "Allow", Kind: streams.PermissionOptionKindAllowOnce},
+		{OptionID: optionAllowAlways, Name: "Always allow", Kind: streams.PermissionOptionKindAllowAlways},
+		{OptionID: optionRejectOnce, Name: "Reject", Kind: streams.PermissionOptionKindRejectOnce},
+	}
+}
+
+// authorize asks the permission handler before a call that needs it. The
+// handler applies the session's auto-approve setting. An "always allow"
+// answer covers the same tool for the rest of the session.
+func (a *Adapter) authorize(ctx context.Context, state *turnState, call toolCall, plan toolPlan) error {
+	if plan.permission == nil {
+		return nil
+	}
+	name := call.Function.Name
+	a.mu.RLock()
+	handler := a.permissionHandler
+	allowed := a.alwaysAllowed[name]
+	a.mu.RUnlock()
+	if allowed {
+		return nil
+	}
+	if handler == nil {
+		return errors.New("no permission handler is configured")
+	}
+
+	resp, err := handler(ctx, &PermissionRequest{
+		SessionID:     state.sessionID,
+		ToolCallID:    call.ID,
+		Title:         plan.title,
+		Options:       permissionOptions(),
+		ActionType:    string(plan.permission.actionType),
+		ActionDetails: plan.permission.details,
+	})
+	if err != nil {
+		return fmt.Errorf("permission request failed: %w", err)
+	}
+	if resp == nil || resp.Cancelled {
+		return errPermissionDenied
+	}
+	switch resp.OptionID {
+	case optionAllowOnce:
+		return nil
+	case optionAllowAlways:
+		a.mu.Lock()
+		a.alwaysAllowed[name] = true
+		a.mu.Unlock()
+		return nil
+	default:
+		return errPermissionDenied
+	}
+}
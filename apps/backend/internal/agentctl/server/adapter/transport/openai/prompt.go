@@ -0,0 +1,276 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+// maxToolRounds bounds the model requests of one turn, so a model that keeps
+// calling tools without converging cannot run forever.
+const maxToolRounds = 100
+
+// Stop reasons reported on the complete event, using the ACP names.
+const (
+	stopReasonEndTurn         = "end_turn"
+	stopReasonMaxTokens       = "max_tokens"
+	stopReasonMaxTurnRequests = "max_turn_requests"
+	stopReasonCancelled       = "cancelled"
+)
+
+// systemPrompt introduces the built-in tools. Kandev's own instructions
+// arrive with the user prompt, as they do for every agent.
+const systemPrompt = `You are a coding agent working in a software project workspace.
+Use the provided tools to explore the code, edit files, run commands and use git; paths are relative to the workspace root.
+Read a file before editing it, prefer edit_file for small changes, and verify your work (for example by running the tests) when you can.
+When the task is done, reply with a short summary of what you changed.`
+
+// turnState is the conversation and usage of one running turn.
+type turnState struct {
+	sessionID  string
+	generation uint64
+	model      string
+	messages   []chatMessage
+	usage      streams.PromptUsage
+	hasUsage   bool
+}
+
+// Prompt runs one turn: it streams the model's reply, executes the tools it
+// calls and sends their results back until the model answers without tool
+// calls. The turn ends with a complete event.
+func (a *Adapter) Prompt(ctx context.Context, message string, attachments []v1.MessageAttachment, promptGeneration uint64) error {
+	turnCtx, cancel := context.WithCancel(ctx)
+	turn := &activeTurn{cancel: cancel, done: make(chan struct{})}
+	state, err := a.beginTurn(turn, promptGeneration)
+	if err != nil {
+		cancel()
+		return err
+	}
+	defer a.endTurn(turn, state)
+
+	state.messages = append(state.messages, a.userMessage(message, attachments))
+	stopReason, err := a.runTurn(turnCtx, state)
+	if err != nil {
+		if errors.Is(turnCtx.Err(), context.Canceled) {
+			stopReason = stopReasonCancelled
+		} else {
+			return err
+		}
+	}
+
+	event := AgentEvent{
+		Type:             streams.EventTypeComplete,
+		SessionID:        state.sessionID,
+		PromptGeneration: promptGeneration,
+		Data:             map[string]any{"stop_reason": stopReason},
+	}
+	if state.hasUsage {
+		usage := state.usage
+		event.Usage = &usage
+	}
+	a.sendUpdate(event)
+	return nil
+}
+
+func (a *Adapter) beginTurn(turn *activeTurn, generation uint64) (*turnState, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case a.closed:
+		return nil, errors.New("adapter closed")
+	case a.client == nil || a.tools == nil:
+		return nil, errors.New("adapter not initialized")
+	case a.turn != nil:
+		return nil, errors.New("a prompt is already running")
+	case a.model == "":
+		return nil, fmt.Errorf("no model selected: set %s or choose a model for the profile", EnvModel)
+	}
+	a.turn = turn
+	messages := make([]chatMessage, 0, len(a.history)+2)
+	if len(a.history) == 0 {
+		messages = append(messages, chatMessage{Role: "system", Content: systemPrompt})
+	}
+	messages = append(messages, a.history...)
+	return &turnState{
+		sessionID:  a.sessionID,
+		generation: generation,
+		model:      a.model,
+		messages:   messages,
+	}, nil
+}
+
+// endTurn keeps the conversation for the next turn. Only whole rounds are
+// kept, so the history never ends with tool calls that have no results.
+func (a *Adapter) endTurn(turn *activeTurn, state *turnState) {
+	a.mu.Lock()
+	a.history = state.messages
+	a.turn = nil
+	a.mu.Unlock()
+	turn.cancel()
+	close(turn.done)
+}
+
+// userMessage builds the prompt message. Inline images are sent as image
+// parts; other attachments are saved to the workspace and referenced by path.
+func (a *Adapter) userMessage(message string, attachments []v1.MessageAttachment) chatMessage {
+	parts := []contentPart{{Type: "text", Text: message}}
+	for _, att := range attachments {
+		if att.Type == "image" && att.Data != "" && att.DeliveryMode != "path" {
+			url := fmt.Sprintf("data:%s;base64,%s", att.MimeType, att.Data)
+			parts = append(parts, contentPart{Type: "image_url", ImageURL: &imageURL{URL: url}})
+			continue
+		}
+		saved, err := a.attachments.SaveAttachments([]v1.MessageAttachment{att})
+		if err != nil || len(saved) == 0 {
+			a.logger.Warn("failed to save attachment to workspace", zap.String("name", att.Name), zap.Error(err))
+			continue
+		}
+		parts = append(parts, contentPart{Type: "text", Text: shared.BuildAttachmentPrompt(saved, att.DeliveryMode == "path")})
+	}
+	if len(parts) == 1 {
+		return chatMessage{Role: "user", Content: message}
+	}
+	return chatMessage{Role: "user", Content: parts}
+}
+
+// runTurn alternates model requests and tool executions and returns the
+// stop reason.
+func (a *Adapter) runTurn(ctx context.Context, state *turnState) (string, error) {
+	for range maxToolRounds {
+		reply, err := a.requestCompletion(ctx, state)
+		if err != nil {
+			return "", err
+		}
+		assistant := chatMessage{Role: "assistant", Content: reply.Content, ToolCalls: reply.ToolCalls}
+		if len(reply.ToolCalls) == 0 {
+			state.messages = append(state.messages, assistant)
+			if reply.FinishReason == "length" {
+				return stopReasonMaxTokens, nil
+			}
+			return stopReasonEndTurn, nil
+		}
+		round := []chatMessage{assistant}
+		for _, call := range reply.ToolCalls {
+			result := a.executeToolCall(ctx, state, call)
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			round = append(round, chatMessage{Role: "tool", ToolCallID: call.ID, Content: result})
+		}
+		state.messages = append(state.messages, round...)
+	}
+	return stopReasonMaxTurnRequests, nil
+}
+
+// requestCompletion streams one model reply, forwarding text and reasoning
+// as they arrive.
+func (a *Adapter) requestCompletion(ctx context.Context, state *turnState) (*completion, error) {
+	a.mu.RLock()
+	c, tools := a.client, a.tools
+	a.mu.RUnlock()
+	if tools == nil {
+		return nil, errors.New("adapter closed")
+	}
+	reply, err := c.streamChat(ctx, chatRequest{
+		Model:    state.model,
+		Messages: state.messages,
+		Tools:    tools.definitions(),
+	}, streamHandler{
+		onText: func(text string) {
+			a.sendUpdate(AgentEvent{
+				Type:             streams.EventTypeMessageChunk,
+				SessionID:        state.sessionID,
+				PromptGeneration: state.generation,
+				Text:             text,
+			})
+		},
+		onReasoning: func(text string) {
+			a.sendUpdate(AgentEvent{
+				Type:             streams.EventTypeReasoning,
+				SessionID:        state.sessionID,
+				PromptGeneration: state.generation,
+				ReasoningText:    text,
+			})
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("chat completion request failed: %w", err)
+	}
+	if reply.Usage != nil {
+		state.hasUsage = true
+		state.usage.InputTokens += reply.Usage.PromptTokens
+		state.usage.OutputTokens += reply.Usage.CompletionTokens
+		state.usage.OutputTokensPresent = true
+		state.usage.TotalTokens += reply.Usage.TotalTokens
+	}
+	return reply, nil
+}
+
+// executeToolCall runs one function call, reporting it as a tool call in the
+// chat, and returns the text sent back to the model.
+func (a *Adapter) executeToolCall(ctx context.Context, state *turnState, call toolCall) string {
+	a.mu.RLock()
+	tools, ws := a.tools, a.workspace
+	a.mu.RUnlock()
+
+	var t *tool
+	if tools != nil {
+		t = tools.lookup(call.Function.Name)
+	}
+	args := toolArgs{}
+	var argsErr error
+	if call.Function.Arguments != "" {
+		argsErr = json.Unmarshal([]byte(call.Function.Arguments), &args)
+	}
+	plan := toolPlan{title: call.Function.Name, payload: streams.NewGeneric(call.Function.Name, call.Function.Arguments)}
+	if t != nil && argsErr == nil {
+		plan = t.plan(args)
+	}
+	a.sendToolEvent(state, call, plan, streams.EventTypeToolCall, "in_progress")
+
+	var result string
+	var err error
+	switch {
+	case t == nil:
+		err = fmt.Errorf("unknown tool %q", call.Function.Name)
+	case argsErr != nil:
+		err = fmt.Errorf("invalid arguments: %w", argsErr)
+	case ws == nil:
+		err = errors.New("the workspace is not available")
+	default:
+		if err = a.authorize(ctx, state, call, plan); err == nil {
+			result, err = t.run(ctx, ws, args, plan.payload)
+		}
+	}
+
+	status := "complete"
+	if err != nil {
+		status = "error"
+		if ctx.Err() != nil {
+			status = stopReasonCancelled
+		}
+		result = "Error: " + err.Error()
+	}
+	a.sendToolEvent(state, call, plan, streams.EventTypeToolUpdate, status)
+	return result
+}
+
+func (a *Adapter) sendToolEvent(state *turnState, call toolCall, plan toolPlan, eventType, status string) {
+	a.sendUpdate(AgentEvent{
+		Type:              eventType,
+		SessionID:         state.sessionID,
+		PromptGeneration:  state.generation,
+		ToolCallID:        call.ID,
+		ToolName:          call.Function.Name,
+		ToolTitle:         plan.title,
+		ToolStatus:        status,
+		NormalizedPayload: plan.payload,
+	})
+}
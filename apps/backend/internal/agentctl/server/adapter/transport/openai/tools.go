@@ -0,0 +1,539 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/internal/agentctl/types"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// maxToolOutputBytes bounds the text a tool returns to the model. Local
+// models often have small context windows, and the full output stays
+// available in the workspace.
+const maxToolOutputBytes = 50_000
+
+// Defaults for tools whose arguments leave the size open.
+const (
+	defaultReadLimit   = 2000
+	defaultSearchLimit = 50
+)
+
+// toolArgs are the decoded arguments of a function call.
+type toolArgs map[string]any
+
+func (a toolArgs) str(key string) string {
+	value, _ := a[key].(string)
+	return value
+}
+
+func (a toolArgs) integer(key string, fallback int) int {
+	if value, ok := a[key].(float64); ok && value > 0 {
+		return int(value)
+	}
+	return fallback
+}
+
+func (a toolArgs) boolean(key string) bool {
+	value, _ := a[key].(bool)
+	return value
+}
+
+// toolPlan is what the chat shows for a call before it runs, and the
+// permission it needs, if any.
+type toolPlan struct {
+	title   string
+	payload *streams.NormalizedPayload
+	// permission is nil for tools that only read.
+	permission *permissionAsk
+}
+
+type permissionAsk struct {
+	actionType streams.PermissionActionType
+	details    map[string]any
+}
+
+// tool is a function offered to the model: a built-in workspace operation or
+// a tool of a connected MCP server.
+type tool struct {
+	definition toolDefinition
+	plan       func(args toolArgs) toolPlan
+	// run executes the call, records its output on the payload and returns
+	// the text handed back to the model.
+	run func(ctx context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error)
+}
+
+// toolSet holds the tools of a session.
+type toolSet struct {
+	byName  map[string]*tool
+	ordered []toolDefinition
+	servers []*mcpServerConn
+}
+
+func newToolSet(workDir string, mcpTools []*mcpTool) *toolSet {
+	set := &toolSet{byName: map[string]*tool{}}
+	for _, t := range builtinTools(workDir) {
+		set.add(t)
+	}
+	for _, t := range mcpTools {
+		set.add(t.tool)
+		set.addServer(t.server)
+	}
+	return set
+}
+
+func (s *toolSet) add(t *tool) {
+	name := t.definition.Function.Name
+	if _, exists := s.byName[name]; exists {
+		return
+	}
+	s.byName[name] = t
+	s.ordered = append(s.ordered, t.definition)
+}
+
+func (s *toolSet) addServer(server *mcpServerConn) {
+	for _, known := range s.servers {
+		if known == server {
+			return
+		}
+	}
+	s.servers = append(s.servers, server)
+}
+
+func (s *toolSet) definitions() []toolDefinition {
+	return s.ordered
+}
+
+func (s *toolSet) lookup(name string) *tool {
+	return s.byName[name]
+}
+
+func (s *toolSet) close() {
+	for _, server := range s.servers {
+		server.close()
+	}
+}
+
+func functionTool(name, description, parameters string) toolDefinition {
+	return toolDefinition{
+		Type: "function",
+		Function: functionSpec{
+			Name:        name,
+			Description: description,
+			Parameters:  json.RawMessage(parameters),
+		},
+	}
+}
+
+// builtinTools are the workspace operations every session gets.
+func builtinTools(workDir string) []*tool {
+	return []*tool{
+		readFileTool(),
+		writeFileTool(),
+		editFileTool(),
+		findFilesTool(),
+		searchContentTool(),
+		runShellTool(workDir),
+		gitStatusTool(),
+		gitDiffTool(),
+		gitCommitTool(),
+	}
+}
+
+func readFileTool() *tool {
+	return &tool{
+		definition: functionTool("read_file",
+			"Read a text file from the workspace. Returns up to `limit` lines starting at line `offset` (1-based).",
+			`{"type":"object","properties":{`+
+				`"path":{"type":"string","description":"File path relative to the workspace root"},`+
+				`"offset":{"type":"integer","description":"First line to return, 1-based (default 1)"},`+
+				`"limit":{"type":"integer","description":"Number of lines to return (default 2000)"}},`+
+				`"required":["path"]}`),
+		plan: func(args toolArgs) toolPlan {
+			path := args.str("path")
+			return toolPlan{
+				title:   "Read " + path,
+				payload: streams.NewReadFile(path, args.integer("offset", 0), args.integer("limit", 0)),
+			}
+		},
+		run: func(_ context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			content, err := ws.ReadFile(args.str("path"))
+			if err != nil {
+				return "", err
+			}
+			lines := strings.Split(content, "\n")
+			start := min(args.integer("offset", 1)-1, len(lines))
+			end := min(start+args.integer("limit", defaultReadLimit), len(lines))
+			selected := strings.Join(lines[start:end], "\n")
+			text, truncated := truncateOutput(selected)
+			payload.ReadFile().Output = &streams.ReadFileOutput{
+				Content:   text,
+				LineCount: end - start,
+				Truncated: truncated || end < len(lines),
+			}
+			if end < len(lines) {
+				text += fmt.Sprintf("\n[showing lines %d-%d of %d]", start+1, end, len(lines))
+			}
+			return text, nil
+		},
+	}
+}
+
+func writeFileTool() *tool {
+	return &tool{
+		definition: functionTool("write_file",
+			"Create a file or replace its whole content. Parent directories are created as needed.",
+			`{"type":"object","properties":{`+
+				`"path":{"type":"string","description":"File path relative to the workspace root"},`+
+				`"content":{"type":"string","description":"The complete new file content"}},`+
+				`"required":["path","content"]}`),
+		plan: func(args toolArgs) toolPlan {
+			path := args.str("path")
+			return toolPlan{
+				title: "Write " + path,
+				payload: streams.NewModifyFile(path, []streams.FileMutation{
+					{Type: streams.MutationReplace, Content: args.str("content")},
+				}),
+				permission: &permissionAsk{
+					actionType: types.ActionTypeFileWrite,
+					details:    map[string]any{"path": path},
+				},
+			}
+		},
+		run: func(_ context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			path := args.str("path")
+			if _, err := ws.ReadFile(path); err != nil {
+				payload.ModifyFile().Mutations[0].Type = streams.MutationCreate
+			}
+			if err := ws.WriteFile(path, args.str("content")); err != nil {
+				return "", err
+			}
+			return "Wrote " + path, nil
+		},
+	}
+}
+
+func editFileTool() *tool {
+	return &tool{
+		definition: functionTool("edit_file",
+			"Replace an exact piece of text in a file. `old_string` must match exactly once unless `replace_all` is set; include enough surrounding lines to make it unique.",
+			`{"type":"object","properties":{`+
+				`"path":{"type":"string","description":"File path relative to the workspace root"},`+
+				`"old_string":{"type":"string","description":"The exact text to replace"},`+
+				`"new_string":{"type":"string","description":"The replacement text"},`+
+				`"replace_all":{"type":"boolean","description":"Replace every occurrence"}},`+
+				`"required":["path","old_string","new_string"]}`),
+		plan: func(args toolArgs) toolPlan {
+			path := args.str("path")
+			return toolPlan{
+				title: "Edit " + path,
+				payload: streams.NewModifyFile(path, []streams.FileMutation{
+					{Type: streams.MutationPatch, OldContent: args.str("old_string"), NewContent: args.str("new_string")},
+				}),
+				permission: &permissionAsk{
+					actionType: types.ActionTypeFileWrite,
+					details:    map[string]any{"path": path},
+				},
+			}
+		},
+		run: func(_ context.Context, ws shared.Workspace, args toolArgs, _ *streams.NormalizedPayload) (string, error) {
+			path, oldString := args.str("path"), args.str("old_string")
+			if oldString == "" {
+				return "", errors.New("old_string must not be empty")
+			}
+			content, err := ws.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			count := strings.Count(content, oldString)
+			switch {
+			case count == 0:
+				return "", errors.New("old_string was not found in the file")
+			case count > 1 && !args.boolean("replace_all"):
+				return "", fmt.Errorf("old_string matches %d times; add context to make it unique or set replace_all", count)
+			}
+			updated := strings.ReplaceAll(content, oldString, args.str("new_string"))
+			if err := ws.WriteFile(path, updated); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Edited %s (%d replacement(s))", path, count), nil
+		},
+	}
+}
+
+func findFilesTool() *tool {
+	return &tool{
+		definition: functionTool("find_files",
+			"Find files in the workspace by fuzzy file name or path.",
+			`{"type":"object","properties":{`+
+				`"query":{"type":"string","description":"Part of a file name or path"},`+
+				`"limit":{"type":"integer","description":"Maximum number of results (default 50)"}},`+
+				`"required":["query"]}`),
+		plan: func(args toolArgs) toolPlan {
+			query := args.str("query")
+			return toolPlan{title: "Find " + query, payload: streams.NewCodeSearch(query, "", "", "")}
+		},
+		run: func(_ context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			files := ws.FindFiles(args.str("query"), args.integer("limit", defaultSearchLimit))
+			payload.CodeSearch().Output = &streams.CodeSearchOutput{Files: files, FileCount: len(files)}
+			if len(files) == 0 {
+				return "No files found.", nil
+			}
+			text, _ := truncateOutput(strings.Join(files, "\n"))
+			return text, nil
+		},
+	}
+}
+
+func searchContentTool() *tool {
+	return &tool{
+		definition: functionTool("search_content",
+			"Search the text of the workspace files. Returns matching lines as path:line: text.",
+			`{"type":"object","properties":{`+
+				`"query":{"type":"string","description":"Text to search for"},`+
+				`"limit":{"type":"integer","description":"Maximum number of matches per repository (default 50)"}},`+
+				`"required":["query"]}`),
+		plan: func(args toolArgs) toolPlan {
+			query := args.str("query")
+			return toolPlan{title: "Search " + query, payload: streams.NewCodeSearch("", query, "", "")}
+		},
+		run: func(ctx context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			results, err := ws.SearchContent(ctx, args.str("query"), args.integer("limit", defaultSearchLimit))
+			if err != nil {
+				return "", err
+			}
+			lines := make([]string, 0, len(results))
+			files := make([]string, 0, len(results))
+			seen := map[string]bool{}
+			for _, result := range results {
+				path := repositoryPath(result.RepositoryName, result.Path)
+				lines = append(lines, fmt.Sprintf("%s:%d: %s", path, result.Line, result.Preview))
+				if !seen[path] {
+					seen[path] = true
+					files = append(files, path)
+				}
+			}
+			payload.CodeSearch().Output = &streams.CodeSearchOutput{Files: files, FileCount: len(files)}
+			if len(lines) == 0 {
+				return "No matches found.", nil
+			}
+			text, _ := truncateOutput(strings.Join(lines, "\n"))
+			return text, nil
+		},
+	}
+}
+
+func runShellTool(workDir string) *tool {
+	return &tool{
+		definition: functionTool("run_shell",
+			"Run a shell command in the workspace root and return its combined output and exit code. Use it for builds, tests and other commands.",
+			`{"type":"object","properties":{`+
+				`"command":{"type":"string","description":"The shell command to run"},`+
+				`"description":{"type":"string","description":"A short description of what the command does"}},`+
+				`"required":["command"]}`),
+		plan: func(args toolArgs) toolPlan {
+			command := args.str("command")
+			return toolPlan{
+				title:   command,
+				payload: streams.NewShellExec(command, workDir, args.str("description"), 0, false),
+				permission: &permissionAsk{
+					actionType: types.ActionTypeCommand,
+					details:    map[string]any{"command": command, "cwd": workDir},
+				},
+			}
+		},
+		run: func(ctx context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			result, err := ws.RunShell(ctx, args.str("command"))
+			if err != nil {
+				return "", err
+			}
+			output, truncated := truncateOutput(result.Output)
+			exitCode := result.ExitCode
+			payload.ShellExec().Output = &streams.ShellExecOutput{
+				ExitCode:  &exitCode,
+				Stdout:    output,
+				Truncated: truncated || result.Truncated,
+			}
+			text := fmt.Sprintf("Exit code: %d\n%s", exitCode, output)
+			if exitCode != 0 {
+				return "", errors.New(text)
+			}
+			return text, nil
+		},
+	}
+}
+
+func gitStatusTool() *tool {
+	return &tool{
+		definition: functionTool("git_status",
+			"Show the branch and changed files of every repository in the workspace.",
+			`{"type":"object","properties":{}}`),
+		plan: func(args toolArgs) toolPlan {
+			return toolPlan{title: "Git status", payload: streams.NewGeneric("git_status", map[string]any(args))}
+		},
+		run: func(ctx context.Context, ws shared.Workspace, _ toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			statuses, err := ws.GitStatus(ctx)
+			if err != nil {
+				return "", err
+			}
+			text := formatGitStatus(statuses)
+			payload.Generic().Output = text
+			return text, nil
+		},
+	}
+}
+
+func gitDiffTool() *tool {
+	return &tool{
+		definition: functionTool("git_diff",
+			"Show the uncommitted changes of the workspace as unified diffs, optionally for one path.",
+			`{"type":"object","properties":{`+
+				`"path":{"type":"string","description":"Only show the diff of this file"}}}`),
+		plan: func(args toolArgs) toolPlan {
+			return toolPlan{title: "Git diff", payload: streams.NewGeneric("git_diff", map[string]any(args))}
+		},
+		run: func(ctx context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			statuses, err := ws.GitStatus(ctx)
+			if err != nil {
+				return "", err
+			}
+			text, _ := truncateOutput(formatGitDiff(statuses, args.str("path")))
+			payload.Generic().Output = text
+			return text, nil
+		},
+	}
+}
+
+func gitCommitTool() *tool {
+	return &tool{
+		definition: functionTool("git_commit",
+			"Stage all changes of a repository and commit them.",
+			`{"type":"object","properties":{`+
+				`"message":{"type":"string","description":"The commit message"},`+
+				`"repository":{"type":"string","description":"Repository directory in a multi-repository workspace; omit otherwise"}},`+
+				`"required":["message"]}`),
+		plan: func(args toolArgs) toolPlan {
+			return toolPlan{
+				title:   "Commit: " + firstLine(args.str("message")),
+				payload: streams.NewGeneric("git_commit", map[string]any(args)),
+				permission: &permissionAsk{
+					actionType: types.ActionTypeCommand,
+					details: map[string]any{
+						"command":    "git commit",
+						"message":    args.str("message"),
+						"repository": args.str("repository"),
+					},
+				},
+			}
+		},
+		run: func(ctx context.Context, ws shared.Workspace, args toolArgs, payload *streams.NormalizedPayload) (string, error) {
+			message := args.str("message")
+			if strings.TrimSpace(message) == "" {
+				return "", errors.New("message must not be empty")
+			}
+			output, err := ws.GitCommit(ctx, args.str("repository"), message)
+			if err != nil {
+				return "", err
+			}
+			payload.Generic().Output = output
+			return output, nil
+		},
+	}
+}
+
+// formatGitStatus renders statuses like `git status --short`.
+func formatGitStatus(statuses []streams.GitStatusUpdate) string {
+	if len(statuses) == 0 {
+		return "No git repositories in the workspace."
+	}
+	var sb strings.Builder
+	for _, status := range statuses {
+		name := status.RepositoryName
+		if name == "" {
+			name = "."
+		}
+		fmt.Fprintf(&sb, "Repository %s on branch %s\n", name, status.Branch)
+		if len(status.Files) == 0 {
+			sb.WriteString("  nothing to commit, working tree clean\n")
+			continue
+		}
+		for _, path := range sortedKeys(status.Files) {
+			file := status.Files[path]
+			staged := ""
+			if file.Staged {
+				staged = " (staged)"
+			}
+			fmt.Fprintf(&sb, "  %-9s %s%s\n", file.Status, path, staged)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatGitDiff(statuses []streams.GitStatusUpdate, only string) string {
+	var sb strings.Builder
+	for _, status := range statuses {
+		for _, path := range sortedKeys(status.Files) {
+			file := status.Files[path]
+			full := repositoryPath(status.RepositoryName, path)
+			if only != "" && only != path && only != full {
+				continue
+			}
+			switch {
+			case file.Diff != "":
+				sb.WriteString(strings.TrimRight(file.Diff, "\n"))
+				sb.WriteString("\n")
+			case file.DiffSkipReason != "":
+				fmt.Fprintf(&sb, "%s: diff omitted (%s)\n", full, file.DiffSkipReason)
+			}
+		}
+	}
+	if sb.Len() == 0 {
+		return "No changes."
+	}
+	return sb.String()
+}
+
+func sortedKeys(files map[string]streams.FileInfo) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// repositoryPath prefixes a repository-relative path with its repository
+// directory in multi-repository workspaces.
+func repositoryPath(repository, path string) string {
+	if repository == "" || strings.HasPrefix(path, repository+"/") {
+		return path
+	}
+	return repository + "/" + path
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+// truncateOutput bounds text returned to the model, cutting on a rune
+// boundary.
+func truncateOutput(text string) (string, bool) {
+	if len(text) <= maxToolOutputBytes {
+		return text, false
+	}
+	cut := maxToolOutputBytes
+	for cut > 0 && !isRuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "\n[output truncated]", true
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -39,6 +39,7 @@ const (
 	envACPMaxFiles     = "KANDEV_DEBUG_ACP_MAX_FILES"
 	envACPRetentionHrs = "KANDEV_DEBUG_ACP_RETENTION_HOURS"
 	envACPMaxFileBytes = "KANDEV_DEBUG_ACP_MAX_FILE_BYTES"
+	envACPRecordDir    = "KANDEV_ACP_RECORD_DIR"
 )
 
 const (
@@ -26,6 +26,7 @@ const (
 	ProtocolOpenCode   = "opencode"
 	ProtocolAmp        = "amp"
 	ProtocolCopilot    = "copilot"
+	ProtocolOpenAI     = "openai"
 )
 
 // ACPDebugEnabled reports whether agent-message debug logging is on.
@@ -38,6 +39,12 @@ func ACPDebugEnabled() bool { return debugMode }
 // env so they resolve to the same directory.
 func ACPLogDir() string { return resolveACPLogDir() }
 
+// ACPRecordDir returns the directory full ACP session recordings are written
+// to, or "" when recording is off. Unlike the debug logs, a recording keeps
+// every frame in both directions with its timing, so the mock agent can
+// replay it (see internal/agentctl/acprecord).
+func ACPRecordDir() string { return os.Getenv(envACPRecordDir) }
+
 // FlushACPLogs makes retained raw/normalized files complete before an
 // explicit diagnostic bundle request reads them. It is intentionally not used
 // on the frame hot path.
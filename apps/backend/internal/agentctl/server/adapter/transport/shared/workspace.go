@@ -0,0 +1,41 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// Workspace is the task workspace as seen by an adapter that runs the agent
+// loop inside agentctl instead of in a subprocess. The process manager
+// implements it over its own workspace trackers, git operators and process
+// runner, so in-process tools get the same path confinement, change
+// notifications and process ownership as the workspace HTTP API.
+//
+// Paths are relative to the task root; in multi-repository workspaces they
+// start with the repository directory.
+type Workspace interface {
+	// ReadFile returns a text file's content.
+	ReadFile(path string) (string, error)
+	// WriteFile creates or replaces a file, creating parent directories.
+	WriteFile(path, content string) error
+	// FindFiles returns up to limit paths matching a fuzzy file-name query.
+	FindFiles(query string, limit int) []string
+	// SearchContent returns up to limit matching lines per repository.
+	SearchContent(ctx context.Context, query string, limit int) ([]streams.WorkspaceContentSearchResult, error)
+	// GitStatus returns the current status of every repository.
+	GitStatus(ctx context.Context) ([]streams.GitStatusUpdate, error)
+	// GitCommit stages all changes of a repository ("" for a single-repository
+	// workspace) and commits them.
+	GitCommit(ctx context.Context, repository, message string) (string, error)
+	// RunShell runs a shell command in the workspace with the agent
+	// environment and returns its combined, bounded output.
+	RunShell(ctx context.Context, command string) (ShellResult, error)
+}
+
+// ShellResult is the outcome of Workspace.RunShell.
+type ShellResult struct {
+	Output    string
+	ExitCode  int
+	Truncated bool
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/server/checkpoint"
+	"github.com/kandev/kandev/internal/agentctl/server/process"
+)
+
+// CreateCheckpointRequest is the body for POST /api/v1/checkpoints and
+// POST /api/v1/checkpoints/apply.
+type CreateCheckpointRequest struct {
+	ID string `json:"id"`
+}
+
+// RestoreCheckpointRequest is the body for POST /api/v1/checkpoints/restore.
+// Discard lists checkpoints to delete once the restore succeeds, normally
+// those taken after ID.
+type RestoreCheckpointRequest struct {
+	ID      string   `json:"id"`
+	Discard []string `json:"discard,omitempty"`
+}
+
+// handleListCheckpoints returns the workspace's checkpoints, oldest first.
+func (s *Server) handleListCheckpoints(c *gin.Context) {
+	list, err := s.procMgr.ListCheckpoints(c.Request.Context())
+	if err != nil {
+		s.handleCheckpointError(c, "list", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"checkpoints": list})
+}
+
+// handleCreateCheckpoint snapshots the workspace. Called by the backend at
+// the start of every agent turn, keyed by the turn ID.
+func (s *Server) handleCreateCheckpoint(c *gin.Context) {
+	var req CreateCheckpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{errKey: "invalid request: " + err.Error()})
+		return
+	}
+	cp, err := s.procMgr.CreateCheckpoint(c.Request.Context(), req.ID)
+	if err != nil {
+		s.handleCheckpointError(c, "create", err)
+		return
+	}
+	c.JSON(http.StatusOK, cp)
+}
+
+// handleRestoreCheckpoint rewinds the workspace files to a checkpoint.
+func (s *Server) handleRestoreCheckpoint(c *gin.Context) {
+	var req RestoreCheckpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{errKey: "invalid request: " + err.Error()})
+		return
+	}
+	cp, err := s.procMgr.RestoreCheckpoint(c.Request.Context(), req.ID, req.Discard)
+	if err != nil {
+		s.handleCheckpointError(c, "restore", err)
+		return
+	}
+	c.JSON(http.StatusOK, cp)
+}
+
+// handleApplyCheckpoint seeds the workspace with a checkpoint taken in
+// another worktree of the same repository.
+func (s *Server) handleApplyCheckpoint(c *gin.Context) {
+	var req CreateCheckpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{errKey: "invalid request: " + err.Error()})
+		return
+	}
+	cp, err := s.procMgr.ApplyCheckpoint(c.Request.Context(), req.ID)
+	if err != nil {
+		s.handleCheckpointError(c, "apply", err)
+		return
+	}
+	c.JSON(http.StatusOK, cp)
+}
+
+func (s *Server) handleCheckpointError(c *gin.Context, operation string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, checkpoint.ErrInvalidID):
+		status = http.StatusBadRequest
+	case errors.Is(err, checkpoint.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, checkpoint.ErrSameWorktree):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, process.ErrOperationInProgress):
+		status = http.StatusConflict
+	case errors.Is(err, process.ErrNoRepository):
+		status = http.StatusUnprocessableEntity
+	default:
+		s.logger.Error("checkpoint operation failed", zap.String("operation", operation), zap.Error(err))
+	}
+	c.JSON(status, gin.H{errKey: err.Error()})
+}
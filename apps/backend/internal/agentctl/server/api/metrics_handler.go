@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kandev/kandev/internal/agentctl/server/process"
 	"github.com/kandev/kandev/internal/system/metrics"
 )
 
@@ -21,9 +22,28 @@ func (s *Server) handleSystemMetrics(c *gin.Context) {
 	snapshot.ID = "agentctl"
 	snapshot.Label = "Execution"
 	snapshot.Kind = "execution"
+	if usage, ok := s.procMgr.ResourceUsage(); ok {
+		applySessionUsage(&snapshot, usage)
+	}
 	c.JSON(http.StatusOK, snapshot)
 }
 
+// applySessionUsage reports a confined session's memory and CPU against its
+// own limits rather than the whole machine, which is what decides when it
+// gets killed or throttled.
+func applySessionUsage(snapshot *metrics.SourceSnapshot, usage process.SessionResourceUsage) {
+	for i := range snapshot.Metrics {
+		sample := &snapshot.Metrics[i]
+		switch {
+		case sample.ID == metrics.MetricMemoryPercent && usage.Limits.MemoryBytes > 0:
+			percent := float64(usage.Usage.MemoryBytes) / float64(usage.Limits.MemoryBytes) * 100
+			sample.Value, sample.Available, sample.Error = &percent, true, ""
+		case sample.ID == metrics.MetricCPUPercent && usage.CPUPercent != nil:
+			sample.Value, sample.Available, sample.Error = usage.CPUPercent, true, ""
+		}
+	}
+}
+
 func splitMetrics(raw string) []string {
 	if raw == "" {
 		return nil
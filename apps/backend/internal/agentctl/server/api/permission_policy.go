@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+)
+
+// SetPermissionPolicyRequest is the body for PUT /api/v1/agent/permission-policy.
+// A nil policy clears it, leaving every request to the existing approval flow.
+type SetPermissionPolicyRequest struct {
+	Policy *permpolicy.Policy `json:"policy"`
+}
+
+// handleSetPermissionPolicy replaces the rules agentctl evaluates before a
+// permission request reaches the UI. The backend calls this when the task
+// enters a workflow step, with the step's rules ahead of the profile's.
+func (s *Server) handleSetPermissionPolicy(c *gin.Context) {
+	var req SetPermissionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{errKey: "invalid request: " + err.Error()})
+		return
+	}
+	if err := s.procMgr.SetPermissionPolicy(req.Policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{errKey: "invalid permission policy: " + err.Error()})
+		return
+	}
+	rules := 0
+	if req.Policy != nil {
+		rules = len(req.Policy.Rules)
+	}
+	s.logger.Info("permission policy updated", zap.Int("rules", rules))
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
@@ -100,6 +100,7 @@ func (s *Server) setupRoutes() {
 
 		// Process control
 		api.POST("/agent/configure", s.handleAgentConfigure)
+		api.PUT("/agent/permission-policy", s.handleSetPermissionPolicy)
 		api.POST("/start", s.handleStart)
 		api.POST("/stop", s.handleStop)
 
@@ -206,6 +207,12 @@ func (s *Server) setupRoutes() {
 		api.GET("/git/cumulative-diff", s.handleGitCumulativeDiff)
 		api.GET("/git/status", s.handleGitStatus)
 		api.GET("/git/status/multi", s.handleGitStatusMulti)
+
+		// Per-turn workspace checkpoints
+		api.GET("/checkpoints", s.handleListCheckpoints)
+		api.POST("/checkpoints", s.handleCreateCheckpoint)
+		api.POST("/checkpoints/restore", s.handleRestoreCheckpoint)
+		api.POST("/checkpoints/apply", s.handleApplyCheckpoint)
 	}
 
 	// Utility agent routes
@@ -0,0 +1,129 @@
+// Package cgroup confines a session's processes to a cgroup v2 group with
+// memory, CPU and process-count limits. It is Linux-only; on other platforms
+// New reports ErrUnsupported and sessions run unconstrained.
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned when the host cannot enforce limits: not Linux,
+// no unified cgroup v2 hierarchy, or no controllers delegated to agentctl.
+var ErrUnsupported = errors.New("cgroup v2 resource limits are not available")
+
+// cpuPeriodUsec is the cpu.max period. A quota of cores*period lets the group
+// use that many CPUs' worth of time per period, matching Docker's --cpus.
+const cpuPeriodUsec = 100000
+
+// Limits caps the resources of one session's process tree. Zero fields are
+// unlimited.
+type Limits struct {
+	MemoryBytes int64   `json:"memory_bytes,omitempty"`
+	CPUCores    float64 `json:"cpu_cores,omitempty"`
+	Pids        int64   `json:"pids,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l.MemoryBytes <= 0 && l.CPUCores <= 0 && l.Pids <= 0
+}
+
+// controllers lists the cgroup controllers the limits need.
+func (l Limits) controllers() []string {
+	var out []string
+	if l.CPUCores > 0 {
+		out = append(out, "cpu")
+	}
+	if l.MemoryBytes > 0 {
+		out = append(out, "memory")
+	}
+	if l.Pids > 0 {
+		out = append(out, "pids")
+	}
+	return out
+}
+
+// setting is one interface file write that applies a limit.
+type setting struct {
+	file  string
+	value string
+}
+
+// settings returns the interface file writes for the limits, in order. With a
+// memory limit the whole group is OOM-killed together: a half-killed agent
+// whose tool subprocess died is harder to reason about than a clean exit.
+func (l Limits) settings() []setting {
+	var out []setting
+	if l.MemoryBytes > 0 {
+		out = append(out,
+			setting{file: "memory.max", value: strconv.FormatInt(l.MemoryBytes, 10)},
+			setting{file: "memory.oom.group", value: "1"},
+		)
+	}
+	if l.CPUCores > 0 {
+		quota := int64(l.CPUCores * cpuPeriodUsec)
+		if quota < 1000 {
+			quota = 1000
+		}
+		out = append(out, setting{file: "cpu.max", value: fmt.Sprintf("%d %d", quota, cpuPeriodUsec)})
+	}
+	if l.Pids > 0 {
+		out = append(out, setting{file: "pids.max", value: strconv.FormatInt(l.Pids, 10)})
+	}
+	return out
+}
+
+// Usage is a point-in-time reading of a group.
+type Usage struct {
+	MemoryBytes     int64
+	MemoryPeakBytes int64
+	CPUUsageUsec    int64
+	Pids            int64
+}
+
+// Events counts limit hits since the group was created.
+type Events struct {
+	// OOMKills counts processes the kernel OOM-killed at memory.max.
+	OOMKills int64
+	// PidsMax counts forks refused at pids.max.
+	PidsMax int64
+}
+
+// unifiedPath returns the cgroup v2 path from /proc/self/cgroup content: the
+// "0::<path>" line of the unified hierarchy.
+func unifiedPath(procSelfCgroup string) (string, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(procSelfCgroup))
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok && strings.HasPrefix(path, "/") {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// parseKeyed parses a flat-keyed interface file such as memory.events or
+// cpu.stat ("key value" per line).
+func parseKeyed(content string) map[string]int64 {
+	out := make(map[string]int64)
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			out[key] = n
+		}
+	}
+	return out
+}
+
+// parseSingle parses a single-value interface file such as memory.current.
+// "max" reads as 0.
+func parseSingle(content string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(content), 10, 64)
+	return n
+}
@@ -0,0 +1,320 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const mountPoint = "/sys/fs/cgroup"
+
+const (
+	// agentctlLeafDir receives the processes of agentctl's own cgroup. cgroup
+	// v2 only lets a group hand controllers to its children while it holds no
+	// processes itself, so they move one level down first.
+	agentctlLeafDir = "kandev-agentctl"
+	// sessionsDir is the parent of every session group.
+	sessionsDir = "kandev-sessions"
+)
+
+// supportedControllers are the controllers Limits can use.
+var supportedControllers = []string{"cpu", "memory", "pids"}
+
+// enableAttempts bounds retries when a process forks into agentctl's cgroup
+// between moving its processes out and enabling controllers.
+const enableAttempts = 3
+
+// closeTimeout bounds how long Close waits for killed processes to leave the
+// group before it can be removed.
+const closeTimeout = 2 * time.Second
+
+var parent struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Group is one session's cgroup.
+type Group struct {
+	path string
+	dir  *os.File
+}
+
+// New creates a group named name under agentctl's session parent and applies
+// limits to it. The first call prepares the parent: agentctl's own cgroup must
+// be delegated to it (for example a systemd unit with Delegate=yes), and every
+// process in that cgroup is moved to a kandev-agentctl child so controllers
+// can be enabled.
+func New(name string, limits Limits) (*Group, error) {
+	parentPath, err := sessionParent()
+	if err != nil {
+		return nil, err
+	}
+	return newGroup(parentPath, name, limits)
+}
+
+func sessionParent() (string, error) {
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+	if parent.path != "" {
+		return parent.path, nil
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	rel, ok := unifiedPath(string(data))
+	if !ok {
+		return "", fmt.Errorf("%w: no unified cgroup v2 hierarchy", ErrUnsupported)
+	}
+	path, err := prepareParent(filepath.Join(mountPoint, rel), rel == "/")
+	if err != nil {
+		return "", err
+	}
+	parent.path = path
+	return path, nil
+}
+
+// prepareParent enables the supported controllers below base and creates the
+// sessions directory with them enabled for its children.
+func prepareParent(base string, isRoot bool) (string, error) {
+	available, err := readList(filepath.Join(base, "cgroup.controllers"))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	var controllers []string
+	for _, c := range supportedControllers {
+		if slices.Contains(available, c) {
+			controllers = append(controllers, c)
+		}
+	}
+	if len(controllers) == 0 {
+		return "", fmt.Errorf("%w: no cpu, memory or pids controller is delegated to %s", ErrUnsupported, base)
+	}
+	if err := enableBelowBase(base, controllers, isRoot); err != nil {
+		return "", err
+	}
+	sessions := filepath.Join(base, sessionsDir)
+	if err := os.Mkdir(sessions, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if err := enableControllers(sessions, controllers); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return sessions, nil
+}
+
+func enableBelowBase(base string, controllers []string, isRoot bool) error {
+	enabled, err := readList(filepath.Join(base, "cgroup.subtree_control"))
+	if err == nil && containsAll(enabled, controllers) {
+		return nil
+	}
+	// The root cgroup is exempt from the no-internal-processes rule.
+	if isRoot {
+		if err := enableControllers(base, controllers); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnsupported, err)
+		}
+		return nil
+	}
+	leaf := filepath.Join(base, agentctlLeafDir)
+	for attempt := 1; ; attempt++ {
+		if err := moveProcesses(base, leaf); err != nil {
+			return fmt.Errorf("%w: move processes out of %s: %v", ErrUnsupported, base, err)
+		}
+		err := enableControllers(base, controllers)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, syscall.EBUSY) || attempt == enableAttempts {
+			return fmt.Errorf("%w: %v", ErrUnsupported, err)
+		}
+	}
+}
+
+func enableControllers(dir string, controllers []string) error {
+	plus := make([]string, len(controllers))
+	for i, c := range controllers {
+		plus[i] = "+" + c
+	}
+	return writeFile(filepath.Join(dir, "cgroup.subtree_control"), strings.Join(plus, " "))
+}
+
+// moveProcesses moves every process of the from group into the to group,
+// creating it if needed. Processes that exit meanwhile are skipped.
+func moveProcesses(from, to string) error {
+	if err := os.Mkdir(to, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	pids, err := readList(filepath.Join(from, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := writeFile(filepath.Join(to, "cgroup.procs"), pid); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return err
+		}
+	}
+	return nil
+}
+
+// newGroup creates the group directory under parentPath and applies limits.
+func newGroup(parentPath, name string, limits Limits) (*Group, error) {
+	enabled, err := readList(filepath.Join(parentPath, "cgroup.subtree_control"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	for _, c := range limits.controllers() {
+		if !slices.Contains(enabled, c) {
+			return nil, fmt.Errorf("%w: the %s controller is not delegated to agentctl", ErrUnsupported, c)
+		}
+	}
+	path := filepath.Join(parentPath, name)
+	if err := os.Mkdir(path, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("create cgroup %s: %w", path, err)
+	}
+	for _, s := range limits.settings() {
+		if err := writeFile(filepath.Join(path, s.file), s.value); err != nil {
+			_ = os.Remove(path)
+			return nil, fmt.Errorf("set %s: %w", s.file, err)
+		}
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("open cgroup %s: %w", path, err)
+	}
+	return &Group{path: path, dir: dir}, nil
+}
+
+// Path returns the group's directory.
+func (g *Group) Path() string {
+	return g.path
+}
+
+// Place makes cmd start inside the group, so the process and everything it
+// spawns are confined from their first instruction. Call it after any other
+// SysProcAttr setup, which may replace the struct.
+func (g *Group) Place(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(g.dir.Fd())
+}
+
+// Usage reads the group's current consumption. Readings for controllers that
+// are not enabled are zero.
+func (g *Group) Usage() (Usage, error) {
+	if _, err := os.Stat(g.path); err != nil {
+		return Usage{}, err
+	}
+	var usage Usage
+	usage.MemoryBytes = parseSingle(g.readOptional("memory.current"))
+	usage.MemoryPeakBytes = parseSingle(g.readOptional("memory.peak"))
+	usage.CPUUsageUsec = parseKeyed(g.readOptional("cpu.stat"))["usage_usec"]
+	usage.Pids = parseSingle(g.readOptional("pids.current"))
+	return usage, nil
+}
+
+// Events reads the group's limit hit counters.
+func (g *Group) Events() (Events, error) {
+	if _, err := os.Stat(g.path); err != nil {
+		return Events{}, err
+	}
+	return Events{
+		OOMKills: parseKeyed(g.readOptional("memory.events"))["oom_kill"],
+		PidsMax:  parseKeyed(g.readOptional("pids.events"))["max"],
+	}, nil
+}
+
+// Close kills whatever is left in the group and removes it.
+func (g *Group) Close() error {
+	err := g.kill()
+	deadline := time.Now().Add(closeTimeout)
+	for {
+		rmErr := os.Remove(g.path)
+		if rmErr == nil || errors.Is(rmErr, fs.ErrNotExist) {
+			break
+		}
+		if time.Now().After(deadline) {
+			err = errors.Join(err, rmErr)
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return errors.Join(err, g.dir.Close())
+}
+
+// kill uses cgroup.kill where the kernel has it (5.14+) and signals each
+// member otherwise.
+func (g *Group) kill() error {
+	err := writeFile(filepath.Join(g.path, "cgroup.kill"), "1")
+	if err == nil || !g.exists() {
+		return nil
+	}
+	pids, readErr := readList(filepath.Join(g.path, "cgroup.procs"))
+	if readErr != nil {
+		return errors.Join(err, readErr)
+	}
+	for _, raw := range pids {
+		pid, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			continue
+		}
+		if killErr := syscall.Kill(pid, syscall.SIGKILL); killErr != nil && !errors.Is(killErr, syscall.ESRCH) {
+			return killErr
+		}
+	}
+	return nil
+}
+
+func (g *Group) exists() bool {
+	_, err := os.Stat(g.path)
+	return err == nil
+}
+
+func (g *Group) readOptional(file string) string {
+	data, err := os.ReadFile(filepath.Join(g.path, file))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func readList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFile writes an interface file. Interface files always exist on
+// cgroupfs, so a missing one means the kernel lacks the feature.
+func writeFile(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(value)
+	return errors.Join(err, f.Close())
+}
@@ -0,0 +1,115 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeCgroup lays out a directory like cgroupfs would, with the interface
+// files the kernel creates for a new group.
+func fakeCgroup(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestNewGroupAppliesLimits(t *testing.T) {
+	parentDir := t.TempDir()
+	fakeCgroup(t, parentDir, map[string]string{"cgroup.subtree_control": "cpu memory pids\n"})
+	groupDir := filepath.Join(parentDir, "session-1")
+	fakeCgroup(t, groupDir, map[string]string{
+		"memory.max": "max", "memory.oom.group": "0", "cpu.max": "max 100000", "pids.max": "max",
+		"memory.current": "1048576\n", "cpu.stat": "usage_usec 2500\nuser_usec 2000\n", "pids.current": "7\n",
+		"memory.events": "low 0\nhigh 0\nmax 4\noom 1\noom_kill 1\n", "pids.events": "max 2\n",
+	})
+
+	group, err := newGroup(parentDir, "session-1", Limits{MemoryBytes: 512 << 20, CPUCores: 2, Pids: 64})
+	if err != nil {
+		t.Fatalf("newGroup: %v", err)
+	}
+	t.Cleanup(func() { _ = group.dir.Close() })
+
+	for file, want := range map[string]string{
+		"memory.max": "536870912", "memory.oom.group": "1", "cpu.max": "200000 100000", "pids.max": "64",
+	} {
+		if got := readFile(t, filepath.Join(groupDir, file)); got != want {
+			t.Errorf("%s = %q, want %q", file, got, want)
+		}
+	}
+	usage, err := group.Usage()
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if usage != (Usage{MemoryBytes: 1 << 20, CPUUsageUsec: 2500, Pids: 7}) {
+		t.Errorf("Usage = %+v", usage)
+	}
+	events, err := group.Events()
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if events != (Events{OOMKills: 1, PidsMax: 2}) {
+		t.Errorf("Events = %+v", events)
+	}
+}
+
+func TestNewGroupRequiresDelegatedControllers(t *testing.T) {
+	parentDir := t.TempDir()
+	fakeCgroup(t, parentDir, map[string]string{"cgroup.subtree_control": "pids\n"})
+
+	_, err := newGroup(parentDir, "session-1", Limits{MemoryBytes: 1 << 30})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("newGroup without the memory controller = %v, want ErrUnsupported", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(parentDir, "session-1")); !os.IsNotExist(statErr) {
+		t.Fatal("a group was created even though its limits cannot apply")
+	}
+}
+
+func TestPrepareParentEnablesControllersForSessions(t *testing.T) {
+	base := t.TempDir()
+	fakeCgroup(t, base, map[string]string{
+		"cgroup.controllers": "cpuset cpu io memory pids\n", "cgroup.subtree_control": "", "cgroup.procs": "",
+	})
+	fakeCgroup(t, filepath.Join(base, agentctlLeafDir), map[string]string{"cgroup.procs": ""})
+	fakeCgroup(t, filepath.Join(base, sessionsDir), map[string]string{"cgroup.subtree_control": ""})
+
+	sessions, err := prepareParent(base, false)
+	if err != nil {
+		t.Fatalf("prepareParent: %v", err)
+	}
+	if sessions != filepath.Join(base, sessionsDir) {
+		t.Fatalf("sessions parent = %q", sessions)
+	}
+	for _, dir := range []string{base, sessions} {
+		if got := readFile(t, filepath.Join(dir, "cgroup.subtree_control")); got != "+cpu +memory +pids" {
+			t.Errorf("%s subtree_control = %q", dir, got)
+		}
+	}
+}
+
+func TestPrepareParentWithoutControllers(t *testing.T) {
+	base := t.TempDir()
+	fakeCgroup(t, base, map[string]string{"cgroup.controllers": "cpuset io\n"})
+	if _, err := prepareParent(base, false); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("prepareParent = %v, want ErrUnsupported", err)
+	}
+}
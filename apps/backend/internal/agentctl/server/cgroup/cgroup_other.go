@@ -0,0 +1,28 @@
+//go:build !linux
+
+package cgroup
+
+import "os/exec"
+
+// Group is one session's cgroup. Outside Linux it is never created.
+type Group struct{}
+
+// New reports ErrUnsupported: cgroups exist only on Linux.
+func New(string, Limits) (*Group, error) {
+	return nil, ErrUnsupported
+}
+
+// Path returns the group's directory.
+func (g *Group) Path() string { return "" }
+
+// Place makes cmd start inside the group.
+func (g *Group) Place(*exec.Cmd) {}
+
+// Usage reads the group's current consumption.
+func (g *Group) Usage() (Usage, error) { return Usage{}, ErrUnsupported }
+
+// Events reads the group's limit hit counters.
+func (g *Group) Events() (Events, error) { return Events{}, ErrUnsupported }
+
+// Close kills whatever is left in the group and removes it.
+func (g *Group) Close() error { return nil }
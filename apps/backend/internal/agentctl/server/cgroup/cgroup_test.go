@@ -0,0 +1,68 @@
+package cgroup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLimitsSettings(t *testing.T) {
+	limits := Limits{MemoryBytes: 2 << 30, CPUCores: 1.5, Pids: 512}
+	want := []setting{
+		{file: "memory.max", value: "2147483648"},
+		{file: "memory.oom.group", value: "1"},
+		{file: "cpu.max", value: "150000 100000"},
+		{file: "pids.max", value: "512"},
+	}
+	if got := limits.settings(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("settings = %v, want %v", got, want)
+	}
+	if got := limits.controllers(); !reflect.DeepEqual(got, []string{"cpu", "memory", "pids"}) {
+		t.Fatalf("controllers = %v", got)
+	}
+}
+
+func TestLimitsPartialAndZero(t *testing.T) {
+	if !(Limits{}).IsZero() {
+		t.Fatal("empty limits should be zero")
+	}
+	limits := Limits{CPUCores: 0.001}
+	if limits.IsZero() {
+		t.Fatal("CPU-only limits reported as zero")
+	}
+	want := []setting{{file: "cpu.max", value: "1000 100000"}}
+	if got := limits.settings(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("tiny CPU quota settings = %v, want the 1ms floor %v", got, want)
+	}
+}
+
+func TestUnifiedPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		ok      bool
+	}{
+		{name: "unified only", content: "0::/user.slice/user-1000.slice/session-2.scope\n", want: "/user.slice/user-1000.slice/session-2.scope", ok: true},
+		{name: "hybrid", content: "12:pids:/user.slice\n1:name=systemd:/user.slice\n0::/user.slice\n", want: "/user.slice", ok: true},
+		{name: "container root", content: "0::/\n", want: "/", ok: true},
+		{name: "v1 only", content: "4:memory:/docker/abc\n1:name=systemd:/docker/abc\n", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := unifiedPath(tt.content)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("unifiedPath = %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseKeyed(t *testing.T) {
+	got := parseKeyed("low 0\nhigh 3\nmax 12\noom 2\noom_kill 1\noom_group_kill 1\n")
+	if got["oom_kill"] != 1 || got["max"] != 12 {
+		t.Fatalf("parseKeyed = %v", got)
+	}
+	if parseSingle("max\n") != 0 || parseSingle("4096\n") != 4096 {
+		t.Fatal("parseSingle misread a single-value file")
+	}
+}
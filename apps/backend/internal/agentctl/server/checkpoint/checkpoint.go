@@ -0,0 +1,418 @@
+// Package checkpoint snapshots a git working tree into hidden refs so it can
+// be restored later. A checkpoint is a commit built from a throwaway index
+// holding every tracked and untracked (non-ignored) file, parented on the
+// HEAD it was taken at; neither the real index nor the working tree is
+// touched while capturing.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kandev/kandev/internal/common/securityutil"
+	"github.com/kandev/kandev/internal/common/subproc"
+)
+
+// RefPrefix namespaces checkpoint refs. They live outside refs/heads and
+// refs/tags so branch listings, pushes and fetches never see them.
+const RefPrefix = "refs/kandev/checkpoints/"
+
+// BackupRef holds the working tree as it was just before the last restore,
+// so a rewind can itself be undone by hand.
+const BackupRef = "refs/kandev/rewind-backup"
+
+var (
+	// ErrNotFound is returned when no checkpoint exists under the given ID.
+	ErrNotFound = errors.New("checkpoint not found")
+	// ErrInvalidID is returned for IDs that cannot be used in a ref name.
+	ErrInvalidID = errors.New("invalid checkpoint id")
+	// ErrSameWorktree is returned when a checkpoint is applied to the working
+	// tree it was taken in; that is what Restore is for.
+	ErrSameWorktree = errors.New("checkpoint belongs to this working tree")
+)
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// ValidID reports whether id can name a checkpoint.
+func ValidID(id string) bool {
+	return validID.MatchString(id) && !strings.Contains(id, "..") && !strings.HasSuffix(id, ".lock")
+}
+
+// Checkpoint is one snapshot of a working tree.
+type Checkpoint struct {
+	ID string `json:"id"`
+	// Commit is the snapshot commit the checkpoint ref points at.
+	Commit string `json:"commit"`
+	// Head is the commit HEAD pointed at; empty on an unborn branch.
+	Head string `json:"head,omitempty"`
+	// Branch is the branch HEAD was on; empty when detached.
+	Branch string `json:"branch,omitempty"`
+	// Workdir is the top level of the working tree the checkpoint was taken
+	// in; empty for checkpoints that predate it.
+	Workdir   string    `json:"workdir,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Create snapshots the working tree at dir under id. It is idempotent: when
+// the checkpoint already exists it is returned unchanged, so a retried
+// prompt does not overwrite the state from before its first attempt.
+func Create(ctx context.Context, dir, id string) (*Checkpoint, error) {
+	if !ValidID(id) {
+		return nil, ErrInvalidID
+	}
+	if existing, err := get(ctx, dir, id); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	cp, err := capture(ctx, dir, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, dir, nil, "update-ref", "-m", "kandev checkpoint", RefPrefix+id, cp.Commit); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// List returns the checkpoints of the repository at dir, oldest first.
+func List(ctx context.Context, dir string) ([]Checkpoint, error) {
+	out, err := git(ctx, dir, nil, "for-each-ref",
+		"--format=%(refname)%00%(objectname)%00%(parent)%00%(contents:body)%01",
+		strings.TrimSuffix(RefPrefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []Checkpoint
+	for _, record := range strings.Split(out, "\x01") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		cp := Checkpoint{
+			ID:     strings.TrimPrefix(fields[0], RefPrefix),
+			Commit: fields[1],
+			Head:   fields[2],
+		}
+		cp.Branch, cp.Workdir, cp.CreatedAt = parseBody(fields[3])
+		checkpoints = append(checkpoints, cp)
+	}
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+	return checkpoints, nil
+}
+
+// Restore puts the working tree at dir back to checkpoint id: HEAD returns to
+// the recorded commit and branch, files match the snapshot, and files that
+// were untracked when it was taken are untracked again. Ignored files are
+// left alone. The state being replaced is saved to BackupRef first.
+func Restore(ctx context.Context, dir, id string) (*Checkpoint, error) {
+	if !ValidID(id) {
+		return nil, ErrInvalidID
+	}
+	cp, err := get(ctx, dir, id)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := capture(ctx, dir, "rewind-backup")
+	if err != nil {
+		return nil, fmt.Errorf("back up working tree: %w", err)
+	}
+	if _, err := git(ctx, dir, nil, "update-ref", "-m", "kandev rewind backup", BackupRef, backup.Commit); err != nil {
+		return nil, err
+	}
+	if err := moveHead(ctx, dir, cp); err != nil {
+		return nil, err
+	}
+	steps := [][]string{
+		{"clean", "-d", "--force", "--quiet"},
+		{"read-tree", "-u", "--reset", cp.Commit},
+	}
+	if cp.Head != "" {
+		steps = append(steps, []string{"reset", "--quiet"})
+	} else {
+		steps = append(steps, []string{"read-tree", "--empty"})
+	}
+	for _, args := range steps {
+		if _, err := git(ctx, dir, nil, args...); err != nil {
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+// Apply seeds the working tree at dir with checkpoint id, which was taken in
+// another working tree of the same repository (checkpoint refs are shared by
+// all worktrees). Unlike Restore it keeps the current branch: the branch is
+// moved to the commit recorded in the checkpoint and the files are made to
+// match the snapshot, so changes that were uncommitted then are uncommitted
+// here too. It is meant for a freshly created worktree and records the
+// applied checkpoint, so applying the same one again is a no-op.
+func Apply(ctx context.Context, dir, id string) (*Checkpoint, error) {
+	if !ValidID(id) {
+		return nil, ErrInvalidID
+	}
+	cp, err := get(ctx, dir, id)
+	if err != nil {
+		return nil, err
+	}
+	workdir, err := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	if cp.Workdir != "" && samePath(cp.Workdir, strings.TrimSpace(workdir)) {
+		return nil, ErrSameWorktree
+	}
+	marker, err := git(ctx, dir, nil, "rev-parse", "--path-format=absolute", "--git-path", applyMarker)
+	if err != nil {
+		return nil, err
+	}
+	marker = filepath.Clean(strings.TrimSpace(marker))
+	if applied, err := os.ReadFile(marker); err == nil && strings.TrimSpace(string(applied)) == id {
+		return cp, nil
+	}
+
+	// The branch only moves when the checkpoint has a commit to move it to;
+	// otherwise the index is rebuilt against whatever HEAD already is.
+	_, headErr := git(ctx, dir, nil, "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	var steps [][]string
+	if cp.Head != "" {
+		steps = append(steps, []string{"reset", "--soft", cp.Head})
+	}
+	steps = append(steps,
+		[]string{"clean", "-d", "--force", "--quiet"},
+		[]string{"read-tree", "-u", "--reset", cp.Commit},
+	)
+	if cp.Head != "" || headErr == nil {
+		steps = append(steps, []string{"reset", "--quiet"})
+	} else {
+		steps = append(steps, []string{"read-tree", "--empty"})
+	}
+	for _, args := range steps {
+		if _, err := git(ctx, dir, nil, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(marker), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(marker, []byte(id+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// applyMarker is the per-worktree git path recording the checkpoint Apply
+// seeded the working tree with.
+const applyMarker = "kandev/applied-checkpoint"
+
+// samePath reports whether two paths name the same directory, resolving
+// symlinks where possible.
+func samePath(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// Delete removes the given checkpoints. Missing ones are ignored.
+func Delete(ctx context.Context, dir string, ids ...string) error {
+	for _, id := range ids {
+		if !ValidID(id) {
+			continue
+		}
+		if _, err := git(ctx, dir, nil, "update-ref", "-d", RefPrefix+id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func get(ctx context.Context, dir, id string) (*Checkpoint, error) {
+	out, err := git(ctx, dir, nil, "for-each-ref",
+		"--format=%(objectname)%00%(parent)%00%(contents:body)", RefPrefix+id)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.SplitN(out, "\x00", 3)
+	if len(fields) != 3 {
+		return nil, ErrNotFound
+	}
+	cp := &Checkpoint{ID: id, Commit: fields[0], Head: fields[1]}
+	cp.Branch, cp.Workdir, cp.CreatedAt = parseBody(fields[2])
+	return cp, nil
+}
+
+// capture writes the working tree into a snapshot commit without creating a
+// ref for it. The real index is copied so staged-but-unchanged state and
+// the stat cache carry over, then everything else is added on top.
+func capture(ctx context.Context, dir, id string) (*Checkpoint, error) {
+	head, _ := git(ctx, dir, nil, "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	branch, _ := git(ctx, dir, nil, "symbolic-ref", "--quiet", "--short", "HEAD")
+	workdir, _ := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	head, branch, workdir = strings.TrimSpace(head), strings.TrimSpace(branch), strings.TrimSpace(workdir)
+
+	indexFile, cleanup, err := tempIndex(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	env := []string{"GIT_INDEX_FILE=" + indexFile}
+	if _, err := git(ctx, dir, env, "add", "--all"); err != nil {
+		return nil, err
+	}
+	tree, err := git(ctx, dir, env, "write-tree")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	message := fmt.Sprintf("kandev checkpoint %s\n\nBranch: %s\nWorkdir: %s\nCreated: %s\n",
+		id, branch, workdir, now.Format(time.RFC3339Nano))
+	args := []string{"commit-tree", "--no-gpg-sign", "-m", message}
+	if head != "" {
+		args = append(args, "-p", head)
+	}
+	args = append(args, strings.TrimSpace(tree))
+	commit, err := git(ctx, dir, identityEnv(now), args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{
+		ID:        id,
+		Commit:    strings.TrimSpace(commit),
+		Head:      head,
+		Branch:    branch,
+		Workdir:   workdir,
+		CreatedAt: now,
+	}, nil
+}
+
+// tempIndex copies the repository's index to a temporary file.
+func tempIndex(ctx context.Context, dir string) (string, func(), error) {
+	indexPath, err := git(ctx, dir, nil, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.CreateTemp("", "kandev-checkpoint-index-*")
+	if err != nil {
+		return "", nil, err
+	}
+	name := tmp.Name()
+	cleanup := func() { _ = os.Remove(name) }
+	_ = tmp.Close()
+
+	data, err := os.ReadFile(filepath.Clean(strings.TrimSpace(indexPath)))
+	switch {
+	case err == nil:
+		err = os.WriteFile(name, data, 0o600)
+	case errors.Is(err, os.ErrNotExist):
+		// No index yet (fresh repository): git treats an empty file as
+		// corrupt, so start from no file at all.
+		err = os.Remove(name)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return name, cleanup, nil
+}
+
+// moveHead points HEAD back at the checkpoint's commit and branch. The branch
+// is force-reset, so commits made after the checkpoint leave it; they stay
+// reachable through BackupRef.
+func moveHead(ctx context.Context, dir string, cp *Checkpoint) error {
+	branch := cp.Branch
+	if branch != "" && !securityutil.IsValidBranchName(branch) {
+		branch = ""
+	}
+	var args [][]string
+	switch {
+	case cp.Head == "" && branch != "":
+		args = [][]string{
+			{"symbolic-ref", "HEAD", "refs/heads/" + branch},
+			{"update-ref", "-d", "refs/heads/" + branch},
+		}
+	case cp.Head == "":
+		return errors.New("checkpoint was taken on an unborn branch with no name")
+	case branch != "":
+		args = [][]string{{"checkout", "--quiet", "--force", "-B", branch, cp.Head}}
+	default:
+		args = [][]string{{"checkout", "--quiet", "--force", "--detach", cp.Head}}
+	}
+	for _, a := range args {
+		if _, err := git(ctx, dir, nil, a...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseBody(body string) (branch, workdir string, created time.Time) {
+	for _, line := range strings.Split(body, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Branch: "):
+			branch = strings.TrimSpace(strings.TrimPrefix(line, "Branch: "))
+		case strings.HasPrefix(line, "Workdir: "):
+			workdir = strings.TrimSpace(strings.TrimPrefix(line, "Workdir: "))
+		case strings.HasPrefix(line, "Created: "):
+			created, _ = time.Parse(time.RFC3339Nano, strings.TrimSpace(strings.TrimPrefix(line, "Created: ")))
+		}
+	}
+	return branch, workdir, created
+}
+
+func identityEnv(now time.Time) []string {
+	date := now.Format(time.RFC3339)
+	return []string{
+		"GIT_AUTHOR_NAME=Kandev",
+		"GIT_AUTHOR_EMAIL=checkpoints@kandev.local",
+		"GIT_AUTHOR_DATE=" + date,
+		"GIT_COMMITTER_NAME=Kandev",
+		"GIT_COMMITTER_EMAIL=checkpoints@kandev.local",
+		"GIT_COMMITTER_DATE=" + date,
+	}
+}
+
+// git runs a plumbing command in dir. Repository hooks are disabled: a
+// checkpoint must never run user code or be vetoed by it.
+func git(ctx context.Context, dir string, extraEnv []string, args ...string) (string, error) {
+	cmd := subproc.NewGitCommand(ctx, append([]string{"-c", "core.hooksPath=/dev/null"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(gitEnv(), extraEnv...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := subproc.RunGitClass(ctx, subproc.GitInteractive, cmd); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// gitEnv is the process environment without variables that would redirect
+// git away from the working tree's own repository and index.
+func gitEnv() []string {
+	env := os.Environ()
+	out := make([]string, 0, len(env))
+	for _, e := range env {
+		if strings.HasPrefix(e, "GIT_DIR=") || strings.HasPrefix(e, "GIT_WORK_TREE=") ||
+			strings.HasPrefix(e, "GIT_INDEX_FILE=") {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
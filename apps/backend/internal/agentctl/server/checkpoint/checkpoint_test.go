@@ -0,0 +1,260 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	for _, key := range []string{"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE"} {
+		t.Setenv(key, "")
+		_ = os.Unsetenv(key)
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--initial-branch=main")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "commit.gpgsign=false"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, dir, name string) (string, bool) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func TestCreateDoesNotTouchIndexOrWorktree(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, "a.txt", "one")
+	runGit(t, dir, "add", "a.txt")
+	runGit(t, dir, "commit", "-m", "init")
+	writeFile(t, dir, "a.txt", "two")
+	writeFile(t, dir, "new.txt", "untracked")
+
+	before := runGit(t, dir, "status", "--porcelain")
+	cp, err := Create(ctx, dir, "turn-1")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if after := runGit(t, dir, "status", "--porcelain"); after != before {
+		t.Fatalf("status changed:\nbefore %q\nafter  %q", before, after)
+	}
+	if cp.Branch != "main" || cp.Head != runGit(t, dir, "rev-parse", "HEAD") {
+		t.Fatalf("checkpoint = %+v", cp)
+	}
+	if got := runGit(t, dir, "show", cp.Commit+":new.txt"); got != "untracked" {
+		t.Fatalf("snapshot new.txt = %q", got)
+	}
+
+	// Creating the same ID again keeps the original snapshot.
+	writeFile(t, dir, "a.txt", "three")
+	again, err := Create(ctx, dir, "turn-1")
+	if err != nil || again.Commit != cp.Commit {
+		t.Fatalf("second Create = %+v, %v; want commit %s", again, err, cp.Commit)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, ".gitignore", "ignored/\n")
+	writeFile(t, dir, "a.txt", "one")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "init")
+	writeFile(t, dir, "a.txt", "modified")
+	writeFile(t, dir, "notes.txt", "keep me")
+	base := runGit(t, dir, "rev-parse", "HEAD")
+
+	if _, err := Create(ctx, dir, "turn-1"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The agent goes off the rails: edits, deletes, commits and switches branch.
+	runGit(t, dir, "checkout", "-b", "agent")
+	writeFile(t, dir, "a.txt", "broken")
+	if err := os.Remove(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "junk/out.txt", "junk")
+	runGit(t, dir, "add", "a.txt")
+	runGit(t, dir, "commit", "-m", "agent commit")
+	writeFile(t, dir, "ignored/cache", "cache")
+
+	if _, err := Restore(ctx, dir, "turn-1"); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if got := runGit(t, dir, "symbolic-ref", "--short", "HEAD"); got != "main" {
+		t.Errorf("branch = %q, want main", got)
+	}
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != base {
+		t.Errorf("HEAD = %s, want %s", got, base)
+	}
+	if got, _ := readFile(t, dir, "a.txt"); got != "modified" {
+		t.Errorf("a.txt = %q, want modified", got)
+	}
+	if got, ok := readFile(t, dir, "notes.txt"); !ok || got != "keep me" {
+		t.Errorf("notes.txt = %q (exists %v), want restored", got, ok)
+	}
+	if _, ok := readFile(t, dir, "junk/out.txt"); ok {
+		t.Error("junk/out.txt survived the restore")
+	}
+	if _, ok := readFile(t, dir, "ignored/cache"); !ok {
+		t.Error("ignored file was removed")
+	}
+	status := runGit(t, dir, "status", "--porcelain")
+	if !strings.HasPrefix(status, "M a.txt") || !strings.Contains(status, "?? notes.txt") {
+		t.Errorf("status = %q, want unstaged a.txt and untracked notes.txt", status)
+	}
+
+	// The replaced state is kept in the backup ref.
+	if got := runGit(t, dir, "show", BackupRef+":junk/out.txt"); got != "junk" {
+		t.Errorf("backup junk/out.txt = %q", got)
+	}
+}
+
+func TestRestoreUnbornBranch(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, "draft.txt", "draft")
+	if _, err := Create(ctx, dir, "turn-1"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	writeFile(t, dir, "draft.txt", "changed")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "first")
+
+	if _, err := Restore(ctx, dir, "turn-1"); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got, _ := readFile(t, dir, "draft.txt"); got != "draft" {
+		t.Errorf("draft.txt = %q", got)
+	}
+	if status := runGit(t, dir, "status", "--porcelain"); status != "?? draft.txt" {
+		t.Errorf("status = %q", status)
+	}
+}
+
+func TestApplyToSiblingWorktree(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, "a.txt", "one")
+	runGit(t, dir, "add", "a.txt")
+	runGit(t, dir, "commit", "-m", "init")
+	base := runGit(t, dir, "rev-parse", "HEAD")
+	writeFile(t, dir, "a.txt", "two")
+	runGit(t, dir, "commit", "-am", "agent commit")
+	writeFile(t, dir, "a.txt", "three")
+	writeFile(t, dir, "new.txt", "untracked")
+	cp, err := Create(ctx, dir, "turn-2")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := Apply(ctx, dir, "turn-2"); !errors.Is(err, ErrSameWorktree) {
+		t.Fatalf("Apply to own worktree: err = %v, want ErrSameWorktree", err)
+	}
+
+	fork := filepath.Join(t.TempDir(), "fork")
+	runGit(t, dir, "worktree", "add", "--quiet", "-b", "fork", fork, base)
+	writeFile(t, fork, "stray.txt", "stray")
+	if _, err := Apply(ctx, fork, "turn-2"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if branch := runGit(t, fork, "symbolic-ref", "--short", "HEAD"); branch != "fork" {
+		t.Errorf("branch = %q, want fork", branch)
+	}
+	if head := runGit(t, fork, "rev-parse", "HEAD"); head != cp.Head {
+		t.Errorf("HEAD = %s, want %s", head, cp.Head)
+	}
+	if got, _ := readFile(t, fork, "a.txt"); got != "three" {
+		t.Errorf("a.txt = %q", got)
+	}
+	if _, ok := readFile(t, fork, "stray.txt"); ok {
+		t.Error("stray.txt should have been removed")
+	}
+	if status := runGit(t, fork, "status", "--porcelain"); status != "M a.txt\n?? new.txt" {
+		t.Errorf("status = %q", status)
+	}
+
+	// A second apply is a no-op, so later edits survive a relaunch.
+	writeFile(t, fork, "a.txt", "four")
+	if _, err := Apply(ctx, fork, "turn-2"); err != nil {
+		t.Fatalf("second Apply: %v", err)
+	}
+	if got, _ := readFile(t, fork, "a.txt"); got != "four" {
+		t.Errorf("a.txt after second apply = %q", got)
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	writeFile(t, dir, "a.txt", "one")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "init")
+
+	for _, id := range []string{"turn-b", "turn-a", "turn-c"} {
+		if _, err := Create(ctx, dir, id); err != nil {
+			t.Fatalf("Create %s: %v", id, err)
+		}
+	}
+	list, err := List(ctx, dir)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var ids []string
+	for _, cp := range list {
+		ids = append(ids, cp.ID)
+		if cp.Branch != "main" || cp.CreatedAt.IsZero() || cp.Head == "" {
+			t.Errorf("checkpoint %+v missing fields", cp)
+		}
+	}
+	if strings.Join(ids, ",") != "turn-b,turn-a,turn-c" {
+		t.Fatalf("List order = %v, want creation order", ids)
+	}
+
+	if err := Delete(ctx, dir, "turn-a", "missing"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := Restore(ctx, dir, "turn-a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Restore deleted = %v, want ErrNotFound", err)
+	}
+	if _, err := Create(ctx, dir, "../escape"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("Create bad id = %v, want ErrInvalidID", err)
+	}
+}
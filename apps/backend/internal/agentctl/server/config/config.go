@@ -23,6 +23,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
 	commonconfig "github.com/kandev/kandev/internal/common/config"
 	"github.com/kandev/kandev/internal/gitconfigenv"
 	"github.com/kandev/kandev/internal/githubauth"
@@ -284,6 +286,15 @@ type InstanceConfig struct {
 	// WorkspaceSourceRoots are canonical durable source roots permitted for
 	// linked workspace file operations.
 	WorkspaceSourceRoots []string
+
+	// ResourceLimits confines the agent and the workspace processes started
+	// for this instance to a cgroup with these limits. Zero means unconfined.
+	ResourceLimits cgroup.Limits
+
+	// PermissionPolicy holds the allow/deny/ask rules evaluated before a
+	// permission request reaches a human. Nil leaves every request to
+	// AutoApprovePermissions and the UI.
+	PermissionPolicy *permpolicy.Policy
 }
 
 // Load loads the configuration from environment variables. Managed launches
@@ -531,6 +542,12 @@ func applyOverrides(cfg *InstanceConfig, overrides *InstanceOverrides) {
 	if overrides.WorkspaceSourceRoots != nil {
 		cfg.WorkspaceSourceRoots = append([]string(nil), overrides.WorkspaceSourceRoots...)
 	}
+	if overrides.ResourceLimits != nil {
+		cfg.ResourceLimits = *overrides.ResourceLimits
+	}
+	if overrides.PermissionPolicy != nil {
+		cfg.PermissionPolicy = overrides.PermissionPolicy
+	}
 }
 
 // applyApprovalOverrides sets approval-related instance overrides. Env is a
@@ -577,6 +594,8 @@ type InstanceOverrides struct {
 	RemoteContributions      map[string]models.RemoteContribution
 	ContributionDestinations map[string]models.ContributionDestination
 	WorkspaceSourceRoots     []string
+	ResourceLimits           *cgroup.Limits
+	PermissionPolicy         *permpolicy.Policy
 }
 
 func cloneComparisonTargets(values map[string]models.ComparisonTarget) map[string]models.ComparisonTarget {
@@ -9,6 +9,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
 	mcpprofile "github.com/kandev/kandev/internal/mcp/profile"
 	"github.com/kandev/kandev/internal/task/models"
 )
@@ -189,6 +191,14 @@ type CreateRequest struct {
 	RemoteContributions      map[string]models.RemoteContribution      `json:"remote_contributions,omitempty"`
 	ContributionDestinations map[string]models.ContributionDestination `json:"contribution_destinations,omitempty"`
 	WorkspaceSourceRoots     []string                                  `json:"workspace_source_roots,omitempty"`
+
+	// ResourceLimits confines the instance's agent and workspace processes to
+	// a cgroup v2 group on Linux. Nil or zero leaves them unconfined.
+	ResourceLimits *cgroup.Limits `json:"resource_limits,omitempty"`
+
+	// PermissionPolicy answers matching permission requests before they
+	// reach the UI. Nil leaves every request to the existing approval flow.
+	PermissionPolicy *permpolicy.Policy `json:"permission_policy,omitempty"`
 }
 
 // CreateResponse contains the result of creating a new agent instance.
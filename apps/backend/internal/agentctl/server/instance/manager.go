@@ -137,6 +137,9 @@ func (m *Manager) CreateInstance(ctx context.Context, req *CreateRequest) (*Crea
 	if err != nil {
 		return nil, fmt.Errorf("prepare agent environment: %w", err)
 	}
+	if err := req.PermissionPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid permission policy: %w", err)
+	}
 
 	id := req.ID
 	if id == "" {
@@ -184,6 +187,8 @@ func (m *Manager) CreateInstance(ctx context.Context, req *CreateRequest) (*Crea
 		RemoteContributions:      req.RemoteContributions,
 		ContributionDestinations: req.ContributionDestinations,
 		WorkspaceSourceRoots:     req.WorkspaceSourceRoots,
+		ResourceLimits:           req.ResourceLimits,
+		PermissionPolicy:         req.PermissionPolicy,
 	}
 
 	m.logger.Info("CreateInstance: applying overrides",
@@ -0,0 +1,61 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/pkg/agent"
+)
+
+// startACPRecording opens a recording of the agent's ACP exchange when
+// KANDEV_ACP_RECORD_DIR is set, and returns the pipes the adapter should be
+// connected to. Without a recording directory, for other protocols, or when
+// the file cannot be created, the process pipes are returned unchanged and
+// the session runs unrecorded.
+func (m *Manager) startACPRecording() (io.Writer, io.Reader) {
+	dir := shared.ACPRecordDir()
+	if dir == "" || m.cfg.Protocol != agent.ProtocolACP {
+		return m.stdin, m.stdout
+	}
+	name := fmt.Sprintf("%s-%s-%d.jsonl", m.cfg.AgentType, time.Now().UTC().Format("20060102T150405"), m.agentPID())
+	rec, err := acprecord.NewRecorder(filepath.Join(dir, name))
+	if err != nil {
+		m.logger.Warn("failed to start ACP recording", zap.Error(err))
+		return m.stdin, m.stdout
+	}
+	_ = rec.Meta("start", map[string]any{
+		"agent_id":   m.cfg.AgentType,
+		"session_id": m.cfg.SessionID,
+		"args":       m.cfg.AgentArgs,
+		"workdir":    m.cfg.WorkDir,
+	})
+	m.acpRecorder.Store(rec)
+	m.logger.Info("recording ACP session", zap.String("path", rec.Path()))
+	return acprecord.Tap(rec, m.stdin, m.stdout)
+}
+
+// recordStderr adds a raw agent stderr line to the active recording.
+func (m *Manager) recordStderr(line string) {
+	if rec := m.acpRecorder.Load(); rec != nil {
+		_ = rec.Stderr(line)
+	}
+}
+
+// stopACPRecording closes the active recording, if any, after the agent
+// process has exited.
+func (m *Manager) stopACPRecording() {
+	rec := m.acpRecorder.Swap(nil)
+	if rec == nil {
+		return
+	}
+	_ = rec.Meta("exit", map[string]any{"exit_code": m.ExitCode()})
+	if err := rec.Close(); err != nil {
+		m.logger.Warn("failed to close ACP recording", zap.Error(err))
+	}
+}
@@ -0,0 +1,155 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter"
+	"github.com/kandev/kandev/internal/agentctl/server/adapter/transport/shared"
+	"github.com/kandev/kandev/internal/agentctl/types"
+	tools "github.com/kandev/kandev/internal/tools/installer"
+)
+
+// agentWorkspace serves the tools of in-process adapters from the manager's
+// workspace trackers, git operators and owned processes.
+type agentWorkspace struct {
+	m *Manager
+}
+
+var _ shared.Workspace = (*agentWorkspace)(nil)
+
+// attachInProcessAdapter hands an in-process adapter the agent environment
+// and the workspace before its session is created.
+func (m *Manager) attachInProcessAdapter() {
+	inProcess, ok := m.adapter.(adapter.InProcessAdapter)
+	if !ok {
+		return
+	}
+	inProcess.SetEnvironment(m.cfg.AgentEnv)
+	inProcess.SetWorkspace(&agentWorkspace{m: m})
+}
+
+func (w *agentWorkspace) tracker() (*WorkspaceTracker, error) {
+	wt := w.m.GetWorkspaceTracker()
+	if wt == nil {
+		return nil, errors.New("workspace tracker not available")
+	}
+	return wt, nil
+}
+
+// ReadFile returns a text file's content.
+func (w *agentWorkspace) ReadFile(path string) (string, error) {
+	wt, err := w.tracker()
+	if err != nil {
+		return "", err
+	}
+	content, _, isBinary, _, err := wt.GetFileContent(path)
+	if err != nil {
+		return "", err
+	}
+	if isBinary {
+		return "", fmt.Errorf("%s is a binary file", path)
+	}
+	return content, nil
+}
+
+// WriteFile creates or replaces a file, creating parent directories.
+func (w *agentWorkspace) WriteFile(path, content string) error {
+	wt, err := w.tracker()
+	if err != nil {
+		return err
+	}
+	target, err := wt.resolveMutationPath(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = target.root.Close() }()
+	runWorkspaceMutationBarrier()
+
+	operation := types.FileOpWrite
+	if _, err := target.root.Stat(target.rel); os.IsNotExist(err) {
+		operation = types.FileOpCreate
+	}
+	if err := target.root.MkdirAll(filepath.Dir(target.rel), 0o755); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+	if err := target.root.WriteFile(target.rel, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	wt.notifyFileChange(wt.mutationNotificationPath(target.safe), operation)
+	return nil
+}
+
+// FindFiles returns up to limit paths matching a fuzzy file-name query.
+func (w *agentWorkspace) FindFiles(query string, limit int) []string {
+	results := w.m.SearchWorkspaceFileResults(query, limit)
+	paths := make([]string, 0, len(results))
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	return paths
+}
+
+// SearchContent returns up to limit matching lines per repository.
+func (w *agentWorkspace) SearchContent(ctx context.Context, query string, limit int) ([]types.WorkspaceContentSearchResult, error) {
+	response, err := w.m.SearchWorkspaceContent(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// GitStatus returns the current status of every repository.
+func (w *agentWorkspace) GitStatus(ctx context.Context) ([]types.GitStatusUpdate, error) {
+	trackers := w.m.contentSearchTrackers()
+	statuses := make([]types.GitStatusUpdate, 0, len(trackers))
+	for _, wt := range trackers {
+		status, err := wt.GetGitStatus(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// GitCommit stages all changes of a repository and commits them.
+func (w *agentWorkspace) GitCommit(ctx context.Context, repository, message string) (string, error) {
+	op, err := w.m.GitOperatorFor(repository)
+	if err != nil {
+		return "", err
+	}
+	result, err := op.Commit(ctx, message, true, false)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success {
+		return result.Output, fmt.Errorf("commit failed: %s", result.Error)
+	}
+	return result.Output, nil
+}
+
+// RunShell runs a shell command in the workspace as an owned process. A
+// nonzero exit is reported through ExitCode, not as an error.
+func (w *agentWorkspace) RunShell(ctx context.Context, command string) (shared.ShellResult, error) {
+	prog, args := shellExecArgs(command)
+	output, err := w.m.CombinedOutput(ctx, tools.CommandSpec{
+		Path: prog,
+		Args: args,
+		Dir:  w.m.cfg.WorkDir,
+	})
+	result := shared.ShellResult{
+		Output:    string(output),
+		Truncated: len(output) >= managedCommandOutputLimit,
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	}
+	return result, err
+}
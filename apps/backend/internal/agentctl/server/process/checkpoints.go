@@ -0,0 +1,232 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/server/checkpoint"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// ErrNoRepository is returned for checkpoint operations on a workspace with
+// no git repository to snapshot.
+var ErrNoRepository = errors.New("workspace has no git repository")
+
+// WorkspaceCheckpoint is a snapshot of every repository in the workspace,
+// taken together at the start of an agent turn.
+type WorkspaceCheckpoint struct {
+	ID           string                 `json:"id"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Repositories []CheckpointRepository `json:"repositories"`
+}
+
+// CheckpointRepository is one repository's part of a workspace checkpoint.
+type CheckpointRepository struct {
+	// Repo is the multi-repo subpath; empty for the workspace root.
+	Repo   string `json:"repo,omitempty"`
+	Commit string `json:"commit"`
+	Head   string `json:"head,omitempty"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// CreateCheckpoint snapshots every repository in the workspace under id,
+// including untracked files. Repositories that already hold the checkpoint
+// keep their original snapshot.
+func (m *Manager) CreateCheckpoint(ctx context.Context, id string) (*WorkspaceCheckpoint, error) {
+	ops, err := m.checkpointOperators()
+	if err != nil {
+		return nil, err
+	}
+	result := &WorkspaceCheckpoint{ID: id}
+	for _, op := range ops {
+		cp, err := checkpoint.Create(ctx, op.workDir, id)
+		if err != nil {
+			return nil, err
+		}
+		result.add(op.repoName, cp)
+	}
+	return result, nil
+}
+
+// ListCheckpoints returns the workspace's checkpoints, oldest first.
+func (m *Manager) ListCheckpoints(ctx context.Context) ([]WorkspaceCheckpoint, error) {
+	ops, err := m.checkpointOperators()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*WorkspaceCheckpoint)
+	for _, op := range ops {
+		list, err := checkpoint.List(ctx, op.workDir)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			wc, ok := byID[list[i].ID]
+			if !ok {
+				wc = &WorkspaceCheckpoint{ID: list[i].ID}
+				byID[list[i].ID] = wc
+			}
+			wc.add(op.repoName, &list[i])
+		}
+	}
+	out := make([]WorkspaceCheckpoint, 0, len(byID))
+	for _, wc := range byID {
+		out = append(out, *wc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// RestoreCheckpoint puts every repository that holds checkpoint id back to
+// it, then deletes the discard checkpoints (those taken after id, which no
+// longer describe anything reachable). Repositories without the checkpoint,
+// such as ones added to the workspace later, are left untouched.
+func (m *Manager) RestoreCheckpoint(ctx context.Context, id string, discard []string) (*WorkspaceCheckpoint, error) {
+	if !checkpoint.ValidID(id) {
+		return nil, checkpoint.ErrInvalidID
+	}
+	ops, err := m.checkpointOperators()
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if !op.tryLock("rewind") {
+			for _, held := range ops[:i] {
+				held.unlock()
+			}
+			return nil, ErrOperationInProgress
+		}
+	}
+	defer func() {
+		for _, op := range ops {
+			op.unlock()
+		}
+	}()
+
+	result := &WorkspaceCheckpoint{ID: id}
+	for _, op := range ops {
+		previousHead, _ := op.runGitCommand(ctx, "rev-parse", "HEAD")
+		cp, err := checkpoint.Restore(ctx, op.workDir, id)
+		if errors.Is(err, checkpoint.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.add(op.repoName, cp)
+		m.logger.Info("workspace restored to checkpoint",
+			zap.String("checkpoint", id),
+			zap.String("repo", op.repoName))
+		op.notifyRewind(strings.TrimSpace(previousHead), cp.Head)
+	}
+	if len(result.Repositories) == 0 {
+		return nil, checkpoint.ErrNotFound
+	}
+	for _, op := range ops {
+		if err := checkpoint.Delete(ctx, op.workDir, discard...); err != nil {
+			m.logger.Warn("failed to delete discarded checkpoints", zap.Error(err))
+		}
+	}
+	return result, nil
+}
+
+// ApplyCheckpoint seeds every repository that holds checkpoint id, taken in
+// another worktree of the same repository, with its state. This is how a
+// forked session starts from the workspace of the session it was forked
+// from. Repositories without the checkpoint, or whose own worktree took it,
+// are left untouched.
+func (m *Manager) ApplyCheckpoint(ctx context.Context, id string) (*WorkspaceCheckpoint, error) {
+	if !checkpoint.ValidID(id) {
+		return nil, checkpoint.ErrInvalidID
+	}
+	ops, err := m.checkpointOperators()
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if !op.tryLock("apply checkpoint") {
+			for _, held := range ops[:i] {
+				held.unlock()
+			}
+			return nil, ErrOperationInProgress
+		}
+	}
+	defer func() {
+		for _, op := range ops {
+			op.unlock()
+		}
+	}()
+
+	result := &WorkspaceCheckpoint{ID: id}
+	skipped := checkpoint.ErrNotFound
+	for _, op := range ops {
+		previousHead, _ := op.runGitCommand(ctx, "rev-parse", "HEAD")
+		cp, err := checkpoint.Apply(ctx, op.workDir, id)
+		switch {
+		case errors.Is(err, checkpoint.ErrNotFound):
+			continue
+		case errors.Is(err, checkpoint.ErrSameWorktree):
+			skipped = err
+			continue
+		case err != nil:
+			return nil, err
+		}
+		result.add(op.repoName, cp)
+		m.logger.Info("workspace seeded from checkpoint",
+			zap.String("checkpoint", id),
+			zap.String("repo", op.repoName))
+		op.notifyRewind(strings.TrimSpace(previousHead), cp.Head)
+	}
+	if len(result.Repositories) == 0 {
+		return nil, skipped
+	}
+	return result, nil
+}
+
+// checkpointOperators returns the git operator of each repository in the
+// workspace.
+func (m *Manager) checkpointOperators() ([]*GitOperator, error) {
+	scopes := m.RepositoryScopes()
+	if len(scopes) == 0 {
+		return nil, ErrNoRepository
+	}
+	ops := make([]*GitOperator, 0, len(scopes))
+	for _, scope := range scopes {
+		op, err := m.GitOperatorFor(scope)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// notifyRewind tells the UI HEAD moved, the same way a reset does.
+func (g *GitOperator) notifyRewind(previousHead, currentHead string) {
+	if g.workspaceTracker == nil || previousHead == currentHead {
+		return
+	}
+	g.workspaceTracker.NotifyGitReset(&streams.GitResetNotification{
+		Timestamp:      time.Now().UTC(),
+		RepositoryName: g.repoName,
+		PreviousHead:   previousHead,
+		CurrentHead:    currentHead,
+	})
+}
+
+func (wc *WorkspaceCheckpoint) add(repo string, cp *checkpoint.Checkpoint) {
+	if wc.CreatedAt.IsZero() || cp.CreatedAt.Before(wc.CreatedAt) {
+		wc.CreatedAt = cp.CreatedAt
+	}
+	wc.Repositories = append(wc.Repositories, CheckpointRepository{
+		Repo:   repo,
+		Commit: cp.Commit,
+		Head:   cp.Head,
+		Branch: cp.Branch,
+	})
+}
@@ -19,6 +19,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kandev/kandev/internal/agentctl/acprecord"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentctl/server/adapter"
 	"github.com/kandev/kandev/internal/agentctl/server/config"
 	"github.com/kandev/kandev/internal/agentctl/server/shell"
@@ -111,6 +113,19 @@ type Manager struct {
 	exitCode           atomic.Int32
 	exitErr            atomic.Value // error
 
+	// Per-session cgroup, created on the first process start when the
+	// instance has resource limits.
+	resourcesOnce sync.Once
+	resources     atomic.Pointer[resourceGroup]
+
+	// Recording of the agent's ACP exchange, set while a recorded agent
+	// process runs (KANDEV_ACP_RECORD_DIR).
+	acpRecorder atomic.Pointer[acprecord.Recorder]
+
+	// Compiled permission policy evaluated before requests reach a human.
+	// Replaced when the task enters a workflow step with its own rules.
+	permissionPolicy atomic.Pointer[permpolicy.Engine]
+
 	// Stderr buffering for error context
 	stderrBuffer    []string
 	stderrMu        sync.RWMutex
@@ -365,6 +380,9 @@ func NewManager(cfg *config.InstanceConfig, log *logger.Logger) *Manager {
 	m.shellMgr = shell.NewManager(cfg.WorkDir, log)
 	m.status.Store(StatusStopped)
 	m.exitCode.Store(-1)
+	if err := m.SetPermissionPolicy(cfg.PermissionPolicy); err != nil {
+		m.logger.Warn("ignoring invalid permission policy", zap.Error(err))
+	}
 	return m
 }
 
@@ -745,6 +763,7 @@ func (m *Manager) StartProcess(ctx context.Context, req StartProcessRequest) (*P
 	if err != nil {
 		return nil, fmt.Errorf("prepare process environment: %w", err)
 	}
+	m.sessionResources()
 	return m.processRunner.Start(ctx, effectiveReq)
 }
 
@@ -763,6 +782,7 @@ func (m *Manager) StartPipedProcess(req PipedStartRequest) (*PipedProcess, error
 	if err != nil {
 		return nil, fmt.Errorf("prepare process environment: %w", err)
 	}
+	m.sessionResources()
 	return m.processRunner.StartPiped(effectiveReq)
 }
 
@@ -1145,7 +1165,8 @@ func (m *Manager) Start(ctx context.Context) error {
 	m.stopChClosed.Store(false)
 
 	// Connect adapter to the process stdin/stdout pipes
-	if err := m.adapter.Connect(m.stdin, m.stdout); err != nil {
+	if err := m.adapter.Connect(m.startACPRecording()); err != nil {
+		m.stopACPRecording()
 		reapErr, owned := m.reapMainProcessLifecycle()
 		switch {
 		case !owned:
@@ -1186,11 +1207,13 @@ func (m *Manager) Start(ctx context.Context) error {
 }
 
 // startOneShot initialises a one-shot adapter without spawning a long-lived subprocess.
-// The adapter manages its own per-prompt subprocess lifecycle internally.
+// The adapter manages its own per-prompt subprocess lifecycle internally, or,
+// for in-process adapters, runs the agent loop inside agentctl.
 func (m *Manager) startOneShot() error {
 	if m.adapterCfg != nil && m.adapterCfg.OneShotConfig != nil {
 		m.adapterCfg.OneShotConfig.Env = m.cfg.AgentEnv
 	}
+	m.attachInProcessAdapter()
 
 	m.stopCh = make(chan struct{})
 	m.doneCh = make(chan struct{})
@@ -1317,6 +1340,9 @@ func (m *Manager) buildFinalCommand() error {
 	// This is important for adapters like OpenCode that spawn child processes
 	// (npx -> sh -> node -> opencode binary).
 	setAgentProcGroup(m.cmd)
+	if rg := m.sessionResources(); rg != nil {
+		rg.group.Place(m.cmd)
+	}
 
 	envBytes, largestEnv := summarizeEnvBytes(m.cfg.AgentEnv, 3)
 	m.logger.Info("agent command prepared",
@@ -1715,7 +1741,9 @@ func (m *Manager) StopForTeardown(ctx context.Context) error {
 	if err := m.WaitForAdmission(ctx); err != nil {
 		return fmt.Errorf("wait for process admission to drain: %w", err)
 	}
-	return m.stop(ctx)
+	err := m.stop(ctx)
+	m.releaseResources()
+	return err
 }
 
 func (m *Manager) stop(ctx context.Context) error {
@@ -2212,6 +2240,7 @@ func (m *Manager) readStderr() {
 	scanner := bufio.NewScanner(m.stderr)
 	for scanner.Scan() {
 		rawLine := stripANSI(scanner.Text())
+		m.recordStderr(rawLine)
 		if m.stderrConsumer != nil {
 			// Protocol-specific consumers inspect the line in memory. Their
 			// normalized output is projected separately before any generic
@@ -2291,6 +2320,8 @@ func (m *Manager) waitForExit() {
 		m.exitCode.Store(0)
 		m.logger.Info("agent process exited during intentional stop")
 	case err != nil:
+		// Report a memory-limit kill before the exit error it caused.
+		m.checkResourceLimits()
 		m.exitErr.Store(errorWrapper{err: err})
 		exitCode := -1
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -2334,6 +2365,7 @@ func (m *Manager) waitForExit() {
 			m.mainReapPending.Store(false)
 		}
 	}
+	m.stopACPRecording()
 	m.status.Store(StatusStopped)
 }
 
@@ -2379,23 +2411,27 @@ func (m *Manager) handlePermissionRequest(ctx context.Context, req *adapter.Perm
 		zap.String("tool_call_id", req.ToolCallID),
 		zap.Bool("auto_approve", m.cfg.AutoApprovePermissions))
 
-	// If auto-approve is enabled, immediately approve with the first "allow" option
-	if m.cfg.AutoApprovePermissions {
-		return m.autoApprovePermission(req)
-	}
-
 	// Create pending permission with response channel
-	createdAt := time.Now().UTC()
 	pending := &PendingPermission{
 		ID:         pendingID,
 		RequestID:  uuid.NewString(),
 		Request:    req,
 		ResponseCh: make(chan *adapter.PermissionResponse, 1),
-		CreatedAt:  createdAt,
+		CreatedAt:  time.Now().UTC(),
 		State:      streams.PermissionStatusPending,
 	}
 	pending.Snapshot = m.permissionSnapshot(pending)
 
+	// The permission policy answers matching requests itself. An "ask" rule
+	// sends the request to the UI even when auto-approve is enabled.
+	result := m.evaluatePermissionPolicy(pending)
+	if resp := m.resolvePermissionByPolicy(pending, result); resp != nil {
+		return resp, nil
+	}
+	if m.cfg.AutoApprovePermissions && result.Decision != permpolicy.DecisionAsk {
+		return m.autoApprovePermission(req)
+	}
+
 	// Store pending permission
 	m.permissionMu.Lock()
 	if replaced := m.pendingPermissions[pendingID]; replaced != nil {
@@ -2491,25 +2527,7 @@ func (m *Manager) autoApprovePermission(req *adapter.PermissionRequest) (*adapte
 // Uses a blocking send with timeout to ensure delivery. If delivery fails within 5 seconds,
 // auto-cancels the permission so the agent doesn't hang waiting for a response.
 func (m *Manager) sendPermissionNotification(pending *PendingPermission) {
-	options := make([]streams.PermissionOption, len(pending.Snapshot.Options))
-	for i, option := range pending.Snapshot.Options {
-		options[i] = streams.PermissionOption{
-			OptionID: option.OptionID,
-			Name:     option.Name,
-			Kind:     option.Kind,
-		}
-	}
-	event := adapter.AgentEvent{
-		Type:              adapter.EventTypePermissionRequest,
-		SessionID:         m.permissionSessionID(pending),
-		ToolCallID:        pending.Request.ToolCallID,
-		RequestID:         pending.RequestID,
-		PendingID:         pending.ID,
-		PermissionTitle:   pending.Snapshot.Title,
-		PermissionOptions: options,
-		ActionType:        pending.Snapshot.Action.Type,
-		ActionDetails:     permissionActionDetailsForEvent(pending.Snapshot.Action),
-	}
+	event := m.permissionRequestEvent(pending)
 
 	m.logger.Info("sending permission notification via updates channel",
 		zap.String("pending_id", pending.ID),
@@ -2530,6 +2548,30 @@ func (m *Manager) sendPermissionNotification(pending *PendingPermission) {
 	}
 }
 
+// permissionRequestEvent builds the permission_request event for a pending
+// request from its sanitized snapshot.
+func (m *Manager) permissionRequestEvent(pending *PendingPermission) adapter.AgentEvent {
+	options := make([]streams.PermissionOption, len(pending.Snapshot.Options))
+	for i, option := range pending.Snapshot.Options {
+		options[i] = streams.PermissionOption{
+			OptionID: option.OptionID,
+			Name:     option.Name,
+			Kind:     option.Kind,
+		}
+	}
+	return adapter.AgentEvent{
+		Type:              adapter.EventTypePermissionRequest,
+		SessionID:         m.permissionSessionID(pending),
+		ToolCallID:        pending.Request.ToolCallID,
+		RequestID:         pending.RequestID,
+		PendingID:         pending.ID,
+		PermissionTitle:   pending.Snapshot.Title,
+		PermissionOptions: options,
+		ActionType:        pending.Snapshot.Action.Type,
+		ActionDetails:     permissionActionDetailsForEvent(pending.Snapshot.Action),
+	}
+}
+
 // sendPermissionCancelledNotification sends a notification that a permission request was cancelled.
 // This happens when the context is cancelled (e.g., agent completes or user stops the task)
 // before the user responds to the permission request.
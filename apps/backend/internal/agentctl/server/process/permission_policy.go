@@ -0,0 +1,95 @@
+package process
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/agentctl/server/adapter"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// SetPermissionPolicy replaces the rules evaluated before a permission
+// request reaches a human. A nil or empty policy turns evaluation off. An
+// invalid policy is rejected and the current one stays in effect.
+func (m *Manager) SetPermissionPolicy(policy *permpolicy.Policy) error {
+	engine, err := permpolicy.Compile(policy)
+	if err != nil {
+		return err
+	}
+	m.permissionPolicy.Store(engine)
+	return nil
+}
+
+// evaluatePermissionPolicy matches the sanitized request, the same one a
+// human would see, against the active policy.
+func (m *Manager) evaluatePermissionPolicy(pending *PendingPermission) permpolicy.Result {
+	engine := m.permissionPolicy.Load()
+	if engine == nil {
+		return permpolicy.Result{}
+	}
+	return engine.Evaluate(pending.Snapshot.Action, m.cfg.WorkDir)
+}
+
+// resolvePermissionByPolicy answers an allowed or denied request and reports
+// the decision to the backend for the audit trail. It returns nil when the
+// request still needs a human: no rule matched, an "ask" rule matched, or an
+// allow rule matched a request that offers no allow option. A denied request
+// without a reject option is cancelled.
+func (m *Manager) resolvePermissionByPolicy(pending *PendingPermission, result permpolicy.Result) *adapter.PermissionResponse {
+	var preferred []streams.PermissionOptionKind
+	switch result.Decision {
+	case permpolicy.DecisionAllow:
+		preferred = []streams.PermissionOptionKind{streams.PermissionOptionKindAllowOnce, streams.PermissionOptionKindAllowAlways}
+	case permpolicy.DecisionDeny:
+		preferred = []streams.PermissionOptionKind{streams.PermissionOptionKindRejectOnce, streams.PermissionOptionKindRejectAlways}
+	default:
+		return nil
+	}
+	option := selectPermissionOption(pending.Request.Options, preferred)
+	if option == nil && result.Decision == permpolicy.DecisionAllow {
+		m.logger.Info("permission policy allowed a request without an allow option; asking instead",
+			zap.String("pending_id", pending.ID),
+			zap.String("rule", result.Rule))
+		return nil
+	}
+
+	decision := &streams.PermissionPolicyDecision{Decision: string(result.Decision), Rule: result.Rule}
+	resp := &adapter.PermissionResponse{Cancelled: true}
+	if option != nil {
+		decision.OptionID = option.OptionID
+		decision.OptionKind = option.Kind
+		resp = &adapter.PermissionResponse{OptionID: option.OptionID}
+	}
+	m.logger.Info("permission request decided by policy",
+		zap.String("pending_id", pending.ID),
+		zap.String("decision", decision.Decision),
+		zap.String("rule", decision.Rule),
+		zap.String("option_id", decision.OptionID))
+
+	event := m.permissionRequestEvent(pending)
+	event.PermissionPolicy = decision
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case m.updatesCh <- event:
+	case <-timer.C:
+		m.logger.Error("failed to deliver policy permission decision",
+			zap.String("pending_id", pending.ID))
+	}
+	return resp
+}
+
+// selectPermissionOption returns the first option of the earliest preferred
+// kind, or nil when none of the kinds is offered.
+func selectPermissionOption(options []adapter.PermissionOption, preferred []streams.PermissionOptionKind) *adapter.PermissionOption {
+	for _, kind := range preferred {
+		for i := range options {
+			if options[i].Kind == kind {
+				return &options[i]
+			}
+		}
+	}
+	return nil
+}
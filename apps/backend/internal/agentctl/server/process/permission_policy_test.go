@@ -0,0 +1,145 @@
+package process
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/agentctl/server/adapter"
+	"github.com/kandev/kandev/internal/agentctl/server/config"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+func policyTestManager(t *testing.T, autoApprove bool, policy *permpolicy.Policy) *Manager {
+	t.Helper()
+	m := &Manager{
+		cfg: &config.InstanceConfig{
+			TaskID:                 "task-1",
+			SessionID:              "session-1",
+			WorkDir:                "/work/repo",
+			AutoApprovePermissions: autoApprove,
+		},
+		logger:             newTestLogger(t),
+		updatesCh:          make(chan adapter.AgentEvent, 2),
+		pendingPermissions: make(map[string]*PendingPermission),
+	}
+	if err := m.SetPermissionPolicy(policy); err != nil {
+		t.Fatalf("set permission policy: %v", err)
+	}
+	return m
+}
+
+func commandPermissionRequest(command string, options ...adapter.PermissionOption) *adapter.PermissionRequest {
+	if options == nil {
+		options = []adapter.PermissionOption{
+			{OptionID: "allow-always", Kind: streams.PermissionOptionKindAllowAlways},
+			{OptionID: "allow-once", Kind: streams.PermissionOptionKindAllowOnce},
+			{OptionID: "reject-once", Kind: streams.PermissionOptionKindRejectOnce},
+		}
+	}
+	return &adapter.PermissionRequest{
+		PendingID:     "pending-1",
+		ToolCallID:    "tool-1",
+		Title:         "Run command",
+		ActionType:    string(streams.ActionTypeCommand),
+		ActionDetails: map[string]any{"command": command, "cwd": "/work/repo"},
+		Options:       options,
+	}
+}
+
+var testPolicy = &permpolicy.Policy{Rules: []permpolicy.Rule{
+	{Name: "go test", Decision: permpolicy.DecisionAllow, Command: "^go test"},
+	{Name: "no rm -rf", Decision: permpolicy.DecisionDeny, Command: `rm\s+-rf`},
+	{Name: "make", Decision: permpolicy.DecisionAsk, Command: "^make"},
+}}
+
+func TestPermissionPolicyDecidesWithoutAsking(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		options    []adapter.PermissionOption
+		wantOption string
+		wantCancel bool
+		wantRule   string
+	}{
+		{name: "allow prefers allow once", command: "go test ./...", wantOption: "allow-once", wantRule: "go test"},
+		{name: "deny picks reject", command: "rm -rf /", wantOption: "reject-once", wantRule: "no rm -rf"},
+		{
+			name:       "deny without reject option cancels",
+			command:    "rm -rf /",
+			options:    []adapter.PermissionOption{{OptionID: "allow-once", Kind: streams.PermissionOptionKindAllowOnce}},
+			wantCancel: true,
+			wantRule:   "no rm -rf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := policyTestManager(t, false, testPolicy)
+			resp, err := m.handlePermissionRequest(context.Background(), commandPermissionRequest(tt.command, tt.options...))
+			if err != nil {
+				t.Fatalf("handle permission: %v", err)
+			}
+			if resp.OptionID != tt.wantOption || resp.Cancelled != tt.wantCancel {
+				t.Fatalf("response = %+v, want option %q cancelled %v", resp, tt.wantOption, tt.wantCancel)
+			}
+			event := <-m.updatesCh
+			if event.PermissionPolicy == nil || event.PermissionPolicy.Rule != tt.wantRule || event.PermissionPolicy.OptionID != tt.wantOption {
+				t.Fatalf("policy decision = %+v, want rule %q option %q", event.PermissionPolicy, tt.wantRule, tt.wantOption)
+			}
+			if len(m.pendingPermissions) != 0 {
+				t.Fatal("policy decision must not leave a pending permission")
+			}
+		})
+	}
+}
+
+func TestPermissionPolicyAskOverridesAutoApprove(t *testing.T) {
+	m := policyTestManager(t, true, testPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan *adapter.PermissionResponse, 1)
+	go func() {
+		resp, _ := m.handlePermissionRequest(ctx, commandPermissionRequest("make deploy"))
+		done <- resp
+	}()
+
+	event := <-m.updatesCh
+	if event.PermissionPolicy != nil {
+		t.Fatalf("ask rule must forward the request, got decision %+v", event.PermissionPolicy)
+	}
+	if _, err := m.ResolvePermission(event.RequestID, event.PendingID, "reject-once"); err != nil {
+		t.Fatalf("resolve permission: %v", err)
+	}
+	select {
+	case resp := <-done:
+		if resp.OptionID != "reject-once" {
+			t.Fatalf("response = %+v, want the human's reject-once", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("permission handler did not return after resolution")
+	}
+}
+
+func TestPermissionPolicyUnmatchedFallsBackToAutoApprove(t *testing.T) {
+	m := policyTestManager(t, true, testPolicy)
+	resp, err := m.handlePermissionRequest(context.Background(), commandPermissionRequest("ls"))
+	if err != nil {
+		t.Fatalf("handle permission: %v", err)
+	}
+	if resp.OptionID != "allow-always" {
+		t.Fatalf("response = %+v, want auto-approve's first allow option", resp)
+	}
+}
+
+func TestSetPermissionPolicyKeepsCurrentPolicyOnError(t *testing.T) {
+	m := policyTestManager(t, false, testPolicy)
+	invalid := &permpolicy.Policy{Rules: []permpolicy.Rule{{Decision: "maybe"}}}
+	if err := m.SetPermissionPolicy(invalid); err == nil {
+		t.Fatal("expected invalid policy to be rejected")
+	}
+	resp, err := m.handlePermissionRequest(context.Background(), commandPermissionRequest("go test ./..."))
+	if err != nil || resp.OptionID != "allow-once" {
+		t.Fatalf("response = %+v, %v; want the previous policy to still allow", resp, err)
+	}
+}
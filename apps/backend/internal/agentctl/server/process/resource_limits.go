@@ -0,0 +1,191 @@
+package process
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/server/adapter"
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+// resourceWatchInterval is how often a confined session's cgroup is checked
+// for limit hits.
+const resourceWatchInterval = 2 * time.Second
+
+// pidsNoticeInterval spaces out process-limit notices: a build that keeps
+// forking at the limit would otherwise post one every check.
+const pidsNoticeInterval = time.Minute
+
+// resourceGroup is the cgroup confining an instance's agent and workspace
+// processes, with the limit hits already reported to the session.
+type resourceGroup struct {
+	group  *cgroup.Group
+	limits cgroup.Limits
+
+	mu             sync.Mutex
+	reported       cgroup.Events
+	lastPidsNotice time.Time
+	lastCPUUsec    int64
+	lastCPUAt      time.Time
+}
+
+// SessionResourceUsage is a confined session's consumption against its
+// limits.
+type SessionResourceUsage struct {
+	Limits cgroup.Limits
+	Usage  cgroup.Usage
+	// CPUPercent is the share of the CPU quota used since the previous
+	// reading; nil on the first reading or without a CPU limit.
+	CPUPercent *float64
+}
+
+// sessionResources returns the instance's cgroup, creating it on first use.
+// It is nil when the instance has no limits or they cannot be applied; the
+// latter is reported to the session once and the session runs unconfined.
+func (m *Manager) sessionResources() *resourceGroup {
+	if m.cfg.ResourceLimits.IsZero() {
+		return nil
+	}
+	m.resourcesOnce.Do(func() {
+		name := m.cfg.InstanceID
+		if name == "" {
+			name = m.cfg.SessionID
+		}
+		group, err := cgroup.New("session-"+name, m.cfg.ResourceLimits)
+		if err != nil {
+			m.logger.Warn("session resource limits are not enforced", zap.Error(err))
+			m.publishResourceLimit(streams.ResourceLimitNotice{Error: err.Error()})
+			return
+		}
+		rg := &resourceGroup{group: group, limits: m.cfg.ResourceLimits}
+		m.resources.Store(rg)
+		m.processRunner.setResourceGroup(group)
+		go m.watchResourceLimits(m.lifetimeCtx)
+		m.logger.Info("session processes confined to cgroup",
+			zap.String("cgroup", group.Path()),
+			zap.Int64("memory_bytes", rg.limits.MemoryBytes),
+			zap.Float64("cpu_cores", rg.limits.CPUCores),
+			zap.Int64("pids", rg.limits.Pids))
+	})
+	return m.resources.Load()
+}
+
+// ResourceUsage reads the confined session's consumption. ok is false when
+// the session is not confined.
+func (m *Manager) ResourceUsage() (SessionResourceUsage, bool) {
+	rg := m.resources.Load()
+	if rg == nil {
+		return SessionResourceUsage{}, false
+	}
+	usage, err := rg.group.Usage()
+	if err != nil {
+		return SessionResourceUsage{}, false
+	}
+	return SessionResourceUsage{
+		Limits:     rg.limits,
+		Usage:      usage,
+		CPUPercent: rg.cpuPercent(usage.CPUUsageUsec, time.Now()),
+	}, true
+}
+
+// cpuPercent converts cumulative CPU time into the share of the quota used
+// since the previous reading.
+func (rg *resourceGroup) cpuPercent(usageUsec int64, now time.Time) *float64 {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+	prevUsec, prevAt := rg.lastCPUUsec, rg.lastCPUAt
+	rg.lastCPUUsec, rg.lastCPUAt = usageUsec, now
+	if rg.limits.CPUCores <= 0 || prevAt.IsZero() || !now.After(prevAt) || usageUsec < prevUsec {
+		return nil
+	}
+	wallUsec := float64(now.Sub(prevAt).Microseconds())
+	percent := float64(usageUsec-prevUsec) / (wallUsec * rg.limits.CPUCores) * 100
+	return &percent
+}
+
+func (m *Manager) watchResourceLimits(ctx context.Context) {
+	ticker := time.NewTicker(resourceWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkResourceLimits()
+		}
+	}
+}
+
+// checkResourceLimits reports limit hits since the previous check. The
+// kernel enforces the limits itself; at memory.max it OOM-kills the whole
+// group (memory.oom.group), which ends the agent.
+func (m *Manager) checkResourceLimits() {
+	rg := m.resources.Load()
+	if rg == nil {
+		return
+	}
+	events, err := rg.group.Events()
+	if err != nil {
+		return
+	}
+	for _, notice := range rg.newHits(events, time.Now()) {
+		m.logger.Warn("session hit a resource limit",
+			zap.String("limit", notice.Limit),
+			zap.Int64("max", notice.Max),
+			zap.Bool("killed", notice.Killed))
+		m.publishResourceLimit(notice)
+	}
+}
+
+func (rg *resourceGroup) newHits(events cgroup.Events, now time.Time) []streams.ResourceLimitNotice {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+	var hits []streams.ResourceLimitNotice
+	if events.OOMKills > rg.reported.OOMKills {
+		hits = append(hits, streams.ResourceLimitNotice{
+			Limit:  streams.ResourceLimitMemory,
+			Max:    rg.limits.MemoryBytes,
+			Killed: true,
+		})
+	}
+	if events.PidsMax > rg.reported.PidsMax && now.Sub(rg.lastPidsNotice) >= pidsNoticeInterval {
+		hits = append(hits, streams.ResourceLimitNotice{
+			Limit: streams.ResourceLimitPids,
+			Max:   rg.limits.Pids,
+		})
+		rg.lastPidsNotice = now
+	}
+	rg.reported = events
+	return hits
+}
+
+func (m *Manager) publishResourceLimit(notice streams.ResourceLimitNotice) {
+	select {
+	case m.updatesCh <- adapter.AgentEvent{
+		Type:          streams.EventTypeResourceLimit,
+		ResourceLimit: &notice,
+	}:
+	default:
+		m.logger.Warn("updates channel full, dropping resource limit notice",
+			zap.String("limit", notice.Limit))
+	}
+}
+
+// releaseResources kills anything left in the session's cgroup and removes
+// it. Called once the instance is torn down.
+func (m *Manager) releaseResources() {
+	rg := m.resources.Swap(nil)
+	if rg == nil {
+		return
+	}
+	m.processRunner.setResourceGroup(nil)
+	if err := rg.group.Close(); err != nil {
+		m.logger.Warn("failed to remove session cgroup",
+			zap.String("cgroup", rg.group.Path()),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+)
+
+func TestResourceGroupNewHits(t *testing.T) {
+	rg := &resourceGroup{limits: cgroup.Limits{MemoryBytes: 1 << 30, Pids: 256}}
+	now := time.Now()
+
+	if hits := rg.newHits(cgroup.Events{}, now); len(hits) != 0 {
+		t.Fatalf("no events: got %d hits", len(hits))
+	}
+
+	hits := rg.newHits(cgroup.Events{OOMKills: 1, PidsMax: 3}, now)
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(hits))
+	}
+	if hits[0].Limit != streams.ResourceLimitMemory || !hits[0].Killed || hits[0].Max != 1<<30 {
+		t.Errorf("memory hit = %+v", hits[0])
+	}
+	if hits[1].Limit != streams.ResourceLimitPids || hits[1].Killed || hits[1].Max != 256 {
+		t.Errorf("pids hit = %+v", hits[1])
+	}
+
+	// Counters already reported do not repeat, and further fork failures
+	// within the notice interval stay quiet.
+	if hits := rg.newHits(cgroup.Events{OOMKills: 1, PidsMax: 9}, now.Add(resourceWatchInterval)); len(hits) != 0 {
+		t.Fatalf("repeat: got %+v", hits)
+	}
+	hits = rg.newHits(cgroup.Events{OOMKills: 1, PidsMax: 12}, now.Add(pidsNoticeInterval))
+	if len(hits) != 1 || hits[0].Limit != streams.ResourceLimitPids {
+		t.Fatalf("after interval: got %+v", hits)
+	}
+}
+
+func TestResourceGroupCPUPercent(t *testing.T) {
+	rg := &resourceGroup{limits: cgroup.Limits{CPUCores: 2}}
+	start := time.Now()
+
+	if got := rg.cpuPercent(1_000_000, start); got != nil {
+		t.Fatalf("first reading = %v, want nil", *got)
+	}
+	// One core busy for a second against a two-core quota.
+	got := rg.cpuPercent(2_000_000, start.Add(time.Second))
+	if got == nil || *got != 50 {
+		t.Fatalf("cpuPercent = %v, want 50", got)
+	}
+
+	unlimited := &resourceGroup{}
+	unlimited.cpuPercent(0, start)
+	if got := unlimited.cpuPercent(1_000_000, start.Add(time.Second)); got != nil {
+		t.Fatalf("without a CPU limit got %v, want nil", *got)
+	}
+}
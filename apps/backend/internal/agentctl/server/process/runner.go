@@ -33,9 +33,11 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kandev/kandev/internal/agentctl/server/cgroup"
 	"github.com/kandev/kandev/internal/agentctl/types"
 	"github.com/kandev/kandev/internal/common/logger"
 	"go.uber.org/zap"
@@ -187,6 +189,26 @@ type ProcessRunner struct {
 	terminateGroupFn func(int) error
 	killGroupFn      func(int) error
 	waitGroupExitFn  func(context.Context, int) bool
+
+	// resourceGroup, when set, is the session cgroup new processes start in.
+	resourceGroup atomic.Pointer[cgroup.Group]
+}
+
+// setResourceGroup makes processes started from now on run inside group;
+// nil stops confining them.
+func (r *ProcessRunner) setResourceGroup(group *cgroup.Group) {
+	if r == nil {
+		return
+	}
+	r.resourceGroup.Store(group)
+}
+
+// placeInResourceGroup starts cmd inside the session cgroup, if any. It must
+// run after the process group setup, which replaces SysProcAttr.
+func (r *ProcessRunner) placeInResourceGroup(cmd *exec.Cmd) {
+	if group := r.resourceGroup.Load(); group != nil {
+		group.Place(cmd)
+	}
 }
 
 // BeginStop closes process admission and waits for any in-flight spawn to
@@ -283,6 +305,7 @@ func (r *ProcessRunner) Start(ctx context.Context, req StartProcessRequest) (*Pr
 	cmd.Env = mergeEnv(req.Env)
 	// Create new process group for clean shutdown (allows killing entire subprocess tree)
 	setManagedProcGroup(cmd)
+	r.placeInResourceGroup(cmd)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
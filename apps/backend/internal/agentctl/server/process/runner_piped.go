@@ -62,6 +62,7 @@ func (r *ProcessRunner) StartPiped(req PipedStartRequest) (*PipedProcess, error)
 	cmd.Dir = req.WorkingDir
 	cmd.Env = mergeEnv(req.Env)
 	setManagedProcGroup(cmd)
+	r.placeInResourceGroup(cmd)
 
 	streams, err := newPipedCommandStreams(cmd)
 	if err != nil {
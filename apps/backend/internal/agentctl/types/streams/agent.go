@@ -87,6 +87,10 @@ const (
 	// configuration delivery and observed use. It deliberately excludes raw
 	// protocol frames, tool payloads, credentials, and full endpoints.
 	EventTypeMCPAttachment = "mcp_attachment"
+
+	// EventTypeResourceLimit reports the session's cgroup limits being hit,
+	// or failing to apply. It carries a ResourceLimitNotice.
+	EventTypeResourceLimit = "resource_limit"
 )
 
 // AgentEventDataPromptHandoff marks a generation-bearing foreground-idle event
@@ -197,6 +201,9 @@ type AgentEvent struct {
 	// present only on the first EventTypeMCPAttachment event for an attempt.
 	MCPAttachmentAttempt *MCPAttachmentAttempt `json:"mcp_attachment_attempt,omitempty"`
 
+	// ResourceLimit describes the limit hit when Type is EventTypeResourceLimit.
+	ResourceLimit *ResourceLimitNotice `json:"resource_limit,omitempty"`
+
 	// --- Permission request fields (for "permission_request" type) ---
 	// RequestID is the Kandev-generated identity for this exact request
 	// generation. It is distinct from the provider-controlled PendingID.
@@ -218,6 +225,10 @@ type AgentEvent struct {
 	// ActionDetails contains structured details about the action.
 	ActionDetails map[string]any `json:"action_details,omitempty"`
 
+	// PermissionPolicy is set when the instance's permission policy already
+	// answered the request; the event is then informational only.
+	PermissionPolicy *PermissionPolicyDecision `json:"permission_policy,omitempty"`
+
 	// --- Session status fields (for "session_status" type) ---
 
 	// SessionStatus indicates whether the session was resumed or new.
@@ -142,6 +142,23 @@ type PermissionRespondResponse struct {
 	Error string `json:"error,omitempty"`
 }
 
+// PermissionPolicyDecision records that agentctl answered a permission
+// request from the instance's permission policy instead of asking a human.
+// It rides on the permission_request event so the backend can add the
+// request, already resolved, to the permission audit trail.
+type PermissionPolicyDecision struct {
+	// Decision is "allow" or "deny".
+	Decision string `json:"decision"`
+
+	// Rule names the policy rule that decided the request.
+	Rule string `json:"rule"`
+
+	// OptionID and OptionKind identify the option sent to the agent. Both are
+	// empty when a deny had no reject option and the request was cancelled.
+	OptionID   string               `json:"option_id,omitempty"`
+	OptionKind PermissionOptionKind `json:"option_kind,omitempty"`
+}
+
 // PermissionChoice is the immutable public identity of one provider-offered
 // option. Provider metadata is intentionally excluded.
 type PermissionChoice struct {
@@ -0,0 +1,22 @@
+package streams
+
+// Resource limit kinds carried by ResourceLimitNotice.Limit.
+const (
+	ResourceLimitMemory = "memory"
+	ResourceLimitPids   = "pids"
+)
+
+// ResourceLimitNotice reports that a session's processes hit one of the
+// cgroup limits agentctl confines them to, or that the limits requested for
+// the session could not be applied at all.
+type ResourceLimitNotice struct {
+	// Limit is ResourceLimitMemory or ResourceLimitPids; empty when the
+	// limits are not enforced.
+	Limit string `json:"limit,omitempty"`
+	// Max is the configured limit: bytes for memory, processes for pids.
+	Max int64 `json:"max,omitempty"`
+	// Killed reports that the kernel killed the session's processes.
+	Killed bool `json:"killed,omitempty"`
+	// Error explains why the requested limits are not enforced.
+	Error string `json:"error,omitempty"`
+}
@@ -13,6 +13,8 @@ func TestRuntimeIsContainerized(t *testing.T) {
 		{RuntimeDocker, true},
 		{RuntimeRemoteDocker, true},
 		{RuntimeSprites, true},
+		{RuntimeKubernetes, true},
+		{RuntimePodman, true},
 		{Runtime(""), false},
 		{Runtime("unknown"), false},
 	}
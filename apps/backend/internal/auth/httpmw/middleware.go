@@ -331,7 +331,7 @@ func isPublicPath(method, path string) bool {
 	}
 	switch {
 	case strings.HasPrefix(path, "/api/v1/automations/webhook/"):
-		// X-Webhook-Secret, constant-time compared by the handler.
+		// X-Webhook-Secret or an HMAC signature, verified by the handler.
 		return true
 	case strings.HasPrefix(path, "/api/v1/office/channels/") && strings.HasSuffix(path, "/inbound"):
 		// HMAC-SHA256 / provider token verified by the channel handler.
@@ -0,0 +1,460 @@
+package automation
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/events"
+	"github.com/kandev/kandev/internal/events/bus"
+)
+
+const (
+	// completionActionsTimeout bounds one run's actions end to end. open_pr
+	// pushes a branch, which is the slow one.
+	completionActionsTimeout = 2 * time.Minute
+	// completionCallbackTimeout bounds a single callback request.
+	completionCallbackTimeout = 15 * time.Second
+	// maxCompletionChainDepth stops fire_automation chains, including an
+	// automation that fires itself to retry, from running forever.
+	maxCompletionChainDepth = 5
+
+	defaultCompletionBody    = "Automation **{{automation.name}}** finished: {{run.status}}.\n\n{{run.summary}}\n\n{{run.error}}"
+	defaultCompletionPRTitle = "[Auto] {{automation.name}}"
+	defaultCompletionPRBody  = "{{run.summary}}"
+)
+
+// PRCommenter posts a comment on a GitHub pull request.
+type PRCommenter interface {
+	CommentOnPRForAutomation(ctx context.Context, workspaceID, owner, repo string, number int, body string) error
+}
+
+// MRCommenter posts a comment on a GitLab merge request.
+type MRCommenter interface {
+	CommentOnMRForWorkspace(ctx context.Context, workspaceID, projectPath string, iid int, body string) error
+}
+
+// PullRequestOpener pushes a session's branch and opens a pull request from
+// it, returning the pull request's URL.
+type PullRequestOpener interface {
+	OpenPullRequest(ctx context.Context, sessionID, title, body, baseBranch string, draft bool) (string, error)
+}
+
+// CompletionActionRunner runs an automation's on_completion actions when one
+// of its runs finishes. It listens for AutomationRunCompleted, which the
+// service publishes once per run, and works off the bus goroutine: actions
+// call external systems and must not hold up the orchestrator finalizing
+// the task. A missing commenter or opener turns its action into a logged
+// no-op rather than an error.
+type CompletionActionRunner struct {
+	svc      *Service
+	eventBus bus.EventBus
+	logger   *logger.Logger
+
+	httpClient  *http.Client
+	prCommenter PRCommenter
+	mrCommenter MRCommenter
+	prOpener    PullRequestOpener
+
+	sub     bus.Subscription
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewCompletionActionRunner creates a new runner.
+func NewCompletionActionRunner(svc *Service, eventBus bus.EventBus, log *logger.Logger) *CompletionActionRunner {
+	return &CompletionActionRunner{
+		svc:        svc,
+		eventBus:   eventBus,
+		logger:     log,
+		httpClient: &http.Client{Timeout: completionCallbackTimeout},
+	}
+}
+
+// SetPRCommenter wires the GitHub side of the comment action.
+func (r *CompletionActionRunner) SetPRCommenter(c PRCommenter) { r.prCommenter = c }
+
+// SetMRCommenter wires the GitLab side of the comment action.
+func (r *CompletionActionRunner) SetMRCommenter(c MRCommenter) { r.mrCommenter = c }
+
+// SetPullRequestOpener wires the open_pr action.
+func (r *CompletionActionRunner) SetPullRequestOpener(o PullRequestOpener) { r.prOpener = o }
+
+// Start subscribes to run completions. Idempotent.
+func (r *CompletionActionRunner) Start(_ context.Context) {
+	if r.started {
+		return
+	}
+	r.started = true
+	if r.eventBus == nil {
+		return
+	}
+	sub, err := r.eventBus.Subscribe(events.AutomationRunCompleted, r.handleRunCompleted)
+	if err != nil {
+		r.logger.Error("failed to subscribe to automation run completions", zap.Error(err))
+		return
+	}
+	r.sub = sub
+}
+
+// Stop unsubscribes and waits for in-flight actions. Idempotent.
+func (r *CompletionActionRunner) Stop() {
+	if !r.started {
+		return
+	}
+	if r.sub != nil {
+		_ = r.sub.Unsubscribe()
+		r.sub = nil
+	}
+	r.wg.Wait()
+	r.started = false
+}
+
+func (r *CompletionActionRunner) handleRunCompleted(_ context.Context, event *bus.Event) error {
+	var evt AutomationRunCompletedEvent
+	if !decodeEventData(event.Data, &evt) || evt.RunID == "" {
+		return nil
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), completionActionsTimeout)
+		defer cancel()
+		r.run(ctx, &evt)
+	}()
+	return nil
+}
+
+// runOutcome is what the actions of one finished run report on. prURL is
+// filled in by an open_pr action for the ones after it.
+type runOutcome struct {
+	automation *Automation
+	run        *AutomationRun
+	status     RunStatus
+	errMsg     string
+	summary    string
+	sessionID  string
+	prURL      string
+}
+
+// render interpolates an action template: the trigger's own placeholders
+// plus the run's.
+func (o *runOutcome) render(template string) string {
+	extra := []string{
+		"{{automation.name}}", o.automation.Name,
+		"{{run.id}}", o.run.ID,
+		"{{run.status}}", string(o.status),
+		"{{run.summary}}", o.summary,
+		"{{run.error}}", o.errMsg,
+		"{{run.task_id}}", o.run.TaskID,
+		"{{run.pr_url}}", o.prURL,
+	}
+	return strings.TrimSpace(interpolate(template, o.run.TriggerType, o.run.TriggerData, extra))
+}
+
+func (r *CompletionActionRunner) run(ctx context.Context, evt *AutomationRunCompletedEvent) {
+	store := r.svc.Store()
+	a, err := store.GetAutomation(ctx, evt.AutomationID)
+	if err != nil || a == nil || len(a.OnCompletion) == 0 {
+		return
+	}
+	run, err := store.GetRun(ctx, evt.RunID)
+	if err != nil || run == nil {
+		return
+	}
+	summary, sessionID, err := store.GetRunOutcome(ctx, evt.TaskID)
+	if err != nil {
+		r.logger.Warn("failed to read automation run outcome",
+			zap.String("run_id", run.ID), zap.Error(err))
+	}
+	o := &runOutcome{
+		automation: a, run: run, status: evt.Status, errMsg: evt.ErrorMessage,
+		summary: summary, sessionID: sessionID,
+	}
+	for i := range a.OnCompletion {
+		act := &a.OnCompletion[i]
+		if !completionActionApplies(act, evt.Status) {
+			continue
+		}
+		if err := r.execute(ctx, o, act); err != nil {
+			r.logger.Warn("automation completion action failed",
+				zap.String("automation_id", a.ID),
+				zap.String("run_id", run.ID),
+				zap.String("action", string(act.Type)),
+				zap.Error(err))
+		}
+	}
+}
+
+// completionActionApplies matches an action's When against the run status.
+func completionActionApplies(act *CompletionAction, status RunStatus) bool {
+	when := act.When
+	if when == "" {
+		when = CompletionWhenAlways
+		if act.Type == CompletionActionOpenPR {
+			when = CompletionWhenSucceeded
+		}
+	}
+	switch when {
+	case CompletionWhenSucceeded:
+		return status == RunStatusSucceeded
+	case CompletionWhenFailed:
+		return status == RunStatusFailed
+	}
+	return true
+}
+
+func (r *CompletionActionRunner) execute(ctx context.Context, o *runOutcome, act *CompletionAction) error {
+	switch act.Type {
+	case CompletionActionComment:
+		return r.comment(ctx, o, act)
+	case CompletionActionCallback:
+		return r.callback(ctx, o, act)
+	case CompletionActionOpenPR:
+		return r.openPR(ctx, o, act)
+	case CompletionActionFireAutomation:
+		return r.fireAutomation(ctx, o, act)
+	}
+	return fmt.Errorf("unknown completion action %q", act.Type)
+}
+
+// commentTarget names the pull or merge request a run was triggered by.
+type commentTarget struct {
+	gitlab bool
+	repo   string // owner/name on GitHub, the project path on GitLab
+	number int
+}
+
+func runCommentTarget(run *AutomationRun) (commentTarget, bool) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(run.TriggerData, &data); err != nil {
+		return commentTarget{}, false
+	}
+	var t commentTarget
+	switch run.TriggerType {
+	case TriggerTypeGitHubPR:
+		t = commentTarget{repo: toString(data[automationRepoKey]), number: toInt(data["number"])}
+	case TriggerTypeGitHubPRMerged, TriggerTypeTaskPRMerged:
+		t = commentTarget{repo: toString(data[automationRepoKey]), number: toInt(data["pr_number"])}
+	case TriggerTypeGitLabMR:
+		t = commentTarget{gitlab: true, repo: toString(data[automationProjectKey]), number: toInt(data["iid"])}
+	default:
+		return commentTarget{}, false
+	}
+	return t, t.repo != "" && t.number > 0
+}
+
+func toInt(v interface{}) int {
+	if f, ok := v.(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+func (r *CompletionActionRunner) comment(ctx context.Context, o *runOutcome, act *CompletionAction) error {
+	target, ok := runCommentTarget(o.run)
+	if !ok {
+		r.logger.Debug("completion comment skipped: run was not triggered by a pull or merge request",
+			zap.String("run_id", o.run.ID), zap.String("trigger_type", string(o.run.TriggerType)))
+		return nil
+	}
+	body := act.Body
+	if body == "" {
+		body = defaultCompletionBody
+	}
+	body = o.render(body)
+	if target.gitlab {
+		if r.mrCommenter == nil {
+			return errors.New("no GitLab commenter configured")
+		}
+		return r.mrCommenter.CommentOnMRForWorkspace(ctx, o.automation.WorkspaceID, target.repo, target.number, body)
+	}
+	if r.prCommenter == nil {
+		return errors.New("no GitHub commenter configured")
+	}
+	owner, name, ok := strings.Cut(target.repo, "/")
+	if !ok {
+		return fmt.Errorf("malformed repository %q", target.repo)
+	}
+	return r.prCommenter.CommentOnPRForAutomation(ctx, o.automation.WorkspaceID, owner, name, target.number, body)
+}
+
+// completionCallbackPayload is the JSON body a callback action POSTs.
+type completionCallbackPayload struct {
+	Event          string          `json:"event"`
+	AutomationID   string          `json:"automation_id"`
+	AutomationName string          `json:"automation_name"`
+	RunID          string          `json:"run_id"`
+	TaskID         string          `json:"task_id"`
+	Status         RunStatus       `json:"status"`
+	ErrorMessage   string          `json:"error_message,omitempty"`
+	Summary        string          `json:"summary,omitempty"`
+	PRURL          string          `json:"pr_url,omitempty"`
+	TriggerType    TriggerType     `json:"trigger_type"`
+	TriggerData    json.RawMessage `json:"trigger_data,omitempty"`
+}
+
+// callback POSTs the run result, signed like a GitHub delivery
+// (X-Kandev-Signature-256: sha256=<hex HMAC of the body>) with the
+// automation's webhook secret so the receiver can authenticate it.
+func (r *CompletionActionRunner) callback(ctx context.Context, o *runOutcome, act *CompletionAction) error {
+	body, err := json.Marshal(completionCallbackPayload{
+		Event:          events.AutomationRunCompleted,
+		AutomationID:   o.automation.ID,
+		AutomationName: o.automation.Name,
+		RunID:          o.run.ID,
+		TaskID:         o.run.TaskID,
+		Status:         o.status,
+		ErrorMessage:   o.errMsg,
+		Summary:        o.summary,
+		PRURL:          o.prURL,
+		TriggerType:    o.run.TriggerType,
+		TriggerData:    o.run.TriggerData,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, act.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Kandev-Event", events.AutomationRunCompleted)
+	req.Header.Set("X-Kandev-Signature-256",
+		"sha256="+hex.EncodeToString(hmacSHA256([]byte(o.automation.WebhookSecret), body)))
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("callback: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback: %s responded %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+func (r *CompletionActionRunner) openPR(ctx context.Context, o *runOutcome, act *CompletionAction) error {
+	if r.prOpener == nil {
+		return errors.New("no pull request opener configured")
+	}
+	if o.sessionID == "" {
+		return errors.New("run has no session to open a pull request from")
+	}
+	title, body := act.Title, act.Body
+	if title == "" {
+		title = defaultCompletionPRTitle
+	}
+	if body == "" {
+		body = defaultCompletionPRBody
+	}
+	prURL, err := r.prOpener.OpenPullRequest(ctx, o.sessionID, o.render(title), o.render(body), act.BaseBranch, act.Draft)
+	if err != nil {
+		return err
+	}
+	o.prURL = prURL
+	return nil
+}
+
+func (r *CompletionActionRunner) fireAutomation(ctx context.Context, o *runOutcome, act *CompletionAction) error {
+	depth := completionChainDepth(o.run) + 1
+	if depth > maxCompletionChainDepth {
+		return fmt.Errorf("completion chain is already %d automations deep", maxCompletionChainDepth)
+	}
+	target, err := r.svc.Store().GetAutomation(ctx, act.AutomationID)
+	if err != nil {
+		return err
+	}
+	// Re-checked at fire time: the target may have moved since the action
+	// was saved, and firing across workspaces is never allowed.
+	if target == nil || target.WorkspaceID != o.automation.WorkspaceID {
+		return fmt.Errorf("automation %s not found in this workspace", act.AutomationID)
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"chain_depth": depth,
+		"source": map[string]interface{}{
+			"automation_id":   o.automation.ID,
+			"automation_name": o.automation.Name,
+			"run_id":          o.run.ID,
+			"task_id":         o.run.TaskID,
+			"status":          o.status,
+			"error_message":   o.errMsg,
+			"summary":         o.summary,
+			"pr_url":          o.prURL,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	triggerID := ""
+	if len(target.Triggers) > 0 {
+		triggerID = target.Triggers[0].ID
+	}
+	result, err := r.svc.FireTrigger(ctx, target.ID, triggerID, TriggerTypeOnCompletion, data, "on_completion:"+o.run.ID)
+	if err != nil {
+		return err
+	}
+	if result.Skipped {
+		r.logger.Info("chained automation did not fire",
+			zap.String("automation_id", target.ID), zap.String("reason", result.Reason))
+	}
+	return nil
+}
+
+// completionChainDepth is how many fire_automation hops led to run.
+func completionChainDepth(run *AutomationRun) int {
+	if run.TriggerType != TriggerTypeOnCompletion {
+		return 0
+	}
+	var data struct {
+		ChainDepth int `json:"chain_depth"`
+	}
+	_ = json.Unmarshal(run.TriggerData, &data)
+	return data.ChainDepth
+}
+
+// validateCompletionActions rejects actions that could never run: an unknown
+// type or When, a callback URL that isn't absolute http(s), and a
+// fire_automation target outside workspaceID.
+func (s *Service) validateCompletionActions(ctx context.Context, workspaceID string, actions []CompletionAction) error {
+	for i := range actions {
+		act := &actions[i]
+		switch act.When {
+		case "", CompletionWhenAlways, CompletionWhenSucceeded, CompletionWhenFailed:
+		default:
+			return fmt.Errorf("on_completion[%d]: unknown when %q", i, act.When)
+		}
+		switch act.Type {
+		case CompletionActionComment, CompletionActionOpenPR:
+		case CompletionActionCallback:
+			u, err := url.Parse(act.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("on_completion[%d]: callback url must be an absolute http(s) URL", i)
+			}
+		case CompletionActionFireAutomation:
+			if act.AutomationID == "" {
+				return fmt.Errorf("on_completion[%d]: automation_id is required", i)
+			}
+			target, err := s.store.GetAutomation(ctx, act.AutomationID)
+			if err != nil {
+				return err
+			}
+			if target == nil || target.WorkspaceID != workspaceID {
+				return fmt.Errorf("on_completion[%d]: automation %s not found in this workspace", i, act.AutomationID)
+			}
+		default:
+			return fmt.Errorf("on_completion[%d]: unknown action type %q", i, act.Type)
+		}
+	}
+	return nil
+}
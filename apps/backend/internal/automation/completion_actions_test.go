@@ -0,0 +1,238 @@
+package automation
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/events"
+	"github.com/kandev/kandev/internal/events/bus"
+)
+
+type recordedComment struct {
+	workspaceID, repo string
+	number            int
+	body              string
+}
+
+type fakeCommenter struct {
+	comments []recordedComment
+}
+
+func (f *fakeCommenter) CommentOnPRForAutomation(_ context.Context, workspaceID, owner, repo string, number int, body string) error {
+	f.comments = append(f.comments, recordedComment{workspaceID, owner + "/" + repo, number, body})
+	return nil
+}
+
+func (f *fakeCommenter) CommentOnMRForWorkspace(_ context.Context, workspaceID, projectPath string, iid int, body string) error {
+	f.comments = append(f.comments, recordedComment{workspaceID, projectPath, iid, body})
+	return nil
+}
+
+func newCompletionTestRunner(t *testing.T, svc *Service) *CompletionActionRunner {
+	t.Helper()
+	log, err := logger.NewFromZap(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCompletionActionRunner(svc, svc.eventBus, log)
+}
+
+func createCompletionAutomation(t *testing.T, svc *Service, workspaceID string, actions []CompletionAction) *Automation {
+	t.Helper()
+	a, err := svc.CreateAutomation(context.Background(), &CreateAutomationRequest{
+		WorkspaceID:       workspaceID,
+		Name:              "nightly deps",
+		AgentProfileID:    "agent-1",
+		ExecutorProfileID: "exec-1",
+		OnCompletion:      actions,
+	})
+	if err != nil {
+		t.Fatalf("CreateAutomation() error = %v", err)
+	}
+	return a
+}
+
+func createCompletionRun(t *testing.T, svc *Service, automationID string, triggerType TriggerType, data string) *AutomationRun {
+	t.Helper()
+	run := &AutomationRun{
+		AutomationID: automationID, TriggerType: triggerType,
+		TaskID: "task-" + automationID, Status: RunStatusTaskCreated,
+		TriggerData: json.RawMessage(data),
+	}
+	if err := svc.Store().CreateRun(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+func completedEvent(run *AutomationRun, status RunStatus, errMsg string) *AutomationRunCompletedEvent {
+	return &AutomationRunCompletedEvent{
+		RunID: run.ID, AutomationID: run.AutomationID, TaskID: run.TaskID,
+		Status: status, ErrorMessage: errMsg,
+	}
+}
+
+func TestFinalizeRun_PublishesCompletionOnce(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	ctx := context.Background()
+	completed := make(chan *AutomationRunCompletedEvent, 4)
+	if _, err := svc.eventBus.Subscribe(events.AutomationRunCompleted, func(_ context.Context, e *bus.Event) error {
+		var evt AutomationRunCompletedEvent
+		if decodeEventData(e.Data, &evt) {
+			completed <- &evt
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := createTestAutomation(t, svc, "ws-1")
+	run := createCompletionRun(t, svc, a.ID, TriggerTypeScheduled, `{}`)
+	if err := svc.MarkRunFailedByTaskID(ctx, run.TaskID, "agent crashed"); err != nil {
+		t.Fatal(err)
+	}
+	// A second terminal signal for the same task finds no pending run.
+	if err := svc.MarkRunSucceededByTaskID(ctx, run.TaskID); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(completed); got != 1 {
+		t.Fatalf("published %d completion events, want 1", got)
+	}
+	evt := <-completed
+	if evt.RunID != run.ID || evt.Status != RunStatusFailed || evt.ErrorMessage != "agent crashed" {
+		t.Fatalf("event = %+v", evt)
+	}
+}
+
+func TestCompletionRunner_CommentsOnTriggeringChange(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	runner := newCompletionTestRunner(t, svc)
+	commenter := &fakeCommenter{}
+	runner.SetPRCommenter(commenter)
+	runner.SetMRCommenter(commenter)
+
+	a := createCompletionAutomation(t, svc, "ws-1", []CompletionAction{
+		{Type: CompletionActionComment, Body: "{{run.status}} on {{automation.name}}"},
+		{Type: CompletionActionComment, When: CompletionWhenFailed, Body: "only on failure"},
+	})
+	pr := createCompletionRun(t, svc, a.ID, TriggerTypeGitHubPR, `{"repo":"acme/api","number":7}`)
+	runner.run(context.Background(), completedEvent(pr, RunStatusSucceeded, ""))
+
+	mr := createCompletionRun(t, svc, a.ID, TriggerTypeGitLabMR, `{"project":"grp/app","iid":3}`)
+	runner.run(context.Background(), completedEvent(mr, RunStatusFailed, "boom"))
+
+	want := []recordedComment{
+		{"ws-1", "acme/api", 7, "succeeded on nightly deps"},
+		{"ws-1", "grp/app", 3, "failed on nightly deps"},
+		{"ws-1", "grp/app", 3, "only on failure"},
+	}
+	if len(commenter.comments) != len(want) {
+		t.Fatalf("comments = %+v, want %+v", commenter.comments, want)
+	}
+	for i := range want {
+		if commenter.comments[i] != want[i] {
+			t.Fatalf("comment[%d] = %+v, want %+v", i, commenter.comments[i], want[i])
+		}
+	}
+}
+
+func TestCompletionRunner_SignedCallback(t *testing.T) {
+	var gotBody []byte
+	var gotSig string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get("X-Kandev-Signature-256")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	svc := newPRMergedTestService(t)
+	runner := newCompletionTestRunner(t, svc)
+	a := createCompletionAutomation(t, svc, "ws-1", []CompletionAction{
+		{Type: CompletionActionCallback, URL: server.URL + "/hook"},
+	})
+	run := createCompletionRun(t, svc, a.ID, TriggerTypeScheduled, `{}`)
+	runner.run(context.Background(), completedEvent(run, RunStatusSucceeded, ""))
+
+	if want := "sha256=" + hex.EncodeToString(testHMAC(a.WebhookSecret, string(gotBody))); gotSig != want {
+		t.Fatalf("signature = %q, want %q", gotSig, want)
+	}
+	var payload completionCallbackPayload
+	if err := json.Unmarshal(gotBody, &payload); err != nil {
+		t.Fatalf("decode callback body: %v", err)
+	}
+	if payload.RunID != run.ID || payload.AutomationName != "nightly deps" || payload.Status != RunStatusSucceeded {
+		t.Fatalf("payload = %+v", payload)
+	}
+}
+
+func TestCompletionRunner_FireAutomationChainDepth(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	runner := newCompletionTestRunner(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	target := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, target.ID, TriggerTypeWebhook, WebhookTriggerConfig{})
+	source := createCompletionAutomation(t, svc, "ws-1", []CompletionAction{
+		{Type: CompletionActionFireAutomation, AutomationID: target.ID},
+	})
+
+	run := createCompletionRun(t, svc, source.ID, TriggerTypeScheduled, `{}`)
+	runner.run(context.Background(), completedEvent(run, RunStatusSucceeded, ""))
+	select {
+	case evt := <-fired:
+		if evt.TriggerType != TriggerTypeOnCompletion || evt.DedupKey != "on_completion:"+run.ID {
+			t.Fatalf("event = %+v", evt)
+		}
+		prompt := InterpolatePrompt("{{source.automation}}: {{source.status}}", evt.TriggerType, evt.TriggerData)
+		if prompt != "nightly deps: succeeded" {
+			t.Fatalf("prompt = %q", prompt)
+		}
+		if got := completionChainDepth(&AutomationRun{TriggerType: evt.TriggerType, TriggerData: evt.TriggerData}); got != 1 {
+			t.Fatalf("chain depth = %d, want 1", got)
+		}
+	default:
+		t.Fatal("expected AutomationTriggered event")
+	}
+
+	deep := createCompletionRun(t, svc, source.ID, TriggerTypeOnCompletion, `{"chain_depth":5}`)
+	runner.run(context.Background(), completedEvent(deep, RunStatusSucceeded, ""))
+	expectNoFire(t, fired)
+}
+
+func TestValidateCompletionActions(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	ctx := context.Background()
+	other := createTestAutomation(t, svc, "ws-2")
+	local := createTestAutomation(t, svc, "ws-1")
+
+	if err := svc.validateCompletionActions(ctx, "ws-1", []CompletionAction{
+		{Type: CompletionActionComment},
+		{Type: CompletionActionCallback, URL: "https://ci.example.com/kandev"},
+		{Type: CompletionActionOpenPR, When: CompletionWhenSucceeded, Draft: true},
+		{Type: CompletionActionFireAutomation, AutomationID: local.ID},
+	}); err != nil {
+		t.Fatalf("valid actions rejected: %v", err)
+	}
+	for _, act := range []CompletionAction{
+		{Type: "email"},
+		{Type: CompletionActionComment, When: "sometimes"},
+		{Type: CompletionActionCallback, URL: "ftp://example.com"},
+		{Type: CompletionActionCallback, URL: "/relative"},
+		{Type: CompletionActionFireAutomation},
+		{Type: CompletionActionFireAutomation, AutomationID: other.ID},
+	} {
+		if err := svc.validateCompletionActions(ctx, "ws-1", []CompletionAction{act}); err == nil {
+			t.Fatalf("expected %+v to be rejected", act)
+		}
+	}
+}
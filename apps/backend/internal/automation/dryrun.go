@@ -0,0 +1,483 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kandev/kandev/internal/gitlab"
+)
+
+// ErrRunNotFound is returned for a run that does not exist or belongs to
+// another automation.
+var ErrRunNotFound = errors.New("automation: run not found")
+
+// ErrRunNotReplayable is returned when replaying a run that did not fail or
+// get skipped: a run still in flight, or one that already succeeded.
+var ErrRunNotReplayable = errors.New("automation: only failed or skipped runs can be replayed")
+
+// ErrTriggerNotFound is returned when a dry run names a trigger the
+// automation does not have.
+var ErrTriggerNotFound = errors.New("automation: trigger not found")
+
+// ErrInvalidDryRunPayload is returned for a sample payload that is not JSON.
+var ErrInvalidDryRunPayload = errors.New("automation: payload must be JSON")
+
+// noRepositoryReason is the orchestrator's failure for a firing that
+// resolves no repository, reported by a dry run as a skip.
+const noRepositoryReason = "no repository available; add a repository to the workspace"
+
+// PreviewRepository is one repository a firing's task would check out.
+type PreviewRepository struct {
+	RepositoryID   string `json:"repository_id"`
+	BaseBranch     string `json:"base_branch,omitempty"`
+	CheckoutBranch string `json:"checkout_branch,omitempty"`
+}
+
+// TaskPreview is the task a firing would create. Notes describe what the
+// firing would do that the preview left undone, such as cloning a repository
+// the workspace has no clone of yet.
+type TaskPreview struct {
+	Title        string              `json:"title"`
+	Prompt       string              `json:"prompt"`
+	Repositories []PreviewRepository `json:"repositories"`
+	Notes        []string            `json:"notes,omitempty"`
+}
+
+// TaskPreviewer renders the task a firing would create, through the same
+// code the orchestrator creates it with, without creating anything.
+type TaskPreviewer interface {
+	PreviewAutomationTask(ctx context.Context, a *Automation, triggerType TriggerType, triggerData json.RawMessage) (*TaskPreview, error)
+}
+
+// SetTaskPreviewer wires the renderer DryRun reports the task through. Nil
+// leaves the title, prompt and repositories of a dry run empty.
+func (s *Service) SetTaskPreviewer(p TaskPreviewer) {
+	s.taskPreviewer = p
+}
+
+// DryRunRequest names the delivery to evaluate. RunID replays a recorded
+// run's payload. Otherwise Payload is a sample: a webhook's request body, or
+// the trigger data of any other type (the shape a run records), and may be
+// omitted for scheduled and manual fires. TriggerID picks the trigger;
+// without it the first trigger of TriggerType, or the first trigger, is used.
+type DryRunRequest struct {
+	TriggerID   string          `json:"trigger_id,omitempty"`
+	TriggerType TriggerType     `json:"trigger_type,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	RunID       string          `json:"run_id,omitempty"`
+}
+
+// DryRunResult is what firing the automation would do. SkipReasons lists
+// every check that would stop the firing, not just the first, so one dry run
+// shows everything left to fix. Notes report side effects of the firing the
+// dry run did not perform.
+type DryRunResult struct {
+	AutomationID      string              `json:"automation_id"`
+	TriggerID         string              `json:"trigger_id,omitempty"`
+	TriggerType       TriggerType         `json:"trigger_type"`
+	TriggerData       json.RawMessage     `json:"trigger_data"`
+	Title             string              `json:"title"`
+	Prompt            string              `json:"prompt"`
+	Repositories      []PreviewRepository `json:"repositories"`
+	AgentProfileID    string              `json:"agent_profile_id"`
+	ExecutorProfileID string              `json:"executor_profile_id"`
+	WorkflowID        string              `json:"workflow_id"`
+	WorkflowStepID    string              `json:"workflow_step_id"`
+	WouldSkip         bool                `json:"would_skip"`
+	SkipReasons       []string            `json:"skip_reasons"`
+	Notes             []string            `json:"notes,omitempty"`
+}
+
+// dryRunInput is a delivery resolved against an automation. trigger is nil
+// for a manual fire or a run whose trigger has since been deleted.
+type dryRunInput struct {
+	trigger     *AutomationTrigger
+	triggerType TriggerType
+	triggerData json.RawMessage
+}
+
+// DryRun evaluates the automation against a sample or recorded payload and
+// reports the task it would create and whether it would be skipped, without
+// creating a task or recording a run.
+func (s *Service) DryRun(ctx context.Context, automationID string, req *DryRunRequest) (*DryRunResult, error) {
+	a, err := s.loadAuthorizedAutomation(ctx, automationID)
+	if err != nil {
+		return nil, err
+	}
+	in, err := s.resolveDryRunInput(ctx, a, req)
+	if err != nil {
+		return nil, err
+	}
+	result := &DryRunResult{
+		AutomationID:      a.ID,
+		TriggerType:       in.triggerType,
+		TriggerData:       in.triggerData,
+		Repositories:      []PreviewRepository{},
+		AgentProfileID:    a.AgentProfileID,
+		ExecutorProfileID: a.ExecutorProfileID,
+		WorkflowID:        a.WorkflowID,
+		WorkflowStepID:    a.WorkflowStepID,
+	}
+	if in.trigger != nil {
+		result.TriggerID = in.trigger.ID
+	}
+	reasons, err := s.dryRunSkipReasons(ctx, a, &in)
+	if err != nil {
+		return nil, err
+	}
+	if s.taskPreviewer != nil {
+		preview, err := s.taskPreviewer.PreviewAutomationTask(ctx, a, in.triggerType, in.triggerData)
+		if err != nil {
+			return nil, fmt.Errorf("preview task: %w", err)
+		}
+		result.Title, result.Prompt, result.Notes = preview.Title, preview.Prompt, preview.Notes
+		switch {
+		case len(preview.Repositories) > 0:
+			result.Repositories = preview.Repositories
+		case len(preview.Notes) == 0:
+			// A note accounts for a repository the firing would still
+			// resolve; without one there is none to use.
+			reasons = append(reasons, noRepositoryReason)
+		}
+	}
+	result.SkipReasons = reasons
+	result.WouldSkip = len(reasons) > 0
+	return result, nil
+}
+
+// ReplayRun fires a failed or skipped run's automation again with the run's
+// recorded payload, re-applying the trigger's current configuration (a
+// webhook's field mappings) so a fix to the automation takes effect. The
+// replay records a new run; the original is left as it was.
+func (s *Service) ReplayRun(ctx context.Context, runID string) (FireResult, error) {
+	run, err := s.store.GetRun(ctx, runID)
+	if err != nil {
+		return FireResult{}, fmt.Errorf("get run: %w", err)
+	}
+	if run == nil {
+		return FireResult{}, ErrRunNotFound
+	}
+	a, err := s.loadAuthorizedAutomation(ctx, run.AutomationID)
+	if err != nil {
+		return FireResult{}, err
+	}
+	if run.Status != RunStatusFailed && run.Status != RunStatusSkipped {
+		return FireResult{}, ErrRunNotReplayable
+	}
+	trigger := findTrigger(a, run.TriggerID)
+	triggerID := run.TriggerID
+	if trigger == nil && len(a.Triggers) > 0 {
+		// The run's trigger is gone; attribute the replay to the automation's
+		// first, as a manual fire is.
+		triggerID = a.Triggers[0].ID
+	}
+	// No dedup key: the original delivery already holds it, and a replay
+	// that fails again must stay replayable.
+	return s.FireTriggerWithPayload(ctx, a.ID, triggerID, run.TriggerType,
+		replayTriggerData(run, trigger), run.RawPayload, "")
+}
+
+func (s *Service) loadAuthorizedAutomation(ctx context.Context, id string) (*Automation, error) {
+	if err := s.authorizeAutomation(ctx, id); err != nil {
+		return nil, err
+	}
+	a, err := s.store.GetAutomation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAutomationNotFound
+	}
+	return a, nil
+}
+
+func (s *Service) resolveDryRunInput(ctx context.Context, a *Automation, req *DryRunRequest) (dryRunInput, error) {
+	if req.RunID != "" {
+		run, err := s.store.GetRun(ctx, req.RunID)
+		if err != nil {
+			return dryRunInput{}, fmt.Errorf("get run: %w", err)
+		}
+		if run == nil || run.AutomationID != a.ID {
+			return dryRunInput{}, ErrRunNotFound
+		}
+		trigger := findTrigger(a, run.TriggerID)
+		return dryRunInput{trigger: trigger, triggerType: run.TriggerType, triggerData: replayTriggerData(run, trigger)}, nil
+	}
+
+	in := dryRunInput{triggerType: req.TriggerType}
+	switch {
+	case req.TriggerID != "":
+		if in.trigger = findTrigger(a, req.TriggerID); in.trigger == nil {
+			return dryRunInput{}, ErrTriggerNotFound
+		}
+	case req.TriggerType != "":
+		in.trigger = firstTriggerOfType(a, req.TriggerType)
+		if in.trigger == nil && req.TriggerType != triggerDataSourceManual {
+			return dryRunInput{}, ErrTriggerNotFound
+		}
+	case len(a.Triggers) > 0:
+		in.trigger = &a.Triggers[0]
+	default:
+		in.triggerType = triggerDataSourceManual
+	}
+	if in.trigger != nil {
+		in.triggerType = in.trigger.Type
+	}
+	data, err := sampleTriggerData(in.trigger, in.triggerType, req.Payload, time.Now().UTC())
+	if err != nil {
+		return dryRunInput{}, err
+	}
+	in.triggerData = data
+	return in, nil
+}
+
+// sampleTriggerData shapes a sample payload the way the trigger's source
+// would before firing.
+func sampleTriggerData(t *AutomationTrigger, triggerType TriggerType, payload json.RawMessage, now time.Time) (json.RawMessage, error) {
+	if triggerType == TriggerTypeWebhook && t != nil {
+		var cfg WebhookTriggerConfig
+		if len(t.Config) > 0 {
+			if err := json.Unmarshal(t.Config, &cfg); err != nil {
+				return nil, fmt.Errorf("invalid webhook trigger config: %w", err)
+			}
+		}
+		return webhookTriggerData(webhookBodyFromRawPayload(payload), cfg.FieldMappings), nil
+	}
+	if len(payload) > 0 {
+		if !json.Valid(payload) {
+			return nil, ErrInvalidDryRunPayload
+		}
+		return payload, nil
+	}
+	switch triggerType {
+	case TriggerTypeScheduled:
+		return json.Marshal(map[string]string{
+			triggerDataSourceKey: string(TriggerTypeScheduled),
+			"timestamp":          now.Format(time.RFC3339),
+		})
+	case triggerDataSourceManual:
+		return json.Marshal(map[string]string{triggerDataSourceKey: triggerDataSourceManual})
+	}
+	return json.RawMessage(`{}`), nil
+}
+
+// replayTriggerData is the trigger data a replay of run fires with. A
+// webhook run is re-shaped from its raw body under the trigger's current
+// field mappings; every other run's trigger data is its payload.
+func replayTriggerData(run *AutomationRun, t *AutomationTrigger) json.RawMessage {
+	if run.TriggerType != TriggerTypeWebhook || len(run.RawPayload) == 0 || t == nil {
+		return run.TriggerData
+	}
+	var cfg WebhookTriggerConfig
+	if len(t.Config) > 0 && json.Unmarshal(t.Config, &cfg) != nil {
+		return run.TriggerData
+	}
+	return webhookTriggerData(webhookBodyFromRawPayload(run.RawPayload), cfg.FieldMappings)
+}
+
+func (s *Service) dryRunSkipReasons(ctx context.Context, a *Automation, in *dryRunInput) ([]string, error) {
+	reasons := []string{}
+	if !a.Enabled {
+		reasons = append(reasons, "automation is disabled")
+	}
+	if in.trigger != nil {
+		if !in.trigger.Enabled {
+			reasons = append(reasons, "trigger is disabled")
+		}
+		if reason := triggerFilterMismatch(a, in.trigger, in.triggerData); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	if a.MaxConcurrentRuns > 0 {
+		active, err := s.store.CountActiveRuns(ctx, a.ID)
+		if err != nil {
+			return nil, fmt.Errorf("count active runs: %w", err)
+		}
+		if active >= a.MaxConcurrentRuns {
+			reasons = append(reasons, concurrencyCapReason(a.MaxConcurrentRuns))
+		}
+	}
+	return reasons, nil
+}
+
+func findTrigger(a *Automation, id string) *AutomationTrigger {
+	if id == "" {
+		return nil
+	}
+	for i := range a.Triggers {
+		if a.Triggers[i].ID == id {
+			return &a.Triggers[i]
+		}
+	}
+	return nil
+}
+
+func firstTriggerOfType(a *Automation, triggerType TriggerType) *AutomationTrigger {
+	for i := range a.Triggers {
+		if a.Triggers[i].Type == triggerType {
+			return &a.Triggers[i]
+		}
+	}
+	return nil
+}
+
+// dryRunData is the union of the trigger-data keys the trigger filters read.
+type dryRunData struct {
+	Repo        string     `json:"repo"`
+	Project     string     `json:"project"`
+	Branch      string     `json:"branch"`
+	BaseBranch  string     `json:"base_branch"`
+	Ref         string     `json:"ref"`
+	AuthorLogin string     `json:"author_login"`
+	Author      string     `json:"author"`
+	Draft       bool       `json:"draft"`
+	Labels      []string   `json:"labels"`
+	Conclusion  string     `json:"conclusion"`
+	CheckName   string     `json:"check_name"`
+	Status      string     `json:"status"`
+	Source      string     `json:"source"`
+	ToStepID    string     `json:"to_step_id"`
+	Task        SourceTask `json:"task"`
+}
+
+// triggerFilterMismatch applies the trigger's filters to trigger data the way
+// its source does before firing, and names the first one that fails. Empty
+// when the data passes or the trigger type has no filters.
+func triggerFilterMismatch(a *Automation, t *AutomationTrigger, triggerData json.RawMessage) string {
+	var d dryRunData
+	_ = json.Unmarshal(triggerData, &d)
+	switch t.Type {
+	case TriggerTypeGitHubPR, TriggerTypeGitHubPush, TriggerTypeGitHubCI, TriggerTypeGitHubPRMerged:
+		return githubFilterMismatch(t, &d)
+	case TriggerTypeGitLabMR, TriggerTypeGitLabPipelineFailed, TriggerTypeGitLabPush:
+		return gitlabFilterMismatch(t, &d)
+	case TriggerTypeTaskStepEntered, TriggerTypeTaskCompleted, TriggerTypeTaskFailed,
+		TriggerTypeTaskPRMerged, TriggerTypeTaskReviewBlocking:
+		return taskEventFilterMismatch(a, t, &d)
+	}
+	return ""
+}
+
+func githubFilterMismatch(t *AutomationTrigger, d *dryRunData) string {
+	owner, name, _ := strings.Cut(d.Repo, "/")
+	switch t.Type {
+	case TriggerTypeGitHubPR:
+		var cfg GitHubPRTriggerConfig
+		if json.Unmarshal(t.Config, &cfg) != nil {
+			return "invalid trigger config"
+		}
+		return firstMismatch(
+			filterCheck{matchesRepo(owner, name, cfg.Repos), "repository " + d.Repo + " is not in the trigger's repos"},
+			filterCheck{!cfg.ExcludeDraft || !d.Draft, "draft pull requests are excluded"},
+			filterCheck{matchesBranches(d.BaseBranch, cfg.Branches), "base branch " + d.BaseBranch + " does not match"},
+			filterCheck{matchesAuthors(d.AuthorLogin, cfg.Authors), "author " + d.AuthorLogin + " does not match"},
+		)
+	case TriggerTypeGitHubPush:
+		var cfg GitHubPushTriggerConfig
+		if json.Unmarshal(t.Config, &cfg) != nil {
+			return "invalid trigger config"
+		}
+		return firstMismatch(
+			filterCheck{matchesRepo(owner, name, cfg.Repos), "repository " + d.Repo + " is not in the trigger's repos"},
+			filterCheck{matchesBranches(d.Branch, cfg.Branches), "branch " + d.Branch + " does not match"},
+		)
+	case TriggerTypeGitHubCI:
+		var cfg GitHubCITriggerConfig
+		if json.Unmarshal(t.Config, &cfg) != nil {
+			return "invalid trigger config"
+		}
+		return firstMismatch(
+			filterCheck{matchesRepo(owner, name, cfg.Repos), "repository " + d.Repo + " is not in the trigger's repos"},
+			filterCheck{matchesFilterValue(d.Conclusion, cfg.Conclusions), "conclusion " + d.Conclusion + " does not match"},
+			filterCheck{matchesFilterValue(d.CheckName, cfg.CheckNames), "check " + d.CheckName + " does not match"},
+			filterCheck{matchesBranches(d.Branch, cfg.Branches), "branch " + d.Branch + " does not match"},
+		)
+	}
+	var cfg GitHubPRMergedTriggerConfig
+	if json.Unmarshal(t.Config, &cfg) != nil {
+		return "invalid trigger config"
+	}
+	return firstMismatch(
+		filterCheck{matchesMergedRepo(owner, name, cfg), "repository " + d.Repo + " is not in the trigger's repos"},
+		filterCheck{matchesMergedBranches(d.BaseBranch, cfg.BaseBranches), "base branch " + d.BaseBranch + " does not match"},
+	)
+}
+
+func gitlabFilterMismatch(t *AutomationTrigger, d *dryRunData) string {
+	projectReason := "project " + d.Project + " is not in the trigger's projects"
+	switch t.Type {
+	case TriggerTypeGitLabMR:
+		var cfg GitLabMRTriggerConfig
+		if json.Unmarshal(t.Config, &cfg) != nil {
+			return "invalid trigger config"
+		}
+		return firstMismatch(
+			filterCheck{matchesProject(d.Project, cfg.Projects), projectReason},
+			filterCheck{!cfg.ExcludeDraft || !d.Draft, "draft merge requests are excluded"},
+			filterCheck{matchesBranches(d.BaseBranch, cfg.Branches), "target branch " + d.BaseBranch + " does not match"},
+			filterCheck{matchesAuthors(d.Author, cfg.Authors), "author " + d.Author + " does not match"},
+			filterCheck{matchesAnyLabel(d.Labels, cfg.Labels), "no label matches"},
+		)
+	case TriggerTypeGitLabPipelineFailed:
+		var cfg GitLabPipelineTriggerConfig
+		if json.Unmarshal(t.Config, &cfg) != nil {
+			return "invalid trigger config"
+		}
+		return firstMismatch(
+			filterCheck{matchesProject(d.Project, cfg.Projects), projectReason},
+			filterCheck{d.Status == gitlabPipelineStatusFailed, "pipeline status " + d.Status + " is not failed"},
+			filterCheck{matchesBranches(d.Ref, cfg.Branches), "ref " + d.Ref + " does not match"},
+			filterCheck{matchesFilterValue(d.Source, cfg.Sources), "source " + d.Source + " does not match"},
+		)
+	}
+	var cfg GitLabPushTriggerConfig
+	if json.Unmarshal(t.Config, &cfg) != nil {
+		return "invalid trigger config"
+	}
+	return firstMismatch(
+		filterCheck{matchesProject(d.Project, cfg.Projects), projectReason},
+		filterCheck{matchesBranches(d.Branch, cfg.Branches), "branch " + d.Branch + " does not match"},
+	)
+}
+
+func taskEventFilterMismatch(a *Automation, t *AutomationTrigger, d *dryRunData) string {
+	var cfg TaskEventTriggerConfig
+	if len(t.Config) > 0 && json.Unmarshal(t.Config, &cfg) != nil {
+		return "invalid trigger config"
+	}
+	return firstMismatch(
+		filterCheck{d.Task.WorkspaceID == a.WorkspaceID, "the task is in another workspace"},
+		filterCheck{len(cfg.WorkflowIDs) == 0 || slices.Contains(cfg.WorkflowIDs, d.Task.WorkflowID), "workflow " + d.Task.WorkflowID + " does not match"},
+		filterCheck{len(cfg.StepIDs) == 0 || slices.Contains(cfg.StepIDs, d.ToStepID), "step " + d.ToStepID + " does not match"},
+	)
+}
+
+// matchesProject reports whether the GitLab poller would see project: it
+// lists each configured project, so an unlisted one never fires.
+func matchesProject(project string, projects []gitlab.ProjectFilter) bool {
+	for _, p := range projects {
+		if strings.EqualFold(p.Path, project) {
+			return true
+		}
+	}
+	return false
+}
+
+type filterCheck struct {
+	ok     bool
+	reason string
+}
+
+func firstMismatch(checks ...filterCheck) string {
+	for _, c := range checks {
+		if !c.ok {
+			return c.reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kandev/kandev/internal/github"
+)
+
+type fakeTaskPreviewer struct {
+	repos []PreviewRepository
+	notes []string
+}
+
+func (f *fakeTaskPreviewer) PreviewAutomationTask(_ context.Context, a *Automation, triggerType TriggerType, triggerData json.RawMessage) (*TaskPreview, error) {
+	return &TaskPreview{
+		Title:        a.Name,
+		Prompt:       InterpolatePrompt(a.Prompt, triggerType, triggerData),
+		Repositories: f.repos,
+		Notes:        f.notes,
+	}, nil
+}
+
+func createDryRunAutomation(t *testing.T, svc *Service, maxRuns int) *Automation {
+	t.Helper()
+	a, err := svc.CreateAutomation(context.Background(), &CreateAutomationRequest{
+		WorkspaceID:       "ws-1",
+		Name:              "triage",
+		AgentProfileID:    "agent-1",
+		ExecutorProfileID: "exec-1",
+		Prompt:            "Triage {{field.title}}",
+		MaxConcurrentRuns: maxRuns,
+	})
+	if err != nil {
+		t.Fatalf("CreateAutomation() error = %v", err)
+	}
+	return a
+}
+
+func TestDryRun_WebhookSample(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	svc.SetTaskPreviewer(&fakeTaskPreviewer{repos: []PreviewRepository{{RepositoryID: "repo-1"}}})
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createDryRunAutomation(t, svc, 0)
+	trig := addTestTrigger(t, svc, a.ID, TriggerTypeWebhook, WebhookTriggerConfig{
+		FieldMappings: map[string]string{"title": "$.incident.title"},
+	})
+	result, err := svc.DryRun(context.Background(), a.ID, &DryRunRequest{
+		Payload: json.RawMessage(`{"incident":{"title":"DB down"}}`),
+	})
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	if result.TriggerID != trig.ID || result.TriggerType != TriggerTypeWebhook {
+		t.Fatalf("trigger = %s/%s, want %s/webhook", result.TriggerID, result.TriggerType, trig.ID)
+	}
+	if result.Prompt != "Triage DB down" || result.Title != "triage" {
+		t.Fatalf("title/prompt = %q/%q", result.Title, result.Prompt)
+	}
+	if result.WouldSkip || len(result.Repositories) != 1 || result.AgentProfileID != "agent-1" {
+		t.Fatalf("result = %+v", result)
+	}
+	expectNoFire(t, fired)
+	runs, err := svc.ListRuns(context.Background(), a.ID, 10)
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("dry run recorded runs: %v, %v", runs, err)
+	}
+}
+
+func TestDryRun_SkipReasons(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	svc.SetTaskPreviewer(&fakeTaskPreviewer{})
+	ctx := context.Background()
+
+	a := createDryRunAutomation(t, svc, 1)
+	addTestTrigger(t, svc, a.ID, TriggerTypeGitHubPR, GitHubPRTriggerConfig{
+		Repos: []github.RepoFilter{{Owner: "acme", Name: "api"}},
+	})
+	if err := svc.DisableAutomation(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	createCompletionRun(t, svc, a.ID, TriggerTypeScheduled, `{}`)
+
+	result, err := svc.DryRun(ctx, a.ID, &DryRunRequest{
+		TriggerType: TriggerTypeGitHubPR,
+		Payload:     json.RawMessage(`{"repo":"acme/web","number":3}`),
+	})
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	want := []string{
+		"automation is disabled",
+		"repository acme/web is not in the trigger's repos",
+		concurrencyCapReason(1),
+		noRepositoryReason,
+	}
+	if !result.WouldSkip || !reflect.DeepEqual(result.SkipReasons, want) {
+		t.Fatalf("skip reasons = %q, want %q", result.SkipReasons, want)
+	}
+
+	if _, err := svc.DryRun(ctx, a.ID, &DryRunRequest{TriggerType: TriggerTypeGitLabMR}); !errors.Is(err, ErrTriggerNotFound) {
+		t.Fatalf("unknown trigger type err = %v, want ErrTriggerNotFound", err)
+	}
+	if _, err := svc.DryRun(ctx, a.ID, &DryRunRequest{Payload: json.RawMessage(`{`)}); !errors.Is(err, ErrInvalidDryRunPayload) {
+		t.Fatalf("invalid payload err = %v, want ErrInvalidDryRunPayload", err)
+	}
+}
+
+func TestDryRun_RepositoryToCloneIsANote(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	note := "repository acme/api would be cloned"
+	svc.SetTaskPreviewer(&fakeTaskPreviewer{notes: []string{note}})
+
+	a := createDryRunAutomation(t, svc, 0)
+	addTestTrigger(t, svc, a.ID, TriggerTypeGitHubPR, GitHubPRTriggerConfig{})
+	result, err := svc.DryRun(context.Background(), a.ID, &DryRunRequest{
+		Payload: json.RawMessage(`{"repo":"acme/api","number":3}`),
+	})
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	if result.WouldSkip || len(result.SkipReasons) != 0 {
+		t.Fatalf("a repository to clone must not skip the firing, got %q", result.SkipReasons)
+	}
+	if !reflect.DeepEqual(result.Notes, []string{note}) {
+		t.Fatalf("notes = %q, want %q", result.Notes, note)
+	}
+}
+
+func TestReplayRun_ReappliesWebhookMappings(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	ctx := context.Background()
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createDryRunAutomation(t, svc, 0)
+	trig := addTestTrigger(t, svc, a.ID, TriggerTypeWebhook, WebhookTriggerConfig{})
+	body := []byte(`{"incident":{"title":"DB down"}}`)
+	run := &AutomationRun{
+		AutomationID: a.ID, TriggerID: trig.ID, TriggerType: TriggerTypeWebhook,
+		TriggerData: webhookTriggerData(body, nil), RawPayload: webhookRawPayload(body),
+		Status: RunStatusFailed, DedupKey: "delivery-1",
+	}
+	if err := svc.Store().CreateRun(ctx, run); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := svc.Store().GetRun(ctx, run.ID)
+	if err != nil || string(stored.RawPayload) != string(body) {
+		t.Fatalf("stored raw payload = %s, %v", stored.RawPayload, err)
+	}
+
+	// The fix: map the title the prompt was missing.
+	cfg, _ := json.Marshal(WebhookTriggerConfig{FieldMappings: map[string]string{"title": "$.incident.title"}})
+	raw := json.RawMessage(cfg)
+	if err := svc.UpdateTrigger(ctx, trig.ID, &UpdateTriggerRequest{Config: &raw}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ReplayRun(ctx, run.ID); err != nil {
+		t.Fatalf("ReplayRun() error = %v", err)
+	}
+	select {
+	case evt := <-fired:
+		if got := InterpolatePrompt(a.Prompt, evt.TriggerType, evt.TriggerData); got != "Triage DB down" {
+			t.Fatalf("replayed prompt = %q", got)
+		}
+		if evt.DedupKey != "" || string(evt.RawPayload) != string(body) {
+			t.Fatalf("event = %+v", evt)
+		}
+	default:
+		t.Fatal("expected AutomationTriggered event")
+	}
+
+	pending := createCompletionRun(t, svc, a.ID, TriggerTypeScheduled, `{}`)
+	if _, err := svc.ReplayRun(ctx, pending.ID); !errors.Is(err, ErrRunNotReplayable) {
+		t.Fatalf("replay of pending run err = %v, want ErrRunNotReplayable", err)
+	}
+	if _, err := svc.ReplayRun(ctx, "missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("replay of missing run err = %v, want ErrRunNotFound", err)
+	}
+}
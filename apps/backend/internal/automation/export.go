@@ -62,10 +62,13 @@ type exportExecutorProfile struct {
 // exportWorkflow is the portable {name, step} descriptor resolved from
 // Automation.WorkflowID / WorkflowStepID. Step is omitted (not merely empty) when the
 // workflow resolves but the step does not, or when no step was ever referenced
-// (AC-19).
+// (AC-19). Revision is the workflow's latest revision at export time, so an
+// importer can tell which definition the step name was read from; omitted
+// when the workflow has never cut a revision.
 type exportWorkflow struct {
-	Name string `yaml:"name"`
-	Step string `yaml:"step,omitempty"`
+	Name     string `yaml:"name"`
+	Step     string `yaml:"step,omitempty"`
+	Revision int    `yaml:"revision,omitempty"`
 }
 
 // exportTrigger is a single trigger's exported form. Key order is pinned by AC-40:
@@ -47,6 +47,12 @@ type ExportWorkflowStepLookup interface {
 	GetStepTx(ctx context.Context, tx *sqlx.Tx, id string) (*workflowmodels.WorkflowStep, bool, error)
 }
 
+// ExportWorkflowRevisionLookup resolves the latest revision number of the
+// workflow an automation references, 0 when none was cut.
+type ExportWorkflowRevisionLookup interface {
+	GetLatestRevisionTx(ctx context.Context, tx *sqlx.Tx, workflowID string) (int, error)
+}
+
 // ExportRepositoryLookup resolves the full repository row a repository_ids
 // entry references. Deliberately not the existing RepositoryLookup: that
 // interface returns (workspaceID, defaultBranch, ok) for cross-workspace
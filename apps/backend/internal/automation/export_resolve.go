@@ -161,6 +161,13 @@ func (s *Service) resolveWorkflow(ctx context.Context, tx *sqlx.Tx, a *Automatio
 		return nil, "unresolved workflow", nil
 	}
 	result := &exportWorkflow{Name: wf.Name}
+	if s.exportWorkflowRevisionLookup != nil {
+		revision, err := s.exportWorkflowRevisionLookup.GetLatestRevisionTx(ctx, tx, a.WorkflowID)
+		if err != nil {
+			return nil, "", fmt.Errorf("resolve workflow %q revision: %w", a.WorkflowID, err)
+		}
+		result.Revision = revision
+	}
 	if a.WorkflowStepID == "" {
 		return result, "", nil
 	}
@@ -81,6 +81,16 @@ func (f *fakeExportWorkflowStepLookup) GetStepTx(_ context.Context, tx *sqlx.Tx,
 	return s, ok, nil
 }
 
+type fakeExportWorkflowRevisionLookup struct {
+	revisions map[string]int
+	gotTx     *sqlx.Tx
+}
+
+func (f *fakeExportWorkflowRevisionLookup) GetLatestRevisionTx(_ context.Context, tx *sqlx.Tx, workflowID string) (int, error) {
+	f.gotTx = tx
+	return f.revisions[workflowID], nil
+}
+
 type fakeExportRepositoryLookup struct {
 	repositories map[string]*taskmodels.Repository
 	err          error
@@ -313,6 +323,27 @@ func TestResolveDescriptors_WorkflowResolvedStepFromDifferentWorkflow_OmitsStepA
 	}
 }
 
+func TestResolveDescriptors_WorkflowRevisionStampedWhenLookupWired(t *testing.T) {
+	svc, _, _, workflowLookup, stepLookup, _ := resolveTestFixture(t)
+	workflowLookup.workflows["wf-1"] = &taskmodels.Workflow{Name: "Review Flow"}
+	stepLookup.steps["step-1"] = &workflowmodels.WorkflowStep{Name: "In Review", WorkflowID: "wf-1"}
+	revisionLookup := &fakeExportWorkflowRevisionLookup{revisions: map[string]int{"wf-1": 3}}
+	svc.SetExportWorkflowRevisionLookup(revisionLookup)
+	tx := beginTestReadTx(t, svc)
+
+	got, _, err := svc.resolveDescriptors(context.Background(), tx, &Automation{ID: "a1", Name: "A", WorkflowID: "wf-1", WorkflowStepID: "step-1"})
+	if err != nil {
+		t.Fatalf("resolveDescriptors: %v", err)
+	}
+	want := &exportWorkflow{Name: "Review Flow", Step: "In Review", Revision: 3}
+	if got.Workflow == nil || *got.Workflow != *want {
+		t.Errorf("Workflow = %+v, want %+v", got.Workflow, want)
+	}
+	if revisionLookup.gotTx != tx {
+		t.Error("revision lookup did not receive the export transaction")
+	}
+}
+
 // AC-19's silent case: workflow_id set, workflow_step_id empty is not
 // "absent" — nothing was referenced. Same shape as the unresolved-step case
 // (workflow name only, no step) but with no warning at all.
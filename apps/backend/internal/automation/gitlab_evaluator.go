@@ -0,0 +1,359 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/gitlab"
+)
+
+const (
+	defaultGitLabPollInterval  = 60 * time.Second
+	gitlabPipelineStatusFailed = "failed"
+)
+
+// GitLabClientSource resolves a workspace's GitLab client. *gitlab.Service
+// satisfies it.
+type GitLabClientSource interface {
+	ClientForWorkspace(ctx context.Context, workspaceID string) (gitlab.Client, error)
+}
+
+// GitLabEvaluator polls GitLab for events matching automation triggers. It
+// handles gitlab_mr, gitlab_pipeline_failed and gitlab_push. Kandev has no
+// GitLab webhook intake, so unlike github_push / github_ci all three are
+// polled, each against the GitLab connection of the automation's workspace.
+type GitLabEvaluator struct {
+	svc     *Service
+	clients GitLabClientSource
+	logger  *logger.Logger
+
+	// heads holds the branch head SHAs the last poll saw, per gitlab_push
+	// trigger and then per "project|branch", so a push shows up as a head
+	// that moved between two polls.
+	mu    sync.Mutex
+	heads map[string]map[string]string
+
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewGitLabEvaluator creates a new GitLab trigger evaluator. It does not
+// start until a client source is wired with SetClientSource.
+func NewGitLabEvaluator(svc *Service, log *logger.Logger) *GitLabEvaluator {
+	return &GitLabEvaluator{
+		svc:    svc,
+		logger: log,
+		heads:  make(map[string]map[string]string),
+	}
+}
+
+// SetClientSource wires the GitLab service. Must be called before Start.
+func (e *GitLabEvaluator) SetClientSource(clients GitLabClientSource) {
+	e.clients = clients
+}
+
+// Start begins the polling loop.
+func (e *GitLabEvaluator) Start(ctx context.Context) {
+	if e.started || e.clients == nil {
+		return
+	}
+	e.started = true
+	ctx, e.cancel = context.WithCancel(ctx)
+
+	e.wg.Add(1)
+	go e.loop(ctx)
+
+	e.logger.Info("automation GitLab evaluator started")
+}
+
+// Stop cancels the polling loop and waits for it to finish.
+func (e *GitLabEvaluator) Stop() {
+	if !e.started {
+		return
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.wg.Wait()
+	e.started = false
+	e.logger.Info("automation GitLab evaluator stopped")
+}
+
+func (e *GitLabEvaluator) loop(ctx context.Context) {
+	defer e.wg.Done()
+
+	e.evaluate(ctx)
+
+	ticker := time.NewTicker(defaultGitLabPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluate(ctx)
+		}
+	}
+}
+
+func (e *GitLabEvaluator) evaluate(ctx context.Context) {
+	mrTriggers := e.listTriggers(ctx, TriggerTypeGitLabMR)
+	for i := range mrTriggers {
+		e.checkMRTrigger(ctx, &mrTriggers[i])
+	}
+	pipelineTriggers := e.listTriggers(ctx, TriggerTypeGitLabPipelineFailed)
+	for i := range pipelineTriggers {
+		e.checkPipelineTrigger(ctx, &pipelineTriggers[i])
+	}
+	pushTriggers := e.listTriggers(ctx, TriggerTypeGitLabPush)
+	for i := range pushTriggers {
+		e.checkPushTrigger(ctx, &pushTriggers[i])
+	}
+	e.pruneHeads(pushTriggers)
+}
+
+func (e *GitLabEvaluator) listTriggers(ctx context.Context, triggerType TriggerType) []AutomationTrigger {
+	triggers, err := e.svc.Store().ListEnabledTriggersByType(ctx, triggerType)
+	if err != nil {
+		e.logger.Error("failed to list GitLab triggers",
+			zap.String("trigger_type", string(triggerType)), zap.Error(err))
+		return nil
+	}
+	return triggers
+}
+
+// triggerClient decodes the trigger's config into cfg and resolves the
+// GitLab client of the automation's workspace. ok=false means the trigger
+// cannot be polled this round.
+func (e *GitLabEvaluator) triggerClient(ctx context.Context, t *AutomationTrigger, cfg interface{}) (gitlab.Client, bool) {
+	if err := json.Unmarshal(t.Config, cfg); err != nil {
+		e.logger.Debug("invalid GitLab trigger config",
+			zap.String("trigger_id", t.ID), zap.Error(err))
+		return nil, false
+	}
+	a, err := e.svc.GetAutomation(ctx, t.AutomationID)
+	if err != nil || a == nil || a.WorkspaceID == "" {
+		e.logger.Debug("automation trigger is missing workspace ownership",
+			zap.String("trigger_id", t.ID), zap.Error(err))
+		return nil, false
+	}
+	client, err := e.clients.ClientForWorkspace(ctx, a.WorkspaceID)
+	if err != nil || client == nil {
+		e.logger.Debug("no GitLab client for automation workspace",
+			zap.String("trigger_id", t.ID),
+			zap.String("workspace_id", a.WorkspaceID),
+			zap.Error(err))
+		return nil, false
+	}
+	return client, true
+}
+
+func (e *GitLabEvaluator) checkMRTrigger(ctx context.Context, t *AutomationTrigger) {
+	var cfg GitLabMRTriggerConfig
+	client, ok := e.triggerClient(ctx, t, &cfg)
+	if !ok {
+		return
+	}
+	for _, project := range cfg.Projects {
+		mrs, err := client.ListProjectMRs(ctx, project.Path)
+		if err != nil {
+			e.logger.Debug("failed to list MRs for automation trigger",
+				zap.String(automationProjectKey, project.Path), zap.Error(err))
+			continue
+		}
+		for _, mr := range mrs {
+			if mr == nil || !matchesMR(mr, &cfg) {
+				continue
+			}
+			e.fireMRTrigger(ctx, t, project.Path, mr)
+		}
+	}
+}
+
+// matchesMR applies a gitlab_mr trigger's filters to an open MR.
+func matchesMR(mr *gitlab.MR, cfg *GitLabMRTriggerConfig) bool {
+	if cfg.ExcludeDraft && mr.Draft {
+		return false
+	}
+	return matchesBranches(mr.BaseBranch, cfg.Branches) &&
+		matchesAuthors(mr.AuthorUsername, cfg.Authors) &&
+		matchesAnyLabel(mr.Labels, cfg.Labels)
+}
+
+// matchesAnyLabel reports whether labels carries one of the filter labels.
+// Empty filter means match all.
+func matchesAnyLabel(labels, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, l := range labels {
+		if matchesFilterValue(l, filters) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *GitLabEvaluator) fireMRTrigger(ctx context.Context, t *AutomationTrigger, projectPath string, mr *gitlab.MR) {
+	if mr.ProjectPath != "" {
+		projectPath = mr.ProjectPath
+	}
+	webURL := mr.WebURL
+	if webURL == "" {
+		webURL = mr.URL
+	}
+	dedupKey := fmt.Sprintf("gitlab_mr:%s!%d", strings.ToLower(projectPath), mr.IID)
+	data, _ := json.Marshal(map[string]interface{}{
+		"iid":                   mr.IID,
+		"title":                 mr.Title,
+		automationHTMLURLKey:    webURL,
+		"author":                mr.AuthorUsername,
+		automationProjectKey:    projectPath,
+		automationHeadBranchKey: mr.HeadBranch,
+		automationBaseBranchKey: mr.BaseBranch,
+		automationBodyKey:       mr.Body,
+		"draft":                 mr.Draft,
+		"labels":                mr.Labels,
+		"state":                 mr.State,
+	})
+	if _, err := e.svc.FireTrigger(ctx, t.AutomationID, t.ID, TriggerTypeGitLabMR, data, dedupKey); err != nil {
+		e.logger.Error("failed to fire MR trigger",
+			zap.String("trigger_id", t.ID), zap.Error(err))
+	}
+}
+
+func (e *GitLabEvaluator) checkPipelineTrigger(ctx context.Context, t *AutomationTrigger) {
+	var cfg GitLabPipelineTriggerConfig
+	client, ok := e.triggerClient(ctx, t, &cfg)
+	if !ok {
+		return
+	}
+	for _, project := range cfg.Projects {
+		pipelines, err := client.ListPipelines(ctx, project.Path, "")
+		if err != nil {
+			e.logger.Debug("failed to list pipelines for automation trigger",
+				zap.String(automationProjectKey, project.Path), zap.Error(err))
+			continue
+		}
+		for i := range pipelines {
+			if !matchesPipeline(&pipelines[i], &cfg, t.CreatedAt) {
+				continue
+			}
+			e.firePipelineTrigger(ctx, t, project.Path, &pipelines[i])
+		}
+	}
+}
+
+// matchesPipeline applies a gitlab_pipeline_failed trigger's filters to a
+// pipeline. Only pipelines that finished after the trigger was created
+// match: each poll sees the project's recent pipelines, and the failures
+// that predate the trigger must not fire it.
+func matchesPipeline(p *gitlab.Pipeline, cfg *GitLabPipelineTriggerConfig, since time.Time) bool {
+	if p.Status != gitlabPipelineStatusFailed || p.FinishedAt == nil || !p.FinishedAt.After(since) {
+		return false
+	}
+	return matchesBranches(p.Ref, cfg.Branches) && matchesFilterValue(p.Source, cfg.Sources)
+}
+
+func (e *GitLabEvaluator) firePipelineTrigger(ctx context.Context, t *AutomationTrigger, projectPath string, p *gitlab.Pipeline) {
+	dedupKey := fmt.Sprintf("gitlab_pipeline:%s#%d", strings.ToLower(projectPath), p.ID)
+	data, _ := json.Marshal(map[string]interface{}{
+		automationProjectKey: projectPath,
+		"pipeline_id":        p.ID,
+		"ref":                p.Ref,
+		"sha":                p.SHA,
+		"status":             p.Status,
+		"source":             p.Source,
+		automationHTMLURLKey: p.WebURL,
+	})
+	if _, err := e.svc.FireTrigger(ctx, t.AutomationID, t.ID, TriggerTypeGitLabPipelineFailed, data, dedupKey); err != nil {
+		e.logger.Error("failed to fire pipeline trigger",
+			zap.String("trigger_id", t.ID), zap.Error(err))
+	}
+}
+
+func (e *GitLabEvaluator) checkPushTrigger(ctx context.Context, t *AutomationTrigger) {
+	var cfg GitLabPushTriggerConfig
+	client, ok := e.triggerClient(ctx, t, &cfg)
+	if !ok {
+		return
+	}
+	for _, project := range cfg.Projects {
+		branches, err := client.ListProjectBranches(ctx, project.Path)
+		if err != nil {
+			e.logger.Debug("failed to list branches for automation trigger",
+				zap.String(automationProjectKey, project.Path), zap.Error(err))
+			continue
+		}
+		for _, b := range branches {
+			if b.CommitSHA == "" || !matchesBranches(b.Name, cfg.Branches) {
+				continue
+			}
+			if e.headMoved(t, project.Path, b) {
+				e.firePushTrigger(ctx, t, project.Path, b)
+			}
+		}
+	}
+}
+
+// headMoved records b's head for the trigger and reports whether it is a
+// push to fire for. A branch seen by an earlier poll fires when its head
+// changed. A branch this process has not seen yet (first poll, new branch,
+// or a restart) fires only when its head commit is newer than the trigger,
+// so the heads that existed when the trigger was created never fire; the
+// SHA in the dedup key keeps a restart from firing the same push twice.
+func (e *GitLabEvaluator) headMoved(t *AutomationTrigger, projectPath string, b gitlab.RepoBranch) bool {
+	key := strings.ToLower(projectPath) + "|" + b.Name
+	e.mu.Lock()
+	heads := e.heads[t.ID]
+	if heads == nil {
+		heads = make(map[string]string)
+		e.heads[t.ID] = heads
+	}
+	prev, seen := heads[key]
+	heads[key] = b.CommitSHA
+	e.mu.Unlock()
+	if seen {
+		return prev != b.CommitSHA
+	}
+	return b.CommittedAt != nil && b.CommittedAt.After(t.CreatedAt)
+}
+
+// pruneHeads drops the recorded heads of push triggers that are gone or
+// disabled.
+func (e *GitLabEvaluator) pruneHeads(live []AutomationTrigger) {
+	keep := make(map[string]struct{}, len(live))
+	for i := range live {
+		keep[live[i].ID] = struct{}{}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for id := range e.heads {
+		if _, ok := keep[id]; !ok {
+			delete(e.heads, id)
+		}
+	}
+}
+
+func (e *GitLabEvaluator) firePushTrigger(ctx context.Context, t *AutomationTrigger, projectPath string, b gitlab.RepoBranch) {
+	dedupKey := fmt.Sprintf("gitlab_push:%s@%s@%s", strings.ToLower(projectPath), b.Name, b.CommitSHA)
+	data, _ := json.Marshal(map[string]interface{}{
+		automationProjectKey: projectPath,
+		"branch":             b.Name,
+		"sha":                b.CommitSHA,
+		"message":            b.CommitTitle,
+	})
+	if _, err := e.svc.FireTrigger(ctx, t.AutomationID, t.ID, TriggerTypeGitLabPush, data, dedupKey); err != nil {
+		e.logger.Error("failed to fire push trigger",
+			zap.String("trigger_id", t.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,171 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/gitlab"
+)
+
+// staticGitLabClients hands every workspace the same client.
+type staticGitLabClients struct{ client gitlab.Client }
+
+func (s staticGitLabClients) ClientForWorkspace(context.Context, string) (gitlab.Client, error) {
+	return s.client, nil
+}
+
+func newTestGitLabEvaluator(t *testing.T, svc *Service) (*GitLabEvaluator, *gitlab.MockClient) {
+	t.Helper()
+	log, err := logger.NewFromZap(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := gitlab.NewMockClient("")
+	e := NewGitLabEvaluator(svc, log)
+	e.SetClientSource(staticGitLabClients{client: mock})
+	return e, mock
+}
+
+func drainFired(fired chan *AutomationTriggeredEvent) []*AutomationTriggeredEvent {
+	var out []*AutomationTriggeredEvent
+	for {
+		select {
+		case evt := <-fired:
+			out = append(out, evt)
+		default:
+			return out
+		}
+	}
+}
+
+func TestGitLabEvaluator_MRTrigger(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	e, mock := newTestGitLabEvaluator(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeGitLabMR, GitLabMRTriggerConfig{
+		Projects:     []gitlab.ProjectFilter{{Path: "acme/api"}},
+		Branches:     []string{"main"},
+		Labels:       []string{"needs-review"},
+		ExcludeDraft: true,
+	})
+	mock.SeedMR("acme/api", &gitlab.MR{
+		IID: 7, Title: "Add rate limiting", State: "open", BaseBranch: "main", HeadBranch: "rate-limit",
+		AuthorUsername: "alice", Labels: []string{"backend", "needs-review"},
+	})
+	mock.SeedMR("acme/api", &gitlab.MR{Title: "Draft", State: "open", BaseBranch: "main", Draft: true, Labels: []string{"needs-review"}})
+	mock.SeedMR("acme/api", &gitlab.MR{Title: "Unlabelled", State: "open", BaseBranch: "main"})
+	mock.SeedMR("acme/api", &gitlab.MR{Title: "Release", State: "open", BaseBranch: "release", Labels: []string{"needs-review"}})
+	mock.SeedMR("acme/web", &gitlab.MR{Title: "Other project", State: "open", BaseBranch: "main", Labels: []string{"needs-review"}})
+
+	e.evaluate(context.Background())
+
+	got := drainFired(fired)
+	if len(got) != 1 {
+		t.Fatalf("fired %d events, want 1: %+v", len(got), got)
+	}
+	evt := got[0]
+	if evt.TriggerType != TriggerTypeGitLabMR || evt.DedupKey != "gitlab_mr:acme/api!7" {
+		t.Fatalf("unexpected event: %+v", evt)
+	}
+	prompt := InterpolatePrompt("{{mr.project}}!{{mr.iid}} {{mr.title}} by {{mr.author}} ({{mr.branch}} → {{mr.target_branch}})",
+		evt.TriggerType, evt.TriggerData)
+	if want := "acme/api!7 Add rate limiting by alice (rate-limit → main)"; prompt != want {
+		t.Fatalf("prompt = %q, want %q", prompt, want)
+	}
+}
+
+func TestGitLabEvaluator_PipelineFailedTrigger(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	e, mock := newTestGitLabEvaluator(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeGitLabPipelineFailed, GitLabPipelineTriggerConfig{
+		Projects: []gitlab.ProjectFilter{{Path: "acme/api"}},
+		Branches: []string{"main", "release/*"},
+	})
+	before := time.Now().Add(-time.Hour)
+	after := time.Now().Add(time.Minute)
+	mock.SeedPipelines("acme/api", []gitlab.Pipeline{
+		{ID: 10, Status: "failed", Ref: "main", FinishedAt: &before},
+		{ID: 11, Status: "success", Ref: "main", FinishedAt: &after},
+		{ID: 12, Status: "failed", Ref: "feature", FinishedAt: &after},
+		{ID: 13, Status: "failed", Ref: "release/v2", SHA: "abc123", Source: "push", WebURL: "https://gitlab.example/p/13", FinishedAt: &after},
+	})
+
+	e.evaluate(context.Background())
+
+	got := drainFired(fired)
+	if len(got) != 1 || got[0].DedupKey != "gitlab_pipeline:acme/api#13" {
+		t.Fatalf("fired = %+v, want only pipeline 13", got)
+	}
+	prompt := InterpolatePrompt("{{pipeline.id}} {{pipeline.ref}} {{pipeline.source}} {{pipeline.url}}", got[0].TriggerType, got[0].TriggerData)
+	if want := "13 release/v2 push https://gitlab.example/p/13"; prompt != want {
+		t.Fatalf("prompt = %q, want %q", prompt, want)
+	}
+}
+
+func TestGitLabEvaluator_PushTrigger(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	e, mock := newTestGitLabEvaluator(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	trig := addTestTrigger(t, svc, a.ID, TriggerTypeGitLabPush, GitLabPushTriggerConfig{
+		Projects: []gitlab.ProjectFilter{{Path: "acme/api"}},
+		Branches: []string{"main"},
+	})
+	old := time.Now().Add(-time.Hour)
+	mock.SeedBranches("acme/api", []gitlab.RepoBranch{
+		{Name: "main", CommitSHA: "aaa", CommittedAt: &old},
+		{Name: "feature", CommitSHA: "fff", CommittedAt: &old},
+	})
+
+	// The heads that existed before the trigger are a baseline, not pushes.
+	e.evaluate(context.Background())
+	if got := drainFired(fired); len(got) != 0 {
+		t.Fatalf("first poll fired %+v, want nothing", got)
+	}
+
+	mock.SeedBranches("acme/api", []gitlab.RepoBranch{
+		{Name: "main", CommitSHA: "bbb", CommitTitle: "fix: retry", CommittedAt: &old},
+		{Name: "feature", CommitSHA: "ggg", CommittedAt: &old},
+	})
+	e.evaluate(context.Background())
+	got := drainFired(fired)
+	if len(got) != 1 || got[0].TriggerID != trig.ID || got[0].DedupKey != "gitlab_push:acme/api@main@bbb" {
+		t.Fatalf("fired = %+v, want one push to main", got)
+	}
+	if prompt := InterpolatePrompt("{{push.project}} {{push.branch}} {{push.sha}} {{push.message}}", got[0].TriggerType, got[0].TriggerData); prompt != "acme/api main bbb fix: retry" {
+		t.Fatalf("prompt = %q", prompt)
+	}
+
+	e.evaluate(context.Background())
+	if got := drainFired(fired); len(got) != 0 {
+		t.Fatalf("unchanged head fired %+v", got)
+	}
+}
+
+func TestGitLabEvaluator_PushTriggerNewHeadAfterRestart(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	e, mock := newTestGitLabEvaluator(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeGitLabPush, GitLabPushTriggerConfig{
+		Projects: []gitlab.ProjectFilter{{Path: "acme/api"}},
+	})
+	recent := time.Now().Add(time.Minute)
+	mock.SeedBranches("acme/api", []gitlab.RepoBranch{{Name: "main", CommitSHA: "ccc", CommittedAt: &recent}})
+
+	e.evaluate(context.Background())
+	if got := drainFired(fired); len(got) != 1 {
+		t.Fatalf("a head committed after the trigger was created should fire on first sight, got %+v", got)
+	}
+}
@@ -3,6 +3,7 @@ package automation
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 
@@ -36,6 +37,8 @@ func registerWSHandlers(dispatcher *ws.Dispatcher, svc *Service, log *logger.Log
 	dispatcher.RegisterFunc(ws.ActionAutomationWebhookRevealSecret, wsRevealWebhookSecret(svc, log))
 	dispatcher.RegisterFunc(ws.ActionAutomationRunDelete, wsDeleteRun(svc, log))
 	dispatcher.RegisterFunc(ws.ActionAutomationRunsDeleteAll, wsDeleteAllRuns(svc, log))
+	dispatcher.RegisterFunc(ws.ActionAutomationDryRun, wsDryRun(svc, log))
+	dispatcher.RegisterFunc(ws.ActionAutomationRunReplay, wsReplayRun(svc, log))
 }
 
 func registerHTTPRoutes(router *gin.Engine, svc *Service, log *logger.Logger) {
@@ -439,3 +442,86 @@ func wsDeleteAllRuns(svc *Service, _ *logger.Logger) func(ctx context.Context, m
 		return ws.NewResponse(msg.ID, msg.Action, map[string]bool{"deleted": true})
 	}
 }
+
+func wsDryRun(svc *Service, _ *logger.Logger) func(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	return func(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+		var req struct {
+			ID          string `json:"id"`
+			WorkspaceID string `json:"workspace_id"`
+			DryRunRequest
+		}
+		if err := msg.ParsePayload(&req); err != nil {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "invalid payload", nil)
+		}
+		if req.ID == "" {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "id required", nil)
+		}
+		if req.WorkspaceID == "" {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "workspace_id required", nil)
+		}
+		// A dry run renders the prompt and replays recorded payloads, so it
+		// checks ownership the way wsDeleteRun does.
+		a, err := svc.GetAutomation(ctx, req.ID)
+		if err != nil {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, err.Error(), nil)
+		}
+		if a == nil || a.WorkspaceID != req.WorkspaceID {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, "automation not found", nil)
+		}
+		result, err := svc.DryRun(ctx, req.ID, &req.DryRunRequest)
+		if err != nil {
+			return dryRunError(msg, err)
+		}
+		return ws.NewResponse(msg.ID, msg.Action, result)
+	}
+}
+
+func wsReplayRun(svc *Service, _ *logger.Logger) func(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	return func(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+		payload, _ := parseMap(msg)
+		runID, _ := payload["run_id"].(string)
+		if runID == "" {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "run_id required", nil)
+		}
+		workspaceID, _ := payload["workspace_id"].(string)
+		if workspaceID == "" {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "workspace_id required", nil)
+		}
+		// See wsDeleteRun: resolve the run's automation and answer NotFound
+		// for a run in another workspace.
+		run, err := svc.GetRun(ctx, runID)
+		if err != nil {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, err.Error(), nil)
+		}
+		if run == nil {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, "run not found", nil)
+		}
+		a, err := svc.GetAutomation(ctx, run.AutomationID)
+		if err != nil {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, err.Error(), nil)
+		}
+		if a == nil || a.WorkspaceID != workspaceID {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, "run not found", nil)
+		}
+		result, err := svc.ReplayRun(ctx, runID)
+		if err != nil {
+			return dryRunError(msg, err)
+		}
+		return ws.NewResponse(msg.ID, msg.Action, map[string]any{
+			"triggered": !result.Skipped,
+			"skipped":   result.Skipped,
+			"reason":    result.Reason,
+		})
+	}
+}
+
+// dryRunError maps DryRun and ReplayRun errors to WS error codes.
+func dryRunError(msg *ws.Message, err error) (*ws.Message, error) {
+	switch {
+	case errors.Is(err, ErrAutomationNotFound), errors.Is(err, ErrRunNotFound), errors.Is(err, ErrTriggerNotFound):
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, err.Error(), nil)
+	case errors.Is(err, ErrRunNotReplayable), errors.Is(err, ErrInvalidDryRunPayload):
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, err.Error(), nil)
+	}
+	return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, err.Error(), nil)
+}
@@ -12,6 +12,12 @@ import (
 // InterpolatePrompt replaces {{placeholder}} tokens in the prompt template
 // with values from the trigger data. Supports nested access via dot notation.
 func InterpolatePrompt(prompt string, triggerType TriggerType, triggerData json.RawMessage) string {
+	return interpolate(prompt, triggerType, triggerData, nil)
+}
+
+// interpolate is InterpolatePrompt with extra replacer pairs applied in the
+// same pass, so a substituted value is never itself re-interpolated.
+func interpolate(prompt string, triggerType TriggerType, triggerData json.RawMessage, extra []string) string {
 	if prompt == "" || !strings.Contains(prompt, "{{") {
 		return prompt
 	}
@@ -36,9 +42,21 @@ func InterpolatePrompt(prompt string, triggerType TriggerType, triggerData json.
 		pairs = append(pairs, pushPlaceholders(data)...)
 	case TriggerTypeGitHubCI:
 		pairs = append(pairs, ciPlaceholders(data)...)
+	case TriggerTypeGitLabMR:
+		pairs = append(pairs, mrPlaceholders(data)...)
+	case TriggerTypeGitLabPipelineFailed:
+		pairs = append(pairs, pipelinePlaceholders(data)...)
+	case TriggerTypeGitLabPush:
+		pairs = append(pairs, gitlabPushPlaceholders(data)...)
 	case TriggerTypeWebhook:
 		pairs = append(pairs, webhookPlaceholders(data)...)
+	case TriggerTypeOnCompletion:
+		pairs = append(pairs, completionSourcePlaceholders(data)...)
+	}
+	if IsTaskEventTrigger(triggerType) {
+		pairs = append(pairs, sourceTaskPlaceholders(data)...)
 	}
+	pairs = append(pairs, extra...)
 
 	result := strings.NewReplacer(pairs...).Replace(prompt)
 	// Resolve {{data.<path>}} and {{webhook.<path>}} tokens that didn't
@@ -86,29 +104,36 @@ func lookupPath(data map[string]interface{}, path string) (string, bool) {
 	if path == "" {
 		return "", false
 	}
-	var cur interface{} = data
-	for _, seg := range strings.Split(path, ".") {
+	cur, ok := walkPath(data, strings.Split(path, "."))
+	if !ok {
+		return "", false
+	}
+	return toString(cur), true
+}
+
+// walkPath follows segs through nested objects and arrays. A nil leaf counts
+// as missing.
+func walkPath(data interface{}, segs []string) (interface{}, bool) {
+	cur := data
+	for _, seg := range segs {
 		switch node := cur.(type) {
 		case map[string]interface{}:
 			next, ok := node[seg]
 			if !ok {
-				return "", false
+				return nil, false
 			}
 			cur = next
 		case []interface{}:
 			idx, err := strconv.Atoi(seg)
 			if err != nil || idx < 0 || idx >= len(node) {
-				return "", false
+				return nil, false
 			}
 			cur = node[idx]
 		default:
-			return "", false
+			return nil, false
 		}
 	}
-	if cur == nil {
-		return "", false
-	}
-	return toString(cur), true
+	return cur, cur != nil
 }
 
 // unresolvedRe matches leftover {{placeholder}} tokens that weren't replaced.
@@ -153,11 +178,89 @@ func ciPlaceholders(data map[string]interface{}) []string {
 	}
 }
 
-func webhookPlaceholders(data map[string]interface{}) []string {
-	raw, _ := json.Marshal(data)
+func mrPlaceholders(data map[string]interface{}) []string {
+	return []string{
+		"{{mr.iid}}", toString(data["iid"]),
+		"{{mr.title}}", toString(data["title"]),
+		"{{mr.url}}", toString(data[automationHTMLURLKey]),
+		"{{mr.author}}", toString(data["author"]),
+		"{{mr.project}}", toString(data[automationProjectKey]),
+		"{{mr.branch}}", toString(data[automationHeadBranchKey]),
+		"{{mr.target_branch}}", toString(data[automationBaseBranchKey]),
+		"{{mr.body}}", toString(data[automationBodyKey]),
+	}
+}
+
+func pipelinePlaceholders(data map[string]interface{}) []string {
+	return []string{
+		"{{pipeline.id}}", toString(data["pipeline_id"]),
+		"{{pipeline.project}}", toString(data[automationProjectKey]),
+		"{{pipeline.ref}}", toString(data["ref"]),
+		"{{pipeline.sha}}", toString(data["sha"]),
+		"{{pipeline.source}}", toString(data["source"]),
+		"{{pipeline.url}}", toString(data[automationHTMLURLKey]),
+	}
+}
+
+func gitlabPushPlaceholders(data map[string]interface{}) []string {
+	return []string{
+		"{{push.branch}}", toString(data["branch"]),
+		"{{push.project}}", toString(data[automationProjectKey]),
+		"{{push.sha}}", toString(data["sha"]),
+		"{{push.message}}", toString(data["message"]),
+	}
+}
+
+// sourceTaskPlaceholders reads the task-event triggers' source task, stored
+// under "task" in the trigger data.
+func sourceTaskPlaceholders(data map[string]interface{}) []string {
+	task, _ := data["task"].(map[string]interface{})
 	return []string{
-		"{{webhook.body}}", string(raw),
+		"{{task.id}}", toString(task["id"]),
+		"{{task.identifier}}", toString(task["identifier"]),
+		"{{task.title}}", toString(task["title"]),
+		"{{task.description}}", toString(task["description"]),
+		"{{task.state}}", toString(task["state"]),
+		"{{task.priority}}", toString(task["priority"]),
+		"{{task.workflow_id}}", toString(task["workflow_id"]),
+		"{{task.step_id}}", toString(task["workflow_step_id"]),
+		"{{task.parent_id}}", toString(task["parent_id"]),
+	}
+}
+
+// completionSourcePlaceholders exposes the finished run that fired an
+// on_completion run, as recorded by the fire_automation action.
+func completionSourcePlaceholders(data map[string]interface{}) []string {
+	source, _ := data["source"].(map[string]interface{})
+	return []string{
+		"{{source.automation}}", toString(source["automation_name"]),
+		"{{source.status}}", toString(source["status"]),
+		"{{source.summary}}", toString(source["summary"]),
+		"{{source.error}}", toString(source["error_message"]),
+		"{{source.task_id}}", toString(source["task_id"]),
+		"{{source.pr_url}}", toString(source["pr_url"]),
+	}
+}
+
+// webhookPlaceholders exposes the payload as {{webhook.body}} and each
+// mapped field as {{field.<name>}}.
+func webhookPlaceholders(data map[string]interface{}) []string {
+	fields, _ := data[webhookFieldsKey].(map[string]interface{})
+	body := data
+	if fields != nil {
+		body = make(map[string]interface{}, len(data))
+		for k, v := range data {
+			if k != webhookFieldsKey {
+				body[k] = v
+			}
+		}
+	}
+	raw, _ := json.Marshal(body)
+	pairs := []string{"{{webhook.body}}", string(raw)}
+	for name, v := range fields {
+		pairs = append(pairs, "{{field."+name+"}}", toString(v))
 	}
+	return pairs
 }
 
 func toString(v interface{}) string {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/kandev/kandev/internal/github"
+	"github.com/kandev/kandev/internal/gitlab"
 )
 
 // TriggerType identifies the kind of trigger.
@@ -21,20 +22,56 @@ const (
 	TriggerTypeGitHubPush     TriggerType = "github_push"
 	TriggerTypeGitHubCI       TriggerType = "github_ci"
 	TriggerTypeWebhook        TriggerType = "webhook"
+
+	// GitLab triggers mirror github_pr / github_ci / github_push. GitLab has
+	// no webhook intake in Kandev, so all three are polled.
+	TriggerTypeGitLabMR             TriggerType = "gitlab_mr"
+	TriggerTypeGitLabPipelineFailed TriggerType = "gitlab_pipeline_failed"
+	TriggerTypeGitLabPush           TriggerType = "gitlab_push"
+
+	// Task-event triggers fire on Kandev's own event bus rather than on an
+	// external system, so one automation can spawn follow-up work from
+	// another task's lifecycle. All share TaskEventTriggerConfig.
+	TriggerTypeTaskStepEntered    TriggerType = "task_step_entered"
+	TriggerTypeTaskCompleted      TriggerType = "task_completed"
+	TriggerTypeTaskFailed         TriggerType = "task_failed"
+	TriggerTypeTaskPRMerged       TriggerType = "task_pr_merged"
+	TriggerTypeTaskReviewBlocking TriggerType = "task_review_blocking"
+
+	// TriggerTypeOnCompletion is not a registered trigger: it is the type
+	// recorded on a run fired by another automation's fire_automation
+	// completion action, the way "manual" is recorded for the Run button.
+	TriggerTypeOnCompletion TriggerType = "on_completion"
 )
 
+// IsTaskEventTrigger reports whether t is one of the task-event trigger
+// types, whose trigger data carries the source task under "task".
+func IsTaskEventTrigger(t TriggerType) bool {
+	switch t {
+	case TriggerTypeTaskStepEntered, TriggerTypeTaskCompleted, TriggerTypeTaskFailed,
+		TriggerTypeTaskPRMerged, TriggerTypeTaskReviewBlocking:
+		return true
+	}
+	return false
+}
+
 const (
 	automationAuthorLoginKey   = "author_login"
 	automationBaseBranchKey    = "base_branch"
 	automationBodyKey          = "body"
 	automationHeadBranchKey    = "head_branch"
 	automationHTMLURLKey       = "html_url"
+	automationProjectKey       = "project"
 	automationRepoKey          = "repo"
 	defaultBranchMain          = "main"
 	exampleGitHubPRURL         = "https://github.com/acme/api/pull/7"
+	exampleProjectPath         = "group/project"
 	exampleRepositoryOwner     = "org/repo"
+	placeholderProjectPath     = "Project (namespace/path)"
 	placeholderRepositoryOwner = "Repository (owner/name)"
 	triggerCategoryGitHub      = "github"
+	triggerCategoryGitLab      = "gitlab"
+	triggerCategoryTask        = "task"
 	triggerDataSourceKey       = "source"
 	triggerDataSourceManual    = "manual"
 )
@@ -105,6 +142,11 @@ type Automation struct {
 	// § Migration.
 	LegacyBoardCard bool `json:"legacy_board_card" db:"legacy_board_card"`
 
+	// OnCompletion lists what to do once a run's task finishes; see
+	// CompletionAction. Stored as JSON in OnCompletionJSON.
+	OnCompletion     []CompletionAction `json:"on_completion" db:"-"`
+	OnCompletionJSON string             `json:"-" db:"on_completion"`
+
 	// Hydrated separately, not stored as columns on this table.
 	Triggers []AutomationTrigger `json:"triggers" db:"-"`
 	// RepositoryIDs is the ordered list of repositories to use for trigger
@@ -142,6 +184,14 @@ type AutomationRun struct {
 	ErrorMessage    string          `json:"error_message,omitempty" db:"error_message"`
 	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
 
+	// RawPayload is the delivery as received, before the trigger shaped it
+	// into TriggerData: a webhook's request body, which its field mappings
+	// are applied to. Kept so a failed or skipped run can be replayed once
+	// the automation is fixed. Empty for triggers whose TriggerData is the
+	// payload, and only read by GetRun; list queries leave it out.
+	RawPayload     json.RawMessage `json:"raw_payload,omitempty" db:"-"`
+	RawPayloadJSON string          `json:"-" db:"raw_payload"`
+
 	// Summary is the tail of the agent's last message on the generated task,
 	// read at list time and truncated for display. Every automation hides its
 	// task from the board, so without this the run row can report that
@@ -219,9 +269,73 @@ type GitHubPRMergedTriggerConfig struct {
 	BaseBranches []string            `json:"base_branches"`
 }
 
+// GitLabMRTriggerConfig filters open merge requests. Projects is required:
+// the poller lists MRs per project.
+type GitLabMRTriggerConfig struct {
+	Projects     []gitlab.ProjectFilter `json:"projects"`
+	Branches     []string               `json:"branches,omitempty"` // target branch glob filter
+	Authors      []string               `json:"authors,omitempty"`  // MR author filter
+	Labels       []string               `json:"labels,omitempty"`   // any-of label filter
+	ExcludeDraft bool                   `json:"exclude_draft,omitempty"`
+}
+
+// GitLabPipelineTriggerConfig filters failed pipelines.
+type GitLabPipelineTriggerConfig struct {
+	Projects []gitlab.ProjectFilter `json:"projects"`
+	Branches []string               `json:"branches,omitempty"` // ref glob filter; empty = all
+	Sources  []string               `json:"sources,omitempty"`  // push, merge_request_event, schedule, ...
+}
+
+// GitLabPushTriggerConfig filters pushes to branches.
+type GitLabPushTriggerConfig struct {
+	Projects []gitlab.ProjectFilter `json:"projects"`
+	Branches []string               `json:"branches"` // glob patterns: ["main", "release/*"]
+}
+
 // WebhookTriggerConfig holds configuration for webhook triggers.
+//
+// SignatureScheme selects how deliveries are authenticated (see the
+// WebhookSignature* constants); every scheme is keyed by the automation's
+// webhook secret, which never appears here so exported configs stay
+// shareable. ReplayWindowSeconds bounds the age of a timestamped delivery
+// (default 300). FieldMappings names JSONPath-style payload paths that
+// prompts and task titles read as {{field.<name>}}.
 type WebhookTriggerConfig struct {
-	FilterExpression string `json:"filter_expression,omitempty"`
+	FilterExpression    string            `json:"filter_expression,omitempty"`
+	SignatureScheme     string            `json:"signature_scheme,omitempty"`
+	ReplayWindowSeconds int               `json:"replay_window_seconds,omitempty"`
+	FieldMappings       map[string]string `json:"field_mappings,omitempty"`
+}
+
+// TaskEventTriggerConfig filters the task-event triggers by the source
+// task. Empty lists match every task in the automation's workspace.
+// StepIDs applies to task_step_entered only: the step the task entered.
+type TaskEventTriggerConfig struct {
+	WorkflowIDs []string `json:"workflow_ids,omitempty"`
+	StepIDs     []string `json:"step_ids,omitempty"`
+}
+
+// SourceTask is the task a task-event trigger fired for, as exposed to the
+// prompt through the {{task.*}} placeholders.
+type SourceTask struct {
+	ID              string `json:"id"`
+	Identifier      string `json:"identifier,omitempty"`
+	WorkspaceID     string `json:"workspace_id"`
+	WorkflowID      string `json:"workflow_id"`
+	WorkflowStepID  string `json:"workflow_step_id"`
+	ParentID        string `json:"parent_id,omitempty"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	State           string `json:"state"`
+	Priority        string `json:"priority"`
+	IsAutomationRun bool   `json:"-"`
+}
+
+// SourceTaskLookup loads the source task of a task-event trigger. ok=false
+// means the task could not be resolved (absent or query error), mirroring
+// TaskOriginLookup.
+type SourceTaskLookup interface {
+	SourceTask(ctx context.Context, taskID string) (SourceTask, bool)
 }
 
 // TaskOriginLookup answers the two facts a merged-PR event needs about the
@@ -250,6 +364,7 @@ type CreateAutomationRequest struct {
 	TaskTitleTemplate string              `json:"task_title_template"`
 	MaxConcurrentRuns int                 `json:"max_concurrent_runs"`
 	Triggers          []CreateTriggerSpec `json:"triggers"`
+	OnCompletion      []CompletionAction  `json:"on_completion"`
 }
 
 // CreateTriggerSpec defines a trigger to add during automation creation.
@@ -274,6 +389,12 @@ type UpdateAutomationRequest struct {
 	TaskTitleTemplate *string  `json:"task_title_template,omitempty"`
 	Enabled           *bool    `json:"enabled,omitempty"`
 	MaxConcurrentRuns *int     `json:"max_concurrent_runs,omitempty"`
+	// WebhookSecret replaces the generated secret, for signature schemes
+	// whose provider issues its own signing secret (Stripe, Svix, Slack).
+	WebhookSecret *string `json:"webhook_secret,omitempty"`
+	// OnCompletion replaces the completion actions when non-nil; an
+	// explicit empty slice clears them, as for RepositoryIDs.
+	OnCompletion []CompletionAction `json:"on_completion,omitempty"`
 }
 
 // AddTriggerRequest adds a trigger to an existing automation.
@@ -312,6 +433,64 @@ type AutomationTriggeredEvent struct {
 	TriggerType  TriggerType     `json:"trigger_type"`
 	TriggerData  json.RawMessage `json:"trigger_data"`
 	DedupKey     string          `json:"dedup_key"`
+	RawPayload   json.RawMessage `json:"raw_payload,omitempty"`
+}
+
+// AutomationRunCompletedEvent is published when a run's task reaches a
+// terminal state and its row flips to succeeded or failed.
+type AutomationRunCompletedEvent struct {
+	RunID        string    `json:"run_id"`
+	AutomationID string    `json:"automation_id"`
+	TaskID       string    `json:"task_id"`
+	Status       RunStatus `json:"status"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+}
+
+// CompletionActionType identifies what a completion action does.
+type CompletionActionType string
+
+const (
+	// CompletionActionComment comments on the pull or merge request that
+	// triggered the run (github_pr, github_pr_merged, task_pr_merged and
+	// gitlab_mr triggers); other trigger types have nothing to comment on.
+	CompletionActionComment CompletionActionType = "comment"
+	// CompletionActionCallback POSTs the run result as JSON to URL, signed
+	// with the automation's webhook secret.
+	CompletionActionCallback CompletionActionType = "callback"
+	// CompletionActionOpenPR pushes the run's branch and opens a pull
+	// request from it.
+	CompletionActionOpenPR CompletionActionType = "open_pr"
+	// CompletionActionFireAutomation fires another automation in the same
+	// workspace with this run's outcome as its trigger data.
+	CompletionActionFireAutomation CompletionActionType = "fire_automation"
+)
+
+// Values of CompletionAction.When. Empty means the type's default: open_pr
+// only after a successful run, everything else always.
+const (
+	CompletionWhenAlways    = "always"
+	CompletionWhenSucceeded = "succeeded"
+	CompletionWhenFailed    = "failed"
+)
+
+// CompletionAction is one step an automation takes after a run finishes.
+// Actions run in order; Title and Body are templates over the trigger's
+// placeholders plus {{automation.name}}, {{run.id}}, {{run.status}},
+// {{run.summary}}, {{run.error}}, {{run.task_id}} and, once an open_pr
+// action has run, {{run.pr_url}}.
+type CompletionAction struct {
+	Type CompletionActionType `json:"type"`
+	When string               `json:"when,omitempty"`
+	// Body is the comment text, or the pull request description for open_pr.
+	Body string `json:"body,omitempty"`
+	// URL is the callback endpoint.
+	URL string `json:"url,omitempty"`
+	// Title, BaseBranch and Draft shape the pull request open_pr creates.
+	Title      string `json:"title,omitempty"`
+	BaseBranch string `json:"base_branch,omitempty"`
+	Draft      bool   `json:"draft,omitempty"`
+	// AutomationID is the automation fire_automation fires.
+	AutomationID string `json:"automation_id,omitempty"`
 }
 
 // RepositoryLookup resolves a repository's workspace ownership for
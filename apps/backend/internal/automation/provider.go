@@ -12,31 +12,42 @@ import (
 
 // Components holds the automation subsystem components for lifecycle management.
 type Components struct {
-	Service            *Service
-	Scheduler          *CronScheduler
-	Evaluator          *GitHubEvaluator
-	WebhookSubscriber  *GitHubWebhookSubscriber
-	PRMergedSubscriber *GitHubPRMergedSubscriber
+	Service             *Service
+	Scheduler           *CronScheduler
+	Evaluator           *GitHubEvaluator
+	GitLabEvaluator     *GitLabEvaluator
+	WebhookSubscriber   *GitHubWebhookSubscriber
+	PRMergedSubscriber  *GitHubPRMergedSubscriber
+	TaskEventSubscriber *TaskEventSubscriber
+	CompletionRunner    *CompletionActionRunner
 }
 
-// Start begins background processing (scheduler + GitHub polling + webhook subscriber + merged-PR subscriber).
+// Start begins background processing (scheduler + GitHub and GitLab polling + webhook subscriber +
+// merged-PR subscriber + task-event subscriber + completion actions).
 func (c *Components) Start(ctx context.Context) {
 	c.Scheduler.Start(ctx)
 	c.Evaluator.Start(ctx)
+	c.GitLabEvaluator.Start(ctx)
 	c.WebhookSubscriber.Start(ctx)
 	c.PRMergedSubscriber.Start(ctx)
+	c.TaskEventSubscriber.Start(ctx)
+	c.CompletionRunner.Start(ctx)
 }
 
 // Stop gracefully shuts down background processing.
 func (c *Components) Stop() {
 	c.Scheduler.Stop()
 	c.Evaluator.Stop()
+	c.GitLabEvaluator.Stop()
 	c.WebhookSubscriber.Stop()
 	c.PRMergedSubscriber.Stop()
+	c.TaskEventSubscriber.Stop()
+	c.CompletionRunner.Stop()
 }
 
-// Provide creates the full automation stack: store, service, scheduler, evaluator,
-// webhook subscriber.
+// Provide creates the full automation stack: store, service, scheduler, evaluators,
+// webhook and event-bus subscribers. The GitLab evaluator stays idle until
+// GitLabEvaluator.SetClientSource wires the GitLab service.
 func Provide(
 	writer, reader *sqlx.DB,
 	eventBus bus.EventBus,
@@ -52,14 +63,20 @@ func Provide(
 	scheduler := NewCronScheduler(svc, log)
 
 	evaluator := NewGitHubEvaluator(svc, ghSvc, log)
+	gitlabEvaluator := NewGitLabEvaluator(svc, log)
 	webhookSubscriber := NewGitHubWebhookSubscriber(svc, eventBus, log)
 	prMergedSubscriber := NewGitHubPRMergedSubscriber(svc, eventBus, log)
+	taskEventSubscriber := NewTaskEventSubscriber(svc, eventBus, log)
+	completionRunner := NewCompletionActionRunner(svc, eventBus, log)
 
 	return &Components{
-		Service:            svc,
-		Scheduler:          scheduler,
-		Evaluator:          evaluator,
-		WebhookSubscriber:  webhookSubscriber,
-		PRMergedSubscriber: prMergedSubscriber,
+		Service:             svc,
+		Scheduler:           scheduler,
+		Evaluator:           evaluator,
+		GitLabEvaluator:     gitlabEvaluator,
+		WebhookSubscriber:   webhookSubscriber,
+		PRMergedSubscriber:  prMergedSubscriber,
+		TaskEventSubscriber: taskEventSubscriber,
+		CompletionRunner:    completionRunner,
 	}, nil
 }
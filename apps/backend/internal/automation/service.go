@@ -122,11 +122,19 @@ type Service struct {
 	// Nil = not wired; the github_pr_merged trigger type then never fires.
 	taskOriginLookup TaskOriginLookup
 
+	// sourceTaskLookup loads the source task for the task-event triggers.
+	// Nil = not wired; those trigger types then never fire.
+	sourceTaskLookup SourceTaskLookup
+
 	// agentProfileLookup validates agent_profile_id on create/update. Nil =
 	// validation skipped, like workflowLocator above and unlike repoLookup —
 	// see validateAgentProfileID for why this one does not fail closed.
 	agentProfileLookup AgentProfileLookup
 
+	// taskPreviewer renders a dry run's task. Nil = dry runs report no
+	// title, prompt or repositories.
+	taskPreviewer TaskPreviewer
+
 	// authorizeWorkspace gates automation access by workspace ownership
 	// (opt-in auth). Nil = unscoped (internal schedulers/pollers, auth
 	// disabled). Set via SetWorkspaceAuthorizer.
@@ -145,6 +153,11 @@ type Service struct {
 	exportWorkflowStepLookup    ExportWorkflowStepLookup
 	exportRepositoryLookup      ExportRepositoryLookup
 
+	// exportWorkflowRevisionLookup stamps exportWorkflow.Revision. Unlike
+	// the lookups above it is optional: nil omits the revision, since the
+	// descriptor resolves by name without it.
+	exportWorkflowRevisionLookup ExportWorkflowRevisionLookup
+
 	// exportWorkspaceLookup answers AC-44 step 2's workspace-existence check.
 	// Nil is a construction error, not "skip enforcement": the endpoint
 	// cannot honour AC-35 without it, so collectExportAutomations fails
@@ -292,6 +305,17 @@ func (s *Service) TaskOriginLookup() TaskOriginLookup {
 	return s.taskOriginLookup
 }
 
+// SetSourceTaskLookup wires the task resolver used by the task-event trigger
+// subscriber. Must be called before Start, like SetTaskOriginLookup.
+func (s *Service) SetSourceTaskLookup(l SourceTaskLookup) {
+	s.sourceTaskLookup = l
+}
+
+// SourceTaskLookup returns the wired lookup (may be nil).
+func (s *Service) SourceTaskLookup() SourceTaskLookup {
+	return s.sourceTaskLookup
+}
+
 // SetRepositoryLookup wires the repository ownership validator for
 // repository_ids on create/update. This is a security control (prevents a
 // crafted request attaching another workspace's repository), so an unset
@@ -325,6 +349,12 @@ func (s *Service) SetExportWorkflowStepLookup(l ExportWorkflowStepLookup) {
 	s.exportWorkflowStepLookup = l
 }
 
+// SetExportWorkflowRevisionLookup wires the workflow revision resolver the
+// YAML export uses to populate exportWorkflow.Revision. Optional.
+func (s *Service) SetExportWorkflowRevisionLookup(l ExportWorkflowRevisionLookup) {
+	s.exportWorkflowRevisionLookup = l
+}
+
 // SetExportRepositoryLookup wires the repository resolver the YAML export
 // uses to resolve repository_ids to names.
 func (s *Service) SetExportRepositoryLookup(l ExportRepositoryLookup) {
@@ -413,6 +443,10 @@ func (s *Service) CreateAutomation(ctx context.Context, req *CreateAutomationReq
 	if err := s.validateAgentProfileID(ctx, req.AgentProfileID); err != nil {
 		return nil, err
 	}
+	if err := s.validateCompletionActions(ctx, req.WorkspaceID, req.OnCompletion); err != nil {
+		return nil, err
+	}
+	a.OnCompletion = req.OnCompletion
 	if err := s.store.CreateAutomation(ctx, a); err != nil {
 		return nil, fmt.Errorf("create automation: %w", err)
 	}
@@ -422,7 +456,7 @@ func (s *Service) CreateAutomation(ctx context.Context, req *CreateAutomationReq
 	// is accepted at creation and rejected on the first edit, and in between the
 	// automation simply never fires with nothing on screen to say why.
 	for _, ts := range req.Triggers {
-		if err := validateScheduledConfig(ts.Type, ts.Config); err != nil {
+		if err := validateTriggerConfig(ts.Type, ts.Config); err != nil {
 			return nil, err
 		}
 		t := &AutomationTrigger{
@@ -474,6 +508,11 @@ func (s *Service) UpdateAutomation(ctx context.Context, id string, req *UpdateAu
 			return nil, err
 		}
 	}
+	// An empty secret would make every delivery fail verification with no
+	// hint why; the generated secret is only ever replaced, never cleared.
+	if req.WebhookSecret != nil && strings.TrimSpace(*req.WebhookSecret) == "" {
+		return nil, fmt.Errorf("webhook secret must not be empty")
+	}
 	if err := s.authorizeUpdatedReferences(ctx, id, req); err != nil {
 		return nil, err
 	}
@@ -484,11 +523,12 @@ func (s *Service) UpdateAutomation(ctx context.Context, id string, req *UpdateAu
 }
 
 // authorizeUpdatedReferences checks the fields of req that name something the
-// automation's workspace must own — its repositories, workflow, and workflow
-// step. All three need the stored automation to learn that workspace, so it is
-// loaded once here rather than by each check.
+// automation's workspace must own — its repositories, workflow, workflow
+// step, and the automations its completion actions fire. All of them need the
+// stored automation to learn that workspace, so it is loaded once here rather
+// than by each check.
 func (s *Service) authorizeUpdatedReferences(ctx context.Context, id string, req *UpdateAutomationRequest) error {
-	if req.RepositoryIDs == nil && req.WorkflowID == nil && req.WorkflowStepID == nil {
+	if req.RepositoryIDs == nil && req.WorkflowID == nil && req.WorkflowStepID == nil && req.OnCompletion == nil {
 		return nil
 	}
 	existing, err := s.store.GetAutomation(ctx, id)
@@ -503,6 +543,9 @@ func (s *Service) authorizeUpdatedReferences(ctx context.Context, id string, req
 			return err
 		}
 	}
+	if err := s.validateCompletionActions(ctx, existing.WorkspaceID, req.OnCompletion); err != nil {
+		return err
+	}
 	if req.WorkflowID == nil && req.WorkflowStepID == nil {
 		return nil
 	}
@@ -575,6 +618,34 @@ func (s *Service) DisableAutomation(ctx context.Context, id string) error {
 
 // --- Trigger CRUD ---
 
+// validateTriggerConfig rejects a trigger config its trigger type could
+// never act on. Types without a check accept any config.
+func validateTriggerConfig(triggerType TriggerType, raw json.RawMessage) error {
+	if IsTaskEventTrigger(triggerType) {
+		return validateTaskEventConfig(triggerType, raw)
+	}
+	if triggerType == TriggerTypeWebhook {
+		return validateWebhookConfig(raw)
+	}
+	return validateScheduledConfig(triggerType, raw)
+}
+
+// validateTaskEventConfig rejects a step filter on a task-event trigger
+// other than task_step_entered, where it would silently match nothing.
+func validateTaskEventConfig(triggerType TriggerType, raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var cfg TaskEventTriggerConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("invalid %s trigger config: %w", triggerType, err)
+	}
+	if len(cfg.StepIDs) > 0 && triggerType != TriggerTypeTaskStepEntered {
+		return fmt.Errorf("step_ids is only supported by %s triggers", TriggerTypeTaskStepEntered)
+	}
+	return nil
+}
+
 // validateScheduledConfig rejects a cron expression the scheduler could never
 // run. Without it the editor's regex is the only gate, and it is both too
 // permissive (accepting "60 * * * *", "*/0 * * * *", reversed ranges like
@@ -609,7 +680,7 @@ func (s *Service) AddTrigger(ctx context.Context, req *AddTriggerRequest) (*Auto
 	if err := s.authorizeAutomation(ctx, req.AutomationID); err != nil {
 		return nil, err
 	}
-	if err := validateScheduledConfig(req.Type, req.Config); err != nil {
+	if err := validateTriggerConfig(req.Type, req.Config); err != nil {
 		return nil, err
 	}
 	t := &AutomationTrigger{
@@ -635,7 +706,7 @@ func (s *Service) UpdateTrigger(ctx context.Context, id string, req *UpdateTrigg
 			return err
 		}
 		if existing != nil {
-			if err := validateScheduledConfig(existing.Type, *req.Config); err != nil {
+			if err := validateTriggerConfig(existing.Type, *req.Config); err != nil {
 				return err
 			}
 		}
@@ -806,6 +877,13 @@ type FireResult struct {
 // FireTrigger publishes an AutomationTriggered event for the given trigger.
 // The orchestrator handles task creation in response.
 func (s *Service) FireTrigger(ctx context.Context, automationID, triggerID string, triggerType TriggerType, triggerData json.RawMessage, dedupKey string) (FireResult, error) {
+	return s.FireTriggerWithPayload(ctx, automationID, triggerID, triggerType, triggerData, nil, dedupKey)
+}
+
+// FireTriggerWithPayload is FireTrigger for a delivery whose raw payload
+// differs from its trigger data. The payload is recorded on the run so the
+// run can be replayed (see ReplayRun).
+func (s *Service) FireTriggerWithPayload(ctx context.Context, automationID, triggerID string, triggerType TriggerType, triggerData, rawPayload json.RawMessage, dedupKey string) (FireResult, error) {
 	// Admission decisions live in one place so every caller — scheduler,
 	// webhook, and the manual Run button — gets the same answer about whether a
 	// fire actually happened.
@@ -840,7 +918,7 @@ func (s *Service) FireTrigger(ctx context.Context, automationID, triggerID strin
 	// Enforce max_concurrent_runs: a run is "active" while still in
 	// task_created (succeeded/failed/skipped don't count). If at the cap,
 	// record a skipped run so the user can see the cap kicked in.
-	capReason, capErr := s.maybeSkipForConcurrencyCap(ctx, a, triggerID, triggerType, triggerData, dedupKey)
+	capReason, capErr := s.maybeSkipForConcurrencyCap(ctx, a, triggerID, triggerType, triggerData, rawPayload, dedupKey)
 	if capErr != nil {
 		return FireResult{}, capErr
 	}
@@ -871,6 +949,7 @@ func (s *Service) FireTrigger(ctx context.Context, automationID, triggerID strin
 		TriggerType:  triggerType,
 		TriggerData:  triggerData,
 		DedupKey:     dedupKey,
+		RawPayload:   rawPayload,
 	}
 
 	if updateErr := s.store.UpdateLastTriggered(ctx, automationID, now); updateErr != nil {
@@ -899,7 +978,7 @@ func (s *Service) RecordRun(ctx context.Context, run *AutomationRun) error {
 // human-readable skip reason, empty when the trigger may proceed. When
 // skipped, a "skipped" run row is persisted so the user can see the cap
 // kicked in.
-func (s *Service) maybeSkipForConcurrencyCap(ctx context.Context, a *Automation, triggerID string, triggerType TriggerType, triggerData json.RawMessage, dedupKey string) (string, error) {
+func (s *Service) maybeSkipForConcurrencyCap(ctx context.Context, a *Automation, triggerID string, triggerType TriggerType, triggerData, rawPayload json.RawMessage, dedupKey string) (string, error) {
 	if a.MaxConcurrentRuns <= 0 {
 		return "", nil
 	}
@@ -911,11 +990,11 @@ func (s *Service) maybeSkipForConcurrencyCap(ctx context.Context, a *Automation,
 	if active < a.MaxConcurrentRuns {
 		return "", nil
 	}
-	reason := fmt.Sprintf("max_concurrent_runs=%d reached", a.MaxConcurrentRuns)
-	// github_pr_merged cap-skip rows must NOT consume the dedup key so that a
-	// later event for the same PR can retry. Write an empty key for this type.
+	reason := concurrencyCapReason(a.MaxConcurrentRuns)
+	// Merged-PR cap-skip rows must NOT consume the dedup key so that a later
+	// event for the same PR can retry. Write an empty key for these types.
 	skipDedupKey := dedupKey
-	if triggerType == TriggerTypeGitHubPRMerged {
+	if triggerType == TriggerTypeGitHubPRMerged || triggerType == TriggerTypeTaskPRMerged {
 		skipDedupKey = ""
 	}
 	skipRun := &AutomationRun{
@@ -925,6 +1004,7 @@ func (s *Service) maybeSkipForConcurrencyCap(ctx context.Context, a *Automation,
 		Status:       RunStatusSkipped,
 		DedupKey:     skipDedupKey,
 		TriggerData:  triggerData,
+		RawPayload:   rawPayload,
 		ErrorMessage: reason,
 	}
 	if recErr := s.createRunLocked(ctx, skipRun); recErr != nil {
@@ -937,17 +1017,50 @@ func (s *Service) maybeSkipForConcurrencyCap(ctx context.Context, a *Automation,
 	return reason, nil
 }
 
+func concurrencyCapReason(maxRuns int) string {
+	return fmt.Sprintf("max_concurrent_runs=%d reached", maxRuns)
+}
+
 // MarkRunFailedByTaskID transitions a still-pending run (task_created) into
 // the failed state. Used when something downstream of task creation aborts
 // the run, e.g. a permission prompt an automation run can't answer.
 func (s *Service) MarkRunFailedByTaskID(ctx context.Context, taskID, errMsg string) error {
-	return s.store.MarkRunFailedByTaskID(ctx, taskID, errMsg)
+	return s.finalizeRun(ctx, taskID, RunStatusFailed, errMsg)
 }
 
 // MarkRunSucceededByTaskID transitions a still-pending run (task_created)
 // into the succeeded state when the launched agent finishes cleanly.
 func (s *Service) MarkRunSucceededByTaskID(ctx context.Context, taskID string) error {
-	return s.store.MarkRunSucceededByTaskID(ctx, taskID)
+	return s.finalizeRun(ctx, taskID, RunStatusSucceeded, "")
+}
+
+// finalizeRun flips the task's pending run and, only when this call is the
+// one that flipped it, publishes AutomationRunCompleted so completion
+// actions run exactly once per run however often the task terminates.
+func (s *Service) finalizeRun(ctx context.Context, taskID string, status RunStatus, errMsg string) error {
+	runID, err := s.store.FinalizeRunByTaskID(ctx, taskID, status, errMsg)
+	if err != nil || runID == "" {
+		return err
+	}
+	run, err := s.store.GetRun(ctx, runID)
+	if err != nil || run == nil {
+		return err
+	}
+	evt := &AutomationRunCompletedEvent{
+		RunID:        run.ID,
+		AutomationID: run.AutomationID,
+		TaskID:       taskID,
+		Status:       status,
+		ErrorMessage: errMsg,
+	}
+	event := bus.NewEvent(events.AutomationRunCompleted, "automation_service", evt)
+	if err := s.eventBus.Publish(ctx, events.AutomationRunCompleted, event); err != nil {
+		// The run's status is already recorded; a lost event costs its
+		// completion actions, not the run.
+		s.logger.Warn("failed to publish automation run completed",
+			zap.String("run_id", run.ID), zap.Error(err))
+	}
+	return nil
 }
 
 // PrunableRunTaskIDs names the tasks whose workspaces the caller may reclaim
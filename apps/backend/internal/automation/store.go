@@ -56,6 +56,7 @@ const createTablesSQL = `
 		enabled BOOLEAN DEFAULT 1,
 		max_concurrent_runs INTEGER DEFAULT 1,
 		webhook_secret TEXT DEFAULT '',
+		on_completion TEXT NOT NULL DEFAULT '[]',
 		last_triggered_at DATETIME,
 		created_at DATETIME NOT NULL,
 		updated_at DATETIME NOT NULL
@@ -84,7 +85,8 @@ const createTablesSQL = `
 		dedup_key TEXT DEFAULT '',
 		trigger_data TEXT NOT NULL DEFAULT '{}',
 		error_message TEXT DEFAULT '',
-		created_at DATETIME NOT NULL
+		created_at DATETIME NOT NULL,
+		raw_payload TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_automation_runs_automation ON automation_runs(automation_id);
@@ -131,6 +133,8 @@ const (
 	migrateTaskTitleSQL     = `ALTER TABLE automations ADD COLUMN task_title_template TEXT DEFAULT ''`
 	migrateExecutionModeSQL = `ALTER TABLE automations ADD COLUMN execution_mode TEXT NOT NULL DEFAULT 'task'`
 	migrateRepositoryIDSQL  = `ALTER TABLE automations ADD COLUMN repository_id TEXT NOT NULL DEFAULT ''`
+	migrateOnCompletionSQL  = `ALTER TABLE automations ADD COLUMN on_completion TEXT NOT NULL DEFAULT '[]'`
+	migrateRunRawPayloadSQL = `ALTER TABLE automation_runs ADD COLUMN raw_payload TEXT NOT NULL DEFAULT ''`
 )
 
 // automationColumns is the explicit column list for every query that scans a
@@ -150,7 +154,7 @@ const (
 // path has one. See docs/specs/office/automations-settings.md § Migration.
 const automationColumns = `id, workspace_id, name, description, workflow_id, workflow_step_id,
 	agent_profile_id, executor_profile_id, prompt, task_title_template,
-	enabled, max_concurrent_runs, webhook_secret, last_triggered_at, created_at, updated_at,
+	enabled, max_concurrent_runs, webhook_secret, on_completion, last_triggered_at, created_at, updated_at,
 	execution_mode = 'task' AS legacy_board_card`
 
 func (s *Store) initSchema() error {
@@ -160,6 +164,8 @@ func (s *Store) initSchema() error {
 	s.db.Exec(migrateTaskTitleSQL)     //nolint:errcheck // duplicate-column on existing DBs
 	s.db.Exec(migrateExecutionModeSQL) //nolint:errcheck // duplicate-column on existing DBs
 	s.db.Exec(migrateRepositoryIDSQL)  //nolint:errcheck // duplicate-column on existing DBs
+	s.db.Exec(migrateOnCompletionSQL)  //nolint:errcheck // duplicate-column on existing DBs
+	s.db.Exec(migrateRunRawPayloadSQL) //nolint:errcheck // duplicate-column on existing DBs
 	return s.backfillLegacyRepositoryIDs()
 }
 
@@ -222,6 +228,9 @@ func (s *Store) CreateAutomation(ctx context.Context, a *Automation) error {
 	now := time.Now().UTC()
 	a.CreatedAt = now
 	a.UpdatedAt = now
+	if err := encodeOnCompletion(a); err != nil {
+		return err
+	}
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
@@ -241,13 +250,13 @@ func (s *Store) CreateAutomation(ctx context.Context, a *Automation) error {
 			agent_profile_id, executor_profile_id,
 			prompt, task_title_template, execution_mode,
 			enabled, max_concurrent_runs,
-			webhook_secret, last_triggered_at, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '', ?, ?, ?, ?, ?, ?)`,
+			webhook_secret, on_completion, last_triggered_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '', ?, ?, ?, ?, ?, ?, ?)`,
 		a.ID, a.WorkspaceID, a.Name, a.Description, a.WorkflowID, a.WorkflowStepID,
 		a.AgentProfileID, a.ExecutorProfileID,
 		a.Prompt, a.TaskTitleTemplate,
 		a.Enabled, a.MaxConcurrentRuns,
-		a.WebhookSecret, a.LastTriggeredAt, a.CreatedAt, a.UpdatedAt)
+		a.WebhookSecret, a.OnCompletionJSON, a.LastTriggeredAt, a.CreatedAt, a.UpdatedAt)
 	if err != nil {
 		return err
 	}
@@ -295,9 +304,34 @@ func (s *Store) GetAutomation(ctx context.Context, id string) (*Automation, erro
 		return nil, fmt.Errorf("hydrate repository_ids: %w", err)
 	}
 	a.RepositoryIDs = repoIDs[id]
+	decodeOnCompletion(&a)
 	return &a, nil
 }
 
+// encodeOnCompletion serialises a.OnCompletion into its column, writing an
+// empty list rather than null so the column's shape never varies.
+func encodeOnCompletion(a *Automation) error {
+	actions := a.OnCompletion
+	if actions == nil {
+		actions = []CompletionAction{}
+	}
+	raw, err := json.Marshal(actions)
+	if err != nil {
+		return fmt.Errorf("encode on_completion: %w", err)
+	}
+	a.OnCompletionJSON = string(raw)
+	return nil
+}
+
+// decodeOnCompletion hydrates a.OnCompletion from its column. A value that
+// fails to decode reads as no actions rather than failing the whole load.
+func decodeOnCompletion(a *Automation) {
+	a.OnCompletion = []CompletionAction{}
+	if a.OnCompletionJSON != "" {
+		_ = json.Unmarshal([]byte(a.OnCompletionJSON), &a.OnCompletion)
+	}
+}
+
 // listRepositoryIDsForAutomations batch-loads ordered repository_ids for
 // several automations at once, mirroring listTriggersForAutomations.
 func (s *Store) listRepositoryIDsForAutomations(ctx context.Context, automationIDs []string) (map[string][]string, error) {
@@ -430,6 +464,7 @@ func (s *Store) hydrateAutomations(ctx context.Context, automations []*Automatio
 	for _, a := range automations {
 		a.Triggers = triggersByAutomation[a.ID]
 		a.RepositoryIDs = repoIDsByAutomation[a.ID]
+		decodeOnCompletion(a)
 	}
 	return nil
 }
@@ -448,6 +483,9 @@ func (s *Store) UpdateAutomation(ctx context.Context, id string, req *UpdateAuto
 	}
 	applyAutomationUpdate(a, req)
 	a.UpdatedAt = time.Now().UTC()
+	if err := encodeOnCompletion(a); err != nil {
+		return err
+	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -459,12 +497,12 @@ func (s *Store) UpdateAutomation(ctx context.Context, id string, req *UpdateAuto
 		UPDATE automations SET name = ?, description = ?, workflow_id = ?, workflow_step_id = ?,
 			agent_profile_id = ?, executor_profile_id = ?,
 			prompt = ?, task_title_template = ?,
-			enabled = ?, max_concurrent_runs = ?, updated_at = ?
+			enabled = ?, max_concurrent_runs = ?, webhook_secret = ?, on_completion = ?, updated_at = ?
 		WHERE id = ?`,
 		a.Name, a.Description, a.WorkflowID, a.WorkflowStepID,
 		a.AgentProfileID, a.ExecutorProfileID,
 		a.Prompt, a.TaskTitleTemplate,
-		a.Enabled, a.MaxConcurrentRuns, a.UpdatedAt, id)
+		a.Enabled, a.MaxConcurrentRuns, a.WebhookSecret, a.OnCompletionJSON, a.UpdatedAt, id)
 	if err != nil {
 		return err
 	}
@@ -510,6 +548,12 @@ func applyAutomationUpdate(a *Automation, req *UpdateAutomationRequest) {
 	if req.TaskTitleTemplate != nil {
 		a.TaskTitleTemplate = *req.TaskTitleTemplate
 	}
+	if req.WebhookSecret != nil {
+		a.WebhookSecret = *req.WebhookSecret
+	}
+	if req.OnCompletion != nil {
+		a.OnCompletion = req.OnCompletion
+	}
 }
 
 // DeleteAutomation removes an automation and its triggers/runs (CASCADE).
@@ -663,12 +707,13 @@ func (s *Store) CreateRun(ctx context.Context, r *AutomationRun) error {
 	}
 	r.CreatedAt = time.Now().UTC()
 	r.TriggerDataJSON = string(r.TriggerData)
+	r.RawPayloadJSON = string(r.RawPayload)
 	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO automation_runs (id, automation_id, trigger_id, trigger_type, task_id, status,
-			dedup_key, trigger_data, error_message, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			dedup_key, trigger_data, error_message, created_at, raw_payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		r.ID, r.AutomationID, r.TriggerID, r.TriggerType, r.TaskID, r.Status,
-		r.DedupKey, r.TriggerDataJSON, r.ErrorMessage, r.CreatedAt)
+		r.DedupKey, r.TriggerDataJSON, r.ErrorMessage, r.CreatedAt, r.RawPayloadJSON)
 	return err
 }
 
@@ -677,30 +722,83 @@ func (s *Store) CreateRun(ctx context.Context, r *AutomationRun) error {
 // prompt an automation run can't answer) makes the run effectively
 // dead. No-op if no matching run is found.
 func (s *Store) MarkRunFailedByTaskID(ctx context.Context, taskID, errMsg string) error {
-	return s.updateRunTerminalStatus(ctx, taskID, RunStatusFailed, errMsg)
+	_, err := s.FinalizeRunByTaskID(ctx, taskID, RunStatusFailed, errMsg)
+	return err
 }
 
 // MarkRunSucceededByTaskID flips the most recent task_created run for a task
 // into the succeeded state. Used when an automation-launched agent completes
 // without error.
 func (s *Store) MarkRunSucceededByTaskID(ctx context.Context, taskID string) error {
-	return s.updateRunTerminalStatus(ctx, taskID, RunStatusSucceeded, "")
+	_, err := s.FinalizeRunByTaskID(ctx, taskID, RunStatusSucceeded, "")
+	return err
 }
 
-// updateRunTerminalStatus is the shared implementation behind MarkRun{Failed,Succeeded}ByTaskID.
-func (s *Store) updateRunTerminalStatus(ctx context.Context, taskID string, status RunStatus, errMsg string) error {
+// FinalizeRunByTaskID is the shared implementation behind
+// MarkRun{Failed,Succeeded}ByTaskID. It returns the id of the run it
+// flipped, or "" when the task had no run still in task_created — so a
+// caller can tell the one transition that happened from the repeats that
+// didn't.
+func (s *Store) FinalizeRunByTaskID(ctx context.Context, taskID string, status RunStatus, errMsg string) (string, error) {
 	if taskID == "" {
-		return nil
+		return "", nil
 	}
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE automation_runs SET status = ?, error_message = ?
-		WHERE id = (
-			SELECT id FROM automation_runs
-			WHERE task_id = ? AND status = ?
-			ORDER BY created_at DESC LIMIT 1
-		)`,
-		string(status), errMsg, taskID, string(RunStatusTaskCreated))
-	return err
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var runID string
+	err = tx.GetContext(ctx, &runID, `
+		SELECT id FROM automation_runs
+		WHERE task_id = ? AND status = ?
+		ORDER BY created_at DESC LIMIT 1`,
+		taskID, string(RunStatusTaskCreated))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE automation_runs SET status = ?, error_message = ? WHERE id = ?`,
+		string(status), errMsg, runID); err != nil {
+		return "", err
+	}
+	return runID, tx.Commit()
+}
+
+// maxRunOutcomeSummary caps the agent message a completion action quotes,
+// comfortably inside what GitHub and GitLab accept in a comment.
+const maxRunOutcomeSummary = 8000
+
+// GetRunOutcome returns the last agent message on a run's task and its
+// primary session id, for the completion actions that report on it. Both
+// are empty when the task tables aren't present (isolated automation-only
+// tests) or the run never got that far.
+func (s *Store) GetRunOutcome(ctx context.Context, taskID string) (summary, sessionID string, err error) {
+	var row struct {
+		Summary   string `db:"summary"`
+		SessionID string `db:"session_id"`
+	}
+	err = s.ro.GetContext(ctx, &row, `
+		SELECT
+			COALESCE((
+				SELECT substr(m.content, 1, ?) FROM task_session_messages m
+				WHERE m.task_id = ? AND m.author_type = 'agent' AND m.type = 'message'
+				ORDER BY m.created_at DESC, m.id DESC LIMIT 1
+			), '') AS summary,
+			COALESCE((
+				SELECT ts.id FROM task_sessions ts
+				WHERE ts.task_id = ? AND ts.is_primary = 1
+				LIMIT 1
+			), '') AS session_id`,
+		maxRunOutcomeSummary, taskID, taskID)
+	if db.IsMissingTableError(err) {
+		return "", "", nil
+	}
+	return row.Summary, row.SessionID, err
 }
 
 // ListRuns returns recent runs for an automation. A task_created run whose
@@ -1101,6 +1199,9 @@ func (s *Store) GetRun(ctx context.Context, id string) (*AutomationRun, error) {
 		return nil, err
 	}
 	r.TriggerData = json.RawMessage(r.TriggerDataJSON)
+	if r.RawPayloadJSON != "" {
+		r.RawPayload = json.RawMessage(r.RawPayloadJSON)
+	}
 	return &r, nil
 }
 
@@ -0,0 +1,303 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/events"
+	"github.com/kandev/kandev/internal/events/bus"
+)
+
+// Task states the state-change trigger types react to. Mirrors
+// v1.TaskStateCompleted / v1.TaskStateFailed as they appear on the bus.
+const (
+	taskStateCompleted = "COMPLETED"
+	taskStateFailed    = "FAILED"
+)
+
+// TaskEventSubscriber fires the task-event triggers from Kandev's own event
+// bus: a task entering a step (manual task.moved or engine-driven
+// task.step_entered), a task completing or failing, a task's pull request
+// merging, and a review publishing blocker findings. Like
+// GitHubPRMergedSubscriber it resolves the source task once per event,
+// never fires for tasks created by automations (so a follow-up cannot
+// trigger itself), and fires each automation at most once per event.
+type TaskEventSubscriber struct {
+	svc      *Service
+	eventBus bus.EventBus
+	logger   *logger.Logger
+
+	subs    []bus.Subscription
+	started bool
+}
+
+// NewTaskEventSubscriber creates a new subscriber.
+func NewTaskEventSubscriber(svc *Service, eventBus bus.EventBus, log *logger.Logger) *TaskEventSubscriber {
+	return &TaskEventSubscriber{svc: svc, eventBus: eventBus, logger: log}
+}
+
+// Start subscribes to the task lifecycle events. Idempotent.
+func (s *TaskEventSubscriber) Start(_ context.Context) {
+	if s.started {
+		return
+	}
+	s.started = true
+	if s.eventBus == nil {
+		return
+	}
+	handlers := []struct {
+		subject string
+		handle  bus.EventHandler
+	}{
+		{events.TaskMoved, s.handleTaskMoved},
+		{events.TaskStepEntered, s.handleTaskStepEntered},
+		{events.TaskStateChanged, s.handleTaskStateChanged},
+		{events.GitHubTaskPRUpdated, s.handleTaskPRUpdated},
+		{events.TaskReviewFindingsPublished, s.handleReviewFindings},
+	}
+	for _, h := range handlers {
+		sub, err := s.eventBus.Subscribe(h.subject, h.handle)
+		if err != nil {
+			s.logger.Error("failed to subscribe for task-event triggers",
+				zap.String("subject", h.subject), zap.Error(err))
+			continue
+		}
+		s.subs = append(s.subs, sub)
+	}
+	if s.svc.SourceTaskLookup() == nil {
+		s.logger.Warn("task-event triggers will not fire until a source-task lookup is wired")
+	} else {
+		s.logger.Info("task-event trigger subscriptions active", zap.Int("subjects", len(s.subs)))
+	}
+}
+
+// Stop unsubscribes from the bus. Idempotent.
+func (s *TaskEventSubscriber) Stop() {
+	if !s.started {
+		return
+	}
+	for _, sub := range s.subs {
+		_ = sub.Unsubscribe()
+	}
+	s.subs = nil
+	s.started = false
+}
+
+// decodeEventData converts a bus payload into a typed struct. The in-memory
+// bus hands subscribers the publisher's map, NATS a JSON-decoded one; a
+// JSON round-trip reads both the same way.
+func decodeEventData(data interface{}, out interface{}) bool {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(encoded, out) == nil
+}
+
+type taskStepChange struct {
+	TaskID          string `json:"task_id"`
+	FromStepID      string `json:"from_step_id"`
+	ToStepID        string `json:"to_step_id"`
+	QueuedForStepID string `json:"queued_for_step_id"`
+	Trigger         string `json:"trigger"`
+}
+
+func (s *TaskEventSubscriber) handleTaskMoved(ctx context.Context, event *bus.Event) error {
+	var move taskStepChange
+	if !decodeEventData(event.Data, &move) {
+		return nil
+	}
+	// A move still waiting for WIP capacity has not entered its step yet;
+	// the promotion publishes its own task.moved once it does.
+	if move.QueuedForStepID != "" {
+		return nil
+	}
+	s.stepEntered(ctx, event, move)
+	return nil
+}
+
+func (s *TaskEventSubscriber) handleTaskStepEntered(ctx context.Context, event *bus.Event) error {
+	var move taskStepChange
+	if !decodeEventData(event.Data, &move) {
+		return nil
+	}
+	s.stepEntered(ctx, event, move)
+	return nil
+}
+
+func (s *TaskEventSubscriber) stepEntered(ctx context.Context, event *bus.Event, move taskStepChange) {
+	if move.TaskID == "" || move.ToStepID == "" || move.ToStepID == move.FromStepID {
+		return
+	}
+	s.fire(ctx, taskEvent{
+		triggerType: TriggerTypeTaskStepEntered,
+		taskID:      move.TaskID,
+		stepID:      move.ToStepID,
+		dedupKey:    fmt.Sprintf("task_step_entered:%s:%s", move.TaskID, event.ID),
+		data: map[string]interface{}{
+			"from_step_id":       move.FromStepID,
+			"to_step_id":         move.ToStepID,
+			"transition_trigger": move.Trigger,
+		},
+	})
+}
+
+func (s *TaskEventSubscriber) handleTaskStateChanged(ctx context.Context, event *bus.Event) error {
+	var change struct {
+		TaskID   string `json:"task_id"`
+		OldState string `json:"old_state"`
+		NewState string `json:"new_state"`
+	}
+	if !decodeEventData(event.Data, &change) || change.TaskID == "" || change.NewState == change.OldState {
+		return nil
+	}
+	var triggerType TriggerType
+	switch change.NewState {
+	case taskStateCompleted:
+		triggerType = TriggerTypeTaskCompleted
+	case taskStateFailed:
+		triggerType = TriggerTypeTaskFailed
+	default:
+		return nil
+	}
+	s.fire(ctx, taskEvent{
+		triggerType: triggerType,
+		taskID:      change.TaskID,
+		dedupKey:    fmt.Sprintf("%s:%s:%s", triggerType, change.TaskID, event.ID),
+		data:        map[string]interface{}{"old_state": change.OldState, "new_state": change.NewState},
+	})
+	return nil
+}
+
+func (s *TaskEventSubscriber) handleTaskPRUpdated(ctx context.Context, event *bus.Event) error {
+	pr, ok := normalizeTaskPR(event.Data)
+	if !ok || pr.TaskID == "" || pr.Owner == "" || pr.Repo == "" || pr.PRNumber <= 0 {
+		return nil
+	}
+	if !strings.EqualFold(strings.TrimSpace(pr.State), "merged") {
+		return nil
+	}
+	// The poller republishes a merged PR on every later change, so the key
+	// is the PR's identity, as for github_pr_merged.
+	s.fire(ctx, taskEvent{
+		triggerType: TriggerTypeTaskPRMerged,
+		taskID:      pr.TaskID,
+		dedupKey: fmt.Sprintf("task_pr_merged:%s:%s/%s#%d",
+			pr.TaskID, strings.ToLower(pr.Owner), strings.ToLower(pr.Repo), pr.PRNumber),
+		data: map[string]interface{}{
+			automationRepoKey:       fmt.Sprintf("%s/%s", pr.Owner, pr.Repo),
+			"pr_number":             pr.PRNumber,
+			"pr_url":                pr.PRURL,
+			automationBaseBranchKey: pr.BaseBranch,
+		},
+	})
+	return nil
+}
+
+func (s *TaskEventSubscriber) handleReviewFindings(ctx context.Context, event *bus.Event) error {
+	var published struct {
+		TaskID   string `json:"task_id"`
+		RunID    string `json:"run_id"`
+		Findings []struct {
+			Severity string `json:"severity"`
+			Status   string `json:"status"`
+		} `json:"findings"`
+	}
+	if !decodeEventData(event.Data, &published) || published.TaskID == "" {
+		return nil
+	}
+	blocking := 0
+	for _, f := range published.Findings {
+		if f.Severity == "blocker" && f.Status == "open" {
+			blocking++
+		}
+	}
+	if blocking == 0 {
+		return nil
+	}
+	s.fire(ctx, taskEvent{
+		triggerType: TriggerTypeTaskReviewBlocking,
+		taskID:      published.TaskID,
+		dedupKey:    fmt.Sprintf("task_review_blocking:%s:%s", published.TaskID, published.RunID),
+		data:        map[string]interface{}{"run_id": published.RunID, "blocking_findings": blocking},
+	})
+	return nil
+}
+
+// taskEvent is one bus event resolved to a task-event trigger type. stepID
+// is the step a task_step_entered event entered; data holds the event's own
+// fields, next to which fire adds the source task.
+type taskEvent struct {
+	triggerType TriggerType
+	taskID      string
+	stepID      string
+	dedupKey    string
+	data        map[string]interface{}
+}
+
+func (s *TaskEventSubscriber) fire(ctx context.Context, ev taskEvent) {
+	lookup := s.svc.SourceTaskLookup()
+	if lookup == nil {
+		return
+	}
+	task, ok := lookup.SourceTask(ctx, ev.taskID)
+	// Loop guard: an automation's own task must never fire another run.
+	if !ok || task.IsAutomationRun {
+		return
+	}
+	triggers, err := s.svc.Store().ListEnabledTriggersByType(ctx, ev.triggerType)
+	if err != nil {
+		s.logger.Error("failed to list task-event triggers",
+			zap.String("trigger_type", string(ev.triggerType)), zap.Error(err))
+		return
+	}
+	if len(triggers) == 0 {
+		return
+	}
+	ev.data["task"] = task
+	data, err := json.Marshal(ev.data)
+	if err != nil {
+		return
+	}
+	fired := make(map[string]struct{})
+	for i := range triggers {
+		t := &triggers[i]
+		if _, done := fired[t.AutomationID]; done || !s.matches(ctx, t, task, ev.stepID) {
+			continue
+		}
+		fired[t.AutomationID] = struct{}{}
+		if _, err := s.svc.FireTrigger(ctx, t.AutomationID, t.ID, ev.triggerType, data, ev.dedupKey); err != nil {
+			s.logger.Error("failed to fire task-event trigger",
+				zap.String("trigger_id", t.ID),
+				zap.String("trigger_type", string(ev.triggerType)),
+				zap.Error(err))
+		}
+	}
+}
+
+// matches applies the trigger's automation workspace and its config filters
+// to the source task.
+func (s *TaskEventSubscriber) matches(ctx context.Context, t *AutomationTrigger, task SourceTask, stepID string) bool {
+	var cfg TaskEventTriggerConfig
+	if len(t.Config) > 0 {
+		if err := json.Unmarshal(t.Config, &cfg); err != nil {
+			s.logger.Debug("invalid task-event trigger config",
+				zap.String("trigger_id", t.ID), zap.Error(err))
+			return false
+		}
+	}
+	a, err := s.svc.GetAutomation(ctx, t.AutomationID)
+	if err != nil || a == nil || a.WorkspaceID != task.WorkspaceID {
+		return false
+	}
+	if len(cfg.WorkflowIDs) > 0 && !slices.Contains(cfg.WorkflowIDs, task.WorkflowID) {
+		return false
+	}
+	return len(cfg.StepIDs) == 0 || slices.Contains(cfg.StepIDs, stepID)
+}
@@ -0,0 +1,210 @@
+package automation
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/events"
+	"github.com/kandev/kandev/internal/events/bus"
+)
+
+// fakeSourceTaskLookup is a map-backed SourceTaskLookup.
+type fakeSourceTaskLookup map[string]SourceTask
+
+func (f fakeSourceTaskLookup) SourceTask(_ context.Context, taskID string) (SourceTask, bool) {
+	task, ok := f[taskID]
+	return task, ok
+}
+
+func sourceTasks() fakeSourceTaskLookup {
+	return fakeSourceTaskLookup{
+		"t1": {
+			ID: "t1", Identifier: "KAN-1", WorkspaceID: "ws-1", WorkflowID: "wf-a", WorkflowStepID: "step-review",
+			Title: "Add rate limiting", Description: "Limit the API", State: "COMPLETED", Priority: "high",
+		},
+		"t-other-ws":   {ID: "t-other-ws", WorkspaceID: "ws-2", WorkflowID: "wf-a"},
+		"t-automation": {ID: "t-automation", WorkspaceID: "ws-1", WorkflowID: "wf-a", IsAutomationRun: true},
+	}
+}
+
+func newTaskEventSubscriber(t *testing.T, svc *Service, lookup SourceTaskLookup) {
+	t.Helper()
+	log, err := logger.NewFromZap(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup != nil {
+		svc.SetSourceTaskLookup(lookup)
+	}
+	sub := NewTaskEventSubscriber(svc, svc.eventBus, log)
+	sub.Start(context.Background())
+	t.Cleanup(sub.Stop)
+}
+
+func publishTaskEvent(t *testing.T, svc *Service, subject string, data map[string]interface{}) {
+	t.Helper()
+	if err := svc.eventBus.Publish(context.Background(), subject, bus.NewEvent(subject, "test", data)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func expectNoFire(t *testing.T, fired chan *AutomationTriggeredEvent) {
+	t.Helper()
+	select {
+	case evt := <-fired:
+		t.Fatalf("expected no fire, got %+v", evt)
+	default:
+	}
+}
+
+func TestTaskEventSubscriber_CompletedExposesSourceTask(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	newTaskEventSubscriber(t, svc, sourceTasks())
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	trig := addTestTrigger(t, svc, a.ID, TriggerTypeTaskCompleted, TaskEventTriggerConfig{WorkflowIDs: []string{"wf-a"}})
+
+	publishTaskEvent(t, svc, events.TaskStateChanged, map[string]interface{}{
+		"task_id": "t1", "old_state": "IN_PROGRESS", "new_state": "COMPLETED",
+	})
+
+	select {
+	case evt := <-fired:
+		if evt.AutomationID != a.ID || evt.TriggerID != trig.ID || evt.TriggerType != TriggerTypeTaskCompleted {
+			t.Fatalf("unexpected event: %+v", evt)
+		}
+		got := InterpolatePrompt("{{task.identifier}} {{task.title}} ({{task.state}}, {{task.priority}}) in {{task.step_id}}",
+			evt.TriggerType, evt.TriggerData)
+		if want := "KAN-1 Add rate limiting (COMPLETED, high) in step-review"; got != want {
+			t.Fatalf("prompt = %q, want %q", got, want)
+		}
+	default:
+		t.Fatal("expected AutomationTriggered event")
+	}
+}
+
+func TestTaskEventSubscriber_StepEnteredFiltersBySteps(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	newTaskEventSubscriber(t, svc, sourceTasks())
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeTaskStepEntered, TaskEventTriggerConfig{StepIDs: []string{"step-review"}})
+
+	publishTaskEvent(t, svc, events.TaskStepEntered, map[string]interface{}{
+		"task_id": "t1", "from_step_id": "step-review", "to_step_id": "step-done",
+	})
+	expectNoFire(t, fired)
+
+	publishTaskEvent(t, svc, events.TaskMoved, map[string]interface{}{
+		"task_id": "t1", "from_step_id": "step-work", "to_step_id": "step-review", "queued_for_step_id": "",
+	})
+	select {
+	case evt := <-fired:
+		var data map[string]interface{}
+		if err := json.Unmarshal(evt.TriggerData, &data); err != nil {
+			t.Fatal(err)
+		}
+		if data["from_step_id"] != "step-work" || data["to_step_id"] != "step-review" {
+			t.Fatalf("unexpected trigger data: %v", data)
+		}
+	default:
+		t.Fatal("a manual move into the step should fire")
+	}
+
+	publishTaskEvent(t, svc, events.TaskStepEntered, map[string]interface{}{
+		"task_id": "t1", "from_step_id": "step-work", "to_step_id": "step-review", "trigger": "on_turn_complete",
+	})
+	select {
+	case <-fired:
+	default:
+		t.Fatal("an engine transition into the step should fire")
+	}
+}
+
+func TestTaskEventSubscriber_Gates(t *testing.T) {
+	tests := []struct {
+		name        string
+		triggerType TriggerType
+		cfg         TaskEventTriggerConfig
+		subject     string
+		data        map[string]interface{}
+	}{
+		{"automation run task", TriggerTypeTaskCompleted, TaskEventTriggerConfig{}, events.TaskStateChanged,
+			map[string]interface{}{"task_id": "t-automation", "old_state": "IN_PROGRESS", "new_state": "COMPLETED"}},
+		{"other workspace", TriggerTypeTaskCompleted, TaskEventTriggerConfig{}, events.TaskStateChanged,
+			map[string]interface{}{"task_id": "t-other-ws", "old_state": "IN_PROGRESS", "new_state": "COMPLETED"}},
+		{"unknown task", TriggerTypeTaskFailed, TaskEventTriggerConfig{}, events.TaskStateChanged,
+			map[string]interface{}{"task_id": "missing", "old_state": "IN_PROGRESS", "new_state": "FAILED"}},
+		{"other workflow", TriggerTypeTaskCompleted, TaskEventTriggerConfig{WorkflowIDs: []string{"wf-b"}}, events.TaskStateChanged,
+			map[string]interface{}{"task_id": "t1", "old_state": "IN_PROGRESS", "new_state": "COMPLETED"}},
+		{"other state", TriggerTypeTaskFailed, TaskEventTriggerConfig{}, events.TaskStateChanged,
+			map[string]interface{}{"task_id": "t1", "old_state": "IN_PROGRESS", "new_state": "COMPLETED"}},
+		{"queued move", TriggerTypeTaskStepEntered, TaskEventTriggerConfig{}, events.TaskMoved,
+			map[string]interface{}{"task_id": "t1", "from_step_id": "a", "to_step_id": "b", "queued_for_step_id": "b"}},
+		{"review without blockers", TriggerTypeTaskReviewBlocking, TaskEventTriggerConfig{}, events.TaskReviewFindingsPublished,
+			map[string]interface{}{"task_id": "t1", "run_id": "r1", "findings": []map[string]interface{}{
+				{"severity": "blocker", "status": "dismissed"}, {"severity": "major", "status": "open"},
+			}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newPRMergedTestService(t)
+			newTaskEventSubscriber(t, svc, sourceTasks())
+			fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+			a := createTestAutomation(t, svc, "ws-1")
+			addTestTrigger(t, svc, a.ID, tt.triggerType, tt.cfg)
+
+			publishTaskEvent(t, svc, tt.subject, tt.data)
+			expectNoFire(t, fired)
+		})
+	}
+}
+
+// TestTaskEventSubscriber_ReviewBlockingFiresOncePerAutomation checks that two
+// matching triggers on one automation produce a single run for the event.
+func TestTaskEventSubscriber_ReviewBlockingFiresOncePerAutomation(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	newTaskEventSubscriber(t, svc, sourceTasks())
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeTaskReviewBlocking, TaskEventTriggerConfig{})
+	addTestTrigger(t, svc, a.ID, TriggerTypeTaskReviewBlocking, TaskEventTriggerConfig{WorkflowIDs: []string{"wf-a"}})
+
+	publishTaskEvent(t, svc, events.TaskReviewFindingsPublished, map[string]interface{}{"task_id": "t1", "run_id": "r1", "findings": []map[string]interface{}{
+		{"severity": "blocker", "status": "open"}, {"severity": "blocker", "status": "open"},
+	}})
+
+	select {
+	case evt := <-fired:
+		if evt.DedupKey != "task_review_blocking:t1:r1" {
+			t.Fatalf("dedup key = %q", evt.DedupKey)
+		}
+		if got := InterpolatePrompt("{{data.blocking_findings}}", evt.TriggerType, evt.TriggerData); got != "2" {
+			t.Fatalf("blocking_findings = %q, want 2", got)
+		}
+	default:
+		t.Fatal("expected AutomationTriggered event")
+	}
+	expectNoFire(t, fired)
+}
+
+func TestValidateTaskEventConfig(t *testing.T) {
+	raw := json.RawMessage(`{"step_ids":["s1"]}`)
+	if err := validateTriggerConfig(TriggerTypeTaskStepEntered, raw); err != nil {
+		t.Fatalf("step_ids on task_step_entered: %v", err)
+	}
+	if err := validateTriggerConfig(TriggerTypeTaskCompleted, raw); err == nil {
+		t.Fatal("expected step_ids on task_completed to be rejected")
+	}
+	if err := validateTriggerConfig(TriggerTypeTaskFailed, json.RawMessage(`{"workflow_ids":"wf"}`)); err == nil {
+		t.Fatal("expected a malformed config to be rejected")
+	}
+}
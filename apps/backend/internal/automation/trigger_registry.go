@@ -15,7 +15,7 @@ type TriggerTypeInfo struct {
 	Type             TriggerType       `json:"type"`
 	Label            string            `json:"label"`
 	Description      string            `json:"description"`
-	Category         string            `json:"category"` // "schedule", "github", "webhook"
+	Category         string            `json:"category"` // "schedule", "github", "gitlab", "webhook", "task"
 	Enabled          bool              `json:"enabled"`
 	Placeholders     []PlaceholderInfo `json:"placeholders"`
 	DefaultPrompt    string            `json:"default_prompt"`
@@ -30,6 +30,28 @@ var commonPlaceholders = []PlaceholderInfo{
 	{Key: "data.*", Description: "Access any field from trigger data", Example: "data.action"},
 }
 
+// taskPlaceholders are the source-task fields every task-event trigger
+// exposes, on top of the common placeholders.
+var taskPlaceholders = []PlaceholderInfo{
+	{Key: "task.id", Description: "Id of the task the event happened to", Example: "t_01H8XK..."},
+	{Key: "task.identifier", Description: "Task identifier", Example: "KAN-42"},
+	{Key: "task.title", Description: "Task title", Example: "Add rate limiting"},
+	{Key: "task.description", Description: "Task description", Example: "Limit the public API to 100 req/s"},
+	{Key: "task.state", Description: "Task state when the event fired", Example: "COMPLETED"},
+	{Key: "task.priority", Description: "Task priority", Example: "high"},
+	{Key: "task.workflow_id", Description: "Workflow the task belongs to", Example: "wf_01H8XK..."},
+	{Key: "task.step_id", Description: "Workflow step the task is in", Example: "ws_01H8XK..."},
+	{Key: "task.parent_id", Description: "Parent task id, empty for top-level tasks", Example: ""},
+}
+
+// taskEventPlaceholders returns the source-task placeholders followed by the
+// event's own fields and the common placeholders.
+func taskEventPlaceholders(extra ...PlaceholderInfo) []PlaceholderInfo {
+	out := append([]PlaceholderInfo{}, taskPlaceholders...)
+	out = append(out, extra...)
+	return append(out, commonPlaceholders...)
+}
+
 var triggerTypeRegistry = []TriggerTypeInfo{
 	{
 		Type:             TriggerTypeScheduled,
@@ -114,19 +136,142 @@ var triggerTypeRegistry = []TriggerTypeInfo{
 		DefaultTaskTitle: "[Auto] CI {{ci.conclusion}} — {{ci.check_name}}",
 		DefaultConfig:    json.RawMessage(`{"repos":[],"conclusions":["failure"]}`),
 	},
+	{
+		Type:  TriggerTypeGitLabMR,
+		Label: "New merge requests",
+		Description: "Polls GitLab for open merge requests in the selected projects matching your filters " +
+			"(target branch, author, labels). Requires a workspace GitLab connection.",
+		Category: triggerCategoryGitLab,
+		Enabled:  true,
+		Placeholders: append([]PlaceholderInfo{
+			{Key: "mr.iid", Description: "Merge request number (IID)", Example: "42"},
+			{Key: "mr.title", Description: "Merge request title", Example: "Fix the bug"},
+			{Key: "mr.url", Description: "Merge request URL", Example: "https://gitlab.com/group/project/-/merge_requests/42"},
+			{Key: "mr.author", Description: "MR author username", Example: "alice"},
+			{Key: "mr.project", Description: placeholderProjectPath, Example: exampleProjectPath},
+			{Key: "mr.branch", Description: "Source branch name", Example: "fix-bug"},
+			{Key: "mr.target_branch", Description: "Target branch name", Example: defaultBranchMain},
+			{Key: "mr.body", Description: "Merge request description", Example: "Closes #123"},
+		}, commonPlaceholders...),
+		DefaultPrompt:    "Review MR !{{mr.iid}} in {{mr.project}}: {{mr.title}}\n\n{{mr.body}}\n\nBranch: {{mr.branch}} → {{mr.target_branch}}",
+		DefaultTaskTitle: "[Auto] {{mr.project}}!{{mr.iid}} — {{mr.title}}",
+		DefaultConfig:    json.RawMessage(`{"projects":[],"exclude_draft":false}`),
+	},
+	{
+		Type:  TriggerTypeGitLabPipelineFailed,
+		Label: "Pipeline failed",
+		Description: "Polls GitLab for pipelines that failed in the selected projects after the trigger was " +
+			"created. Requires a workspace GitLab connection.",
+		Category: triggerCategoryGitLab,
+		Enabled:  true,
+		Placeholders: append([]PlaceholderInfo{
+			{Key: "pipeline.id", Description: "Pipeline ID", Example: "1234567"},
+			{Key: "pipeline.project", Description: placeholderProjectPath, Example: exampleProjectPath},
+			{Key: "pipeline.ref", Description: "Branch or tag the pipeline ran for", Example: defaultBranchMain},
+			{Key: "pipeline.sha", Description: "Commit SHA", Example: "abc1234"},
+			{Key: "pipeline.source", Description: "What started the pipeline", Example: "push"},
+			{Key: "pipeline.url", Description: "Pipeline URL", Example: "https://gitlab.com/..."},
+		}, commonPlaceholders...),
+		DefaultPrompt:    "Pipeline {{pipeline.id}} failed on {{pipeline.ref}} in {{pipeline.project}}\n\n{{pipeline.url}}",
+		DefaultTaskTitle: "[Auto] Pipeline failed — {{pipeline.project}}@{{pipeline.ref}}",
+		DefaultConfig:    json.RawMessage(`{"projects":[],"branches":["main"]}`),
+	},
+	{
+		Type:  TriggerTypeGitLabPush,
+		Label: "Push to branch",
+		Description: "Polls GitLab branch heads in the selected projects and triggers when a matching branch " +
+			"moves to a new commit. Requires a workspace GitLab connection.",
+		Category: triggerCategoryGitLab,
+		Enabled:  true,
+		Placeholders: append([]PlaceholderInfo{
+			{Key: "push.branch", Description: "Branch that was pushed to", Example: defaultBranchMain},
+			{Key: "push.project", Description: placeholderProjectPath, Example: exampleProjectPath},
+			{Key: "push.sha", Description: "New head commit SHA", Example: "abc1234"},
+			{Key: "push.message", Description: "Head commit title", Example: "feat: add feature"},
+		}, commonPlaceholders...),
+		DefaultPrompt:    "Review push to {{push.branch}} in {{push.project}}\n\nCommit: {{push.sha}}\n{{push.message}}",
+		DefaultTaskTitle: "[Auto] Push to {{push.branch}} — {{push.project}}",
+		DefaultConfig:    json.RawMessage(`{"projects":[],"branches":["main"]}`),
+	},
 	{
 		Type:        TriggerTypeWebhook,
 		Label:       "Webhook",
-		Description: "Triggers when an HTTP POST is received at the automation's webhook URL",
+		Description: "Triggers when a signed HTTP POST is received at the automation's webhook URL",
 		Category:    "webhook",
 		Enabled:     true,
 		Placeholders: append([]PlaceholderInfo{
 			{Key: "webhook.body", Description: "Full webhook request body (JSON)", Example: `{"event":"deploy"}`},
+			{Key: "field.<name>", Description: "Payload field extracted by the trigger's field_mappings", Example: "field.severity"},
 		}, commonPlaceholders...),
 		DefaultPrompt:    "Process webhook event.\n\n{{webhook.body}}",
 		DefaultTaskTitle: "",
 		DefaultConfig:    json.RawMessage(`{}`),
 	},
+	{
+		Type:        TriggerTypeTaskStepEntered,
+		Label:       "Task entered step",
+		Description: "Triggers when a task in this workspace enters one of the selected workflow steps, whether moved by hand or by the workflow.",
+		Category:    triggerCategoryTask,
+		Enabled:     true,
+		Placeholders: taskEventPlaceholders(
+			PlaceholderInfo{Key: "data.from_step_id", Description: "Step the task left", Example: "ws_01H8XK..."},
+			PlaceholderInfo{Key: "data.to_step_id", Description: "Step the task entered", Example: "ws_01H8XL..."},
+		),
+		DefaultPrompt:    "Task {{task.identifier}} \"{{task.title}}\" entered a new workflow step.\n\n{{task.description}}",
+		DefaultTaskTitle: "[Auto] Follow-up for {{task.title}}",
+		DefaultConfig:    json.RawMessage(`{"workflow_ids":[],"step_ids":[]}`),
+	},
+	{
+		Type:             TriggerTypeTaskCompleted,
+		Label:            "Task completed",
+		Description:      "Triggers when a task in this workspace completes. Tasks created by automations never trigger it.",
+		Category:         triggerCategoryTask,
+		Enabled:          true,
+		Placeholders:     taskEventPlaceholders(),
+		DefaultPrompt:    "Task {{task.identifier}} \"{{task.title}}\" has completed. Update the documentation for the change it made.\n\n{{task.description}}",
+		DefaultTaskTitle: "[Auto] Docs for {{task.title}}",
+		DefaultConfig:    json.RawMessage(`{"workflow_ids":[]}`),
+	},
+	{
+		Type:             TriggerTypeTaskFailed,
+		Label:            "Task failed",
+		Description:      "Triggers when a task in this workspace fails. Tasks created by automations never trigger it.",
+		Category:         triggerCategoryTask,
+		Enabled:          true,
+		Placeholders:     taskEventPlaceholders(),
+		DefaultPrompt:    "Task {{task.identifier}} \"{{task.title}}\" has failed. Investigate why.\n\n{{task.description}}",
+		DefaultTaskTitle: "[Auto] Investigate {{task.title}}",
+		DefaultConfig:    json.RawMessage(`{"workflow_ids":[]}`),
+	},
+	{
+		Type:        TriggerTypeTaskPRMerged,
+		Label:       "Task pull request merged",
+		Description: "Triggers when a pull request linked to a task in this workspace is merged, with the task's fields available to the prompt.",
+		Category:    triggerCategoryTask,
+		Enabled:     true,
+		Placeholders: taskEventPlaceholders(
+			PlaceholderInfo{Key: "data.repo", Description: "Repository the pull request belonged to", Example: "acme/api"},
+			PlaceholderInfo{Key: "data.pr_number", Description: "Pull request number", Example: "7"},
+			PlaceholderInfo{Key: "data.pr_url", Description: "Pull request URL", Example: exampleGitHubPRURL},
+		),
+		DefaultPrompt:    "The pull request {{data.pr_url}} for task \"{{task.title}}\" has merged.\n\n{{task.description}}",
+		DefaultTaskTitle: "[Auto] Follow-up for {{task.title}}",
+		DefaultConfig:    json.RawMessage(`{"workflow_ids":[]}`),
+	},
+	{
+		Type:        TriggerTypeTaskReviewBlocking,
+		Label:       "Review found blockers",
+		Description: "Triggers when a code review of a task in this workspace publishes blocker-severity findings.",
+		Category:    triggerCategoryTask,
+		Enabled:     true,
+		Placeholders: taskEventPlaceholders(
+			PlaceholderInfo{Key: "data.run_id", Description: "Review run that produced the findings", Example: "rr_01H8XK..."},
+			PlaceholderInfo{Key: "data.blocking_findings", Description: "Number of open blocker findings", Example: "2"},
+		),
+		DefaultPrompt:    "The review of task \"{{task.title}}\" found {{data.blocking_findings}} blocking issue(s). Triage them.",
+		DefaultTaskTitle: "[Auto] Blockers in {{task.title}}",
+		DefaultConfig:    json.RawMessage(`{"workflow_ids":[]}`),
+	},
 }
 
 // GetTriggerTypes returns metadata for all known trigger types.
@@ -12,9 +12,9 @@ import (
 func TestTriggerRegistry_GuardTest(t *testing.T) {
 	types := GetTriggerTypes()
 
-	// 1. Exactly 6 entries.
-	if len(types) != 6 {
-		t.Errorf("registry has %d entries, want 6", len(types))
+	// 1. Exactly 14 entries.
+	if len(types) != 14 {
+		t.Errorf("registry has %d entries, want 14", len(types))
 	}
 
 	// 2. Type values in array order, pinning index-2 insertion.
@@ -24,7 +24,15 @@ func TestTriggerRegistry_GuardTest(t *testing.T) {
 		TriggerTypeGitHubPRMerged,
 		TriggerTypeGitHubPush,
 		TriggerTypeGitHubCI,
+		TriggerTypeGitLabMR,
+		TriggerTypeGitLabPipelineFailed,
+		TriggerTypeGitLabPush,
 		TriggerTypeWebhook,
+		TriggerTypeTaskStepEntered,
+		TriggerTypeTaskCompleted,
+		TriggerTypeTaskFailed,
+		TriggerTypeTaskPRMerged,
+		TriggerTypeTaskReviewBlocking,
 	}
 	for i, want := range wantOrder {
 		if i >= len(types) {
@@ -1,10 +1,10 @@
 package automation
 
 import (
-	"crypto/subtle"
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -26,7 +26,8 @@ func NewWebhookHandler(svc *Service, log *logger.Logger) *WebhookHandler {
 }
 
 // Handle processes an incoming webhook POST request.
-// URL format: POST /api/v1/automations/webhook/:id  with X-Webhook-Secret header
+// URL format: POST /api/v1/automations/webhook/:id, authenticated per the
+// webhook trigger's signature_scheme (X-Webhook-Secret header by default).
 func (h *WebhookHandler) Handle(c *gin.Context) {
 	automationID := c.Param("id")
 	if automationID == "" {
@@ -44,43 +45,44 @@ func (h *WebhookHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	// Secret must come via header — query params would leak into URLs/logs.
-	// Compared in constant time so an attacker can't recover the secret byte
-	// by byte from timing differences.
-	secret := c.GetHeader("X-Webhook-Secret")
-	if subtle.ConstantTimeCompare([]byte(secret), []byte(a.WebhookSecret)) != 1 {
-		c.JSON(http.StatusUnauthorized, gin.H{responseErrorKey: "invalid webhook secret"})
-		return
-	}
-
 	// Find the first enabled webhook trigger for this automation. Bail out if
 	// none — firing with an empty trigger ID papers over a misconfiguration.
-	triggerID := ""
-	for _, t := range a.Triggers {
-		if t.Type == TriggerTypeWebhook && t.Enabled {
-			triggerID = t.ID
-			break
-		}
-	}
-	if triggerID == "" {
+	// It is needed before authentication: its config picks the scheme.
+	trigger := firstEnabledWebhookTrigger(a)
+	if trigger == nil {
 		c.JSON(http.StatusConflict, gin.H{responseErrorKey: "no enabled webhook trigger"})
 		return
 	}
+	var cfg WebhookTriggerConfig
+	if len(trigger.Config) > 0 {
+		if err := json.Unmarshal(trigger.Config, &cfg); err != nil {
+			c.JSON(http.StatusConflict, gin.H{responseErrorKey: "invalid webhook trigger config"})
+			return
+		}
+	}
 
-	// Read body as trigger data.
+	// Signatures cover the raw body, so it is read before verifying.
 	body, readErr := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20)) // 1MB limit
 	if readErr != nil {
 		c.JSON(http.StatusBadRequest, gin.H{responseErrorKey: "failed to read body"})
 		return
 	}
 
-	// Ensure valid JSON; wrap raw text if needed.
-	triggerData := json.RawMessage(body)
-	if len(body) == 0 || !json.Valid(body) {
-		triggerData, _ = json.Marshal(map[string]string{"body": string(body)})
+	// Secrets must come via headers — query params would leak into URLs/logs.
+	if err := verifyWebhookRequest(cfg, a.WebhookSecret, c.Request.Header, body, time.Now()); err != nil {
+		h.logger.Debug("rejected webhook delivery",
+			zap.String("automation_id", automationID), zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{responseErrorKey: "invalid webhook signature"})
+		return
 	}
 
-	if _, fireErr := h.svc.FireTrigger(c.Request.Context(), automationID, triggerID, TriggerTypeWebhook, triggerData, ""); fireErr != nil {
+	triggerData := webhookTriggerData(body, cfg.FieldMappings)
+	dedupKey := ""
+	if id := webhookDeliveryID(cfg, c.Request.Header, body); id != "" {
+		dedupKey = "webhook:" + id
+	}
+	if _, fireErr := h.svc.FireTriggerWithPayload(c.Request.Context(), automationID, trigger.ID, TriggerTypeWebhook,
+		triggerData, webhookRawPayload(body), dedupKey); fireErr != nil {
 		h.logger.Error("failed to fire webhook trigger",
 			zap.String("automation_id", automationID),
 			zap.Error(fireErr))
@@ -90,3 +92,12 @@ func (h *WebhookHandler) Handle(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "triggered"})
 }
+
+func firstEnabledWebhookTrigger(a *Automation) *AutomationTrigger {
+	for i := range a.Triggers {
+		if t := &a.Triggers[i]; t.Type == TriggerTypeWebhook && t.Enabled {
+			return t
+		}
+	}
+	return nil
+}
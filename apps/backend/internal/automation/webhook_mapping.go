@@ -0,0 +1,166 @@
+package automation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// webhookFieldsKey is the trigger-data key the webhook handler stores mapped
+// payload fields under. {{field.<name>}} reads it and {{webhook.body}}
+// leaves it out, so the prompt sees the payload as it was delivered.
+const webhookFieldsKey = "_fields"
+
+// webhookFieldNameRe is the shape of a mapping name — whatever can sit
+// inside a {{field.<name>}} placeholder.
+var webhookFieldNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// parseFieldPath splits a JSONPath-style expression into lookup segments.
+// Supported: an optional leading "$", dotted keys, [n] array indexes and
+// ['key'] / ["key"] for keys containing dots or spaces — e.g.
+// "$.incident.title", "alerts[0].labels.severity", "$['x.y'].z".
+func parseFieldPath(path string) ([]string, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segs []string
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %q", path)
+			}
+			seg := rest[1:end]
+			if unquoted, ok := unquoteFieldKey(seg); ok {
+				seg = unquoted
+			} else if !isDigits(seg) {
+				return nil, fmt.Errorf("index %q in %q is neither a number nor a quoted key", seg, path)
+			}
+			segs = append(segs, seg)
+			rest = rest[end+1:]
+			continue
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("empty key in %q", path)
+		}
+		segs = append(segs, rest[:end])
+		rest = rest[end:]
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("path %q selects nothing", path)
+	}
+	return segs, nil
+}
+
+func unquoteFieldKey(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateWebhookConfig rejects a signature scheme the handler can't verify
+// and field mappings that could never resolve.
+func validateWebhookConfig(raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var cfg WebhookTriggerConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("invalid webhook trigger config: %w", err)
+	}
+	if !validWebhookSignatureScheme(cfg.SignatureScheme) {
+		return fmt.Errorf("unsupported webhook signature scheme %q", cfg.SignatureScheme)
+	}
+	if cfg.ReplayWindowSeconds < 0 {
+		return fmt.Errorf("replay_window_seconds must not be negative")
+	}
+	for name, path := range cfg.FieldMappings {
+		if !webhookFieldNameRe.MatchString(name) {
+			return fmt.Errorf("invalid field mapping name %q: use letters, digits, _ or -", name)
+		}
+		if _, err := parseFieldPath(path); err != nil {
+			return fmt.Errorf("invalid path for field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// extractWebhookFields resolves each mapping against the decoded payload.
+// Fields whose path is missing from this delivery are left out, so their
+// placeholders render empty rather than failing the run.
+func extractWebhookFields(mappings map[string]string, payload interface{}) map[string]string {
+	fields := make(map[string]string, len(mappings))
+	for name, path := range mappings {
+		segs, err := parseFieldPath(path)
+		if err != nil {
+			continue
+		}
+		if v, ok := walkPath(payload, segs); ok {
+			fields[name] = toString(v)
+		}
+	}
+	return fields
+}
+
+// webhookTriggerData builds the trigger data for a delivery: the JSON body
+// (a non-JSON or non-object body wrapped as {"body": ...}) plus the mapped
+// fields under webhookFieldsKey.
+func webhookTriggerData(body []byte, mappings map[string]string) json.RawMessage {
+	if len(body) == 0 || !json.Valid(body) {
+		data, _ := json.Marshal(map[string]string{"body": string(body)})
+		return data
+	}
+	if len(mappings) == 0 {
+		return json.RawMessage(body)
+	}
+	var payload interface{}
+	_ = json.Unmarshal(body, &payload)
+	obj, ok := payload.(map[string]interface{})
+	if !ok {
+		obj = map[string]interface{}{"body": payload}
+	}
+	obj[webhookFieldsKey] = extractWebhookFields(mappings, payload)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return json.RawMessage(body)
+	}
+	return data
+}
+
+// webhookRawPayload is body as recorded on the run for replay. Runs store
+// their raw payload as JSON, so a body that isn't JSON is kept as a string.
+func webhookRawPayload(body []byte) json.RawMessage {
+	if len(body) > 0 && json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	data, _ := json.Marshal(string(body))
+	return data
+}
+
+// webhookBodyFromRawPayload reverses webhookRawPayload.
+func webhookBodyFromRawPayload(raw json.RawMessage) []byte {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []byte(text)
+	}
+	return raw
+}
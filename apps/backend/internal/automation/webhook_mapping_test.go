@@ -0,0 +1,81 @@
+package automation
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "$.incident.title", want: []string{"incident", "title"}},
+		{path: "alerts[0].labels.severity", want: []string{"alerts", "0", "labels", "severity"}},
+		{path: "$['x.y'][\"a b\"]", want: []string{"x.y", "a b"}},
+		{path: "$", wantErr: true},
+		{path: "alerts[first]", wantErr: true},
+		{path: "alerts[0", wantErr: true},
+		{path: "a..b", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFieldPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseFieldPath(%q) err = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseFieldPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookTriggerData_FieldMappings(t *testing.T) {
+	body := []byte(`{"incident":{"title":"DB down","urgency":"high"},"alerts":[{"id":42}]}`)
+	data := webhookTriggerData(body, map[string]string{
+		"title":    "$.incident.title",
+		"alert_id": "alerts[0].id",
+		"missing":  "$.nope",
+	})
+
+	prompt := InterpolatePrompt("{{field.title}} #{{field.alert_id}} [{{field.missing}}] {{webhook.incident.urgency}}", TriggerTypeWebhook, data)
+	if want := "DB down #42 [] high"; prompt != want {
+		t.Fatalf("prompt = %q, want %q", prompt, want)
+	}
+	// The mapped fields are bookkeeping, not part of the delivered payload.
+	var gotBody, wantBody interface{}
+	_ = json.Unmarshal([]byte(InterpolatePrompt("{{webhook.body}}", TriggerTypeWebhook, data)), &gotBody)
+	_ = json.Unmarshal(body, &wantBody)
+	if !reflect.DeepEqual(gotBody, wantBody) {
+		t.Fatalf("webhook.body = %v, want %v", gotBody, wantBody)
+	}
+}
+
+func TestWebhookTriggerData_NonObjectPayload(t *testing.T) {
+	data := webhookTriggerData([]byte(`[{"name":"a"}]`), map[string]string{"first": "[0].name"})
+	if got := InterpolatePrompt("{{field.first}}", TriggerTypeWebhook, data); got != "a" {
+		t.Fatalf("field.first = %q, want a", got)
+	}
+	raw := webhookTriggerData([]byte("plain text"), map[string]string{"x": "$.x"})
+	if got := InterpolatePrompt("{{webhook.body}}", TriggerTypeWebhook, raw); got != `{"body":"plain text"}` {
+		t.Fatalf("webhook.body = %q", got)
+	}
+}
+
+func TestValidateWebhookConfig(t *testing.T) {
+	valid := json.RawMessage(`{"signature_scheme":"stripe","replay_window_seconds":600,"field_mappings":{"title":"$.data.object.description"}}`)
+	if err := validateTriggerConfig(TriggerTypeWebhook, valid); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	for _, raw := range []string{
+		`{"signature_scheme":"md5"}`,
+		`{"replay_window_seconds":-1}`,
+		`{"field_mappings":{"bad name":"$.a"}}`,
+		`{"field_mappings":{"a":"$.a["}}`,
+	} {
+		if err := validateTriggerConfig(TriggerTypeWebhook, json.RawMessage(raw)); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
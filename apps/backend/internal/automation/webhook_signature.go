@@ -0,0 +1,256 @@
+package automation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook signature schemes a webhook trigger can require. Every scheme
+// keys its HMAC with the automation's webhook secret; for providers that
+// issue their own signing secret (Stripe, Svix, Slack) that secret is set
+// on the automation in place of the generated one.
+const (
+	// WebhookSignatureSecretHeader compares the X-Webhook-Secret header with
+	// the secret. The default when a trigger names no scheme.
+	WebhookSignatureSecretHeader = "secret_header"
+	// WebhookSignatureGitHub verifies X-Hub-Signature-256: "sha256=" + hex
+	// HMAC-SHA256 of the body.
+	WebhookSignatureGitHub = "github"
+	// WebhookSignatureStandard verifies Standard Webhooks / Svix deliveries:
+	// webhook-signature (or svix-signature) holds space-separated "v1,<base64>"
+	// HMAC-SHA256 signatures of "<id>.<timestamp>.<body>". A "whsec_" secret
+	// is base64-decoded into the key.
+	WebhookSignatureStandard = "standard_webhooks"
+	// WebhookSignatureStripe verifies Stripe-Signature: "t=<ts>,v1=<hex>"
+	// with the HMAC-SHA256 of "<ts>.<body>".
+	WebhookSignatureStripe = "stripe"
+	// WebhookSignatureSlack verifies X-Slack-Signature: "v0=" + hex
+	// HMAC-SHA256 of "v0:<X-Slack-Request-Timestamp>:<body>".
+	WebhookSignatureSlack = "slack"
+)
+
+// defaultWebhookReplayWindow bounds how old a timestamped delivery may be,
+// the five minutes Stripe, Svix and Slack all recommend.
+const defaultWebhookReplayWindow = 5 * time.Minute
+
+var (
+	errWebhookSignatureMissing = errors.New("webhook signature missing")
+	errWebhookSignatureInvalid = errors.New("invalid webhook signature")
+	errWebhookTimestampInvalid = errors.New("webhook timestamp outside the replay window")
+)
+
+// validWebhookSignatureScheme reports whether scheme is one verifyWebhookRequest
+// understands. Empty selects WebhookSignatureSecretHeader.
+func validWebhookSignatureScheme(scheme string) bool {
+	switch scheme {
+	case "", WebhookSignatureSecretHeader, WebhookSignatureGitHub,
+		WebhookSignatureStandard, WebhookSignatureStripe, WebhookSignatureSlack:
+		return true
+	}
+	return false
+}
+
+// replayWindow is the trigger's replay window, defaulted.
+func (c WebhookTriggerConfig) replayWindow() time.Duration {
+	if c.ReplayWindowSeconds > 0 {
+		return time.Duration(c.ReplayWindowSeconds) * time.Second
+	}
+	return defaultWebhookReplayWindow
+}
+
+// verifyWebhookRequest authenticates a delivery under the trigger's
+// signature scheme. now is injected for the replay-window check.
+func verifyWebhookRequest(cfg WebhookTriggerConfig, secret string, header http.Header, body []byte, now time.Time) error {
+	if secret == "" {
+		return errWebhookSignatureInvalid
+	}
+	switch cfg.SignatureScheme {
+	case "", WebhookSignatureSecretHeader:
+		// Compared in constant time so an attacker can't recover the secret
+		// byte by byte from timing differences.
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Webhook-Secret")), []byte(secret)) != 1 {
+			return errWebhookSignatureInvalid
+		}
+		return nil
+	case WebhookSignatureGitHub:
+		return verifyHexSignature(header.Get("X-Hub-Signature-256"), "sha256=", []byte(secret), body)
+	case WebhookSignatureStandard:
+		return verifyStandardWebhook(cfg, secret, header, body, now)
+	case WebhookSignatureStripe:
+		return verifyStripeSignature(cfg, secret, header.Get("Stripe-Signature"), body, now)
+	case WebhookSignatureSlack:
+		ts := header.Get("X-Slack-Request-Timestamp")
+		if err := checkWebhookTimestamp(ts, cfg.replayWindow(), now); err != nil {
+			return err
+		}
+		signed := append([]byte("v0:"+ts+":"), body...)
+		return verifyHexSignature(header.Get("X-Slack-Signature"), "v0=", []byte(secret), signed)
+	default:
+		return fmt.Errorf("unsupported webhook signature scheme %q", cfg.SignatureScheme)
+	}
+}
+
+func hmacSHA256(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// verifyHexSignature checks a "<prefix><hex digest>" header value.
+func verifyHexSignature(value, prefix string, key, payload []byte) error {
+	if value == "" {
+		return errWebhookSignatureMissing
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || !strings.HasPrefix(value, prefix) || !hmac.Equal(got, hmacSHA256(key, payload)) {
+		return errWebhookSignatureInvalid
+	}
+	return nil
+}
+
+// checkWebhookTimestamp rejects a unix-seconds timestamp further than window
+// from now in either direction.
+func checkWebhookTimestamp(value string, window time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return errWebhookSignatureMissing
+	}
+	if math.Abs(now.Sub(time.Unix(ts, 0)).Seconds()) > window.Seconds() {
+		return errWebhookTimestampInvalid
+	}
+	return nil
+}
+
+// webhookHeader reads the Standard Webhooks header name, falling back to the
+// svix- prefixed one Svix still sends.
+func webhookHeader(header http.Header, name string) string {
+	if v := header.Get("webhook-" + name); v != "" {
+		return v
+	}
+	return header.Get("svix-" + name)
+}
+
+func verifyStandardWebhook(cfg WebhookTriggerConfig, secret string, header http.Header, body []byte, now time.Time) error {
+	id, ts, sigs := webhookHeader(header, "id"), webhookHeader(header, "timestamp"), webhookHeader(header, "signature")
+	if id == "" || sigs == "" {
+		return errWebhookSignatureMissing
+	}
+	if err := checkWebhookTimestamp(ts, cfg.replayWindow(), now); err != nil {
+		return err
+	}
+	key := []byte(secret)
+	if encoded, ok := strings.CutPrefix(secret, "whsec_"); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return errWebhookSignatureInvalid
+		}
+		key = decoded
+	}
+	signed := append([]byte(id+"."+ts+"."), body...)
+	want := hmacSHA256(key, signed)
+	// The header may carry several signatures during a secret rotation;
+	// any valid v1 signature authenticates the delivery.
+	for _, sig := range strings.Fields(sigs) {
+		encoded, ok := strings.CutPrefix(sig, "v1,")
+		if !ok {
+			continue
+		}
+		got, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil && hmac.Equal(got, want) {
+			return nil
+		}
+	}
+	return errWebhookSignatureInvalid
+}
+
+// parseStripeSignature splits a Stripe-Signature header into its signed
+// timestamp and v1 signatures.
+func parseStripeSignature(value string) (ts string, sigs []string) {
+	for _, part := range strings.Split(value, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	return ts, sigs
+}
+
+func stripeTimestamp(value string) string {
+	ts, _ := parseStripeSignature(value)
+	return ts
+}
+
+func verifyStripeSignature(cfg WebhookTriggerConfig, secret, value string, body []byte, now time.Time) error {
+	if value == "" {
+		return errWebhookSignatureMissing
+	}
+	ts, sigs := parseStripeSignature(value)
+	if err := checkWebhookTimestamp(ts, cfg.replayWindow(), now); err != nil {
+		return err
+	}
+	want := hmacSHA256([]byte(secret), append([]byte(ts+"."), body...))
+	for _, sig := range sigs {
+		got, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(got, want) {
+			return nil
+		}
+	}
+	return errWebhookSignatureInvalid
+}
+
+// webhookDeliveryID returns an id for a delivery built only from what the
+// scheme's signature covers, so a replayed delivery is fired only once
+// however its unsigned headers were altered. Empty when the scheme signs
+// nothing that identifies a delivery.
+//
+//   - Standard Webhooks sign their webhook-id.
+//   - GitHub signs only the body and has no timestamp to bound a replay, so
+//     the body hash is the id. A redelivery of the same event body is
+//     therefore deduped as well.
+//   - Stripe and Slack event bodies carry a signed event id ("id",
+//     "event_id") that provider retries keep. Deliveries without one, such
+//     as Slack slash commands, use the signed timestamp and the body hash.
+func webhookDeliveryID(cfg WebhookTriggerConfig, header http.Header, body []byte) string {
+	switch cfg.SignatureScheme {
+	case WebhookSignatureStandard:
+		return webhookHeader(header, "id")
+	case WebhookSignatureGitHub:
+		return "sha256:" + webhookBodyHash(body)
+	case WebhookSignatureStripe:
+		var event struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(body, &event) == nil && event.ID != "" {
+			return event.ID
+		}
+		return stripeTimestamp(header.Get("Stripe-Signature")) + ":" + webhookBodyHash(body)
+	case WebhookSignatureSlack:
+		var event struct {
+			EventID string `json:"event_id"`
+		}
+		if json.Unmarshal(body, &event) == nil && event.EventID != "" {
+			return event.EventID
+		}
+		return strings.TrimSpace(header.Get("X-Slack-Request-Timestamp")) + ":" + webhookBodyHash(body)
+	}
+	return ""
+}
+
+func webhookBodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
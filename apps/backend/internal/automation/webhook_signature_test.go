@@ -0,0 +1,101 @@
+package automation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testHMAC(key, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func TestVerifyWebhookRequest(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"event":"deploy"}`)
+	now := time.Unix(1_700_000_000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	svixKey := "whsec_" + base64.StdEncoding.EncodeToString([]byte("svix-key"))
+	svixSig := "v1," + base64.StdEncoding.EncodeToString(testHMAC("svix-key", "msg_1."+ts+"."+string(body)))
+
+	tests := []struct {
+		name    string
+		scheme  string
+		secret  string
+		header  map[string]string
+		wantErr error
+	}{
+		{"secret header", "", secret, map[string]string{"X-Webhook-Secret": secret}, nil},
+		{"secret header wrong", WebhookSignatureSecretHeader, secret, map[string]string{"X-Webhook-Secret": "nope"}, errWebhookSignatureInvalid},
+		{"github", WebhookSignatureGitHub, secret, map[string]string{
+			"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(testHMAC(secret, string(body))),
+		}, nil},
+		{"github missing prefix", WebhookSignatureGitHub, secret, map[string]string{
+			"X-Hub-Signature-256": hex.EncodeToString(testHMAC(secret, string(body))),
+		}, errWebhookSignatureInvalid},
+		{"github missing header", WebhookSignatureGitHub, secret, nil, errWebhookSignatureMissing},
+		{"stripe", WebhookSignatureStripe, secret, map[string]string{
+			"Stripe-Signature": "t=" + ts + ",v1=deadbeef,v1=" + hex.EncodeToString(testHMAC(secret, ts+"."+string(body))),
+		}, nil},
+		{"stripe stale", WebhookSignatureStripe, secret, map[string]string{
+			"Stripe-Signature": "t=" + stale + ",v1=" + hex.EncodeToString(testHMAC(secret, stale+"."+string(body))),
+		}, errWebhookTimestampInvalid},
+		{"standard webhooks", WebhookSignatureStandard, svixKey, map[string]string{
+			"webhook-id": "msg_1", "webhook-timestamp": ts, "webhook-signature": "v1,bm9wZQ== " + svixSig,
+		}, nil},
+		{"svix headers", WebhookSignatureStandard, svixKey, map[string]string{
+			"svix-id": "msg_1", "svix-timestamp": ts, "svix-signature": svixSig,
+		}, nil},
+		{"standard webhooks tampered id", WebhookSignatureStandard, svixKey, map[string]string{
+			"webhook-id": "msg_2", "webhook-timestamp": ts, "webhook-signature": svixSig,
+		}, errWebhookSignatureInvalid},
+		{"slack", WebhookSignatureSlack, secret, map[string]string{
+			"X-Slack-Request-Timestamp": ts,
+			"X-Slack-Signature":         "v0=" + hex.EncodeToString(testHMAC(secret, "v0:"+ts+":"+string(body))),
+		}, nil},
+		{"slack stale", WebhookSignatureSlack, secret, map[string]string{
+			"X-Slack-Request-Timestamp": stale,
+			"X-Slack-Signature":         "v0=" + hex.EncodeToString(testHMAC(secret, "v0:"+stale+":"+string(body))),
+		}, errWebhookTimestampInvalid},
+		{"empty secret", "", "", map[string]string{"X-Webhook-Secret": ""}, errWebhookSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			err := verifyWebhookRequest(WebhookTriggerConfig{SignatureScheme: tt.scheme}, tt.secret, header, body, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookRequest_ReplayWindow(t *testing.T) {
+	const secret = "s3cret"
+	now := time.Unix(1_700_000_000, 0)
+	ts := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	header := http.Header{}
+	header.Set("Stripe-Signature", "t="+ts+",v1="+hex.EncodeToString(testHMAC(secret, ts+".{}")))
+
+	cfg := WebhookTriggerConfig{SignatureScheme: WebhookSignatureStripe, ReplayWindowSeconds: 900}
+	if err := verifyWebhookRequest(cfg, secret, header, []byte("{}"), now); err != nil {
+		t.Fatalf("a 10-minute-old delivery inside a 15-minute window: %v", err)
+	}
+	cfg.ReplayWindowSeconds = 0
+	if err := verifyWebhookRequest(cfg, secret, header, []byte("{}"), now); !errors.Is(err, errWebhookTimestampInvalid) {
+		t.Fatalf("default window: err = %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package automation
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+func newWebhookTestRouter(t *testing.T, svc *Service) *gin.Engine {
+	t.Helper()
+	log, err := logger.NewFromZap(zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/automations/webhook/:id", NewWebhookHandler(svc, log).Handle)
+	return router
+}
+
+func postWebhook(router *gin.Engine, automationID, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/automations/webhook/"+automationID, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookHandler_GitHubSignatureAndFieldMapping(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	router := newWebhookTestRouter(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeWebhook, WebhookTriggerConfig{
+		SignatureScheme: WebhookSignatureGitHub,
+		FieldMappings:   map[string]string{"title": "$.issue.title"},
+	})
+	const body = `{"action":"opened","issue":{"title":"Crash on save"}}`
+
+	rec := postWebhook(router, a.ID, body, map[string]string{"X-Hub-Signature-256": "sha256=00"})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status = %d, want 401", rec.Code)
+	}
+	expectNoFire(t, fired)
+
+	rec = postWebhook(router, a.ID, body, map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(testHMAC(a.WebhookSecret, body)),
+		"X-GitHub-Delivery":   "delivery-1",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case evt := <-fired:
+		if !strings.HasPrefix(evt.DedupKey, "webhook:sha256:") {
+			t.Fatalf("dedup key = %q", evt.DedupKey)
+		}
+		if got := InterpolatePrompt("Triage: {{field.title}}", evt.TriggerType, evt.TriggerData); got != "Triage: Crash on save" {
+			t.Fatalf("prompt = %q", got)
+		}
+	default:
+		t.Fatal("expected AutomationTriggered event")
+	}
+}
+
+func TestWebhookHandler_ProviderIssuedSecret(t *testing.T) {
+	svc := newPRMergedTestService(t)
+	router := newWebhookTestRouter(t, svc)
+	fired := subscribeAutomationTriggered(t, svc.eventBus)
+
+	a := createTestAutomation(t, svc, "ws-1")
+	addTestTrigger(t, svc, a.ID, TriggerTypeWebhook, WebhookTriggerConfig{})
+	secret := "provider-issued"
+	if _, err := svc.UpdateAutomation(context.Background(), a.ID, &UpdateAutomationRequest{WebhookSecret: &secret}); err != nil {
+		t.Fatalf("UpdateAutomation: %v", err)
+	}
+	empty := " "
+	if _, err := svc.UpdateAutomation(context.Background(), a.ID, &UpdateAutomationRequest{WebhookSecret: &empty}); err == nil {
+		t.Fatal("expected an empty webhook secret to be rejected")
+	}
+
+	if rec := postWebhook(router, a.ID, "{}", map[string]string{"X-Webhook-Secret": a.WebhookSecret}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("replaced secret still accepted: status = %d", rec.Code)
+	}
+	if rec := postWebhook(router, a.ID, "{}", map[string]string{"X-Webhook-Secret": secret}); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case <-fired:
+	default:
+		t.Fatal("expected AutomationTriggered event")
+	}
+}
+
+// TestWebhookHandler_ReplayFiresOnce replays signed deliveries inside the
+// five-minute replay window, once verbatim and once with the unsigned header
+// text rewritten so that the signature still verifies. Each delivery fires
+// the automation once. A provider retry, signed afresh, is deduped only by
+// a signed event id.
+func TestWebhookHandler_ReplayFiresOnce(t *testing.T) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	retryTS := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	githubHeader := func(secret, _, body string) map[string]string {
+		return map[string]string{
+			"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(testHMAC(secret, body)),
+			"X-GitHub-Delivery":   "delivery-1",
+		}
+	}
+	stripeHeader := func(secret, ts, body string) map[string]string {
+		return map[string]string{"Stripe-Signature": "t=" + ts + ",v1=" + hex.EncodeToString(testHMAC(secret, ts+"."+body))}
+	}
+	slackHeader := func(secret, ts, body string) map[string]string {
+		return map[string]string{
+			"X-Slack-Request-Timestamp": ts,
+			"X-Slack-Signature":         "v0=" + hex.EncodeToString(testHMAC(secret, "v0:"+ts+":"+body)),
+		}
+	}
+
+	upperSlackSignature := func(h map[string]string) {
+		h["X-Slack-Signature"] = "v0=" + strings.ToUpper(strings.TrimPrefix(h["X-Slack-Signature"], "v0="))
+	}
+
+	tests := []struct {
+		name   string
+		scheme string
+		body   string
+		// header signs a delivery at a timestamp; tamper rewrites the
+		// unsigned text of a signed header.
+		header func(secret, ts, body string) map[string]string
+		tamper func(map[string]string)
+		// retryDeduped reports whether a delivery signed afresh still
+		// counts as the same one.
+		retryDeduped bool
+	}{
+		{
+			name: "github", scheme: WebhookSignatureGitHub,
+			body: `{"action":"opened"}`, header: githubHeader,
+			tamper:       func(h map[string]string) { h["X-GitHub-Delivery"] = "delivery-2" },
+			retryDeduped: true,
+		},
+		{
+			name: "stripe event", scheme: WebhookSignatureStripe,
+			body: `{"id":"evt_1","type":"invoice.paid"}`, header: stripeHeader,
+			tamper:       func(h map[string]string) { h["Stripe-Signature"] += ",v1=00" },
+			retryDeduped: true,
+		},
+		{
+			name: "stripe without event id", scheme: WebhookSignatureStripe,
+			body: `{"type":"invoice.paid"}`, header: stripeHeader,
+			tamper: func(h map[string]string) { h["Stripe-Signature"] += ",v1=00" },
+		},
+		{
+			name: "slack event", scheme: WebhookSignatureSlack,
+			body: `{"type":"event_callback","event_id":"Ev01"}`, header: slackHeader,
+			tamper:       upperSlackSignature,
+			retryDeduped: true,
+		},
+		{
+			name: "slack slash command", scheme: WebhookSignatureSlack,
+			body: "command=%2Fdeploy&text=prod", header: slackHeader,
+			tamper: upperSlackSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newPRMergedTestService(t)
+			router := newWebhookTestRouter(t, svc)
+			fired := subscribeAutomationTriggered(t, svc.eventBus)
+			a := createTestAutomation(t, svc, "ws-1")
+			addTestTrigger(t, svc, a.ID, TriggerTypeWebhook, WebhookTriggerConfig{SignatureScheme: tt.scheme})
+
+			tampered := tt.header(a.WebhookSecret, ts, tt.body)
+			tt.tamper(tampered)
+			for _, header := range []map[string]string{tt.header(a.WebhookSecret, ts, tt.body), tt.header(a.WebhookSecret, ts, tt.body), tampered} {
+				if rec := postWebhook(router, a.ID, tt.body, header); rec.Code != http.StatusOK {
+					t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+				}
+			}
+			select {
+			case <-fired:
+			default:
+				t.Fatal("expected AutomationTriggered event")
+			}
+			expectNoFire(t, fired)
+
+			retry := postWebhook(router, a.ID, tt.body, tt.header(a.WebhookSecret, retryTS, tt.body))
+			if retry.Code != http.StatusOK {
+				t.Fatalf("retry status = %d, body %s", retry.Code, retry.Body.String())
+			}
+			if !tt.retryDeduped {
+				select {
+				case <-fired:
+				default:
+					t.Fatal("expected the re-signed delivery to fire")
+				}
+			}
+			expectNoFire(t, fired)
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/runtime/agentctl"
 	runtimeenv "github.com/kandev/kandev/internal/agent/runtime/environment"
 	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentctl/types/streams"
 	"github.com/kandev/kandev/internal/clarification"
 	"github.com/kandev/kandev/internal/common/logger"
@@ -230,6 +231,7 @@ func buildLifecycleLaunchRequest(
 		McpMode:                       req.McpMode,
 		McpProviders:                  req.McpProviders,
 		McpProfile:                    req.McpProfile,
+		StepPermissionPolicy:          req.StepPermissionPolicy,
 		IsEphemeral:                   req.IsEphemeral,
 		IsPassthrough:                 req.IsPassthrough,
 		SetupScript:                   req.SetupScript,
@@ -569,6 +571,12 @@ func (a *lifecycleAdapter) SetSessionModeBySessionID(ctx context.Context, sessio
 	return a.mgr.SetSessionModeBySessionID(ctx, sessionID, modeID)
 }
 
+// SetPermissionPolicyBySessionID replaces the workflow step permission rules
+// of a live session's agentctl; the profile's rules are re-applied after them.
+func (a *lifecycleAdapter) SetPermissionPolicyBySessionID(ctx context.Context, sessionID string, stepPolicy *permpolicy.Policy) error {
+	return a.mgr.SetPermissionPolicyForSession(ctx, sessionID, stepPolicy)
+}
+
 // RespondToPermissionBySessionID sends a response to a permission request for a session
 func (a *lifecycleAdapter) RespondToPermissionBySessionID(ctx context.Context, sessionID, pendingID, optionID string, cancelled bool) error {
 	return a.mgr.RespondToPermissionBySessionID(sessionID, pendingID, optionID, cancelled)
@@ -754,6 +762,45 @@ func (a *lifecycleAdapter) GetGitStatusFresh(ctx context.Context, sessionID stri
 	return agentClient.GetGitStatusFresh(ctx)
 }
 
+// CreateWorkspaceCheckpoint implements executor.WorkspaceCheckpointer.
+func (a *lifecycleAdapter) CreateWorkspaceCheckpoint(ctx context.Context, sessionID, checkpointID string) (*client.WorkspaceCheckpoint, error) {
+	agentClient, err := a.agentctlForSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return agentClient.CreateCheckpoint(ctx, checkpointID)
+}
+
+// ListWorkspaceCheckpoints implements executor.WorkspaceCheckpointer.
+func (a *lifecycleAdapter) ListWorkspaceCheckpoints(ctx context.Context, sessionID string) ([]client.WorkspaceCheckpoint, error) {
+	agentClient, err := a.agentctlForSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return agentClient.ListCheckpoints(ctx)
+}
+
+// RestoreWorkspaceCheckpoint implements executor.WorkspaceCheckpointer.
+func (a *lifecycleAdapter) RestoreWorkspaceCheckpoint(ctx context.Context, sessionID, checkpointID string, discard []string) (*client.WorkspaceCheckpoint, error) {
+	agentClient, err := a.agentctlForSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return agentClient.RestoreCheckpoint(ctx, checkpointID, discard)
+}
+
+func (a *lifecycleAdapter) agentctlForSession(sessionID string) (*client.Client, error) {
+	execution, ok := a.mgr.GetExecutionBySessionID(sessionID)
+	if !ok {
+		return nil, executor.ErrExecutionNotFound
+	}
+	agentClient := execution.GetAgentCtlClient()
+	if agentClient == nil {
+		return nil, executor.ErrExecutionNotFound
+	}
+	return agentClient, nil
+}
+
 // WaitForAgentctlReady waits for the agentctl HTTP server to be ready for a session.
 func (a *lifecycleAdapter) WaitForAgentctlReady(ctx context.Context, sessionID string) error {
 	return a.mgr.WaitForAgentctlReadyForSession(ctx, sessionID)
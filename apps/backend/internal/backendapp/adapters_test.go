@@ -521,6 +521,95 @@ func TestResolveForReviewRedetectsStoredMasterAfterClonePath(t *testing.T) {
 	}
 }
 
+func TestLookupForReviewNeverClonesOrWrites(t *testing.T) {
+	harness := newBootStateTestHarness(t)
+	ctx := context.Background()
+	workspace, err := harness.taskSvc.CreateWorkspace(ctx, &taskservice.CreateWorkspaceRequest{
+		Name: "Workspace",
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	basePath := canonicalTempDir(t)
+	adapter := &repositoryResolverAdapter{
+		cloner: repoclone.NewCloner(
+			repoclone.Config{BasePath: basePath}, repoclone.ProtocolHTTPS, "", newTestLogger(),
+		),
+		taskSvc: harness.taskSvc,
+		logger:  newTestLogger(),
+	}
+
+	repoID, _, err := adapter.LookupForReview(ctx, workspace.ID, "github", "owner", "repo", "")
+	if err != nil {
+		t.Fatalf("LookupForReview: %v", err)
+	}
+	if repoID != "" {
+		t.Fatalf("repository ID = %q, want none for an uncloned repo", repoID)
+	}
+	repos, err := harness.taskSvc.ListRepositories(ctx, workspace.ID)
+	if err != nil {
+		t.Fatalf("ListRepositories: %v", err)
+	}
+	if len(repos) != 0 {
+		t.Fatalf("lookup created repositories: %+v", repos)
+	}
+	if entries, _ := os.ReadDir(basePath); len(entries) != 0 {
+		t.Fatalf("lookup wrote to the clone directory: %v", entries)
+	}
+
+	// A stored master that the clone reports as main is re-detected, as
+	// ResolveForReview does, but not persisted.
+	repoPath := t.TempDir()
+	gitDir := filepath.Join(repoPath, ".git")
+	if err := os.MkdirAll(filepath.Join(gitDir, "refs", "remotes", "origin"), 0o755); err != nil {
+		t.Fatalf("mkdir origin refs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature/x\n"), 0o644); err != nil {
+		t.Fatalf("write HEAD: %v", err)
+	}
+	if err := os.WriteFile(
+		filepath.Join(gitDir, "refs", "remotes", "origin", "HEAD"),
+		[]byte("ref: refs/remotes/origin/master\n"),
+		0o644,
+	); err != nil {
+		t.Fatalf("write origin HEAD: %v", err)
+	}
+	for _, branch := range []string{"main", "master"} {
+		refPath := filepath.Join(gitDir, "refs", "remotes", "origin", branch)
+		if err := os.WriteFile(refPath, []byte("0000000\n"), 0o644); err != nil {
+			t.Fatalf("write %s ref: %v", branch, err)
+		}
+	}
+	repo, err := harness.taskSvc.CreateRepository(ctx, &taskservice.CreateRepositoryRequest{
+		WorkspaceID:   workspace.ID,
+		Name:          "owner/repo",
+		SourceType:    "provider",
+		LocalPath:     repoPath,
+		Provider:      "github",
+		ProviderHost:  "https://github.com",
+		ProviderOwner: "owner",
+		ProviderName:  "repo",
+		DefaultBranch: "master",
+	})
+	if err != nil {
+		t.Fatalf("CreateRepository: %v", err)
+	}
+	repoID, baseBranch, err := adapter.LookupForReview(ctx, workspace.ID, "github", "owner", "repo", "")
+	if err != nil {
+		t.Fatalf("LookupForReview: %v", err)
+	}
+	if repoID != repo.ID || baseBranch != "main" {
+		t.Fatalf("lookup = %q/%q, want %q/main", repoID, baseBranch, repo.ID)
+	}
+	stored, err := harness.taskSvc.GetRepository(ctx, repo.ID)
+	if err != nil {
+		t.Fatalf("GetRepository: %v", err)
+	}
+	if stored.DefaultBranch != "master" {
+		t.Fatalf("lookup persisted default_branch %q", stored.DefaultBranch)
+	}
+}
+
 type staticRepoCloneCredential struct{}
 
 func (staticRepoCloneCredential) ResolveGitCredential(
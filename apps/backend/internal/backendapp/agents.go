@@ -18,11 +18,28 @@ import (
 	"github.com/kandev/kandev/internal/agentctl/server/process"
 	"github.com/kandev/kandev/internal/common/config"
 	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/egress"
+	"github.com/kandev/kandev/internal/events"
 	"github.com/kandev/kandev/internal/events/bus"
 	"github.com/kandev/kandev/internal/secrets"
 	"github.com/kandev/kandev/internal/task/models"
 )
 
+// provideEgressProxy creates the proxy that enforces executor profiles'
+// egress policies. Denied requests are published for the orchestrator to
+// post into the task's session.
+func provideEgressProxy(cfg *config.Config, log *logger.Logger, eventBus bus.EventBus) *egress.Proxy {
+	return egress.NewProxy(cfg.EgressProxy.ListenAddress, log, func(denial egress.Denial) {
+		event := bus.NewEvent(events.ExecutorEgressDenied, "egress-proxy", denial)
+		if err := eventBus.Publish(context.Background(), events.ExecutorEgressDenied, event); err != nil {
+			log.Warn("failed to publish egress denial",
+				zap.String("session_id", denial.SessionID),
+				zap.String("host", denial.Host),
+				zap.Error(err))
+		}
+	})
+}
+
 func provideLifecycleManager(
 	ctx context.Context,
 	cfg *config.Config,
@@ -34,6 +51,7 @@ func provideLifecycleManager(
 	baseBranchProvider lifecycle.BaseBranchProvider,
 	comparisonTargetProvider lifecycle.ComparisonTargetProvider,
 	managedRuntimeSelections managedruntime.SelectionReader,
+	egressProxy *egress.Proxy,
 ) (*lifecycle.Manager, error) {
 	log.Info("Initializing Agent Manager...")
 
@@ -67,10 +85,28 @@ func provideLifecycleManager(
 	// Register Docker runtime if enabled (client is created lazily on first use)
 	if cfg.Docker.Enabled {
 		dockerExec := lifecycle.NewDockerExecutor(cfg.Docker, cfg.ResolvedHomeDir(), log)
+		dockerExec.SetEgressProxy(egressProxy)
 		executorRegistry.Register(dockerExec)
 		log.Info("Docker runtime registered (lazy initialization)")
 	}
 
+	// Register Podman runtime if enabled (client is created lazily on first use)
+	if cfg.Podman.Enabled {
+		podmanExec := lifecycle.NewPodmanExecutor(cfg.Podman, cfg.ResolvedHomeDir(), log)
+		podmanExec.SetEgressProxy(egressProxy)
+		executorRegistry.Register(podmanExec)
+		log.Info("Podman runtime registered (lazy initialization)",
+			zap.String("host", cfg.Podman.Host))
+	}
+
+	// Register Kubernetes runtime if enabled (client is created lazily on first use)
+	if cfg.Kubernetes.Enabled {
+		executorRegistry.Register(lifecycle.NewKubernetesExecutor(cfg.Kubernetes, log))
+		log.Info("Kubernetes runtime registered (lazy initialization)",
+			zap.String("namespace", cfg.Kubernetes.Namespace),
+			zap.String("connect_mode", cfg.Kubernetes.ConnectMode))
+	}
+
 	// Register Remote Docker runtime (always available, instances are created lazily per host)
 	remoteDockerExec := lifecycle.NewRemoteDockerExecutor(log)
 	executorRegistry.Register(remoteDockerExec)
@@ -123,6 +159,8 @@ func provideLifecycleManager(
 	preparerRegistry.Register(models.ExecutorTypeLocalDocker, lifecycle.NewDockerPreparer(log))
 	preparerRegistry.Register(models.ExecutorTypeSprites, lifecycle.NewSpritesPreparer(log))
 	preparerRegistry.Register(models.ExecutorTypeSSH, lifecycle.NewSSHPreparer(log))
+	preparerRegistry.Register(models.ExecutorTypeKubernetes, lifecycle.NewKubernetesPreparer(log))
+	preparerRegistry.Register(models.ExecutorTypePodman, lifecycle.NewPodmanPreparer(log))
 	lifecycleMgr.SetPreparerRegistry(preparerRegistry)
 	lifecycleMgr.SetSecretStore(secretStore)
 	if err := lifecycleMgr.SetAgentctlStartupConfig(cfg.ManagedAgentctlStartupConfig()); err != nil {
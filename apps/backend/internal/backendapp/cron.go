@@ -2,7 +2,9 @@ package backendapp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,11 +16,12 @@ import (
 	officeroutines "github.com/kandev/kandev/internal/office/routines"
 	schedulercron "github.com/kandev/kandev/internal/scheduler/cron"
 	tasksqlite "github.com/kandev/kandev/internal/task/repository/sqlite"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
 	workflowrepo "github.com/kandev/kandev/internal/workflow/repository"
 )
 
 // startCronScheduler builds and starts the Phase 5 shared cron loop.
-// All handlers (heartbeat, budget, routines, Office recovery) ride a single
+// All handlers (heartbeat, budget, step timeouts, routines, Office recovery) ride a single
 // goroutine so the backend has one cron driver for all
 // task-model-unification timers.
 //
@@ -36,6 +39,7 @@ func startCronScheduler(
 ) *schedulercron.Loop {
 	heartbeat := buildHeartbeatHandler(repos, dispatcher, log)
 	budget := buildBudgetHandler(repos, dispatcher, log)
+	timeouts := buildStepTimeoutHandler(repos, dispatcher, log)
 	// Only wire a routines ticker when the office routines service exists.
 	// Assigning a nil *RoutineService straight into the RoutineTicker
 	// interface would produce a non-nil typed-nil interface and panic on
@@ -46,7 +50,7 @@ func startCronScheduler(
 	}
 	routines := schedulercron.NewRoutinesHandler(routineTicker, nil, log)
 	loop := schedulercron.NewLoop(schedulercron.DefaultTickInterval, log,
-		heartbeat, budget, routines, officeRecovery)
+		heartbeat, budget, timeouts, routines, officeRecovery)
 	loop.Start(ctx)
 	log.Info("phase 5 cron loop started",
 		zap.Duration("interval", schedulercron.DefaultTickInterval))
@@ -82,6 +86,27 @@ func buildBudgetHandler(
 	)
 }
 
+func buildStepTimeoutHandler(
+	repos *Repositories,
+	dispatcher *officeenginedispatcher.Dispatcher,
+	log *logger.Logger,
+) *schedulercron.StepTimeoutHandler {
+	// Keep a nil *Dispatcher out of the interface so the handler sees an
+	// unwired dispatcher and stays idle.
+	var engineDispatcher schedulercron.HeartbeatEngineDispatcher
+	if dispatcher != nil {
+		engineDispatcher = dispatcher
+	}
+	return schedulercron.NewStepTimeoutHandler(
+		&stepTimeoutStepLister{wf: repos.Workflow},
+		&stepTimeoutTaskLister{tasks: repos.Task},
+		&stepTimerStore{tasks: repos.Task},
+		engineDispatcher,
+		nil,
+		log,
+	)
+}
+
 // heartbeatStepLister adapts the workflow repository to
 // cron.HeartbeatStepLister. It scans every step's events JSON for the
 // on_heartbeat key — Phase 6 tightens this with a SQL LIKE pre-filter
@@ -144,6 +169,102 @@ func (l *heartbeatTaskLister) ListActiveTasksAtStep(
 	return out, nil
 }
 
+// stepTimeoutStepLister adapts the workflow repository to
+// cron.StepTimeoutStepLister by decoding each step's events JSON.
+type stepTimeoutStepLister struct {
+	wf *workflowrepo.Repository
+}
+
+func (l *stepTimeoutStepLister) ListTimeoutSteps(ctx context.Context) ([]schedulercron.StepTimeoutInfo, error) {
+	if l.wf == nil {
+		return nil, nil
+	}
+	rows, err := l.wf.ListAllStepEventsJSON(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list workflow step events: %w", err)
+	}
+	var out []schedulercron.StepTimeoutInfo
+	for _, r := range rows {
+		if !strings.Contains(r.EventsJSON, `"on_timeout"`) {
+			continue
+		}
+		var events wfmodels.StepEvents
+		if err := json.Unmarshal([]byte(r.EventsJSON), &events); err != nil {
+			continue
+		}
+		if !events.Timeout.Enabled() || len(events.OnTimeout) == 0 {
+			continue
+		}
+		out = append(out, schedulercron.StepTimeoutInfo{
+			StepID:      r.StepID,
+			WorkflowID:  r.WorkflowID,
+			WallClock:   time.Duration(events.Timeout.AfterMinutes) * time.Minute,
+			AgentActive: time.Duration(events.Timeout.ActiveAfterMinutes) * time.Minute,
+		})
+	}
+	return out, nil
+}
+
+// stepTimeoutTaskLister adapts the task repository to
+// cron.StepTimeoutTaskLister.
+type stepTimeoutTaskLister struct {
+	tasks *tasksqlite.Repository
+}
+
+func (l *stepTimeoutTaskLister) ListTaskIDsAtStep(ctx context.Context, stepID string) ([]string, error) {
+	if l.tasks == nil {
+		return nil, nil
+	}
+	tasks, err := l.tasks.ListTasksByWorkflowStep(ctx, stepID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// stepTimerStore adapts the task repository's task_step_timers and
+// task_step_transitions accessors to cron.StepTimerStore.
+type stepTimerStore struct {
+	tasks *tasksqlite.Repository
+}
+
+func (s *stepTimerStore) StepEnteredAt(ctx context.Context, taskID, stepID string) (time.Time, bool, error) {
+	return s.tasks.LatestStepEntry(ctx, taskID, stepID)
+}
+
+func (s *stepTimerStore) LoadTimer(ctx context.Context, taskID string) (*schedulercron.StepTimerState, error) {
+	timer, err := s.tasks.GetStepTimer(ctx, taskID)
+	if err != nil || timer == nil {
+		return nil, err
+	}
+	return &schedulercron.StepTimerState{
+		StepID:           timer.StepID,
+		EnteredAt:        timer.EnteredAt,
+		WallClockFired:   timer.WallClockFiredAt != nil,
+		AgentActiveFired: timer.AgentActiveFiredAt != nil,
+	}, nil
+}
+
+func (s *stepTimerStore) ResetTimer(ctx context.Context, taskID, stepID string, enteredAt time.Time) error {
+	return s.tasks.ResetStepTimer(ctx, taskID, stepID, enteredAt)
+}
+
+func (s *stepTimerStore) ClaimTimeout(ctx context.Context, taskID, stepID, kind string, at time.Time) (bool, error) {
+	return s.tasks.ClaimStepTimeout(ctx, taskID, stepID, kind, at)
+}
+
+func (s *stepTimerStore) ReleaseTimeout(ctx context.Context, taskID, stepID, kind string) error {
+	return s.tasks.ReleaseStepTimeout(ctx, taskID, stepID, kind)
+}
+
+func (s *stepTimerStore) AgentActiveTime(ctx context.Context, taskID string, since, until time.Time) (time.Duration, error) {
+	return s.tasks.SumAgentActiveTime(ctx, taskID, since, until)
+}
+
 // heartbeatAgentRuntime adapts the office agent + runtime repos to
 // cron.HeartbeatAgentRuntime. The gate combines two checks:
 //
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
 	agentcontroller "github.com/kandev/kandev/internal/agent/controller"
@@ -32,6 +33,7 @@ import (
 	terminalrepo "github.com/kandev/kandev/internal/terminal/repository"
 	terminalservice "github.com/kandev/kandev/internal/terminal/service"
 	userservice "github.com/kandev/kandev/internal/user/service"
+	"github.com/kandev/kandev/internal/workflow/engine"
 	v1 "github.com/kandev/kandev/pkg/api/v1"
 	ws "github.com/kandev/kandev/pkg/websocket"
 )
@@ -53,6 +55,23 @@ func (a *scriptServiceAdapter) GetRepositoryScript(ctx context.Context, id strin
 	}, nil
 }
 
+// workflowNotifierAdapter routes the workflow engine's notify action to the
+// notification service.
+type workflowNotifierAdapter struct {
+	svc *notificationservice.Service
+}
+
+func (a workflowNotifierAdapter) Notify(ctx context.Context, n engine.Notification) error {
+	// Triggers dispatched without an operation id still need a unique
+	// occurrence, otherwise the service drops them as duplicates.
+	occurrenceID := n.OperationID
+	if occurrenceID == "" {
+		occurrenceID = uuid.New().String()
+	}
+	a.svc.HandleWorkflowNotification(ctx, n.TaskID, n.SessionID, occurrenceID, n.Title, n.Message)
+	return nil
+}
+
 // hubModeQuerierAdapter converts the hub's SessionMode enum to lifecycle's
 // WorkspacePollMode (same string values) so lifecycle doesn't import websocket.
 type hubModeQuerierAdapter struct {
@@ -374,6 +393,9 @@ func provideGateway(
 
 	notificationSvc := notificationservice.NewService(notificationRepo, taskRepo, gateway.Hub, log)
 	notificationCtrl := notificationcontroller.NewController(notificationSvc)
+	if orchestratorSvc != nil {
+		orchestratorSvc.SetEngineNotifier(workflowNotifierAdapter{svc: notificationSvc})
+	}
 	if eventBus != nil {
 		_, err = eventBus.Subscribe(events.TurnCompleted, func(ctx context.Context, event *bus.Event) error {
 			data, ok := event.Data.(map[string]interface{})
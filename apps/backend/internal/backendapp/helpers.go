@@ -1582,6 +1582,7 @@ func registerMCPAndDebugRoutes(
 	mcpHandlers.SetClarificationInputPauser(p.orchestratorSvc)
 	mcpHandlers.SetPromptReferenceResolver(p.services.Prompts)
 	mcpHandlers.SetTaskStopper(p.orchestratorSvc)
+	mcpHandlers.SetFanOutSelector(p.orchestratorSvc)
 	mcpHandlers.SetAgentPermissionService(p.orchestratorSvc)
 	mcpHandlers.SetTaskTitleBranchRenamer(p.orchestratorSvc)
 	mcpHandlers.SetUserSettingsProvider(p.services.User)
@@ -1620,6 +1621,10 @@ func registerMCPAndDebugRoutes(
 	mcpHandlers.SetReviewService(reviewParts.service)
 	mcpHandlers.SetReviewRunner(reviewParts.runner)
 	p.orchestratorSvc.SetReviewRunner(reviewParts.runner)
+	// run_check quality gates execute in the session's agentctl workspace.
+	if p.lifecycleMgr != nil {
+		p.orchestratorSvc.SetWorkflowCheckRunner(orchestrator.NewWorkflowCheckRunner(p.lifecycleMgr))
+	}
 	reviewParts.runner.Start(context.Background())
 	if p.addCleanup != nil {
 		p.addCleanup(func() error {
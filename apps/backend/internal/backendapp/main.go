@@ -487,6 +487,8 @@ func startAgentInfrastructure(
 		restoreCleanups = append(restoreCleanups, stop)
 	}
 	userSecretStore := secrets.NewUserVisibleStore(repos.Secrets)
+	egressProxy := provideEgressProxy(cfg, log, eventBus)
+	addRuntimeCleanup(egressProxy.Close)
 	// ============================================
 	// AGENT MANAGER
 	// ============================================
@@ -501,6 +503,7 @@ func startAgentInfrastructure(
 		services.Task.TaskBaseBranches,
 		services.Task.TaskComparisonTargets,
 		services.ManagedRuntimeSelections,
+		egressProxy,
 	)
 	if err != nil {
 		log.Error("Failed to initialize agent manager", zap.Error(err))
@@ -601,6 +604,7 @@ func startAgentInfrastructure(
 		orchestratorSvc.LaunchDynamicRouteAction,
 	))
 	orchestratorSvc.SetProfileExecutionResolver(services.DynamicProfileResolver)
+	enableLaunchAdmission(orchestratorSvc, cfg.Admission, lifecycleMgr)
 
 	// Wire the soft-deleted-profile pre-flight into the watcher dispatch.
 	// Orphan watchers (their agent profile was soft-deleted by the
@@ -642,6 +646,7 @@ func startAgentInfrastructure(
 	// tasks via the task service directly.
 	if services.Automation != nil {
 		orchestratorSvc.SetAutomationService(services.Automation.Service)
+		services.Automation.Service.SetTaskPreviewer(orchestratorSvc)
 	}
 
 	// Wire GitHub service into orchestrator for PR auto-detection on push
@@ -806,6 +811,10 @@ func startGatewayAndServe(
 		return false
 	}
 
+	if services.Automation != nil {
+		services.Automation.CompletionRunner.SetPullRequestOpener(&automationPROpenerAdapter{dispatcher: gateway.Dispatcher})
+	}
+
 	gateways.RegisterSessionStreamNotifications(ctx, eventBus, gateway.Hub, log)
 	gateway.Hub.SetSessionDataProvider(buildSessionDataProvider(repos.Task, lifecycleMgr, orchestratorSvc, log))
 	gateway.Hub.SetSessionGitDataProvider(buildSessionGitDataProvider(repos.Task, lifecycleMgr, log))
@@ -1556,13 +1565,29 @@ func wireWorkflowEngineForOffice(
 		return nil
 	}
 	// Build the dispatcher. The session resolver is the task repo,
-	// which exposes GetActiveTaskSessionByTaskID.
-	dispatcher := officeenginedispatcher.New(eng, repos.Task, log)
+	// which exposes GetActiveTaskSessionByTaskID. Triggers go through the
+	// orchestrator so kanban tasks get the full step lifecycle.
+	dispatcher := officeenginedispatcher.New(
+		&lifecycleEngineHandle{Engine: eng, orchestrator: orchestratorSvc}, repos.Task, log)
 	officeSvc.SetWorkflowEngineDispatcher(dispatcher)
 	log.Info("workflow engine dispatcher wired for office")
 	return dispatcher
 }
 
+// lifecycleEngineHandle is the engine the office dispatcher drives.
+// HandleTrigger goes through the orchestrator, which keeps the engine's
+// direct path for office tasks and applies kanban transitions through the
+// same on_exit/on_enter lifecycle as on_turn_complete. The decision and
+// role entry points are the engine's own.
+type lifecycleEngineHandle struct {
+	*workflowengine.Engine
+	orchestrator *orchestrator.Service
+}
+
+func (h *lifecycleEngineHandle) HandleTrigger(ctx context.Context, in workflowengine.HandleInput) (workflowengine.HandleResult, error) {
+	return h.orchestrator.HandleWorkflowEventTrigger(ctx, in)
+}
+
 // runsServiceEngineAdapter bridges runs/service.Service.QueueRun (which
 // takes runs/service.QueueRunRequest) to engine.RunQueueAdapter (which
 // takes engine.QueueRunRequest). The two structs have identical fields
@@ -5,6 +5,8 @@ import (
 
 	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
 	"github.com/kandev/kandev/internal/agentruntime"
+	"github.com/kandev/kandev/internal/common/config"
+	"github.com/kandev/kandev/internal/orchestrator"
 	"github.com/kandev/kandev/internal/system/metrics"
 )
 
@@ -44,9 +46,20 @@ func (p lifecycleMetricProvider) MetricExecutions() []metrics.ExecutionSource {
 
 func shouldCollectExecutionMetrics(runtime agentruntime.Runtime) bool {
 	switch runtime {
-	case agentruntime.RuntimeDocker, agentruntime.RuntimeRemoteDocker, agentruntime.RuntimeSprites, agentruntime.RuntimeSSH:
+	case agentruntime.RuntimeDocker, agentruntime.RuntimeRemoteDocker, agentruntime.RuntimeSprites, agentruntime.RuntimeSSH,
+		agentruntime.RuntimeKubernetes, agentruntime.RuntimePodman:
 		return true
 	default:
 		return false
 	}
 }
+
+// enableLaunchAdmission turns on resource-aware launch admission when the
+// config sets a limit. It samples the host with its own collector and remote
+// executors through the same execution list the metrics panel uses.
+func enableLaunchAdmission(orch *orchestrator.Service, cfg config.AdmissionConfig, manager metricExecutionLister) {
+	orch.EnableLaunchAdmission(metrics.PressureThresholds{
+		MemoryPercent: cfg.MaxMemoryPercent,
+		LoadPerCore:   cfg.MaxLoadPerCore,
+	}, metrics.NewCollector(), lifecycleMetricProvider{manager: manager})
+}
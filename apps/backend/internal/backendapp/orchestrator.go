@@ -429,6 +429,21 @@ func (a *orchestratorWorkflowStepGetterAdapter) GetPreviousStepByPosition(ctx co
 	return a.svc.GetPreviousStepByPosition(ctx, workflowID, currentPosition)
 }
 
+// GetTaskStep resolves a step from the task's pinned workflow revision.
+func (a *orchestratorWorkflowStepGetterAdapter) GetTaskStep(ctx context.Context, taskID, stepID string) (*wfmodels.WorkflowStep, error) {
+	return a.svc.GetTaskStep(ctx, taskID, stepID)
+}
+
+// GetTaskNextStepByPosition resolves the next step from the task's pinned workflow revision.
+func (a *orchestratorWorkflowStepGetterAdapter) GetTaskNextStepByPosition(ctx context.Context, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error) {
+	return a.svc.GetTaskNextStepByPosition(ctx, taskID, workflowID, currentPosition)
+}
+
+// GetTaskPreviousStepByPosition resolves the previous step from the task's pinned workflow revision.
+func (a *orchestratorWorkflowStepGetterAdapter) GetTaskPreviousStepByPosition(ctx context.Context, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error) {
+	return a.svc.GetTaskPreviousStepByPosition(ctx, taskID, workflowID, currentPosition)
+}
+
 // GetWorkflowMeta implements orchestrator.WorkflowStepGetter.
 func (a *orchestratorWorkflowStepGetterAdapter) GetWorkflowMeta(ctx context.Context, workflowID string) (orchestrator.WorkflowMeta, error) {
 	meta, err := a.svc.GetWorkflowMeta(ctx, workflowID)
@@ -1027,6 +1042,28 @@ func (a *repositoryResolverAdapter) ResolveForReview(
 	return repo.ID, baseBranch, nil
 }
 
+// LookupForReview implements orchestrator.RepositoryLookup. It resolves a
+// repository the way ResolveForReview does when the workspace already has a
+// clone of it, and reports an empty repository ID otherwise. Nothing is
+// cloned or written, including a detected default branch.
+func (a *repositoryResolverAdapter) LookupForReview(
+	ctx context.Context, workspaceID, provider, owner, name, defaultBranch string,
+) (string, string, error) {
+	hostname, err := defaultProviderHostname(provider)
+	if err != nil {
+		return "", "", err
+	}
+	existing, err := a.taskSvc.GetRepositoryByProviderInfo(ctx, workspaceID, provider, "https://"+hostname, owner, name)
+	if err != nil {
+		return "", "", fmt.Errorf("lookup repository by provider info: %w", err)
+	}
+	if existing == nil || existing.LocalPath == "" {
+		return "", "", nil
+	}
+	baseBranch, _ := reviewBaseBranch(existing, existing.LocalPath, defaultBranch)
+	return existing.ID, baseBranch, nil
+}
+
 func defaultProviderHostname(provider string) (string, error) {
 	switch strings.ToLower(provider) {
 	case "gitlab":
@@ -1044,31 +1081,33 @@ func (a *repositoryResolverAdapter) resolveReviewBaseBranch(
 	localPath string,
 	requestedBranch string,
 ) string {
+	branch, detected := reviewBaseBranch(repo, localPath, requestedBranch)
+	if detected {
+		// Persist what the local clone reports for future lookups.
+		return a.persistDetectedDefaultBranch(ctx, repo, branch)
+	}
+	return branch
+}
+
+// reviewBaseBranch picks the base branch of a review task: the requested
+// branch, else the repository's stored default, else the local clone's.
+// detected reports a branch read from the clone that differs from the
+// stored one, which the caller may persist.
+func reviewBaseBranch(repo *taskmodels.Repository, localPath, requestedBranch string) (branch string, detected bool) {
 	if requestedBranch != "" {
-		return requestedBranch
+		return requestedBranch, false
 	}
 	stored := strings.TrimSpace(repo.DefaultBranch)
 	if stored == defaultMasterBranch && localPath != "" {
 		if detected := detectGitDefaultBranch(localPath); detected == defaultMainBranch {
-			return a.persistDetectedDefaultBranch(ctx, repo, detected)
+			return detected, true
 		}
 	}
 	if stored != "" {
-		return stored
-	}
-	return a.detectAndPersistDefaultBranch(ctx, repo, localPath)
-}
-
-// detectAndPersistDefaultBranch reads the default branch from the local clone
-// and persists it to the repository record for future lookups.
-func (a *repositoryResolverAdapter) detectAndPersistDefaultBranch(
-	ctx context.Context, repo *taskmodels.Repository, localPath string,
-) string {
-	detected := detectGitDefaultBranch(localPath)
-	if detected == "" {
-		return ""
+		return stored, false
 	}
-	return a.persistDetectedDefaultBranch(ctx, repo, detected)
+	branch = detectGitDefaultBranch(localPath)
+	return branch, branch != ""
 }
 
 func (a *repositoryResolverAdapter) persistDetectedDefaultBranch(
@@ -170,9 +170,16 @@ func provideServices(cfg *config.Config, log *logger.Logger, repos *Repositories
 
 	// Wire start step resolver to task service for CreateTask
 	taskSvc.SetStartStepResolver(&startStepResolverAdapter{svc: workflowSvc})
+	// Pin tasks to the workflow revision current when they enter a workflow.
+	taskSvc.SetWorkflowRevisionPinner(workflowSvc)
+	// Revision migrations move tasks, which the task repository owns.
+	workflowSvc.SetRevisionMigrationApplier(repos.Task)
 	// Session history is owned by workflow service, but access is owned by the
 	// task service. Keep the authorization check at the service boundary.
 	workflowSvc.SetSessionAccessChecker(taskSvc.AuthorizeSessionAccess)
+	// run_check gate results are recorded by the task repository and
+	// served with the session's step history.
+	workflowSvc.SetStepCheckResultLister(repos.Task)
 
 	// Wire the ADR 0015 audit-trail writer for manual step transitions.
 	// workflowSvc.CreateStepTransition already matches
@@ -265,7 +272,16 @@ func provideServices(cfg *config.Config, log *logger.Logger, repos *Repositories
 		automationWorkflowLocator := &automationWorkflowLocatorAdapter{svc: taskSvc, workflows: workflowSvc}
 		automationComponents.Service.SetWorkflowLocator(automationWorkflowLocator)
 		automationComponents.Service.SetWorkflowStepLocator(automationWorkflowLocator)
-		automationComponents.Service.SetTaskOriginLookup(&automationTaskOriginLookupAdapter{svc: taskSvc, log: log})
+		automationTaskOrigin := &automationTaskOriginLookupAdapter{svc: taskSvc, log: log}
+		automationComponents.Service.SetTaskOriginLookup(automationTaskOrigin)
+		automationComponents.Service.SetSourceTaskLookup(automationTaskOrigin)
+		if githubSvc != nil {
+			automationComponents.CompletionRunner.SetPRCommenter(githubSvc)
+		}
+		if gitlabSvc != nil {
+			automationComponents.GitLabEvaluator.SetClientSource(gitlabSvc)
+			automationComponents.CompletionRunner.SetMRCommenter(gitlabSvc)
+		}
 		// Profile deletion disables the automations bound to a profile before
 		// the row goes, but nothing ever checked that the binding pointed at a
 		// real profile in the first place — so a create or rebind naming an id
@@ -280,6 +296,7 @@ func provideServices(cfg *config.Config, log *logger.Logger, repos *Repositories
 		automationComponents.Service.SetExportExecutorProfileLookup(repos.Task)
 		automationComponents.Service.SetExportWorkflowLookup(repos.Task)
 		automationComponents.Service.SetExportWorkflowStepLookup(repos.Workflow)
+		automationComponents.Service.SetExportWorkflowRevisionLookup(repos.Workflow)
 		automationComponents.Service.SetExportRepositoryLookup(repos.Task)
 		automationComponents.Service.SetExportWorkspaceLookup(&automationExportWorkspaceLookupAdapter{svc: taskSvc})
 	}
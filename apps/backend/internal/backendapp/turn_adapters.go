@@ -3,11 +3,14 @@ package backendapp
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	agenthandlers "github.com/kandev/kandev/internal/agent/handlers"
 	settingsstore "github.com/kandev/kandev/internal/agent/settings/store"
 	"github.com/kandev/kandev/internal/automation"
 	"github.com/kandev/kandev/internal/azuredevops"
@@ -17,6 +20,7 @@ import (
 	taskrepo "github.com/kandev/kandev/internal/task/repository/sqlite"
 	taskservice "github.com/kandev/kandev/internal/task/service"
 	workflowservice "github.com/kandev/kandev/internal/workflow/service"
+	ws "github.com/kandev/kandev/pkg/websocket"
 )
 
 // turnServiceAdapter adapts the task service to the orchestrator.TurnService interface.
@@ -83,6 +87,16 @@ func (a *turnServiceAdapter) AbandonOpenTurns(ctx context.Context, sessionID str
 	return a.svc.AbandonOpenTurns(ctx, sessionID)
 }
 
+// ListTurnsBySession implements orchestrator.TurnRewinder.
+func (a *turnServiceAdapter) ListTurnsBySession(ctx context.Context, sessionID string) ([]*models.Turn, error) {
+	return a.svc.ListTurnsBySession(ctx, sessionID)
+}
+
+// MarkTurnsRewound implements orchestrator.TurnRewinder.
+func (a *turnServiceAdapter) MarkTurnsRewound(ctx context.Context, sessionID string, turnIDs []string) error {
+	return a.svc.MarkTurnsRewound(ctx, sessionID, turnIDs)
+}
+
 func newTurnServiceAdapter(svc *taskservice.Service) *turnServiceAdapter {
 	return &turnServiceAdapter{svc: svc}
 }
@@ -280,6 +294,32 @@ func (a *automationTaskOriginLookupAdapter) TaskWorkspaceAndAutomationOrigin(ctx
 	return task.WorkspaceID, task.Origin == models.TaskOriginAutomationRun, true
 }
 
+// SourceTask satisfies automation.SourceTaskLookup for the task-event
+// triggers, over the same task read as TaskWorkspaceAndAutomationOrigin.
+func (a *automationTaskOriginLookupAdapter) SourceTask(ctx context.Context, taskID string) (automation.SourceTask, bool) {
+	task, err := a.svc.GetTask(ctx, taskID)
+	if err != nil {
+		a.log.Warn("source task lookup failed", zap.String("task_id", taskID), zap.Error(err))
+		return automation.SourceTask{}, false
+	}
+	if task == nil {
+		return automation.SourceTask{}, false
+	}
+	return automation.SourceTask{
+		ID:              task.ID,
+		Identifier:      task.Identifier,
+		WorkspaceID:     task.WorkspaceID,
+		WorkflowID:      task.WorkflowID,
+		WorkflowStepID:  task.WorkflowStepID,
+		ParentID:        task.ParentID,
+		Title:           task.Title,
+		Description:     task.Description,
+		State:           string(task.State),
+		Priority:        task.Priority,
+		IsAutomationRun: task.Origin == models.TaskOriginAutomationRun,
+	}, true
+}
+
 // automationExportWorkspaceLookupAdapter satisfies automation.ExportWorkspaceLookup
 // over the task service's GetWorkspace, which already returns
 // repoerrors.ErrWorkspaceNotFound (wrapped) both for a missing row and for a
@@ -390,3 +430,49 @@ func (a *repositoryLookupAdapter) RepositoryExists(ctx context.Context, workspac
 	}
 	return false, nil
 }
+
+// automationPROpenerAdapter satisfies automation.PullRequestOpener by
+// dispatching worktree.create_pr in-process, so an automation's open_pr
+// action pushes and associates the PR exactly as the UI's button does.
+type automationPROpenerAdapter struct {
+	dispatcher interface {
+		Dispatch(ctx context.Context, msg *ws.Message) (*ws.Message, error)
+	}
+}
+
+func (a *automationPROpenerAdapter) OpenPullRequest(
+	ctx context.Context, sessionID, title, body, baseBranch string, draft bool,
+) (string, error) {
+	msg, err := ws.NewRequest(uuid.New().String(), ws.ActionWorktreeCreatePR, agenthandlers.GitCreatePRRequest{
+		SessionID:  sessionID,
+		Title:      title,
+		Body:       body,
+		BaseBranch: baseBranch,
+		Draft:      draft,
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := a.dispatcher.Dispatch(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil {
+		return "", errors.New("create PR: empty response")
+	}
+	if resp.Type == ws.MessageTypeError {
+		return "", fmt.Errorf("create PR: %s", string(resp.Payload))
+	}
+	var result struct {
+		Success bool   `json:"success"`
+		PRURL   string `json:"pr_url"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Payload, &result); err != nil {
+		return "", fmt.Errorf("create PR: %w", err)
+	}
+	if !result.Success {
+		return "", fmt.Errorf("create PR: %s", result.Error)
+	}
+	return result.PRURL, nil
+}
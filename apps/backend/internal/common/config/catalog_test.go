@@ -156,6 +156,7 @@ func auditedStartupEnvironmentInventory() []auditedStartupEnvironment {
 		{envVar: "KANDEV_DEBUG_ACP_MAX_FILE_BYTES", class: "exclusion"},
 		{envVar: "KANDEV_MCP_LOG_FILE", class: "exclusion"},
 		{envVar: "KANDEV_DEBUG_LOG_DIR", class: "exclusion"},
+		{envVar: "KANDEV_ACP_RECORD_DIR", class: "exclusion"},
 		{envVar: "AGENTCTL_AUTO_APPROVE_PERMISSIONS", class: "exclusion"},
 	}
 }
@@ -43,6 +43,10 @@ type Config struct {
 	NATS                   NATSConfig                   `mapstructure:"nats"`
 	Events                 EventsConfig                 `mapstructure:"events"`
 	Docker                 DockerConfig                 `mapstructure:"docker"`
+	Kubernetes             KubernetesConfig             `mapstructure:"kubernetes"`
+	Podman                 PodmanConfig                 `mapstructure:"podman"`
+	EgressProxy            EgressProxyConfig            `mapstructure:"egressProxy"`
+	Admission              AdmissionConfig              `mapstructure:"admission"`
 	Agent                  AgentConfig                  `mapstructure:"agent"`
 	Auth                   AuthConfig                   `mapstructure:"auth"`
 	Logging                LoggingConfig                `mapstructure:"logging"`
@@ -387,6 +391,77 @@ type DockerConfig struct {
 	VolumeBasePath string `mapstructure:"volumeBasePath"`
 }
 
+// KubernetesConfig holds Kubernetes executor configuration. Per-task pod
+// settings (namespace, resources, node selector, service account, workspace
+// volume) come from the executor profile; these are the cluster connection
+// and its defaults.
+type KubernetesConfig struct {
+	// Enabled registers the Kubernetes runtime. Default: false.
+	Enabled bool `mapstructure:"enabled"`
+	// Kubeconfig is the kubeconfig file to connect with. Empty uses
+	// $KUBECONFIG or ~/.kube/config, then the in-cluster service account.
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// Context selects a kubeconfig context. Empty uses current-context.
+	Context string `mapstructure:"context"`
+	// Namespace is where agent pods run unless the profile names another.
+	Namespace string `mapstructure:"namespace"`
+	// ConnectMode is how the backend reaches agentctl in a pod: "proxy"
+	// port-forwards through the API server, "direct" dials the pod IP
+	// (only reachable when the backend itself runs in the cluster).
+	ConnectMode string `mapstructure:"connectMode"`
+	// AgentctlImage is an image carrying /usr/local/bin/agentctl, copied into
+	// agent pods by an init container. Empty expects agentctl in the agent image.
+	AgentctlImage string `mapstructure:"agentctlImage"`
+}
+
+// PodmanConfig holds Podman executor configuration. Podman is driven through
+// its Docker-compatible API socket, so the same container plumbing as the
+// Docker executor applies; these fields cover what differs on rootless hosts.
+type PodmanConfig struct {
+	// Enabled registers the Podman runtime. Default: false.
+	Enabled bool `mapstructure:"enabled"`
+	// Host is the Podman API socket. Default: $CONTAINER_HOST, then the
+	// rootless user socket under $XDG_RUNTIME_DIR, then the rootful socket.
+	Host       string `mapstructure:"host"`
+	APIVersion string `mapstructure:"apiVersion"`
+	// Network is the container network mode. Empty uses Podman's default,
+	// which is pasta or slirp4netns when rootless.
+	Network string `mapstructure:"network"`
+	// UserNS is the user namespace mode ("keep-id", "auto", "host", ...).
+	// Empty keeps Podman's default: rootless containers map root to the
+	// invoking user, so files written to bind mounts stay owned by that
+	// user. Use "keep-id" for images that run as a non-root USER.
+	UserNS string `mapstructure:"userns"`
+	// SELinuxLabel controls relabeling of writable bind mounts: "shared"
+	// (:z), "private" (:Z), "disable" (label=disable on the container) or
+	// "none" to leave labels alone. Default: "shared".
+	SELinuxLabel string `mapstructure:"selinuxLabel"`
+}
+
+// EgressProxyConfig holds settings for the forward proxy that enforces
+// executor profiles' egress policies. The proxy only listens once a
+// container is launched under a policy.
+type EgressProxyConfig struct {
+	// ListenAddress is where the proxy listens. It must be reachable from
+	// containers through the engine's host gateway, so the default binds
+	// every interface; requests without a container's credential are
+	// rejected. Default: "0.0.0.0:39430".
+	ListenAddress string `mapstructure:"listenAddress"`
+}
+
+// AdmissionConfig holds the resource limits above which new agent launches
+// wait in the scheduler until pressure drops. The limits apply to the backend
+// host for local executors and to the executor's own machine for remote
+// ones. Zero disables a limit; both default to zero.
+type AdmissionConfig struct {
+	// MaxMemoryPercent defers launches while memory use is above this
+	// percentage, e.g. 85.
+	MaxMemoryPercent float64 `mapstructure:"maxMemoryPercent"`
+	// MaxLoadPerCore defers launches while the one-minute load average
+	// divided by the CPU count is above this value, e.g. 1.5.
+	MaxLoadPerCore float64 `mapstructure:"maxLoadPerCore"`
+}
+
 // AuthConfig holds authentication configuration. Whether authentication is
 // enforced is controlled by the `features.auth` runtime flag
 // (KANDEV_FEATURES_AUTH), not here — these are only session-mechanics knobs.
@@ -601,6 +676,29 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("docker.defaultNetwork", "kandev-network")
 	v.SetDefault("docker.volumeBasePath", defaultDockerVolumePath())
 
+	// Kubernetes defaults — opt-in, connects through the API server
+	v.SetDefault("kubernetes.enabled", false)
+	v.SetDefault("kubernetes.kubeconfig", "")
+	v.SetDefault("kubernetes.context", "")
+	v.SetDefault("kubernetes.namespace", "default")
+	v.SetDefault("kubernetes.connectMode", "proxy")
+	v.SetDefault("kubernetes.agentctlImage", "")
+
+	// Podman defaults — opt-in, uses the Docker-compatible API socket
+	v.SetDefault("podman.enabled", false)
+	v.SetDefault("podman.host", DefaultPodmanHost())
+	v.SetDefault("podman.apiVersion", "")
+	v.SetDefault("podman.network", "")
+	v.SetDefault("podman.userns", "")
+	v.SetDefault("podman.selinuxLabel", "shared")
+
+	// Egress proxy defaults — listens lazily, only when a profile sets a policy
+	v.SetDefault("egressProxy.listenAddress", "0.0.0.0:39430")
+
+	// Admission defaults — launches are never deferred unless a limit is set
+	v.SetDefault("admission.maxMemoryPercent", 0)
+	v.SetDefault("admission.maxLoadPerCore", 0)
+
 	// Agent defaults (runtime selection is now per-task based on executor type)
 	v.SetDefault("agent.standaloneHost", "localhost")
 	v.SetDefault("agent.standalonePort", ports.AgentCtl)
@@ -659,6 +757,19 @@ func DefaultDockerHost() string {
 	return "unix:///var/run/docker.sock"
 }
 
+// DefaultPodmanHost returns the Podman API socket to connect to. Respects
+// CONTAINER_HOST (Podman's own convention), then prefers the rootless user
+// socket when a user runtime dir exists, falling back to the rootful socket.
+func DefaultPodmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return "unix://" + filepath.Join(runtimeDir, "podman", "podman.sock")
+	}
+	return "unix:///run/podman/podman.sock"
+}
+
 // defaultDockerVolumePath returns the platform-appropriate volume base path.
 func defaultDockerVolumePath() string {
 	if runtime.GOOS == "windows" {
@@ -777,6 +888,17 @@ func loadWithPath(configPath, homeDir string) (*Config, error) {
 	return &cfg, nil
 }
 
+func validateAdmission(cfg AdmissionConfig) []string {
+	var errs []string
+	if cfg.MaxMemoryPercent < 0 || cfg.MaxMemoryPercent > 100 {
+		errs = append(errs, "admission.maxMemoryPercent must be between 0 and 100")
+	}
+	if cfg.MaxLoadPerCore < 0 {
+		errs = append(errs, "admission.maxLoadPerCore must not be negative")
+	}
+	return errs
+}
+
 // validate checks that all required configuration fields are set.
 // In development mode (default), most fields are optional.
 func validate(cfg *Config) error {
@@ -822,6 +944,22 @@ func validate(cfg *Config) error {
 	// Docker validation - optional (agent features disabled if not available)
 	// No validation needed - will gracefully degrade
 
+	if cfg.Kubernetes.Enabled {
+		if mode := cfg.Kubernetes.ConnectMode; mode != "proxy" && mode != "direct" {
+			errs = append(errs, "kubernetes.connectMode must be one of: proxy, direct")
+		}
+	}
+
+	if cfg.Podman.Enabled {
+		switch cfg.Podman.SELinuxLabel {
+		case "shared", "private", "disable", "none":
+		default:
+			errs = append(errs, "podman.selinuxLabel must be one of: shared, private, disable, none")
+		}
+	}
+
+	errs = append(errs, validateAdmission(cfg.Admission)...)
+
 	// Auth validation - generate random secret if not set (dev mode)
 	if cfg.Auth.JWTSecret == "" {
 		cfg.Auth.JWTSecret = generateDevSecret()
@@ -203,6 +203,75 @@ func TestValidate_PostgresSSLMode(t *testing.T) {
 	})
 }
 
+func TestValidate_KubernetesConnectMode(t *testing.T) {
+	for _, mode := range []string{"proxy", "direct"} {
+		cfg := minimalValidConfig()
+		cfg.Kubernetes.Enabled = true
+		cfg.Kubernetes.ConnectMode = mode
+		if err := validate(cfg); err != nil {
+			t.Errorf("connectMode %q rejected: %v", mode, err)
+		}
+	}
+
+	cfg := minimalValidConfig()
+	cfg.Kubernetes.ConnectMode = "tunnel"
+	if err := validate(cfg); err != nil {
+		t.Errorf("disabled kubernetes should ignore connectMode, got %v", err)
+	}
+	cfg.Kubernetes.Enabled = true
+	if err := validate(cfg); err == nil || !strings.Contains(err.Error(), "kubernetes.connectMode") {
+		t.Fatalf("expected connectMode error, got %v", err)
+	}
+}
+
+func TestValidate_PodmanSELinuxLabel(t *testing.T) {
+	for _, label := range []string{"shared", "private", "disable", "none"} {
+		cfg := minimalValidConfig()
+		cfg.Podman.Enabled = true
+		cfg.Podman.SELinuxLabel = label
+		if err := validate(cfg); err != nil {
+			t.Errorf("selinuxLabel %q rejected: %v", label, err)
+		}
+	}
+
+	cfg := minimalValidConfig()
+	cfg.Podman.Enabled = true
+	cfg.Podman.SELinuxLabel = "z"
+	if err := validate(cfg); err == nil || !strings.Contains(err.Error(), "podman.selinuxLabel") {
+		t.Fatalf("expected selinuxLabel error, got %v", err)
+	}
+}
+
+func TestValidate_AdmissionLimits(t *testing.T) {
+	cfg := minimalValidConfig()
+	cfg.Admission = AdmissionConfig{MaxMemoryPercent: 85, MaxLoadPerCore: 1.5}
+	if err := validate(cfg); err != nil {
+		t.Fatalf("valid admission limits rejected: %v", err)
+	}
+
+	cfg.Admission = AdmissionConfig{MaxMemoryPercent: 120, MaxLoadPerCore: -1}
+	err := validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "admission.maxMemoryPercent") || !strings.Contains(err.Error(), "admission.maxLoadPerCore") {
+		t.Fatalf("expected admission errors, got %v", err)
+	}
+}
+
+func TestDefaultPodmanHost(t *testing.T) {
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	if got := DefaultPodmanHost(); got != "unix:///run/user/1000/podman/podman.sock" {
+		t.Errorf("rootless host = %q", got)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if got := DefaultPodmanHost(); got != "unix:///run/podman/podman.sock" {
+		t.Errorf("rootful host = %q", got)
+	}
+	t.Setenv("CONTAINER_HOST", "tcp://podman:8080")
+	if got := DefaultPodmanHost(); got != "tcp://podman:8080" {
+		t.Errorf("CONTAINER_HOST override = %q", got)
+	}
+}
+
 // TestFeatures_ProductionDefaults pins the production policy: new and
 // in-progress features remain off until a deployment explicitly opts in.
 func TestFeatures_ProductionDefaults(t *testing.T) {
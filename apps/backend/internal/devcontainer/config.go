@@ -0,0 +1,195 @@
+// Package devcontainer reads a repository's devcontainer.json and turns it
+// into what the container executors need: an image to pull or build (with
+// features layered on top), extra environment, mounts and forwarded ports,
+// and the lifecycle commands to run before agentctl starts.
+//
+// Only the single-container subset of the spec is supported. Docker Compose
+// based configs, local features and VS Code customizations are ignored or
+// rejected with an error.
+package devcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned by Load when the repository has no devcontainer.json.
+var ErrNotFound = errors.New("devcontainer.json not found")
+
+// defaultConfigPaths are searched in order when no explicit path is given,
+// matching the lookup order of the devcontainer CLI.
+var defaultConfigPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// Config is the subset of devcontainer.json the executors act on.
+type Config struct {
+	Name  string       `json:"name"`
+	Image string       `json:"image"`
+	Build *BuildConfig `json:"build"`
+	// DockerFile and Context are the legacy top-level spellings of
+	// build.dockerfile and build.context.
+	DockerFile string `json:"dockerFile"`
+	Context    string `json:"context"`
+
+	DockerComposeFile json.RawMessage `json:"dockerComposeFile"`
+
+	Features                    map[string]json.RawMessage `json:"features"`
+	OverrideFeatureInstallOrder []string                   `json:"overrideFeatureInstallOrder"`
+
+	ContainerEnv map[string]string `json:"containerEnv"`
+	Mounts       []Mount           `json:"mounts"`
+	ForwardPorts []Port            `json:"forwardPorts"`
+
+	PostCreateCommand LifecycleCommand `json:"postCreateCommand"`
+	PostStartCommand  LifecycleCommand `json:"postStartCommand"`
+
+	// Path is the absolute path of the devcontainer.json this was read from.
+	Path string `json:"-"`
+	// content is the comment-stripped file, kept for hashing.
+	content []byte
+}
+
+// BuildConfig is the "build" section of devcontainer.json. Paths are
+// relative to the directory holding devcontainer.json.
+type BuildConfig struct {
+	Dockerfile string            `json:"dockerfile"`
+	Context    string            `json:"context"`
+	Args       map[string]string `json:"args"`
+	Target     string            `json:"target"`
+}
+
+// Load reads the devcontainer config for the repository at repoPath.
+// configPath is relative to the repository; empty searches the default
+// locations. Returns ErrNotFound when there is no config.
+func Load(repoPath, configPath string) (*Config, error) {
+	candidates := defaultConfigPaths
+	if configPath != "" {
+		if filepath.IsAbs(configPath) || !filepath.IsLocal(filepath.Clean(configPath)) {
+			return nil, fmt.Errorf("devcontainer config path %q must be relative to the repository", configPath)
+		}
+		candidates = []string{configPath}
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(repoPath, candidate)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", candidate, err)
+		}
+		cfg, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", candidate, err)
+		}
+		cfg.Path = path
+		return cfg, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Parse decodes devcontainer.json content and checks it describes a single
+// image- or Dockerfile-based container.
+func Parse(data []byte) (*Config, error) {
+	content := stripJSONC(data)
+	var cfg Config
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, err
+	}
+	cfg.content = content
+	if cfg.Build == nil && cfg.DockerFile != "" {
+		cfg.Build = &BuildConfig{Dockerfile: cfg.DockerFile, Context: cfg.Context}
+	}
+	if len(cfg.DockerComposeFile) > 0 {
+		return nil, errors.New("docker compose based devcontainers are not supported")
+	}
+	hasBuild := cfg.Build != nil && cfg.Build.Dockerfile != ""
+	if cfg.Image == "" && !hasBuild {
+		return nil, errors.New(`devcontainer.json must set "image" or "build.dockerfile"`)
+	}
+	if cfg.Image != "" && hasBuild {
+		return nil, errors.New(`devcontainer.json sets both "image" and "build.dockerfile"`)
+	}
+	return &cfg, nil
+}
+
+// Dir is the directory holding devcontainer.json, which relative build
+// paths are resolved against.
+func (c *Config) Dir() string {
+	return filepath.Dir(c.Path)
+}
+
+// Mount is one entry of "mounts". The spec allows either the Docker
+// --mount string form or an object.
+type Mount struct {
+	Type     string `json:"type"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readonly"`
+}
+
+// UnmarshalJSON accepts "type=bind,source=/a,target=/b" strings and objects.
+func (m *Mount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		type plain Mount
+		return json.Unmarshal(data, (*plain)(m))
+	}
+	for _, part := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(key) {
+		case "type":
+			m.Type = value
+		case "source", "src":
+			m.Source = value
+		case "target", "destination", "dst":
+			m.Target = value
+		case "readonly", "ro":
+			m.ReadOnly = value == "" || value == "true" || value == "1"
+		}
+	}
+	return nil
+}
+
+// Port is one entry of "forwardPorts": a port number, or "host:port" for a
+// port on another host. Only ports of the dev container itself can be
+// published, so Host is kept to let callers skip the others.
+type Port struct {
+	Host string
+	Port int
+}
+
+// UnmarshalJSON accepts 3000 and "localhost:3000".
+func (p *Port) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		p.Port = n
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("forwardPorts entry must be a number or \"host:port\": %s", data)
+	}
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		host, port = "", s
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid forwardPorts entry %q", s)
+	}
+	p.Host, p.Port = host, n
+	return nil
+}
+
+// IsLocal reports whether the port belongs to the dev container itself.
+func (p Port) IsLocal() bool {
+	return p.Host == "" || p.Host == "localhost" || p.Host == "127.0.0.1"
+}
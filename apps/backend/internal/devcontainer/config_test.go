@@ -0,0 +1,110 @@
+package devcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDevcontainer(t *testing.T, repo, rel, content string) {
+	t.Helper()
+	path := filepath.Join(repo, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_DefaultLocations(t *testing.T) {
+	repo := t.TempDir()
+	if _, err := Load(repo, ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("empty repo: err = %v, want ErrNotFound", err)
+	}
+
+	writeDevcontainer(t, repo, ".devcontainer.json", `{"image": "root-file"}`)
+	cfg, err := Load(repo, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Image != "root-file" {
+		t.Errorf("Image = %q, want root-file", cfg.Image)
+	}
+
+	writeDevcontainer(t, repo, ".devcontainer/devcontainer.json", `{"image": "folder-file"}`)
+	cfg, err = Load(repo, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Image != "folder-file" {
+		t.Errorf("Image = %q, want .devcontainer/devcontainer.json to take precedence", cfg.Image)
+	}
+	if cfg.Dir() != filepath.Join(repo, ".devcontainer") {
+		t.Errorf("Dir() = %q", cfg.Dir())
+	}
+}
+
+func TestLoad_ExplicitPathMustStayInRepo(t *testing.T) {
+	repo := t.TempDir()
+	writeDevcontainer(t, repo, ".devcontainer/python/devcontainer.json", `{"image": "python"}`)
+
+	cfg, err := Load(repo, ".devcontainer/python/devcontainer.json")
+	if err != nil || cfg.Image != "python" {
+		t.Fatalf("Load explicit path = %+v, %v", cfg, err)
+	}
+	for _, path := range []string{"../outside.json", "/etc/devcontainer.json"} {
+		if _, err := Load(repo, path); err == nil || !strings.Contains(err.Error(), "relative to the repository") {
+			t.Errorf("Load(%q) err = %v, want a path error", path, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+  "dockerFile": "Dockerfile",
+  "context": "..",
+  "mounts": [
+    "source=${localWorkspaceFolder}/.cache,target=/root/.cache,type=bind",
+    {"source": "node_modules", "target": "/workspace/node_modules", "type": "volume"}
+  ],
+  "forwardPorts": [3000, "db:5432", "localhost:8080"],
+  "postCreateCommand": ["npm", "ci"],
+  "postStartCommand": {"server": "npm run dev", "watch": ["make", "watch"]}
+}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Build == nil || cfg.Build.Dockerfile != "Dockerfile" || cfg.Build.Context != ".." {
+		t.Errorf("Build = %+v, want the legacy dockerFile/context promoted", cfg.Build)
+	}
+	if len(cfg.Mounts) != 2 || cfg.Mounts[0].Target != "/root/.cache" || cfg.Mounts[1].Type != "volume" {
+		t.Errorf("Mounts = %+v", cfg.Mounts)
+	}
+	if got := cfg.LocalForwardPorts(); len(got) != 2 || got[0] != 3000 || got[1] != 8080 {
+		t.Errorf("LocalForwardPorts() = %v, want [3000 8080]", got)
+	}
+	if len(cfg.PostCreateCommand.Args) != 2 {
+		t.Errorf("PostCreateCommand = %+v, want the array form", cfg.PostCreateCommand)
+	}
+	if len(cfg.PostStartCommand.Parallel) != 2 {
+		t.Errorf("PostStartCommand = %+v, want the object form", cfg.PostStartCommand)
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"no image":        `{"name": "x"}`,
+		"image and build": `{"image": "a", "build": {"dockerfile": "Dockerfile"}}`,
+		"compose":         `{"dockerComposeFile": "docker-compose.yml", "service": "app"}`,
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(content)); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Feature is one entry of "features": a reference to an OCI artifact
+// (ghcr.io/devcontainers/features/node:1) or an https tarball, plus the
+// options passed to its install.sh.
+type Feature struct {
+	ID      string
+	Options map[string]string
+}
+
+// features returns the configured features in install order: the
+// overrideFeatureInstallOrder entries first, then the rest by ID.
+func (c *Config) features() ([]Feature, error) {
+	ids := make([]string, 0, len(c.Features))
+	for id := range c.Features {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	ordered := make([]string, 0, len(ids))
+	placed := make(map[string]bool, len(ids))
+	for _, id := range c.OverrideFeatureInstallOrder {
+		if _, ok := c.Features[id]; ok && !placed[id] {
+			ordered = append(ordered, id)
+			placed[id] = true
+		}
+	}
+	for _, id := range ids {
+		if !placed[id] {
+			ordered = append(ordered, id)
+		}
+	}
+
+	features := make([]Feature, 0, len(ordered))
+	for _, id := range ordered {
+		if err := validateFeatureID(id); err != nil {
+			return nil, err
+		}
+		options, err := parseFeatureOptions(c.Features[id])
+		if err != nil {
+			return nil, fmt.Errorf("feature %q: %w", id, err)
+		}
+		features = append(features, Feature{ID: id, Options: options})
+	}
+	return features, nil
+}
+
+func validateFeatureID(id string) error {
+	switch {
+	case strings.HasPrefix(id, "./"), strings.HasPrefix(id, "../"):
+		return fmt.Errorf("feature %q: local features are not supported", id)
+	case strings.HasPrefix(id, "https://"):
+		return nil
+	case strings.HasPrefix(id, "http://"):
+		return fmt.Errorf("feature %q: tarball features must use https", id)
+	case !strings.Contains(id, "/"):
+		return fmt.Errorf("feature %q: expected an OCI reference like ghcr.io/devcontainers/features/node:1", id)
+	}
+	for _, r := range id {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("./-_:@", r) {
+			return fmt.Errorf("feature %q: invalid character %q", id, r)
+		}
+	}
+	return nil
+}
+
+// parseFeatureOptions accepts the option object, a bare string (shorthand
+// for {"version": ...}) or true.
+func parseFeatureOptions(raw json.RawMessage) (map[string]string, error) {
+	var version string
+	if err := json.Unmarshal(raw, &version); err == nil {
+		return map[string]string{"version": version}, nil
+	}
+	var enabled bool
+	if err := json.Unmarshal(raw, &enabled); err == nil {
+		return map[string]string{}, nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("options must be an object: %w", err)
+	}
+	options := make(map[string]string, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			options[key] = v
+		case bool:
+			options[key] = strconv.FormatBool(v)
+		case float64:
+			options[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("option %q must be a string, boolean or number", key)
+		}
+	}
+	return options, nil
+}
+
+// env returns the feature's options as the environment install.sh reads:
+// option IDs upper-cased with non-alphanumerics replaced by underscores.
+func (f Feature) env() []string {
+	env := make([]string, 0, len(f.Options))
+	for key, value := range f.Options {
+		env = append(env, featureOptionEnvName(key)+"="+shellQuote(value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+func featureOptionEnvName(option string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, option)
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// featureInstallScript fetches a feature and runs its install.sh. OCI
+// features are pulled anonymously through the registry HTTP API (public
+// registries such as ghcr.io hand out pull tokens without credentials);
+// the devcontainer layer is a plain tar holding install.sh.
+const featureInstallScript = `set -e
+ref="$1"
+dir=$(mktemp -d)
+case "$ref" in
+https://*)
+  curl -fsSL "$ref" -o "$dir/feature.tgz"
+  ;;
+*)
+  registry=${ref%%/*}
+  rest=${ref#*/}
+  case "$rest" in
+  *@*) repo=${rest%@*}; tag=${rest#*@} ;;
+  *:*) repo=${rest%:*}; tag=${rest##*:} ;;
+  *) repo=$rest; tag=latest ;;
+  esac
+  token=$(curl -fsSL "https://$registry/token?scope=repository:$repo:pull" 2>/dev/null | sed -n 's/.*"token" *: *"\([^"]*\)".*/\1/p')
+  fetch() {
+    if [ -n "$token" ]; then curl -fsSL -H "Authorization: Bearer $token" "$@"; else curl -fsSL "$@"; fi
+  }
+  manifest=$(fetch -H "Accept: application/vnd.oci.image.manifest.v1+json" "https://$registry/v2/$repo/manifests/$tag" | tr -d '\n\r\t ')
+  digest=$(printf '%s' "$manifest" | sed -n 's/.*"layers":\[{[^}]*"digest":"\([^"]*\)".*/\1/p')
+  if [ -z "$digest" ]; then echo "devcontainer feature $ref: no layer in manifest" >&2; exit 1; fi
+  fetch "https://$registry/v2/$repo/blobs/$digest" -o "$dir/feature.tgz"
+  ;;
+esac
+mkdir "$dir/src"
+tar -xf "$dir/feature.tgz" -C "$dir/src"
+cd "$dir/src"
+chmod +x install.sh
+./install.sh
+cd /
+rm -rf "$dir"
+`
@@ -0,0 +1,139 @@
+package devcontainer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ImageRepository holds the final, feature-layered images.
+	ImageRepository = "kandev-devcontainer"
+	// BaseImageRepository holds images built from a devcontainer Dockerfile
+	// before features are layered on.
+	BaseImageRepository = "kandev-devcontainer-base"
+
+	featureInstallerPath = "/tmp/kandev-install-feature.sh"
+)
+
+// ImagePlan describes how to produce the image for a devcontainer config.
+// Both tags derive from Hash, so an unchanged config reuses a cached image.
+type ImagePlan struct {
+	// Hash identifies the config: devcontainer.json, the Dockerfile it builds
+	// and any extra install commands.
+	Hash string
+	// Pull is the image to pull when the config names one.
+	Pull string
+	// Build is set when the config builds from a Dockerfile.
+	Build *DockerfileBuild
+	// BaseImage is the image features are layered on: Pull or Build.Tag.
+	BaseImage string
+	// Layer is a Dockerfile installing the features and extra commands on
+	// BaseImage. Empty when there is nothing to add.
+	Layer string
+	// Image is the image the container runs: BaseImage when Layer is empty.
+	Image string
+}
+
+// DockerfileBuild is a build of the repository's own Dockerfile.
+type DockerfileBuild struct {
+	// ContextDir is the host directory sent as build context.
+	ContextDir string
+	// Dockerfile is the Dockerfile path inside ContextDir, slash-separated.
+	Dockerfile string
+	Args       map[string]string
+	Target     string
+	Tag        string
+}
+
+// Plan works out how to produce the config's image. extraInstall are shell
+// commands run as root after the features (e.g. installing the agent CLI
+// the image lacks); they are part of the cache key.
+func (c *Config) Plan(extraInstall ...string) (*ImagePlan, error) {
+	features, err := c.features()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(c.content)
+	plan := &ImagePlan{Pull: c.Image, BaseImage: c.Image}
+	if c.Build != nil && c.Build.Dockerfile != "" {
+		build, dockerfile, err := c.dockerfileBuild()
+		if err != nil {
+			return nil, err
+		}
+		h.Write([]byte{0})
+		h.Write(dockerfile)
+		plan.Build = build
+	}
+	for _, cmd := range extraInstall {
+		h.Write([]byte{0})
+		h.Write([]byte(cmd))
+	}
+	plan.Hash = hex.EncodeToString(h.Sum(nil))[:16]
+
+	if plan.Build != nil {
+		plan.Build.Tag = BaseImageRepository + ":" + plan.Hash
+		plan.BaseImage = plan.Build.Tag
+	}
+	plan.Layer = layerDockerfile(plan.BaseImage, features, extraInstall)
+	plan.Image = plan.BaseImage
+	if plan.Layer != "" {
+		plan.Image = ImageRepository + ":" + plan.Hash
+	}
+	return plan, nil
+}
+
+func (c *Config) dockerfileBuild() (*DockerfileBuild, []byte, error) {
+	contextDir := filepath.Join(c.Dir(), c.Build.Context)
+	dockerfilePath := filepath.Join(c.Dir(), c.Build.Dockerfile)
+	rel, err := filepath.Rel(contextDir, dockerfilePath)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, nil, fmt.Errorf("dockerfile %q must be inside the build context %q", c.Build.Dockerfile, c.Build.Context)
+	}
+	dockerfile, err := os.ReadFile(dockerfilePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read dockerfile: %w", err)
+	}
+	return &DockerfileBuild{
+		ContextDir: contextDir,
+		Dockerfile: filepath.ToSlash(rel),
+		Args:       c.Build.Args,
+		Target:     c.Build.Target,
+	}, dockerfile, nil
+}
+
+// layerDockerfile renders the Dockerfile that installs features and extra
+// commands on base. The feature installer and the extra commands, which may
+// be multi-line scripts, are inlined as base64 because the layer is built
+// without a context directory.
+func layerDockerfile(base string, features []Feature, extraInstall []string) string {
+	var installs []string
+	for _, cmd := range extraInstall {
+		if strings.TrimSpace(cmd) != "" {
+			installs = append(installs, cmd)
+		}
+	}
+	if len(features) == 0 && len(installs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "FROM %s\nUSER root\n", base)
+	if len(features) > 0 {
+		encoded := base64.StdEncoding.EncodeToString([]byte(featureInstallScript))
+		fmt.Fprintf(&b, "RUN echo %s | base64 -d > %s\n", encoded, featureInstallerPath)
+		for _, f := range features {
+			env := append([]string{"_REMOTE_USER=root", "_CONTAINER_USER=root"}, f.env()...)
+			fmt.Fprintf(&b, "RUN env %s sh %s %s\n", strings.Join(env, " "), featureInstallerPath, shellQuote(f.ID))
+		}
+		fmt.Fprintf(&b, "RUN rm -f %s\n", featureInstallerPath)
+	}
+	for _, cmd := range installs {
+		fmt.Fprintf(&b, "RUN echo %s | base64 -d | sh\n", base64.StdEncoding.EncodeToString([]byte(cmd)))
+	}
+	return b.String()
+}
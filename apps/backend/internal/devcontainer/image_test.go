@@ -0,0 +1,135 @@
+package devcontainer
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestPlan_ImageWithoutLayer(t *testing.T) {
+	cfg, err := Parse([]byte(`{"image": "mcr.microsoft.com/devcontainers/go:1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := cfg.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if plan.Pull != "mcr.microsoft.com/devcontainers/go:1" || plan.Build != nil {
+		t.Errorf("plan = %+v, want a pull-only plan", plan)
+	}
+	if plan.Layer != "" || plan.Image != plan.Pull {
+		t.Errorf("plan without features should run the pulled image, got %+v", plan)
+	}
+}
+
+func TestPlan_FeaturesAndExtraInstall(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+  "image": "debian:12",
+  "features": {
+    "ghcr.io/devcontainers/features/node:1": {"version": "20", "node-gyp-deps": true},
+    "ghcr.io/devcontainers/features/go:1": "1.22",
+    "https://example.com/feature.tgz": {}
+  },
+  "overrideFeatureInstallOrder": ["ghcr.io/devcontainers/features/node:1"]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := cfg.Plan("npm install -g some-agent")
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if !strings.HasPrefix(plan.Image, ImageRepository+":") || plan.BaseImage != "debian:12" {
+		t.Errorf("plan = %+v", plan)
+	}
+	layer := plan.Layer
+	node := strings.Index(layer, "features/node:1")
+	goFeature := strings.Index(layer, "features/go:1")
+	if !strings.HasPrefix(layer, "FROM debian:12\nUSER root\n") || node < 0 || goFeature < 0 || node > goFeature {
+		t.Fatalf("layer should start from the base and install node first:\n%s", layer)
+	}
+	for _, want := range []string{"NODE_GYP_DEPS='true'", "VERSION='20'", "VERSION='1.22'",
+		"RUN echo " + base64.StdEncoding.EncodeToString([]byte("npm install -g some-agent")) + " | base64 -d | sh",
+	} {
+		if !strings.Contains(layer, want) {
+			t.Errorf("layer missing %q:\n%s", want, layer)
+		}
+	}
+
+	other, err := cfg.Plan("npm install -g other-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Hash == plan.Hash {
+		t.Error("extra install commands must change the cache key")
+	}
+	again, _ := cfg.Plan("npm install -g some-agent")
+	if again.Image != plan.Image {
+		t.Error("the same config must map to the same cached image")
+	}
+}
+
+func TestPlan_DockerfileBuild(t *testing.T) {
+	repo := t.TempDir()
+	writeDevcontainer(t, repo, ".devcontainer/devcontainer.json", `{"build": {"dockerfile": "Dockerfile", "context": "..", "args": {"V": "1"}}}`)
+	writeDevcontainer(t, repo, ".devcontainer/Dockerfile", "FROM debian:12\n")
+	cfg, err := Load(repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := cfg.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if plan.Build == nil || plan.Build.ContextDir != repo || plan.Build.Dockerfile != ".devcontainer/Dockerfile" {
+		t.Fatalf("Build = %+v", plan.Build)
+	}
+	if plan.Image != plan.Build.Tag || !strings.HasPrefix(plan.Build.Tag, BaseImageRepository+":") {
+		t.Errorf("plan = %+v, want the built base image to be run as is", plan)
+	}
+
+	writeDevcontainer(t, repo, ".devcontainer/Dockerfile", "FROM debian:13\n")
+	changed, err := cfg.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Hash == plan.Hash {
+		t.Error("editing the Dockerfile must change the cache key")
+	}
+}
+
+func TestPlan_DockerfileOutsideContext(t *testing.T) {
+	repo := t.TempDir()
+	writeDevcontainer(t, repo, ".devcontainer/devcontainer.json", `{"build": {"dockerfile": "../Dockerfile", "context": "."}}`)
+	cfg, err := Load(repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Plan(); err == nil || !strings.Contains(err.Error(), "inside the build context") {
+		t.Fatalf("Plan err = %v", err)
+	}
+}
+
+func TestPlan_RejectsLocalFeatures(t *testing.T) {
+	cfg, err := Parse([]byte(`{"image": "x", "features": {"./local-feature": {}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Plan(); err == nil || !strings.Contains(err.Error(), "local features") {
+		t.Fatalf("Plan err = %v", err)
+	}
+}
+
+func TestFeatureOptionEnvName(t *testing.T) {
+	cases := map[string]string{
+		"version":       "VERSION",
+		"node-gyp-deps": "NODE_GYP_DEPS",
+		"3rdParty":      "_3RDPARTY",
+	}
+	for in, want := range cases {
+		if got := featureOptionEnvName(in); got != want {
+			t.Errorf("featureOptionEnvName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
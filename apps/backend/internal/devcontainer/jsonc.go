@@ -0,0 +1,63 @@
+package devcontainer
+
+// stripJSONC turns JSON-with-comments into plain JSON: it removes // line
+// comments, /* block */ comments and trailing commas before a closing
+// bracket, leaving string contents untouched. devcontainer.json is JSONC
+// and encoding/json rejects all three.
+func stripJSONC(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(src) {
+				i++
+				out = append(out, src[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 2
+			for i+1 < len(src) && (src[i] != '*' || src[i+1] != '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			out = append(trimTrailingComma(out), c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// trimTrailingComma drops a comma (and the whitespace after it) that ends
+// out, so "[1, 2, ]" closes as "[1, 2 ]".
+func trimTrailingComma(out []byte) []byte {
+	j := len(out) - 1
+	for j >= 0 && isJSONSpace(out[j]) {
+		j--
+	}
+	if j >= 0 && out[j] == ',' {
+		return append(out[:j], out[j+1:]...)
+	}
+	return out
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,30 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	src := []byte(`{
+  // line comment
+  "image": "mcr.microsoft.com/devcontainers/go:1", /* block
+  comment */
+  "url": "https://example.com/a//b", // comment after a string with //
+  "quote": "say \"hi\" /* not a comment */",
+  "ports": [3000, 8080,],
+}`)
+	var got map[string]interface{}
+	if err := json.Unmarshal(stripJSONC(src), &got); err != nil {
+		t.Fatalf("unmarshal stripped JSONC: %v\n%s", err, stripJSONC(src))
+	}
+	if got["url"] != "https://example.com/a//b" {
+		t.Errorf("url = %v, want // inside strings preserved", got["url"])
+	}
+	if got["quote"] != `say "hi" /* not a comment */` {
+		t.Errorf("quote = %v", got["quote"])
+	}
+	if ports, _ := got["ports"].([]interface{}); len(ports) != 2 {
+		t.Errorf("ports = %v, want the trailing comma dropped", got["ports"])
+	}
+}
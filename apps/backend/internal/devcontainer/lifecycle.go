@@ -0,0 +1,109 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// postCreateMarker records that postCreateCommand already ran in this
+// container. It lives outside the workspace so it is not committed, and on
+// the container filesystem so it survives stop/start but not re-creation.
+const postCreateMarker = "/var/tmp/kandev-devcontainer-post-create.done"
+
+// LifecycleCommand is a devcontainer lifecycle hook. The spec allows a shell
+// string, an exec-style argument array, or an object of named commands that
+// run in parallel.
+type LifecycleCommand struct {
+	Shell    string
+	Args     []string
+	Parallel map[string]LifecycleCommand
+}
+
+// UnmarshalJSON accepts the three spec forms.
+func (c *LifecycleCommand) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(data, &c.Shell); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &c.Args); err == nil {
+		return nil
+	}
+	var parallel map[string]LifecycleCommand
+	if err := json.Unmarshal(data, &parallel); err != nil {
+		return fmt.Errorf("lifecycle command must be a string, array or object: %s", data)
+	}
+	for name, cmd := range parallel {
+		if len(cmd.Parallel) > 0 {
+			return fmt.Errorf("lifecycle command %q: parallel commands cannot nest", name)
+		}
+	}
+	c.Parallel = parallel
+	return nil
+}
+
+// IsZero reports whether no command is set.
+func (c LifecycleCommand) IsZero() bool {
+	return c.Shell == "" && len(c.Args) == 0 && len(c.Parallel) == 0
+}
+
+// script renders the command as shell. Parallel commands are started in
+// the background in name order and the script fails if any of them fails.
+func (c LifecycleCommand) script() string {
+	switch {
+	case c.Shell != "":
+		return c.Shell
+	case len(c.Args) > 0:
+		quoted := make([]string, len(c.Args))
+		for i, arg := range c.Args {
+			quoted[i] = shellQuote(arg)
+		}
+		return strings.Join(quoted, " ")
+	}
+	names := make([]string, 0, len(c.Parallel))
+	for name := range c.Parallel {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("pids=''\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "( %s ) & pids=\"$pids $!\"\n", c.Parallel[name].script())
+	}
+	b.WriteString(`for pid in $pids; do wait "$pid" || exit 1; done`)
+	return b.String()
+}
+
+// LifecycleScript renders postCreateCommand and postStartCommand as a shell
+// snippet run from workspaceDir. postCreateCommand runs once per container,
+// guarded by a marker file; postStartCommand runs on every container start.
+// Returns "" when neither is set.
+func (c *Config) LifecycleScript(workspaceDir string) string {
+	if c.PostCreateCommand.IsZero() && c.PostStartCommand.IsZero() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n# devcontainer lifecycle commands\n")
+	if !c.PostCreateCommand.IsZero() {
+		fmt.Fprintf(&b, "if [ ! -f %s ]; then\n", shellQuote(postCreateMarker))
+		writeLifecycleStep(&b, "postCreateCommand", workspaceDir, c.PostCreateCommand)
+		fmt.Fprintf(&b, "touch %s\nfi\n", shellQuote(postCreateMarker))
+	}
+	if !c.PostStartCommand.IsZero() {
+		writeLifecycleStep(&b, "postStartCommand", workspaceDir, c.PostStartCommand)
+	}
+	return b.String()
+}
+
+func writeLifecycleStep(b *strings.Builder, name, workspaceDir string, cmd LifecycleCommand) {
+	fmt.Fprintf(b, "(\ncd %s || exit 1\n%s\n) || { echo %s >&2; exit 1; }\n",
+		shellQuote(workspaceDir), cmd.script(), shellQuote("[devcontainer] "+name+" failed"))
+}
+
+// shellQuote returns value as a single-quoted shell word.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,77 @@
+package devcontainer
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLifecycleScript_Empty(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.LifecycleScript("/workspace"); got != "" {
+		t.Errorf("LifecycleScript() = %q, want empty", got)
+	}
+}
+
+func TestLifecycleScript_RendersCommandForms(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+  "image": "x",
+  "postCreateCommand": ["echo", "it's created"],
+  "postStartCommand": {"b": "echo b", "a": ["echo", "a"]}
+}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	script := cfg.LifecycleScript("/workspace")
+	for _, want := range []string{
+		"if [ ! -f '" + postCreateMarker + "' ]; then",
+		`'echo' 'it'"'"'s created'`,
+		"( 'echo' 'a' ) & pids=",
+		"( echo b ) & pids=",
+		"cd '/workspace' || exit 1",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Index(script, "'echo' 'a'") > strings.Index(script, "echo b") {
+		t.Errorf("parallel commands should start in name order:\n%s", script)
+	}
+}
+
+// TestLifecycleScript_Runs executes the rendered script: postCreate runs once,
+// postStart on every run, and a failing parallel command fails the script.
+func TestLifecycleScript_Runs(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	cfg := &Config{
+		PostCreateCommand: LifecycleCommand{Shell: "echo create >> " + log},
+		PostStartCommand: LifecycleCommand{Parallel: map[string]LifecycleCommand{
+			"one": {Shell: "echo start >> " + log},
+		}},
+	}
+	script := strings.ReplaceAll(cfg.LifecycleScript(dir), postCreateMarker, filepath.Join(dir, "marker"))
+
+	for i := 0; i < 2; i++ {
+		if out, err := exec.Command("sh", "-c", script).CombinedOutput(); err != nil {
+			t.Fatalf("run %d: %v\n%s", i, err, out)
+		}
+	}
+	out, err := exec.Command("cat", log).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != "create\nstart\nstart\n" {
+		t.Errorf("log = %q, want postCreate once and postStart twice", got)
+	}
+
+	cfg.PostStartCommand.Parallel["two"] = LifecycleCommand{Shell: "exit 3"}
+	script = strings.ReplaceAll(cfg.LifecycleScript(dir), postCreateMarker, filepath.Join(dir, "marker"))
+	if err := exec.Command("sh", "-c", script).Run(); err == nil {
+		t.Error("expected a failing parallel command to fail the script")
+	}
+}
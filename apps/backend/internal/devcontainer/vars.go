@@ -0,0 +1,139 @@
+package devcontainer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Variables resolves the ${...} references devcontainer.json values may use.
+// Unknown variables are left as written.
+type Variables struct {
+	LocalWorkspaceFolder     string
+	ContainerWorkspaceFolder string
+	// LocalEnv looks up ${localEnv:NAME}. Nil uses os.LookupEnv.
+	LocalEnv func(string) (string, bool)
+	// ContainerEnv backs ${containerEnv:NAME}, typically the image's
+	// configured environment.
+	ContainerEnv map[string]string
+}
+
+// Expand substitutes every known ${...} reference in s.
+func (v Variables) Expand(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end += start
+		b.WriteString(s[:start])
+		if value, ok := v.lookup(s[start+2 : end]); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[start : end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+func (v Variables) lookup(ref string) (string, bool) {
+	name, arg, hasArg := strings.Cut(ref, ":")
+	switch name {
+	case "localWorkspaceFolder":
+		return v.LocalWorkspaceFolder, true
+	case "localWorkspaceFolderBasename":
+		return filepath.Base(v.LocalWorkspaceFolder), true
+	case "containerWorkspaceFolder":
+		return v.ContainerWorkspaceFolder, true
+	case "containerWorkspaceFolderBasename":
+		return path.Base(v.ContainerWorkspaceFolder), true
+	case "localEnv", "env":
+		if !hasArg {
+			return "", false
+		}
+		key, def, _ := strings.Cut(arg, ":")
+		lookupEnv := v.LocalEnv
+		if lookupEnv == nil {
+			lookupEnv = os.LookupEnv
+		}
+		if value, ok := lookupEnv(key); ok {
+			return value, true
+		}
+		return def, true
+	case "containerEnv":
+		if !hasArg {
+			return "", false
+		}
+		key, def, _ := strings.Cut(arg, ":")
+		if value, ok := v.ContainerEnv[key]; ok {
+			return value, true
+		}
+		return def, true
+	}
+	return "", false
+}
+
+// Env returns containerEnv as sorted KEY=value pairs with variables expanded.
+func (c *Config) Env(vars Variables) []string {
+	env := make([]string, 0, len(c.ContainerEnv))
+	for key, value := range c.ContainerEnv {
+		env = append(env, key+"="+vars.Expand(value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// ResolvedMounts returns "mounts" with variables expanded. Bind sources must
+// be absolute host paths; volume sources are volume names.
+func (c *Config) ResolvedMounts(vars Variables) ([]Mount, error) {
+	mounts := make([]Mount, 0, len(c.Mounts))
+	for _, m := range c.Mounts {
+		m.Source = vars.Expand(m.Source)
+		m.Target = vars.Expand(m.Target)
+		if m.Type == "" {
+			m.Type = "bind"
+		}
+		if m.Target == "" {
+			return nil, fmt.Errorf("mount of %q has no target", m.Source)
+		}
+		switch m.Type {
+		case "bind":
+			if !filepath.IsAbs(m.Source) {
+				return nil, fmt.Errorf("bind mount source %q must be an absolute path", m.Source)
+			}
+		case "volume":
+			if m.Source == "" {
+				return nil, fmt.Errorf("volume mount at %q has no source", m.Target)
+			}
+		default:
+			return nil, fmt.Errorf("mount type %q is not supported", m.Type)
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+// LocalForwardPorts returns the forwardPorts that belong to the dev
+// container itself, deduplicated and in declaration order.
+func (c *Config) LocalForwardPorts() []int {
+	seen := make(map[int]bool, len(c.ForwardPorts))
+	var ports []int
+	for _, p := range c.ForwardPorts {
+		if !p.IsLocal() || p.Port <= 0 || p.Port > 65535 || seen[p.Port] {
+			continue
+		}
+		seen[p.Port] = true
+		ports = append(ports, p.Port)
+	}
+	return ports
+}
@@ -0,0 +1,70 @@
+package devcontainer
+
+import "testing"
+
+func TestVariablesExpand(t *testing.T) {
+	vars := Variables{
+		LocalWorkspaceFolder:     "/home/u/src/app",
+		ContainerWorkspaceFolder: "/workspace",
+		LocalEnv: func(key string) (string, bool) {
+			if key == "HOME" {
+				return "/home/u", true
+			}
+			return "", false
+		},
+		ContainerEnv: map[string]string{"PATH": "/usr/bin"},
+	}
+	cases := map[string]string{
+		"${localWorkspaceFolder}/.cache":          "/home/u/src/app/.cache",
+		"${localWorkspaceFolderBasename}":         "app",
+		"${containerWorkspaceFolder}/bin":         "/workspace/bin",
+		"${localEnv:HOME}/.ssh":                   "/home/u/.ssh",
+		"${localEnv:MISSING:fallback}":            "fallback",
+		"${localEnv:MISSING}":                     "",
+		"${containerEnv:PATH}:/workspace/bin":     "/usr/bin:/workspace/bin",
+		"${devcontainerId} stays":                 "${devcontainerId} stays",
+		"unterminated ${localWorkspaceFolder":     "unterminated ${localWorkspaceFolder",
+		"${localWorkspaceFolder}${localEnv:HOME}": "/home/u/src/app/home/u",
+	}
+	for in, want := range cases {
+		if got := vars.Expand(in); got != want {
+			t.Errorf("Expand(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolvedMounts(t *testing.T) {
+	cfg := &Config{Mounts: []Mount{
+		{Source: "${localWorkspaceFolder}/.cache", Target: "/root/.cache"},
+		{Type: "volume", Source: "deps", Target: "/workspace/node_modules"},
+	}}
+	mounts, err := cfg.ResolvedMounts(Variables{LocalWorkspaceFolder: "/repo"})
+	if err != nil {
+		t.Fatalf("ResolvedMounts: %v", err)
+	}
+	if mounts[0].Type != "bind" || mounts[0].Source != "/repo/.cache" {
+		t.Errorf("mounts[0] = %+v", mounts[0])
+	}
+
+	for _, bad := range []Mount{
+		{Source: "relative", Target: "/x"},
+		{Source: "/abs"},
+		{Type: "tmpfs", Target: "/tmp"},
+	} {
+		cfg := &Config{Mounts: []Mount{bad}}
+		if _, err := cfg.ResolvedMounts(Variables{}); err == nil {
+			t.Errorf("ResolvedMounts(%+v) = nil error", bad)
+		}
+	}
+}
+
+func TestEnvIsSortedAndExpanded(t *testing.T) {
+	cfg := &Config{ContainerEnv: map[string]string{
+		"PATH":  "${containerEnv:PATH}:/opt/bin",
+		"GOENV": "off",
+	}}
+	env := cfg.Env(Variables{ContainerEnv: map[string]string{"PATH": "/usr/bin"}})
+	if len(env) != 2 || env[0] != "GOENV=off" || env[1] != "PATH=/usr/bin:/opt/bin" {
+		t.Errorf("Env() = %v", env)
+	}
+}
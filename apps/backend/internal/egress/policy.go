@@ -0,0 +1,145 @@
+// Package egress runs the forward proxy that enforces executor profiles'
+// outbound network policies. Containers launched under a policy get the
+// proxy in HTTP_PROXY/HTTPS_PROXY with a per-instance credential; the proxy
+// resolves the credential to the instance's policy, tunnels or forwards
+// allowed requests and reports denied ones so they can be surfaced in the
+// task's session.
+package egress
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Mode selects how strict a policy is.
+type Mode string
+
+const (
+	// ModeOff leaves outbound traffic alone; no proxy is injected.
+	ModeOff Mode = ""
+	// ModeAllowlist allows the model API, the hosts Kandev itself needs and
+	// the profile's listed domains.
+	ModeAllowlist Mode = "allowlist"
+	// ModeModelAPIOnly allows the model API and the hosts Kandev itself
+	// needs; listed domains are ignored.
+	ModeModelAPIOnly Mode = "model_api_only"
+)
+
+// ParseMode validates a profile's egress_policy value.
+func ParseMode(raw string) (Mode, error) {
+	switch mode := Mode(strings.TrimSpace(raw)); mode {
+	case ModeOff, ModeAllowlist, ModeModelAPIOnly:
+		return mode, nil
+	default:
+		return ModeOff, fmt.Errorf("unknown egress policy %q (want %q or %q)", raw, ModeAllowlist, ModeModelAPIOnly)
+	}
+}
+
+// DefaultModelAPIDomains are the model provider endpoints of the built-in
+// agents, allowed in every mode unless a profile overrides them.
+var DefaultModelAPIDomains = []string{
+	"api.anthropic.com",
+	"console.anthropic.com",
+	"api.openai.com",
+	"auth.openai.com",
+	"chatgpt.com",
+	"generativelanguage.googleapis.com",
+	"cloudcode-pa.googleapis.com",
+	"oauth2.googleapis.com",
+	"*.githubcopilot.com",
+	"api.x.ai",
+	"api.mistral.ai",
+}
+
+// Policy is a resolved set of allowed destinations. An entry is a host name
+// matched exactly, "*.example.com" for any subdomain of example.com, or an
+// IP address.
+type Policy struct {
+	Mode    Mode
+	allowed []string
+}
+
+// NewPolicy builds the policy for mode. modelAPI falls back to
+// DefaultModelAPIDomains when empty; required lists hosts Kandev needs in
+// every mode (the repository's git host, the credential broker).
+func NewPolicy(mode Mode, modelAPI, domains, required []string) Policy {
+	if len(modelAPI) == 0 {
+		modelAPI = DefaultModelAPIDomains
+	}
+	p := Policy{Mode: mode}
+	p.add(modelAPI...)
+	p.add(required...)
+	if mode == ModeAllowlist {
+		p.add(domains...)
+	}
+	return p
+}
+
+func (p *Policy) add(entries ...string) {
+	for _, entry := range entries {
+		if entry = normalizeHost(entry); entry != "" {
+			p.allowed = append(p.allowed, entry)
+		}
+	}
+}
+
+// Allows reports whether host (without port) may be reached.
+func (p Policy) Allows(host string) bool {
+	host = normalizeHost(host)
+	if host == "" {
+		return false
+	}
+	for _, entry := range p.allowed {
+		if matchHost(entry, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed returns the policy's entries.
+func (p Policy) Allowed() []string {
+	return append([]string(nil), p.allowed...)
+}
+
+func matchHost(entry, host string) bool {
+	if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return entry == host
+}
+
+// ParseDomains splits a comma or newline separated domain list.
+func ParseDomains(raw string) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	})
+	out := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if host := normalizeHost(field); host != "" {
+			out = append(out, host)
+		}
+	}
+	return out
+}
+
+// normalizeHost lowercases host and strips a trailing dot, IPv6 brackets
+// and the scheme, user info, port and path of a URL.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	return host
+}
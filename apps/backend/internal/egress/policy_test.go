@@ -0,0 +1,75 @@
+package egress
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	for raw, want := range map[string]Mode{
+		"":                 ModeOff,
+		" allowlist ":      ModeAllowlist,
+		"model_api_only":   ModeModelAPIOnly,
+		"\tmodel_api_only": ModeModelAPIOnly,
+	} {
+		got, err := ParseMode(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseMode("deny_all"); err == nil {
+		t.Error("ParseMode(deny_all) succeeded, want error")
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	domains := []string{"registry.npmjs.org", "*.pypi.org", "10.0.0.5"}
+	required := []string{"https://git.example.com/acme/api.git", "", "file:///srv/repo"}
+	allowlist := NewPolicy(ModeAllowlist, nil, domains, required)
+	modelOnly := NewPolicy(ModeModelAPIOnly, nil, domains, required)
+
+	tests := []struct {
+		host          string
+		wantAllowlist bool
+		wantModelOnly bool
+	}{
+		{host: "api.anthropic.com", wantAllowlist: true, wantModelOnly: true},
+		{host: "API.Anthropic.com.", wantAllowlist: true, wantModelOnly: true},
+		{host: "api.individual.githubcopilot.com", wantAllowlist: true, wantModelOnly: true},
+		{host: "githubcopilot.com", wantAllowlist: false, wantModelOnly: false},
+		{host: "git.example.com", wantAllowlist: true, wantModelOnly: true},
+		{host: "registry.npmjs.org", wantAllowlist: true, wantModelOnly: false},
+		{host: "files.pypi.org", wantAllowlist: true, wantModelOnly: false},
+		{host: "pypi.org", wantAllowlist: false, wantModelOnly: false},
+		{host: "evilpypi.org", wantAllowlist: false, wantModelOnly: false},
+		{host: "10.0.0.5", wantAllowlist: true, wantModelOnly: false},
+		{host: "npmjs.org", wantAllowlist: false, wantModelOnly: false},
+		{host: "", wantAllowlist: false, wantModelOnly: false},
+	}
+	for _, tt := range tests {
+		if got := allowlist.Allows(tt.host); got != tt.wantAllowlist {
+			t.Errorf("allowlist.Allows(%q) = %v, want %v", tt.host, got, tt.wantAllowlist)
+		}
+		if got := modelOnly.Allows(tt.host); got != tt.wantModelOnly {
+			t.Errorf("modelOnly.Allows(%q) = %v, want %v", tt.host, got, tt.wantModelOnly)
+		}
+	}
+}
+
+func TestPolicyModelAPIOverride(t *testing.T) {
+	p := NewPolicy(ModeModelAPIOnly, []string{"llm.internal.example"}, nil, nil)
+	if !p.Allows("llm.internal.example") {
+		t.Error("override model API host not allowed")
+	}
+	if p.Allows("api.anthropic.com") {
+		t.Error("default model API host still allowed after override")
+	}
+}
+
+func TestParseDomains(t *testing.T) {
+	got := ParseDomains(" registry.npmjs.org,\n*.PyPI.org \r\nhttps://proxy.golang.org/x , ,git@github.com:acme/api.git")
+	want := []string{"registry.npmjs.org", "*.pypi.org", "proxy.golang.org", "github.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseDomains = %v, want %v", got, want)
+	}
+}
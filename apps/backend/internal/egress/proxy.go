@@ -0,0 +1,345 @@
+package egress
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+)
+
+const (
+	// proxyUser is the fixed user name in proxy URLs; the password is the
+	// per-instance credential.
+	proxyUser = "kandev"
+
+	dialTimeout = 10 * time.Second
+	// noticeInterval folds repeated denials of the same host for one
+	// instance into a single notice.
+	noticeInterval = time.Minute
+)
+
+// ErrProxyClosed is returned by Register after Close.
+var ErrProxyClosed = errors.New("egress proxy closed")
+
+// Route binds a proxy credential to the instance it was issued for.
+type Route struct {
+	TaskID     string
+	SessionID  string
+	InstanceID string
+	Policy     Policy
+}
+
+// Denial describes a request the proxy refused. It is published on the
+// event bus as the executor.egress_denied payload.
+type Denial struct {
+	TaskID     string `json:"task_id"`
+	SessionID  string `json:"session_id"`
+	InstanceID string `json:"instance_id"`
+	Mode       Mode   `json:"mode"`
+	Method     string `json:"method"`
+	Host       string `json:"host"`
+	Port       string `json:"port,omitempty"`
+	// Repeats counts denials of the same host by the same instance that
+	// were folded into this notice since the previous one.
+	Repeats  int       `json:"repeats,omitempty"`
+	DeniedAt time.Time `json:"denied_at"`
+}
+
+type noticeKey struct {
+	credential string
+	host       string
+}
+
+type noticeState struct {
+	lastAt  time.Time
+	repeats int
+}
+
+// Proxy is an HTTP forward proxy that only lets instances reach the hosts
+// their policy allows. It listens lazily: the listener opens on the first
+// Register, so installs that never configure a policy never open the port.
+type Proxy struct {
+	listenAddr string
+	logger     *logger.Logger
+	onDenied   func(Denial)
+	now        func() time.Time
+	dialer     *net.Dialer
+	forward    *httputil.ReverseProxy
+
+	mu       sync.Mutex
+	routes   map[string]Route
+	notices  map[noticeKey]noticeState
+	listener net.Listener
+	server   *http.Server
+	closed   bool
+}
+
+// NewProxy creates a proxy that will listen on listenAddr. onDenied is
+// called for denied requests, at most once per host and instance per
+// minute; it may be nil.
+func NewProxy(listenAddr string, log *logger.Logger, onDenied func(Denial)) *Proxy {
+	p := &Proxy{
+		listenAddr: listenAddr,
+		logger:     log.WithFields(zap.String("component", "egress-proxy")),
+		onDenied:   onDenied,
+		now:        time.Now,
+		dialer:     &net.Dialer{Timeout: dialTimeout},
+		routes:     make(map[string]Route),
+		notices:    make(map[noticeKey]noticeState),
+	}
+	transport := &http.Transport{
+		// Forward directly: the backend's own proxy settings are not the
+		// container's.
+		Proxy:                 nil,
+		DialContext:           p.dialer.DialContext,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: 5 * time.Minute,
+		MaxIdleConnsPerHost:   4,
+	}
+	p.forward = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL = r.In.URL
+			r.Out.Host = r.In.Host
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			p.logger.Debug("egress forward failed", zap.String("host", r.URL.Host), zap.Error(err))
+			http.Error(w, "egress proxy: upstream unreachable", http.StatusBadGateway)
+		},
+	}
+	return p
+}
+
+// NewCredential returns a fresh random proxy credential.
+func NewCredential() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate egress credential: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Register starts routing credential to route, opening the listener if
+// needed, and returns the port the proxy listens on. Registering a known
+// credential replaces its route, which is how a reconnected container gets
+// its policy back after a backend restart.
+func (p *Proxy) Register(credential string, route Route) (int, error) {
+	if credential == "" {
+		return 0, errors.New("egress credential is empty")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrProxyClosed
+	}
+	if err := p.listenLocked(); err != nil {
+		return 0, err
+	}
+	p.routes[credential] = route
+	return p.listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Unregister stops routing credential. Later requests carrying it are
+// rejected as unauthenticated.
+func (p *Proxy) Unregister(credential string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.routes, credential)
+	for key := range p.notices {
+		if key.credential == credential {
+			delete(p.notices, key)
+		}
+	}
+}
+
+// Close stops the listener. Tunnels already established are left to finish.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	server := p.server
+	p.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+func (p *Proxy) listenLocked() error {
+	if p.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", p.listenAddr)
+	if err != nil {
+		return fmt.Errorf("start egress proxy on %s: %w", p.listenAddr, err)
+	}
+	p.listener = listener
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logger.Error("egress proxy stopped", zap.Error(err))
+		}
+	}()
+	p.logger.Info("egress proxy listening", zap.String("address", listener.Addr().String()))
+	return nil
+}
+
+// ServeHTTP handles CONNECT tunnels and absolute-form plain HTTP requests.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	credential, route, ok := p.authenticate(r)
+	if !ok {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="kandev-egress"`)
+		http.Error(w, "egress proxy: authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+	target := r.Host
+	if r.Method != http.MethodConnect {
+		if !r.URL.IsAbs() || r.URL.Host == "" {
+			http.Error(w, "egress proxy: absolute URL required", http.StatusBadRequest)
+			return
+		}
+		target = r.URL.Host
+	}
+	host, port := splitTarget(target)
+	if !route.Policy.Allows(host) {
+		p.deny(credential, route, r.Method, host, port)
+		http.Error(w, fmt.Sprintf("egress proxy: %s is not allowed by the executor profile's network policy", host), http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r, target)
+		return
+	}
+	p.forward.ServeHTTP(w, r)
+}
+
+func (p *Proxy) authenticate(r *http.Request) (string, Route, bool) {
+	encoded, ok := strings.CutPrefix(r.Header.Get("Proxy-Authorization"), "Basic ")
+	if !ok {
+		return "", Route{}, false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", Route{}, false
+	}
+	_, credential, _ := strings.Cut(string(decoded), ":")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	route, ok := p.routes[credential]
+	return credential, route, ok && credential != ""
+}
+
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request, target string) {
+	upstream, err := p.dialer.DialContext(r.Context(), "tcp", target)
+	if err != nil {
+		http.Error(w, "egress proxy: upstream unreachable", http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "egress proxy: tunneling unsupported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		_ = client.Close()
+		_ = upstream.Close()
+		return
+	}
+	go func() {
+		defer func() { _ = upstream.Close() }()
+		// Bytes the client sent after the CONNECT line may already sit in
+		// the server's read buffer.
+		_, _ = io.Copy(upstream, buffered)
+	}()
+	go func() {
+		defer func() { _ = client.Close() }()
+		_, _ = io.Copy(client, upstream)
+	}()
+}
+
+func (p *Proxy) deny(credential string, route Route, method, host, port string) {
+	p.logger.Info("egress request denied",
+		zap.String("task_id", route.TaskID),
+		zap.String("session_id", route.SessionID),
+		zap.String("instance_id", route.InstanceID),
+		zap.String("method", method),
+		zap.String("host", host),
+		zap.String("port", port))
+	if p.onDenied == nil {
+		return
+	}
+	now := p.now()
+	key := noticeKey{credential: credential, host: host}
+	p.mu.Lock()
+	state := p.notices[key]
+	if !state.lastAt.IsZero() && now.Sub(state.lastAt) < noticeInterval {
+		state.repeats++
+		p.notices[key] = state
+		p.mu.Unlock()
+		return
+	}
+	p.notices[key] = noticeState{lastAt: now}
+	p.mu.Unlock()
+	p.onDenied(Denial{
+		TaskID:     route.TaskID,
+		SessionID:  route.SessionID,
+		InstanceID: route.InstanceID,
+		Mode:       route.Policy.Mode,
+		Method:     method,
+		Host:       host,
+		Port:       port,
+		Repeats:    state.repeats,
+		DeniedAt:   now,
+	})
+}
+
+func splitTarget(target string) (string, string) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return normalizeHost(target), ""
+	}
+	return normalizeHost(host), port
+}
+
+// ProxyURL is the HTTP_PROXY value for a container reaching the proxy at
+// host:port with credential.
+func ProxyURL(host string, port int, credential string) string {
+	u := url.URL{
+		Scheme: "http",
+		User:   url.UserPassword(proxyUser, credential),
+		Host:   net.JoinHostPort(host, fmt.Sprint(port)),
+	}
+	return u.String()
+}
+
+// CredentialFromProxyURL extracts the credential from a ProxyURL value, or
+// returns "" when raw is not one.
+func CredentialFromProxyURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil || u.User.Username() != proxyUser {
+		return ""
+	}
+	credential, _ := u.User.Password()
+	return credential
+}
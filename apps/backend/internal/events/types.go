@@ -10,6 +10,10 @@ const (
 	TaskMoved                      = "task.moved" // Manual step change via MoveTask
 	TaskQueuePromoted              = "task.queue_promoted"
 	SessionWorkspaceSourcesUpdated = "session.workspace_sources.updated"
+	// TaskStepEntered fires when the workflow engine moves a task to another
+	// step on its own; manual moves publish TaskMoved instead. Payload:
+	// {task_id, session_id, from_step_id, to_step_id, trigger}.
+	TaskStepEntered = "task.step_entered"
 	// TaskDependenciesResolved fires when a task's last unresolved dependency
 	// completes successfully. Payload: {task_id, resolved_by_task_id}.
 	TaskDependenciesResolved = "task.dependencies_resolved"
@@ -167,6 +171,11 @@ const (
 	ExecutorPrepareCompleted = "executor.prepare.completed"
 )
 
+// ExecutorEgressDenied is published by the egress proxy when a container's
+// request is blocked by its executor profile's network policy. The payload
+// is an egress.Denial.
+const ExecutorEgressDenied = "executor.egress_denied"
+
 // Event types for users
 const (
 	UserSettingsUpdated = "user.settings.updated"
@@ -293,8 +302,9 @@ const (
 
 // Event types for automations
 const (
-	AutomationTriggered  = "automation.triggered"   // A trigger fired
-	AutomationRunCreated = "automation.run.created" // Run outcome recorded
+	AutomationTriggered    = "automation.triggered"     // A trigger fired
+	AutomationRunCreated   = "automation.run.created"   // Run outcome recorded
+	AutomationRunCompleted = "automation.run.completed" // Run's task finished
 )
 
 // Event types for GitHub integration
@@ -202,3 +202,24 @@ func (s *Service) MergePRForAutomation(
 	)
 	return err
 }
+
+// CommentOnPRForAutomation posts body as a COMMENT review on a pull request
+// using the workspace's automation credential.
+func (s *Service) CommentOnPRForAutomation(
+	ctx context.Context,
+	workspaceID, owner, repo string,
+	number int,
+	body string,
+) error {
+	if err := s.ensureRepositoryInWorkspaceScope(ctx, workspaceID, owner, repo); err != nil {
+		return err
+	}
+	resolved, err := s.resolveAutomationClient(ctx, workspaceID, owner, repo)
+	if err != nil {
+		return err
+	}
+	if err := requireGitHubCapability(resolved, CapabilityPullRequestWrite); err != nil {
+		return err
+	}
+	return resolved.Client.SubmitReview(ctx, owner, repo, number, "COMMENT", body)
+}
@@ -34,6 +34,10 @@ type Client interface {
 	// for a project.
 	ListAuthoredMRs(ctx context.Context, projectPath string) ([]*MR, error)
 
+	// ListProjectMRs lists open MRs of a project, whoever authored them.
+	// Used by the automation gitlab_mr trigger poller.
+	ListProjectMRs(ctx context.Context, projectPath string) ([]*MR, error)
+
 	// ListReviewRequestedMRs lists open MRs where the user is a reviewer.
 	// filter is an optional additional GitLab API filter (e.g.
 	// "project_id=123" or "milestone=v1"); customQuery, when non-empty,
@@ -56,6 +60,9 @@ type Client interface {
 	// returned.
 	ListMRDiscussions(ctx context.Context, projectPath string, iid int, since *time.Time) ([]MRDiscussion, error)
 
+	// CreateMRNote posts a top-level comment on a merge request.
+	CreateMRNote(ctx context.Context, projectPath string, iid int, body string) (*MRNote, error)
+
 	// CreateMRDiscussionNote posts a reply note in an existing discussion.
 	CreateMRDiscussionNote(ctx context.Context, projectPath string, iid int, discussionID, body string) (*MRNote, error)
 
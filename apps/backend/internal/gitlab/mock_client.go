@@ -253,6 +253,18 @@ func (c *MockClient) ListAuthoredMRs(_ context.Context, projectPath string) ([]*
 	return out, nil
 }
 
+func (c *MockClient) ListProjectMRs(_ context.Context, projectPath string) ([]*MR, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := []*MR{}
+	for _, mr := range c.mrs {
+		if mr.ProjectPath == projectPath && mr.State == mrStateOpen {
+			out = append(out, mr)
+		}
+	}
+	return out, nil
+}
+
 func (c *MockClient) ListReviewRequestedMRs(context.Context, string, string) ([]*MR, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -291,6 +303,23 @@ func (c *MockClient) ListMRDiscussions(_ context.Context, projectPath string, ii
 	return c.discussions[mockMRKey{Project: projectPath, IID: iid}], nil
 }
 
+// CreateMRNote records the comment as a new single-note discussion, the way
+// GitLab stores a top-level note.
+func (c *MockClient) CreateMRNote(_ context.Context, projectPath string, iid int, body string) (*MRNote, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := mockMRKey{Project: projectPath, IID: iid}
+	now := time.Now().UTC()
+	note := MRNote{ID: now.UnixNano(), Author: c.username, Body: body, CreatedAt: now, UpdatedAt: now}
+	c.discussions[key] = append(c.discussions[key], MRDiscussion{
+		ID:        fmt.Sprintf("note-%d", note.ID),
+		Notes:     []MRNote{note},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	return &note, nil
+}
+
 func (c *MockClient) CreateMRDiscussionNote(_ context.Context, projectPath string, iid int, discussionID, body string) (*MRNote, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
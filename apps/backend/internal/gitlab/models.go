@@ -247,9 +247,14 @@ type Group struct {
 	AvatarURL string `json:"avatar_url"`
 }
 
-// RepoBranch represents a branch in a GitLab project.
+// RepoBranch represents a branch in a GitLab project. The commit fields
+// describe the branch head; automation push triggers compare CommitSHA
+// between polls.
 type RepoBranch struct {
-	Name string `json:"name"`
+	Name        string     `json:"name"`
+	CommitSHA   string     `json:"commit_sha,omitempty"`
+	CommitTitle string     `json:"commit_title,omitempty"`
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
 }
 
 // Tree entry types as returned by GitLab's repository/tree endpoint.
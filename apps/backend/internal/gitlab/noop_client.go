@@ -47,6 +47,10 @@ func (c *NoopClient) ListAuthoredMRs(context.Context, string) ([]*MR, error) {
 	return nil, ErrNoClient
 }
 
+func (c *NoopClient) ListProjectMRs(context.Context, string) ([]*MR, error) {
+	return nil, ErrNoClient
+}
+
 func (c *NoopClient) ListReviewRequestedMRs(context.Context, string, string) ([]*MR, error) {
 	return nil, ErrNoClient
 }
@@ -67,6 +71,10 @@ func (c *NoopClient) ListMRDiscussions(context.Context, string, int, *time.Time)
 	return nil, ErrNoClient
 }
 
+func (c *NoopClient) CreateMRNote(context.Context, string, int, string) (*MRNote, error) {
+	return nil, ErrNoClient
+}
+
 func (c *NoopClient) CreateMRDiscussionNote(context.Context, string, int, string, string) (*MRNote, error) {
 	return nil, ErrNoClient
 }
@@ -46,9 +46,11 @@ func TestNoopClient_DataMethods_ReturnErrNoClient(t *testing.T) {
 		{"GetMR", mustErr2(c.GetMR(ctx, "g/p", 1))},
 		{"FindMRByBranch", mustErr2(c.FindMRByBranch(ctx, "g/p", "feat"))},
 		{"ListAuthoredMRs", mustErr2(c.ListAuthoredMRs(ctx, "g/p"))},
+		{"ListProjectMRs", mustErr2(c.ListProjectMRs(ctx, "g/p"))},
 		{"ListReviewRequestedMRs", mustErr2(c.ListReviewRequestedMRs(ctx, "", ""))},
 		{"ListMRApprovals", mustErr2(c.ListMRApprovals(ctx, "g/p", 1))},
 		{"ListMRDiscussions", mustErr2(c.ListMRDiscussions(ctx, "g/p", 1, nil))},
+		{"CreateMRNote", mustErr2(c.CreateMRNote(ctx, "g/p", 1, "body"))},
 		{"CreateMRDiscussionNote", mustErr2(c.CreateMRDiscussionNote(ctx, "g/p", 1, "d", "body"))},
 		{"ResolveMRDiscussion", c.ResolveMRDiscussion(ctx, "g/p", 1, "d")},
 		{"ListPipelines", mustErr2(c.ListPipelines(ctx, "g/p", "main"))},
@@ -226,6 +226,15 @@ func (c *PATClient) ListAuthoredMRs(ctx context.Context, projectPath string) ([]
 	return convertRawMRSlice(raw), nil
 }
 
+func (c *PATClient) ListProjectMRs(ctx context.Context, projectPath string) ([]*MR, error) {
+	var raw []rawMR
+	endpoint := fmt.Sprintf("/projects/%s/merge_requests?state=opened&per_page=100", projectRef(projectPath))
+	if err := c.get(ctx, endpoint, &raw); err != nil {
+		return nil, fmt.Errorf("list project MRs: %w", err)
+	}
+	return convertRawMRSlice(raw), nil
+}
+
 func (c *PATClient) ListReviewRequestedMRs(ctx context.Context, filter, customQuery string) ([]*MR, error) {
 	query := buildReviewMRQuery(filter, customQuery)
 	endpoint := "/merge_requests?" + query
@@ -397,6 +406,17 @@ func (c *PATClient) ListMRDiscussions(ctx context.Context, projectPath string, i
 	return discussions, nil
 }
 
+func (c *PATClient) CreateMRNote(ctx context.Context, projectPath string, iid int, body string) (*MRNote, error) {
+	endpoint := fmt.Sprintf("/projects/%s/merge_requests/%d/notes", projectRef(projectPath), iid)
+	payload := map[string]string{"body": body}
+	var raw rawNote
+	if err := c.postJSON(ctx, endpoint, payload, &raw); err != nil {
+		return nil, fmt.Errorf("create note: %w", err)
+	}
+	note := convertRawNote(&raw)
+	return &note, nil
+}
+
 func (c *PATClient) CreateMRDiscussionNote(ctx context.Context, projectPath string, iid int, discussionID, body string) (*MRNote, error) {
 	endpoint := fmt.Sprintf("/projects/%s/merge_requests/%d/discussions/%s/notes",
 		projectRef(projectPath), iid, url.PathEscape(discussionID))
@@ -637,14 +657,24 @@ func (c *PATClient) ListProjectBranches(ctx context.Context, projectPath string)
 	var branches []RepoBranch
 	for endpoint != "" {
 		var page []struct {
-			Name string `json:"name"`
+			Name   string `json:"name"`
+			Commit struct {
+				ID            string     `json:"id"`
+				Title         string     `json:"title"`
+				CommittedDate *time.Time `json:"committed_date"`
+			} `json:"commit"`
 		}
 		nextLink, err := c.getPaginated(ctx, endpoint, &page)
 		if err != nil {
 			return nil, fmt.Errorf("list branches: %w", err)
 		}
 		for _, b := range page {
-			branches = append(branches, RepoBranch{Name: b.Name})
+			branches = append(branches, RepoBranch{
+				Name:        b.Name,
+				CommitSHA:   b.Commit.ID,
+				CommitTitle: b.Commit.Title,
+				CommittedAt: b.Commit.CommittedDate,
+			})
 		}
 		endpoint = nextLink
 	}
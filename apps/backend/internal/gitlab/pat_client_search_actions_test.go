@@ -247,6 +247,18 @@ func TestPATClientMRListQueries(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "project",
+			call: func(c *PATClient) ([]*MR, error) { return c.ListProjectMRs(t.Context(), "acme/widget") },
+			check: func(t *testing.T, r *http.Request) {
+				if got, want := r.URL.Path, "/projects/acme/widget/merge_requests"; got != want {
+					t.Errorf("path = %q, want %q", got, want)
+				}
+				if got := r.URL.Query().Get("author_username"); got != "" {
+					t.Errorf("author_username = %q, want unset", got)
+				}
+			},
+		},
 		{
 			name: "review requested",
 			call: func(c *PATClient) ([]*MR, error) {
@@ -64,7 +64,7 @@ func TestPATClient_AnonymousReadOmitsPrivateToken(t *testing.T) {
 		if got := r.Header.Get("PRIVATE-TOKEN"); got != "" {
 			t.Fatalf("PRIVATE-TOKEN = %q, want absent", got)
 		}
-		_, _ = w.Write([]byte(`[{"name":"main"}]`))
+		_, _ = w.Write([]byte(`[{"name":"main","commit":{"id":"abc123","title":"Fix build"}}]`))
 	}))
 	t.Cleanup(stop)
 
@@ -72,8 +72,8 @@ func TestPATClient_AnonymousReadOmitsPrivateToken(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ListProjectBranches() error = %v", err)
 	}
-	if len(branches) != 1 || branches[0].Name != "main" {
-		t.Fatalf("branches = %#v, want main", branches)
+	if len(branches) != 1 || branches[0].Name != "main" || branches[0].CommitSHA != "abc123" || branches[0].CommitTitle != "Fix build" {
+		t.Fatalf("branches = %#v, want main at abc123", branches)
 	}
 }
 
@@ -267,6 +267,27 @@ func TestPATClient_CreateMR_NoDoubleDraftPrefix(t *testing.T) {
 	}
 }
 
+func TestPATClient_CreateMRNote(t *testing.T) {
+	var receivedBody map[string]any
+	host, stop := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.EscapedPath(), "/projects/g%2Fp/merge_requests/3/notes") {
+			t.Errorf("request = %s %s", r.Method, r.URL.EscapedPath())
+		}
+		_ = json.NewDecoder(r.Body).Decode(&receivedBody)
+		_, _ = w.Write([]byte(`{"id":11,"body":"done","author":{"username":"bot"}}`))
+	}))
+	defer stop()
+
+	c := NewPATClient(host, "tok")
+	note, err := c.CreateMRNote(context.Background(), "g/p", 3, "done")
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if note.ID != 11 || receivedBody["body"] != "done" {
+		t.Errorf("note = %+v, body sent = %v", note, receivedBody)
+	}
+}
+
 func TestPATClient_ResolveDiscussion_UsesPUT(t *testing.T) {
 	called := false
 	host, stop := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -558,6 +558,17 @@ func (s *Service) CreateMRDiscussionNote(ctx context.Context, projectPath string
 	return s.Client().CreateMRDiscussionNote(ctx, projectPath, iid, discussionID, body)
 }
 
+// CommentOnMRForWorkspace posts a top-level note on a merge request with the
+// workspace's GitLab client.
+func (s *Service) CommentOnMRForWorkspace(ctx context.Context, workspaceID, projectPath string, iid int, body string) error {
+	client, err := s.ClientForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	_, err = client.CreateMRNote(ctx, projectPath, iid, body)
+	return err
+}
+
 // ResolveMRDiscussion proxies to the underlying client.
 func (s *Service) ResolveMRDiscussion(ctx context.Context, projectPath string, iid int, discussionID string) error {
 	return s.Client().ResolveMRDiscussion(ctx, projectPath, iid, discussionID)
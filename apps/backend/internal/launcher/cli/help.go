@@ -9,12 +9,14 @@ Usage:
   kandev [--port <port>] [--verbose] [--debug]
   kandev --dev [--port <port>]
   kandev service install|uninstall|start|stop|restart|status|logs [--system]
+  kandev workflow simulate --workflow <file.yml> --scenario <file.yml> [--json]
 
 Options:
   dev              Use local repo for dev (Go backend + Vite dev server).
   start            Use local production build.
   run              Use installed runtime bundle (default).
   service          Manage kandev as an OS service.
+  workflow         Simulate a workflow export against scripted events.
   --dev            Alias for "dev".
   --version, -V    Print CLI version and exit.
   --port           Port for the Go backend. Alias for --backend-port.
@@ -12,6 +12,7 @@ func TestHelpDescribesPublicCommands(t *testing.T) {
 		"kandev dev",
 		"kandev start",
 		"--dev",
+		"kandev workflow simulate",
 		"--web-internal-port",
 	} {
 		if !strings.Contains(help, want) {
@@ -18,6 +18,9 @@ func Run(args []string, build BuildInfo) int {
 	if len(args) > 0 && args[0] == "service" {
 		return runService(args[1:], build)
 	}
+	if len(args) > 0 && args[0] == "workflow" {
+		return runWorkflow(args[1:])
+	}
 	opts, err := parseArgs(args)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "[kandev] "+err.Error())
@@ -0,0 +1,191 @@
+package launcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kandev/kandev/internal/workflow/simulator"
+)
+
+type workflowArgs struct {
+	Action       string
+	WorkflowFile string
+	ScenarioFile string
+	Name         string
+	JSON         bool
+	ShowHelp     bool
+}
+
+const actionSimulate = "simulate"
+
+const workflowHelp = `kandev workflow — work with workflow definitions offline
+
+Usage:
+  kandev workflow simulate --workflow <file.yml> --scenario <file.yml> [--name <workflow>] [--json]
+
+Options:
+  --workflow    Workflow export (YAML) to simulate.
+  --scenario    Scripted events (YAML or JSON) to drive the workflow with.
+  --name        Workflow to pick when the export holds more than one.
+  --json        Print the full result as JSON instead of a summary.
+`
+
+var workflowStdout io.Writer = os.Stdout
+
+func runWorkflow(argv []string) int {
+	args, err := parseWorkflowArgs(argv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[kandev] "+err.Error())
+		return 2
+	}
+	if args.ShowHelp {
+		fmt.Print(workflowHelp)
+		return 0
+	}
+	if err := simulateWorkflow(context.Background(), args, workflowStdout); err != nil {
+		fmt.Fprintln(os.Stderr, "[kandev] "+err.Error())
+		return 1
+	}
+	return 0
+}
+
+func parseWorkflowArgs(argv []string) (workflowArgs, error) {
+	if len(argv) == 0 || argv[0] == flagHelp || argv[0] == "-h" {
+		return workflowArgs{Action: actionSimulate, ShowHelp: true}, nil
+	}
+	if argv[0] != actionSimulate {
+		return workflowArgs{}, ParseError{Message: fmt.Sprintf("unknown workflow action %q", argv[0])}
+	}
+	out := workflowArgs{Action: actionSimulate}
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		var target *string
+		switch arg {
+		case flagHelp, "-h":
+			out.ShowHelp = true
+			continue
+		case "--json":
+			out.JSON = true
+			continue
+		case "--workflow":
+			target = &out.WorkflowFile
+		case "--scenario":
+			target = &out.ScenarioFile
+		case "--name":
+			target = &out.Name
+		default:
+			return out, ParseError{Message: fmt.Sprintf("unknown workflow simulate option %q", arg)}
+		}
+		value, err := takeValue(argv, i, arg)
+		if err != nil {
+			return out, err
+		}
+		*target = value
+		i++
+	}
+	if out.ShowHelp {
+		return out, nil
+	}
+	if out.WorkflowFile == "" || out.ScenarioFile == "" {
+		return out, ParseError{Message: "kandev workflow simulate requires --workflow <file> and --scenario <file>"}
+	}
+	return out, nil
+}
+
+func simulateWorkflow(ctx context.Context, args workflowArgs, w io.Writer) error {
+	workflowData, err := os.ReadFile(args.WorkflowFile)
+	if err != nil {
+		return fmt.Errorf("read workflow: %w", err)
+	}
+	def, err := simulator.DefinitionFromYAML(workflowData, args.Name)
+	if err != nil {
+		return err
+	}
+	scenarioData, err := os.ReadFile(args.ScenarioFile)
+	if err != nil {
+		return fmt.Errorf("read scenario: %w", err)
+	}
+	var scenario simulator.Scenario
+	if err := yaml.Unmarshal(scenarioData, &scenario); err != nil {
+		return fmt.Errorf("parse scenario: %w", err)
+	}
+	res, err := simulator.Run(ctx, def, scenario)
+	if err != nil {
+		return err
+	}
+	if args.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	printSimulation(w, res)
+	return nil
+}
+
+// printSimulation writes a human-readable summary: the step path, then one
+// block per event with what the engine did in response.
+func printSimulation(w io.Writer, res *simulator.Result) {
+	fmt.Fprintf(w, "workflow: %s\n", res.Workflow)
+	fmt.Fprintf(w, "path:     %s\n\n", strings.Join(res.Path, " -> "))
+	printSimulationEvent(w, "start", res.Start)
+	for _, ev := range res.Events {
+		printSimulationEvent(w, fmt.Sprintf("#%d %s", ev.Index, ev.Type), ev)
+	}
+	fmt.Fprintf(w, "\nfinal step: %s\n", res.FinalStep)
+}
+
+func printSimulationEvent(w io.Writer, label string, ev simulator.EventResult) {
+	fmt.Fprintf(w, "%s [%s] %s\n", label, ev.Step, ev.Outcome)
+	for _, g := range ev.Guards {
+		fmt.Fprintf(w, "  guard   %s %s %s: %s\n", g.Trigger, g.Action, describeGuard(g), guardVerdict(g))
+	}
+	for _, c := range ev.Checks {
+		verdict := "failed"
+		if c.Passed {
+			verdict = "passed"
+		}
+		fmt.Fprintf(w, "  check   %q %s (exit %d)\n", c.Command, verdict, c.ExitCode)
+	}
+	for _, a := range ev.Actions {
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  action  %s %s %s", a.Trigger, a.Kind, a.Detail), " "))
+	}
+	for _, l := range ev.Limits {
+		fmt.Fprintf(w, "  limit   %s reached max_iterations %d\n", l.Step, l.MaxIterations)
+	}
+	for _, t := range ev.Transitions {
+		fmt.Fprintf(w, "  move    %s -> %s (%s)\n", t.From, t.To, t.Trigger)
+	}
+	if ev.Error != "" {
+		fmt.Fprintf(w, "  error   %s\n", ev.Error)
+	}
+}
+
+func describeGuard(g simulator.GuardResult) string {
+	if g.Expression != "" {
+		return fmt.Sprintf("if %q", g.Expression)
+	}
+	return fmt.Sprintf("quorum %s %s", g.Role, g.Threshold)
+}
+
+func guardVerdict(g simulator.GuardResult) string {
+	if g.Error != "" {
+		return "error: " + g.Error
+	}
+	verdict := "not satisfied"
+	if g.Satisfied {
+		verdict = "satisfied"
+	}
+	if g.Required > 0 {
+		verdict += fmt.Sprintf(" (%d/%d)", g.Received, g.Required)
+	}
+	if g.Reason != "" && !g.Satisfied {
+		verdict += " " + g.Reason
+	}
+	return verdict
+}
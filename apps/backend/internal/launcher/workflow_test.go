@@ -0,0 +1,136 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const simulateWorkflowYAML = `version: 1
+type: kandev_workflow
+workflows:
+  - name: Review loop
+    steps:
+      - name: Work
+        position: 0
+        is_start_step: true
+        events:
+          on_turn_complete:
+            - type: move_to_next
+      - name: Review
+        position: 1
+        events:
+          on_turn_complete:
+            - type: move_to_next
+              config:
+                wait_for_quorum:
+                  role: reviewer
+                  threshold: all_approve
+      - name: Done
+        position: 2
+`
+
+const simulateScenarioYAML = `participants:
+  - id: alice
+    step: Review
+    role: reviewer
+events:
+  - type: turn_complete
+  - type: decision
+    participant: alice
+    decision: approved
+`
+
+func writeSimulationFiles(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	workflow := filepath.Join(dir, "workflow.yml")
+	scenario := filepath.Join(dir, "scenario.yml")
+	if err := os.WriteFile(workflow, []byte(simulateWorkflowYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(scenario, []byte(simulateScenarioYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return workflow, scenario
+}
+
+func TestParseWorkflowArgs(t *testing.T) {
+	args, err := parseWorkflowArgs([]string{"simulate", "--workflow", "w.yml", "--scenario", "s.yml", "--name", "Review loop", "--json"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if args.WorkflowFile != "w.yml" || args.ScenarioFile != "s.yml" || args.Name != "Review loop" || !args.JSON {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	if args, err := parseWorkflowArgs(nil); err != nil || !args.ShowHelp {
+		t.Fatalf("bare workflow command should show help, got %+v, %v", args, err)
+	}
+	for _, argv := range [][]string{
+		{"lint"},
+		{"simulate", "--workflow", "w.yml"},
+		{"simulate", "--workflow", "w.yml", "--scenario"},
+		{"simulate", "--workflow", "w.yml", "--scenario", "s.yml", "--verbose"},
+	} {
+		var parseErr ParseError
+		if _, err := parseWorkflowArgs(argv); !errors.As(err, &parseErr) {
+			t.Fatalf("%v: expected ParseError, got %v", argv, err)
+		}
+	}
+}
+
+func TestSimulateWorkflowPrintsPathAndGuards(t *testing.T) {
+	workflow, scenario := writeSimulationFiles(t)
+	var out bytes.Buffer
+	err := simulateWorkflow(context.Background(), workflowArgs{WorkflowFile: workflow, ScenarioFile: scenario}, &out)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"path:     Work -> Review -> Done",
+		"move    Work -> Review (on_turn_complete)",
+		"guard   on_turn_complete move_to_next quorum reviewer all_approve: satisfied (1/1)",
+		"final step: Done",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("output missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestSimulateWorkflowJSON(t *testing.T) {
+	workflow, scenario := writeSimulationFiles(t)
+	var out bytes.Buffer
+	err := simulateWorkflow(context.Background(), workflowArgs{WorkflowFile: workflow, ScenarioFile: scenario, JSON: true}, &out)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	var res struct {
+		Path      []string `json:"path"`
+		FinalStep string   `json:"final_step"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatalf("decode: %v\n%s", err, out.String())
+	}
+	if res.FinalStep != "Done" || len(res.Path) != 3 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestSimulateWorkflowRejectsUnknownParticipant(t *testing.T) {
+	workflow, _ := writeSimulationFiles(t)
+	scenario := filepath.Join(t.TempDir(), "scenario.yml")
+	if err := os.WriteFile(scenario, []byte("events:\n  - type: decision\n    participant: carol\n    decision: approved\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := simulateWorkflow(context.Background(), workflowArgs{WorkflowFile: workflow, ScenarioFile: scenario}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "carol") {
+		t.Fatalf("expected unknown participant error, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/orchestrator"
+	ws "github.com/kandev/kandev/pkg/websocket"
+)
+
+// FanOutSelector records a fan-out judge's pick. The orchestrator owns the
+// join state and checks that the caller is the judge of its parent's
+// fan-out.
+type FanOutSelector interface {
+	SelectFanOutWinnerAsJudge(ctx context.Context, judgeTaskID, winnerTaskID, reason string) error
+}
+
+// SetFanOutSelector wires the select_fan_out_winner_kandev MCP tool.
+func (h *Handlers) SetFanOutSelector(selector FanOutSelector) {
+	h.fanOutSelector = selector
+}
+
+// handleSelectFanOutWinner dispatches mcp.select_fan_out_winner. task_id
+// is the calling (judge) task, set by the MCP server from its binding.
+func (h *Handlers) handleSelectFanOutWinner(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	if h.fanOutSelector == nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "fan-out selection is not configured", nil)
+	}
+	var req struct {
+		TaskID       string `json:"task_id"`
+		WinnerTaskID string `json:"winner_task_id"`
+		Reason       string `json:"reason"`
+	}
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "Invalid payload: "+err.Error(), nil)
+	}
+	req.TaskID = strings.TrimSpace(req.TaskID)
+	req.WinnerTaskID = strings.TrimSpace(req.WinnerTaskID)
+	if req.TaskID == "" || req.WinnerTaskID == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "task_id and winner_task_id are required", nil)
+	}
+	if err := h.fanOutSelector.SelectFanOutWinnerAsJudge(ctx, req.TaskID, req.WinnerTaskID, req.Reason); err != nil {
+		if errors.Is(err, orchestrator.ErrInvalidFanOutSelection) {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, err.Error(), nil)
+		}
+		h.logger.Error("select_fan_out_winner: failed to record selection",
+			zap.String("task_id", req.TaskID), zap.Error(err))
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "failed to record fan-out selection", nil)
+	}
+	return ws.NewResponse(msg.ID, msg.Action, map[string]interface{}{
+		keyTaskID:        req.TaskID,
+		"winner_task_id": req.WinnerTaskID,
+	})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kandev/kandev/internal/orchestrator"
+	ws "github.com/kandev/kandev/pkg/websocket"
+)
+
+type recordingFanOutSelector struct {
+	err   error
+	calls [][3]string
+}
+
+func (s *recordingFanOutSelector) SelectFanOutWinnerAsJudge(_ context.Context, judgeTaskID, winnerTaskID, reason string) error {
+	s.calls = append(s.calls, [3]string{judgeTaskID, winnerTaskID, reason})
+	return s.err
+}
+
+func TestHandleSelectFanOutWinner(t *testing.T) {
+	selector := &recordingFanOutSelector{}
+	h := &Handlers{fanOutSelector: selector, logger: testLogger(t)}
+
+	msg := makeWSMessage(t, ws.ActionMCPSelectFanOutWinner, map[string]interface{}{
+		"task_id": "judge", "winner_task_id": "branch-1", "reason": "cleanest",
+	})
+	resp, err := h.handleSelectFanOutWinner(context.Background(), msg)
+	if err != nil || resp.Type == ws.MessageTypeError {
+		t.Fatalf("unexpected failure: resp=%+v err=%v", resp, err)
+	}
+	if len(selector.calls) != 1 || selector.calls[0] != [3]string{"judge", "branch-1", "cleanest"} {
+		t.Fatalf("unexpected selector calls: %+v", selector.calls)
+	}
+
+	selector.err = fmt.Errorf("%w: fan-out is joined", orchestrator.ErrInvalidFanOutSelection)
+	resp, _ = h.handleSelectFanOutWinner(context.Background(), msg)
+	assertWSError(t, resp, ws.ErrorCodeValidation)
+
+	resp, _ = h.handleSelectFanOutWinner(context.Background(), makeWSMessage(t, ws.ActionMCPSelectFanOutWinner, map[string]interface{}{"task_id": "judge"}))
+	assertWSError(t, resp, ws.ErrorCodeValidation)
+}
@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	ws "github.com/kandev/kandev/pkg/websocket"
+)
+
+// registerSelectFanOutWinnerTool registers select_fan_out_winner_kandev,
+// the tool a fan-out judge task uses to pick the winning branch. The judge
+// is resolved from the bound task; the backend rejects callers that are
+// not the judge of a fan-out awaiting its decision.
+func (s *Server) registerSelectFanOutWinnerTool() {
+	s.mcpServer.AddTool(
+		mcp.NewTool("select_fan_out_winner_kandev",
+			mcp.WithDescription(`Pick the winning branch of a fan-out step. Only available to the judge task of a fan-out: the candidates and their task ids are listed in your task description. Call this once, after comparing every candidate's changes.`),
+			mcp.WithString("winner_task_id", mcp.Required(), mcp.Description("Task id of the winning candidate.")),
+			mcp.WithString("reason", mcp.Required(), mcp.Description("Short explanation of why this candidate won. Recorded on the parent task.")),
+		),
+		s.wrapHandler("select_fan_out_winner_kandev", s.selectFanOutWinnerHandler()),
+	)
+}
+
+func (s *Server) selectFanOutWinnerHandler() server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if s.taskID == "" {
+			return mcp.NewToolResultError("select_fan_out_winner_kandev requires a bound task"), nil
+		}
+		winner := strings.TrimSpace(req.GetString("winner_task_id", ""))
+		reason := strings.TrimSpace(req.GetString("reason", ""))
+		if winner == "" || reason == "" {
+			return mcp.NewToolResultError("winner_task_id and reason are required"), nil
+		}
+		payload := map[string]interface{}{
+			mcpKeyTaskID:     s.taskID,
+			"winner_task_id": winner,
+			"reason":         reason,
+		}
+		var result map[string]interface{}
+		if err := s.backend.RequestPayload(ctx, ws.ActionMCPSelectFanOutWinner, payload, &result); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		data, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(string(data)), nil
+	}
+}
@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"testing"
+
+	ws "github.com/kandev/kandev/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectFanOutWinner_SendsBoundJudgeTask(t *testing.T) {
+	backend := &testBackend{response: map[string]interface{}{"winner_task_id": "branch-2"}}
+	s := New(backend, "judge-session", "judge-task", 10005, newTestLogger(t), "", false, ModeTask)
+
+	result := callTool(t, s, "select_fan_out_winner_kandev", map[string]interface{}{
+		"winner_task_id": " branch-2 ",
+		"reason":         "smallest diff, tests pass",
+	})
+
+	require.False(t, result.IsError)
+	assert.Equal(t, ws.ActionMCPSelectFanOutWinner, backend.lastAction)
+	payload, ok := backend.lastPayload.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "judge-task", payload["task_id"], "the judge is always the bound task")
+	assert.Equal(t, "branch-2", payload["winner_task_id"])
+	assert.Equal(t, "smallest diff, tests pass", payload["reason"])
+}
+
+func TestSelectFanOutWinner_RejectsMissingArguments(t *testing.T) {
+	backend := &testBackend{}
+	s := New(backend, "judge-session", "judge-task", 10005, newTestLogger(t), "", false, ModeTask)
+
+	result := callTool(t, s, "select_fan_out_winner_kandev", map[string]interface{}{
+		"winner_task_id": "branch-2",
+		"reason":         "  ",
+	})
+
+	require.True(t, result.IsError)
+	assert.Empty(t, backend.lastAction, "backend must not be called without a reason")
+}
@@ -923,6 +923,7 @@ func (s *Server) profileToolGroups() []profileToolGroup {
 			s.registerUpdateRepositoryBaseBranchTool()
 		}},
 		{name: "step-completion", enabled: kanban, register: func(s *Server) { s.registerStepCompleteTool() }},
+		{name: "fan-out", enabled: kanban, register: func(s *Server) { s.registerSelectFanOutWinnerTool() }},
 		{name: "task-title", enabled: andProfilePredicates(kanban, capabilityEnabled(mcpprofile.CapabilityTaskTitle)), register: func(s *Server) { s.registerSetTaskTitleTool() }},
 		{name: "diagnostics", enabled: kanban, register: func(s *Server) { s.registerDiagnosticBundleTool() }},
 	}
@@ -709,7 +709,7 @@ drained:
 	// as in TestServerModeTask_ToolCount and
 	// TestRegisterTools_LoggedCountMatchesRegisteredTools (list_task_sessions_test.go),
 	// which pin the per-mode registration rather than this SetProviders rebuild.
-	require.Len(t, tools, 36, "final registry should contain the complete GitLab-only task tool set")
+	require.Len(t, tools, 37, "final registry should contain the complete GitLab-only task tool set")
 	assert.Contains(t, tools, "get_task_mr_automation_kandev")
 	assert.NotContains(t, tools, "get_task_pr_automation_kandev")
 }
@@ -876,9 +876,9 @@ func TestServerModeTask_ToolCount(t *testing.T) {
 	// 20 kanban (incl. delete + archive task + stop_task + spawn_session +
 	// list_task_sessions + PR automation + MR automation) + 1 add_branch_to_task +
 	// 1 add_workspace_sources + 1 update_repository_base_branch +
-	// 1 step_complete (ADR 0015) + 1 interaction + 4 plan + 3 walkthrough +
-	// 1 publish_review_findings + 1 related-tasks + 1 diagnostic bundle
-	// + 2 task-dependency (add/remove) + 1 rich-output = 38.
+	// 1 step_complete (ADR 0015) + 1 select_fan_out_winner + 1 interaction +
+	// 4 plan + 3 walkthrough + 1 publish_review_findings + 1 related-tasks +
+	// 1 diagnostic bundle + 2 task-dependency (add/remove) + 1 rich-output = 39.
 	// Task-document tools (list/get/write) are office-only.
 	assert.Contains(t, tools, "step_complete_kandev", "ADR 0015 explicit-completion signal must be registered in task mode")
 	assert.Contains(t, tools, "show_walkthrough_kandev", "walkthrough tool must be registered in task mode")
@@ -889,7 +889,8 @@ func TestServerModeTask_ToolCount(t *testing.T) {
 	assert.Contains(t, tools, "add_task_dependency_kandev", "dependency edges must be manageable in task mode")
 	assert.Contains(t, tools, "remove_task_dependency_kandev")
 	assert.Contains(t, tools, "show_rich_output_kandev", "native rich output must be registered in task mode")
-	assert.Equal(t, 38, len(tools))
+	assert.Contains(t, tools, "select_fan_out_winner_kandev", "fan-out judges run as task-mode sessions")
+	assert.Equal(t, 39, len(tools))
 }
 
 func TestServerStepCompleteTool_TaskOnlyAndDiscoverable(t *testing.T) {
@@ -25,6 +25,7 @@ const (
 	EventTaskSessionClarificationAsked = "session.clarification_requested"
 	EventOfficeInboxItem               = "office.inbox_item"
 	EventSystemUpdateAvailable         = "system.update_available"
+	EventWorkflowNotification          = "workflow.notification"
 	desktopNativeNotificationsEnv      = "KANDEV_DESKTOP_NATIVE_NOTIFICATIONS"
 )
 
@@ -70,7 +71,7 @@ func (s *Service) AppriseAvailable() bool {
 }
 
 func (s *Service) AvailableEvents() []string {
-	return []string{EventTaskSessionTurnFinished, EventTaskSessionClarificationAsked, EventOfficeInboxItem, EventSystemUpdateAvailable}
+	return []string{EventTaskSessionTurnFinished, EventTaskSessionClarificationAsked, EventOfficeInboxItem, EventSystemUpdateAvailable, EventWorkflowNotification}
 }
 
 func (s *Service) ListProviders(ctx context.Context, userID string) ([]*models.Provider, map[string][]string, error) {
@@ -188,6 +189,16 @@ func (s *Service) HandleUpdateAvailable(ctx context.Context, version, releaseURL
 	})
 }
 
+// HandleWorkflowNotification sends a notification raised by a workflow
+// step's notify action. occurrenceID is the engine operation id, so a
+// redelivered trigger does not notify twice.
+func (s *Service) HandleWorkflowNotification(ctx context.Context, taskID, sessionID, occurrenceID, title, body string) {
+	s.handleSemanticOccurrence(ctx, taskID, sessionID, occurrenceID, EventWorkflowNotification, map[string]string{
+		"title": title,
+		"body":  body,
+	})
+}
+
 func (s *Service) handleSemanticOccurrence(ctx context.Context, taskID, sessionID, occurrenceID, eventType string, payload map[string]string) {
 	if occurrenceID == "" {
 		return
@@ -311,6 +322,9 @@ func (s *Service) buildSemanticMessage(ctx context.Context, taskID, eventType st
 	if eventType == EventSystemUpdateAvailable {
 		return semanticMessageCopy(eventType, payload["version"])
 	}
+	if eventType == EventWorkflowNotification {
+		return payload["title"], payload["body"]
+	}
 	title, body := semanticMessageCopy(eventType, "")
 	if taskID == "" || s.taskRepo == nil {
 		return title, body
@@ -375,6 +389,7 @@ func (s *Service) ensureDefaultProviders(ctx context.Context, userID string) err
 			EventTaskSessionClarificationAsked,
 			EventOfficeInboxItem,
 			EventSystemUpdateAvailable,
+			EventWorkflowNotification,
 		}); err != nil {
 			return err
 		}
@@ -410,6 +425,7 @@ func (s *Service) ensureSystemProvider(ctx context.Context, userID string) error
 		EventTaskSessionClarificationAsked,
 		EventOfficeInboxItem,
 		EventSystemUpdateAvailable,
+		EventWorkflowNotification,
 	})
 }
 
@@ -379,3 +379,30 @@ func TestProviderSendsClarificationAction(t *testing.T) {
 		t.Fatalf("test message = %#v, want clarification action", capture.messages)
 	}
 }
+
+func TestWorkflowNotificationUsesActionCopyAndOperationIdempotency(t *testing.T) {
+	t.Setenv(desktopNativeNotificationsEnv, "true")
+	log, err := logger.NewFromZap(zap.NewNop())
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	repo := &notificationTestRepository{
+		providers: []*models.Provider{{ID: "provider-1", Type: models.ProviderTypeLocal, Enabled: true}},
+		subscriptions: map[string][]*models.Subscription{
+			"provider-1": {{ProviderID: "provider-1", EventType: EventWorkflowNotification, Enabled: true}},
+		},
+	}
+	service := NewService(repo, nil, nil, log)
+	capture := &captureProvider{}
+	service.providers[models.ProviderTypeLocal] = capture
+
+	service.HandleWorkflowNotification(context.Background(), "task-1", "session-1", "op-1", "Task overdue in Review", "Waiting 2d")
+	service.HandleWorkflowNotification(context.Background(), "task-1", "session-1", "op-1", "Task overdue in Review", "Waiting 2d")
+
+	if len(capture.messages) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(capture.messages))
+	}
+	if got := capture.messages[0]; got.EventType != EventWorkflowNotification || got.Title != "Task overdue in Review" || got.Body != "Waiting 2d" || got.TaskID != "task-1" {
+		t.Fatalf("workflow notification = %#v", got)
+	}
+}
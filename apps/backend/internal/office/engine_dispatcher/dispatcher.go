@@ -106,7 +106,8 @@ func New(eng EngineHandle, sessions SessionResolver, log *logger.Logger) *Dispat
 // HandleTrigger satisfies shared.WorkflowEngineDispatcher.
 //
 // Resolves the task's active session — or, for comment wakes, the latest
-// reusable completed/idle session — then invokes engine.HandleTrigger. Errors from the
+// reusable completed/idle session, and for step timeouts the latest session
+// in any state — then invokes engine.HandleTrigger. Errors from the
 // engine (e.g. queue_run resolver failures) bubble up so the office event
 // subscriber can log them.
 func (d *Dispatcher) HandleTrigger(
@@ -167,6 +168,12 @@ func (d *Dispatcher) resolveSession(
 	if err != nil && !errors.Is(err, taskmodels.ErrTaskSessionNotFound) {
 		return nil, fmt.Errorf("active session lookup: %w", err)
 	}
+	if trigger == engine.TriggerOnTimeout {
+		// A step timeout escalates a task that is, by definition, idle in
+		// its step, so any session will do: engine state keys on the
+		// session but the current step comes from the task row.
+		return d.latestSession(ctx, taskID)
+	}
 	if trigger != engine.TriggerOnComment {
 		return nil, nil
 	}
@@ -188,6 +195,17 @@ func (d *Dispatcher) resolveSession(
 	return nil, nil
 }
 
+func (d *Dispatcher) latestSession(ctx context.Context, taskID string) (*taskmodels.TaskSession, error) {
+	session, err := d.sessions.GetTaskSessionByTaskID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, taskmodels.ErrTaskSessionNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("latest session lookup: %w", err)
+	}
+	return session, nil
+}
+
 func isReusableCommentSession(state taskmodels.TaskSessionState) bool {
 	return state == taskmodels.TaskSessionStateCompleted || state == taskmodels.TaskSessionStateIdle
 }
@@ -483,6 +483,27 @@ func TestDispatcher_DoesNotUseLatestSessionForNonCommentTriggers(t *testing.T) {
 	}
 }
 
+func TestDispatcher_UsesLatestSessionForTimeout(t *testing.T) {
+	eng := &fakeEngine{}
+	sessions := &fakeSessions{
+		activeErr: taskmodels.ErrTaskSessionNotFound,
+		latestSession: &taskmodels.TaskSession{
+			ID:    "sess-failed",
+			State: taskmodels.TaskSessionStateFailed,
+		},
+	}
+	d := New(eng, sessions, logger.Default())
+
+	err := d.HandleTrigger(context.Background(), "task-1", engine.TriggerOnTimeout,
+		engine.OnTimeoutPayload{Kind: engine.TimeoutKindWallClock}, "timeout:1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !eng.called || eng.captured.SessionID != "sess-failed" {
+		t.Fatalf("expected timeout to use the latest session, got %+v", eng.captured)
+	}
+}
+
 func TestDispatcher_ReturnsErrNoSessionWhenSessionMissing(t *testing.T) {
 	eng := &fakeEngine{}
 	sessions := &fakeSessions{} // session nil
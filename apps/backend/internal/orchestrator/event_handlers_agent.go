@@ -1382,6 +1382,10 @@ func (s *Service) handleAgentFailedLocked(ctx context.Context, data watcher.Agen
 	// Make all agent CLI failures recoverable — let the user choose to resume or start fresh.
 	if data.SessionID != "" {
 		s.handleRecoverableFailureLocked(ctx, data)
+		// on_agent_error may move the task (e.g. to a triage step). Run it
+		// off this goroutine: the transition's on_exit/on_enter hooks must
+		// not execute under the session's cancel-in-flight guard.
+		go s.processOnAgentErrorViaEngine(context.WithoutCancel(ctx), data)
 		return
 	}
 
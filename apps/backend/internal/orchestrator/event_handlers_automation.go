@@ -70,7 +70,8 @@ func (s *Service) SetAutomationService(svc AutomationService) {
 }
 
 // SetWorktreeManager wires the worktree manager used to reclaim the workspaces
-// of automation runs that have aged out of the retention window.
+// of automation runs that have aged out of the retention window, and to merge
+// a fan-out winner's branch into the parent's worktree.
 //
 // Takes the concrete manager rather than the interface so a nil manager stays
 // nil here: assigning a typed-nil pointer into an interface field produces a
@@ -81,6 +82,7 @@ func (s *Service) SetWorktreeManager(mgr *worktree.Manager) {
 	}
 	s.worktreeReaper = mgr
 	s.taskLaunchRecoveryWorktree = mgr
+	s.fanOutMerger = mgr
 }
 
 // subscribeAutomationEvents subscribes to automation-related events on the event bus.
@@ -127,14 +129,7 @@ func (s *Service) createAutomationTask(ctx context.Context, evt *automation.Auto
 		return
 	}
 
-	// Interpolate prompt with trigger data.
-	prompt := automation.InterpolatePrompt(a.Prompt, evt.TriggerType, evt.TriggerData)
-	if prompt == "" {
-		prompt = fmt.Sprintf("Automation '%s' triggered by %s", a.Name, evt.TriggerType)
-	}
-
-	title := s.resolveAutomationTaskTitle(a, evt)
-	repositories := s.resolveAutomationRepository(ctx, a, evt)
+	title, prompt, repositories := s.renderAutomationTask(ctx, a, evt)
 	if len(repositories) == 0 {
 		errMsg := "no repository available; add a repository to the workspace"
 		s.logger.Warn("automation skipped: "+errMsg,
@@ -244,6 +239,60 @@ func (s *Service) autoStartAutomationTask(ctx context.Context, a *automation.Aut
 		zap.String("automation_id", a.ID))
 }
 
+// renderAutomationTask resolves the title, description and repositories of
+// the task a firing creates. Shared with PreviewAutomationTask so a dry run
+// reports exactly what a real firing would produce.
+func (s *Service) renderAutomationTask(
+	ctx context.Context, a *automation.Automation, evt *automation.AutomationTriggeredEvent,
+) (string, string, []ReviewTaskRepository) {
+	title, prompt := s.renderAutomationTaskText(a, evt)
+	return title, prompt, s.resolveAutomationRepository(ctx, a, evt)
+}
+
+func (s *Service) renderAutomationTaskText(a *automation.Automation, evt *automation.AutomationTriggeredEvent) (string, string) {
+	prompt := automation.InterpolatePrompt(a.Prompt, evt.TriggerType, evt.TriggerData)
+	if prompt == "" {
+		prompt = fmt.Sprintf("Automation '%s' triggered by %s", a.Name, evt.TriggerType)
+	}
+	return s.resolveAutomationTaskTitle(a, evt), prompt
+}
+
+// PreviewAutomationTask satisfies automation.TaskPreviewer for dry runs. It
+// renders the task as renderAutomationTask does, except that a github_pr
+// repository is only looked up: one the workspace has no clone of is
+// reported as a note instead of being cloned and recorded.
+func (s *Service) PreviewAutomationTask(
+	ctx context.Context, a *automation.Automation, triggerType automation.TriggerType, triggerData json.RawMessage,
+) (*automation.TaskPreview, error) {
+	evt := &automation.AutomationTriggeredEvent{
+		AutomationID: a.ID,
+		TriggerType:  triggerType,
+		TriggerData:  triggerData,
+	}
+	title, prompt := s.renderAutomationTaskText(a, evt)
+	var repositories []ReviewTaskRepository
+	var notes []string
+	if evt.TriggerType == automation.TriggerTypeGitHubPR {
+		repositories, notes = s.previewGitHubPRTriggerRepository(ctx, a.WorkspaceID, evt.TriggerData)
+	} else {
+		repositories = s.resolveAutomationRepository(ctx, a, evt)
+	}
+	preview := &automation.TaskPreview{
+		Title:        title,
+		Prompt:       prompt,
+		Repositories: make([]automation.PreviewRepository, 0, len(repositories)),
+		Notes:        notes,
+	}
+	for _, r := range repositories {
+		preview.Repositories = append(preview.Repositories, automation.PreviewRepository{
+			RepositoryID:   r.RepositoryID,
+			BaseBranch:     r.BaseBranch,
+			CheckoutBranch: r.CheckoutBranch,
+		})
+	}
+	return preview, nil
+}
+
 // resolveAutomationRepository determines the repositories for an
 // automation-triggered task. For github_pr triggers, it always extracts
 // repo info from the trigger data — the PR's own repo is the only sensible
@@ -305,21 +354,12 @@ func (s *Service) resolveGitHubPRTriggerRepository(
 	if s.repositoryResolver == nil {
 		return nil
 	}
-	var data struct {
-		Repo       string `json:"repo"`
-		HeadBranch string `json:"head_branch"`
-		BaseBranch string `json:"base_branch"`
-	}
-	if err := json.Unmarshal(triggerData, &data); err != nil || data.Repo == "" {
-		return nil
-	}
-	parts := strings.SplitN(data.Repo, "/", 2)
-	if len(parts) != 2 {
+	data, ok := parsePRTriggerRepository(triggerData)
+	if !ok {
 		return nil
 	}
-	owner, name := parts[0], parts[1]
 	repoID, baseBranch, err := s.repositoryResolver.ResolveForReview(
-		ctx, workspaceID, "github", owner, name, data.BaseBranch,
+		ctx, workspaceID, "github", data.owner, data.name, data.BaseBranch,
 	)
 	if err != nil || repoID == "" {
 		s.logger.Warn("failed to resolve PR trigger repository",
@@ -333,6 +373,60 @@ func (s *Service) resolveGitHubPRTriggerRepository(
 	}}
 }
 
+// previewGitHubPRTriggerRepository is resolveGitHubPRTriggerRepository for a
+// dry run: it only looks the repository up, and notes the clone a firing
+// would make when the workspace has none.
+func (s *Service) previewGitHubPRTriggerRepository(
+	ctx context.Context, workspaceID string, triggerData json.RawMessage,
+) ([]ReviewTaskRepository, []string) {
+	if s.repositoryResolver == nil {
+		return nil, nil
+	}
+	data, ok := parsePRTriggerRepository(triggerData)
+	if !ok {
+		return nil, nil
+	}
+	lookup, ok := s.repositoryResolver.(RepositoryLookup)
+	if !ok {
+		return nil, []string{fmt.Sprintf("repository %s would be resolved when the automation fires", data.Repo)}
+	}
+	repoID, baseBranch, err := lookup.LookupForReview(ctx, workspaceID, "github", data.owner, data.name, data.BaseBranch)
+	if err != nil {
+		s.logger.Warn("failed to look up PR trigger repository",
+			zap.String("repo", data.Repo), zap.Error(err))
+		return nil, nil
+	}
+	if repoID == "" {
+		return nil, []string{fmt.Sprintf("repository %s would be cloned", data.Repo)}
+	}
+	return []ReviewTaskRepository{{
+		RepositoryID:   repoID,
+		BaseBranch:     baseBranch,
+		CheckoutBranch: data.HeadBranch,
+	}}, nil
+}
+
+// prTriggerRepository is the repository a github_pr trigger's data names.
+type prTriggerRepository struct {
+	Repo        string `json:"repo"`
+	HeadBranch  string `json:"head_branch"`
+	BaseBranch  string `json:"base_branch"`
+	owner, name string
+}
+
+func parsePRTriggerRepository(triggerData json.RawMessage) (prTriggerRepository, bool) {
+	var data prTriggerRepository
+	if err := json.Unmarshal(triggerData, &data); err != nil || data.Repo == "" {
+		return data, false
+	}
+	parts := strings.SplitN(data.Repo, "/", 2)
+	if len(parts) != 2 {
+		return data, false
+	}
+	data.owner, data.name = parts[0], parts[1]
+	return data, true
+}
+
 // resolveWorkspaceRepository looks up the workspace's first repository
 // and returns it for task creation. This mirrors how the review watch
 // auto-resolves repos — no manual selector needed.
@@ -445,6 +539,7 @@ func (s *Service) recordFailedRun(ctx context.Context, evt *automation.Automatio
 		Status:       automation.RunStatusFailed,
 		DedupKey:     failDedupKey,
 		TriggerData:  evt.TriggerData,
+		RawPayload:   evt.RawPayload,
 		ErrorMessage: errMsg,
 	}
 	if recordErr := s.automationService.RecordRun(ctx, run); recordErr != nil {
@@ -487,6 +582,7 @@ func (s *Service) recordSuccessRun(
 		Status:       automation.RunStatusTaskCreated,
 		DedupKey:     evt.DedupKey,
 		TriggerData:  evt.TriggerData,
+		RawPayload:   evt.RawPayload,
 	}
 	return s.automationService.RecordRun(ctx, run)
 }
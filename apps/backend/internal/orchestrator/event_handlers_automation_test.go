@@ -151,6 +151,40 @@ func TestResolveAutomationRepository_GitHubPRIgnoresConfiguredRepositoryIDs(t *t
 	}
 }
 
+// lookupOnlyResolver is a RepositoryResolver whose clone path must not be
+// reached: ResolveForReview fails the test, LookupForReview reports repoID.
+type lookupOnlyResolver struct {
+	t      *testing.T
+	repoID string
+}
+
+func (r lookupOnlyResolver) ResolveForReview(context.Context, string, string, string, string, string) (string, string, error) {
+	r.t.Fatal("a preview must not clone or create a repository")
+	return "", "", nil
+}
+
+func (r lookupOnlyResolver) LookupForReview(_ context.Context, _, _, _, _, defaultBranch string) (string, string, error) {
+	return r.repoID, defaultBranch, nil
+}
+
+func TestPreviewAutomationTask_GitHubPROnlyLooksUpRepository(t *testing.T) {
+	svc := createTestService(setupTestRepo(t), newMockStepGetter(), newMockTaskRepo())
+	a := &automation.Automation{WorkspaceID: "ws-1", Name: "review"}
+	data := json.RawMessage(`{"repo":"owner/name","head_branch":"feature/x","base_branch":"main"}`)
+
+	svc.repositoryResolver = lookupOnlyResolver{t: t, repoID: "repo-pr"}
+	preview, err := svc.PreviewAutomationTask(context.Background(), a, automation.TriggerTypeGitHubPR, data)
+	require.NoError(t, err)
+	require.Equal(t, []automation.PreviewRepository{{RepositoryID: "repo-pr", BaseBranch: "main", CheckoutBranch: "feature/x"}}, preview.Repositories)
+	require.Empty(t, preview.Notes)
+
+	svc.repositoryResolver = lookupOnlyResolver{t: t}
+	preview, err = svc.PreviewAutomationTask(context.Background(), a, automation.TriggerTypeGitHubPR, data)
+	require.NoError(t, err)
+	require.Empty(t, preview.Repositories)
+	require.Equal(t, []string{"repository owner/name would be cloned"}, preview.Notes)
+}
+
 // stubReviewTaskCreator records the request the automation handler builds and
 // returns a task the caller can then look up in the repo.
 type stubReviewTaskCreator struct {
@@ -46,6 +46,7 @@ func (s *Service) processParentChildrenCompletedForTaskState(ctx context.Context
 		return
 	}
 
+	s.processFanOutJoin(ctx, child.ParentID)
 	s.processOnChildrenCompleted(ctx, child.ParentID)
 }
 
@@ -104,6 +105,11 @@ func (s *Service) processOnChildrenCompleted(ctx context.Context, parentID strin
 	if !ok {
 		return false
 	}
+	// Checked before the operation is marked applied: the rows do not
+	// change when the fan-out's winner is picked afterwards.
+	if fanOutJoinPending(session, parent.WorkflowStepID) {
+		return false
+	}
 
 	operationID := childCompletionOperationID(parentID, rows)
 	unlock := s.lockChildCompletionOperation(operationID)
@@ -225,14 +231,20 @@ func (s *Service) evaluateChildrenCompleted(
 	rows []models.ChildCompletionRow,
 ) (engine.HandleResult, bool) {
 	state := s.buildMachineState(ctx, parent, session)
-	result, err := s.workflowEngine.HandleTrigger(ctx, engine.HandleInput{
+	in := engine.HandleInput{
 		TaskID:         parent.ID,
 		SessionID:      session.ID,
 		Trigger:        engine.TriggerOnChildrenCompleted,
 		EvaluateOnly:   true,
 		PreloadedState: &state,
 		Payload:        childCompletionPayload(rows),
-	})
+	}
+	result, err := s.workflowEngine.HandleTrigger(ctx, in)
+	if err == nil && isPendingCheck(result) {
+		// The caller marks the operation once the parent moved, under its
+		// lock, so the gate runs here rather than in the background.
+		result, err = s.completePendingCheck(ctx, in, *result.Check)
+	}
 	if err != nil {
 		s.logger.Warn("on_children_completed: workflow engine error",
 			zap.String("parent_task_id", parent.ID),
@@ -100,6 +100,11 @@ func (s *Service) evaluateDependentAfterPredecessorChange(
 	// deferred-launch claim keeps this to one session even if WIP promotion
 	// reaches the task at the same moment.
 	s.publishDependenciesResolved(ctx, dependentID, predecessorID)
+	if s.processOnBlockerResolvedViaEngine(ctx, dependentID, predecessorID) {
+		// The step's on_blocker_resolved moved the task; the target step's
+		// on_enter owns any start from here.
+		return
+	}
 	task, err := s.repo.GetTask(ctx, dependentID)
 	if err != nil || task == nil {
 		s.logger.Warn("dependency resolution: failed to load unblocked task",
@@ -0,0 +1,52 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/egress"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+// handleEgressDenied posts a warning into the session whose container made a
+// request its executor profile's network policy blocked, so the user can
+// see what the agent tried to reach and widen the allowlist if needed.
+func (s *Service) handleEgressDenied(ctx context.Context, denial egress.Denial) {
+	if s.messageCreator == nil || denial.TaskID == "" || denial.SessionID == "" {
+		return
+	}
+	metadata := map[string]interface{}{
+		"variant":       "warning",
+		"egress_denied": true,
+		"egress_host":   denial.Host,
+		"egress_policy": string(denial.Mode),
+	}
+	if err := s.messageCreator.CreateSessionMessage(
+		ctx, denial.TaskID, egressDeniedNoticeContent(denial), denial.SessionID,
+		string(v1.MessageTypeStatus), s.getActiveTurnID(denial.SessionID), metadata, false,
+	); err != nil {
+		s.logger.Warn("failed to persist egress denial notice",
+			zap.String("task_id", denial.TaskID),
+			zap.String("session_id", denial.SessionID),
+			zap.String("host", denial.Host),
+			zap.Error(err))
+	}
+}
+
+func egressDeniedNoticeContent(denial egress.Denial) string {
+	target := denial.Host
+	if denial.Port != "" && denial.Port != "80" && denial.Port != "443" {
+		target = net.JoinHostPort(denial.Host, denial.Port)
+	}
+	content := fmt.Sprintf("Blocked outbound request to %s: not allowed by the executor profile's network policy.", target)
+	switch {
+	case denial.Repeats == 1:
+		content += " 1 earlier request to this host was also blocked."
+	case denial.Repeats > 1:
+		content += fmt.Sprintf(" %d earlier requests to this host were also blocked.", denial.Repeats)
+	}
+	return content
+}
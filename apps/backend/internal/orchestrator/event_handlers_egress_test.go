@@ -0,0 +1,73 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kandev/kandev/internal/egress"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+func TestHandleEgressDenied_PostsWarningNotice(t *testing.T) {
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "task-1", "session-1", "step-1")
+	svc := createTestService(repo, newMockStepGetter(), newMockTaskRepo())
+	messages := &mockMessageCreator{}
+	svc.messageCreator = messages
+
+	svc.handleEgressDenied(context.Background(), egress.Denial{
+		TaskID:    "task-1",
+		SessionID: "session-1",
+		Mode:      egress.ModeAllowlist,
+		Method:    "CONNECT",
+		Host:      "pastebin.com",
+		Port:      "443",
+	})
+
+	if len(messages.sessionMessages) != 1 {
+		t.Fatalf("session messages = %d, want 1", len(messages.sessionMessages))
+	}
+	message := messages.sessionMessages[0]
+	if message.messageType != string(v1.MessageTypeStatus) || message.sessionID != "session-1" {
+		t.Fatalf("message = %+v, want a status message in session-1", message)
+	}
+	if message.metadata["variant"] != "warning" || message.metadata["egress_host"] != "pastebin.com" {
+		t.Fatalf("metadata = %#v", message.metadata)
+	}
+}
+
+func TestHandleEgressDenied_IgnoresUnscopedDenial(t *testing.T) {
+	svc := createTestService(setupTestRepo(t), newMockStepGetter(), newMockTaskRepo())
+	messages := &mockMessageCreator{}
+	svc.messageCreator = messages
+
+	svc.handleEgressDenied(context.Background(), egress.Denial{Host: "pastebin.com"})
+	if len(messages.sessionMessages) != 0 {
+		t.Fatalf("session messages = %d, want 0", len(messages.sessionMessages))
+	}
+}
+
+func TestEgressDeniedNoticeContent(t *testing.T) {
+	tests := []struct {
+		denial egress.Denial
+		want   string
+	}{
+		{
+			denial: egress.Denial{Host: "pastebin.com", Port: "443"},
+			want:   "Blocked outbound request to pastebin.com: not allowed by the executor profile's network policy.",
+		},
+		{
+			denial: egress.Denial{Host: "10.0.0.5", Port: "8080", Repeats: 1},
+			want:   "Blocked outbound request to 10.0.0.5:8080: not allowed by the executor profile's network policy. 1 earlier request to this host was also blocked.",
+		},
+		{
+			denial: egress.Denial{Host: "example.com", Repeats: 4},
+			want:   "Blocked outbound request to example.com: not allowed by the executor profile's network policy. 4 earlier requests to this host were also blocked.",
+		},
+	}
+	for _, tt := range tests {
+		if got := egressDeniedNoticeContent(tt.denial); got != tt.want {
+			t.Errorf("egressDeniedNoticeContent(%+v) = %q, want %q", tt.denial, got, tt.want)
+		}
+	}
+}
@@ -663,6 +663,13 @@ func (s *Service) handlePermissionRequest(ctx context.Context, data watcher.Perm
 		return
 	}
 
+	// Requests the permission policy already answered are only recorded;
+	// the agent is not waiting on anyone.
+	if data.PermissionPolicy != nil {
+		s.recordPolicyPermissionDecision(ctx, data)
+		return
+	}
+
 	s.setSessionWaitingForInput(ctx, data.TaskID, data.TaskSessionID)
 
 	if s.messageCreator != nil {
@@ -115,6 +115,14 @@ type RepositoryResolver interface {
 	ResolveForReview(ctx context.Context, workspaceID, provider, owner, name, defaultBranch string) (repositoryID, baseBranch string, err error)
 }
 
+// RepositoryLookup is implemented by a RepositoryResolver that can find a
+// repo's existing clone without cloning it or creating a Repository record.
+// repositoryID is empty when the workspace has no clone yet. Dry runs use it
+// so a preview never writes.
+type RepositoryLookup interface {
+	LookupForReview(ctx context.Context, workspaceID, provider, owner, name, defaultBranch string) (repositoryID, baseBranch string, err error)
+}
+
 // ReviewTaskRequest contains the data for creating a task from a review watch PR.
 type ReviewTaskRequest struct {
 	WorkspaceID    string
@@ -0,0 +1,57 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+// handleResourceLimitEvent posts a warning into a session that reached one of
+// its executor profile's resource limits, or whose limits could not be
+// applied, so the user knows why the agent died or why it ran unconfined.
+func (s *Service) handleResourceLimitEvent(ctx context.Context, payload *lifecycle.AgentStreamEventPayload) {
+	if s.messageCreator == nil || payload == nil || payload.Data == nil || payload.Data.ResourceLimit == nil {
+		return
+	}
+	if payload.TaskID == "" || payload.SessionID == "" {
+		return
+	}
+	notice := *payload.Data.ResourceLimit
+	metadata := map[string]interface{}{
+		"variant":        "warning",
+		"resource_limit": notice.Limit,
+	}
+	if notice.Killed {
+		metadata["resource_limit_killed"] = true
+	}
+	if err := s.messageCreator.CreateSessionMessage(
+		ctx, payload.TaskID, resourceLimitNoticeContent(notice), payload.SessionID,
+		string(v1.MessageTypeStatus), s.getActiveTurnID(payload.SessionID), metadata, false,
+	); err != nil {
+		s.logger.Warn("failed to persist resource limit notice",
+			zap.String("task_id", payload.TaskID),
+			zap.String("session_id", payload.SessionID),
+			zap.String("limit", notice.Limit),
+			zap.Error(err))
+	}
+}
+
+func resourceLimitNoticeContent(notice streams.ResourceLimitNotice) string {
+	switch {
+	case notice.Error != "":
+		return "Resource limits from the executor profile could not be applied; the agent is running without them: " + notice.Error
+	case notice.Limit == streams.ResourceLimitMemory:
+		return fmt.Sprintf("The agent and its processes were stopped after reaching the %d MB memory limit set by the executor profile.",
+			notice.Max/(1024*1024))
+	case notice.Limit == streams.ResourceLimitPids:
+		return fmt.Sprintf("A process started by the agent was refused: the session reached the %d process limit set by the executor profile.",
+			notice.Max)
+	default:
+		return "The session reached a resource limit set by the executor profile."
+	}
+}
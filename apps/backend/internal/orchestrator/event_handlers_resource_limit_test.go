@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+func TestHandleResourceLimitEvent_PostsWarningNotice(t *testing.T) {
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "task-1", "session-1", "step-1")
+	svc := createTestService(repo, newMockStepGetter(), newMockTaskRepo())
+	messages := &mockMessageCreator{}
+	svc.messageCreator = messages
+
+	svc.handleResourceLimitEvent(context.Background(), &lifecycle.AgentStreamEventPayload{
+		TaskID:    "task-1",
+		SessionID: "session-1",
+		Data: &lifecycle.AgentStreamEventData{
+			Type: streams.EventTypeResourceLimit,
+			ResourceLimit: &streams.ResourceLimitNotice{
+				Limit:  streams.ResourceLimitMemory,
+				Max:    2 << 30,
+				Killed: true,
+			},
+		},
+	})
+
+	if len(messages.sessionMessages) != 1 {
+		t.Fatalf("session messages = %d, want 1", len(messages.sessionMessages))
+	}
+	message := messages.sessionMessages[0]
+	if message.messageType != string(v1.MessageTypeStatus) || message.sessionID != "session-1" {
+		t.Fatalf("message = %+v, want a status message in session-1", message)
+	}
+	if message.metadata["variant"] != "warning" || message.metadata["resource_limit"] != "memory" ||
+		message.metadata["resource_limit_killed"] != true {
+		t.Fatalf("metadata = %#v", message.metadata)
+	}
+}
+
+func TestResourceLimitNoticeContent(t *testing.T) {
+	tests := []struct {
+		notice streams.ResourceLimitNotice
+		want   string
+	}{
+		{
+			notice: streams.ResourceLimitNotice{Limit: streams.ResourceLimitMemory, Max: 512 << 20, Killed: true},
+			want:   "The agent and its processes were stopped after reaching the 512 MB memory limit set by the executor profile.",
+		},
+		{
+			notice: streams.ResourceLimitNotice{Limit: streams.ResourceLimitPids, Max: 256},
+			want:   "A process started by the agent was refused: the session reached the 256 process limit set by the executor profile.",
+		},
+		{
+			notice: streams.ResourceLimitNotice{Error: "cgroup v2 resource limits are not available"},
+			want:   "Resource limits from the executor profile could not be applied; the agent is running without them: cgroup v2 resource limits are not available",
+		},
+	}
+	for _, tt := range tests {
+		if got := resourceLimitNoticeContent(tt.notice); got != tt.want {
+			t.Errorf("resourceLimitNoticeContent(%+v) = %q, want %q", tt.notice, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/orchestrator/watcher"
+	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+// HandleWorkflowEventTrigger runs an event-driven workflow trigger
+// (on_comment, on_blocker_resolved, on_agent_error, …) raised outside the
+// orchestrator's own turn loop. The office engine dispatcher routes through
+// it, as do the orchestrator's own event sources below.
+//
+// Office tasks keep the engine's direct path. Kanban tasks evaluate the
+// trigger evaluate-only and apply any transition through
+// applyEngineTransition, so credential preflight, on_exit, step history,
+// terminal handling and on_enter run exactly as they do for
+// on_turn_complete. Without that, an event-driven move would update the
+// task's step and nothing else.
+func (s *Service) HandleWorkflowEventTrigger(ctx context.Context, in engine.HandleInput) (engine.HandleResult, error) {
+	eng := s.workflowEngine
+	if eng == nil || s.workflowStore == nil {
+		return engine.HandleResult{}, errors.New("workflow engine is not initialized")
+	}
+	if in.EvaluateOnly {
+		return eng.HandleTrigger(ctx, in)
+	}
+	task, err := s.repo.GetTask(ctx, in.TaskID)
+	if err != nil {
+		return engine.HandleResult{}, fmt.Errorf("load task %s: %w", in.TaskID, err)
+	}
+	if task.IsFromOffice {
+		return eng.HandleTrigger(ctx, in)
+	}
+	if in.Trigger == engine.TriggerOnChildrenCompleted {
+		// processOnChildrenCompleted owns this trigger for kanban parents;
+		// its operation id tracks the child rows, so a second source would
+		// move the parent twice.
+		return engine.HandleResult{}, nil
+	}
+	session, err := s.repo.GetTaskSession(ctx, in.SessionID)
+	if err != nil {
+		return engine.HandleResult{}, fmt.Errorf("load session %s: %w", in.SessionID, err)
+	}
+	return s.handleKanbanEventTrigger(ctx, eng, task, session, in)
+}
+
+func (s *Service) handleKanbanEventTrigger(
+	ctx context.Context,
+	eng *engine.Engine,
+	task *models.Task,
+	session *models.TaskSession,
+	in engine.HandleInput,
+) (engine.HandleResult, error) {
+	if task.WorkflowStepID == "" || task.IsEphemeral {
+		return engine.HandleResult{}, nil
+	}
+	// The engine's check-then-mark on the operation id is not atomic;
+	// serialise redeliveries so one evaluation wins.
+	if in.OperationID != "" {
+		unlock := s.lockChildCompletionOperation(in.OperationID)
+		defer unlock()
+	}
+	return s.handleKanbanEventTriggerLocked(ctx, eng, task, session, in)
+}
+
+// handleKanbanEventTriggerLocked is handleKanbanEventTrigger for a caller
+// that already holds the operation lock.
+func (s *Service) handleKanbanEventTriggerLocked(
+	ctx context.Context,
+	eng *engine.Engine,
+	task *models.Task,
+	session *models.TaskSession,
+	in engine.HandleInput,
+) (engine.HandleResult, error) {
+	state := s.buildMachineState(ctx, task, session)
+	evalIn := in
+	evalIn.EvaluateOnly = true
+	evalIn.PreloadedState = &state
+	result, err := eng.HandleTrigger(ctx, evalIn)
+	if err != nil {
+		return engine.HandleResult{}, err
+	}
+	if isPendingCheck(result) {
+		// Persist what the actions before the gate wrote, then let the
+		// gate decide the trigger in the background.
+		if err := s.persistEngineDataPatch(ctx, session.ID, result); err != nil {
+			return result, err
+		}
+		s.runPendingCheck(ctx, in, *result.Check, func(ctx context.Context, result engine.HandleResult) {
+			if _, err := s.resumeKanbanEventTrigger(ctx, in, result); err != nil {
+				s.logger.Warn("failed to apply workflow event after run_check gate",
+					zap.String("task_id", in.TaskID),
+					zap.String("session_id", in.SessionID),
+					zap.String("trigger", string(in.Trigger)),
+					zap.Error(err))
+			}
+		}, nil)
+		return result, nil
+	}
+	return s.commitKanbanEventTrigger(ctx, task, session, in.Trigger, result)
+}
+
+// resumeKanbanEventTrigger commits a kanban event trigger whose run_check
+// gate finished, against the task and session as they are now.
+func (s *Service) resumeKanbanEventTrigger(ctx context.Context, in engine.HandleInput, result engine.HandleResult) (engine.HandleResult, error) {
+	task, err := s.repo.GetTask(ctx, in.TaskID)
+	if err != nil {
+		return result, fmt.Errorf("load task %s: %w", in.TaskID, err)
+	}
+	session, err := s.repo.GetTaskSession(ctx, in.SessionID)
+	if err != nil {
+		return result, fmt.Errorf("load session %s: %w", in.SessionID, err)
+	}
+	return s.commitKanbanEventTrigger(ctx, task, session, in.Trigger, result)
+}
+
+// commitKanbanEventTrigger applies an evaluated kanban event trigger: its
+// data patch when the task stays, the transition otherwise.
+func (s *Service) commitKanbanEventTrigger(
+	ctx context.Context,
+	task *models.Task,
+	session *models.TaskSession,
+	trigger engine.Trigger,
+	result engine.HandleResult,
+) (engine.HandleResult, error) {
+	if !result.Transitioned {
+		// Evaluate-only leaves data patches to the caller; transitions
+		// persist theirs in applyEngineTransition.
+		return result, s.persistEngineDataPatch(ctx, session.ID, result)
+	}
+
+	s.logger.Info("engine: event-driven transition",
+		zap.String("task_id", task.ID),
+		zap.String("session_id", session.ID),
+		zap.String("trigger", string(trigger)),
+		zap.String("from_step_id", result.FromStepID),
+		zap.String("to_step_id", result.ToStepID))
+	if !s.applyEngineTransition(ctx, task.ID, session, result, trigger, task.Description, true) {
+		result.Transitioned = false
+	}
+	return result, nil
+}
+
+// persistEngineDataPatch persists the data an evaluate-only trigger wrote.
+func (s *Service) persistEngineDataPatch(ctx context.Context, sessionID string, result engine.HandleResult) error {
+	if len(result.DataPatch) == 0 {
+		return nil
+	}
+	if err := s.workflowStore.PersistData(ctx, sessionID, result.DataPatch); err != nil {
+		return fmt.Errorf("persist workflow data: %w", err)
+	}
+	return nil
+}
+
+// processOnBlockerResolvedViaEngine dispatches on_blocker_resolved once a
+// task's last dependency resolved. The operation id matches the office
+// subscriber's, so whichever source sees the resolution first handles it.
+// Returns true when the task moved.
+func (s *Service) processOnBlockerResolvedViaEngine(ctx context.Context, taskID, resolvedByTaskID string) bool {
+	if s.workflowEngine == nil {
+		return false
+	}
+	session, err := s.repo.GetActiveTaskSessionByTaskID(ctx, taskID)
+	if err != nil || session == nil {
+		// A blocked task usually has not started yet; there is no engine
+		// state to evaluate against.
+		return false
+	}
+	result, err := s.HandleWorkflowEventTrigger(ctx, engine.HandleInput{
+		TaskID:      taskID,
+		SessionID:   session.ID,
+		Trigger:     engine.TriggerOnBlockerResolved,
+		OperationID: fmt.Sprintf("blockers_resolved:%s", taskID),
+		Payload:     engine.OnBlockerResolvedPayload{ResolvedBlockerIDs: []string{resolvedByTaskID}},
+	})
+	if err != nil {
+		s.logger.Warn("on_blocker_resolved: workflow engine error",
+			zap.String("task_id", taskID),
+			zap.String("session_id", session.ID),
+			zap.Error(err))
+		return false
+	}
+	return result.Transitioned
+}
+
+// processOnAgentErrorViaEngine dispatches on_agent_error for a terminal
+// agent failure on a kanban session. The per-step retry count is bumped in
+// the session's workflow data first, so guards such as
+// `data.agent_error_count >= 3` see this failure. The bump happens under
+// the operation lock and only once the operation id is known to be new, so
+// a redelivered failure leaves the count alone.
+func (s *Service) processOnAgentErrorViaEngine(ctx context.Context, data watcher.AgentEventData) bool {
+	if s.workflowEngine == nil || s.workflowStore == nil || data.SessionID == "" {
+		return false
+	}
+	task, err := s.repo.GetTask(ctx, data.TaskID)
+	if err != nil || task == nil || task.WorkflowStepID == "" || task.IsEphemeral || task.IsFromOffice {
+		return false
+	}
+	opID := ""
+	if data.AgentExecutionID != "" {
+		opID = fmt.Sprintf("agent_error:%s:%s", data.SessionID, data.AgentExecutionID)
+		unlock := s.lockChildCompletionOperation(opID)
+		defer unlock()
+		applied, err := s.workflowStore.IsOperationApplied(ctx, opID)
+		if err != nil || applied {
+			return false
+		}
+	}
+	session, err := s.repo.GetTaskSession(ctx, data.SessionID)
+	if err != nil || session == nil {
+		return false
+	}
+	var bag map[string]any
+	if session.Metadata != nil {
+		bag, _ = session.Metadata["workflow_data"].(map[string]any)
+	}
+	attempt := engine.NextAgentErrorAttempt(bag, task.WorkflowStepID)
+	if err := s.workflowStore.PersistData(ctx, session.ID, engine.AgentErrorDataPatch(task.WorkflowStepID, attempt)); err != nil {
+		s.logger.Warn("on_agent_error: failed to persist retry count",
+			zap.String("task_id", task.ID),
+			zap.String("session_id", session.ID),
+			zap.Error(err))
+		return false
+	}
+	// Reload so the machine state carries the bumped count.
+	session, err = s.repo.GetTaskSession(ctx, session.ID)
+	if err != nil || session == nil {
+		return false
+	}
+
+	result, err := s.handleKanbanEventTriggerLocked(ctx, s.workflowEngine, task, session, engine.HandleInput{
+		TaskID:      task.ID,
+		SessionID:   session.ID,
+		Trigger:     engine.TriggerOnAgentError,
+		OperationID: opID,
+		Payload: engine.OnAgentErrorPayload{
+			FailedAgentID:   session.AgentProfileID,
+			FailedSessionID: session.ID,
+			ErrorMessage:    data.ErrorMessage,
+			StepID:          task.WorkflowStepID,
+			Attempt:         attempt,
+		},
+	})
+	if err != nil {
+		s.logger.Warn("on_agent_error: workflow engine error",
+			zap.String("task_id", task.ID),
+			zap.String("session_id", session.ID),
+			zap.Error(err))
+		return false
+	}
+	return result.Transitioned
+}
@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kandev/kandev/internal/orchestrator/watcher"
+	"github.com/kandev/kandev/internal/workflow/engine"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+func newStepTriggerService(t *testing.T, events wfmodels.StepEvents) (*Service, chan struct{}) {
+	t.Helper()
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "task1", "session1", "step_implement")
+
+	stepGetter := newMockStepGetter()
+	stepGetter.steps["step_implement"] = &wfmodels.WorkflowStep{
+		ID: "step_implement", WorkflowID: "wf1", Name: "Implement", Position: 0, Events: events,
+	}
+	stepGetter.steps["step_review"] = &wfmodels.WorkflowStep{
+		ID: "step_review", WorkflowID: "wf1", Name: "Review", Position: 1,
+	}
+	stepGetter.steps["step_triage"] = &wfmodels.WorkflowStep{
+		ID: "step_triage", WorkflowID: "wf1", Name: "Triage", Position: 2,
+	}
+
+	svc := createEngineService(t, repo, stepGetter, &mockAgentManager{repoForExecutionLookup: repo})
+	onEnterDone := make(chan struct{}, 1)
+	svc.onProcessOnEnterComplete = func() {
+		select {
+		case onEnterDone <- struct{}{}:
+		default:
+		}
+	}
+	return svc, onEnterDone
+}
+
+func TestHandleWorkflowEventTrigger_KanbanCommentRunsLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc, onEnterDone := newStepTriggerService(t, wfmodels.StepEvents{
+		OnComment: []wfmodels.GenericAction{{Type: wfmodels.GenericActionMoveToNext}},
+	})
+
+	in := engine.HandleInput{
+		TaskID:      "task1",
+		SessionID:   "session1",
+		Trigger:     engine.TriggerOnComment,
+		OperationID: "comment:c1",
+		Payload:     engine.OnCommentPayload{CommentID: "c1", Body: "ship it"},
+	}
+	result, err := svc.HandleWorkflowEventTrigger(ctx, in)
+	if err != nil {
+		t.Fatalf("HandleWorkflowEventTrigger: %v", err)
+	}
+	if !result.Transitioned || result.ToStepID != "step_review" {
+		t.Fatalf("expected move to step_review, got %+v", result)
+	}
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	task, err := svc.repo.GetTask(ctx, "task1")
+	if err != nil {
+		t.Fatalf("load task: %v", err)
+	}
+	if task.WorkflowStepID != "step_review" {
+		t.Fatalf("expected task on step_review, got %q", task.WorkflowStepID)
+	}
+
+	// Redelivery of the same comment must not move the task again.
+	result, err = svc.HandleWorkflowEventTrigger(ctx, in)
+	if err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+	if result.Transitioned {
+		t.Fatalf("expected duplicate operation to be a no-op, got %+v", result)
+	}
+}
+
+func TestHandleWorkflowEventTrigger_SkipsKanbanChildrenCompleted(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newStepTriggerService(t, wfmodels.StepEvents{
+		OnChildrenCompleted: []wfmodels.GenericAction{{Type: wfmodels.GenericActionMoveToNext}},
+	})
+
+	result, err := svc.HandleWorkflowEventTrigger(ctx, engine.HandleInput{
+		TaskID:    "task1",
+		SessionID: "session1",
+		Trigger:   engine.TriggerOnChildrenCompleted,
+	})
+	if err != nil {
+		t.Fatalf("HandleWorkflowEventTrigger: %v", err)
+	}
+	if result.Transitioned {
+		t.Fatalf("expected processOnChildrenCompleted to own kanban parents, got %+v", result)
+	}
+}
+
+func TestProcessOnAgentErrorViaEngine_RedeliveryDoesNotBumpRetryCount(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newStepTriggerService(t, wfmodels.StepEvents{
+		OnAgentError: []wfmodels.GenericAction{{
+			Type:   wfmodels.GenericActionMoveToStep,
+			Config: map[string]any{"step_id": "step_triage", "if": "data.agent_error_count >= 2"},
+		}},
+	})
+
+	failure := watcher.AgentEventData{
+		TaskID:           "task1",
+		SessionID:        "session1",
+		AgentExecutionID: "exec1",
+		ErrorMessage:     "agent crashed",
+	}
+	for delivery := 1; delivery <= 2; delivery++ {
+		if moved := svc.processOnAgentErrorViaEngine(ctx, failure); moved {
+			t.Fatalf("delivery %d: one failure must not reach the retry limit", delivery)
+		}
+	}
+
+	session, err := svc.repo.GetTaskSession(ctx, "session1")
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	data, _ := session.Metadata["workflow_data"].(map[string]any)
+	if got := engine.NextAgentErrorAttempt(data, "step_implement"); got != 2 {
+		t.Fatalf("expected one recorded failure after a redelivery, next attempt = %d", got)
+	}
+}
+
+func TestProcessOnAgentErrorViaEngine_RoutesAfterRetryLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, onEnterDone := newStepTriggerService(t, wfmodels.StepEvents{
+		OnAgentError: []wfmodels.GenericAction{{
+			Type:   wfmodels.GenericActionMoveToStep,
+			Config: map[string]any{"step_id": "step_triage", "if": "data.agent_error_count >= 3"},
+		}},
+	})
+
+	for attempt, execID := range []string{"exec1", "exec2", "exec3"} {
+		moved := svc.processOnAgentErrorViaEngine(ctx, watcher.AgentEventData{
+			TaskID:           "task1",
+			SessionID:        "session1",
+			AgentExecutionID: execID,
+			ErrorMessage:     "agent crashed",
+		})
+		if wantMove := attempt == 2; moved != wantMove {
+			t.Fatalf("attempt %d: moved = %v, want %v", attempt+1, moved, wantMove)
+		}
+	}
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	task, err := svc.repo.GetTask(ctx, "task1")
+	if err != nil {
+		t.Fatalf("load task: %v", err)
+	}
+	if task.WorkflowStepID != "step_triage" {
+		t.Fatalf("expected task on step_triage, got %q", task.WorkflowStepID)
+	}
+	session, err := svc.repo.GetTaskSession(ctx, "session1")
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	data, _ := session.Metadata["workflow_data"].(map[string]any)
+	if got := engine.NextAgentErrorAttempt(data, "step_implement"); got != 4 {
+		t.Fatalf("expected three recorded failures on step_implement, next attempt = %d", got)
+	}
+}
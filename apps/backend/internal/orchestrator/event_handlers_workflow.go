@@ -132,7 +132,7 @@ func (s *Service) loadLegacyTurnCompletionStep(
 			zap.String("session_id", session.ID))
 		return nil, false
 	}
-	currentStep, err := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+	currentStep, err := s.getTaskStep(ctx, task.ID, task.WorkflowStepID)
 	if err != nil || currentStep == nil {
 		s.logger.Warn("failed to get workflow step for transition",
 			zap.String("workflow_step_id", task.WorkflowStepID),
@@ -180,7 +180,7 @@ func (s *Service) shouldRunLegacyTurnCompletion(
 func (s *Service) resolveTransitionTargetStep(ctx context.Context, taskID, sessionID string, currentStep *wfmodels.WorkflowStep, action *wfmodels.OnTurnCompleteAction) (string, bool) {
 	switch action.Type {
 	case wfmodels.OnTurnCompleteMoveToNext:
-		nextStep, err := s.workflowStepGetter.GetNextStepByPosition(ctx, currentStep.WorkflowID, currentStep.Position)
+		nextStep, err := s.getTaskNextStep(ctx, taskID, currentStep.WorkflowID, currentStep.Position)
 		if err != nil {
 			s.logger.Warn("failed to get next step by position",
 				zap.String("workflow_id", currentStep.WorkflowID),
@@ -196,7 +196,7 @@ func (s *Service) resolveTransitionTargetStep(ctx context.Context, taskID, sessi
 		}
 		return nextStep.ID, true
 	case wfmodels.OnTurnCompleteMoveToPrevious:
-		prevStep, err := s.workflowStepGetter.GetPreviousStepByPosition(ctx, currentStep.WorkflowID, currentStep.Position)
+		prevStep, err := s.getTaskPreviousStep(ctx, taskID, currentStep.WorkflowID, currentStep.Position)
 		if err != nil {
 			s.logger.Warn("failed to get previous step by position",
 				zap.String("workflow_id", currentStep.WorkflowID),
@@ -245,7 +245,7 @@ func (s *Service) processOnTurnStart(ctx context.Context, task *models.Task, ses
 	workflowStepID := task.WorkflowStepID
 
 	// Get the current workflow step
-	currentStep, err := s.workflowStepGetter.GetStep(ctx, workflowStepID)
+	currentStep, err := s.getTaskStep(ctx, task.ID, workflowStepID)
 	if err != nil || currentStep == nil {
 		s.logger.Warn("failed to get workflow step for on_turn_start",
 			zap.String("workflow_step_id", workflowStepID),
@@ -275,7 +275,7 @@ func (s *Service) processOnTurnStart(ctx context.Context, task *models.Task, ses
 	}
 
 	// Resolve the target step ID
-	targetStepID, ok := s.resolveTurnStartTargetStep(ctx, currentStep, transitionAction)
+	targetStepID, ok := s.resolveTurnStartTargetStep(ctx, task.ID, currentStep, transitionAction)
 	if !ok {
 		return false
 	}
@@ -340,7 +340,7 @@ func (s *Service) ProcessOnTurnStart(ctx context.Context, taskID, sessionID stri
 // If false, only the step change is applied (used for on_turn_start where the user is about to send a message).
 func (s *Service) executeStepTransition(ctx context.Context, taskID, sessionID string, fromStep *wfmodels.WorkflowStep, toStepID string, triggerOnEnter bool) {
 	// Get the target step
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, toStepID)
+	targetStep, err := s.getTaskStep(ctx, taskID, toStepID)
 	if err != nil {
 		s.logger.Warn("failed to get target workflow step",
 			zap.String("target_step_id", toStepID),
@@ -428,6 +428,7 @@ func (s *Service) executeStepTransition(ctx context.Context, taskID, sessionID s
 		trigger = wfmodels.StepTransitionTriggerAutoComplete
 	}
 	s.recordAutoStepTransition(ctx, sessionID, fromStep.ID, toStepID, consumedSignal, trigger)
+	s.publishTaskStepEntered(ctx, taskID, sessionID, fromStep.ID, toStepID, trigger)
 
 	if triggerOnEnter {
 		// ADR 0015 — clear any pending completion-signal bag for the
@@ -656,7 +657,7 @@ func (s *Service) loadTaskMovedLifecyclePrerequisites(
 }
 
 func (s *Service) loadTaskMovedPromotionTarget(ctx context.Context, data watcher.TaskMovedEventData) (*wfmodels.WorkflowStep, bool) {
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, data.ToStepID)
+	targetStep, err := s.getTaskStep(ctx, data.TaskID, data.ToStepID)
 	if err != nil || targetStep == nil {
 		s.logger.Warn("task.moved: failed to load promotion target step",
 			zap.String("task_id", data.TaskID),
@@ -679,7 +680,7 @@ func (s *Service) loadTaskMovedSessionPrerequisites(
 			zap.Error(err))
 		return nil, nil, false
 	}
-	fromStep, err := s.workflowStepGetter.GetStep(ctx, data.FromStepID)
+	fromStep, err := s.getTaskStep(ctx, data.TaskID, data.FromStepID)
 	if err != nil || fromStep == nil {
 		s.logger.Warn("task.moved: failed to load lifecycle source step",
 			zap.String("task_id", data.TaskID),
@@ -712,7 +713,7 @@ func (s *Service) handleTaskQueuePromoted(ctx context.Context, data watcher.Task
 		// queue reconciliation and retry destination entry.
 		return
 	}
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+	targetStep, err := s.getTaskStep(ctx, task.ID, task.WorkflowStepID)
 	if err != nil || targetStep == nil {
 		s.logger.Warn("task.queue_promoted: failed to load target step",
 			zap.String("task_id", task.ID), zap.String("step_id", task.WorkflowStepID), zap.Error(err))
@@ -919,7 +920,7 @@ func (s *Service) recoverQueuedMoveExit(ctx context.Context, task *models.Task)
 	if sourceStepID == "" || err != nil || session == nil || s.workflowStepGetter == nil {
 		return false
 	}
-	fromStep, err := s.workflowStepGetter.GetStep(ctx, sourceStepID)
+	fromStep, err := s.getTaskStep(ctx, task.ID, sourceStepID)
 	if err != nil || fromStep == nil {
 		return false
 	}
@@ -949,11 +950,11 @@ func (s *Service) recoverManualMoveLifecycle(ctx context.Context, task *models.T
 		s.continueManualMoveLifecycle(ctx, task.ID)
 		return true
 	}
-	fromStep, err := s.workflowStepGetter.GetStep(ctx, sourceStepID)
+	fromStep, err := s.getTaskStep(ctx, task.ID, sourceStepID)
 	if err != nil || fromStep == nil {
 		return false
 	}
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+	targetStep, err := s.getTaskStep(ctx, task.ID, task.WorkflowStepID)
 	if err != nil || targetStep == nil {
 		return false
 	}
@@ -1004,7 +1005,7 @@ func (s *Service) syncTaskStateForQueuePromotion(ctx context.Context, task *mode
 	if targetStep == nil {
 		return nil
 	}
-	next, err := s.workflowStepGetter.GetNextStepByPosition(ctx, targetStep.WorkflowID, targetStep.Position)
+	next, err := s.getTaskNextStep(ctx, task.ID, targetStep.WorkflowID, targetStep.Position)
 	if err != nil {
 		return fmt.Errorf("load next step after promoted step %s: %w", targetStep.ID, err)
 	}
@@ -1053,7 +1054,7 @@ func (s *Service) autoStartTaskForStep(ctx context.Context, taskID, stepID, even
 	}
 
 	// Load the target step to check auto-start and plan mode flags
-	step, err := s.workflowStepGetter.GetStep(ctx, stepID)
+	step, err := s.getTaskStep(ctx, taskID, stepID)
 	if err != nil {
 		s.logger.Warn(eventName+": failed to load target step",
 			zap.String("task_id", taskID),
@@ -1447,7 +1448,7 @@ func (s *Service) fromStepAndTargetForTaskMoved(
 }
 
 func (s *Service) processStepExit(ctx context.Context, taskID string, session *models.TaskSession, fromStepID string) {
-	fromStep, err := s.workflowStepGetter.GetStep(ctx, fromStepID)
+	fromStep, err := s.getTaskStep(ctx, taskID, fromStepID)
 	if err != nil || fromStep == nil {
 		s.logger.Warn("failed to load from-step for queued move on_exit",
 			zap.String("step_id", fromStepID), zap.Error(err))
@@ -1459,7 +1460,7 @@ func (s *Service) processStepExit(ctx context.Context, taskID string, session *m
 func (s *Service) processStepExitWithStep(ctx context.Context, taskID string, session *models.TaskSession, fromStep *wfmodels.WorkflowStep, fromStepID string) {
 	if fromStep == nil {
 		var err error
-		fromStep, err = s.workflowStepGetter.GetStep(ctx, fromStepID)
+		fromStep, err = s.getTaskStep(ctx, taskID, fromStepID)
 		if err != nil || fromStep == nil {
 			s.logger.Warn("failed to load from-step for queued move on_exit",
 				zap.String("step_id", fromStepID), zap.Error(err))
@@ -1717,7 +1718,7 @@ func (s *Service) processStepExitAndEnterWithSteps(
 ) {
 	if fromStep == nil {
 		var err error
-		fromStep, err = s.workflowStepGetter.GetStep(ctx, fromStepID)
+		fromStep, err = s.getTaskStep(ctx, taskID, fromStepID)
 		if err != nil || fromStep == nil {
 			s.logger.Warn("failed to load from-step for on_exit",
 				zap.String("step_id", fromStepID),
@@ -1731,7 +1732,7 @@ func (s *Service) processStepExitAndEnterWithSteps(
 
 	if targetStep == nil {
 		var err error
-		targetStep, err = s.workflowStepGetter.GetStep(ctx, toStepID)
+		targetStep, err = s.getTaskStep(ctx, taskID, toStepID)
 		if err != nil || targetStep == nil {
 			s.logger.Warn("failed to load target step for on_enter",
 				zap.String("step_id", toStepID),
@@ -2214,6 +2215,10 @@ func (s *Service) processOnEnter(ctx context.Context, taskID string, session *mo
 
 	hasPlanMode := s.resolveStepPlanMode(ctx, session, step, isPassthrough)
 
+	// Runs before the early return below: a step without on_enter actions
+	// still has to drop the previous step's permission rules.
+	s.applyStepPermissionPolicy(ctx, session, step)
+
 	if len(step.Events.OnEnter) == 0 && !sessionSwitched {
 		// Active-turn case (e.g. move_task_kandev mid-turn): the agent is still
 		// running and will fire agent.ready when the turn ends. Don't flip state
@@ -2403,7 +2408,7 @@ func (s *Service) applyPendingMove(ctx context.Context, taskID, sessionID string
 		return
 	}
 
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, move.WorkflowStepID)
+	targetStep, err := s.getTaskStep(ctx, taskID, move.WorkflowStepID)
 	if err != nil || targetStep == nil {
 		s.logger.Error("failed to load target step for pending move",
 			zap.String("task_id", taskID),
@@ -3836,16 +3841,16 @@ func (s *Service) publishSessionCreatedEvent(ctx context.Context, taskID, sessio
 
 // resolveTurnStartTargetStep resolves the target step ID for an on_turn_start transition action.
 // Returns the step ID and true if resolved; empty string and false if not resolvable.
-func (s *Service) resolveTurnStartTargetStep(ctx context.Context, currentStep *wfmodels.WorkflowStep, action *wfmodels.OnTurnStartAction) (string, bool) {
+func (s *Service) resolveTurnStartTargetStep(ctx context.Context, taskID string, currentStep *wfmodels.WorkflowStep, action *wfmodels.OnTurnStartAction) (string, bool) {
 	switch action.Type {
 	case wfmodels.OnTurnStartMoveToNext:
-		next, err := s.workflowStepGetter.GetNextStepByPosition(ctx, currentStep.WorkflowID, currentStep.Position)
+		next, err := s.getTaskNextStep(ctx, taskID, currentStep.WorkflowID, currentStep.Position)
 		if err != nil || next == nil {
 			return "", false
 		}
 		return next.ID, true
 	case wfmodels.OnTurnStartMoveToPrevious:
-		prev, err := s.workflowStepGetter.GetPreviousStepByPosition(ctx, currentStep.WorkflowID, currentStep.Position)
+		prev, err := s.getTaskPreviousStep(ctx, taskID, currentStep.WorkflowID, currentStep.Position)
 		if err != nil || prev == nil {
 			return "", false
 		}
@@ -3931,13 +3936,14 @@ func (s *Service) processOnTurnCompleteViaEngineWithCause(
 	}
 
 	state := s.buildMachineState(ctx, task, session)
-	result, err := s.workflowEngine.HandleTrigger(ctx, engine.HandleInput{
+	in := engine.HandleInput{
 		TaskID:         taskID,
 		SessionID:      session.ID,
 		Trigger:        engine.TriggerOnTurnComplete,
 		EvaluateOnly:   true,
 		PreloadedState: &state,
-	})
+	}
+	result, err := s.workflowEngine.HandleTrigger(ctx, in)
 	if err != nil {
 		s.logger.Error("workflow engine error on_turn_complete",
 			zap.String("task_id", taskID),
@@ -3947,7 +3953,62 @@ func (s *Service) processOnTurnCompleteViaEngineWithCause(
 		return false
 	}
 
+	if isPendingCheck(result) {
+		// The run_check gate runs in the background and decides the turn
+		// when it finishes. Report a transition in progress meanwhile, so
+		// the caller neither drains the queue nor parks the session. The
+		// completed gate only carries last_check, so persist what the
+		// actions before it wrote now.
+		if err := s.persistEngineDataPatch(ctx, session.ID, result); err != nil {
+			s.logger.Warn("failed to persist workflow data before run_check gate",
+				zap.String("task_id", taskID),
+				zap.String("session_id", session.ID),
+				zap.Error(err))
+		}
+		// The caller skipped parking the session and draining its queue
+		// while the gate ran; a gate that decides nothing does both, as the
+		// engine-error branch above does.
+		park := func(ctx context.Context) {
+			s.setSessionWaitingForInput(ctx, taskID, in.SessionID)
+			s.drainQueuedMessageForPromptableSession(ctx, in.SessionID)
+		}
+		s.runPendingCheck(ctx, in, *result.Check, func(ctx context.Context, result engine.HandleResult) {
+			session, err := s.repo.GetTaskSession(ctx, in.SessionID)
+			if err != nil {
+				s.logger.Warn("failed to reload session after run_check gate",
+					zap.String("task_id", taskID),
+					zap.String("session_id", in.SessionID),
+					zap.Error(err))
+				park(ctx)
+				return
+			}
+			if !s.finishTurnCompleteViaEngine(ctx, task, session, cause, result) {
+				// Deliver what was queued, gate feedback included.
+				s.drainQueuedMessageForPromptableSession(ctx, session.ID)
+			}
+		}, park)
+		return true
+	}
+	return s.finishTurnCompleteViaEngine(ctx, task, session, cause, result)
+}
+
+// finishTurnCompleteViaEngine acts on an evaluated on_turn_complete: it
+// feeds a failed gate back to the agent and applies the transition, or
+// parks the session when there is none.
+func (s *Service) finishTurnCompleteViaEngine(
+	ctx context.Context,
+	task *models.Task,
+	session *models.TaskSession,
+	cause turnCompletionCause,
+	result engine.HandleResult,
+) bool {
+	taskID := task.ID
+	// A failed run_check gate feeds its output back to the agent. Queued
+	// before the transition so the fail step's auto-start prompt merges it.
+	s.queueCheckFeedback(ctx, taskID, session.ID, result.Check)
+
 	if !result.Transitioned {
+		s.persistCheckSummary(ctx, taskID, session.ID, result)
 		s.setSessionWaitingForInput(ctx, taskID, session.ID, session)
 		return false
 	}
@@ -3964,6 +4025,22 @@ func (s *Service) processOnTurnCompleteViaEngineWithCause(
 	return s.applyEngineTransition(ctx, taskID, session, result, engine.TriggerOnTurnComplete, task.Description, true)
 }
 
+// persistCheckSummary persists the last_check data of a run_check gate that
+// kept the task in place. The trigger ran evaluate-only, and transitioning
+// triggers persist their data through applyEngineTransition instead.
+func (s *Service) persistCheckSummary(ctx context.Context, taskID, sessionID string, result engine.HandleResult) {
+	summary, ok := result.DataPatch[engine.LastCheckDataKey]
+	if result.Check == nil || !ok || s.workflowStore == nil {
+		return
+	}
+	if err := s.workflowStore.PersistData(ctx, sessionID, map[string]any{engine.LastCheckDataKey: summary}); err != nil {
+		s.logger.Warn("failed to persist run_check summary",
+			zap.String("task_id", taskID),
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+	}
+}
+
 func (s *Service) prepareEngineTurnCompletion(
 	ctx context.Context,
 	taskID string,
@@ -3986,7 +4063,7 @@ func (s *Service) prepareEngineTurnCompletion(
 	// not silently bypass the gate (which would let a signal-required step
 	// auto-advance whenever the loader hiccups). Block the transition and
 	// let the next turn re-evaluate after the underlying error clears.
-	currentStep, stepErr := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+	currentStep, stepErr := s.getTaskStep(ctx, task.ID, task.WorkflowStepID)
 	if stepErr != nil || currentStep == nil {
 		s.logger.Warn("on_turn_complete: failed to load current step for signal gating, blocking transition",
 			zap.String("task_id", taskID),
@@ -4158,7 +4235,7 @@ func (s *Service) applyEngineTransitionWithCommit(
 	var targetStep *wfmodels.WorkflowStep
 	if triggerOnEnter {
 		var err error
-		targetStep, err = s.workflowStepGetter.GetStep(ctx, result.ToStepID)
+		targetStep, err = s.getTaskStep(ctx, taskID, result.ToStepID)
 		if err != nil {
 			s.logger.Warn("target step not found, skipping transition",
 				zap.String("step_id", result.ToStepID),
@@ -4169,7 +4246,7 @@ func (s *Service) applyEngineTransitionWithCommit(
 	} else {
 		// Even without on_enter, load the target step — needed for profile switch check.
 		var err error
-		targetStep, err = s.workflowStepGetter.GetStep(ctx, result.ToStepID)
+		targetStep, err = s.getTaskStep(ctx, taskID, result.ToStepID)
 		if err != nil {
 			s.logger.Warn("target step not found, skipping transition",
 				zap.String("step_id", result.ToStepID),
@@ -4188,7 +4265,7 @@ func (s *Service) applyEngineTransitionWithCommit(
 
 	terminalTarget := s.workflowStepIsTerminal(ctx, targetStep.ID)
 
-	fromStep, err := s.workflowStepGetter.GetStep(ctx, result.FromStepID)
+	fromStep, err := s.getTaskStep(ctx, taskID, result.FromStepID)
 	if err != nil {
 		s.logger.Warn("failed to load from-step for on_exit",
 			zap.String("step_id", result.FromStepID),
@@ -4212,8 +4289,8 @@ func (s *Service) applyEngineTransitionWithCommit(
 
 	// ADR 0015 — record the audit row before the pending signal (if any) is
 	// cleared. Only an on_turn_complete transition can have consumed a
-	// signal; on_turn_start and on_children_completed transitions record
-	// with no signal metadata.
+	// signal; on_turn_start and event-driven transitions record with no
+	// signal metadata.
 	var consumedSignal *models.PendingStepCompletionSignal
 	if trigger == engine.TriggerOnTurnComplete {
 		if signal, has := models.LoadPendingStepSignal(session.Metadata); has && signal.StepID == result.FromStepID {
@@ -4226,8 +4303,25 @@ func (s *Service) applyEngineTransitionWithCommit(
 		historyTrigger = wfmodels.StepTransitionTriggerTurnStart
 	case engine.TriggerOnChildrenCompleted:
 		historyTrigger = wfmodels.StepTransitionTriggerChildrenCompleted
+	case engine.TriggerOnComment:
+		historyTrigger = wfmodels.StepTransitionTriggerComment
+	case engine.TriggerOnBlockerResolved:
+		historyTrigger = wfmodels.StepTransitionTriggerBlockerResolved
+	case engine.TriggerOnAgentError:
+		historyTrigger = wfmodels.StepTransitionTriggerAgentError
+	case engine.TriggerOnTimeout:
+		historyTrigger = wfmodels.StepTransitionTriggerTimeout
+	}
+	if result.IterationLimit != nil {
+		historyTrigger = wfmodels.StepTransitionTriggerIterationLimit
+		s.logger.Info("workflow loop hit max_iterations",
+			zap.String("task_id", taskID),
+			zap.String("bounded_step_id", result.IterationLimit.StepID),
+			zap.Int("max_iterations", result.IterationLimit.MaxIterations),
+			zap.String("to_step_id", result.ToStepID))
 	}
 	s.recordAutoStepTransition(ctx, session.ID, result.FromStepID, result.ToStepID, consumedSignal, historyTrigger)
+	s.publishTaskStepEntered(ctx, taskID, session.ID, result.FromStepID, result.ToStepID, historyTrigger)
 
 	// ADR 0015 — a successful on_turn_complete transition consumes any
 	// pending step-completion signal for the source step. The bag must be
@@ -4349,13 +4443,19 @@ func (s *Service) processOnTurnStartViaEngine(ctx context.Context, taskID string
 	}
 
 	state := s.buildMachineState(ctx, task, session)
-	result, err := s.workflowEngine.HandleTrigger(ctx, engine.HandleInput{
+	in := engine.HandleInput{
 		TaskID:         taskID,
 		SessionID:      session.ID,
 		Trigger:        engine.TriggerOnTurnStart,
 		EvaluateOnly:   true,
 		PreloadedState: &state,
-	})
+	}
+	result, err := s.workflowEngine.HandleTrigger(ctx, in)
+	if err == nil && isPendingCheck(result) {
+		// The user's message waits on the step this decides, so the gate
+		// cannot run in the background.
+		result, err = s.completePendingCheck(ctx, in, *result.Check)
+	}
 	if err != nil {
 		s.logger.Error("workflow engine error on_turn_start",
 			zap.String("task_id", taskID),
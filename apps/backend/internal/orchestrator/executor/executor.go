@@ -14,6 +14,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/runtime/agentctl"
 	runtimeenv "github.com/kandev/kandev/internal/agent/runtime/environment"
 	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
 	"github.com/kandev/kandev/internal/agentctl/types/streams"
 	"github.com/kandev/kandev/internal/agentruntime"
 	"github.com/kandev/kandev/internal/common/logger"
@@ -303,6 +304,15 @@ type PromptTurnIDSetter interface {
 	SetPromptTurnID(ctx context.Context, agentExecutionID, turnID string) error
 }
 
+// WorkspaceCheckpointer is an optional lifecycle capability for the per-turn
+// workspace checkpoints agentctl takes before each prompt. All methods
+// return ErrExecutionNotFound when the session has no running execution.
+type WorkspaceCheckpointer interface {
+	CreateWorkspaceCheckpoint(ctx context.Context, sessionID, checkpointID string) (*client.WorkspaceCheckpoint, error)
+	ListWorkspaceCheckpoints(ctx context.Context, sessionID string) ([]client.WorkspaceCheckpoint, error)
+	RestoreWorkspaceCheckpoint(ctx context.Context, sessionID, checkpointID string, discard []string) (*client.WorkspaceCheckpoint, error)
+}
+
 // RemoteRuntimeStatus mirrors runtime status details needed by orchestrator/UI.
 type RemoteRuntimeStatus struct {
 	RuntimeName   agentruntime.Runtime
@@ -374,6 +384,7 @@ type LaunchAgentRequest struct {
 	McpMode                       string              // MCP tool mode: "task" (default), "config", or "office"
 	McpProviders                  []string            // Normalized provider capabilities attached to the task
 	McpProfile                    *mcpprofile.Context // Backend-owned base surface and additive MCP capabilities
+	StepPermissionPolicy          *permpolicy.Policy  // Current workflow step's permission rules, evaluated ahead of the profile's
 	IsEphemeral                   bool                // Ephemeral task (quick chat) — enables fallback workspace creation
 	WorkspacePath                 string              // Optional host folder for repo-less tasks (overrides scratch fallback)
 
@@ -2,14 +2,60 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"sort"
 
 	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
 	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/task/repository/repoerrors"
 	"github.com/kandev/kandev/internal/worktree"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
 	"go.uber.org/zap"
 )
 
+// resolveSessionTaskEnvironment returns the environment a launch of session
+// runs in: the task's shared one, or for a session with an environment of its
+// own (models.SessionOwnsEnvironment) that one. (nil, nil) means it does not
+// exist yet. An owned session still linked to an environment it does not own,
+// e.g. relinked to the shared one by a startup heal after a failed first
+// launch, is unlinked so the launch creates its own.
+func (e *Executor) resolveSessionTaskEnvironment(ctx context.Context, taskID string, session *models.TaskSession) (*models.TaskEnvironment, error) {
+	if !models.SessionOwnsEnvironment(session) {
+		return e.repo.GetTaskEnvironmentByTaskID(ctx, taskID)
+	}
+	if session.TaskEnvironmentID == "" {
+		return nil, nil
+	}
+	env, err := e.repo.GetTaskEnvironment(ctx, session.TaskEnvironmentID)
+	if err != nil && !errors.Is(err, repoerrors.ErrTaskEnvironmentNotFound) {
+		return nil, err
+	}
+	if env == nil || env.SessionID != session.ID {
+		session.TaskEnvironmentID = ""
+		return nil, nil
+	}
+	return env, nil
+}
+
+// applyOwnEnvironment adjusts the launch of a session with an environment of
+// its own. Its worktrees go under a task directory named after the session so
+// they never collide with the task's shared ones, and only the session's own
+// fork checkpoint seeds them: the task metadata may name the checkpoint the
+// task itself was forked from. env is the session's environment when it
+// already exists; its persisted directory wins.
+func applyOwnEnvironment(req *LaunchAgentRequest, task *v1.Task, session *models.TaskSession, env *models.TaskEnvironment) {
+	if !models.SessionOwnsEnvironment(session) {
+		return
+	}
+	if req.TaskDirName != "" && (env == nil || env.TaskDirName == "") {
+		req.TaskDirName = worktree.SemanticWorktreeName(task.Title, worktree.TaskDirSuffix(session.ID))
+	}
+	delete(req.Metadata, models.MetaKeyForkCheckpoint)
+	if checkpoint, _ := session.Metadata[models.MetaKeyForkCheckpoint].(string); checkpoint != "" {
+		ensureLaunchMetadata(req)[models.MetaKeyForkCheckpoint] = checkpoint
+	}
+}
+
 // reuseExistingEnvironment carries forward worktree, container, sandbox, and
 // runtime metadata from an existing TaskEnvironment into the launch request
 // so that executor backends can reuse the prior execution.
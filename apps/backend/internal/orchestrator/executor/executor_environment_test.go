@@ -635,3 +635,62 @@ func TestApplyRepositoryConfig_DirectLocalFallsBackToRepositoryIDForUnsafeRepoNa
 		t.Errorf("req.TaskDirName = %q, want empty for direct-local launch", req.TaskDirName)
 	}
 }
+
+func TestResolveSessionTaskEnvironment_OwnedSessionSkipsSharedEnvironment(t *testing.T) {
+	repo := newMockRepository()
+	repo.taskEnvironments["env-shared"] = &models.TaskEnvironment{ID: "env-shared", TaskID: "task-1"}
+	repo.taskEnvironments["env-fork"] = &models.TaskEnvironment{ID: "env-fork", TaskID: "task-1", SessionID: "session-fork"}
+	e := newTestExecutor(t, &mockAgentManager{}, repo)
+	ownMetadata := map[string]interface{}{models.SessionMetaKeyOwnEnvironment: true}
+
+	shared, err := e.resolveSessionTaskEnvironment(context.Background(), "task-1", &models.TaskSession{ID: "session-1"})
+	if err != nil || shared == nil || shared.ID != "env-shared" {
+		t.Fatalf("shared session environment = %+v, %v; want env-shared", shared, err)
+	}
+
+	fork := &models.TaskSession{ID: "session-fork", TaskEnvironmentID: "env-fork", Metadata: ownMetadata}
+	own, err := e.resolveSessionTaskEnvironment(context.Background(), "task-1", fork)
+	if err != nil || own == nil || own.ID != "env-fork" {
+		t.Fatalf("owned session environment = %+v, %v; want env-fork", own, err)
+	}
+
+	// Relinked to the shared environment, e.g. by the startup heal after a
+	// failed first launch: the session gets an environment of its own.
+	relinked := &models.TaskSession{ID: "session-new", TaskEnvironmentID: "env-shared", Metadata: ownMetadata}
+	env, err := e.resolveSessionTaskEnvironment(context.Background(), "task-1", relinked)
+	if err != nil || env != nil {
+		t.Fatalf("relinked session environment = %+v, %v; want none", env, err)
+	}
+	if relinked.TaskEnvironmentID != "" {
+		t.Fatalf("relinked session TaskEnvironmentID = %q, want cleared", relinked.TaskEnvironmentID)
+	}
+}
+
+func TestApplyOwnEnvironment_UsesSessionTaskDirAndCheckpoint(t *testing.T) {
+	task := &v1.Task{ID: "task-1", Title: "Fix login"}
+	session := &models.TaskSession{ID: "session-fork", Metadata: map[string]interface{}{
+		models.SessionMetaKeyOwnEnvironment: true,
+		models.MetaKeyForkCheckpoint:        "turn-2",
+	}}
+	req := &LaunchAgentRequest{
+		UseWorktree: true,
+		TaskDirName: "fix-login_task",
+		Metadata:    map[string]interface{}{models.MetaKeyForkCheckpoint: "task-fork-checkpoint"},
+	}
+
+	applyOwnEnvironment(req, task, session, nil)
+
+	if req.TaskDirName == "fix-login_task" || req.TaskDirName == "" {
+		t.Fatalf("TaskDirName = %q, want a session-scoped directory", req.TaskDirName)
+	}
+	if got := req.Metadata[models.MetaKeyForkCheckpoint]; got != "turn-2" {
+		t.Fatalf("fork checkpoint = %v, want turn-2", got)
+	}
+
+	persisted := &models.TaskEnvironment{ID: "env-fork", SessionID: "session-fork", TaskDirName: "persisted-dir"}
+	req = &LaunchAgentRequest{UseWorktree: true, TaskDirName: "persisted-dir"}
+	applyOwnEnvironment(req, task, session, persisted)
+	if req.TaskDirName != "persisted-dir" {
+		t.Fatalf("TaskDirName = %q, want the persisted persisted-dir", req.TaskDirName)
+	}
+}
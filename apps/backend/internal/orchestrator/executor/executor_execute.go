@@ -98,7 +98,8 @@ func (e *Executor) resolveTaskSessionMCPProfile(ctx context.Context, taskID stri
 // kandev-managed feature branch propagated through env metadata.
 func isContainerizedExecutor(executorType string) bool {
 	switch models.ExecutorType(executorType) {
-	case models.ExecutorTypeLocalDocker, models.ExecutorTypeRemoteDocker, models.ExecutorTypeSprites:
+	case models.ExecutorTypeLocalDocker, models.ExecutorTypeRemoteDocker, models.ExecutorTypeSprites,
+		models.ExecutorTypeKubernetes, models.ExecutorTypePodman:
 		return true
 	default:
 		return false
@@ -1022,11 +1023,11 @@ func (e *Executor) LaunchPreparedSession(ctx context.Context, task *v1.Task, ses
 
 	// Resolve the env ID before LaunchAgent so the in-memory AgentExecution
 	// is env-scoped from the first shell/layout request, not only after DB
-	// persistence succeeds. GetTaskEnvironmentByTaskID returns (nil, nil)
+	// persistence succeeds. resolveSessionTaskEnvironment returns (nil, nil)
 	// when no row exists; a real DB error must propagate so the launch
 	// fails closed instead of silently launching a fresh environment that
 	// orphans the existing container/sandbox/worktree.
-	existingEnv, err := e.repo.GetTaskEnvironmentByTaskID(ctx, task.ID)
+	existingEnv, err := e.resolveSessionTaskEnvironment(ctx, task.ID, session)
 	if err != nil {
 		return nil, fmt.Errorf("lookup existing task environment: %w", err)
 	}
@@ -1036,7 +1037,7 @@ func (e *Executor) LaunchPreparedSession(ctx context.Context, task *v1.Task, ses
 	// The by-task-id lookup misses that row because it indexes by the
 	// child task id, so without this fallback the launch path creates a
 	// fresh worktree and the inheritance contract silently breaks.
-	if existingEnv == nil && session.TaskEnvironmentID != "" {
+	if existingEnv == nil && session.TaskEnvironmentID != "" && !models.SessionOwnsEnvironment(session) {
 		if inherited, err := e.repo.GetTaskEnvironment(ctx, session.TaskEnvironmentID); err == nil {
 			existingEnv = inherited
 		}
@@ -1105,6 +1106,7 @@ func (e *Executor) LaunchPreparedSession(ctx context.Context, task *v1.Task, ses
 
 	// Check for an existing task environment to reuse worktree, container, or sandbox
 	e.reuseExistingEnvironment(ctx, req, existingEnv)
+	applyOwnEnvironment(req, task, session, existingEnv)
 
 	e.logger.Info("launching agent for prepared session",
 		zap.String("task_id", task.ID),
@@ -1417,6 +1419,7 @@ func (e *Executor) buildLaunchAgentRequest(ctx context.Context, task *v1.Task, s
 		WorkspacePath:     session.WorkspacePath,
 		McpProviders:      deriveMCPProviders(allRepos),
 	}
+	req.StepPermissionPolicy = e.stepPermissionPolicy(session)
 
 	execConfig := e.resolveExecutorConfig(ctx, executorID, task.WorkspaceID, metadata)
 	if execConfig.ExecutorID != "" {
@@ -1936,7 +1939,7 @@ func (e *Executor) persistTaskEnvironment(
 	// see existingEnv == nil from the original call and try to create a
 	// duplicate.
 	if existingEnv == nil {
-		if fresh, err := e.repo.GetTaskEnvironmentByTaskID(ctx, taskID); err == nil && fresh != nil {
+		if fresh, err := e.resolveSessionTaskEnvironment(ctx, taskID, session); err == nil && fresh != nil {
 			existingEnv = fresh
 		}
 	}
@@ -1998,6 +2001,9 @@ func (e *Executor) persistTaskEnvironment(
 		TaskDirName:   req.TaskDirName,
 		SandboxID:     extractSandboxID(resp.Metadata),
 	}
+	if models.SessionOwnsEnvironment(session) {
+		env.SessionID = session.ID
+	}
 	// Embed per-repo rows in the same create transaction. Single-repo
 	// launches produce one row so the worktree identity is always recorded.
 	env.Repos = environmentReposForLaunch(req, resp)
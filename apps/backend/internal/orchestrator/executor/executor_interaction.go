@@ -716,6 +716,7 @@ func (e *Executor) buildSwitchModelRequest(ctx context.Context, task *models.Tas
 		IsPassthrough:     session.IsPassthrough,
 		TaskEnvironmentID: session.TaskEnvironmentID,
 	}
+	req.StepPermissionPolicy = e.stepPermissionPolicy(session)
 
 	mcpMode, err := e.resolveTaskSessionMCPMode(ctx, task.ID, session, true)
 	if err != nil {
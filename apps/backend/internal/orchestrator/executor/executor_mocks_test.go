@@ -1103,7 +1103,7 @@ func (m *mockRepository) GetTaskEnvironmentByTaskID(ctx context.Context, taskID
 		return m.getTaskEnvironmentByTaskIDFunc(ctx, taskID)
 	}
 	for _, env := range m.taskEnvironments {
-		if env.TaskID == taskID {
+		if env.TaskID == taskID && env.SessionID == "" {
 			return env, nil
 		}
 	}
@@ -906,6 +906,7 @@ func (e *Executor) buildResumeRequestAtCredentialBoundary(
 		IsEphemeral:          task.IsEphemeral,
 		IsPassthrough:        session.IsPassthrough,
 		TaskEnvironmentID:    session.TaskEnvironmentID,
+		StepPermissionPolicy: e.stepPermissionPolicy(session),
 	}
 
 	metadata := map[string]interface{}{}
@@ -947,6 +948,7 @@ func (e *Executor) buildResumeRequestAtCredentialBoundary(
 	}
 
 	e.reuseExistingEnvironment(ctx, req, existingEnv)
+	applyOwnEnvironment(req, task, session, existingEnv)
 
 	req.McpMode, err = e.resolveTaskSessionMCPMode(ctx, task.ID, session, true)
 	if err != nil {
@@ -1014,7 +1016,7 @@ func (e *Executor) configureResumeGitHubCredentials(
 }
 
 func (e *Executor) resolveResumeTaskEnvironment(ctx context.Context, taskID string, session *models.TaskSession) (*models.TaskEnvironment, error) {
-	env, err := e.repo.GetTaskEnvironmentByTaskID(ctx, taskID)
+	env, err := e.resolveSessionTaskEnvironment(ctx, taskID, session)
 	if err != nil {
 		return nil, fmt.Errorf("lookup existing task environment: %w", err)
 	}
@@ -253,10 +253,37 @@ var profileConfigRenameKeys = map[string]string{
 // the empty fall-through to a default. Without this, a task that
 // supplies ssh_workdir_root or ssh_shell in its Metadata wins when the
 // profile has no value set — that's a redirect vector (workdir target,
-// login shell that runs every remote command).
+// login shell that runs every remote command). The Kubernetes pod settings
+// are here for the same reason: a task must not pick its own namespace,
+// service account or resource limits. devcontainer is here because it lets
+// the repository choose the image, host mounts and commands to run. The warm
+// pool settings are here so a task cannot make the backend keep idle
+// containers running, the egress policy settings so a task cannot lift or
+// widen the network policy it runs under, and the session resource limits so
+// a task cannot raise its own quota.
 var profileConfigAuthoritativeKeys = []string{
 	lifecycle.MetadataKeySSHWorkdirRoot,
 	lifecycle.MetadataKeySSHShell,
+	lifecycle.MetadataKeyKubernetesNamespace,
+	lifecycle.MetadataKeyKubernetesServiceAccount,
+	lifecycle.MetadataKeyKubernetesNodeSelector,
+	lifecycle.MetadataKeyKubernetesWorkspaceVolume,
+	lifecycle.MetadataKeyKubernetesPVCSize,
+	lifecycle.MetadataKeyKubernetesStorageClass,
+	lifecycle.MetadataKeyKubernetesCPURequest,
+	lifecycle.MetadataKeyKubernetesCPULimit,
+	lifecycle.MetadataKeyKubernetesMemoryRequest,
+	lifecycle.MetadataKeyKubernetesMemoryLimit,
+	lifecycle.MetadataKeyDevcontainer,
+	lifecycle.MetadataKeyWarmPoolSize,
+	lifecycle.MetadataKeyWarmPoolMaxIdle,
+	lifecycle.MetadataKeyWarmPoolRepositories,
+	lifecycle.MetadataKeyEgressPolicy,
+	lifecycle.MetadataKeyEgressAllowedDomains,
+	lifecycle.MetadataKeyEgressModelAPIDomains,
+	lifecycle.MetadataKeyMemoryLimitMB,
+	lifecycle.MetadataKeyCPULimitCores,
+	lifecycle.MetadataKeyPidsLimit,
 }
 
 // applyProfileConfigToMetadata projects profile.Config keys into the
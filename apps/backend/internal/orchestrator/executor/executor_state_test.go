@@ -86,6 +86,55 @@ func TestResolveExecutorConfig_AuthoritativeSSHKeys_ClobberTaskMetadata(t *testi
 			taskValue:    "bash --norc",
 			wantMetadata: "",
 		},
+		{
+			name:         "kubernetes_namespace_profile_wins",
+			key:          lifecycle.MetadataKeyKubernetesNamespace,
+			profileValue: "agents",
+			taskValue:    "kube-system",
+			wantMetadata: "agents",
+		},
+		{
+			name:         "empty_service_account_profile_clobbers_task",
+			key:          lifecycle.MetadataKeyKubernetesServiceAccount,
+			profileValue: "",
+			taskValue:    "cluster-admin",
+			wantMetadata: "",
+		},
+		{
+			name:         "empty_devcontainer_profile_clobbers_task",
+			key:          lifecycle.MetadataKeyDevcontainer,
+			profileValue: "",
+			taskValue:    "true",
+			wantMetadata: "",
+		},
+		{
+			name:         "empty_warm_pool_size_profile_clobbers_task",
+			key:          lifecycle.MetadataKeyWarmPoolSize,
+			profileValue: "",
+			taskValue:    "8",
+			wantMetadata: "",
+		},
+		{
+			name:         "profile_egress_policy_overrides_task",
+			key:          lifecycle.MetadataKeyEgressPolicy,
+			profileValue: "model_api_only",
+			taskValue:    "",
+			wantMetadata: "model_api_only",
+		},
+		{
+			name:         "empty_egress_domains_profile_clobbers_task",
+			key:          lifecycle.MetadataKeyEgressAllowedDomains,
+			profileValue: "",
+			taskValue:    "attacker.example",
+			wantMetadata: "",
+		},
+		{
+			name:         "empty_memory_limit_profile_clobbers_task",
+			key:          lifecycle.MetadataKeyMemoryLimitMB,
+			profileValue: "",
+			taskValue:    "1048576",
+			wantMetadata: "",
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
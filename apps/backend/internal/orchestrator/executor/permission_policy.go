@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+// stepPermissionPolicy returns the workflow step permission rules the
+// orchestrator recorded on the session, or nil when the current step has
+// none. Metadata read from the database holds decoded JSON; in-process
+// writers may have stored the policy itself.
+func (e *Executor) stepPermissionPolicy(session *models.TaskSession) *permpolicy.Policy {
+	if session == nil {
+		return nil
+	}
+	switch value := session.Metadata[models.SessionMetaKeyPermissionPolicy].(type) {
+	case nil:
+		return nil
+	case *permpolicy.Policy:
+		return value
+	default:
+		raw, err := json.Marshal(value)
+		if err == nil {
+			var policy permpolicy.Policy
+			if err = json.Unmarshal(raw, &policy); err == nil {
+				return &policy
+			}
+		}
+		e.logger.Warn("ignoring unreadable step permission policy in session metadata",
+			zap.String("session_id", session.ID),
+			zap.Error(err))
+		return nil
+	}
+}
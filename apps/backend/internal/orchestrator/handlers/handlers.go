@@ -46,8 +46,11 @@ func (h *Handlers) RegisterHandlers(d *ws.Dispatcher) {
 	d.RegisterFunc(ws.ActionSessionSetPlanMode, h.wsSetPlanMode)
 	d.RegisterFunc(ws.ActionSessionRename, h.wsRenameSession)
 	d.RegisterFunc(ws.ActionSessionRouteAction, h.wsRouteAction)
+	d.RegisterFunc(ws.ActionSessionCheckpoints, h.wsListCheckpoints)
+	d.RegisterFunc(ws.ActionSessionRewind, h.wsRewindSession)
 	d.RegisterFunc(ws.ActionGitHubCheckSessionPR, h.wsCheckSessionPR)
 	d.RegisterFunc(ws.ActionGitLabCheckSessionMR, h.wsCheckSessionMR)
+	d.RegisterFunc(ws.ActionWorkflowFanOutSelect, h.wsSelectFanOutWinner)
 }
 
 type wsRouteActionRequest struct {
@@ -347,6 +350,31 @@ func (h *Handlers) wsStopTask(ctx context.Context, msg *ws.Message) (*ws.Message
 	return ws.NewResponse(msg.ID, msg.Action, dto.SuccessResponse{Success: true})
 }
 
+type wsSelectFanOutWinnerRequest struct {
+	TaskID       string `json:"task_id"`
+	WinnerTaskID string `json:"winner_task_id"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// wsSelectFanOutWinner picks the winning branch of a task's fan-out step.
+func (h *Handlers) wsSelectFanOutWinner(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	var req wsSelectFanOutWinnerRequest
+	if err := msg.ParsePayload(&req); err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "Invalid payload: "+err.Error(), nil)
+	}
+	if req.TaskID == "" || req.WinnerTaskID == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "task_id and winner_task_id are required", nil)
+	}
+	if err := h.service.SelectFanOutWinner(ctx, req.TaskID, req.WinnerTaskID, "user", req.Reason); err != nil {
+		if errors.Is(err, orchestrator.ErrInvalidFanOutSelection) {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, err.Error(), nil)
+		}
+		h.logger.Error("failed to select fan-out winner", zap.String("task_id", req.TaskID), zap.Error(err))
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Failed to select fan-out winner: "+err.Error(), nil)
+	}
+	return ws.NewResponse(msg.ID, msg.Action, dto.SuccessResponse{Success: true})
+}
+
 type wsPermissionRespondRequest struct {
 	TaskID    string `json:"task_id"`
 	SessionID string `json:"session_id"`
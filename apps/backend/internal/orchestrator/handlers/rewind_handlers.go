@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+
+	client "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/orchestrator"
+	ws "github.com/kandev/kandev/pkg/websocket"
+)
+
+type wsListCheckpointsRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+// wsListCheckpoints returns the turns the session can be rewound to.
+func (h *Handlers) wsListCheckpoints(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	var req wsListCheckpointsRequest
+	if err := msg.ParsePayload(&req); err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "Invalid payload: "+err.Error(), nil)
+	}
+	if req.SessionID == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "session_id is required", nil)
+	}
+	checkpoints, err := h.service.ListSessionCheckpoints(ctx, req.SessionID)
+	if err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Failed to list checkpoints: "+err.Error(), nil)
+	}
+	return ws.NewResponse(msg.ID, msg.Action, map[string]any{
+		"session_id":  req.SessionID,
+		"checkpoints": checkpoints,
+	})
+}
+
+type wsRewindSessionRequest struct {
+	SessionID string `json:"session_id"`
+	TurnID    string `json:"turn_id"`
+}
+
+// wsRewindSession restores the workspace to before a turn and marks that
+// turn and the ones after it as rewound.
+func (h *Handlers) wsRewindSession(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	var req wsRewindSessionRequest
+	if err := msg.ParsePayload(&req); err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "Invalid payload: "+err.Error(), nil)
+	}
+	if req.SessionID == "" || req.TurnID == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "session_id and turn_id are required", nil)
+	}
+	result, err := h.service.RewindSession(ctx, req.SessionID, req.TurnID)
+	switch {
+	case errors.Is(err, orchestrator.ErrNoCheckpoint):
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, err.Error(), nil)
+	case errors.Is(err, client.ErrCheckpointBusy):
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeConflict, err.Error(), nil)
+	case err != nil:
+		h.logger.Error("failed to rewind session",
+			zap.String("session_id", req.SessionID),
+			zap.String("turn_id", req.TurnID),
+			zap.Error(err))
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Failed to rewind session: "+err.Error(), nil)
+	}
+	return ws.NewResponse(msg.ID, msg.Action, result)
+}
@@ -0,0 +1,210 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/orchestrator/scheduler"
+	"github.com/kandev/kandev/internal/system/metrics"
+	"github.com/kandev/kandev/internal/task/models"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+// admissionSampleTimeout bounds one pressure reading, so an unreachable
+// executor cannot stall every launch behind it.
+const admissionSampleTimeout = 5 * time.Second
+
+// hostPressureSampler reads metrics for the machine the backend runs on.
+type hostPressureSampler interface {
+	Sample(ctx context.Context, metricIDs []string, diskPath string) metrics.SourceSnapshot
+}
+
+// admissionStore is the repository subset launch admission needs.
+type admissionStore interface {
+	GetExecutor(ctx context.Context, id string) (*models.Executor, error)
+	GetTaskSession(ctx context.Context, id string) (*models.TaskSession, error)
+}
+
+// launchAdmission defers new agent launches while the machine they would run
+// on is over its resource limits. Local executors (including local Docker and
+// Podman) run on the backend host, so the host is checked. SSH and remote
+// Docker executors share one remote machine between sessions, so it is read
+// through the agentctl of the executor's running sessions; with none running
+// there is nothing of ours loading it and the launch is admitted. Sprites and
+// Kubernetes start each session on fresh capacity and are always admitted.
+type launchAdmission struct {
+	thresholds metrics.PressureThresholds
+	host       hostPressureSampler
+	executions metrics.ExecutionProvider
+	store      admissionStore
+}
+
+// Check implements scheduler.AdmissionChecker.
+func (a *launchAdmission) Check(ctx context.Context, req scheduler.AdmissionRequest) string {
+	ctx, cancel := context.WithTimeout(ctx, admissionSampleTimeout)
+	defer cancel()
+	var executor *models.Executor
+	if req.ExecutorID != "" {
+		executor, _ = a.store.GetExecutor(ctx, req.ExecutorID)
+	}
+	var executorType models.ExecutorType
+	if executor != nil {
+		executorType = executor.Type
+	}
+	switch executorType {
+	case models.ExecutorTypeSSH, models.ExecutorTypeRemoteDocker:
+		return a.checkRemote(ctx, executor)
+	case models.ExecutorTypeSprites, models.ExecutorTypeKubernetes, models.ExecutorTypeMockRemote:
+		return ""
+	default:
+		if reason := a.thresholds.Exceeded(a.host.Sample(ctx, metrics.PressureMetricIDs, "/")); reason != "" {
+			return "host " + reason
+		}
+		return ""
+	}
+}
+
+func (a *launchAdmission) checkRemote(ctx context.Context, executor *models.Executor) string {
+	if a.executions == nil {
+		return ""
+	}
+	for _, execution := range a.executions.MetricExecutions() {
+		if execution.Client == nil || execution.SessionID == "" {
+			continue
+		}
+		session, err := a.store.GetTaskSession(ctx, execution.SessionID)
+		if err != nil || session == nil || session.ExecutorID != executor.ID {
+			continue
+		}
+		source, err := execution.Client.SystemMetrics(ctx, metrics.PressureMetricIDs, "/")
+		if err != nil || source == nil {
+			continue
+		}
+		if reason := a.thresholds.Exceeded(*source); reason != "" {
+			return fmt.Sprintf("executor %q %s", executor.Name, reason)
+		}
+		// Sessions of one SSH or remote Docker executor share a machine;
+		// one reading speaks for all of them.
+		return ""
+	}
+	return ""
+}
+
+// EnableLaunchAdmission turns on resource-aware admission control: new agent
+// launches started through StartTask wait in the scheduler while the target
+// machine is over thresholds, and start one per scheduler tick once it is
+// not. A no-op when no threshold is set.
+func (s *Service) EnableLaunchAdmission(thresholds metrics.PressureThresholds, host hostPressureSampler, executions metrics.ExecutionProvider) {
+	if !thresholds.Enabled() {
+		return
+	}
+	s.scheduler.SetAdmission(&launchAdmission{
+		thresholds: thresholds,
+		host:       host,
+		executions: executions,
+		store:      s.repo,
+	}, s.onAdmissionWaitChanged)
+	s.logger.Info("launch admission control enabled",
+		zap.Float64("max_memory_percent", thresholds.MemoryPercent),
+		zap.Float64("max_load_per_core", thresholds.LoadPerCore))
+}
+
+// admitLaunch asks admission control whether a launch may start now. When it
+// may not, launch is queued in the scheduler and admitLaunch returns false.
+func (s *Service) admitLaunch(ctx context.Context, taskID, executorID, executorProfileID string, launch func(context.Context) error) bool {
+	req := scheduler.AdmissionRequest{
+		TaskID:     taskID,
+		ExecutorID: s.admissionExecutorID(ctx, taskID, executorID, executorProfileID),
+	}
+	reason := s.scheduler.Admit(ctx, req)
+	if reason == "" {
+		return true
+	}
+	s.scheduler.DeferLaunch(scheduler.DeferredLaunch{AdmissionRequest: req, Launch: launch}, reason)
+	return false
+}
+
+// resumeStartTask returns the launch admission control runs once a deferred
+// start is admitted. It captures startTask's arguments as they were when the
+// start was deferred.
+func (s *Service) resumeStartTask(
+	taskID, agentProfileID, executorID, executorProfileID, priority, prompt, workflowStepID string,
+	planMode, autoStart bool, attachments []v1.MessageAttachment, opts startTaskOptions,
+) func(context.Context) error {
+	opts.Admitted = true
+	return func(ctx context.Context) error {
+		_, err := s.startTask(ctx, taskID, agentProfileID, executorID, executorProfileID, priority, prompt,
+			workflowStepID, planMode, autoStart, attachments, opts)
+		return err
+	}
+}
+
+// admissionExecutorID resolves the executor a launch will most likely use,
+// following the same precedence as session preparation: explicit executor,
+// then the executor profile's executor, then the task's stored choice. An
+// empty result is checked as a local launch.
+func (s *Service) admissionExecutorID(ctx context.Context, taskID, executorID, executorProfileID string) string {
+	if executorID != "" {
+		return executorID
+	}
+	profiles, ok := s.repo.(interface {
+		GetExecutorProfile(ctx context.Context, id string) (*models.ExecutorProfile, error)
+	})
+	if executorProfileID != "" && ok {
+		if profile, err := profiles.GetExecutorProfile(ctx, executorProfileID); err == nil && profile != nil {
+			return profile.ExecutorID
+		}
+	}
+	if task, err := s.repo.GetTask(ctx, taskID); err == nil && task != nil {
+		if id, ok := task.Metadata[models.MetaKeyExecutorID].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// onAdmissionWaitChanged mirrors a task's admission wait into its metadata so
+// the task card can show why the agent has not started.
+func (s *Service) onAdmissionWaitChanged(taskID, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if reason == "" {
+		s.clearAdmissionWait(ctx, taskID)
+		return
+	}
+	wait := map[string]interface{}{
+		"reason": reason,
+		"since":  time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := s.repo.SetTaskMetadataKey(ctx, taskID, models.MetaKeyAdmissionWait, wait); err != nil {
+		s.logger.Warn("failed to record admission wait on task",
+			zap.String("task_id", taskID), zap.Error(err))
+		return
+	}
+	s.republishTask(ctx, taskID)
+}
+
+// clearAdmissionWait removes the admission wait marker, republishing the
+// task only when one was there.
+func (s *Service) clearAdmissionWait(ctx context.Context, taskID string) {
+	removed, err := s.repo.RemoveTaskMetadataKey(ctx, taskID, models.MetaKeyAdmissionWait)
+	if err != nil {
+		s.logger.Warn("failed to clear admission wait on task",
+			zap.String("task_id", taskID), zap.Error(err))
+		return
+	}
+	if removed {
+		s.republishTask(ctx, taskID)
+	}
+}
+
+func (s *Service) republishTask(ctx context.Context, taskID string) {
+	task, err := s.repo.GetTask(ctx, taskID)
+	if err != nil || task == nil {
+		return
+	}
+	s.publishTaskUpdated(ctx, task)
+}
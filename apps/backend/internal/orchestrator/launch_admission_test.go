@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kandev/kandev/internal/orchestrator/scheduler"
+	"github.com/kandev/kandev/internal/system/metrics"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+type fakePressureSource struct {
+	memory  float64
+	samples int
+}
+
+func (f *fakePressureSource) snapshot() metrics.SourceSnapshot {
+	memory := f.memory
+	return metrics.SourceSnapshot{Metrics: []metrics.MetricSample{
+		{ID: metrics.MetricMemoryPercent, Value: &memory, Available: true},
+	}}
+}
+
+func (f *fakePressureSource) Sample(context.Context, []string, string) metrics.SourceSnapshot {
+	f.samples++
+	return f.snapshot()
+}
+
+func (f *fakePressureSource) SystemMetrics(context.Context, []string, string) (*metrics.SourceSnapshot, error) {
+	f.samples++
+	snapshot := f.snapshot()
+	return &snapshot, nil
+}
+
+type fakeAdmissionStore struct {
+	executors map[string]*models.Executor
+	sessions  map[string]*models.TaskSession
+}
+
+func (f *fakeAdmissionStore) GetExecutor(_ context.Context, id string) (*models.Executor, error) {
+	if executor, ok := f.executors[id]; ok {
+		return executor, nil
+	}
+	return nil, errors.New("executor not found")
+}
+
+func (f *fakeAdmissionStore) GetTaskSession(_ context.Context, id string) (*models.TaskSession, error) {
+	if session, ok := f.sessions[id]; ok {
+		return session, nil
+	}
+	return nil, errors.New("session not found")
+}
+
+type fakeExecutionProvider []metrics.ExecutionSource
+
+func (f fakeExecutionProvider) MetricExecutions() []metrics.ExecutionSource { return f }
+
+func TestLaunchAdmissionCheck(t *testing.T) {
+	store := &fakeAdmissionStore{
+		executors: map[string]*models.Executor{
+			"exec-worktree": {ID: "exec-worktree", Type: models.ExecutorTypeWorktree},
+			"exec-ssh":      {ID: "exec-ssh", Name: "build box", Type: models.ExecutorTypeSSH},
+			"exec-k8s":      {ID: "exec-k8s", Type: models.ExecutorTypeKubernetes},
+		},
+		sessions: map[string]*models.TaskSession{
+			"other-exec": {ID: "other-exec", ExecutorID: "exec-worktree"},
+			"ssh-1":      {ID: "ssh-1", ExecutorID: "exec-ssh"},
+		},
+	}
+	tests := []struct {
+		name       string
+		executorID string
+		host       float64
+		remote     float64
+		want       string
+	}{
+		{name: "host under limit", executorID: "exec-worktree", host: 50, remote: 99},
+		{name: "host over limit", executorID: "exec-worktree", host: 90, want: "host memory at 90% (limit 85%)"},
+		{name: "unknown executor checks host", executorID: "", host: 90, want: "host memory at 90% (limit 85%)"},
+		{name: "ssh reads its own machine", executorID: "exec-ssh", host: 99, remote: 92, want: `executor "build box" memory at 92% (limit 85%)`},
+		{name: "ssh under limit ignores host", executorID: "exec-ssh", host: 99, remote: 40},
+		{name: "kubernetes always admitted", executorID: "exec-k8s", host: 99, remote: 99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := &fakePressureSource{memory: tt.host}
+			remote := &fakePressureSource{memory: tt.remote}
+			admission := &launchAdmission{
+				thresholds: metrics.PressureThresholds{MemoryPercent: 85},
+				host:       host,
+				executions: fakeExecutionProvider{
+					{ID: "e0", SessionID: "other-exec", Client: remote},
+					{ID: "e1", SessionID: "ssh-1", Client: remote},
+				},
+				store: store,
+			}
+			got := admission.Check(context.Background(), scheduler.AdmissionRequest{TaskID: "t1", ExecutorID: tt.executorID})
+			if got != tt.want {
+				t.Fatalf("Check = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaunchAdmissionRemoteWithoutRunningSessions(t *testing.T) {
+	store := &fakeAdmissionStore{executors: map[string]*models.Executor{
+		"exec-ssh": {ID: "exec-ssh", Type: models.ExecutorTypeSSH},
+	}}
+	host := &fakePressureSource{memory: 99}
+	admission := &launchAdmission{
+		thresholds: metrics.PressureThresholds{MemoryPercent: 85},
+		host:       host,
+		executions: fakeExecutionProvider{},
+		store:      store,
+	}
+	if got := admission.Check(context.Background(), scheduler.AdmissionRequest{ExecutorID: "exec-ssh"}); got != "" {
+		t.Fatalf("Check = %q, want admitted", got)
+	}
+	if host.samples != 0 {
+		t.Fatalf("remote launch sampled the backend host %d times", host.samples)
+	}
+}
@@ -0,0 +1,148 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+	"github.com/kandev/kandev/internal/orchestrator/watcher"
+	"github.com/kandev/kandev/internal/task/models"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+// permissionPolicySetter is the optional agent-manager seam that replaces a
+// live session's step permission rules. Managers without it pick the rules
+// up from session metadata on the next launch or resume.
+type permissionPolicySetter interface {
+	SetPermissionPolicyBySessionID(context.Context, string, *permpolicy.Policy) error
+}
+
+// applyStepPermissionPolicy makes the step's set_permission_policy rules the
+// session's step rules, replacing those of the previous step, and pushes them
+// to the running agentctl. The agent profile's rules stay in effect after
+// the step's.
+func (s *Service) applyStepPermissionPolicy(ctx context.Context, session *models.TaskSession, step *wfmodels.WorkflowStep) {
+	policy, changed := s.persistStepPermissionPolicy(ctx, session, step)
+	if !changed {
+		return
+	}
+	setter, ok := s.agentManager.(permissionPolicySetter)
+	if !ok {
+		return
+	}
+	if err := setter.SetPermissionPolicyBySessionID(ctx, session.ID, policy); err != nil {
+		s.logger.Warn("set_permission_policy: could not apply rules to a live agent (persisted for next launch)",
+			zap.String("session_id", session.ID),
+			zap.String("step_id", step.ID),
+			zap.Error(err))
+	}
+}
+
+// persistStepPermissionPolicy records the step's permission rules in the
+// session metadata, or clears the previous step's. It returns the step's
+// rules and whether the session has or had step rules, i.e. whether a
+// running agentctl needs to be updated.
+func (s *Service) persistStepPermissionPolicy(
+	ctx context.Context, session *models.TaskSession, step *wfmodels.WorkflowStep,
+) (*permpolicy.Policy, bool) {
+	if session == nil || step == nil || s.repo == nil {
+		return nil, false
+	}
+	policy, err := wfmodels.StepPermissionPolicy(step)
+	if err != nil {
+		s.logger.Warn("ignoring invalid set_permission_policy action",
+			zap.String("session_id", session.ID),
+			zap.String("step_id", step.ID),
+			zap.Error(err))
+		return nil, false
+	}
+	previous := session.Metadata[models.SessionMetaKeyPermissionPolicy]
+	if policy == nil && previous == nil {
+		return nil, false
+	}
+	var value interface{}
+	if policy != nil {
+		value = policy
+	}
+	if err := s.repo.SetSessionMetadataKey(ctx, session.ID, models.SessionMetaKeyPermissionPolicy, value); err != nil {
+		s.logger.Warn("failed to persist step permission policy",
+			zap.String("session_id", session.ID),
+			zap.String("step_id", step.ID),
+			zap.Error(err))
+	}
+	if session.Metadata == nil {
+		session.Metadata = make(map[string]interface{})
+	}
+	session.Metadata[models.SessionMetaKeyPermissionPolicy] = value
+	return policy, true
+}
+
+// recordPolicyPermissionDecision writes a request agentctl's permission
+// policy answered to the permission audit trail: the permission_request
+// message is created and immediately claimed and finalized with the policy
+// as its source, so it reads like any other resolved request.
+func (s *Service) recordPolicyPermissionDecision(ctx context.Context, data watcher.PermissionRequestData) {
+	if s.messageCreator == nil {
+		return
+	}
+	decision := data.PermissionPolicy
+	logFields := []zap.Field{
+		zap.String("task_id", data.TaskID),
+		zap.String("session_id", data.TaskSessionID),
+		zap.String("pending_id", data.PendingID),
+		zap.String("decision", decision.Decision),
+		zap.String("rule", decision.Rule),
+	}
+	if _, err := s.messageCreator.CreatePermissionRequestMessage(
+		ctx, data.TaskID, data.TaskSessionID, data.RequestID, data.PendingID, data.ToolCallID, data.Title,
+		s.getActiveTurnID(data.TaskSessionID), data.Options, data.ActionType, data.ActionDetails,
+	); err != nil {
+		s.logger.Error("failed to create policy-decided permission message", append(logFields, zap.Error(err))...)
+		return
+	}
+
+	optionID, optionKind := decision.OptionID, string(decision.OptionKind)
+	if optionID == "" {
+		optionID, optionKind = stopReasonCancelled, stopReasonCancelled
+	}
+	claimID := uuid.NewString()
+	claim, err := s.claimPermissionWithRetry(ctx, models.PermissionResolutionClaimRequest{
+		TaskID:    data.TaskID,
+		SessionID: data.TaskSessionID,
+		Audit: models.PermissionResolutionAudit{
+			ClaimID:    claimID,
+			ActorKind:  models.PermissionActorAutomation,
+			Source:     models.PermissionSourcePolicy,
+			RequestID:  data.RequestID,
+			PendingID:  data.PendingID,
+			OptionID:   optionID,
+			OptionKind: optionKind,
+			SelectedAt: time.Now().UTC(),
+			PolicyRule: decision.Rule,
+		},
+	})
+	if err != nil || claim == nil || claim.Outcome != models.PermissionClaimed {
+		s.logger.Error("failed to record policy permission decision", append(logFields, zap.Error(err))...)
+		return
+	}
+	status := models.PermissionStatusRejected
+	if decision.Decision == string(permpolicy.DecisionAllow) {
+		status = models.PermissionStatusApproved
+	}
+	finalized, err := s.messageCreator.FinalizePermissionResolution(ctx, models.PermissionResolutionFinalizeRequest{
+		TaskID:      data.TaskID,
+		SessionID:   data.TaskSessionID,
+		RequestID:   data.RequestID,
+		PendingID:   data.PendingID,
+		ClaimID:     claimID,
+		Result:      models.PermissionResolutionAccepted,
+		Status:      status,
+		FinalizedAt: time.Now().UTC(),
+	})
+	if err != nil || finalized == nil || finalized.Outcome != models.PermissionFinalized {
+		s.logger.Error("failed to finalize policy permission decision", append(logFields, zap.Error(err))...)
+	}
+}
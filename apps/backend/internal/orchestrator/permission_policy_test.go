@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kandev/kandev/internal/agentctl/types/streams"
+	"github.com/kandev/kandev/internal/orchestrator/watcher"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+func TestRecordPolicyPermissionDecisionAuditsPolicyAsSource(t *testing.T) {
+	tests := []struct {
+		name           string
+		decision       streams.PermissionPolicyDecision
+		wantOptionID   string
+		wantOptionKind string
+		wantStatus     models.PermissionStatus
+	}{
+		{
+			name: "allow",
+			decision: streams.PermissionPolicyDecision{
+				Decision: "allow", Rule: "edits in repo",
+				OptionID: "allow-once", OptionKind: streams.PermissionOptionKindAllowOnce,
+			},
+			wantOptionID:   "allow-once",
+			wantOptionKind: string(streams.PermissionOptionKindAllowOnce),
+			wantStatus:     models.PermissionStatusApproved,
+		},
+		{
+			name:           "deny without a reject option cancels",
+			decision:       streams.PermissionPolicyDecision{Decision: "deny", Rule: "no rm -rf"},
+			wantOptionID:   stopReasonCancelled,
+			wantOptionKind: stopReasonCancelled,
+			wantStatus:     models.PermissionStatusRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestRepo(t)
+			var claims []models.PermissionResolutionClaimRequest
+			var finals []models.PermissionResolutionFinalizeRequest
+			messages := &mockMessageCreator{
+				permissionClaimFn: func(_ context.Context, request models.PermissionResolutionClaimRequest) (*models.PermissionResolutionClaimResult, error) {
+					claims = append(claims, request)
+					return &models.PermissionResolutionClaimResult{Outcome: models.PermissionClaimed}, nil
+				},
+				permissionFinishFn: func(_ context.Context, request models.PermissionResolutionFinalizeRequest) (*models.PermissionResolutionFinalizeResult, error) {
+					finals = append(finals, request)
+					return &models.PermissionResolutionFinalizeResult{Outcome: models.PermissionFinalized}, nil
+				},
+			}
+			svc := createTestServiceWithScheduler(repo, newMockStepGetter(), newMockTaskRepo(), &mockAgentManager{})
+			svc.messageCreator = messages
+
+			decision := tt.decision
+			svc.recordPolicyPermissionDecision(context.Background(), watcher.PermissionRequestData{
+				TaskID: "task-1", TaskSessionID: "session-1", RequestID: "request-1", PendingID: "pending-1",
+				PermissionPolicy: &decision,
+			})
+
+			if len(claims) != 1 || len(finals) != 1 {
+				t.Fatalf("claims = %d, finalizations = %d; want one each", len(claims), len(finals))
+			}
+			audit := claims[0].Audit
+			if audit.Source != models.PermissionSourcePolicy || audit.ActorKind != models.PermissionActorAutomation {
+				t.Errorf("audit source/actor = %q/%q, want policy/automation", audit.Source, audit.ActorKind)
+			}
+			if audit.PolicyRule != tt.decision.Rule || audit.OptionID != tt.wantOptionID || audit.OptionKind != tt.wantOptionKind {
+				t.Errorf("audit = %+v, want rule %q option %q/%q", audit, tt.decision.Rule, tt.wantOptionID, tt.wantOptionKind)
+			}
+			if finals[0].ClaimID != audit.ClaimID || finals[0].Status != tt.wantStatus ||
+				finals[0].Result != models.PermissionResolutionAccepted {
+				t.Errorf("finalization = %+v, want claim %s accepted as %s", finals[0], audit.ClaimID, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AdmissionRequest describes a launch asking to start.
+type AdmissionRequest struct {
+	TaskID     string
+	ExecutorID string
+}
+
+// AdmissionChecker decides whether a new agent launch may start now. Check
+// returns why the launch should wait, or "" to admit it.
+type AdmissionChecker interface {
+	Check(ctx context.Context, req AdmissionRequest) string
+}
+
+// DeferredLaunch is a launch held back by admission control. Launch is run
+// once the launch is admitted and must not ask for admission again.
+type DeferredLaunch struct {
+	AdmissionRequest
+	Launch func(ctx context.Context) error
+
+	reason     string
+	deferredAt time.Time
+}
+
+// AdmissionListener is told when a task's launch starts waiting and, with an
+// empty reason, when it stops waiting.
+type AdmissionListener func(taskID, reason string)
+
+// SetAdmission installs the admission checker and the listener notified of
+// deferred launches. A nil checker admits every launch.
+func (s *Scheduler) SetAdmission(checker AdmissionChecker, listener AdmissionListener) {
+	s.admissionMu.Lock()
+	defer s.admissionMu.Unlock()
+	s.admission = checker
+	s.admissionListener = listener
+}
+
+// Admit returns why a new launch must wait, or "" when it may start. Launches
+// already waiting go first, so a new launch is never admitted ahead of them.
+func (s *Scheduler) Admit(ctx context.Context, req AdmissionRequest) string {
+	s.admissionMu.Lock()
+	checker := s.admission
+	waiting := len(s.deferred)
+	headReason := ""
+	if waiting > 0 {
+		headReason = s.deferred[0].reason
+	}
+	s.admissionMu.Unlock()
+	if checker == nil {
+		return ""
+	}
+	if waiting > 0 {
+		return fmt.Sprintf("%s; %d launch(es) ahead", headReason, waiting)
+	}
+	return checker.Check(ctx, req)
+}
+
+// DeferLaunch queues a launch until admission control lets it start. A task
+// has at most one deferred launch; deferring it again replaces the launch but
+// keeps its place in line.
+func (s *Scheduler) DeferLaunch(launch DeferredLaunch, reason string) {
+	s.admissionMu.Lock()
+	launch.reason = reason
+	launch.deferredAt = time.Now()
+	replaced := false
+	for i, queued := range s.deferred {
+		if queued.TaskID == launch.TaskID {
+			launch.deferredAt = queued.deferredAt
+			s.deferred[i] = launch
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		s.deferred = append(s.deferred, launch)
+	}
+	listener := s.admissionListener
+	s.admissionMu.Unlock()
+
+	s.logger.Info("launch deferred by admission control",
+		zap.String("task_id", launch.TaskID),
+		zap.String("reason", reason))
+	if listener != nil {
+		listener(launch.TaskID, reason)
+	}
+}
+
+// CancelDeferredLaunch drops a task's deferred launch. It reports whether
+// one was queued.
+func (s *Scheduler) CancelDeferredLaunch(taskID string) bool {
+	s.admissionMu.Lock()
+	removed := false
+	for i, queued := range s.deferred {
+		if queued.TaskID == taskID {
+			s.deferred = append(s.deferred[:i], s.deferred[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	listener := s.admissionListener
+	s.admissionMu.Unlock()
+	if removed && listener != nil {
+		listener(taskID, "")
+	}
+	return removed
+}
+
+// DeferredLaunchCount returns how many launches are waiting for admission.
+func (s *Scheduler) DeferredLaunchCount() int {
+	s.admissionMu.Lock()
+	defer s.admissionMu.Unlock()
+	return len(s.deferred)
+}
+
+// processDeferredLaunches starts the oldest deferred launch when admission
+// control allows it. One launch per tick: a new agent takes a while to show
+// up in load and memory figures, and admitting the whole line at once is the
+// stampede this exists to prevent.
+func (s *Scheduler) processDeferredLaunches(ctx context.Context) {
+	s.admissionMu.Lock()
+	if len(s.deferred) == 0 {
+		s.admissionMu.Unlock()
+		return
+	}
+	head := s.deferred[0]
+	checker := s.admission
+	listener := s.admissionListener
+	s.admissionMu.Unlock()
+
+	// The reason shown for a waiting launch is the one it was deferred with;
+	// re-publishing it on every tick would churn the task for a figure that
+	// moves by a tenth of a percent.
+	if checker != nil && checker.Check(ctx, head.AdmissionRequest) != "" {
+		return
+	}
+	if !s.takeDeferred(head.TaskID) {
+		return
+	}
+	if listener != nil {
+		listener(head.TaskID, "")
+	}
+	s.logger.Info("deferred launch admitted",
+		zap.String("task_id", head.TaskID),
+		zap.Duration("waited", time.Since(head.deferredAt)))
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := head.Launch(ctx); err != nil {
+			s.logger.Error("deferred launch failed",
+				zap.String("task_id", head.TaskID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// takeDeferred removes a queued launch, reporting false when it was
+// cancelled in the meantime.
+func (s *Scheduler) takeDeferred(taskID string) bool {
+	s.admissionMu.Lock()
+	defer s.admissionMu.Unlock()
+	for i, queued := range s.deferred {
+		if queued.TaskID == taskID {
+			s.deferred = append(s.deferred[:i], s.deferred[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// dropDeferredLaunches forgets every deferred launch when the scheduler
+// stops; they are held in memory only, so their tasks must stop advertising
+// a wait nothing will end.
+func (s *Scheduler) dropDeferredLaunches() {
+	s.admissionMu.Lock()
+	dropped := s.deferred
+	s.deferred = nil
+	listener := s.admissionListener
+	s.admissionMu.Unlock()
+	if listener == nil {
+		return
+	}
+	for _, launch := range dropped {
+		listener(launch.TaskID, "")
+	}
+}
@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/orchestrator/queue"
+)
+
+type fakeAdmission struct {
+	mu     sync.Mutex
+	reason string
+}
+
+func (f *fakeAdmission) Check(context.Context, AdmissionRequest) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reason
+}
+
+func (f *fakeAdmission) set(reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reason = reason
+}
+
+type admissionEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (a *admissionEvents) record(taskID, reason string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, taskID+"="+reason)
+}
+
+func (a *admissionEvents) all() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.events...)
+}
+
+func newAdmissionScheduler(t *testing.T) (*Scheduler, *fakeAdmission, *admissionEvents) {
+	t.Helper()
+	s := NewScheduler(queue.NewTaskQueue(10), nil, nil, createTestLogger(), DefaultSchedulerConfig())
+	checker := &fakeAdmission{}
+	events := &admissionEvents{}
+	s.SetAdmission(checker, events.record)
+	return s, checker, events
+}
+
+func TestAdmitWithoutChecker(t *testing.T) {
+	s := NewScheduler(queue.NewTaskQueue(10), nil, nil, createTestLogger(), DefaultSchedulerConfig())
+	if reason := s.Admit(context.Background(), AdmissionRequest{TaskID: "t1"}); reason != "" {
+		t.Fatalf("Admit = %q, want admitted", reason)
+	}
+}
+
+func TestDeferredLaunchesResumeInOrderWhenPressureDrops(t *testing.T) {
+	s, checker, events := newAdmissionScheduler(t)
+	ctx := context.Background()
+	launched := make(chan string, 2)
+	deferTask := func(taskID, reason string) {
+		s.DeferLaunch(DeferredLaunch{
+			AdmissionRequest: AdmissionRequest{TaskID: taskID},
+			Launch: func(context.Context) error {
+				launched <- taskID
+				return nil
+			},
+		}, reason)
+	}
+
+	checker.set("memory at 91% (limit 85%)")
+	reason := s.Admit(ctx, AdmissionRequest{TaskID: "t1"})
+	if reason != "memory at 91% (limit 85%)" {
+		t.Fatalf("Admit = %q, want checker reason", reason)
+	}
+	deferTask("t1", reason)
+
+	// Pressure dropping does not let a new launch jump the line.
+	checker.set("")
+	reason = s.Admit(ctx, AdmissionRequest{TaskID: "t2"})
+	if !strings.Contains(reason, "1 launch(es) ahead") {
+		t.Fatalf("Admit behind a waiting launch = %q", reason)
+	}
+	deferTask("t2", reason)
+
+	for _, want := range []string{"t1", "t2"} {
+		s.processDeferredLaunches(ctx)
+		select {
+		case got := <-launched:
+			if got != want {
+				t.Fatalf("launched %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not launched", want)
+		}
+	}
+	s.wg.Wait()
+	if n := s.DeferredLaunchCount(); n != 0 {
+		t.Fatalf("DeferredLaunchCount = %d, want 0", n)
+	}
+	got := events.all()
+	if len(got) != 4 || got[2] != "t1=" || got[3] != "t2=" {
+		t.Fatalf("listener events = %v", got)
+	}
+}
+
+func TestDeferredLaunchWaitsWhilePressureHolds(t *testing.T) {
+	s, checker, _ := newAdmissionScheduler(t)
+	checker.set("load at 2.4 per core (limit 1.5)")
+	s.DeferLaunch(DeferredLaunch{
+		AdmissionRequest: AdmissionRequest{TaskID: "t1"},
+		Launch: func(context.Context) error {
+			t.Error("launch ran under pressure")
+			return nil
+		},
+	}, "load")
+
+	s.processDeferredLaunches(context.Background())
+	if n := s.DeferredLaunchCount(); n != 1 {
+		t.Fatalf("DeferredLaunchCount = %d, want 1", n)
+	}
+}
+
+func TestRemoveTaskCancelsDeferredLaunch(t *testing.T) {
+	s, checker, events := newAdmissionScheduler(t)
+	checker.set("memory")
+	s.DeferLaunch(DeferredLaunch{AdmissionRequest: AdmissionRequest{TaskID: "t1"}}, "memory")
+	s.DeferLaunch(DeferredLaunch{AdmissionRequest: AdmissionRequest{TaskID: "t1"}}, "memory")
+
+	if n := s.DeferredLaunchCount(); n != 1 {
+		t.Fatalf("re-deferring a task queued it twice: count = %d", n)
+	}
+	if !s.RemoveTask("t1") {
+		t.Fatal("RemoveTask did not report the deferred launch")
+	}
+	if n := s.DeferredLaunchCount(); n != 0 {
+		t.Fatalf("DeferredLaunchCount = %d after RemoveTask", n)
+	}
+	if got := events.all(); got[len(got)-1] != "t1=" {
+		t.Fatalf("listener not told the wait ended: %v", got)
+	}
+}
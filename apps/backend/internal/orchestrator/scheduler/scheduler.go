@@ -85,6 +85,13 @@ type Scheduler struct {
 	retryCount map[string]int
 	retryMu    sync.RWMutex
 
+	// Admission control; deferred holds launches waiting for it, oldest
+	// first.
+	admission         AdmissionChecker
+	admissionListener AdmissionListener
+	deferred          []DeferredLaunch
+	admissionMu       sync.Mutex
+
 	// Statistics
 	totalProcessed int64
 	totalFailed    int64
@@ -145,6 +152,7 @@ func (s *Scheduler) Stop() error {
 	s.mu.Unlock()
 
 	s.wg.Wait()
+	s.dropDeferredLaunches()
 	s.logger.Info("scheduler stopped")
 	return nil
 }
@@ -165,9 +173,13 @@ func (s *Scheduler) EnqueueTask(task *v1.Task) error {
 	return s.queue.Enqueue(task)
 }
 
-// RemoveTask removes a task from the queue
+// RemoveTask removes a task from the queue and drops its deferred launch
 func (s *Scheduler) RemoveTask(taskID string) bool {
 	removed := s.queue.Remove(taskID)
+	if s.CancelDeferredLaunch(taskID) {
+		s.logger.Info("dropped deferred launch", zap.String("task_id", taskID))
+		removed = true
+	}
 	if removed {
 		s.logger.Info("removed task from queue", zap.String("task_id", taskID))
 	}
@@ -268,6 +280,7 @@ func (s *Scheduler) processLoop(ctx context.Context) {
 			s.logger.Info("scheduler stopping due to stop signal")
 			return
 		case <-ticker.C:
+			s.processDeferredLaunches(ctx)
 			s.processTasks(ctx)
 		}
 	}
@@ -570,6 +570,9 @@ type Service struct {
 	// onProcessOnEnterComplete is a package-test hook for synchronizing with
 	// applyEngineTransition's asynchronous processOnEnter goroutine.
 	onProcessOnEnterComplete func()
+	// fanOutTaskStarter replaces the agent launch of fan-out branch and
+	// judge tasks in package tests. Nil in production.
+	fanOutTaskStarter func(ctx context.Context, task *models.Task, agentProfileID, prompt string) error
 	// queuedMoveExitStart and queuedMoveExitComplete are package-test hooks
 	// for the durable source-exit barrier.
 	onQueuedMoveExitStart             func()
@@ -592,6 +595,9 @@ type Service struct {
 	// Primary agent resolvers — without coupling the orchestrator to
 	// the office or runs packages.
 	engineOptions []engine.Option
+	// checkRunner runs the run_check gates evaluate-only triggers leave
+	// pending; see runPendingCheck.
+	checkRunner engine.CheckRunner
 
 	// Phase 2 (ADR-0004) callback dependencies, set via dedicated
 	// setters from cmd/kandev. When set, buildWorkflowCallbacks
@@ -606,6 +612,8 @@ type Service struct {
 	// Phase 8 dependencies — also nil-safe.
 	engineTaskCreator      engine.TaskCreator
 	engineWorkflowSwitcher engine.WorkflowSwitcher
+	// Delivers the notify action; nil-safe like the above.
+	engineNotifier engine.Notifier
 
 	// Native code review. When set, buildWorkflowCallbacks registers the
 	// run_code_review on_enter action. Nil-safe: without it the action kind
@@ -718,6 +726,11 @@ type Service struct {
 	// manager simply keeps every run's checkout. Set via SetWorktreeManager.
 	worktreeReaper automationWorktreeReaper
 
+	// fanOutMerger merges a fan-out winner's branch into the parent's
+	// worktree. Nil leaves the parent's workspace untouched. Set via
+	// SetWorktreeManager.
+	fanOutMerger fanOutBranchMerger
+
 	// taskLaunchRecoveryWorktree resolves live remote defaults for the explicit
 	// task.launch.recover action. It is nil in isolated orchestrator tests.
 	taskLaunchRecoveryWorktree taskLaunchRecoveryWorktree
@@ -1236,6 +1249,7 @@ func NewService(
 		OnAgentCompleted:       s.handleAgentCompleted,
 		OnAgentFailed:          s.handleAgentFailed,
 		OnAgentStalled:         s.handleAgentStalled,
+		OnEgressDenied:         s.handleEgressDenied,
 		OnAgentStopped:         s.handleAgentStopped,
 		OnAgentStreamEvent:     s.handleAgentStreamEvent,
 		OnACPSessionCreated:    s.handleACPSessionCreated,
@@ -1509,6 +1523,26 @@ func (s *Service) publishTaskActivityIfChanged(ctx context.Context, taskID strin
 	s.taskEvents.PublishTaskActivityIfChanged(ctx, taskID)
 }
 
+// publishTaskStepEntered announces a step change the workflow engine applied
+// on its own. Manual moves publish task.moved; without this, engine-driven
+// transitions would surface on the bus only as a generic task.updated.
+func (s *Service) publishTaskStepEntered(ctx context.Context, taskID, sessionID, fromStepID, toStepID string, trigger wfmodels.StepTransitionTrigger) {
+	if s.eventBus == nil || taskID == "" {
+		return
+	}
+	event := bus.NewEvent(events.TaskStepEntered, "orchestrator", map[string]interface{}{
+		"task_id":      taskID,
+		"session_id":   sessionID,
+		"from_step_id": fromStepID,
+		"to_step_id":   toStepID,
+		"trigger":      string(trigger),
+	})
+	if err := s.eventBus.Publish(ctx, events.TaskStepEntered, event); err != nil {
+		s.logger.Error("failed to publish task.step_entered event",
+			zap.String("task_id", taskID), zap.Error(err))
+	}
+}
+
 func (s *Service) publishTaskMoved(ctx context.Context, task *models.Task, fromWorkflowID, fromStepID, toStepID, sessionID string) {
 	if s.eventBus == nil || task == nil {
 		return
@@ -1649,8 +1683,12 @@ func (s *Service) initWorkflowEngine() {
 	// the service's logger. Passed here (rather than folded into
 	// s.engineOptions via a Set* method) because s.logger is a stable
 	// constructor-time field already in scope, unlike the optional
-	// dependencies those methods wire in after Service creation.
-	options := append([]engine.Option{engine.WithLogger(s.logger)}, s.engineOptions...)
+	// dependencies those methods wire in after Service creation. The guard
+	// facts provider reads the service lazily, so it is always wired too.
+	options := append([]engine.Option{
+		engine.WithLogger(s.logger),
+		engine.WithGuardFactsProvider(workflowGuardFacts{svc: s}),
+	}, s.engineOptions...)
 	s.workflowEngine = engine.New(store, callbacks, options...)
 }
 
@@ -1717,6 +1755,13 @@ func (s *Service) SetEngineWorkflowSwitcher(switcher engine.WorkflowSwitcher) {
 	s.reinitWorkflowEngine()
 }
 
+// SetEngineNotifier wires the engine's Notifier for the notify action.
+// Only the callback registry needs it, so no engine option is added.
+func (s *Service) SetEngineNotifier(notifier engine.Notifier) {
+	s.engineNotifier = notifier
+	s.reinitWorkflowEngine()
+}
+
 func (s *Service) reinitWorkflowEngine() {
 	if s.workflowStepGetter != nil {
 		s.initWorkflowEngine()
@@ -0,0 +1,267 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/orchestrator/executor"
+	"github.com/kandev/kandev/internal/sysprompt"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+// Transcript limits for the conversation a fork is seeded with. Long tool
+// output and pasted logs are cut per message, and the oldest messages are
+// dropped first when the whole conversation is too long.
+const (
+	forkMessageMaxChars    = 2000
+	forkTranscriptMaxChars = 30000
+)
+
+// ErrForkMessageNotFound is returned when the message to fork from is not an
+// active message of the session.
+var ErrForkMessageNotFound = errors.New("message not found in session")
+
+// ForkOrigin describes the conversation a forked session continues. Like
+// SpawnOrigin it is built server-side from stored messages, never from
+// client text, and turned into a trusted system block on the first turn.
+type ForkOrigin struct {
+	TaskID     string
+	SessionID  string
+	MessageID  string
+	TurnNumber int
+	Transcript string
+	// CheckpointID is SessionFork.CheckpointID. A fork into its source task
+	// keeps it on the session; other forks carry it in their task metadata.
+	CheckpointID string
+}
+
+// SessionFork is a resolved fork point: the source it was taken from, the
+// checkpoint to seed the new workspace with and the context for its first
+// turn.
+type SessionFork struct {
+	SourceTask    *models.Task
+	SourceSession *models.TaskSession
+	MessageID     string
+	// CheckpointID is the workspace checkpoint matching the fork point;
+	// empty when none could be resolved.
+	CheckpointID string
+	// Prompt is the forked user message when forking at one, so the client
+	// can offer to send it again (possibly edited) in the new session.
+	Prompt string
+	Origin *ForkOrigin
+}
+
+// PrepareSessionFork resolves forking a session at messageID. The fork
+// continues the conversation up to and including that message; forking at a
+// user message stops just before it, so the prompt can be sent again
+// differently. The workspace checkpoint is the one taken when the fork point
+// was current: the start of a user message's turn, or the end of any other
+// message's turn, which is the next turn's checkpoint or, for the latest
+// turn, one taken now.
+func (s *Service) PrepareSessionFork(ctx context.Context, sessionID, messageID string) (*SessionFork, error) {
+	if err := s.authorizeSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	session, err := s.repo.GetTaskSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	task, err := s.repo.GetTask(ctx, session.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("task not found: %w", err)
+	}
+	messages, err := s.repo.ListMessages(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list messages: %w", err)
+	}
+	live := liveMessages(messages)
+	index := -1
+	for i, message := range live {
+		if message.ID == messageID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, ErrForkMessageNotFound
+	}
+	point := live[index]
+	turnIDs := turnOrder(live)
+	turnNumber := indexOf(turnIDs, point.TurnID) + 1
+
+	fork := &SessionFork{
+		SourceTask:    task,
+		SourceSession: session,
+		MessageID:     messageID,
+	}
+	history := live[:index+1]
+	if point.AuthorType == models.MessageAuthorUser {
+		history = live[:index]
+		fork.Prompt = sysprompt.StripSystemContent(point.Content)
+		fork.CheckpointID = point.TurnID
+		turnNumber--
+	} else {
+		fork.CheckpointID, err = s.forkCheckpointAfter(ctx, session, turnIDs, turnNumber)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fork.Origin = &ForkOrigin{
+		TaskID:       task.ID,
+		SessionID:    session.ID,
+		MessageID:    messageID,
+		TurnNumber:   turnNumber,
+		Transcript:   forkTranscript(history),
+		CheckpointID: fork.CheckpointID,
+	}
+	return fork, nil
+}
+
+// forkCheckpointAfter returns the checkpoint holding the workspace as turn
+// turnNumber (1-based) left it. Failing to take a new checkpoint is not
+// fatal: the fork then starts from its base branch.
+func (s *Service) forkCheckpointAfter(
+	ctx context.Context,
+	session *models.TaskSession,
+	turnIDs []string,
+	turnNumber int,
+) (string, error) {
+	if turnNumber < len(turnIDs) {
+		return turnIDs[turnNumber], nil
+	}
+	if session.State == models.TaskSessionStateRunning || session.State == models.TaskSessionStateStarting {
+		return "", errors.New("the agent is still working on this turn; wait for it to finish before forking from it")
+	}
+	checkpointer, ok := s.agentManager.(executor.WorkspaceCheckpointer)
+	if !ok {
+		return "", nil
+	}
+	id := "fork-" + uuid.New().String()
+	if _, err := checkpointer.CreateWorkspaceCheckpoint(ctx, session.ID, id); err != nil {
+		s.logger.Info("forking without a workspace checkpoint",
+			zap.String("session_id", session.ID), zap.Error(err))
+		return "", nil
+	}
+	return id, nil
+}
+
+// scopeSameTaskFork prepares a fork's session in its source task to launch in
+// an environment of its own: a new worktree and branch, seeded from the fork
+// checkpoint, beside the environment the task's other sessions share. The
+// environment itself is created by the launch; the session is only unlinked
+// from any environment here.
+func (s *Service) scopeSameTaskFork(ctx context.Context, sessionID string, origin *ForkOrigin) error {
+	session, err := s.repo.GetTaskSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("load forked session: %w", err)
+	}
+	if session.Metadata == nil {
+		session.Metadata = make(map[string]interface{})
+	}
+	session.Metadata[models.SessionMetaKeyOwnEnvironment] = true
+	session.Metadata[models.MetaKeyForkedFrom] = map[string]interface{}{
+		"task_id":    origin.TaskID,
+		"session_id": origin.SessionID,
+		"message_id": origin.MessageID,
+	}
+	// PrepareSession copied the task metadata, which names the checkpoint
+	// the task itself was forked from, if any.
+	delete(session.Metadata, models.MetaKeyForkCheckpoint)
+	if origin.CheckpointID != "" {
+		session.Metadata[models.MetaKeyForkCheckpoint] = origin.CheckpointID
+	}
+	session.TaskEnvironmentID = ""
+	if err := s.repo.UpdateTaskSession(ctx, session); err != nil {
+		return fmt.Errorf("scope forked session environment: %w", err)
+	}
+	return nil
+}
+
+// liveMessages drops messages of rewound turns.
+func liveMessages(messages []*models.Message) []*models.Message {
+	live := make([]*models.Message, 0, len(messages))
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		if rewound, _ := message.Metadata[models.MessageMetaKeyRewound].(bool); rewound {
+			continue
+		}
+		live = append(live, message)
+	}
+	return live
+}
+
+// turnOrder returns the distinct turn IDs of messages in order of appearance.
+func turnOrder(messages []*models.Message) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, message := range messages {
+		if message.TurnID == "" || seen[message.TurnID] {
+			continue
+		}
+		seen[message.TurnID] = true
+		ids = append(ids, message.TurnID)
+	}
+	return ids
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// forkTranscript renders the user and agent messages of a conversation as
+// plain text. Tool calls, thinking and status messages are left out: their
+// effect is in the workspace the fork is seeded with.
+func forkTranscript(messages []*models.Message) string {
+	var lines []string
+	for _, message := range messages {
+		var speaker string
+		switch {
+		case message.AuthorType == models.MessageAuthorUser:
+			speaker = "User"
+		case message.Type == "" || message.Type == models.MessageTypeMessage || message.Type == models.MessageTypeContent:
+			speaker = "Agent"
+		default:
+			continue
+		}
+		content := sysprompt.StripSystemContent(message.Content)
+		if content == "" {
+			continue
+		}
+		if runes := []rune(content); len(runes) > forkMessageMaxChars {
+			content = string(runes[:forkMessageMaxChars]) + " […]"
+		}
+		lines = append(lines, speaker+": "+content)
+	}
+
+	total, start := 0, len(lines)
+	for start > 0 && total+len(lines[start-1]) <= forkTranscriptMaxChars {
+		start--
+		total += len(lines[start])
+	}
+	kept := lines[start:]
+	if start > 0 {
+		kept = append([]string{fmt.Sprintf("(%d earlier messages omitted)", start)}, kept...)
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// forkOriginContent renders the forked-conversation context for a launch that
+// continues another session, or "" for ordinary launches.
+func forkOriginContent(origin *ForkOrigin) string {
+	if origin == nil {
+		return ""
+	}
+	return sysprompt.ForkedSessionContext(origin.TaskID, origin.SessionID, origin.TurnNumber, origin.Transcript)
+}
@@ -0,0 +1,140 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/task/models"
+	sqliterepo "github.com/kandev/kandev/internal/task/repository/sqlite"
+)
+
+func setupForkTest(t *testing.T) (*Service, *checkpointingAgentManager, *sqliterepo.Repository) {
+	t.Helper()
+	ctx := context.Background()
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "t1", "s1", "step1")
+	requireNoError(t, repo.UpdateTaskSessionState(ctx, "s1", models.TaskSessionStateWaitingForInput, ""))
+
+	now := time.Now().UTC()
+	requireNoError(t, repo.CreateTurn(ctx, &models.Turn{ID: "turn-1", TaskSessionID: "s1", TaskID: "t1", StartedAt: now}))
+	requireNoError(t, repo.CreateTurn(ctx, &models.Turn{ID: "turn-2", TaskSessionID: "s1", TaskID: "t1", StartedAt: now.Add(time.Minute)}))
+	messages := []*models.Message{
+		{ID: "u1", TurnID: "turn-1", AuthorType: models.MessageAuthorUser, Content: "first request"},
+		{ID: "tool1", TurnID: "turn-1", AuthorType: models.MessageAuthorAgent, Type: models.MessageTypeToolExecute, Content: "ls -la"},
+		{ID: "a1", TurnID: "turn-1", AuthorType: models.MessageAuthorAgent, Type: models.MessageTypeMessage, Content: "first reply"},
+		{ID: "u2", TurnID: "turn-2", AuthorType: models.MessageAuthorUser, Content: "second request"},
+		{ID: "a2", TurnID: "turn-2", AuthorType: models.MessageAuthorAgent, Type: models.MessageTypeMessage, Content: "second reply"},
+	}
+	for i, message := range messages {
+		message.TaskSessionID = "s1"
+		message.TaskID = "t1"
+		message.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		requireNoError(t, repo.CreateMessage(ctx, message))
+	}
+
+	agentManager := &checkpointingAgentManager{mockAgentManager: &mockAgentManager{}}
+	svc := createTestServiceWithAgent(repo, newMockStepGetter(), newMockTaskRepo(), agentManager)
+	return svc, agentManager, repo
+}
+
+func TestPrepareSessionFork_AgentMessageUsesNextTurnCheckpoint(t *testing.T) {
+	svc, agentManager, _ := setupForkTest(t)
+
+	fork, err := svc.PrepareSessionFork(context.Background(), "s1", "a1")
+	if err != nil {
+		t.Fatalf("prepare fork: %v", err)
+	}
+	if fork.CheckpointID != "turn-2" || fork.Prompt != "" || fork.Origin.TurnNumber != 1 {
+		t.Fatalf("unexpected fork: checkpoint=%q prompt=%q turn=%d", fork.CheckpointID, fork.Prompt, fork.Origin.TurnNumber)
+	}
+	transcript := fork.Origin.Transcript
+	if !strings.Contains(transcript, "User: first request") || !strings.Contains(transcript, "Agent: first reply") {
+		t.Fatalf("transcript misses the forked turn: %q", transcript)
+	}
+	if strings.Contains(transcript, "second") || strings.Contains(transcript, "ls -la") {
+		t.Fatalf("transcript has messages it should not: %q", transcript)
+	}
+	if len(agentManager.checkpoints) != 0 {
+		t.Fatal("an existing checkpoint should be reused")
+	}
+}
+
+func TestPrepareSessionFork_UserMessageForksBeforeIt(t *testing.T) {
+	svc, _, _ := setupForkTest(t)
+
+	fork, err := svc.PrepareSessionFork(context.Background(), "s1", "u2")
+	if err != nil {
+		t.Fatalf("prepare fork: %v", err)
+	}
+	if fork.CheckpointID != "turn-2" || fork.Prompt != "second request" || fork.Origin.TurnNumber != 1 {
+		t.Fatalf("unexpected fork: checkpoint=%q prompt=%q turn=%d", fork.CheckpointID, fork.Prompt, fork.Origin.TurnNumber)
+	}
+	if strings.Contains(fork.Origin.Transcript, "second request") {
+		t.Fatalf("the forked prompt must not be in the transcript: %q", fork.Origin.Transcript)
+	}
+}
+
+func TestPrepareSessionFork_LatestTurnTakesCheckpoint(t *testing.T) {
+	svc, agentManager, _ := setupForkTest(t)
+
+	fork, err := svc.PrepareSessionFork(context.Background(), "s1", "a2")
+	if err != nil {
+		t.Fatalf("prepare fork: %v", err)
+	}
+	if !strings.HasPrefix(fork.CheckpointID, "fork-") || len(agentManager.checkpoints) != 1 ||
+		agentManager.checkpoints[0].ID != fork.CheckpointID {
+		t.Fatalf("checkpoint = %q, taken = %+v", fork.CheckpointID, agentManager.checkpoints)
+	}
+
+	requireNoError(t, svc.repo.UpdateTaskSessionState(context.Background(), "s1", models.TaskSessionStateRunning, ""))
+	if _, err := svc.PrepareSessionFork(context.Background(), "s1", "a2"); err == nil {
+		t.Fatal("expected forking from a running turn to fail")
+	}
+}
+
+func TestPrepareSessionFork_RewoundMessageNotFound(t *testing.T) {
+	svc, _, repo := setupForkTest(t)
+	ctx := context.Background()
+	message, err := repo.GetMessage(ctx, "a2")
+	requireNoError(t, err)
+	message.Metadata = map[string]interface{}{models.MessageMetaKeyRewound: true}
+	requireNoError(t, repo.UpdateMessage(ctx, message))
+
+	if _, err := svc.PrepareSessionFork(ctx, "s1", "a2"); !errors.Is(err, ErrForkMessageNotFound) {
+		t.Fatalf("err = %v, want ErrForkMessageNotFound", err)
+	}
+	if _, err := svc.PrepareSessionFork(ctx, "s1", "missing"); !errors.Is(err, ErrForkMessageNotFound) {
+		t.Fatalf("err = %v, want ErrForkMessageNotFound", err)
+	}
+}
+
+func TestScopeSameTaskFork_GivesTheSessionItsOwnEnvironment(t *testing.T) {
+	svc, _, repo := setupForkTest(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	requireNoError(t, repo.CreateTaskSession(ctx, &models.TaskSession{
+		ID: "s2", TaskID: "t1", State: models.TaskSessionStateCreated, StartedAt: now, UpdatedAt: now,
+		TaskEnvironmentID: "env-shared",
+		Metadata:          map[string]interface{}{models.MetaKeyForkCheckpoint: "task-fork-checkpoint"},
+	}))
+
+	fork, err := svc.PrepareSessionFork(ctx, "s1", "a1")
+	requireNoError(t, err)
+	requireNoError(t, svc.scopeSameTaskFork(ctx, "s2", fork.Origin))
+
+	scoped, err := repo.GetTaskSession(ctx, "s2")
+	requireNoError(t, err)
+	if !models.SessionOwnsEnvironment(scoped) || scoped.TaskEnvironmentID != "" {
+		t.Fatalf("session not scoped: metadata=%v env=%q", scoped.Metadata, scoped.TaskEnvironmentID)
+	}
+	if got := scoped.Metadata[models.MetaKeyForkCheckpoint]; got != "turn-2" {
+		t.Fatalf("fork checkpoint = %v, want turn-2", got)
+	}
+	forkedFrom, _ := scoped.Metadata[models.MetaKeyForkedFrom].(map[string]interface{})
+	if forkedFrom["session_id"] != "s1" || forkedFrom["message_id"] != "a1" {
+		t.Fatalf("forked_from = %v", scoped.Metadata[models.MetaKeyForkedFrom])
+	}
+}
@@ -78,6 +78,9 @@ type LaunchSessionRequest struct {
 	// <kandev-system> block that survives first-turn canonicalization, so a WS
 	// client must not be able to forge one and fabricate server authority.
 	SpawnOrigin *SpawnOrigin `json:"-"`
+	// ForkOrigin carries the conversation a forked session continues, resolved
+	// by PrepareSessionFork. Server-only for the same reason as SpawnOrigin.
+	ForkOrigin *ForkOrigin `json:"-"`
 }
 
 // SpawnOrigin describes the agent session that spawned a new sibling session.
@@ -252,13 +255,18 @@ func (s *Service) launchStart(ctx context.Context, req *LaunchSessionRequest) (*
 		ctx, req.TaskID, req.AgentProfileID, req.ExecutorID,
 		req.ExecutorProfileID, req.Priority, req.Prompt,
 		req.WorkflowStepID, req.PlanMode, req.AutoStart, req.Attachments,
-		startTaskOptions{ProfileExplicit: req.ProfileExplicit, SpawnOrigin: req.SpawnOrigin},
+		startTaskOptions{
+			ProfileExplicit: req.ProfileExplicit,
+			SpawnOrigin:     req.SpawnOrigin,
+			ForkOrigin:      req.ForkOrigin,
+		},
 	)
 	if err != nil {
 		return nil, err
 	}
 	if execution == nil {
-		// The automatic terminal-PR gate intentionally skips session creation.
+		// The automatic terminal-PR gate intentionally skips session creation,
+		// and admission control may defer the launch until resources free up.
 		// Return a successful no-op response so session.ensure and WS callers do
 		// not dereference a nil execution while the task-owned error card remains
 		// the recovery surface.
@@ -283,7 +291,7 @@ func (s *Service) shouldBlockAutoStart(ctx context.Context, req *LaunchSessionRe
 		return false
 	}
 
-	step, err := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+	step, err := s.getTaskStep(ctx, task.ID, task.WorkflowStepID)
 	if err != nil || step == nil {
 		return false
 	}
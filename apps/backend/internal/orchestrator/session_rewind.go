@@ -0,0 +1,259 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	client "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/orchestrator/executor"
+	"github.com/kandev/kandev/internal/sysprompt"
+	"github.com/kandev/kandev/internal/task/models"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+var (
+	// ErrRewindUnsupported is returned when the runtime or turn store cannot
+	// take part in a rewind.
+	ErrRewindUnsupported = errors.New("workspace rewind is not supported for this session")
+	// ErrNoCheckpoint is returned when the turn has no workspace checkpoint,
+	// e.g. it ran before checkpoints existed or in a workspace without git.
+	ErrNoCheckpoint = errors.New("no workspace checkpoint for turn")
+)
+
+// TurnRewinder is the optional TurnService capability rewinds need: the
+// session's turn history and a way to mark discarded turns.
+type TurnRewinder interface {
+	ListTurnsBySession(ctx context.Context, sessionID string) ([]*models.Turn, error)
+	MarkTurnsRewound(ctx context.Context, sessionID string, turnIDs []string) error
+}
+
+// SessionCheckpoint is a turn the session can be rewound to.
+type SessionCheckpoint struct {
+	TurnID string `json:"turn_id"`
+	// TurnNumber is the 1-based position of the turn among the session's
+	// turns that have not been rewound.
+	TurnNumber   int                           `json:"turn_number"`
+	CreatedAt    time.Time                     `json:"created_at"`
+	Repositories []client.CheckpointRepository `json:"repositories"`
+}
+
+// RewindResult describes a completed rewind.
+type RewindResult struct {
+	SessionID    string `json:"session_id"`
+	TurnID       string `json:"turn_id"`
+	TurnNumber   int    `json:"turn_number"`
+	RewoundTurns int    `json:"rewound_turns"`
+}
+
+// ListSessionCheckpoints returns the turns of a session that have a
+// workspace checkpoint, oldest first. Empty when the session has no running
+// execution to ask.
+func (s *Service) ListSessionCheckpoints(ctx context.Context, sessionID string) ([]SessionCheckpoint, error) {
+	if err := s.authorizeSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	checkpointer, rewinder, err := s.rewindCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	turns, err := liveTurns(ctx, rewinder, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints, err := checkpointer.ListWorkspaceCheckpoints(ctx, sessionID)
+	if errors.Is(err, executor.ErrExecutionNotFound) {
+		return []SessionCheckpoint{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]client.WorkspaceCheckpoint, len(checkpoints))
+	for _, cp := range checkpoints {
+		byID[cp.ID] = cp
+	}
+	out := []SessionCheckpoint{}
+	for i, turn := range turns {
+		cp, ok := byID[turn.ID]
+		if !ok {
+			continue
+		}
+		out = append(out, SessionCheckpoint{
+			TurnID:       turn.ID,
+			TurnNumber:   i + 1,
+			CreatedAt:    cp.CreatedAt,
+			Repositories: cp.Repositories,
+		})
+	}
+	return out, nil
+}
+
+// RewindSession restores the session's workspace to the checkpoint taken
+// before turnID started and marks that turn and every later one as rewound.
+// The agent's own conversation cannot be truncated, so the next prompt tells
+// it to disregard the rewound turns. The session must be idle.
+func (s *Service) RewindSession(ctx context.Context, sessionID, turnID string) (*RewindResult, error) {
+	if err := s.authorizeSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	checkpointer, rewinder, err := s.rewindCapabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	releaseLifecycleLock := s.acquireSessionLifecycleLock(sessionID)
+	defer releaseLifecycleLock()
+	s.setSessionResetInProgress(sessionID, true)
+	defer s.setSessionResetInProgress(sessionID, false)
+
+	session, err := s.repo.GetTaskSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+	if session.State != models.TaskSessionStateWaitingForInput {
+		return nil, fmt.Errorf("agent must be idle to rewind, current state: %s", session.State)
+	}
+	if hasRunning, hasErr := s.repo.HasExecutorRunningRow(ctx, sessionID); hasErr != nil || !hasRunning {
+		return nil, fmt.Errorf("no active agent execution for session %s", sessionID)
+	}
+
+	turns, err := liveTurns(ctx, rewinder, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, turn := range turns {
+		if turn.ID == turnID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("turn %s is not an active turn of session %s", turnID, sessionID)
+	}
+	turnNumber := index + 1
+	discarded := make([]string, 0, len(turns)-index)
+	for _, turn := range turns[index:] {
+		discarded = append(discarded, turn.ID)
+	}
+
+	if _, err := checkpointer.RestoreWorkspaceCheckpoint(ctx, sessionID, turnID, discarded); err != nil {
+		if errors.Is(err, client.ErrCheckpointNotFound) {
+			return nil, fmt.Errorf("%w %d", ErrNoCheckpoint, turnNumber)
+		}
+		return nil, fmt.Errorf("restore workspace: %w", err)
+	}
+	// The files are back; from here on failures only leave the history
+	// unmarked, so they are logged rather than returned.
+	s.finishRewind(ctx, session, rewinder, discarded, turnNumber)
+
+	return &RewindResult{
+		SessionID:    sessionID,
+		TurnID:       turnID,
+		TurnNumber:   turnNumber,
+		RewoundTurns: len(discarded),
+	}, nil
+}
+
+func (s *Service) finishRewind(
+	ctx context.Context,
+	session *models.TaskSession,
+	rewinder TurnRewinder,
+	discarded []string,
+	turnNumber int,
+) {
+	if err := rewinder.MarkTurnsRewound(ctx, session.ID, discarded); err != nil {
+		s.logger.Warn("failed to mark rewound turns",
+			zap.String("session_id", session.ID), zap.Error(err))
+	}
+	// Rewinding again before the next prompt keeps the earliest turn: the
+	// agent must disregard everything from there on.
+	if pending := pendingRewindTurn(session); pending == 0 || turnNumber < pending {
+		if err := s.repo.SetSessionMetadataKey(ctx, session.ID, models.SessionMetaKeyPendingRewind, turnNumber); err != nil {
+			s.logger.Warn("failed to record pending rewind",
+				zap.String("session_id", session.ID), zap.Error(err))
+		}
+	}
+	if s.messageCreator != nil {
+		if err := s.messageCreator.CreateSessionMessage(
+			ctx, session.TaskID,
+			fmt.Sprintf("Rewound workspace and conversation to before turn %d", turnNumber),
+			session.ID, string(v1.MessageTypeStatus),
+			s.getActiveTurnID(session.ID),
+			nil, false,
+		); err != nil {
+			s.logger.Warn("failed to create rewind message",
+				zap.String("session_id", session.ID), zap.Error(err))
+		}
+	}
+}
+
+// injectPendingRewind prepends the rewind notice while a rewind has not yet
+// reached the agent.
+func injectPendingRewind(session *models.TaskSession, prompt string) string {
+	if turn := pendingRewindTurn(session); turn > 0 {
+		return sysprompt.InjectWorkspaceRewind(turn, prompt)
+	}
+	return prompt
+}
+
+// clearPendingRewindOnDispatch wraps a prompt's dispatch callback so the
+// rewind notice is dropped once the prompt carrying it reaches the agent.
+func (s *Service) clearPendingRewindOnDispatch(ctx context.Context, session *models.TaskSession, onDispatched func()) func() {
+	if pendingRewindTurn(session) == 0 {
+		return onDispatched
+	}
+	return func() {
+		onDispatched()
+		if err := s.repo.SetSessionMetadataKey(context.WithoutCancel(ctx), session.ID, models.SessionMetaKeyPendingRewind, nil); err != nil {
+			s.logger.Warn("failed to clear pending rewind",
+				zap.String("session_id", session.ID), zap.Error(err))
+		}
+	}
+}
+
+func pendingRewindTurn(session *models.TaskSession) int {
+	if session == nil {
+		return 0
+	}
+	switch v := session.Metadata[models.SessionMetaKeyPendingRewind].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+func (s *Service) rewindCapabilities() (executor.WorkspaceCheckpointer, TurnRewinder, error) {
+	checkpointer, ok := s.agentManager.(executor.WorkspaceCheckpointer)
+	if !ok {
+		return nil, nil, ErrRewindUnsupported
+	}
+	rewinder, ok := s.turnService.(TurnRewinder)
+	if !ok {
+		return nil, nil, ErrRewindUnsupported
+	}
+	return checkpointer, rewinder, nil
+}
+
+// liveTurns returns the session's turns that have not been rewound, in
+// start order.
+func liveTurns(ctx context.Context, rewinder TurnRewinder, sessionID string) ([]*models.Turn, error) {
+	turns, err := rewinder.ListTurnsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list turns: %w", err)
+	}
+	live := make([]*models.Turn, 0, len(turns))
+	for _, turn := range turns {
+		if rewound, _ := turn.Metadata[models.TurnMetaKeyRewound].(bool); rewound {
+			continue
+		}
+		live = append(live, turn)
+	}
+	return live, nil
+}
@@ -0,0 +1,183 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	client "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+type checkpointingAgentManager struct {
+	*mockAgentManager
+	checkpoints []client.WorkspaceCheckpoint
+	restoredID  string
+	discarded   []string
+}
+
+func (m *checkpointingAgentManager) CreateWorkspaceCheckpoint(
+	_ context.Context,
+	_, checkpointID string,
+) (*client.WorkspaceCheckpoint, error) {
+	cp := client.WorkspaceCheckpoint{ID: checkpointID, CreatedAt: time.Now().UTC()}
+	m.checkpoints = append(m.checkpoints, cp)
+	return &cp, nil
+}
+
+func (m *checkpointingAgentManager) ListWorkspaceCheckpoints(context.Context, string) ([]client.WorkspaceCheckpoint, error) {
+	return m.checkpoints, nil
+}
+
+func (m *checkpointingAgentManager) RestoreWorkspaceCheckpoint(
+	_ context.Context,
+	_, checkpointID string,
+	discard []string,
+) (*client.WorkspaceCheckpoint, error) {
+	for i := range m.checkpoints {
+		if m.checkpoints[i].ID == checkpointID {
+			m.restoredID = checkpointID
+			m.discarded = discard
+			return &m.checkpoints[i], nil
+		}
+	}
+	return nil, client.ErrCheckpointNotFound
+}
+
+type fakeTurnRewinder struct {
+	TurnService
+	turns   []*models.Turn
+	rewound []string
+}
+
+func (r *fakeTurnRewinder) ListTurnsBySession(context.Context, string) ([]*models.Turn, error) {
+	return r.turns, nil
+}
+
+func (r *fakeTurnRewinder) MarkTurnsRewound(_ context.Context, _ string, turnIDs []string) error {
+	r.rewound = append(r.rewound, turnIDs...)
+	for _, turn := range r.turns {
+		for _, id := range turnIDs {
+			if turn.ID == id {
+				turn.Metadata = map[string]interface{}{models.TurnMetaKeyRewound: true}
+			}
+		}
+	}
+	return nil
+}
+
+func setupRewindTest(t *testing.T) (*Service, *checkpointingAgentManager, *fakeTurnRewinder) {
+	t.Helper()
+	ctx := context.Background()
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "t1", "s1", "step1")
+	seedExecutorRunning(t, repo, "s1", "t1", "exec-1")
+	if err := repo.UpdateTaskSessionState(ctx, "s1", models.TaskSessionStateWaitingForInput, ""); err != nil {
+		t.Fatalf("set session idle: %v", err)
+	}
+
+	now := time.Now().UTC()
+	agentManager := &checkpointingAgentManager{
+		mockAgentManager: &mockAgentManager{},
+		checkpoints: []client.WorkspaceCheckpoint{
+			{ID: "turn-1", CreatedAt: now},
+			{ID: "turn-2", CreatedAt: now.Add(time.Minute)},
+			{ID: "turn-3", CreatedAt: now.Add(2 * time.Minute)},
+		},
+	}
+	rewinder := &fakeTurnRewinder{turns: []*models.Turn{
+		{ID: "turn-1", TaskSessionID: "s1", StartedAt: now},
+		{ID: "turn-2", TaskSessionID: "s1", StartedAt: now.Add(time.Minute)},
+		{ID: "turn-3", TaskSessionID: "s1", StartedAt: now.Add(2 * time.Minute)},
+	}}
+	svc := createTestServiceWithAgent(repo, newMockStepGetter(), newMockTaskRepo(), agentManager)
+	svc.turnService = rewinder
+	return svc, agentManager, rewinder
+}
+
+func TestRewindSession_RestoresCheckpointAndMarksLaterTurns(t *testing.T) {
+	ctx := context.Background()
+	svc, agentManager, rewinder := setupRewindTest(t)
+
+	result, err := svc.RewindSession(ctx, "s1", "turn-2")
+	if err != nil {
+		t.Fatalf("rewind: %v", err)
+	}
+	if result.TurnNumber != 2 || result.RewoundTurns != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if agentManager.restoredID != "turn-2" {
+		t.Fatalf("restored %q, want turn-2", agentManager.restoredID)
+	}
+	if len(rewinder.rewound) != 2 || rewinder.rewound[0] != "turn-2" || rewinder.rewound[1] != "turn-3" {
+		t.Fatalf("rewound turns = %v, want [turn-2 turn-3]", rewinder.rewound)
+	}
+
+	session, err := svc.repo.GetTaskSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	if got := pendingRewindTurn(session); got != 2 {
+		t.Fatalf("pending rewind turn = %d, want 2", got)
+	}
+	if prompt := injectPendingRewind(session, "next"); prompt == "next" {
+		t.Fatal("expected the next prompt to carry the rewind notice")
+	}
+
+	// Rewound turns drop out of the list; only turn 1 remains.
+	checkpoints, err := svc.ListSessionCheckpoints(ctx, "s1")
+	if err != nil {
+		t.Fatalf("list checkpoints: %v", err)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].TurnID != "turn-1" || checkpoints[0].TurnNumber != 1 {
+		t.Fatalf("unexpected checkpoints after rewind: %+v", checkpoints)
+	}
+}
+
+func TestRewindSession_KeepsEarliestPendingTurn(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := setupRewindTest(t)
+
+	if _, err := svc.RewindSession(ctx, "s1", "turn-2"); err != nil {
+		t.Fatalf("first rewind: %v", err)
+	}
+	if _, err := svc.RewindSession(ctx, "s1", "turn-1"); err != nil {
+		t.Fatalf("second rewind: %v", err)
+	}
+	session, err := svc.repo.GetTaskSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("load session: %v", err)
+	}
+	if got := pendingRewindTurn(session); got != 1 {
+		t.Fatalf("pending rewind turn = %d, want 1", got)
+	}
+}
+
+func TestRewindSession_RequiresIdleSession(t *testing.T) {
+	ctx := context.Background()
+	svc, agentManager, _ := setupRewindTest(t)
+	if err := svc.repo.UpdateTaskSessionState(ctx, "s1", models.TaskSessionStateRunning, ""); err != nil {
+		t.Fatalf("set session running: %v", err)
+	}
+	if _, err := svc.RewindSession(ctx, "s1", "turn-2"); err == nil {
+		t.Fatal("expected rewind of a running session to fail")
+	}
+	if agentManager.restoredID != "" {
+		t.Fatal("workspace must not be restored while the agent is running")
+	}
+}
+
+func TestRewindSession_MissingCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	svc, agentManager, rewinder := setupRewindTest(t)
+	agentManager.checkpoints = agentManager.checkpoints[:1]
+
+	_, err := svc.RewindSession(ctx, "s1", "turn-2")
+	if !errors.Is(err, ErrNoCheckpoint) {
+		t.Fatalf("err = %v, want ErrNoCheckpoint", err)
+	}
+	if len(rewinder.rewound) != 0 {
+		t.Fatalf("no turns should be marked when the restore fails, got %v", rewinder.rewound)
+	}
+}
@@ -852,6 +852,12 @@ type startTaskOptions struct {
 	// SpawnOrigin is set when another agent session spawned this launch; it
 	// produces the spawner-attribution system block on the first turn.
 	SpawnOrigin *SpawnOrigin
+	// ForkOrigin is set when the launch continues a forked conversation; it
+	// produces the conversation system block on the first turn.
+	ForkOrigin *ForkOrigin
+	// Admitted marks a launch resumed by admission control, which must not
+	// be deferred a second time.
+	Admitted bool
 }
 
 // StartTaskWithRoute launches a stable Office identity through a complete
@@ -931,6 +937,16 @@ func (s *Service) startTask(ctx context.Context, taskID string, agentProfileID s
 			return nil, nil
 		}
 	}
+	// Resource admission control holds the launch back, before any task or
+	// session state changes, while the target machine is over its limits. A
+	// nil execution tells callers nothing was started, as for the terminal-PR
+	// gate above; the task card shows the reason via MetaKeyAdmissionWait.
+	if !isOfficeTask && !opts.Admitted {
+		resume := s.resumeStartTask(taskID, agentProfileID, executorID, executorProfileID, priority, prompt, workflowStepID, planMode, autoStart, attachments, opts)
+		if !s.admitLaunch(ctx, taskID, executorID, executorProfileID, resume) {
+			return nil, nil
+		}
+	}
 	workflowSessionConfigStepID := workflowStepID
 	// Manual starts often omit workflow_step_id because the task is already
 	// bound to its current step. Resolve that canonical step before profile
@@ -999,6 +1015,10 @@ func (s *Service) startTask(ctx context.Context, taskID string, agentProfileID s
 			zap.Error(err))
 		return nil, err
 	}
+	if _, waiting := task.Metadata[models.MetaKeyAdmissionWait]; waiting {
+		// Left behind by a deferred launch lost to a backend restart.
+		s.clearAdmissionWait(ctx, taskID)
+	}
 	launchErrorStamp := ""
 	if launchError, found := models.LoadTaskLaunchError(task.Metadata); found {
 		launchErrorStamp = launchError.Stamp()
@@ -1032,6 +1052,11 @@ func (s *Service) startTask(ctx context.Context, taskID string, agentProfileID s
 	if err != nil {
 		return nil, err
 	}
+	if opts.ForkOrigin != nil && opts.ForkOrigin.TaskID == task.ID {
+		if err := s.scopeSameTaskFork(ctx, sessionID, opts.ForkOrigin); err != nil {
+			return nil, err
+		}
+	}
 	// Seed a matching conditional session configuration before lifecycle
 	// startup. The ACP manager applies this durable runtime layer after the
 	// selected profile and before the first prompt, preserving the original
@@ -1127,6 +1152,7 @@ func (s *Service) startTask(ctx context.Context, taskID string, agentProfileID s
 			autopilot:                 task.Autopilot,
 			includeParentQuestionTool: task.Autopilot && task.ParentID != "",
 			spawnOrigin:               opts.SpawnOrigin,
+			forkOrigin:                opts.ForkOrigin,
 		})
 	}
 
@@ -1184,7 +1210,7 @@ func (s *Service) applyWorkflowSessionConfigBeforeLaunchForStep(
 	if s.workflowStepGetter == nil || workflowStepID == "" {
 		return
 	}
-	workflowStep, err := s.workflowStepGetter.GetStep(ctx, workflowStepID)
+	workflowStep, err := s.getTaskStep(ctx, taskID, workflowStepID)
 	if err != nil {
 		s.logger.Warn("failed to load workflow step for session configuration",
 			zap.String("task_id", taskID), zap.String("step_id", workflowStepID), zap.Error(err))
@@ -1199,6 +1225,7 @@ func (s *Service) applyWorkflowSessionConfigBeforeLaunchForStep(
 			zap.String("task_id", taskID), zap.String("session_id", sessionID), zap.Error(err))
 		return
 	}
+	s.persistStepPermissionPolicy(ctx, preparedSession, workflowStep) // read back by the executor at launch
 	s.applyWorkflowSessionConfigBeforeLaunch(ctx, taskID, preparedSession, workflowStep)
 }
 
@@ -1216,17 +1243,22 @@ type launchPromptContext struct {
 	includeParentQuestionTool bool
 	referenceContext          string
 	spawnOrigin               *SpawnOrigin
+	forkOrigin                *ForkOrigin
 }
 
 // applyLaunchPromptContext prepends the first-turn system context to a launch
 // prompt: the Kandev (or Office) MCP block, plus spawner attribution when the
-// launch came from spawn_session_kandev.
+// launch came from spawn_session_kandev and the forked conversation when it
+// continues another session.
 //
-// Passthrough profiles get attribution only, as plain text — see
-// applySpawnOriginText for why they skip the MCP block entirely.
+// Passthrough profiles get attribution and conversation only, as plain text —
+// see applySpawnOriginText for why they skip the MCP block entirely.
 func (s *Service) applyLaunchPromptContext(ctx context.Context, p launchPromptContext) string {
 	if p.isPassthrough {
 		prompt := applySpawnOriginText(p.prompt, p.spawnOrigin)
+		if fork := forkOriginContent(p.forkOrigin); fork != "" {
+			prompt = fork + "\n\n" + prompt
+		}
 		if p.includeTaskTitleTool {
 			return sysprompt.PendingTaskTitlePassthroughInstruction() + "\n\n" + prompt
 		}
@@ -1237,9 +1269,13 @@ func (s *Service) applyLaunchPromptContext(ctx context.Context, p launchPromptCo
 	// not recognize, so the block has to be generated from the same server state
 	// that whitelists it as trusted content.
 	prompt, spawnContext := applySpawnOriginContext(p.prompt, p.spawnOrigin)
+	forkContext := forkOriginContent(p.forkOrigin)
+	if forkContext != "" {
+		prompt = sysprompt.Wrap(forkContext) + "\n\n" + prompt
+	}
 	if p.isOfficeTask {
 		return sysprompt.InjectOfficeContext(
-			p.taskID, p.sessionID, prompt, p.referenceContext, spawnContext,
+			p.taskID, p.sessionID, prompt, p.referenceContext, spawnContext, forkContext,
 		)
 	}
 	return sysprompt.InjectKandevContextWithOptions(p.taskID, p.sessionID, prompt, sysprompt.KandevContextOptions{
@@ -1249,7 +1285,7 @@ func (s *Service) applyLaunchPromptContext(ctx context.Context, p launchPromptCo
 		Autopilot:                      p.autopilot,
 		IncludeUserQuestionTool:        !p.autopilot && !p.isPassthrough,
 		IncludeParentQuestionTool:      p.autopilot && p.includeParentQuestionTool,
-	}, p.referenceContext, spawnContext)
+	}, p.referenceContext, spawnContext, forkContext)
 }
 
 // spawnOriginContent renders the spawner-attribution text for a launch requested
@@ -1621,7 +1657,7 @@ func (s *Service) resolveEffectiveAgentProfile(ctx context.Context, taskID, work
 		effectiveStepID = dbTask.WorkflowStepID
 	}
 
-	step, err := s.workflowStepGetter.GetStep(ctx, effectiveStepID)
+	step, err := s.getTaskStep(ctx, taskID, effectiveStepID)
 	if err != nil || step == nil {
 		s.logger.Debug("resolveEffectiveAgentProfile: failed to load step",
 			zap.String("task_id", taskID),
@@ -1692,7 +1728,7 @@ func (s *Service) applyWorkflowAndPlanMode(ctx context.Context, prompt string, t
 	stepHasPlanMode := false
 	// Skip workflow step prompt injection for ephemeral tasks - they don't have workflows
 	if !isEphemeral && workflowStepID != "" && s.workflowStepGetter != nil {
-		step, err := s.workflowStepGetter.GetStep(ctx, workflowStepID)
+		step, err := s.getTaskStep(ctx, taskID, workflowStepID)
 		if err != nil {
 			s.logger.Warn("failed to get workflow step for prompt building",
 				zap.String("workflow_step_id", workflowStepID),
@@ -2103,7 +2139,7 @@ func (s *Service) StartSessionForWorkflowStep(ctx context.Context, taskID, sessi
 		return fmt.Errorf("workflow step getter not configured")
 	}
 
-	step, err := s.workflowStepGetter.GetStep(ctx, workflowStepID)
+	step, err := s.getTaskStep(ctx, taskID, workflowStepID)
 	if err != nil {
 		return fmt.Errorf("failed to get workflow step: %w", err)
 	}
@@ -4004,6 +4040,7 @@ func (s *Service) promptTask(ctx context.Context, taskID, sessionID string, prom
 	onDispatched := s.promptDispatchCallback(
 		promptCtx, taskID, sessionID, rollback.reservedTurn, foregroundDispatch, dispatchOutcome,
 	)
+	onDispatched = s.clearPendingRewindOnDispatch(promptCtx, session, onDispatched)
 	result, err := s.executor.PromptWithDispatchCallback(
 		promptCtx, taskID, sessionID, effectivePrompt, attachments, dispatchOnly,
 		onDispatched, session,
@@ -4489,8 +4526,8 @@ func (s *Service) handlePromptDispatchFailure(
 	return nil, s.handlePromptError(ctx, taskID, sessionID, rollback.previousSessionState, promptErr)
 }
 
-// effectivePromptForSession applies promptTask's config-mode and plan-mode
-// prompt transforms to the raw prompt, in that order, using session's
+// effectivePromptForSession applies promptTask's config-mode, plan-mode and
+// pending-rewind prompt transforms to the raw prompt, in that order, using session's
 // *current* Metadata. Each call always starts from the raw prompt argument
 // (never a previously-transformed result), so it is safe for promptTask to
 // call this twice — once on session load and again after a reload that
@@ -4504,7 +4541,7 @@ func (s *Service) effectivePromptForSession(sessionID, prompt string, planMode b
 	if planMode {
 		effectivePrompt = sysprompt.InjectPlanMode(effectivePrompt)
 	}
-	return effectivePrompt
+	return injectPendingRewind(session, effectivePrompt)
 }
 
 var (
@@ -6138,7 +6175,7 @@ func (s *Service) reconcileCancelledAgentWorkflow(ctx context.Context, session *
 func (s *Service) reconcileCancelledTaskReview(ctx context.Context, taskID, sessionID string) {
 	task, err := s.repo.GetTask(ctx, taskID)
 	if err == nil && task != nil && task.WorkflowStepID != "" && s.workflowStepGetter != nil {
-		step, stepErr := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+		step, stepErr := s.getTaskStep(ctx, taskID, task.WorkflowStepID)
 		if stepErr == nil && step != nil && s.workflowStepIsTerminal(ctx, step.ID) {
 			return
 		}
@@ -12,6 +12,7 @@ import (
 	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
 	"github.com/kandev/kandev/internal/agentctl/types/streams"
 	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/egress"
 	"github.com/kandev/kandev/internal/events"
 	"github.com/kandev/kandev/internal/events/bus"
 	v1 "github.com/kandev/kandev/pkg/api/v1"
@@ -71,6 +72,9 @@ type PermissionRequestData struct {
 	Options       []map[string]interface{} `json:"options"`
 	ActionType    string                   `json:"action_type"`
 	ActionDetails map[string]interface{}   `json:"action_details"`
+	// PermissionPolicy is set when agentctl's permission policy already
+	// answered the request.
+	PermissionPolicy *streams.PermissionPolicyDecision `json:"permission_policy,omitempty"`
 }
 
 // GitEventData is an alias for lifecycle.GitEventPayload.
@@ -135,6 +139,9 @@ type EventHandlers struct {
 
 	// Context window events
 	OnContextWindowUpdated func(ctx context.Context, data ContextWindowData)
+
+	// Egress proxy denials for executors with a network policy
+	OnEgressDenied func(ctx context.Context, denial egress.Denial)
 }
 
 // Watcher subscribes to events and dispatches to handlers
@@ -188,6 +195,10 @@ func (w *Watcher) Start(ctx context.Context) error {
 		w.unsubscribeAll()
 		return err
 	}
+	if err := w.subscribeToEgressDeniedEvents(); err != nil {
+		w.unsubscribeAll()
+		return err
+	}
 
 	// Subscribe to ACP session events
 	if err := w.subscribeToACPSessionEvents(); err != nil {
@@ -468,6 +479,37 @@ func (w *Watcher) createAgentEventHandler(handler func(ctx context.Context, data
 	}
 }
 
+func (w *Watcher) subscribeToEgressDeniedEvents() error {
+	if w.handlers.OnEgressDenied == nil {
+		return nil
+	}
+	sub, err := w.eventBus.QueueSubscribe(
+		events.ExecutorEgressDenied,
+		w.queue,
+		w.createEgressDeniedEventHandler(w.handlers.OnEgressDenied),
+	)
+	if err != nil {
+		w.logger.Error("failed to subscribe to egress denied event", zap.Error(err))
+		return err
+	}
+	w.subscriptions = append(w.subscriptions, sub)
+	return nil
+}
+
+func (w *Watcher) createEgressDeniedEventHandler(
+	handler func(ctx context.Context, denial egress.Denial),
+) bus.EventHandler {
+	return func(ctx context.Context, event *bus.Event) error {
+		var denial egress.Denial
+		if err := w.parseEventData(event.Data, &denial); err != nil {
+			w.logger.Error("failed to parse egress denied event", zap.Error(err))
+			return nil
+		}
+		handler(ctx, denial)
+		return nil
+	}
+}
+
 func (w *Watcher) createAgentStalledEventHandler(
 	handler func(ctx context.Context, payload lifecycle.AgentStalledPayload),
 ) bus.EventHandler {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
 	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/egress"
 	"github.com/kandev/kandev/internal/events"
 	"github.com/kandev/kandev/internal/events/bus"
 	v1 "github.com/kandev/kandev/pkg/api/v1"
@@ -345,6 +346,49 @@ func TestWatcherDispatchesAgentStalled(t *testing.T) {
 	})
 }
 
+func TestWatcherDispatchesEgressDenied(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		eventBus := newMockEventBus()
+		var received egress.Denial
+		var handled bool
+		var mu sync.Mutex
+		w := NewWatcher(eventBus, EventHandlers{
+			OnEgressDenied: func(_ context.Context, denial egress.Denial) {
+				mu.Lock()
+				received = denial
+				handled = true
+				mu.Unlock()
+			},
+		}, "orchestrator-test", createTestLogger())
+		if err := w.Start(context.Background()); err != nil {
+			t.Fatalf("start watcher: %v", err)
+		}
+		t.Cleanup(func() { _ = w.Stop() })
+
+		event := bus.NewEvent(events.ExecutorEgressDenied, "test", egress.Denial{
+			TaskID:    "task-123",
+			SessionID: "session-789",
+			Mode:      egress.ModeAllowlist,
+			Method:    "CONNECT",
+			Host:      "pastebin.com",
+			Port:      "443",
+		})
+		if err := eventBus.Publish(context.Background(), events.ExecutorEgressDenied, event); err != nil {
+			t.Fatalf("publish egress denied event: %v", err)
+		}
+		synctest.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !handled {
+			t.Fatal("OnEgressDenied handler was not called")
+		}
+		if received.SessionID != "session-789" || received.Host != "pastebin.com" || received.Mode != egress.ModeAllowlist {
+			t.Fatalf("received denial = %#v", received)
+		}
+	})
+}
+
 func TestAgentStreamEventHandling(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		eventBus := newMockEventBus()
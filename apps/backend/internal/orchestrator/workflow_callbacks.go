@@ -60,6 +60,10 @@ func buildWorkflowCallbacks(svc *Service) engine.MapRegistry {
 	}
 	if svc.engineTaskCreator != nil {
 		r[engine.ActionCreateChildTask] = engine.CreateChildTaskCallback{Creator: svc.engineTaskCreator}
+		r[engine.ActionFanOut] = engine.FanOutCallback{Launcher: svc}
+	}
+	if svc.engineNotifier != nil {
+		r[engine.ActionNotify] = engine.NotifyCallback{Notifier: svc.engineNotifier}
 	}
 	if svc.engineWorkflowSwitcher != nil {
 		r[engine.ActionSwitchWorkflow] = engine.SwitchWorkflowCallback{
@@ -144,7 +148,7 @@ func (s *Service) prepareDirectWorkflowStepEntry(
 	if s.workflowStepGetter == nil || task.WorkflowStepID == "" {
 		return "", nil, fmt.Errorf("workflow step is not available for task %s", taskID)
 	}
-	step, err := s.workflowStepGetter.GetStep(ctx, task.WorkflowStepID)
+	step, err := s.getTaskStep(ctx, task.ID, task.WorkflowStepID)
 	if err != nil {
 		return "", nil, fmt.Errorf("load destination workflow step: %w", err)
 	}
@@ -0,0 +1,255 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
+	agentctltypes "github.com/kandev/kandev/internal/agentctl/types"
+	"github.com/kandev/kandev/internal/orchestrator/messagequeue"
+	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+// WorkflowCheckProcessRunner is the slice of *lifecycle.Manager the run_check
+// gate needs: start a process in the session's agentctl workspace, poll it,
+// and stop it on timeout.
+type WorkflowCheckProcessRunner interface {
+	StartProcess(ctx context.Context, req lifecycle.StartProcessRequest) (*agentctl.ProcessInfo, error)
+	GetProcess(ctx context.Context, processID string, includeOutput bool) (*agentctl.ProcessInfo, error)
+	StopProcessForSession(ctx context.Context, sessionID, processID string) error
+}
+
+// checkPollInterval is how often a running check process is polled.
+const checkPollInterval = 250 * time.Millisecond
+
+// WorkflowCheckRunner implements engine.CheckRunner on top of agentctl's
+// process API, so checks run in the same workspace (worktree, container,
+// remote sandbox) the agent edits.
+type WorkflowCheckRunner struct {
+	processes    WorkflowCheckProcessRunner
+	pollInterval time.Duration
+}
+
+var _ engine.CheckRunner = (*WorkflowCheckRunner)(nil)
+
+// NewWorkflowCheckRunner creates an agentctl-backed run_check runner.
+func NewWorkflowCheckRunner(processes WorkflowCheckProcessRunner) *WorkflowCheckRunner {
+	return &WorkflowCheckRunner{processes: processes, pollInterval: checkPollInterval}
+}
+
+// RunCheck starts the command and blocks until it exits or req.Timeout
+// elapses. A timed-out process is stopped best-effort.
+func (r *WorkflowCheckRunner) RunCheck(ctx context.Context, req engine.CheckRequest) (engine.CheckResult, error) {
+	if req.SessionID == "" {
+		return engine.CheckResult{}, errors.New("run_check requires a session")
+	}
+	started := time.Now()
+	process, err := r.processes.StartProcess(ctx, lifecycle.StartProcessRequest{
+		SessionID:  req.SessionID,
+		Kind:       string(agentctltypes.ProcessKindCustom),
+		ScriptName: "run_check",
+		Command:    req.Command,
+	})
+	if err != nil {
+		return engine.CheckResult{}, err
+	}
+	if process == nil {
+		return engine.CheckResult{}, errors.New("agentctl returned no process")
+	}
+
+	deadline := time.NewTimer(req.Timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		if res, done := checkProcessResult(process); done {
+			res.Duration = time.Since(started)
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			r.stop(req.SessionID, process.ID)
+			return engine.CheckResult{}, context.Cause(ctx)
+		case <-deadline.C:
+			r.stop(req.SessionID, process.ID)
+			return engine.CheckResult{
+				TimedOut: true,
+				Output:   processOutput(process),
+				Duration: time.Since(started),
+			}, nil
+		case <-ticker.C:
+			process, err = r.processes.GetProcess(ctx, process.ID, true)
+			if err != nil {
+				return engine.CheckResult{}, err
+			}
+		}
+	}
+}
+
+func (r *WorkflowCheckRunner) stop(sessionID, processID string) {
+	stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = r.processes.StopProcessForSession(stopCtx, sessionID, processID)
+}
+
+func checkProcessResult(process *agentctl.ProcessInfo) (engine.CheckResult, bool) {
+	switch process.Status {
+	case agentctltypes.ProcessStatusExited, agentctltypes.ProcessStatusFailed, agentctltypes.ProcessStatusStopped:
+	default:
+		return engine.CheckResult{}, false
+	}
+	exitCode := -1
+	if process.ExitCode != nil {
+		exitCode = *process.ExitCode
+	}
+	if process.Status != agentctltypes.ProcessStatusExited && exitCode == 0 {
+		exitCode = -1
+	}
+	return engine.CheckResult{ExitCode: exitCode, Output: processOutput(process)}, true
+}
+
+func processOutput(process *agentctl.ProcessInfo) string {
+	var b strings.Builder
+	for _, chunk := range process.Output {
+		b.WriteString(chunk.Data)
+	}
+	return b.String()
+}
+
+// stepCheckResultRecorder is satisfied by the sqlite task repository.
+type stepCheckResultRecorder interface {
+	RecordStepCheckResult(ctx context.Context, res *models.StepCheckResult) error
+}
+
+// workflowCheckRecorder adapts the task repository to engine.CheckRecorder.
+type workflowCheckRecorder struct {
+	repo stepCheckResultRecorder
+}
+
+func (r workflowCheckRecorder) RecordCheckResult(ctx context.Context, rec engine.CheckRecord) error {
+	return r.repo.RecordStepCheckResult(ctx, &models.StepCheckResult{
+		TaskID:       rec.TaskID,
+		SessionID:    rec.SessionID,
+		StepID:       rec.StepID,
+		Trigger:      rec.Trigger,
+		Command:      rec.Command,
+		Passed:       rec.Passed,
+		ExitCode:     rec.ExitCode,
+		TimedOut:     rec.TimedOut,
+		Output:       rec.Output,
+		Error:        rec.Error,
+		DurationMs:   rec.Duration.Milliseconds(),
+		TargetStepID: rec.TargetStepID,
+		OccurredAt:   rec.OccurredAt,
+	})
+}
+
+// SetWorkflowCheckRunner wires the run_check quality gate. Results are
+// persisted through the task repository when it supports it.
+func (s *Service) SetWorkflowCheckRunner(runner engine.CheckRunner) {
+	s.checkRunner = runner
+	s.engineOptions = append(s.engineOptions, engine.WithCheckRunner(runner))
+	if recorder, ok := s.repo.(stepCheckResultRecorder); ok {
+		s.engineOptions = append(s.engineOptions, engine.WithCheckRecorder(workflowCheckRecorder{repo: recorder}))
+	}
+	s.reinitWorkflowEngine()
+}
+
+// errCheckRunnerNotWired fails a pending gate closed when no runner is set.
+var errCheckRunnerNotWired = errors.New("run_check runner is not configured")
+
+// isPendingCheck reports whether an evaluate-only trigger stopped at a
+// run_check gate it did not run.
+func isPendingCheck(result engine.HandleResult) bool {
+	return result.Check != nil && result.Check.Pending
+}
+
+// completePendingCheck runs the gate an evaluate-only trigger left pending
+// and finishes the trigger with its result. The returned result has no
+// Check when the task left the gate's step while the command ran.
+func (s *Service) completePendingCheck(ctx context.Context, in engine.HandleInput, pending engine.CheckOutcome) (engine.HandleResult, error) {
+	started := time.Now()
+	res, runErr := engine.CheckResult{}, errCheckRunnerNotWired
+	if s.checkRunner != nil {
+		res, runErr = s.checkRunner.RunCheck(ctx, pending.Request(in.TaskID, in.SessionID))
+	}
+	return s.workflowEngine.CompletePendingCheck(ctx, in, pending, started, res, runErr)
+}
+
+// runPendingCheck is completePendingCheck on its own goroutine, so a gate
+// running a test suite does not hold up the event handler that evaluated
+// the trigger. resume continues the trigger with the finished result.
+// When the gate decides nothing, because completing it failed, the task
+// moved on while the command ran or another delivery already handled the
+// trigger, abandon runs instead, if set. Completion holds the operation
+// lock, so a redelivered trigger whose gate finishes second finds the
+// operation applied.
+func (s *Service) runPendingCheck(
+	ctx context.Context,
+	in engine.HandleInput,
+	pending engine.CheckOutcome,
+	resume func(ctx context.Context, result engine.HandleResult),
+	abandon func(ctx context.Context),
+) {
+	ctx = context.WithoutCancel(ctx)
+	// The state the trigger was evaluated against is stale once the
+	// command finishes; the engine reloads it.
+	in.PreloadedState = nil
+	go func() {
+		if in.OperationID != "" {
+			unlock := s.lockChildCompletionOperation(in.OperationID)
+			defer unlock()
+		}
+		result, err := s.completePendingCheck(ctx, in, pending)
+		switch {
+		case err != nil:
+			s.logger.Warn("failed to complete run_check gate",
+				zap.String("task_id", in.TaskID),
+				zap.String("session_id", in.SessionID),
+				zap.String("step_id", pending.StepID),
+				zap.Error(err))
+		case result.Check == nil:
+			s.logger.Debug("run_check gate result discarded: the task moved on or the trigger was already handled",
+				zap.String("task_id", in.TaskID),
+				zap.String("step_id", pending.StepID))
+		default:
+			resume(ctx, result)
+			return
+		}
+		if abandon != nil {
+			abandon(ctx)
+		}
+	}()
+}
+
+// queueCheckFeedback hands a failed gate's output to the agent as its next
+// prompt. The message goes through the session queue: when the gate kept
+// the task in place the ready-handler drains it, and when the gate routed
+// to a fail step the auto-start prompt merges it (takeAndMergeHandoffMessage).
+func (s *Service) queueCheckFeedback(ctx context.Context, taskID, sessionID string, check *engine.CheckOutcome) {
+	if check == nil || check.Passed || s.messageQueue == nil {
+		return
+	}
+	meta := map[string]interface{}{
+		"workflow_check": map[string]interface{}{
+			"step_id":   check.StepID,
+			"command":   check.Command,
+			"exit_code": check.ExitCode,
+			"timed_out": check.TimedOut,
+		},
+	}
+	if _, err := s.messageQueue.QueueMessageWithMetadata(
+		ctx, sessionID, taskID, check.FeedbackPrompt(), "", messagequeue.QueuedByWorkflow, false, nil, meta,
+	); err != nil {
+		s.logger.Warn("failed to queue run_check feedback",
+			zap.String("task_id", taskID),
+			zap.String("session_id", sessionID),
+			zap.Error(err))
+	}
+}
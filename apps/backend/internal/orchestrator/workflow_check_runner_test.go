@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	agentctl "github.com/kandev/kandev/internal/agent/runtime/agentctl"
+	"github.com/kandev/kandev/internal/agent/runtime/lifecycle"
+	agentctltypes "github.com/kandev/kandev/internal/agentctl/types"
+	"github.com/kandev/kandev/internal/workflow/engine"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+type fakeCheckProcesses struct {
+	started  []lifecycle.StartProcessRequest
+	polls    []*agentctl.ProcessInfo
+	stopped  []string
+	pollSeen int
+}
+
+func (f *fakeCheckProcesses) StartProcess(_ context.Context, req lifecycle.StartProcessRequest) (*agentctl.ProcessInfo, error) {
+	f.started = append(f.started, req)
+	return &agentctl.ProcessInfo{ID: "proc-1", Status: agentctltypes.ProcessStatusRunning}, nil
+}
+
+func (f *fakeCheckProcesses) GetProcess(_ context.Context, _ string, _ bool) (*agentctl.ProcessInfo, error) {
+	if f.pollSeen >= len(f.polls) {
+		return &agentctl.ProcessInfo{ID: "proc-1", Status: agentctltypes.ProcessStatusRunning}, nil
+	}
+	p := f.polls[f.pollSeen]
+	f.pollSeen++
+	return p, nil
+}
+
+func (f *fakeCheckProcesses) StopProcessForSession(_ context.Context, _, processID string) error {
+	f.stopped = append(f.stopped, processID)
+	return nil
+}
+
+func TestWorkflowCheckRunner_ReportsExitCodeAndOutput(t *testing.T) {
+	exit := 3
+	processes := &fakeCheckProcesses{polls: []*agentctl.ProcessInfo{{
+		ID: "proc-1", Status: agentctltypes.ProcessStatusExited, ExitCode: &exit,
+		Output: []agentctl.ProcessOutputChunk{{Data: "FAIL pkg\n"}, {Data: "exit 3\n"}},
+	}}}
+	runner := NewWorkflowCheckRunner(processes)
+	runner.pollInterval = time.Millisecond
+
+	res, err := runner.RunCheck(context.Background(), engine.CheckRequest{
+		SessionID: "s1", Command: "go test ./...", Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ExitCode != 3 || res.TimedOut || res.Output != "FAIL pkg\nexit 3\n" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if len(processes.started) != 1 || processes.started[0].Command != "go test ./..." || processes.started[0].SessionID != "s1" {
+		t.Fatalf("unexpected start requests: %+v", processes.started)
+	}
+}
+
+func TestWorkflowCheckRunner_TimeoutStopsProcess(t *testing.T) {
+	processes := &fakeCheckProcesses{}
+	runner := NewWorkflowCheckRunner(processes)
+	runner.pollInterval = time.Millisecond
+
+	res, err := runner.RunCheck(context.Background(), engine.CheckRequest{
+		SessionID: "s1", Command: "sleep 600", Timeout: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.TimedOut {
+		t.Fatalf("expected timeout, got %+v", res)
+	}
+	if len(processes.stopped) != 1 || processes.stopped[0] != "proc-1" {
+		t.Fatalf("expected timed-out process to be stopped, got %v", processes.stopped)
+	}
+}
+
+func TestWorkflowCheckRunner_FailedStatusNeverPasses(t *testing.T) {
+	zero := 0
+	res, done := checkProcessResult(&agentctl.ProcessInfo{Status: agentctltypes.ProcessStatusFailed, ExitCode: &zero})
+	if !done || res.ExitCode == 0 {
+		t.Fatalf("failed process must not report exit 0, got %+v", res)
+	}
+}
+
+func TestRunPendingCheck_AbandonsGateThatNoLongerApplies(t *testing.T) {
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "t1", "s1", "step2")
+	stepGetter := newMockStepGetter()
+	// The step lost its run_check while the command ran.
+	stepGetter.steps["step2"] = &wfmodels.WorkflowStep{ID: "step2", WorkflowID: "wf1"}
+	svc := createTestService(repo, stepGetter, newMockTaskRepo())
+	svc.initWorkflowEngine()
+
+	abandoned := make(chan struct{})
+	in := engine.HandleInput{TaskID: "t1", SessionID: "s1", Trigger: engine.TriggerOnTurnComplete, EvaluateOnly: true}
+	pending := engine.CheckOutcome{StepID: "step2", Command: "make test", Pending: true}
+	svc.runPendingCheck(context.Background(), in, pending, func(context.Context, engine.HandleResult) {
+		t.Error("a gate that no longer applies must not resume the trigger")
+	}, func(context.Context) { close(abandoned) })
+
+	select {
+	case <-abandoned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the abandoned gate to hand the trigger back")
+	}
+}
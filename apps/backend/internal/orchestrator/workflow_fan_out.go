@@ -0,0 +1,574 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/workflow/engine"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+// ErrInvalidFanOutSelection is returned when a winner is picked for a
+// fan-out that is not waiting for one, or the pick is not a succeeded
+// branch of it.
+var ErrInvalidFanOutSelection = errors.New("invalid fan-out selection")
+
+const (
+	fanOutSelectedByAuto  = "auto"
+	fanOutSelectedByJudge = "judge"
+	fanOutSelectedByUser  = "user"
+)
+
+var _ engine.FanOutLauncher = (*Service)(nil)
+
+// LaunchFanOut satisfies engine.FanOutLauncher. It creates one child task
+// per agent profile, records the FanOutGroup on the parent session, then
+// starts every branch.
+//
+// The group is persisted before any branch starts: branches usually run in
+// the parent's workflow, and the recursion guard below relies on the group
+// to recognise a branch entering the same fan_out step.
+func (s *Service) LaunchFanOut(ctx context.Context, req engine.FanOutRequest) error {
+	if s.engineTaskCreator == nil || s.workflowStore == nil {
+		return errors.New("fan-out requires a task creator and workflow store")
+	}
+	unlock := s.lockChildCompletionOperation(fanOutLockKey(req.TaskID))
+	defer unlock()
+
+	parent, err := s.repo.GetTask(ctx, req.TaskID)
+	if err != nil {
+		return fmt.Errorf("load task %s: %w", req.TaskID, err)
+	}
+	if s.isFanOutMember(ctx, parent) {
+		s.logger.Info("fan_out: skipping nested fan-out for a branch or judge task",
+			zap.String("task_id", parent.ID),
+			zap.String("parent_task_id", parent.ParentID))
+		return nil
+	}
+	session, err := s.repo.GetTaskSession(ctx, req.SessionID)
+	if err != nil {
+		return fmt.Errorf("load session %s: %w", req.SessionID, err)
+	}
+	if existing, ok := fanOutGroupForSession(session); ok && existing.StepID == req.StepID && !existing.Status.Settled() {
+		return nil
+	}
+
+	group := &engine.FanOutGroup{
+		StepID:              req.StepID,
+		Join:                req.Join,
+		JudgeAgentProfileID: req.JudgeAgentProfileID,
+		BranchWorkflowID:    req.BranchWorkflowID,
+		BranchStepID:        req.BranchStepID,
+		Status:              engine.FanOutStatusRunning,
+	}
+	var createErr error
+	for i, profileID := range req.AgentProfileIDs {
+		childID, err := s.engineTaskCreator.CreateChildTask(ctx, parent.ID, engine.ChildTaskSpec{
+			Title:          fmt.Sprintf("%s [%d/%d]", parent.Title, i+1, len(req.AgentProfileIDs)),
+			Description:    parent.Description,
+			WorkflowID:     req.BranchWorkflowID,
+			StepID:         req.BranchStepID,
+			AgentProfileID: profileID,
+		})
+		if err != nil {
+			createErr = fmt.Errorf("create branch for agent profile %s: %w", profileID, err)
+			break
+		}
+		group.Branches = append(group.Branches, engine.FanOutBranch{TaskID: childID, AgentProfileID: profileID})
+	}
+	if len(group.Branches) == 0 {
+		return createErr
+	}
+	if err := s.persistFanOutGroup(ctx, session.ID, group); err != nil {
+		return err
+	}
+
+	s.logger.Info("fan_out: launched branches",
+		zap.String("task_id", parent.ID),
+		zap.String("step_id", req.StepID),
+		zap.String("join", req.Join),
+		zap.Int("branches", len(group.Branches)))
+	for _, b := range group.Branches {
+		s.startFanOutTask(ctx, parent, b.TaskID, b.AgentProfileID, parent.Description)
+	}
+	// Branches created before a failure still run and join; the error only
+	// reports the ones that are missing.
+	return createErr
+}
+
+// isFanOutMember reports whether task is a branch or the judge of its
+// parent's fan-out. Branches inherit the parent's workflow by default, so
+// without this check every branch would fan out again on entering the step.
+func (s *Service) isFanOutMember(ctx context.Context, task *models.Task) bool {
+	if task.ParentID == "" {
+		return false
+	}
+	group, _, ok := s.loadFanOutGroup(ctx, task.ParentID)
+	if !ok {
+		return false
+	}
+	if group.JudgeTaskID == task.ID {
+		return true
+	}
+	_, isBranch := group.Branch(task.ID)
+	return isBranch
+}
+
+// startFanOutTask launches the agent of a branch or judge task in the
+// background. A launch failure marks the task FAILED so the join can
+// still advance without it.
+func (s *Service) startFanOutTask(ctx context.Context, parent *models.Task, taskID, agentProfileID, prompt string) {
+	go func() {
+		asyncCtx := context.WithoutCancel(ctx)
+		task, err := s.repo.GetTask(asyncCtx, taskID)
+		if err == nil {
+			if s.fanOutTaskStarter != nil {
+				err = s.fanOutTaskStarter(asyncCtx, task, agentProfileID, prompt)
+			} else {
+				executorID, _ := parent.Metadata[models.MetaKeyExecutorID].(string)
+				executorProfileID, _ := parent.Metadata[models.MetaKeyExecutorProfileID].(string)
+				_, err = s.startTask(asyncCtx, task.ID, agentProfileID, executorID, executorProfileID, "",
+					prompt, task.WorkflowStepID, false, true, nil, startTaskOptions{ProfileExplicit: true})
+			}
+		}
+		if err != nil {
+			s.logger.Error("fan_out: failed to start task",
+				zap.String("task_id", taskID),
+				zap.String("parent_task_id", parent.ID),
+				zap.String("agent_profile_id", agentProfileID),
+				zap.Error(err))
+			s.settleFanOutTask(asyncCtx, taskID, v1.TaskStateFailed)
+		}
+	}()
+}
+
+// processFanOutJoin re-evaluates the fan-out of parentID after one of its
+// children reached a terminal state. Losing branches of a first_success
+// race are cancelled once the join is recorded, outside the join lock:
+// each cancellation re-enters here through the task state change.
+func (s *Service) processFanOutJoin(ctx context.Context, parentID string) {
+	for _, taskID := range s.advanceFanOutJoin(ctx, parentID) {
+		if s.executor != nil {
+			if err := s.executor.StopByTaskID(ctx, taskID, "fan-out branch lost the race", false); err != nil {
+				s.logger.Debug("fan_out: failed to stop losing branch",
+					zap.String("task_id", taskID),
+					zap.Error(err))
+			}
+		}
+		s.settleFanOutTask(ctx, taskID, v1.TaskStateCancelled)
+	}
+}
+
+func (s *Service) advanceFanOutJoin(ctx context.Context, parentID string) []string {
+	if parentID == "" || s.workflowStore == nil {
+		return nil
+	}
+	unlock := s.lockChildCompletionOperation(fanOutLockKey(parentID))
+	defer unlock()
+
+	group, session, ok := s.loadFanOutGroup(ctx, parentID)
+	if !ok || group.Status.Settled() {
+		return nil
+	}
+	outcomes, ok := s.fanOutBranchOutcomes(ctx, parentID, group)
+	if !ok {
+		return nil
+	}
+
+	switch group.Status {
+	case engine.FanOutStatusAwaitingSelection:
+		return nil
+	case engine.FanOutStatusJudging:
+		// A judge that stopped without picking hands the choice to a user.
+		if outcomes[group.JudgeTaskID] != engine.FanOutBranchPending {
+			group.Status = engine.FanOutStatusAwaitingSelection
+			if err := s.persistFanOutGroup(ctx, session.ID, group); err != nil {
+				s.logger.Warn("fan_out: failed to persist join", zap.String("parent_task_id", parentID), zap.Error(err))
+			}
+		}
+		return nil
+	}
+
+	branches := make([]engine.FanOutBranch, len(group.Branches))
+	for i, b := range group.Branches {
+		b.Outcome = outcomes[b.TaskID]
+		branches[i] = b
+	}
+	decision := engine.DecideFanOutJoin(group.Join, branches)
+	if decision.Status == engine.FanOutStatusRunning {
+		return nil
+	}
+	group.Status = decision.Status
+	if decision.WinnerTaskID != "" {
+		group.WinnerTaskID = decision.WinnerTaskID
+		group.SelectedBy = fanOutSelectedByAuto
+		s.mergeFanOutWinner(ctx, session, group)
+	}
+	startJudge := func() {}
+	if decision.Status == engine.FanOutStatusJudging {
+		startJudge = s.createFanOutJudge(ctx, parentID, group, branches)
+	}
+	if err := s.persistFanOutGroup(ctx, session.ID, group); err != nil {
+		s.logger.Warn("fan_out: failed to persist join", zap.String("parent_task_id", parentID), zap.Error(err))
+		return nil
+	}
+	startJudge()
+	s.logger.Info("fan_out: join advanced",
+		zap.String("parent_task_id", parentID),
+		zap.String("status", string(group.Status)),
+		zap.String("winner_task_id", group.WinnerTaskID))
+	return decision.CancelTaskIDs
+}
+
+// createFanOutJudge creates the judge child and returns the func that
+// starts it, to be called once the group recording JudgeTaskID is
+// persisted. When the judge cannot be created the group falls back to
+// awaiting a user's selection.
+func (s *Service) createFanOutJudge(ctx context.Context, parentID string, group *engine.FanOutGroup, branches []engine.FanOutBranch) func() {
+	parent, err := s.repo.GetTask(ctx, parentID)
+	if err != nil {
+		group.Status = engine.FanOutStatusAwaitingSelection
+		return func() {}
+	}
+	prompt := s.fanOutJudgePrompt(ctx, parent, branches)
+	judgeID, err := s.engineTaskCreator.CreateChildTask(ctx, parentID, engine.ChildTaskSpec{
+		Title:          parent.Title + " [judge]",
+		Description:    prompt,
+		WorkflowID:     group.BranchWorkflowID,
+		StepID:         group.BranchStepID,
+		AgentProfileID: group.JudgeAgentProfileID,
+	})
+	if err != nil {
+		s.logger.Warn("fan_out: failed to create judge task; awaiting user selection",
+			zap.String("parent_task_id", parentID),
+			zap.Error(err))
+		group.Status = engine.FanOutStatusAwaitingSelection
+		return func() {}
+	}
+	group.JudgeTaskID = judgeID
+	profileID := group.JudgeAgentProfileID
+	return func() { s.startFanOutTask(ctx, parent, judgeID, profileID, prompt) }
+}
+
+func (s *Service) fanOutJudgePrompt(ctx context.Context, parent *models.Task, branches []engine.FanOutBranch) string {
+	var b strings.Builder
+	b.WriteString("Several agents worked on the same task in parallel, each on its own branch. ")
+	b.WriteString("Compare their results and pick the best one.\n\n")
+	b.WriteString("## Task\n\n")
+	b.WriteString(parent.Description)
+	b.WriteString("\n\n## Candidates\n\n")
+	for _, br := range branches {
+		if br.Outcome != engine.FanOutBranchSucceeded {
+			continue
+		}
+		fmt.Fprintf(&b, "- task_id %s (agent profile %s", br.TaskID, br.AgentProfileID)
+		if session, err := s.repo.GetActiveTaskSessionByTaskID(ctx, br.TaskID); err == nil && session != nil && session.WorktreeBranch() != "" {
+			fmt.Fprintf(&b, ", branch %s", session.WorktreeBranch())
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString("\nReview each candidate's changes, then call select_fan_out_winner_kandev ")
+	b.WriteString("with the winning task_id and a short reason.")
+	return b.String()
+}
+
+// SelectFanOutWinner records winnerTaskID as the result of parentID's
+// fan-out and lets on_children_completed move the parent on. Valid only
+// while the group is judging or awaiting a selection; a user may overrule
+// a judge that has not decided yet.
+func (s *Service) SelectFanOutWinner(ctx context.Context, parentID, winnerTaskID, selectedBy, reason string) error {
+	judgeTaskID, err := s.recordFanOutWinner(ctx, parentID, winnerTaskID, selectedBy, reason)
+	if err != nil {
+		return err
+	}
+	if judgeTaskID != "" && s.settleFanOutTask(ctx, judgeTaskID, v1.TaskStateCompleted) {
+		// The judge was the last open child; its state change already ran
+		// on_children_completed for the parent.
+		return nil
+	}
+	s.processOnChildrenCompleted(ctx, parentID)
+	return nil
+}
+
+// SelectFanOutWinnerAsJudge is SelectFanOutWinner for the judge task's own
+// agent, which only knows its task id.
+func (s *Service) SelectFanOutWinnerAsJudge(ctx context.Context, judgeTaskID, winnerTaskID, reason string) error {
+	judge, err := s.repo.GetTask(ctx, judgeTaskID)
+	if err != nil {
+		return fmt.Errorf("load task %s: %w", judgeTaskID, err)
+	}
+	group, _, ok := s.loadFanOutGroup(ctx, judge.ParentID)
+	if !ok || group.JudgeTaskID != judgeTaskID {
+		return fmt.Errorf("%w: task %s is not a fan-out judge", ErrInvalidFanOutSelection, judgeTaskID)
+	}
+	return s.SelectFanOutWinner(ctx, judge.ParentID, winnerTaskID, fanOutSelectedByJudge, reason)
+}
+
+func (s *Service) recordFanOutWinner(ctx context.Context, parentID, winnerTaskID, selectedBy, reason string) (string, error) {
+	unlock := s.lockChildCompletionOperation(fanOutLockKey(parentID))
+	defer unlock()
+
+	group, session, ok := s.loadFanOutGroup(ctx, parentID)
+	if !ok {
+		return "", fmt.Errorf("%w: task %s has no fan-out", ErrInvalidFanOutSelection, parentID)
+	}
+	if group.Status != engine.FanOutStatusJudging && group.Status != engine.FanOutStatusAwaitingSelection {
+		return "", fmt.Errorf("%w: fan-out is %s", ErrInvalidFanOutSelection, group.Status)
+	}
+	if _, isBranch := group.Branch(winnerTaskID); !isBranch {
+		return "", fmt.Errorf("%w: task %s is not a branch of this fan-out", ErrInvalidFanOutSelection, winnerTaskID)
+	}
+	outcomes, ok := s.fanOutBranchOutcomes(ctx, parentID, group)
+	if !ok {
+		return "", errors.New("failed to load fan-out branches")
+	}
+	if outcomes[winnerTaskID] != engine.FanOutBranchSucceeded {
+		return "", fmt.Errorf("%w: branch %s did not succeed", ErrInvalidFanOutSelection, winnerTaskID)
+	}
+	if selectedBy == "" {
+		selectedBy = fanOutSelectedByUser
+	}
+	group.Status = engine.FanOutStatusJoined
+	group.WinnerTaskID = winnerTaskID
+	group.SelectedBy = selectedBy
+	group.Reason = strings.TrimSpace(reason)
+	s.mergeFanOutWinner(ctx, session, group)
+	if err := s.persistFanOutGroup(ctx, session.ID, group); err != nil {
+		return "", err
+	}
+	s.logger.Info("fan_out: winner selected",
+		zap.String("parent_task_id", parentID),
+		zap.String("winner_task_id", winnerTaskID),
+		zap.String("selected_by", selectedBy))
+	return group.JudgeTaskID, nil
+}
+
+// fanOutBranchMerger is the slice of worktree.Manager that brings a fan-out
+// winner into the parent's workspace.
+type fanOutBranchMerger interface {
+	CommitPendingChanges(ctx context.Context, worktreePath, message string) (bool, error)
+	MergeBranch(ctx context.Context, worktreePath, branch, message string) error
+}
+
+// mergeFanOutWinner merges the winning branch into each of the parent's
+// worktrees, matched by repository, before the parent moves on, and
+// records the outcome on group. Worktrees of one repository share its refs,
+// so the winner's branch resolves from the parent's checkout. Only host
+// worktrees can be merged: when the winner or the parent runs on any other
+// executor, the merge is not attempted. A failed or skipped merge leaves
+// the parent's workspace as it was; the join still completes and
+// MergeError tells the user to bring the winner in by hand.
+func (s *Service) mergeFanOutWinner(ctx context.Context, parentSession *models.TaskSession, group *engine.FanOutGroup) {
+	if s.fanOutMerger == nil || parentSession == nil || group.WinnerTaskID == "" {
+		return
+	}
+	group.WinnerMerged, group.MergeError = false, ""
+	if err := s.mergeFanOutWinnerBranches(ctx, parentSession, group.WinnerTaskID); err != nil {
+		group.MergeError = err.Error()
+		s.logger.Warn("fan_out: failed to merge the winner into the parent",
+			zap.String("parent_task_id", parentSession.TaskID),
+			zap.String("winner_task_id", group.WinnerTaskID),
+			zap.Error(err))
+		return
+	}
+	group.WinnerMerged = true
+}
+
+func (s *Service) mergeFanOutWinnerBranches(ctx context.Context, parentSession *models.TaskSession, winnerTaskID string) error {
+	winnerSession, winnerWorktrees, err := s.fanOutWinnerWorktrees(ctx, winnerTaskID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireFanOutHostWorktrees(ctx, winnerSession, "winner"); err != nil {
+		return err
+	}
+	if err := s.requireFanOutHostWorktrees(ctx, parentSession, "parent"); err != nil {
+		return err
+	}
+	merged := 0
+	for _, wt := range parentSession.Worktrees {
+		if wt == nil || wt.WorktreePath == "" {
+			continue
+		}
+		winner := winnerWorktrees[wt.RepositoryID]
+		if winner == nil || winner.WorktreeBranch == wt.WorktreeBranch {
+			continue
+		}
+		// The winner may have stopped with work it never committed; merging
+		// only its commits would silently drop that work.
+		if winner.WorktreePath != "" {
+			message := fmt.Sprintf("Commit pending changes of fan-out winner (task %s)", winnerTaskID)
+			if _, err := s.fanOutMerger.CommitPendingChanges(ctx, winner.WorktreePath, message); err != nil {
+				return fmt.Errorf("commit the winner's pending changes in %s: %w", winner.WorktreePath, err)
+			}
+		}
+		message := fmt.Sprintf("Merge fan-out winner %s (task %s)", winner.WorktreeBranch, winnerTaskID)
+		if err := s.fanOutMerger.MergeBranch(ctx, wt.WorktreePath, winner.WorktreeBranch, message); err != nil {
+			return fmt.Errorf("merge %s into %s: %w", winner.WorktreeBranch, wt.WorktreePath, err)
+		}
+		merged++
+	}
+	if merged == 0 {
+		return errors.New("the parent has no worktree sharing a repository with the winner")
+	}
+	return nil
+}
+
+// requireFanOutHostWorktrees fails when session does not run on the worktree
+// executor. Other executors keep their checkouts in a container or on a
+// remote machine, where the backend can neither commit nor merge.
+func (s *Service) requireFanOutHostWorktrees(ctx context.Context, session *models.TaskSession, role string) error {
+	env, err := s.titleBranchEnvironment(ctx, session, session.TaskID)
+	if err != nil {
+		return fmt.Errorf("load the %s's environment: %w", role, err)
+	}
+	if executorType := s.titleBranchExecutorType(ctx, session, env); executorType != models.ExecutorTypeWorktree {
+		return fmt.Errorf("the %s runs on the %s executor; only worktree executors are merged automatically", role, executorType)
+	}
+	return nil
+}
+
+// fanOutWinnerWorktrees returns the winner's newest session that has a
+// worktree, with its worktrees by repository ID.
+func (s *Service) fanOutWinnerWorktrees(ctx context.Context, winnerTaskID string) (*models.TaskSession, map[string]*models.TaskEnvironmentRepo, error) {
+	sessions, err := s.repo.ListTaskSessions(ctx, winnerTaskID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load sessions of winner %s: %w", winnerTaskID, err)
+	}
+	for _, session := range sessions {
+		worktrees := make(map[string]*models.TaskEnvironmentRepo, len(session.Worktrees))
+		for _, wt := range session.Worktrees {
+			if wt != nil && wt.WorktreeBranch != "" {
+				worktrees[wt.RepositoryID] = wt
+			}
+		}
+		if len(worktrees) > 0 {
+			return session, worktrees, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("winner %s has no worktree branch", winnerTaskID)
+}
+
+// fanOutJoinPending reports whether session's fan-out for stepID is still
+// waiting on its branches, judge or user. on_children_completed holds off
+// until then: the children are all terminal between the last branch
+// finishing and the selection, but the parent has nothing to move on with.
+func fanOutJoinPending(session *models.TaskSession, stepID string) bool {
+	group, ok := fanOutGroupForSession(session)
+	return ok && group.StepID == stepID && !group.Status.Settled()
+}
+
+// fanOutBranchOutcomes maps each branch (and the judge, if any) to its
+// outcome. A child that completed or sits on a terminal step succeeded;
+// one that failed, was cancelled or no longer exists failed.
+func (s *Service) fanOutBranchOutcomes(ctx context.Context, parentID string, group *engine.FanOutGroup) (map[string]engine.FanOutBranchOutcome, bool) {
+	rows, err := s.repo.ListChildCompletionRows(ctx, parentID)
+	if err != nil {
+		s.logger.Warn("fan_out: failed to list branches",
+			zap.String("parent_task_id", parentID),
+			zap.Error(err))
+		return nil, false
+	}
+	s.annotateTerminalChildSteps(ctx, rows)
+	byID := make(map[string]models.ChildCompletionRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	ids := make([]string, 0, len(group.Branches)+1)
+	for _, b := range group.Branches {
+		ids = append(ids, b.TaskID)
+	}
+	if group.JudgeTaskID != "" {
+		ids = append(ids, group.JudgeTaskID)
+	}
+	outcomes := make(map[string]engine.FanOutBranchOutcome, len(ids))
+	for _, id := range ids {
+		row, ok := byID[id]
+		switch {
+		case !ok:
+			outcomes[id] = engine.FanOutBranchFailed
+		case row.State == v1.TaskStateCompleted || (row.TerminalWorkflowStep && !models.IsTerminalTaskState(row.State)):
+			outcomes[id] = engine.FanOutBranchSucceeded
+		case models.IsTerminalTaskState(row.State):
+			outcomes[id] = engine.FanOutBranchFailed
+		default:
+			outcomes[id] = engine.FanOutBranchPending
+		}
+	}
+	return outcomes, true
+}
+
+// settleFanOutTask moves a branch or judge task to a terminal state and
+// runs the parent's completion handling, like a terminal step move does.
+// Returns false when the task was already terminal.
+func (s *Service) settleFanOutTask(ctx context.Context, taskID string, state v1.TaskState) bool {
+	s.taskRuntimeStateMu.Lock()
+	task, err := s.repo.GetTask(ctx, taskID)
+	if err != nil {
+		s.taskRuntimeStateMu.Unlock()
+		s.logger.Warn("fan_out: failed to load task",
+			zap.String("task_id", taskID),
+			zap.Error(err))
+		return false
+	}
+	if models.IsTerminalTaskState(task.State) {
+		s.taskRuntimeStateMu.Unlock()
+		return false
+	}
+	oldState := task.State
+	task.State = state
+	task.UpdatedAt = time.Now().UTC()
+	if err := s.repo.UpdateTask(ctx, task); err != nil {
+		s.taskRuntimeStateMu.Unlock()
+		s.logger.Warn("fan_out: failed to update task state",
+			zap.String("task_id", taskID),
+			zap.String("state", string(state)),
+			zap.Error(err))
+		return false
+	}
+	s.taskRuntimeStateMu.Unlock()
+	s.publishTaskUpdated(ctx, task)
+	s.publishTaskStateChanged(ctx, task, oldState)
+	s.processParentChildrenCompletedForTaskState(ctx, taskID, state)
+	return true
+}
+
+func (s *Service) loadFanOutGroup(ctx context.Context, parentID string) (*engine.FanOutGroup, *models.TaskSession, bool) {
+	if parentID == "" {
+		return nil, nil, false
+	}
+	session, err := s.repo.GetActiveTaskSessionByTaskID(ctx, parentID)
+	if err != nil || session == nil {
+		return nil, nil, false
+	}
+	group, ok := fanOutGroupForSession(session)
+	if !ok {
+		return nil, nil, false
+	}
+	return group, session, true
+}
+
+func fanOutGroupForSession(session *models.TaskSession) (*engine.FanOutGroup, bool) {
+	if session == nil || session.Metadata == nil {
+		return nil, false
+	}
+	bag, _ := session.Metadata["workflow_data"].(map[string]any)
+	return engine.FanOutGroupFromData(bag)
+}
+
+func (s *Service) persistFanOutGroup(ctx context.Context, sessionID string, group *engine.FanOutGroup) error {
+	if err := s.workflowStore.PersistData(ctx, sessionID, map[string]any{engine.FanOutDataKey: group.ToData()}); err != nil {
+		return fmt.Errorf("persist fan-out group: %w", err)
+	}
+	return nil
+}
+
+func fanOutLockKey(parentID string) string {
+	return "fan_out:" + parentID
+}
@@ -0,0 +1,378 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/task/models"
+	sqliterepo "github.com/kandev/kandev/internal/task/repository/sqlite"
+	"github.com/kandev/kandev/internal/workflow/engine"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+	"github.com/kandev/kandev/internal/worktree"
+	v1 "github.com/kandev/kandev/pkg/api/v1"
+)
+
+// fanOutTaskCreator creates branch and judge tasks straight in the repo.
+type fanOutTaskCreator struct {
+	repo *sqliterepo.Repository
+	mu   sync.Mutex
+	n    int
+}
+
+func (c *fanOutTaskCreator) CreateChildTask(ctx context.Context, parentID string, spec engine.ChildTaskSpec) (string, error) {
+	c.mu.Lock()
+	c.n++
+	id := fmt.Sprintf("%s-child-%d", parentID, c.n)
+	c.mu.Unlock()
+	now := time.Now().UTC()
+	err := c.repo.CreateTask(ctx, &models.Task{
+		ID:          id,
+		WorkspaceID: "ws1",
+		WorkflowID:  "wf1",
+		Title:       spec.Title,
+		Description: spec.Description,
+		State:       v1.TaskStateTODO,
+		ParentID:    parentID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	return id, err
+}
+
+type fanOutStart struct {
+	taskID, profileID, prompt string
+}
+
+func newFanOutService(t *testing.T) (*Service, chan fanOutStart, chan struct{}) {
+	t.Helper()
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "parent", "parent-session", "step_race")
+
+	stepGetter := newMockStepGetter()
+	stepGetter.steps["step_race"] = &wfmodels.WorkflowStep{
+		ID: "step_race", WorkflowID: "wf1", Name: "Race", Position: 0,
+		Events: wfmodels.StepEvents{
+			OnChildrenCompleted: []wfmodels.GenericAction{{Type: wfmodels.GenericActionMoveToNext}},
+		},
+	}
+	stepGetter.steps["step_review"] = &wfmodels.WorkflowStep{
+		ID: "step_review", WorkflowID: "wf1", Name: "Review", Position: 1,
+	}
+
+	svc := createEngineService(t, repo, stepGetter, &mockAgentManager{repoForExecutionLookup: repo})
+	svc.engineTaskCreator = &fanOutTaskCreator{repo: repo}
+	starts := make(chan fanOutStart, 8)
+	svc.fanOutTaskStarter = func(_ context.Context, task *models.Task, profileID, prompt string) error {
+		starts <- fanOutStart{taskID: task.ID, profileID: profileID, prompt: prompt}
+		return nil
+	}
+	onEnterDone := make(chan struct{}, 1)
+	svc.onProcessOnEnterComplete = func() {
+		select {
+		case onEnterDone <- struct{}{}:
+		default:
+		}
+	}
+	return svc, starts, onEnterDone
+}
+
+func launchTestFanOut(t *testing.T, svc *Service, starts <-chan fanOutStart, join, judge string, profiles ...string) []string {
+	t.Helper()
+	err := svc.LaunchFanOut(context.Background(), engine.FanOutRequest{
+		TaskID:              "parent",
+		SessionID:           "parent-session",
+		StepID:              "step_race",
+		AgentProfileIDs:     profiles,
+		Join:                join,
+		JudgeAgentProfileID: judge,
+	})
+	if err != nil {
+		t.Fatalf("LaunchFanOut: %v", err)
+	}
+	group := requireFanOutGroup(t, svc)
+	if len(group.Branches) != len(profiles) || group.Status != engine.FanOutStatusRunning {
+		t.Fatalf("unexpected group after launch: %+v", group)
+	}
+	ids := make([]string, len(group.Branches))
+	for i, b := range group.Branches {
+		ids[i] = b.TaskID
+		if b.AgentProfileID != profiles[i] {
+			t.Fatalf("branch %d runs %q, want %q", i, b.AgentProfileID, profiles[i])
+		}
+	}
+	for range profiles {
+		waitForFanOutStart(t, starts)
+	}
+	return ids
+}
+
+func waitForFanOutStart(t *testing.T, starts <-chan fanOutStart) fanOutStart {
+	t.Helper()
+	select {
+	case s := <-starts:
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a fan-out task to start")
+		return fanOutStart{}
+	}
+}
+
+func requireFanOutGroup(t *testing.T, svc *Service) *engine.FanOutGroup {
+	t.Helper()
+	group, _, ok := svc.loadFanOutGroup(context.Background(), "parent")
+	if !ok {
+		t.Fatal("expected a fan-out group on the parent session")
+	}
+	return group
+}
+
+func requireTaskState(t *testing.T, svc *Service, taskID string, want v1.TaskState) {
+	t.Helper()
+	task, err := svc.repo.GetTask(context.Background(), taskID)
+	if err != nil {
+		t.Fatalf("load task %s: %v", taskID, err)
+	}
+	if task.State != want {
+		t.Fatalf("task %s state = %s, want %s", taskID, task.State, want)
+	}
+}
+
+func requireParentStep(t *testing.T, svc *Service, want string) {
+	t.Helper()
+	parent, err := svc.repo.GetTask(context.Background(), "parent")
+	if err != nil {
+		t.Fatalf("load parent: %v", err)
+	}
+	if parent.WorkflowStepID != want {
+		t.Fatalf("parent step = %q, want %q", parent.WorkflowStepID, want)
+	}
+}
+
+func TestFanOut_FirstSuccessCancelsLosersAndMovesParent(t *testing.T) {
+	ctx := context.Background()
+	svc, starts, onEnterDone := newFanOutService(t)
+	branches := launchTestFanOut(t, svc, starts, wfmodels.FanOutJoinFirstSuccess, "", "claude", "codex", "gemini")
+
+	// A second on_enter delivery while the race runs is a no-op.
+	if err := svc.LaunchFanOut(ctx, engine.FanOutRequest{
+		TaskID: "parent", SessionID: "parent-session", StepID: "step_race",
+		AgentProfileIDs: []string{"claude", "codex"}, Join: wfmodels.FanOutJoinFirstSuccess,
+	}); err != nil {
+		t.Fatalf("relaunch: %v", err)
+	}
+	if got := requireFanOutGroup(t, svc); len(got.Branches) != 3 {
+		t.Fatalf("relaunch replaced the running group: %+v", got)
+	}
+	// A branch entering the same fan_out step does not fan out again.
+	if err := svc.LaunchFanOut(ctx, engine.FanOutRequest{
+		TaskID: branches[0], SessionID: "unused", StepID: "step_race",
+		AgentProfileIDs: []string{"claude", "codex"}, Join: wfmodels.FanOutJoinFirstSuccess,
+	}); err != nil {
+		t.Fatalf("nested launch: %v", err)
+	}
+	if rows, _ := svc.repo.ListChildCompletionRows(ctx, branches[0]); len(rows) != 0 {
+		t.Fatalf("branch created %d nested children", len(rows))
+	}
+
+	svc.settleFanOutTask(ctx, branches[1], v1.TaskStateCompleted)
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	group := requireFanOutGroup(t, svc)
+	if group.Status != engine.FanOutStatusJoined || group.WinnerTaskID != branches[1] || group.SelectedBy != fanOutSelectedByAuto {
+		t.Fatalf("unexpected join: %+v", group)
+	}
+	requireTaskState(t, svc, branches[0], v1.TaskStateCancelled)
+	requireTaskState(t, svc, branches[2], v1.TaskStateCancelled)
+	requireParentStep(t, svc, "step_review")
+}
+
+func TestFanOut_HumanJoinWaitsForSelection(t *testing.T) {
+	ctx := context.Background()
+	svc, starts, onEnterDone := newFanOutService(t)
+	branches := launchTestFanOut(t, svc, starts, wfmodels.FanOutJoinHuman, "", "claude", "codex")
+
+	svc.settleFanOutTask(ctx, branches[0], v1.TaskStateCompleted)
+	svc.settleFanOutTask(ctx, branches[1], v1.TaskStateCompleted)
+	if group := requireFanOutGroup(t, svc); group.Status != engine.FanOutStatusAwaitingSelection {
+		t.Fatalf("status = %s, want awaiting_selection", group.Status)
+	}
+	// Every child is terminal, but the parent waits for the pick.
+	requireParentStep(t, svc, "step_race")
+
+	if err := svc.SelectFanOutWinner(ctx, "parent", "not-a-branch", fanOutSelectedByUser, ""); !errors.Is(err, ErrInvalidFanOutSelection) {
+		t.Fatalf("expected ErrInvalidFanOutSelection, got %v", err)
+	}
+	if err := svc.SelectFanOutWinner(ctx, "parent", branches[1], fanOutSelectedByUser, "cleaner"); err != nil {
+		t.Fatalf("SelectFanOutWinner: %v", err)
+	}
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	group := requireFanOutGroup(t, svc)
+	if group.Status != engine.FanOutStatusJoined || group.WinnerTaskID != branches[1] || group.Reason != "cleaner" {
+		t.Fatalf("unexpected join: %+v", group)
+	}
+	requireParentStep(t, svc, "step_review")
+	if err := svc.SelectFanOutWinner(ctx, "parent", branches[0], fanOutSelectedByUser, ""); !errors.Is(err, ErrInvalidFanOutSelection) {
+		t.Fatalf("expected a joined fan-out to reject a second pick, got %v", err)
+	}
+}
+
+func TestFanOut_JudgePicksWinner(t *testing.T) {
+	ctx := context.Background()
+	svc, starts, onEnterDone := newFanOutService(t)
+	branches := launchTestFanOut(t, svc, starts, wfmodels.FanOutJoinJudge, "opus", "claude", "codex", "gemini")
+
+	svc.settleFanOutTask(ctx, branches[0], v1.TaskStateCompleted)
+	svc.settleFanOutTask(ctx, branches[1], v1.TaskStateFailed)
+	svc.settleFanOutTask(ctx, branches[2], v1.TaskStateCompleted)
+
+	judgeStart := waitForFanOutStart(t, starts)
+	group := requireFanOutGroup(t, svc)
+	if group.Status != engine.FanOutStatusJudging || group.JudgeTaskID != judgeStart.taskID || judgeStart.profileID != "opus" {
+		t.Fatalf("unexpected judging state: group=%+v start=%+v", group, judgeStart)
+	}
+	if !strings.Contains(judgeStart.prompt, branches[0]) || !strings.Contains(judgeStart.prompt, branches[2]) ||
+		strings.Contains(judgeStart.prompt, branches[1]) {
+		t.Fatalf("judge prompt should list only the succeeded branches:\n%s", judgeStart.prompt)
+	}
+	requireParentStep(t, svc, "step_race")
+
+	if err := svc.SelectFanOutWinnerAsJudge(ctx, branches[0], branches[2], "x"); !errors.Is(err, ErrInvalidFanOutSelection) {
+		t.Fatalf("expected a branch to be refused as judge, got %v", err)
+	}
+	if err := svc.SelectFanOutWinnerAsJudge(ctx, judgeStart.taskID, branches[1], "x"); !errors.Is(err, ErrInvalidFanOutSelection) {
+		t.Fatalf("expected a failed branch to be refused as winner, got %v", err)
+	}
+	if err := svc.SelectFanOutWinnerAsJudge(ctx, judgeStart.taskID, branches[2], "covers the edge cases"); err != nil {
+		t.Fatalf("SelectFanOutWinnerAsJudge: %v", err)
+	}
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	group = requireFanOutGroup(t, svc)
+	if group.Status != engine.FanOutStatusJoined || group.WinnerTaskID != branches[2] || group.SelectedBy != fanOutSelectedByJudge {
+		t.Fatalf("unexpected join: %+v", group)
+	}
+	requireTaskState(t, svc, judgeStart.taskID, v1.TaskStateCompleted)
+	requireParentStep(t, svc, "step_review")
+}
+
+func runFanOutGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// attachFanOutWorktree gives sessionID of taskID a worktree of repo1 at path
+// on branch, run by executorType.
+func attachFanOutWorktree(t *testing.T, repo *sqliterepo.Repository, taskID, sessionID, executorType, path, branch string) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	envID := "env-" + taskID
+	if err := repo.CreateTaskEnvironment(ctx, &models.TaskEnvironment{
+		ID: envID, TaskID: taskID, ExecutorType: executorType,
+		WorkspacePath: path, Status: models.TaskEnvironmentStatusReady,
+	}); err != nil {
+		t.Fatalf("create environment: %v", err)
+	}
+	session, err := repo.GetTaskSession(ctx, sessionID)
+	if err != nil {
+		session = &models.TaskSession{ID: sessionID, TaskID: taskID, State: models.TaskSessionStateCompleted, StartedAt: now, UpdatedAt: now}
+		if err := repo.CreateTaskSession(ctx, session); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+	}
+	session.TaskEnvironmentID = envID
+	if err := repo.UpdateTaskSession(ctx, session); err != nil {
+		t.Fatalf("link session to environment: %v", err)
+	}
+	if err := repo.CreateTaskEnvironmentRepo(ctx, &models.TaskEnvironmentRepo{
+		ID: "wt-" + taskID, TaskEnvironmentID: envID, WorktreeID: "worktree-" + taskID, RepositoryID: "repo1",
+		WorktreePath: path, WorktreeBranch: branch, CreatedAt: now,
+	}); err != nil {
+		t.Fatalf("create worktree: %v", err)
+	}
+}
+
+// setupFanOutMerge gives the parent and the second branch of a first-success
+// fan-out git worktrees of one repository, the winner's run by
+// winnerExecutor, with a committed and an uncommitted change in the winner's.
+func setupFanOutMerge(t *testing.T, winnerExecutor string) (*Service, []string, chan struct{}, string) {
+	t.Helper()
+	ctx := context.Background()
+	svc, starts, onEnterDone := newFanOutService(t)
+	svc.fanOutMerger = &worktree.Manager{}
+	branches := launchTestFanOut(t, svc, starts, wfmodels.FanOutJoinFirstSuccess, "", "claude", "codex")
+
+	repo := svc.repo.(*sqliterepo.Repository)
+	now := time.Now().UTC()
+	if err := repo.CreateRepository(ctx, &models.Repository{
+		ID: "repo1", WorkspaceID: "ws1", Name: "widgets", CreatedAt: now, UpdatedAt: now,
+	}); err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+	parentPath := t.TempDir()
+	runFanOutGit(t, parentPath, "init", "-b", "parent-branch")
+	runFanOutGit(t, parentPath, "config", "user.email", "test@example.com")
+	runFanOutGit(t, parentPath, "config", "user.name", "Test User")
+	runFanOutGit(t, parentPath, "config", "commit.gpgsign", "false")
+	runFanOutGit(t, parentPath, "commit", "--allow-empty", "-m", "initial")
+	winnerPath := filepath.Join(t.TempDir(), "winner")
+	runFanOutGit(t, parentPath, "worktree", "add", "-b", "winner-branch", winnerPath)
+	if err := os.WriteFile(filepath.Join(winnerPath, "solution.go"), []byte("package solution\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runFanOutGit(t, winnerPath, "add", "solution.go")
+	runFanOutGit(t, winnerPath, "commit", "-m", "winning change")
+	if err := os.WriteFile(filepath.Join(winnerPath, "notes.md"), []byte("left uncommitted\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	attachFanOutWorktree(t, repo, "parent", "parent-session", "worktree", parentPath, "parent-branch")
+	attachFanOutWorktree(t, repo, branches[1], branches[1]+"-session", winnerExecutor, winnerPath, "winner-branch")
+	return svc, branches, onEnterDone, parentPath
+}
+
+func TestFanOut_JoinMergesWinnerIntoParentWorkspace(t *testing.T) {
+	svc, branches, onEnterDone, parentPath := setupFanOutMerge(t, "worktree")
+
+	svc.settleFanOutTask(context.Background(), branches[1], v1.TaskStateCompleted)
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	group := requireFanOutGroup(t, svc)
+	if group.WinnerTaskID != branches[1] || !group.WinnerMerged || group.MergeError != "" {
+		t.Fatalf("unexpected join: %+v", group)
+	}
+	for file, want := range map[string]string{"solution.go": "package solution\n", "notes.md": "left uncommitted\n"} {
+		if got, err := os.ReadFile(filepath.Join(parentPath, file)); err != nil || string(got) != want {
+			t.Fatalf("parent workspace %s = %q, %v; want the winner's change", file, got, err)
+		}
+	}
+	requireParentStep(t, svc, "step_review")
+}
+
+func TestFanOut_JoinDoesNotMergeContainerWinner(t *testing.T) {
+	svc, branches, onEnterDone, parentPath := setupFanOutMerge(t, "local_docker")
+
+	svc.settleFanOutTask(context.Background(), branches[1], v1.TaskStateCompleted)
+	waitForChildrenCompletedOnEnter(t, onEnterDone)
+
+	group := requireFanOutGroup(t, svc)
+	if group.WinnerTaskID != branches[1] || group.WinnerMerged || !strings.Contains(group.MergeError, "local_docker executor") {
+		t.Fatalf("expected the join to report an unmerged winner, got %+v", group)
+	}
+	if _, err := os.Stat(filepath.Join(parentPath, "solution.go")); !os.IsNotExist(err) {
+		t.Fatalf("parent workspace was changed: %v", err)
+	}
+	requireParentStep(t, svc, "step_review")
+}
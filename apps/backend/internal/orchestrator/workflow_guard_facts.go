@@ -0,0 +1,145 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kandev/kandev/internal/github"
+	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+// taskRepositoryLister and taskReviewReader are optional repo capabilities
+// the guard facts use; the sqlite task repository satisfies both.
+type taskRepositoryLister interface {
+	ListTaskRepositories(ctx context.Context, taskID string) ([]*models.TaskRepository, error)
+}
+
+type taskReviewReader interface {
+	ListTaskReviewRuns(ctx context.Context, taskID string, limit int) ([]*models.TaskReviewRun, error)
+	ListTaskReviewFindings(ctx context.Context, taskID string) ([]*models.TaskReviewFinding, error)
+}
+
+// workflowGuardFacts implements engine.GuardFactsProvider for transition
+// guard expressions. It reads the service's dependencies at call time so
+// the GitHub service, wired after the engine, is picked up without a
+// rebuild.
+type workflowGuardFacts struct {
+	svc *Service
+}
+
+var _ engine.GuardFactsProvider = workflowGuardFacts{}
+
+func (f workflowGuardFacts) LoadGuardFacts(ctx context.Context, taskID string) (engine.GuardFacts, error) {
+	task, err := f.svc.repo.GetTask(ctx, taskID)
+	if err != nil {
+		return engine.GuardFacts{}, err
+	}
+	facts := engine.GuardFacts{Task: taskGuardFacts(task)}
+	if lister, ok := f.svc.repo.(taskRepositoryLister); ok {
+		repos, err := lister.ListTaskRepositories(ctx, taskID)
+		if err != nil {
+			return engine.GuardFacts{}, err
+		}
+		facts.Task["repository_count"] = len(repos)
+	}
+	if f.svc.githubService != nil {
+		pr, err := f.svc.githubService.GetTaskPR(ctx, taskID)
+		if err != nil {
+			return engine.GuardFacts{}, err
+		}
+		facts.PR = prGuardFacts(pr)
+	}
+	if reader, ok := f.svc.repo.(taskReviewReader); ok {
+		review, err := reviewGuardFacts(ctx, reader, taskID)
+		if err != nil {
+			return engine.GuardFacts{}, err
+		}
+		facts.Review = review
+	}
+	return facts, nil
+}
+
+func taskGuardFacts(task *models.Task) map[string]any {
+	var labels []any
+	if task.Labels != "" {
+		_ = json.Unmarshal([]byte(task.Labels), &labels)
+	}
+	if labels == nil {
+		labels = []any{}
+	}
+	return map[string]any{
+		"id":               task.ID,
+		"title":            task.Title,
+		"identifier":       task.Identifier,
+		"priority":         task.Priority,
+		"state":            string(task.State),
+		"labels":           labels,
+		"repository_count": len(task.Repositories),
+		"parent_id":        task.ParentID,
+	}
+}
+
+// prGuardFacts projects the task's primary PR. nil (no PR linked) leaves
+// every pr.* field null.
+func prGuardFacts(pr *github.TaskPR) map[string]any {
+	if pr == nil {
+		return nil
+	}
+	return map[string]any{
+		"number":             pr.PRNumber,
+		"state":              pr.State,
+		"review_state":       pr.ReviewState,
+		"checks":             pr.ChecksState,
+		"checks_total":       pr.ChecksTotal,
+		"checks_passing":     pr.ChecksPassing,
+		"mergeable":          pr.MergeableState,
+		"review_count":       pr.ReviewCount,
+		"unresolved_threads": pr.UnresolvedReviewThreads,
+		"additions":          pr.Additions,
+		"deletions":          pr.Deletions,
+	}
+}
+
+// reviewGuardFacts summarises the last completed native review run: its
+// status plus counts of the run's findings that are still open, overall and
+// per severity. blocking_findings counts blocker-severity findings. nil when
+// the task has never completed a review.
+func reviewGuardFacts(ctx context.Context, reader taskReviewReader, taskID string) (map[string]any, error) {
+	runs, err := reader.ListTaskReviewRuns(ctx, taskID, 0)
+	if err != nil {
+		return nil, err
+	}
+	var last *models.TaskReviewRun
+	for _, run := range runs {
+		if run.Status == models.ReviewRunCompleted {
+			last = run
+			break
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	findings, err := reader.ListTaskReviewFindings(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[models.ReviewSeverity]int{}
+	open := 0
+	for _, finding := range findings {
+		if finding.RunID != last.ID || finding.Status != models.ReviewFindingOpen {
+			continue
+		}
+		open++
+		counts[finding.Severity]++
+	}
+	return map[string]any{
+		"run_id":            last.ID,
+		"status":            string(last.Status),
+		"findings":          open,
+		"blocking_findings": counts[models.ReviewSeverityBlocker],
+		"major_findings":    counts[models.ReviewSeverityMajor],
+		"minor_findings":    counts[models.ReviewSeverityMinor],
+		"nit_findings":      counts[models.ReviewSeverityNit],
+	}, nil
+}
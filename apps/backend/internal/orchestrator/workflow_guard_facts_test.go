@@ -0,0 +1,72 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kandev/kandev/internal/github"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+type fakeReviewReader struct {
+	runs     []*models.TaskReviewRun
+	findings []*models.TaskReviewFinding
+}
+
+func (f *fakeReviewReader) ListTaskReviewRuns(_ context.Context, _ string, _ int) ([]*models.TaskReviewRun, error) {
+	return f.runs, nil
+}
+
+func (f *fakeReviewReader) ListTaskReviewFindings(_ context.Context, _ string) ([]*models.TaskReviewFinding, error) {
+	return f.findings, nil
+}
+
+func TestReviewGuardFacts_CountsOpenFindingsOfLastCompletedRun(t *testing.T) {
+	reader := &fakeReviewReader{
+		runs: []*models.TaskReviewRun{
+			{ID: "run-3", Status: models.ReviewRunRunning},
+			{ID: "run-2", Status: models.ReviewRunCompleted},
+			{ID: "run-1", Status: models.ReviewRunCompleted},
+		},
+		findings: []*models.TaskReviewFinding{
+			{RunID: "run-2", Severity: models.ReviewSeverityBlocker, Status: models.ReviewFindingOpen},
+			{RunID: "run-2", Severity: models.ReviewSeverityBlocker, Status: models.ReviewFindingDismissed},
+			{RunID: "run-2", Severity: models.ReviewSeverityNit, Status: models.ReviewFindingOpen},
+			{RunID: "run-1", Severity: models.ReviewSeverityBlocker, Status: models.ReviewFindingOpen},
+		},
+	}
+	facts, err := reviewGuardFacts(context.Background(), reader, "t1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if facts["run_id"] != "run-2" || facts["findings"] != 2 || facts["blocking_findings"] != 1 || facts["nit_findings"] != 1 {
+		t.Fatalf("unexpected review facts: %v", facts)
+	}
+}
+
+func TestReviewGuardFacts_NilWithoutCompletedRun(t *testing.T) {
+	reader := &fakeReviewReader{runs: []*models.TaskReviewRun{{ID: "run-1", Status: models.ReviewRunFailed}}}
+	facts, err := reviewGuardFacts(context.Background(), reader, "t1")
+	if err != nil || facts != nil {
+		t.Fatalf("expected nil facts, got %v, %v", facts, err)
+	}
+}
+
+func TestTaskAndPRGuardFacts(t *testing.T) {
+	task := taskGuardFacts(&models.Task{ID: "t1", Priority: "high", Labels: `["bug","backend"]`})
+	labels, _ := task["labels"].([]any)
+	if task["priority"] != "high" || len(labels) != 2 || labels[0] != "bug" {
+		t.Fatalf("unexpected task facts: %v", task)
+	}
+	if empty := taskGuardFacts(&models.Task{ID: "t2"}); empty["labels"] == nil {
+		t.Fatalf("labels must default to an empty list")
+	}
+
+	if prGuardFacts(nil) != nil {
+		t.Fatalf("no PR must leave pr facts nil")
+	}
+	pr := prGuardFacts(&github.TaskPR{PRNumber: 7, ChecksState: "success", State: "open"})
+	if pr["checks"] != "success" || pr["number"] != 7 {
+		t.Fatalf("unexpected pr facts: %v", pr)
+	}
+}
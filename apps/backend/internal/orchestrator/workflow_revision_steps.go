@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kandev/kandev/internal/workflow/engine"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+// taskStepGetter is the optional WorkflowStepGetter extension that resolves
+// steps as a task runs them: from the workflow revision the task is pinned
+// to, so editing the live workflow does not change the transitions of a
+// task in flight until it is migrated. Getters without it serve live steps.
+type taskStepGetter interface {
+	GetTaskStep(ctx context.Context, taskID, stepID string) (*wfmodels.WorkflowStep, error)
+	GetTaskNextStepByPosition(ctx context.Context, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error)
+	GetTaskPreviousStepByPosition(ctx context.Context, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error)
+}
+
+// getTaskStep loads stepID as taskID runs it. An empty taskID loads the live
+// step.
+func getTaskStep(ctx context.Context, getter WorkflowStepGetter, taskID, stepID string) (*wfmodels.WorkflowStep, error) {
+	if tg, ok := getter.(taskStepGetter); ok && taskID != "" {
+		return tg.GetTaskStep(ctx, taskID, stepID)
+	}
+	return getter.GetStep(ctx, stepID)
+}
+
+func getTaskNextStep(ctx context.Context, getter WorkflowStepGetter, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error) {
+	if tg, ok := getter.(taskStepGetter); ok && taskID != "" {
+		return tg.GetTaskNextStepByPosition(ctx, taskID, workflowID, currentPosition)
+	}
+	return getter.GetNextStepByPosition(ctx, workflowID, currentPosition)
+}
+
+func getTaskPreviousStep(ctx context.Context, getter WorkflowStepGetter, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error) {
+	if tg, ok := getter.(taskStepGetter); ok && taskID != "" {
+		return tg.GetTaskPreviousStepByPosition(ctx, taskID, workflowID, currentPosition)
+	}
+	return getter.GetPreviousStepByPosition(ctx, workflowID, currentPosition)
+}
+
+// getTaskStep is getTaskStep over the service's step getter.
+func (s *Service) getTaskStep(ctx context.Context, taskID, stepID string) (*wfmodels.WorkflowStep, error) {
+	return getTaskStep(ctx, s.workflowStepGetter, taskID, stepID)
+}
+
+func (s *Service) getTaskNextStep(ctx context.Context, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error) {
+	return getTaskNextStep(ctx, s.workflowStepGetter, taskID, workflowID, currentPosition)
+}
+
+func (s *Service) getTaskPreviousStep(ctx context.Context, taskID, workflowID string, currentPosition int) (*wfmodels.WorkflowStep, error) {
+	return getTaskPreviousStep(ctx, s.workflowStepGetter, taskID, workflowID, currentPosition)
+}
+
+// LoadTaskStep implements engine.TaskStepLoader.
+func (s *workflowStore) LoadTaskStep(ctx context.Context, taskID, _, stepID string) (engine.StepSpec, error) {
+	step, err := getTaskStep(ctx, s.workflowStepGetter, taskID, stepID)
+	if err != nil {
+		return engine.StepSpec{}, fmt.Errorf("load step %s: %w", stepID, err)
+	}
+	return engine.CompileStep(step), nil
+}
+
+// LoadTaskNextStep implements engine.TaskStepLoader.
+func (s *workflowStore) LoadTaskNextStep(ctx context.Context, taskID, workflowID string, currentPosition int) (engine.StepSpec, error) {
+	step, err := getTaskNextStep(ctx, s.workflowStepGetter, taskID, workflowID, currentPosition)
+	if err != nil {
+		return engine.StepSpec{}, fmt.Errorf("load next step after position %d: %w", currentPosition, err)
+	}
+	if step == nil {
+		return engine.StepSpec{}, fmt.Errorf("no next step after position %d in workflow %s", currentPosition, workflowID)
+	}
+	return engine.CompileStep(step), nil
+}
+
+// LoadTaskPreviousStep implements engine.TaskStepLoader.
+func (s *workflowStore) LoadTaskPreviousStep(ctx context.Context, taskID, workflowID string, currentPosition int) (engine.StepSpec, error) {
+	step, err := getTaskPreviousStep(ctx, s.workflowStepGetter, taskID, workflowID, currentPosition)
+	if err != nil {
+		return engine.StepSpec{}, fmt.Errorf("load previous step before position %d: %w", currentPosition, err)
+	}
+	if step == nil {
+		return engine.StepSpec{}, fmt.Errorf("no previous step before position %d in workflow %s", currentPosition, workflowID)
+	}
+	return engine.CompileStep(step), nil
+}
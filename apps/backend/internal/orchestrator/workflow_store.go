@@ -239,7 +239,7 @@ func (s *workflowStore) applyTransition(ctx context.Context, taskID, sessionID,
 	if err != nil {
 		return fmt.Errorf("load task for transition: %w", err)
 	}
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, toStepID)
+	targetStep, err := getTaskStep(ctx, s.workflowStepGetter, taskID, toStepID)
 	if err != nil {
 		return fmt.Errorf("load target step for transition: %w", err)
 	}
@@ -366,7 +366,7 @@ func (s *workflowStore) applyTransitionIfAtStepRaw(
 	if err != nil {
 		return nil, "", false, fmt.Errorf("load task for CAS transition: %w", err)
 	}
-	targetStep, err := s.workflowStepGetter.GetStep(ctx, toStepID)
+	targetStep, err := getTaskStep(ctx, s.workflowStepGetter, taskID, toStepID)
 	if err != nil {
 		return nil, "", false, fmt.Errorf("load target step for CAS transition: %w", err)
 	}
@@ -710,7 +710,7 @@ func (s *workflowStore) syncQueuedPromotionState(ctx context.Context, task *mode
 	if targetStep == nil || s.workflowStepGetter == nil {
 		return task.State, false, nil
 	}
-	next, err := s.workflowStepGetter.GetNextStepByPosition(ctx, targetStep.WorkflowID, targetStep.Position)
+	next, err := getTaskNextStep(ctx, s.workflowStepGetter, task.ID, targetStep.WorkflowID, targetStep.Position)
 	if err != nil {
 		return task.State, false, fmt.Errorf("load next step after %s: %w", targetStep.ID, err)
 	}
@@ -829,6 +829,13 @@ func (s *workflowStore) PersistData(ctx context.Context, sessionID string, data
 	if err := s.repo.SetSessionMetadataKey(ctx, sessionID, "workflow_data", existing); err != nil {
 		return fmt.Errorf("persist workflow data: %w", err)
 	}
+	// Mirror max_iterations counters onto the task so they are visible
+	// without reading the session's data bag.
+	if counts, ok := data[engine.IterationsDataKey]; ok && session.TaskID != "" {
+		if err := s.repo.SetTaskMetadataKey(ctx, session.TaskID, models.MetaKeyWorkflowIterations, counts); err != nil {
+			return fmt.Errorf("persist workflow iterations: %w", err)
+		}
+	}
 	return nil
 }
 
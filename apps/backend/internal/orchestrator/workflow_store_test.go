@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/workflow/engine"
 	wfmodels "github.com/kandev/kandev/internal/workflow/models"
 )
 
@@ -511,6 +512,40 @@ func TestWorkflowStore_PersistData(t *testing.T) {
 	}
 }
 
+// TestWorkflowStore_PersistData_MirrorsIterations checks that max_iterations
+// counters written to the data bag are also visible on the task metadata.
+func TestWorkflowStore_PersistData_MirrorsIterations(t *testing.T) {
+	ctx := context.Background()
+	repo := setupTestRepo(t)
+	seedSession(t, repo, "t1", "s1", "step1")
+
+	store := newWorkflowStore(repo, newMockStepGetter(), nil, noopPublisher, testLogger())
+
+	if err := store.PersistData(ctx, "s1", map[string]any{"plan_mode": true}); err != nil {
+		t.Fatalf("PersistData failed: %v", err)
+	}
+	task, err := repo.GetTask(ctx, "t1")
+	if err != nil {
+		t.Fatalf("failed to get task: %v", err)
+	}
+	if _, ok := task.Metadata[models.MetaKeyWorkflowIterations]; ok {
+		t.Fatalf("expected no iterations mirror without counters, got %v", task.Metadata)
+	}
+
+	err = store.PersistData(ctx, "s1", map[string]any{engine.IterationsDataKey: map[string]any{"step2": 2}})
+	if err != nil {
+		t.Fatalf("PersistData failed: %v", err)
+	}
+	task, err = repo.GetTask(ctx, "t1")
+	if err != nil {
+		t.Fatalf("failed to get task: %v", err)
+	}
+	counts, ok := task.Metadata[models.MetaKeyWorkflowIterations].(map[string]interface{})
+	if !ok || counts["step2"] != float64(2) {
+		t.Fatalf("expected step2 iterations mirrored to task metadata, got %v", task.Metadata)
+	}
+}
+
 func TestWorkflowStore_OperationIdempotency(t *testing.T) {
 	ctx := context.Background()
 	store := newWorkflowStore(nil, newMockStepGetter(), nil, noopPublisher, testLogger())
@@ -0,0 +1,230 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+// StepTimeoutInfo is one workflow step with an SLA timeout configured.
+// A zero duration disables that kind of timeout.
+type StepTimeoutInfo struct {
+	StepID      string
+	WorkflowID  string
+	WallClock   time.Duration
+	AgentActive time.Duration
+}
+
+// StepTimeoutStepLister returns the workflow steps whose events configure
+// a timeout with on_timeout actions.
+type StepTimeoutStepLister interface {
+	ListTimeoutSteps(ctx context.Context) ([]StepTimeoutInfo, error)
+}
+
+// StepTimeoutTaskLister returns the ids of active (non-archived) tasks
+// currently in a workflow step.
+type StepTimeoutTaskLister interface {
+	ListTaskIDsAtStep(ctx context.Context, stepID string) ([]string, error)
+}
+
+// StepTimerState is the persisted timer for a task's current step visit.
+type StepTimerState struct {
+	StepID           string
+	EnteredAt        time.Time
+	WallClockFired   bool
+	AgentActiveFired bool
+}
+
+// StepTimerStore persists step timers so fired timeouts survive a backend
+// restart. ClaimTimeout must be atomic: it reports true for exactly one
+// caller per (task, step visit, kind) until ReleaseTimeout is called.
+type StepTimerStore interface {
+	// StepEnteredAt returns when the task last entered stepID; found is
+	// false when no record exists.
+	StepEnteredAt(ctx context.Context, taskID, stepID string) (enteredAt time.Time, found bool, err error)
+	LoadTimer(ctx context.Context, taskID string) (*StepTimerState, error)
+	ResetTimer(ctx context.Context, taskID, stepID string, enteredAt time.Time) error
+	ClaimTimeout(ctx context.Context, taskID, stepID, kind string, at time.Time) (bool, error)
+	ReleaseTimeout(ctx context.Context, taskID, stepID, kind string) error
+	// AgentActiveTime sums the agent turn time on the task in [since, until).
+	AgentActiveTime(ctx context.Context, taskID string, since, until time.Time) (time.Duration, error)
+}
+
+// StepTimeoutHandler implements Handler for engine.TriggerOnTimeout.
+//
+// Unlike the heartbeat handler it keeps no in-memory state: each fire is
+// claimed in the StepTimerStore before dispatch, so a restart between
+// ticks neither loses nor repeats an escalation. A dispatch that fails is
+// released and retried on the next tick.
+type StepTimeoutHandler struct {
+	steps      StepTimeoutStepLister
+	tasks      StepTimeoutTaskLister
+	timers     StepTimerStore
+	dispatcher HeartbeatEngineDispatcher
+	now        func() time.Time
+	log        *logger.Logger
+}
+
+// NewStepTimeoutHandler builds a StepTimeoutHandler. Any nil dependency
+// makes Tick a no-op.
+func NewStepTimeoutHandler(
+	steps StepTimeoutStepLister,
+	tasks StepTimeoutTaskLister,
+	timers StepTimerStore,
+	dispatcher HeartbeatEngineDispatcher,
+	now func() time.Time,
+	log *logger.Logger,
+) *StepTimeoutHandler {
+	if now == nil {
+		now = func() time.Time { return time.Now().UTC() }
+	}
+	return &StepTimeoutHandler{
+		steps:      steps,
+		tasks:      tasks,
+		timers:     timers,
+		dispatcher: dispatcher,
+		now:        now,
+		log:        log.WithFields(zap.String("handler", "step_timeout")),
+	}
+}
+
+// Name implements Handler.
+func (h *StepTimeoutHandler) Name() string { return "step_timeout" }
+
+// Tick implements Handler. For every step with a timeout it syncs each
+// task's timer with the step the task is in, then fires on_timeout for
+// every limit that has elapsed and not yet fired. Per-task errors are
+// logged and never abort the pass.
+func (h *StepTimeoutHandler) Tick(ctx context.Context) error {
+	if h.steps == nil || h.tasks == nil || h.timers == nil || h.dispatcher == nil {
+		return nil
+	}
+	steps, err := h.steps.ListTimeoutSteps(ctx)
+	if err != nil {
+		return fmt.Errorf("list timeout steps: %w", err)
+	}
+	now := h.now()
+	for i := range steps {
+		step := &steps[i]
+		taskIDs, err := h.tasks.ListTaskIDsAtStep(ctx, step.StepID)
+		if err != nil {
+			h.log.Warn("list tasks at timeout step failed",
+				zap.String("step_id", step.StepID), zap.Error(err))
+			continue
+		}
+		for _, taskID := range taskIDs {
+			if err := h.tickTask(ctx, step, taskID, now); err != nil {
+				h.log.Warn("step timeout check failed",
+					zap.String("task_id", taskID),
+					zap.String("step_id", step.StepID),
+					zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+func (h *StepTimeoutHandler) tickTask(ctx context.Context, step *StepTimeoutInfo, taskID string, now time.Time) error {
+	timer, err := h.syncTimer(ctx, step.StepID, taskID, now)
+	if err != nil {
+		return err
+	}
+	if step.WallClock > 0 && !timer.WallClockFired {
+		if elapsed := now.Sub(timer.EnteredAt); elapsed >= step.WallClock {
+			h.fire(ctx, taskID, timer, engine.TimeoutKindWallClock, elapsed, step.WallClock, now)
+		}
+	}
+	if step.AgentActive > 0 && !timer.AgentActiveFired {
+		active, err := h.timers.AgentActiveTime(ctx, taskID, timer.EnteredAt, now)
+		if err != nil {
+			return fmt.Errorf("agent active time: %w", err)
+		}
+		if active >= step.AgentActive {
+			h.fire(ctx, taskID, timer, engine.TimeoutKindAgentActive, active, step.AgentActive, now)
+		}
+	}
+	return nil
+}
+
+// syncTimer returns the task's timer for its visit to stepID, resetting it
+// when the stored timer belongs to another step or an earlier visit. Tasks
+// with no recorded entry (they predate the transition ledger) start their
+// timer the first time they are seen.
+func (h *StepTimeoutHandler) syncTimer(ctx context.Context, stepID, taskID string, now time.Time) (*StepTimerState, error) {
+	timer, err := h.timers.LoadTimer(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("load timer: %w", err)
+	}
+	entered, found, err := h.timers.StepEnteredAt(ctx, taskID, stepID)
+	if err != nil {
+		return nil, fmt.Errorf("step entry: %w", err)
+	}
+	sameStep := timer != nil && timer.StepID == stepID
+	if !found {
+		if sameStep {
+			return timer, nil
+		}
+		entered = now
+	}
+	if sameStep && timer.EnteredAt.Truncate(time.Millisecond).Equal(entered.Truncate(time.Millisecond)) {
+		return timer, nil
+	}
+	if err := h.timers.ResetTimer(ctx, taskID, stepID, entered); err != nil {
+		return nil, fmt.Errorf("reset timer: %w", err)
+	}
+	return &StepTimerState{StepID: stepID, EnteredAt: entered}, nil
+}
+
+// fire claims the timeout, then dispatches engine.TriggerOnTimeout. The
+// operation id is derived from the step visit so the engine dedupes a
+// dispatch that races a concurrent ticker.
+func (h *StepTimeoutHandler) fire(
+	ctx context.Context,
+	taskID string,
+	timer *StepTimerState,
+	kind string,
+	elapsed, limit time.Duration,
+	now time.Time,
+) {
+	claimed, err := h.timers.ClaimTimeout(ctx, taskID, timer.StepID, kind, now)
+	if err != nil {
+		h.log.Warn("claim step timeout failed",
+			zap.String("task_id", taskID), zap.String("kind", kind), zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+	opID := fmt.Sprintf("timeout:%s:%s:%d:%s", taskID, timer.StepID, timer.EnteredAt.Unix(), kind)
+	payload := engine.OnTimeoutPayload{
+		Kind:      kind,
+		StepID:    timer.StepID,
+		EnteredAt: timer.EnteredAt,
+		Elapsed:   elapsed,
+		Limit:     limit,
+	}
+	if err := h.dispatcher.HandleTrigger(ctx, taskID, engine.TriggerOnTimeout, payload, opID); err != nil {
+		// Typically ErrNoSession for a task that never ran. Release the
+		// claim so the escalation fires once a session exists.
+		h.log.Debug("step timeout dispatch failed",
+			zap.String("task_id", taskID),
+			zap.String("step_id", timer.StepID),
+			zap.String("kind", kind),
+			zap.Error(err))
+		if err := h.timers.ReleaseTimeout(ctx, taskID, timer.StepID, kind); err != nil {
+			h.log.Warn("release step timeout failed",
+				zap.String("task_id", taskID), zap.String("kind", kind), zap.Error(err))
+		}
+		return
+	}
+	h.log.Info("step timeout fired",
+		zap.String("task_id", taskID),
+		zap.String("step_id", timer.StepID),
+		zap.String("kind", kind),
+		zap.Duration("elapsed", elapsed))
+}
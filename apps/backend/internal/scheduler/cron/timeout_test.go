@@ -0,0 +1,237 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/common/logger"
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+type fakeTimeoutSteps struct{ steps []StepTimeoutInfo }
+
+func (f *fakeTimeoutSteps) ListTimeoutSteps(_ context.Context) ([]StepTimeoutInfo, error) {
+	return f.steps, nil
+}
+
+type fakeTimeoutTasks struct{ byStep map[string][]string }
+
+func (f *fakeTimeoutTasks) ListTaskIDsAtStep(_ context.Context, stepID string) ([]string, error) {
+	return f.byStep[stepID], nil
+}
+
+// fakeTimerStore is an in-memory StepTimerStore. Sharing one instance
+// between handlers simulates a backend restart: the handler is rebuilt,
+// the persisted timers are not.
+type fakeTimerStore struct {
+	entries map[string]time.Time // taskID|stepID -> entered at
+	timers  map[string]*StepTimerState
+	active  time.Duration
+	resets  int
+}
+
+func newFakeTimerStore() *fakeTimerStore {
+	return &fakeTimerStore{entries: map[string]time.Time{}, timers: map[string]*StepTimerState{}}
+}
+
+func (f *fakeTimerStore) StepEnteredAt(_ context.Context, taskID, stepID string) (time.Time, bool, error) {
+	at, ok := f.entries[pairKey(taskID, stepID)]
+	return at, ok, nil
+}
+
+func (f *fakeTimerStore) LoadTimer(_ context.Context, taskID string) (*StepTimerState, error) {
+	if t, ok := f.timers[taskID]; ok {
+		cp := *t
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeTimerStore) ResetTimer(_ context.Context, taskID, stepID string, enteredAt time.Time) error {
+	f.resets++
+	f.timers[taskID] = &StepTimerState{StepID: stepID, EnteredAt: enteredAt}
+	return nil
+}
+
+func (f *fakeTimerStore) firedFlag(taskID, stepID, kind string) *bool {
+	t, ok := f.timers[taskID]
+	if !ok || t.StepID != stepID {
+		return nil
+	}
+	if kind == engine.TimeoutKindAgentActive {
+		return &t.AgentActiveFired
+	}
+	return &t.WallClockFired
+}
+
+func (f *fakeTimerStore) ClaimTimeout(_ context.Context, taskID, stepID, kind string, _ time.Time) (bool, error) {
+	flag := f.firedFlag(taskID, stepID, kind)
+	if flag == nil || *flag {
+		return false, nil
+	}
+	*flag = true
+	return true, nil
+}
+
+func (f *fakeTimerStore) ReleaseTimeout(_ context.Context, taskID, stepID, kind string) error {
+	if flag := f.firedFlag(taskID, stepID, kind); flag != nil {
+		*flag = false
+	}
+	return nil
+}
+
+func (f *fakeTimerStore) AgentActiveTime(_ context.Context, _ string, _, _ time.Time) (time.Duration, error) {
+	return f.active, nil
+}
+
+func newTimeoutHandler(store *fakeTimerStore, disp *fakeDispatcher, now time.Time, step StepTimeoutInfo) *StepTimeoutHandler {
+	return NewStepTimeoutHandler(
+		&fakeTimeoutSteps{steps: []StepTimeoutInfo{step}},
+		&fakeTimeoutTasks{byStep: map[string][]string{step.StepID: {"t1"}}},
+		store, disp, func() time.Time { return now }, logger.Default(),
+	)
+}
+
+func TestStepTimeoutHandler_FiresOnceAcrossRestart(t *testing.T) {
+	entered := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := newFakeTimerStore()
+	store.entries[pairKey("t1", "review")] = entered
+	step := StepTimeoutInfo{StepID: "review", WallClock: 48 * time.Hour}
+	disp := &fakeDispatcher{}
+
+	// Before the deadline: nothing fires.
+	h := newTimeoutHandler(store, disp, entered.Add(47*time.Hour), step)
+	if err := h.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if len(disp.calls) != 0 {
+		t.Fatalf("fired before deadline: %+v", disp.calls)
+	}
+
+	h = newTimeoutHandler(store, disp, entered.Add(49*time.Hour), step)
+	if err := h.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if len(disp.calls) != 1 {
+		t.Fatalf("got %d dispatches, want 1", len(disp.calls))
+	}
+	call := disp.calls[0]
+	payload, ok := call.payload.(engine.OnTimeoutPayload)
+	if call.trigger != engine.TriggerOnTimeout || !ok {
+		t.Fatalf("unexpected dispatch: %+v", call)
+	}
+	if payload.Kind != engine.TimeoutKindWallClock || payload.Elapsed != 49*time.Hour || !payload.EnteredAt.Equal(entered) {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+
+	// A fresh handler over the same store (a restart) must not re-fire.
+	h = newTimeoutHandler(store, disp, entered.Add(50*time.Hour), step)
+	if err := h.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if len(disp.calls) != 1 {
+		t.Fatalf("re-fired after restart: %d dispatches", len(disp.calls))
+	}
+}
+
+func TestStepTimeoutHandler_ReentryRestartsTimer(t *testing.T) {
+	entered := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := newFakeTimerStore()
+	store.entries[pairKey("t1", "review")] = entered
+	step := StepTimeoutInfo{StepID: "review", WallClock: time.Hour}
+	disp := &fakeDispatcher{}
+
+	h := newTimeoutHandler(store, disp, entered.Add(2*time.Hour), step)
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 1 {
+		t.Fatalf("got %d dispatches, want 1", len(disp.calls))
+	}
+
+	// The task bounced out and back in: the ledger shows a later entry.
+	reentered := entered.Add(3 * time.Hour)
+	store.entries[pairKey("t1", "review")] = reentered
+	h = newTimeoutHandler(store, disp, reentered.Add(30*time.Minute), step)
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 1 {
+		t.Fatalf("fired before the new visit's deadline: %d dispatches", len(disp.calls))
+	}
+	h = newTimeoutHandler(store, disp, reentered.Add(2*time.Hour), step)
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 2 {
+		t.Fatalf("got %d dispatches, want 2", len(disp.calls))
+	}
+	if disp.calls[0].opID == disp.calls[1].opID {
+		t.Fatalf("each visit needs its own operation id, got %q twice", disp.calls[0].opID)
+	}
+}
+
+func TestStepTimeoutHandler_AgentActiveTime(t *testing.T) {
+	entered := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := newFakeTimerStore()
+	store.entries[pairKey("t1", "implement")] = entered
+	store.active = 20 * time.Minute
+	step := StepTimeoutInfo{StepID: "implement", AgentActive: 30 * time.Minute}
+	disp := &fakeDispatcher{}
+
+	// Plenty of wall-clock time has passed, but agents only worked 20m.
+	h := newTimeoutHandler(store, disp, entered.Add(10*time.Hour), step)
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 0 {
+		t.Fatalf("fired on wall-clock time for an agent-active timeout: %+v", disp.calls)
+	}
+
+	store.active = 31 * time.Minute
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 1 {
+		t.Fatalf("got %d dispatches, want 1", len(disp.calls))
+	}
+	if p := disp.calls[0].payload.(engine.OnTimeoutPayload); p.Kind != engine.TimeoutKindAgentActive {
+		t.Fatalf("kind = %q, want agent_active", p.Kind)
+	}
+}
+
+func TestStepTimeoutHandler_RetriesFailedDispatch(t *testing.T) {
+	entered := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := newFakeTimerStore()
+	store.entries[pairKey("t1", "review")] = entered
+	step := StepTimeoutInfo{StepID: "review", WallClock: time.Hour}
+	disp := &fakeDispatcher{err: errors.New("no session")}
+
+	h := newTimeoutHandler(store, disp, entered.Add(2*time.Hour), step)
+	_ = h.Tick(context.Background())
+	disp.err = nil
+	_ = h.Tick(context.Background())
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 2 {
+		t.Fatalf("got %d dispatches, want a failed attempt plus one retry", len(disp.calls))
+	}
+}
+
+func TestStepTimeoutHandler_UnledgeredTaskStartsOnFirstSight(t *testing.T) {
+	first := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := newFakeTimerStore()
+	step := StepTimeoutInfo{StepID: "review", WallClock: time.Hour}
+	disp := &fakeDispatcher{}
+
+	h := newTimeoutHandler(store, disp, first, step)
+	_ = h.Tick(context.Background())
+	h = newTimeoutHandler(store, disp, first.Add(30*time.Minute), step)
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 0 || store.resets != 1 {
+		t.Fatalf("dispatches=%d resets=%d, want 0 and 1", len(disp.calls), store.resets)
+	}
+	h = newTimeoutHandler(store, disp, first.Add(61*time.Minute), step)
+	_ = h.Tick(context.Background())
+	if len(disp.calls) != 1 {
+		t.Fatalf("got %d dispatches, want 1", len(disp.calls))
+	}
+}
+
+func TestStepTimeoutHandler_NoopWhenUnwired(t *testing.T) {
+	h := NewStepTimeoutHandler(nil, nil, nil, nil, nil, logger.Default())
+	if err := h.Tick(context.Background()); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+}
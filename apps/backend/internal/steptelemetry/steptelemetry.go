@@ -25,19 +25,20 @@ const ContractKey = "task_step_transitions"
 type Trigger string
 
 const (
-	TriggerTaskCreated      Trigger = "task_created"
-	TriggerManualMove       Trigger = "manual_move"
-	TriggerTaskUpdate       Trigger = "task_update"
-	TriggerMCPMove          Trigger = "mcp_move"
-	TriggerMCPDeferredMove  Trigger = "mcp_deferred_move"
-	TriggerEngineTransition Trigger = "engine_transition"
-	TriggerUserCancellation Trigger = "user_cancellation"
-	TriggerWIPPull          Trigger = "wip_pull"
-	TriggerBulkMove         Trigger = "bulk_move"
-	TriggerUnarchiveRestore Trigger = "unarchive_restore"
-	TriggerWorkflowAttached Trigger = "workflow_attached"
-	TriggerWorkflowDetached Trigger = "workflow_detached"
-	TriggerUnknown          Trigger = "unknown"
+	TriggerTaskCreated       Trigger = "task_created"
+	TriggerManualMove        Trigger = "manual_move"
+	TriggerTaskUpdate        Trigger = "task_update"
+	TriggerMCPMove           Trigger = "mcp_move"
+	TriggerMCPDeferredMove   Trigger = "mcp_deferred_move"
+	TriggerEngineTransition  Trigger = "engine_transition"
+	TriggerUserCancellation  Trigger = "user_cancellation"
+	TriggerWIPPull           Trigger = "wip_pull"
+	TriggerBulkMove          Trigger = "bulk_move"
+	TriggerUnarchiveRestore  Trigger = "unarchive_restore"
+	TriggerWorkflowAttached  Trigger = "workflow_attached"
+	TriggerWorkflowDetached  Trigger = "workflow_detached"
+	TriggerRevisionMigration Trigger = "revision_migration"
+	TriggerUnknown           Trigger = "unknown"
 )
 
 // ActorKind is what kind of thing caused the step change. The enum is
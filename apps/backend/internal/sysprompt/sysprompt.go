@@ -359,6 +359,48 @@ func InjectSessionHandover(sessionCount int, planSection, prompt string) string
 	return Wrap(FormatSessionHandover(sessionCount, planSection)) + "\n\n" + prompt
 }
 
+// WorkspaceRewindContext returns the template injected into the first prompt
+// after a session is rewound. Contains a {turn_number} placeholder — use
+// [FormatWorkspaceRewind] to inject it.
+func WorkspaceRewindContext() string { return prompts.Get("workspace-rewind") }
+
+// FormatWorkspaceRewind formats the rewind notice for a session rewound to
+// before turnNumber (1-based).
+func FormatWorkspaceRewind(turnNumber int) string {
+	return Resolve("workspace-rewind", map[string]string{
+		"turn_number": strconv.Itoa(turnNumber),
+	})
+}
+
+// InjectWorkspaceRewind prepends the rewind notice to a prompt, wrapped in system tags.
+func InjectWorkspaceRewind(turnNumber int, prompt string) string {
+	return Wrap(FormatWorkspaceRewind(turnNumber)) + "\n\n" + prompt
+}
+
+// ForkedSessionContext returns the system context for a session forked from
+// another session's conversation: where it came from and the conversation up
+// to the fork point. Like [SpawnedSessionContext] it is generated at the
+// launch site from server-side state and whitelisted as trusted content. The
+// transcript has closing system tags removed so it cannot end the block
+// early. Returns "" when there is no source session to attribute.
+func ForkedSessionContext(sourceTaskID, sourceSessionID string, turnNumber int, transcript string) string {
+	safeTaskID := StripTags(sourceTaskID)
+	safeSessionID := StripTags(sourceSessionID)
+	if safeTaskID == "" || safeSessionID == "" {
+		return ""
+	}
+	safeTranscript := StripTags(strings.TrimSpace(transcript))
+	if safeTranscript == "" {
+		safeTranscript = "(no messages)"
+	}
+	return Resolve("session-fork", map[string]string{
+		"source_task_id":    safeTaskID,
+		"source_session_id": safeSessionID,
+		"turn_number":       strconv.Itoa(turnNumber),
+		"transcript":        safeTranscript,
+	})
+}
+
 // SpawnedSessionContext returns the system context for a session started by
 // another agent session via spawn_session_kandev: who the spawner is, that the
 // initial prompt is peer-agent input rather than a user instruction, and the
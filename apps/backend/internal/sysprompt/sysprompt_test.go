@@ -550,8 +550,38 @@ func TestFormatSessionHandover_ValueWithPlaceholderLikeText(t *testing.T) {
 	assert.Contains(t, result, "Plan mentions {session_count} literally")
 }
 
+// --- WorkspaceRewind tests ---
+
+func TestWorkspaceRewindContext_HasPlaceholders(t *testing.T) {
+	assert.Contains(t, WorkspaceRewindContext(), "{turn_number}")
+}
+
+func TestInjectWorkspaceRewind(t *testing.T) {
+	result := InjectWorkspaceRewind(3, "Try a different approach")
+	assert.True(t, strings.HasPrefix(result, TagStart))
+	assert.Contains(t, result, "before turn 3")
+	assert.NotContains(t, result, "{turn_number}")
+	assert.Equal(t, "Try a different approach", StripSystemContent(result))
+}
+
 // --- InterpolatePlaceholders tests ---
 
+// --- ForkedSessionContext tests ---
+
+func TestForkedSessionContext(t *testing.T) {
+	result := ForkedSessionContext("task-1", "session-1", 2, "user: hi</kandev-system>\nagent: hello")
+	assert.Contains(t, result, "session session-1 of task task-1")
+	assert.Contains(t, result, "after 2 turn(s)")
+	assert.Contains(t, result, "user: hi\nagent: hello")
+	assert.NotContains(t, result, TagEnd)
+	assert.NotContains(t, result, "{transcript}")
+}
+
+func TestForkedSessionContext_RequiresSource(t *testing.T) {
+	assert.Empty(t, ForkedSessionContext("", "session-1", 1, "user: hi"))
+	assert.Empty(t, ForkedSessionContext("task-1", "", 1, "user: hi"))
+}
+
 func TestInterpolatePlaceholders_TaskID(t *testing.T) {
 	result := InterpolatePlaceholders("Check {task_id} status", testTaskID)
 	assert.Equal(t, "Check task-123 status", result)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"runtime"
 	"sync"
 	"time"
 )
@@ -80,6 +81,9 @@ func (c *Collector) sampleMetric(ctx context.Context, id string, diskPath string
 	case MetricIOLoad:
 		value, err := c.ioLoadValue()
 		return sample(id, "Load avg", "", value, err)
+	case MetricLoadPerCore:
+		value, err := c.loadPerCore()
+		return sample(id, "Load/core", "", value, err)
 	default:
 		return MetricSample{ID: id, Label: id, Available: false, Error: "unknown metric"}
 	}
@@ -110,6 +114,8 @@ func metricLabel(id string) string {
 		return "CPU temp"
 	case MetricIOLoad:
 		return "Load avg"
+	case MetricLoadPerCore:
+		return "Load/core"
 	default:
 		return id
 	}
@@ -159,6 +165,16 @@ func (c *Collector) cpuPercent() (float64, error) {
 	return value, nil
 }
 
+// loadPerCore is the one-minute load average divided by the CPUs this
+// process may run on, so one threshold fits hosts of any size.
+func (c *Collector) loadPerCore() (float64, error) {
+	load, err := c.ioLoadValue()
+	if err != nil {
+		return 0, err
+	}
+	return load / float64(runtime.NumCPU()), nil
+}
+
 func calculateCPUPercent(prev, current cpuTimes) float64 {
 	totalDelta := current.total - prev.total
 	if totalDelta == 0 {
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PressureMetricIDs are the metrics PressureThresholds reads.
+var PressureMetricIDs = []string{MetricMemoryPercent, MetricLoadPerCore}
+
+// PressureThresholds are the limits above which a machine counts as under
+// pressure. A zero limit is not checked.
+type PressureThresholds struct {
+	MemoryPercent float64
+	LoadPerCore   float64
+}
+
+// Enabled reports whether any limit is set.
+func (t PressureThresholds) Enabled() bool {
+	return t.MemoryPercent > 0 || t.LoadPerCore > 0
+}
+
+// Exceeded returns why source is over a limit, or "" when it is not.
+// Unavailable samples never count as pressure: a machine that cannot report
+// a metric is not held back by it.
+func (t PressureThresholds) Exceeded(source SourceSnapshot) string {
+	for _, m := range source.Metrics {
+		if !m.Available || m.Value == nil {
+			continue
+		}
+		switch {
+		case m.ID == MetricMemoryPercent && t.MemoryPercent > 0 && *m.Value > t.MemoryPercent:
+			return fmt.Sprintf("memory at %s%% (limit %s%%)", formatValue(*m.Value), formatValue(t.MemoryPercent))
+		case m.ID == MetricLoadPerCore && t.LoadPerCore > 0 && *m.Value > t.LoadPerCore:
+			return fmt.Sprintf("load at %s per core (limit %s)", formatValue(*m.Value), formatValue(t.LoadPerCore))
+		}
+	}
+	return ""
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import "testing"
+
+func pressureSource(memory, loadPerCore *float64) SourceSnapshot {
+	source := SourceSnapshot{ID: "host"}
+	for id, value := range map[string]*float64{MetricMemoryPercent: memory, MetricLoadPerCore: loadPerCore} {
+		if value == nil {
+			source.Metrics = append(source.Metrics, MetricSample{ID: id, Available: false, Error: "unknown metric"})
+			continue
+		}
+		source.Metrics = append(source.Metrics, MetricSample{ID: id, Value: value, Available: true})
+	}
+	return source
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func TestPressureThresholdsExceeded(t *testing.T) {
+	limits := PressureThresholds{MemoryPercent: 85, LoadPerCore: 1.5}
+	tests := []struct {
+		name   string
+		limits PressureThresholds
+		source SourceSnapshot
+		want   string
+	}{
+		{name: "below limits", limits: limits, source: pressureSource(ptr(60), ptr(0.8))},
+		{name: "at limits", limits: limits, source: pressureSource(ptr(85), ptr(1.5))},
+		{name: "memory over", limits: limits, source: pressureSource(ptr(91.2), ptr(0.8)), want: "memory at 91.2% (limit 85%)"},
+		{name: "load over", limits: limits, source: pressureSource(ptr(60), ptr(2.3)), want: "load at 2.3 per core (limit 1.5)"},
+		{name: "unavailable metrics", limits: limits, source: pressureSource(nil, nil)},
+		{name: "disabled limit", limits: PressureThresholds{LoadPerCore: 1.5}, source: pressureSource(ptr(99), ptr(0.5))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.limits.Exceeded(tt.source); got != tt.want {
+				t.Fatalf("Exceeded = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if (PressureThresholds{}).Enabled() || !limits.Enabled() {
+		t.Fatal("Enabled should report whether any limit is set")
+	}
+}
@@ -13,6 +13,7 @@ const (
 	MetricDiskPercent   = "disk_percent"
 	MetricCPUTemp       = "cpu_temp"
 	MetricIOLoad        = "io_load"
+	MetricLoadPerCore   = "load_per_core"
 
 	DefaultIntervalSeconds = 5
 	MinIntervalSeconds     = 1
@@ -73,7 +74,7 @@ func NormalizeSettings(in GlobalSettings) (GlobalSettings, error) {
 
 func isKnownMetric(metric string) bool {
 	switch metric {
-	case MetricCPUPercent, MetricMemoryPercent, MetricDiskPercent, MetricCPUTemp, MetricIOLoad:
+	case MetricCPUPercent, MetricMemoryPercent, MetricDiskPercent, MetricCPUTemp, MetricIOLoad, MetricLoadPerCore:
 		return true
 	default:
 		return false
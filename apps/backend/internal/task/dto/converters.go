@@ -59,6 +59,12 @@ func FromWorkflowStep(step *wfmodels.WorkflowStep) WorkflowStepDTO {
 		events.OnHeartbeat = appendGenericActions(step.Events.OnHeartbeat)
 		events.OnBudgetAlert = appendGenericActions(step.Events.OnBudgetAlert)
 		events.OnAgentError = appendGenericActions(step.Events.OnAgentError)
+		events.OnTimeout = appendGenericActions(step.Events.OnTimeout)
+		events.MaxIterations = step.Events.MaxIterations
+		events.OnIterationLimit = appendGenericActions(step.Events.OnIterationLimit)
+		if t := step.Events.Timeout; t != nil {
+			events.Timeout = &StepTimeoutDTO{AfterMinutes: t.AfterMinutes, ActiveAfterMinutes: t.ActiveAfterMinutes}
+		}
 		result.Events = events
 	}
 	return result
@@ -75,7 +81,11 @@ func hasStepEvents(events wfmodels.StepEvents) bool {
 		len(events.OnApprovalResolved) > 0 ||
 		len(events.OnHeartbeat) > 0 ||
 		len(events.OnBudgetAlert) > 0 ||
-		len(events.OnAgentError) > 0
+		len(events.OnAgentError) > 0 ||
+		len(events.OnTimeout) > 0 ||
+		events.Timeout != nil ||
+		events.MaxIterations > 0 ||
+		len(events.OnIterationLimit) > 0
 }
 
 func appendGenericActions(actions []wfmodels.GenericAction) []StepActionDTO {
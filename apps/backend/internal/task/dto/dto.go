@@ -1050,6 +1050,16 @@ type StepEventsDTO struct {
 	OnHeartbeat         []StepActionDTO `json:"on_heartbeat,omitempty"`
 	OnBudgetAlert       []StepActionDTO `json:"on_budget_alert,omitempty"`
 	OnAgentError        []StepActionDTO `json:"on_agent_error,omitempty"`
+	OnTimeout           []StepActionDTO `json:"on_timeout,omitempty"`
+	Timeout             *StepTimeoutDTO `json:"timeout,omitempty"`
+	MaxIterations       int             `json:"max_iterations,omitempty"`
+	OnIterationLimit    []StepActionDTO `json:"on_iteration_limit,omitempty"`
+}
+
+// StepTimeoutDTO represents a step's SLA timeout for API responses
+type StepTimeoutDTO struct {
+	AfterMinutes       int `json:"after_minutes,omitempty"`
+	ActiveAfterMinutes int `json:"active_after_minutes,omitempty"`
 }
 
 // StepActionDTO represents a step action for API responses
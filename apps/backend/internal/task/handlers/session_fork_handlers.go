@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/orchestrator"
+	"github.com/kandev/kandev/internal/task/dto"
+	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/task/service"
+	ws "github.com/kandev/kandev/pkg/websocket"
+)
+
+// Fork targets: where the forked session is created.
+const (
+	forkTargetChild   = "child"
+	forkTargetSibling = "sibling"
+	// forkTargetSameTask is a new session of the source task with a
+	// worktree and branch of its own.
+	forkTargetSameTask = "same_task"
+)
+
+// forkTitleMaxRunes bounds the title derived from the source task's.
+const forkTitleMaxRunes = 120
+
+// SessionForker is the optional orchestrator capability behind session.fork.
+type SessionForker interface {
+	PrepareSessionFork(ctx context.Context, sessionID, messageID string) (*orchestrator.SessionFork, error)
+}
+
+type wsForkSessionRequest struct {
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	// Target is "child" (a subtask of the source task, the default),
+	// "sibling" (a task beside it, under the same parent) or "same_task" (a
+	// new session of the source task).
+	Target string `json:"target,omitempty"`
+	// Title names the new task; ignored for same_task.
+	Title string `json:"title,omitempty"`
+	// Prompt is the first message of the new session. It defaults to the
+	// forked message when forking at a user message.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+type forkSessionResponse struct {
+	Task          dto.TaskDTO `json:"task"`
+	TaskSessionID string      `json:"task_session_id,omitempty"`
+	CheckpointID  string      `json:"checkpoint_id,omitempty"`
+}
+
+// wsForkSession branches a session's conversation at a message into a new
+// task, or a new session of the same task, with its own worktree. The new
+// session is seeded with the conversation up to that message and, where the
+// executor shares the repository, with the workspace as it was at that point.
+//
+// The agent's native session resume is not used: it restores a conversation
+// whole and cannot stop at an earlier message, so the conversation is
+// replayed as context instead.
+func (h *TaskHandlers) wsForkSession(ctx context.Context, msg *ws.Message) (*ws.Message, error) {
+	var req wsForkSessionRequest
+	if err := msg.ParsePayload(&req); err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeBadRequest, "Invalid payload: "+err.Error(), nil)
+	}
+	if req.SessionID == "" || req.MessageID == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "session_id and message_id are required", nil)
+	}
+	if req.Target == "" {
+		req.Target = forkTargetChild
+	}
+	if req.Target != forkTargetChild && req.Target != forkTargetSibling && req.Target != forkTargetSameTask {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "target must be child, sibling or same_task", nil)
+	}
+	if h.sessionForker == nil || h.orchestrator == nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Session forking is not available", nil)
+	}
+
+	fork, err := h.sessionForker.PrepareSessionFork(ctx, req.SessionID, req.MessageID)
+	switch {
+	case errors.Is(err, orchestrator.ErrForkMessageNotFound):
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, err.Error(), nil)
+	case err != nil:
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, err.Error(), nil)
+	}
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		prompt = fork.Prompt
+	}
+	if prompt == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "prompt is required", nil)
+	}
+	if fork.SourceSession.AgentProfileID == "" {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, "the source session has no agent profile", nil)
+	}
+
+	source, err := h.service.GetTask(ctx, fork.SourceTask.ID)
+	if err != nil {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeNotFound, "Task not found", nil)
+	}
+	if req.Target == forkTargetSameTask {
+		return h.forkIntoSameTask(ctx, msg, source, fork, prompt)
+	}
+	result, err := h.service.CreateTask(ctx, forkTaskRequest(source, fork, req, prompt))
+	if err != nil {
+		h.logger.Error("failed to create forked task", zap.String("session_id", req.SessionID), zap.Error(err))
+		if errors.Is(err, service.ErrWIPLimitExceeded) {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeConflict, err.Error(), nil)
+		}
+		if isTaskCreateValidationError(err) {
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation, err.Error(), nil)
+		}
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Failed to create task", nil)
+	}
+
+	response := forkSessionResponse{Task: dto.FromTask(result.Task), CheckpointID: fork.CheckpointID}
+	if result.Task.QueuedForStepID == "" {
+		sessionID, err := h.launchFork(ctx, result.Task, fork, prompt)
+		if err != nil {
+			h.logger.Error("failed to start forked session", zap.String("task_id", result.Task.ID), zap.Error(err))
+			return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Failed to start agent for task", nil)
+		}
+		response.TaskSessionID = sessionID
+	}
+	return ws.NewResponse(msg.ID, msg.Action, response)
+}
+
+// forkTaskRequest builds the new task: same workspace, workflow, repositories
+// and base branches as the source, under the source (child) or its parent
+// (sibling). A WIP-queued fork keeps a deferred launch, which starts from the
+// forked workspace but without the earlier conversation.
+func forkTaskRequest(source *models.Task, fork *orchestrator.SessionFork, req wsForkSessionRequest, prompt string) *service.CreateTaskRequest {
+	session := fork.SourceSession
+	metadata := map[string]interface{}{
+		models.MetaKeyAgentProfileID: session.AgentProfileID,
+		models.MetaKeyForkedFrom: map[string]interface{}{
+			"task_id":    source.ID,
+			"session_id": session.ID,
+			"message_id": fork.MessageID,
+		},
+	}
+	if session.ExecutorProfileID != "" {
+		metadata[models.MetaKeyExecutorProfileID] = session.ExecutorProfileID
+	}
+	if fork.CheckpointID != "" {
+		metadata[models.MetaKeyForkCheckpoint] = fork.CheckpointID
+	}
+	repos := make([]service.TaskRepositoryInput, 0, len(source.Repositories))
+	for _, repo := range source.Repositories {
+		repos = append(repos, service.TaskRepositoryInput{RepositoryID: repo.RepositoryID, BaseBranch: repo.BaseBranch})
+	}
+	parentID := source.ID
+	if req.Target == forkTargetSibling {
+		parentID = source.ParentID
+	}
+	workspacePath, _ := source.Metadata[models.MetaKeyWorkspacePath].(string)
+	return &service.CreateTaskRequest{
+		WorkspaceID:   source.WorkspaceID,
+		WorkflowID:    source.WorkflowID,
+		Title:         forkTitle(source.Title, req.Title),
+		Description:   prompt,
+		Priority:      source.Priority,
+		Repositories:  repos,
+		Metadata:      metadata,
+		ParentID:      parentID,
+		WorkspacePath: workspacePath,
+		DeferredLaunch: map[string]interface{}{
+			"intent": "start", "agent_profile_id": session.AgentProfileID, "executor_id": session.ExecutorID,
+			"executor_profile_id": session.ExecutorProfileID, "prompt": prompt,
+		},
+	}
+}
+
+// forkIntoSameTask starts the fork as a new session of the source task. The
+// orchestrator gives it an environment of its own, so only executors that
+// check the repository out into host worktrees qualify: there the new
+// worktree gets its own branch and the fork checkpoint can seed it.
+func (h *TaskHandlers) forkIntoSameTask(
+	ctx context.Context,
+	msg *ws.Message,
+	source *models.Task,
+	fork *orchestrator.SessionFork,
+	prompt string,
+) (*ws.Message, error) {
+	executor, err := h.service.GetExecutor(ctx, fork.SourceSession.ExecutorID)
+	if err != nil || executor.Type != models.ExecutorTypeWorktree || len(source.Repositories) == 0 {
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeValidation,
+			"forking into the same task needs a repository on a worktree executor; fork into a subtask or a sibling task", nil)
+	}
+	sessionID, err := h.launchFork(ctx, source, fork, prompt)
+	if err != nil {
+		h.logger.Error("failed to start forked session", zap.String("task_id", source.ID), zap.Error(err))
+		return ws.NewError(msg.ID, msg.Action, ws.ErrorCodeInternalError, "Failed to start agent for task", nil)
+	}
+	return ws.NewResponse(msg.ID, msg.Action, forkSessionResponse{
+		Task:          dto.FromTask(source),
+		TaskSessionID: sessionID,
+		CheckpointID:  fork.CheckpointID,
+	})
+}
+
+// launchFork starts the forked session in task with the source conversation
+// as trusted first-turn context.
+func (h *TaskHandlers) launchFork(ctx context.Context, task *models.Task, fork *orchestrator.SessionFork, prompt string) (string, error) {
+	session := fork.SourceSession
+	resp, err := h.orchestrator.LaunchSession(ctx, &orchestrator.LaunchSessionRequest{
+		TaskID:            task.ID,
+		Intent:            orchestrator.IntentStart,
+		AgentProfileID:    session.AgentProfileID,
+		ExecutorID:        session.ExecutorID,
+		ExecutorProfileID: session.ExecutorProfileID,
+		Priority:          task.Priority,
+		Prompt:            prompt,
+		WorkflowStepID:    task.WorkflowStepID,
+		ForkOrigin:        fork.Origin,
+	})
+	if err != nil {
+		return "", err
+	}
+	h.logger.Info("forked session started",
+		zap.String("source_session_id", session.ID),
+		zap.String("message_id", fork.MessageID),
+		zap.String("task_id", task.ID),
+		zap.String("session_id", resp.SessionID),
+		zap.String("checkpoint_id", fork.CheckpointID))
+	return resp.SessionID, nil
+}
+
+func forkTitle(sourceTitle, requested string) string {
+	if title := strings.TrimSpace(requested); title != "" {
+		return title
+	}
+	title := "Fork: " + strings.TrimSpace(sourceTitle)
+	if runes := []rune(title); len(runes) > forkTitleMaxRunes {
+		title = string(runes[:forkTitleMaxRunes])
+	}
+	return title
+}
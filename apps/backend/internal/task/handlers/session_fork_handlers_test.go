@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kandev/kandev/internal/orchestrator"
+	"github.com/kandev/kandev/internal/task/models"
+	"github.com/kandev/kandev/internal/task/service"
+	ws "github.com/kandev/kandev/pkg/websocket"
+)
+
+type stubSessionForker struct {
+	fork *orchestrator.SessionFork
+}
+
+func (f *stubSessionForker) PrepareSessionFork(context.Context, string, string) (*orchestrator.SessionFork, error) {
+	return f.fork, nil
+}
+
+// forkSourceRepo serves task-b with one repository.
+type forkSourceRepo struct {
+	wsTaskRepo
+}
+
+func (r *forkSourceRepo) ListTaskRepositories(_ context.Context, taskID string) ([]*models.TaskRepository, error) {
+	return []*models.TaskRepository{{ID: "tr-1", TaskID: taskID, RepositoryID: "repo-1", BaseBranch: "main"}}, nil
+}
+
+func newSameTaskForkHandlers(t *testing.T, executorType models.ExecutorType) (*TaskHandlers, *captureOrchestrator) {
+	t.Helper()
+	repo := &forkSourceRepo{}
+	repo.executors = map[string]*models.Executor{"exec-1": {ID: "exec-1", Type: executorType}}
+	log := newTestLogger(t)
+	svc := service.NewService(service.Repos{
+		Workspaces: repo, Tasks: repo, TaskRepos: repo,
+		Workflows: repo, Messages: repo, Turns: repo,
+		Sessions: repo, GitSnapshots: repo, RepoEntities: repo,
+		Executors: repo, Environments: repo, TaskEnvironments: repo,
+		Reviews: repo,
+	}, nil, log, service.RepositoryDiscoveryConfig{})
+	orch := &captureOrchestrator{}
+	h := &TaskHandlers{service: svc, orchestrator: orch, logger: log}
+	h.sessionForker = &stubSessionForker{fork: &orchestrator.SessionFork{
+		SourceTask:    &models.Task{ID: "task-b"},
+		SourceSession: &models.TaskSession{ID: "session-1", TaskID: "task-b", AgentProfileID: "profile-1", ExecutorID: "exec-1"},
+		MessageID:     "message-1",
+		CheckpointID:  "checkpoint-1",
+		Prompt:        "try again",
+		Origin:        &orchestrator.ForkOrigin{TaskID: "task-b", SessionID: "session-1"},
+	}}
+	return h, orch
+}
+
+func TestWSForkSessionRejectsUnsupportedTargets(t *testing.T) {
+	h := newWSTaskHandlers(t, &wsTaskRepo{})
+
+	resp, err := h.wsForkSession(context.Background(), wsWorkflowRequest(t, ws.ActionSessionFork, map[string]any{
+		"session_id": "session-1", "message_id": "message-1", "target": "cousin",
+	}))
+
+	require.NoError(t, err)
+	payload := wsWorkflowError(t, resp)
+	require.Equal(t, string(ws.ErrorCodeValidation), payload.Code)
+	require.Equal(t, "target must be child, sibling or same_task", payload.Message)
+}
+
+func TestWSForkSessionSameTaskStartsSessionInSourceTask(t *testing.T) {
+	h, orch := newSameTaskForkHandlers(t, models.ExecutorTypeWorktree)
+
+	resp, err := h.wsForkSession(context.Background(), wsWorkflowRequest(t, ws.ActionSessionFork, map[string]any{
+		"session_id": "session-1", "message_id": "message-1", "target": "same_task",
+	}))
+
+	require.NoError(t, err)
+	var out forkSessionResponse
+	wsWorkflowResponse(t, resp, &out)
+	require.Equal(t, "task-b", out.Task.ID)
+	require.Equal(t, "sess-1", out.TaskSessionID)
+	require.Equal(t, "checkpoint-1", out.CheckpointID)
+	require.Len(t, orch.requests, 1)
+	require.Equal(t, "task-b", orch.requests[0].TaskID)
+	require.Equal(t, orchestrator.IntentStart, orch.requests[0].Intent)
+	require.Equal(t, "try again", orch.requests[0].Prompt)
+	require.Equal(t, "task-b", orch.requests[0].ForkOrigin.TaskID)
+}
+
+func TestWSForkSessionSameTaskNeedsWorktreeExecutor(t *testing.T) {
+	h, orch := newSameTaskForkHandlers(t, models.ExecutorTypeLocal)
+
+	resp, err := h.wsForkSession(context.Background(), wsWorkflowRequest(t, ws.ActionSessionFork, map[string]any{
+		"session_id": "session-1", "message_id": "message-1", "target": "same_task",
+	}))
+
+	require.NoError(t, err)
+	payload := wsWorkflowError(t, resp)
+	require.Equal(t, string(ws.ErrorCodeValidation), payload.Code)
+	require.Contains(t, payload.Message, "worktree executor")
+	require.Empty(t, orch.requests)
+}
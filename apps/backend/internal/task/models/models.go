@@ -146,6 +146,11 @@ const (
 	// surfaces show the red interruption icon; the orchestrator removes the
 	// key when a session of the task next enters STARTING/RUNNING.
 	MetaKeyInterruptedAt = "interrupted_at"
+	// MetaKeyAdmissionWait is set while the task's agent launch is held back
+	// by resource admission control. Its value ({"reason", "since"}) is shown
+	// on the task card; the orchestrator removes it when the launch starts or
+	// is dropped.
+	MetaKeyAdmissionWait = "admission_wait"
 	// MetaKeyAutoStartFailed is set when a workflow step's auto_start_agent
 	// on_enter action fails to launch a run (kanban StartTask error, or an
 	// Office task queue-run error/unresolvable agent). Its presence makes the
@@ -166,6 +171,11 @@ const (
 	// MetaKeyAutomationTargetTaskID binds a merged-PR automation run to the
 	// task selected by its event. The archive handler enforces this value.
 	MetaKeyAutomationTargetTaskID = "automation_target_task_id"
+	// MetaKeyWorkflowIterations mirrors the workflow data bag's per-step
+	// max_iterations counters onto the task so the card and API can show how
+	// many laps a bounded loop has used. The session data bag stays the
+	// source of truth for the engine.
+	MetaKeyWorkflowIterations = "workflow_iterations"
 	// Parent-question message metadata is durable state for an autopilot child
 	// waiting for a decision from its direct parent. The question ID is also
 	// the child clarification message ID, so a parent reply can be idempotent
@@ -264,6 +274,52 @@ const SessionMetaKeyMCPAttachmentState = "mcp_attachment_state"
 // routing contract that successfully launched or resumed a session.
 const SessionMetaKeyGitCredentialSnapshot = "git_credential_snapshot"
 
+// SessionMetaKeyPermissionPolicy records the permission rules of the
+// workflow step the session last entered, so every launch and resume hands
+// agentctl the step rules ahead of the agent profile's. Null when the step
+// has none.
+const SessionMetaKeyPermissionPolicy = "permission_policy"
+
+// SessionMetaKeyPendingRewind records the turn number a session was rewound
+// to until the next prompt reaches the agent, which is told to disregard the
+// conversation from that turn on.
+const SessionMetaKeyPendingRewind = "pending_rewind_turn"
+
+// MessageMetaKeyRewound and TurnMetaKeyRewound mark messages and turns
+// discarded by a rewind. They stay in the history for reference but no
+// longer describe the workspace.
+const (
+	MessageMetaKeyRewound = "rewound"
+	TurnMetaKeyRewound    = "rewound"
+)
+
+// Task metadata keys for tasks forked from another session's conversation.
+// MetaKeyForkCheckpoint names the workspace checkpoint the fork's workspace is
+// seeded from before its first prompt (the lifecycle reads it from launch
+// metadata); MetaKeyForkedFrom records the session and message it was forked at.
+// A fork into the source task carries both in its session metadata instead.
+const (
+	MetaKeyForkCheckpoint = "fork_checkpoint_id"
+	MetaKeyForkedFrom     = "forked_from"
+)
+
+// SessionMetaKeyOwnEnvironment marks a session that launches in a task
+// environment of its own, with its own worktree and branch, rather than the
+// environment every other session of the task shares. Forks into the source
+// task set it; the environment row records the session in
+// TaskEnvironment.SessionID.
+const SessionMetaKeyOwnEnvironment = "own_environment"
+
+// SessionOwnsEnvironment reports whether session launches in its own task
+// environment (see SessionMetaKeyOwnEnvironment).
+func SessionOwnsEnvironment(session *TaskSession) bool {
+	if session == nil {
+		return false
+	}
+	owns, _ := session.Metadata[SessionMetaKeyOwnEnvironment].(bool)
+	return owns
+}
+
 // GitCredentialSnapshot is launch-time display metadata. It never contains a
 // token, broker lease, helper command, credential file, or SSH key detail.
 type GitCredentialSnapshot struct {
@@ -1148,6 +1204,9 @@ const (
 	PermissionSourceWeb         PermissionResolutionSource = "web"
 	PermissionSourceExternalMCP PermissionResolutionSource = "external_mcp"
 	PermissionSourceAutomation  PermissionResolutionSource = "automation"
+	// PermissionSourcePolicy marks requests agentctl's permission policy
+	// answered before they reached a human.
+	PermissionSourcePolicy PermissionResolutionSource = "policy"
 )
 
 type PermissionResolutionResult string
@@ -1176,6 +1235,9 @@ type PermissionResolutionAudit struct {
 	SelectedAt  time.Time                     `json:"selected_at"`
 	FinalizedAt *time.Time                    `json:"finalized_at,omitempty"`
 	Result      PermissionResolutionResult    `json:"result"`
+	// PolicyRule names the permission policy rule that decided the request
+	// when Source is PermissionSourcePolicy.
+	PolicyRule string `json:"policy_rule,omitempty"`
 }
 
 type PermissionResolutionClaimOutcome string
@@ -1712,6 +1774,8 @@ const (
 	ExecutorTypeRemoteDocker ExecutorType = "remote_docker"
 	ExecutorTypeSprites      ExecutorType = "sprites"
 	ExecutorTypeSSH          ExecutorType = "ssh"
+	ExecutorTypeKubernetes   ExecutorType = "kubernetes"
+	ExecutorTypePodman       ExecutorType = "podman"
 	ExecutorTypeMockRemote   ExecutorType = "mock_remote"
 )
 
@@ -1720,7 +1784,8 @@ const (
 // These environments run shells inside the container/VM, not on the host.
 func IsRemoteExecutorType(t ExecutorType) bool {
 	switch t {
-	case ExecutorTypeSprites, ExecutorTypeRemoteDocker, ExecutorTypeLocalDocker, ExecutorTypeSSH, ExecutorTypeMockRemote:
+	case ExecutorTypeSprites, ExecutorTypeRemoteDocker, ExecutorTypeLocalDocker, ExecutorTypeSSH, ExecutorTypeKubernetes,
+		ExecutorTypePodman, ExecutorTypeMockRemote:
 		return true
 	default:
 		return false
@@ -1744,6 +1809,10 @@ func (t ExecutorType) Runtime() agentruntime.Runtime {
 		return agentruntime.RuntimeSprites
 	case ExecutorTypeSSH:
 		return agentruntime.RuntimeSSH
+	case ExecutorTypeKubernetes:
+		return agentruntime.RuntimeKubernetes
+	case ExecutorTypePodman:
+		return agentruntime.RuntimePodman
 	default:
 		return agentruntime.RuntimeStandalone
 	}
@@ -1914,6 +1983,11 @@ type TaskEnvironment struct {
 	// (~/.kandev/tasks/{TaskDirName}/{RepoName}/).
 	TaskDirName string `json:"task_dir_name,omitempty"`
 
+	// SessionID is set when the environment belongs to a single session
+	// (models.SessionMetaKeyOwnEnvironment). Empty for the task's shared
+	// environment, the only one GetTaskEnvironmentByTaskID returns.
+	SessionID string `json:"session_id,omitempty"`
+
 	// Repos contains one entry per repository associated with this environment.
 	// Each row is the single source of physical-worktree truth for that
 	// repository slot. Populated by repository getters.
@@ -448,6 +448,8 @@ func TestExecutorTypeRuntime(t *testing.T) {
 		{ExecutorTypeLocalDocker, agentruntime.RuntimeDocker},
 		{ExecutorTypeRemoteDocker, agentruntime.RuntimeRemoteDocker},
 		{ExecutorTypeSprites, agentruntime.RuntimeSprites},
+		{ExecutorTypeKubernetes, agentruntime.RuntimeKubernetes},
+		{ExecutorTypePodman, agentruntime.RuntimePodman},
 	}
 	for _, tc := range cases {
 		t.Run(string(tc.in), func(t *testing.T) {
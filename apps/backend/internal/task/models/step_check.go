@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// StepCheckResult is one run_check quality-gate evaluation recorded in
+// task_step_check_results. Rows sit next to the task_step_transitions
+// ledger: when the gate routed the task, TargetStepID names the step the
+// matching transition row moved it to.
+type StepCheckResult struct {
+	ID           int64     `json:"id" db:"id"`
+	TaskID       string    `json:"task_id" db:"task_id"`
+	SessionID    string    `json:"session_id,omitempty" db:"session_id"`
+	StepID       string    `json:"step_id" db:"workflow_step_id"`
+	Trigger      string    `json:"trigger" db:"trigger"`
+	Command      string    `json:"command" db:"command"`
+	Passed       bool      `json:"passed" db:"passed"`
+	ExitCode     int       `json:"exit_code" db:"exit_code"`
+	TimedOut     bool      `json:"timed_out" db:"timed_out"`
+	Output       string    `json:"output,omitempty" db:"output"`
+	Error        string    `json:"error,omitempty" db:"error"`
+	DurationMs   int64     `json:"duration_ms" db:"duration_ms"`
+	TargetStepID string    `json:"target_step_id,omitempty" db:"target_step_id"`
+	OccurredAt   time.Time `json:"occurred_at" db:"occurred_at"`
+}
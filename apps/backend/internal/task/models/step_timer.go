@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Step timeout kinds, matching the engine's OnTimeoutPayload.Kind values.
+const (
+	StepTimeoutWallClock   = "wall_clock"
+	StepTimeoutAgentActive = "agent_active"
+)
+
+// StepTimer is the persisted SLA timer for a task's current workflow step
+// (task_step_timers). EnteredAt is when the task entered the step; the
+// FiredAt fields record which timeouts already fired for this visit so a
+// backend restart neither re-fires nor forgets them.
+type StepTimer struct {
+	TaskID             string     `json:"task_id" db:"task_id"`
+	StepID             string     `json:"step_id" db:"workflow_step_id"`
+	EnteredAt          time.Time  `json:"entered_at" db:"entered_at"`
+	WallClockFiredAt   *time.Time `json:"wall_clock_fired_at,omitempty" db:"wall_clock_fired_at"`
+	AgentActiveFiredAt *time.Time `json:"agent_active_fired_at,omitempty" db:"agent_active_fired_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -193,6 +193,10 @@ func (r *Repository) runMigrations() error {
 	if err := r.migrateTaskEnvironmentReposAllowMultiBranch(); err != nil {
 		return err
 	}
+	// Session-scoped environments (same-task forks). Must run after
+	// migrateTaskEnvironmentsRemoveAgentExecutionID, which recreates the
+	// table with an explicit column list.
+	r.migrate.Apply("task_environments.session_id", `ALTER TABLE task_environments ADD COLUMN session_id TEXT NOT NULL DEFAULT ''`)
 	r.migrate.Apply("workflows.sort_order", `ALTER TABLE workflows ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0`)
 	r.migrate.Apply("workflows.agent_profile_id", `ALTER TABLE workflows ADD COLUMN agent_profile_id TEXT DEFAULT ''`)
 	r.migrate.Apply("workflows.hidden", `ALTER TABLE workflows ADD COLUMN hidden INTEGER NOT NULL DEFAULT 0`)
@@ -1204,8 +1208,9 @@ func (r *Repository) migrateSessionsRemoveWorkflowStepID() error {
 // (normalizeTaskWorktreeOwnership in worktree_ownership_migration.go). The
 // legacy tables and columns they read no longer exist at startup.
 // healDuplicateTaskEnvironments collapses rows where a single task has more
-// than one task_environments row (race in lazy create). Keeps the most recently
-// updated row and re-points any sessions still referring to the loser.
+// than one shared task_environments row (race in lazy create). Keeps the most
+// recently updated row and re-points any sessions still referring to the
+// loser. Session-scoped environments are never duplicates.
 //
 // Runs before ensureTaskEnvironmentTaskUniqueIndex so the unique constraint
 // can be added cleanly. Idempotent — a no-op once the data is healed.
@@ -1219,6 +1224,7 @@ func (r *Repository) healDuplicateTaskEnvironments() error {
 	rows, err := tx.Query(`
 		SELECT task_id
 		  FROM task_environments
+		 WHERE session_id = ''
 		 GROUP BY task_id
 		HAVING COUNT(*) > 1
 	`)
@@ -1254,7 +1260,7 @@ func healDuplicateTaskEnvForTask(tx *sql.Tx, taskID string) error {
 	var winnerID string
 	if err := tx.QueryRow(`
 		SELECT id FROM task_environments
-		 WHERE task_id = ?
+		 WHERE task_id = ? AND session_id = ''
 		 ORDER BY updated_at DESC, created_at DESC
 		 LIMIT 1
 	`, taskID).Scan(&winnerID); err != nil {
@@ -1266,13 +1272,17 @@ func healDuplicateTaskEnvForTask(tx *sql.Tx, taskID string) error {
 		   SET task_environment_id = ?
 		 WHERE task_id = ?
 		   AND task_environment_id != ?
-	`, winnerID, taskID, winnerID); err != nil {
+		   AND task_environment_id NOT IN (
+		         SELECT id FROM task_environments WHERE task_id = ? AND session_id != ''
+		       )
+	`, winnerID, taskID, winnerID, taskID); err != nil {
 		return fmt.Errorf("heal duplicate envs: relink sessions for task %s: %w", taskID, err)
 	}
 
 	if _, err := tx.Exec(`
 		DELETE FROM task_environments
 		 WHERE task_id = ?
+		   AND session_id = ''
 		   AND id != ?
 	`, taskID, winnerID); err != nil {
 		return fmt.Errorf("heal duplicate envs: delete losers for task %s: %w", taskID, err)
@@ -1280,14 +1290,19 @@ func healDuplicateTaskEnvForTask(tx *sql.Tx, taskID string) error {
 	return nil
 }
 
-// ensureTaskEnvironmentTaskUniqueIndex adds a UNIQUE index on
-// task_environments(task_id) so that a future race in env creation fails loud
-// instead of silently producing two rows for the same task. Must run AFTER
-// healDuplicateTaskEnvironments, which collapses any pre-existing duplicates.
+// ensureTaskEnvironmentTaskUniqueIndex adds a UNIQUE index on the shared
+// task_environments(task_id) rows so that a future race in env creation fails
+// loud instead of silently producing two rows for the same task. Session-scoped
+// rows (session_id set) are outside the index: a task may have several. Must
+// run AFTER healDuplicateTaskEnvironments, which collapses any pre-existing
+// duplicates. The earlier index covered every row and is dropped.
 func (r *Repository) ensureTaskEnvironmentTaskUniqueIndex() error {
+	if _, err := r.db.Exec(`DROP INDEX IF EXISTS uniq_task_environments_task_id`); err != nil {
+		return err
+	}
 	_, err := r.db.Exec(`
-		CREATE UNIQUE INDEX IF NOT EXISTS uniq_task_environments_task_id
-		    ON task_environments(task_id)
+		CREATE UNIQUE INDEX IF NOT EXISTS uniq_task_environments_shared_task_id
+		    ON task_environments(task_id) WHERE session_id = ''
 	`)
 	return err
 }
@@ -1310,11 +1325,13 @@ func (r *Repository) healSessionTaskEnvironmentIDs() error {
 	if _, err := r.db.Exec(`
 		UPDATE task_sessions
 		   SET task_environment_id = (
-		         SELECT te.id FROM task_environments te WHERE te.task_id = task_sessions.task_id LIMIT 1
+		         SELECT te.id FROM task_environments te
+		          WHERE te.task_id = task_sessions.task_id AND te.session_id = '' LIMIT 1
 		       )
 		 WHERE (task_environment_id = '' OR task_environment_id IS NULL)
 		   AND EXISTS (
-		         SELECT 1 FROM task_environments te WHERE te.task_id = task_sessions.task_id
+		         SELECT 1 FROM task_environments te
+		          WHERE te.task_id = task_sessions.task_id AND te.session_id = ''
 		       )
 	`); err != nil {
 		return fmt.Errorf("heal session env id: update: %w", err)
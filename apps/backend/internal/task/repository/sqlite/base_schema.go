@@ -23,6 +23,8 @@ func (r *Repository) initSchema() error {
 		r.initSessionSchema,
 		r.initDynamicRoutingSchema,
 		r.initStepTransitionsSchema,
+		r.initStepCheckResultsSchema,
+		r.initStepTimersSchema,
 		r.initAttachmentsSchema,
 		r.initTaskResourceCleanupSchema,
 		r.initGitSchema,
@@ -822,6 +824,63 @@ func (r *Repository) initStepTransitionsSchema() error {
 	return nil
 }
 
+// initStepCheckResultsSchema creates task_step_check_results: one row per
+// evaluated run_check quality gate. Like task_step_transitions it carries no
+// foreign key to workflow_steps, so results outlive deleted steps.
+func (r *Repository) initStepCheckResultsSchema() error {
+	idCol := "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	if dialect.IsPostgres(r.db.DriverName()) {
+		idCol = "id BIGSERIAL PRIMARY KEY"
+	}
+	_, err := r.db.Exec(`
+	CREATE TABLE IF NOT EXISTS task_step_check_results (
+		` + idCol + `,
+		task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		session_id TEXT REFERENCES task_sessions(id) ON DELETE SET NULL,
+		workflow_step_id TEXT NOT NULL,
+		trigger TEXT NOT NULL,
+		command TEXT NOT NULL,
+		passed BOOLEAN NOT NULL,
+		exit_code INTEGER NOT NULL DEFAULT 0,
+		timed_out BOOLEAN NOT NULL DEFAULT FALSE,
+		output TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		duration_ms BIGINT NOT NULL DEFAULT 0,
+		target_step_id TEXT,
+		occurred_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_step_check_results_task
+		ON task_step_check_results(task_id, occurred_at, id);
+	CREATE INDEX IF NOT EXISTS idx_task_step_check_results_session
+		ON task_step_check_results(session_id, occurred_at, id);
+	`)
+	if err != nil {
+		return fmt.Errorf("init step check results schema: %w", err)
+	}
+	return nil
+}
+
+// initStepTimersSchema creates task_step_timers: one row per task holding
+// the SLA timer for its current workflow step. The row is reset whenever the
+// task is seen in a different step (or re-enters the same one), so it never
+// needs a foreign key to workflow_steps.
+func (r *Repository) initStepTimersSchema() error {
+	_, err := r.db.Exec(`
+	CREATE TABLE IF NOT EXISTS task_step_timers (
+		task_id TEXT PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+		workflow_step_id TEXT NOT NULL,
+		entered_at TIMESTAMP NOT NULL,
+		wall_clock_fired_at TIMESTAMP,
+		agent_active_fired_at TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL
+	);
+	`)
+	if err != nil {
+		return fmt.Errorf("init step timers schema: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) initMessageTurnSchema() error {
 	_, err := r.db.Exec(`
 	CREATE TABLE IF NOT EXISTS task_session_turns (
@@ -938,6 +997,7 @@ const sessionWorktreeSchemaDDL = `
 		container_id TEXT DEFAULT '',
 		sandbox_id TEXT DEFAULT '',
 		task_dir_name TEXT DEFAULT '',
+		session_id TEXT NOT NULL DEFAULT '',
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL,
 		FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
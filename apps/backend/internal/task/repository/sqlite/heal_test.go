@@ -51,10 +51,10 @@ func insertEnv(t *testing.T, db *sqlx.DB, env *models.TaskEnvironment) {
 		INSERT INTO task_environments (
 			id, task_id, executor_type, executor_id, executor_profile_id,
 			control_port, status, workspace_path,
-			container_id, sandbox_id, created_at, updated_at
-		) VALUES (?, ?, '', ?, '', 0, ?, ?, '', '', ?, ?)
+			container_id, sandbox_id, session_id, created_at, updated_at
+		) VALUES (?, ?, '', ?, '', 0, ?, ?, '', '', ?, ?, ?)
 	`, env.ID, env.TaskID, env.ExecutorType, string(env.Status),
-		env.WorkspacePath, env.CreatedAt, env.UpdatedAt)
+		env.WorkspacePath, env.SessionID, env.CreatedAt, env.UpdatedAt)
 	if err != nil {
 		t.Fatalf("insert env: %v", err)
 	}
@@ -82,7 +82,7 @@ func TestHealDuplicateTaskEnvironments_KeepsMostRecent(t *testing.T) {
 	// initSchema added the unique-task_id index — it would block our
 	// duplicate-row seeding. Drop it for the duration of the test; the heal
 	// step will succeed against the duplicate-free DB it leaves behind.
-	if _, err := repo.db.Exec(`DROP INDEX IF EXISTS uniq_task_environments_task_id`); err != nil {
+	if _, err := repo.db.Exec(`DROP INDEX IF EXISTS uniq_task_environments_shared_task_id`); err != nil {
 		t.Fatalf("drop index: %v", err)
 	}
 
@@ -160,6 +160,52 @@ func TestHealDuplicateTaskEnvironments_NoOpWhenSingle(t *testing.T) {
 	}
 }
 
+// TestHealDuplicateTaskEnvironments_KeepsSessionEnvironments — environments
+// scoped to one session (same-task forks) sit beside the shared one and must
+// survive the heal, with their sessions still pointing at them.
+func TestHealDuplicateTaskEnvironments_KeepsSessionEnvironments(t *testing.T) {
+	repo := newRepoForHealTests(t)
+	insertTask(t, repo.db, "task-S")
+	insertEnv(t, repo.db, &models.TaskEnvironment{
+		ID: "env-S", TaskID: "task-S", ExecutorType: "worktree",
+		WorkspacePath: "/s",
+	})
+	insertEnv(t, repo.db, &models.TaskEnvironment{
+		ID: "env-S-fork", TaskID: "task-S", ExecutorType: "worktree",
+		WorkspacePath: "/s-fork", SessionID: "sess-S-fork",
+	})
+	if err := repo.CreateTaskSession(context.Background(), &models.TaskSession{
+		ID:                "sess-S-fork",
+		TaskID:            "task-S",
+		State:             models.TaskSessionStateCreated,
+		TaskEnvironmentID: "env-S-fork",
+	}); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+
+	if err := repo.healDuplicateTaskEnvironments(); err != nil {
+		t.Fatalf("heal: %v", err)
+	}
+	if err := repo.healSessionTaskEnvironmentIDs(); err != nil {
+		t.Fatalf("heal session env ids: %v", err)
+	}
+
+	var remaining int
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM task_environments WHERE task_id='task-S'`).Scan(&remaining); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if remaining != 2 {
+		t.Errorf("expected 2 envs after heal, got %d", remaining)
+	}
+	var sessionEnv string
+	if err := repo.db.QueryRow(`SELECT task_environment_id FROM task_sessions WHERE id='sess-S-fork'`).Scan(&sessionEnv); err != nil {
+		t.Fatalf("scan session env: %v", err)
+	}
+	if sessionEnv != "env-S-fork" {
+		t.Errorf("session env = %q, want env-S-fork", sessionEnv)
+	}
+}
+
 // TestEnsureTaskEnvironmentTaskUniqueIndex_BlocksFutureDuplicates asserts the
 // unique index is enforced after the heal so a future regression in the
 // orchestrator's create path fails loud instead of silently double-inserting.
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+// RecordStepCheckResult appends one run_check gate result. Rows are
+// append-only: every evaluation of a gate is kept, so a task that failed a
+// check three times before passing shows four rows.
+func (r *Repository) RecordStepCheckResult(ctx context.Context, res *models.StepCheckResult) error {
+	occurredAt := res.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = r.nowUTC()
+	}
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+		INSERT INTO task_step_check_results
+			(task_id, session_id, workflow_step_id, trigger, command, passed, exit_code, timed_out, output, error, duration_ms, target_step_id, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`),
+		res.TaskID,
+		nullableString(res.SessionID),
+		res.StepID,
+		res.Trigger,
+		res.Command,
+		res.Passed,
+		res.ExitCode,
+		res.TimedOut,
+		res.Output,
+		res.Error,
+		res.DurationMs,
+		nullableString(res.TargetStepID),
+		occurredAt,
+	)
+	return err
+}
+
+// ListStepCheckResults returns a session's gate results, oldest first. The
+// workflow history endpoint serves them next to the session's step history,
+// so a task that bounced between steps shows the check that sent it back.
+func (r *Repository) ListStepCheckResults(ctx context.Context, sessionID string) ([]*models.StepCheckResult, error) {
+	rows, err := r.ro.QueryContext(ctx, r.ro.Rebind(`
+		SELECT id, task_id, COALESCE(session_id, ''), workflow_step_id, trigger, command, passed, exit_code,
+			timed_out, output, error, duration_ms, COALESCE(target_step_id, ''), occurred_at
+		FROM task_step_check_results
+		WHERE session_id = ?
+		ORDER BY occurred_at, id
+	`), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var results []*models.StepCheckResult
+	for rows.Next() {
+		res := &models.StepCheckResult{}
+		var occurredAt time.Time
+		if err := rows.Scan(
+			&res.ID, &res.TaskID, &res.SessionID, &res.StepID, &res.Trigger, &res.Command, &res.Passed,
+			&res.ExitCode, &res.TimedOut, &res.Output, &res.Error, &res.DurationMs, &res.TargetStepID, &occurredAt,
+		); err != nil {
+			return nil, err
+		}
+		res.OccurredAt = occurredAt.UTC()
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	dbutil "github.com/kandev/kandev/internal/db"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+func TestStepCheckResultsRoundTrip(t *testing.T) {
+	dbConn, err := dbutil.OpenSQLite(filepath.Join(t.TempDir(), "step-check-results.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db := sqlx.NewDb(dbConn, "sqlite3")
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo, err := NewWithDB(db, db, nil)
+	if err != nil {
+		t.Fatalf("initialize schema: %v", err)
+	}
+	if err := repo.initStepCheckResultsSchema(); err != nil {
+		t.Fatalf("replay initStepCheckResultsSchema: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if _, err := db.Exec(db.Rebind(`
+		INSERT INTO tasks (id, workspace_id, title, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`), "task-gate", "ws-gate", "Gated task", now, now); err != nil {
+		t.Fatalf("seed task: %v", err)
+	}
+	for _, sessionID := range []string{"session-gate", "session-other"} {
+		if _, err := db.Exec(db.Rebind(`
+			INSERT INTO task_sessions (id, task_id, state, started_at, updated_at)
+			VALUES (?, ?, ?, ?, ?)
+		`), sessionID, "task-gate", "COMPLETED", now, now); err != nil {
+			t.Fatalf("seed session: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	first := &models.StepCheckResult{
+		TaskID: "task-gate", SessionID: "session-gate", StepID: "implement", Trigger: "on_turn_complete",
+		Command: "make test", ExitCode: 2, Output: "FAIL", DurationMs: 1200,
+		OccurredAt: now,
+	}
+	second := &models.StepCheckResult{
+		TaskID: "task-gate", SessionID: "session-gate", StepID: "implement", Trigger: "on_turn_complete",
+		Command: "make test", Passed: true, TargetStepID: "review",
+		OccurredAt: now.Add(time.Minute),
+	}
+	other := &models.StepCheckResult{
+		TaskID: "task-gate", SessionID: "session-other", StepID: "implement", Trigger: "on_turn_complete",
+		Command: "make test", Passed: true, OccurredAt: now,
+	}
+	for _, res := range []*models.StepCheckResult{first, second, other} {
+		if err := repo.RecordStepCheckResult(ctx, res); err != nil {
+			t.Fatalf("record check result: %v", err)
+		}
+	}
+
+	got, err := repo.ListStepCheckResults(ctx, "session-gate")
+	if err != nil {
+		t.Fatalf("list check results: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2", len(got))
+	}
+	if got[0].Passed || got[0].ExitCode != 2 || got[0].Output != "FAIL" || got[0].TargetStepID != "" {
+		t.Fatalf("unexpected first result: %+v", got[0])
+	}
+	if !got[1].Passed || got[1].TargetStepID != "review" {
+		t.Fatalf("unexpected second result: %+v", got[1])
+	}
+}
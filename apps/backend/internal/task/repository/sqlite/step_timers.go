@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+// stepTimerFiredColumn maps a timeout kind to its fired_at column. Unknown
+// kinds are rejected so a typo cannot silently build a bad UPDATE.
+func stepTimerFiredColumn(kind string) (string, error) {
+	switch kind {
+	case models.StepTimeoutWallClock:
+		return "wall_clock_fired_at", nil
+	case models.StepTimeoutAgentActive:
+		return "agent_active_fired_at", nil
+	}
+	return "", fmt.Errorf("unknown step timeout kind %q", kind)
+}
+
+// GetStepTimer returns the task's step timer, or nil when none is stored.
+func (r *Repository) GetStepTimer(ctx context.Context, taskID string) (*models.StepTimer, error) {
+	timer := &models.StepTimer{}
+	var wallFired, activeFired sql.NullTime
+	err := r.ro.QueryRowContext(ctx, r.ro.Rebind(`
+		SELECT task_id, workflow_step_id, entered_at, wall_clock_fired_at, agent_active_fired_at, updated_at
+		FROM task_step_timers
+		WHERE task_id = ?
+	`), taskID).Scan(
+		&timer.TaskID, &timer.StepID, &timer.EnteredAt, &wallFired, &activeFired, &timer.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	timer.EnteredAt = timer.EnteredAt.UTC()
+	timer.UpdatedAt = timer.UpdatedAt.UTC()
+	if wallFired.Valid {
+		t := wallFired.Time.UTC()
+		timer.WallClockFiredAt = &t
+	}
+	if activeFired.Valid {
+		t := activeFired.Time.UTC()
+		timer.AgentActiveFiredAt = &t
+	}
+	return timer, nil
+}
+
+// ResetStepTimer starts a fresh timer for the task's visit to stepID that
+// began at enteredAt, clearing any fired markers from a previous visit.
+func (r *Repository) ResetStepTimer(ctx context.Context, taskID, stepID string, enteredAt time.Time) error {
+	now := r.nowUTC()
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+		INSERT INTO task_step_timers (task_id, workflow_step_id, entered_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+			workflow_step_id = excluded.workflow_step_id,
+			entered_at = excluded.entered_at,
+			wall_clock_fired_at = NULL,
+			agent_active_fired_at = NULL,
+			updated_at = excluded.updated_at
+	`), taskID, stepID, enteredAt.UTC(), now)
+	return err
+}
+
+// ClaimStepTimeout marks the kind's timeout as fired for the task's visit to
+// stepID. It reports false when the timer already fired or was reset for a
+// different step, so concurrent tickers fire each timeout at most once.
+func (r *Repository) ClaimStepTimeout(ctx context.Context, taskID, stepID, kind string, firedAt time.Time) (bool, error) {
+	col, err := stepTimerFiredColumn(kind)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.ExecContext(ctx, r.db.Rebind(`
+		UPDATE task_step_timers SET `+col+` = ?, updated_at = ?
+		WHERE task_id = ? AND workflow_step_id = ? AND `+col+` IS NULL
+	`), firedAt.UTC(), r.nowUTC(), taskID, stepID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseStepTimeout undoes ClaimStepTimeout after a failed dispatch so the
+// next tick retries it.
+func (r *Repository) ReleaseStepTimeout(ctx context.Context, taskID, stepID, kind string) error {
+	col, err := stepTimerFiredColumn(kind)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, r.db.Rebind(`
+		UPDATE task_step_timers SET `+col+` = NULL, updated_at = ?
+		WHERE task_id = ? AND workflow_step_id = ?
+	`), r.nowUTC(), taskID, stepID)
+	return err
+}
+
+// LatestStepEntry returns when the task last entered stepID according to the
+// task_step_transitions ledger. found is false when the ledger has no such
+// row (tasks created before the ledger existed).
+func (r *Repository) LatestStepEntry(ctx context.Context, taskID, stepID string) (enteredAt time.Time, found bool, err error) {
+	err = r.ro.QueryRowContext(ctx, r.ro.Rebind(`
+		SELECT occurred_at FROM task_step_transitions
+		WHERE task_id = ? AND to_workflow_step_id = ?
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT 1
+	`), taskID, stepID).Scan(&enteredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return enteredAt.UTC(), true, nil
+}
+
+// SumAgentActiveTime returns how long agent turns on the task ran between
+// since and until. Turns straddling since are clipped to it, and turns still
+// running count up to until.
+func (r *Repository) SumAgentActiveTime(ctx context.Context, taskID string, since, until time.Time) (time.Duration, error) {
+	rows, err := r.ro.QueryContext(ctx, r.ro.Rebind(`
+		SELECT started_at, completed_at FROM task_session_turns
+		WHERE task_id = ? AND started_at < ? AND (completed_at IS NULL OR completed_at > ?)
+	`), taskID, until.UTC(), since.UTC())
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var total time.Duration
+	for rows.Next() {
+		var started time.Time
+		var completed sql.NullTime
+		if err := rows.Scan(&started, &completed); err != nil {
+			return 0, err
+		}
+		end := until
+		if completed.Valid && completed.Time.Before(until) {
+			end = completed.Time
+		}
+		if started.Before(since) {
+			started = since
+		}
+		if end.After(started) {
+			total += end.Sub(started)
+		}
+	}
+	return total, rows.Err()
+}
@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	dbutil "github.com/kandev/kandev/internal/db"
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+func newStepTimerTestRepo(t *testing.T) (*Repository, *sqlx.DB) {
+	t.Helper()
+	dbConn, err := dbutil.OpenSQLite(filepath.Join(t.TempDir(), "step-timers.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db := sqlx.NewDb(dbConn, "sqlite3")
+	t.Cleanup(func() { _ = db.Close() })
+
+	repo, err := NewWithDB(db, db, nil)
+	if err != nil {
+		t.Fatalf("initialize schema: %v", err)
+	}
+	if err := repo.initStepTimersSchema(); err != nil {
+		t.Fatalf("replay initStepTimersSchema: %v", err)
+	}
+	now := time.Now().UTC()
+	if _, err := db.Exec(db.Rebind(`
+		INSERT INTO tasks (id, workspace_id, title, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`), "task-sla", "ws-sla", "Stuck in review", now, now); err != nil {
+		t.Fatalf("seed task: %v", err)
+	}
+	return repo, db
+}
+
+func TestStepTimerClaimSurvivesReload(t *testing.T) {
+	repo, _ := newStepTimerTestRepo(t)
+	ctx := context.Background()
+	entered := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	if timer, err := repo.GetStepTimer(ctx, "task-sla"); err != nil || timer != nil {
+		t.Fatalf("expected no timer, got %+v, %v", timer, err)
+	}
+	if err := repo.ResetStepTimer(ctx, "task-sla", "review", entered); err != nil {
+		t.Fatalf("reset timer: %v", err)
+	}
+
+	claimed, err := repo.ClaimStepTimeout(ctx, "task-sla", "review", models.StepTimeoutWallClock, entered.Add(48*time.Hour))
+	if err != nil || !claimed {
+		t.Fatalf("first claim = %v, %v; want true", claimed, err)
+	}
+	claimed, err = repo.ClaimStepTimeout(ctx, "task-sla", "review", models.StepTimeoutWallClock, entered.Add(49*time.Hour))
+	if err != nil || claimed {
+		t.Fatalf("second claim = %v, %v; want false", claimed, err)
+	}
+	if claimed, _ := repo.ClaimStepTimeout(ctx, "task-sla", "triage", models.StepTimeoutAgentActive, entered); claimed {
+		t.Fatal("claim against a different step must not succeed")
+	}
+
+	timer, err := repo.GetStepTimer(ctx, "task-sla")
+	if err != nil {
+		t.Fatalf("get timer: %v", err)
+	}
+	if timer.StepID != "review" || !timer.EnteredAt.Equal(entered) {
+		t.Fatalf("unexpected timer: %+v", timer)
+	}
+	if timer.WallClockFiredAt == nil || timer.AgentActiveFiredAt != nil {
+		t.Fatalf("unexpected fired markers: %+v", timer)
+	}
+
+	if err := repo.ReleaseStepTimeout(ctx, "task-sla", "review", models.StepTimeoutWallClock); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if claimed, _ := repo.ClaimStepTimeout(ctx, "task-sla", "review", models.StepTimeoutWallClock, entered); !claimed {
+		t.Fatal("released timeout must be claimable again")
+	}
+
+	if err := repo.ResetStepTimer(ctx, "task-sla", "review", entered.Add(72*time.Hour)); err != nil {
+		t.Fatalf("reset timer: %v", err)
+	}
+	timer, _ = repo.GetStepTimer(ctx, "task-sla")
+	if timer.WallClockFiredAt != nil || !timer.EnteredAt.Equal(entered.Add(72*time.Hour)) {
+		t.Fatalf("reset must clear fired markers: %+v", timer)
+	}
+
+	if _, err := repo.ClaimStepTimeout(ctx, "task-sla", "review", "bogus", entered); err == nil {
+		t.Fatal("expected unknown kind to be rejected")
+	}
+}
+
+func TestLatestStepEntryAndAgentActiveTime(t *testing.T) {
+	repo, db := newStepTimerTestRepo(t)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	if _, found, err := repo.LatestStepEntry(ctx, "task-sla", "review"); err != nil || found {
+		t.Fatalf("expected no ledger entry, got found=%v err=%v", found, err)
+	}
+	for i, at := range []time.Time{base, base.Add(2 * time.Hour)} {
+		if _, err := db.Exec(db.Rebind(`
+			INSERT INTO task_step_transitions
+				(task_id, to_workflow_id, to_workflow_step_id, trigger, actor_kind, contract_version, occurred_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`), "task-sla", "wf", "review", "manual_move", "user", 1, at); err != nil {
+			t.Fatalf("seed ledger row %d: %v", i, err)
+		}
+	}
+	entered, found, err := repo.LatestStepEntry(ctx, "task-sla", "review")
+	if err != nil || !found || !entered.Equal(base.Add(2*time.Hour)) {
+		t.Fatalf("LatestStepEntry = %v, %v, %v", entered, found, err)
+	}
+
+	if _, err := db.Exec(db.Rebind(`
+		INSERT INTO task_sessions (id, task_id, state, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`), "session-1", "task-sla", "RUNNING", base, base); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	since := base.Add(2 * time.Hour)
+	until := base.Add(5 * time.Hour)
+	turns := []struct {
+		id        string
+		start     time.Time
+		completed *time.Time
+	}{
+		{"before", base, ptrTime(base.Add(time.Hour))},                                   // ignored
+		{"straddle", since.Add(-30 * time.Minute), ptrTime(since.Add(30 * time.Minute))}, // 30m
+		{"inside", since.Add(time.Hour), ptrTime(since.Add(90 * time.Minute))},           // 30m
+		{"running", until.Add(-20 * time.Minute), nil},                                   // 20m
+	}
+	for _, turn := range turns {
+		if _, err := db.Exec(db.Rebind(`
+			INSERT INTO task_session_turns (id, task_session_id, task_id, started_at, completed_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`), turn.id, "session-1", "task-sla", turn.start, turn.completed, turn.start, turn.start); err != nil {
+			t.Fatalf("seed turn %s: %v", turn.id, err)
+		}
+	}
+	active, err := repo.SumAgentActiveTime(ctx, "task-sla", since, until)
+	if err != nil {
+		t.Fatalf("SumAgentActiveTime: %v", err)
+	}
+	if active != 80*time.Minute {
+		t.Fatalf("active = %s, want 1h20m", active)
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
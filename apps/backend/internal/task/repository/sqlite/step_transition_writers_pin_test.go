@@ -53,6 +53,7 @@ var registeredStepMutators = []string{
 	"task/repository/sqlite/Repository.RestoreTaskMessageRollbackIfSessionState",
 	"task/repository/sqlite/Repository.AddTaskToWorkflow",
 	"task/repository/sqlite/Repository.RemoveTaskFromWorkflow",
+	"task/repository/sqlite/Repository.ApplyWorkflowRevisionMigration",
 }
 
 func TestStepTransitionWritersArePinned(t *testing.T) {
@@ -168,3 +168,10 @@ func nullableString(s string) interface{} {
 	}
 	return s
 }
+
+// revisionMigrationAttribution is ApplyWorkflowRevisionMigration's ledger row
+// attribution: the writer hard-codes TriggerRevisionMigration, since moving a
+// task between workflow revisions means the same thing whoever asked for it.
+func revisionMigrationAttribution(ctx context.Context) steptelemetry.Attribution {
+	return hardcodedTriggerAttribution(ctx, steptelemetry.TriggerRevisionMigration)
+}
@@ -46,14 +46,14 @@ func (r *Repository) CreateTaskEnvironment(ctx context.Context, env *models.Task
 			id, task_id, executor_type, executor_id, executor_profile_id,
 			control_port, status,
 			workspace_path,
-			container_id, sandbox_id, task_dir_name,
+			container_id, sandbox_id, task_dir_name, session_id,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`),
 		env.ID, env.TaskID, env.ExecutorType, env.ExecutorID, env.ExecutorProfileID,
 		env.ControlPort, string(env.Status),
 		env.WorkspacePath,
-		env.ContainerID, env.SandboxID, env.TaskDirName,
+		env.ContainerID, env.SandboxID, env.TaskDirName, env.SessionID,
 		env.CreatedAt, env.UpdatedAt,
 	); err != nil {
 		return err
@@ -78,14 +78,14 @@ func (r *Repository) GetTaskEnvironment(ctx context.Context, id string) (*models
 		SELECT id, task_id, executor_type, executor_id, executor_profile_id,
 			control_port, status,
 			workspace_path,
-			container_id, sandbox_id, COALESCE(task_dir_name, ''),
+			container_id, sandbox_id, COALESCE(task_dir_name, ''), session_id,
 			created_at, updated_at
 		FROM task_environments WHERE id = ?
 	`), id).Scan(
 		&env.ID, &env.TaskID, &env.ExecutorType, &env.ExecutorID, &env.ExecutorProfileID,
 		&env.ControlPort, &status,
 		&env.WorkspacePath,
-		&env.ContainerID, &env.SandboxID, &env.TaskDirName,
+		&env.ContainerID, &env.SandboxID, &env.TaskDirName, &env.SessionID,
 		&env.CreatedAt, &env.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -105,7 +105,8 @@ func (r *Repository) GetTaskEnvironment(ctx context.Context, id string) (*models
 }
 
 // GetTaskEnvironmentByTaskID retrieves the active task environment for a task,
-// including per-repo rows.
+// including per-repo rows. It is the environment the task's sessions share;
+// environments scoped to a single session are read with GetTaskEnvironment.
 func (r *Repository) GetTaskEnvironmentByTaskID(ctx context.Context, taskID string) (*models.TaskEnvironment, error) {
 	env := &models.TaskEnvironment{}
 	var status string
@@ -114,14 +115,15 @@ func (r *Repository) GetTaskEnvironmentByTaskID(ctx context.Context, taskID stri
 		SELECT id, task_id, executor_type, executor_id, executor_profile_id,
 			control_port, status,
 			workspace_path,
-			container_id, sandbox_id, COALESCE(task_dir_name, ''),
+			container_id, sandbox_id, COALESCE(task_dir_name, ''), session_id,
 			created_at, updated_at
-		FROM task_environments WHERE task_id = ? ORDER BY created_at DESC LIMIT 1
+		FROM task_environments WHERE task_id = ? AND session_id = ''
+		ORDER BY created_at DESC LIMIT 1
 	`), taskID).Scan(
 		&env.ID, &env.TaskID, &env.ExecutorType, &env.ExecutorID, &env.ExecutorProfileID,
 		&env.ControlPort, &status,
 		&env.WorkspacePath,
-		&env.ContainerID, &env.SandboxID, &env.TaskDirName,
+		&env.ContainerID, &env.SandboxID, &env.TaskDirName, &env.SessionID,
 		&env.CreatedAt, &env.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
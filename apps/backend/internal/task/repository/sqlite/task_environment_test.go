@@ -120,3 +120,36 @@ func TestTransferTaskEnvironmentMissingReturnsSentinel(t *testing.T) {
 		t.Fatalf("TransferTaskEnvironmentToTask error = %v, want ErrTaskEnvironmentNotFound", err)
 	}
 }
+
+func TestSessionScopedTaskEnvironmentIsNotTheTaskEnvironment(t *testing.T) {
+	repo := newRepoForEntityTests(t)
+	ctx := context.Background()
+	seedWorkspace(t, repo, "workspace-session-env")
+	if err := repo.CreateTask(ctx, &models.Task{ID: "task-session-env", WorkspaceID: "workspace-session-env", Title: "Task"}); err != nil {
+		t.Fatal(err)
+	}
+	shared := &models.TaskEnvironment{ID: "env-shared", TaskID: "task-session-env", ExecutorType: string(models.ExecutorTypeLocal), Status: models.TaskEnvironmentStatusReady}
+	if err := repo.CreateTaskEnvironment(ctx, shared); err != nil {
+		t.Fatalf("create shared environment: %v", err)
+	}
+	for _, id := range []string{"env-fork-one", "env-fork-two"} {
+		if err := repo.CreateTaskEnvironment(ctx, &models.TaskEnvironment{
+			ID: id, TaskID: "task-session-env", SessionID: "session-" + id,
+			ExecutorType: string(models.ExecutorTypeLocal), Status: models.TaskEnvironmentStatusReady,
+		}); err != nil {
+			t.Fatalf("create session environment %s: %v", id, err)
+		}
+	}
+	if err := repo.CreateTaskEnvironment(ctx, &models.TaskEnvironment{ID: "env-shared-duplicate", TaskID: "task-session-env"}); err == nil {
+		t.Fatal("a second shared environment for the task was accepted")
+	}
+
+	got, err := repo.GetTaskEnvironmentByTaskID(ctx, "task-session-env")
+	if err != nil || got == nil || got.ID != "env-shared" {
+		t.Fatalf("GetTaskEnvironmentByTaskID = %+v, %v; want env-shared", got, err)
+	}
+	fork, err := repo.GetTaskEnvironment(ctx, "env-fork-two")
+	if err != nil || fork.SessionID != "session-env-fork-two" {
+		t.Fatalf("GetTaskEnvironment = %+v, %v; want session-env-fork-two", fork, err)
+	}
+}
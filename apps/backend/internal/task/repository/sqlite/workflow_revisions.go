@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kandev/kandev/internal/steptelemetry"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+// ApplyWorkflowRevisionMigration re-pins tasks from one workflow revision to
+// another and moves each onto its mapped step, in one transaction. A task is
+// only moved while it still sits on FromStepID at fromRevision; applied[i]
+// reports whether moves[i] was. No on_exit/on_enter runs, but every step
+// change writes its task_step_transitions row like any other move.
+//
+// The pin table belongs to the workflow repository; it lives in the same
+// database, and the re-pin has to commit atomically with the step change.
+func (r *Repository) ApplyWorkflowRevisionMigration(
+	ctx context.Context, workflowID string, fromRevision, toRevision int, moves []wfmodels.RevisionTaskMove,
+) ([]bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	migrationCtx := steptelemetry.WithAttribution(ctx, revisionMigrationAttribution(ctx))
+	applied := make([]bool, len(moves))
+	for i, m := range moves {
+		fromWorkflowID, fromStepID, found, err := r.readTaskStepInTx(ctx, tx, m.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("read step of task %s: %w", m.TaskID, err)
+		}
+		if !found || fromWorkflowID != workflowID || fromStepID != m.FromStepID {
+			continue
+		}
+		now := time.Now().UTC()
+		res, err := tx.ExecContext(ctx, r.db.Rebind(`
+			UPDATE workflow_task_revisions SET revision = ?, pinned_at = ?
+			WHERE task_id = ? AND workflow_id = ? AND revision = ?
+		`), toRevision, now, m.TaskID, workflowID, fromRevision)
+		if err != nil {
+			return nil, fmt.Errorf("re-pin task %s: %w", m.TaskID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		if m.ToStepID != m.FromStepID {
+			if _, err := tx.ExecContext(ctx, r.db.Rebind(`
+				UPDATE tasks SET workflow_step_id = ?, updated_at = ? WHERE id = ?
+			`), m.ToStepID, now, m.TaskID); err != nil {
+				return nil, fmt.Errorf("move task %s: %w", m.TaskID, err)
+			}
+			if err := r.recordStepTransition(migrationCtx, tx, stepTransitionInput{
+				taskID:             m.TaskID,
+				fromWorkflowID:     workflowID,
+				fromWorkflowStepID: m.FromStepID,
+				toWorkflowID:       workflowID,
+				toWorkflowStepID:   m.ToStepID,
+				occurredAt:         now,
+			}); err != nil {
+				return nil, fmt.Errorf("record migration of task %s: %w", m.TaskID, err)
+			}
+		}
+		applied[i] = true
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
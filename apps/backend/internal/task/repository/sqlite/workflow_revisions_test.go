@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kandev/kandev/internal/steptelemetry"
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+// createRevisionPinTable creates the workflow repository's pin table, which
+// the task repository does not own but updates during a migration.
+func createRevisionPinTable(t *testing.T, repo *Repository) {
+	t.Helper()
+	if _, err := repo.db.Exec(`CREATE TABLE workflow_task_revisions (
+		task_id TEXT PRIMARY KEY, workflow_id TEXT NOT NULL, revision INTEGER NOT NULL, pinned_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		t.Fatalf("create pin table: %v", err)
+	}
+}
+
+func pinRevision(t *testing.T, repo *Repository, taskID string, revision int) {
+	t.Helper()
+	if _, err := repo.db.Exec(`INSERT INTO workflow_task_revisions (task_id, workflow_id, revision, pinned_at) VALUES (?, 'wf-1', ?, ?)`,
+		taskID, revision, time.Now().UTC()); err != nil {
+		t.Fatalf("pin %s: %v", taskID, err)
+	}
+}
+
+func pinnedRevision(t *testing.T, repo *Repository, taskID string) int {
+	t.Helper()
+	var revision int
+	if err := repo.db.QueryRow(`SELECT revision FROM workflow_task_revisions WHERE task_id = ?`, taskID).Scan(&revision); err != nil {
+		t.Fatalf("read pin of %s: %v", taskID, err)
+	}
+	return revision
+}
+
+func TestApplyWorkflowRevisionMigrationWritesLedgerAndSkipsMovedTasks(t *testing.T) {
+	repo := newStepTransitionsTestRepo(t)
+	createRevisionPinTable(t, repo)
+	ctx := context.Background()
+	for _, id := range []string{"t1", "t2", "t3"} {
+		createStepTransitionsTestTask(t, repo, id, "wf-1", "old")
+		pinRevision(t, repo, id, 1)
+	}
+	// t2 left the step after the migration was planned.
+	if _, err := repo.db.Exec(`UPDATE tasks SET workflow_step_id = 'elsewhere' WHERE id = 't2'`); err != nil {
+		t.Fatalf("move t2: %v", err)
+	}
+
+	applied, err := repo.ApplyWorkflowRevisionMigration(ctx, "wf-1", 1, 2, []wfmodels.RevisionTaskMove{
+		{TaskID: "t1", FromStepID: "old", ToStepID: "new"},
+		{TaskID: "t2", FromStepID: "old", ToStepID: "new"},
+		{TaskID: "t3", FromStepID: "old", ToStepID: "old"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyWorkflowRevisionMigration: %v", err)
+	}
+	if !applied[0] || applied[1] || !applied[2] {
+		t.Fatalf("applied = %v, want [true false true]", applied)
+	}
+	for id, want := range map[string]int{"t1": 2, "t2": 1, "t3": 2} {
+		if got := pinnedRevision(t, repo, id); got != want {
+			t.Errorf("pin of %s = %d, want %d", id, got, want)
+		}
+	}
+
+	rows := stepTransitionRowsForTask(t, repo, "t1")
+	if len(rows) != 2 {
+		t.Fatalf("t1 rows = %d, want genesis + migration", len(rows))
+	}
+	migration := rows[1]
+	if migration.trigger != string(steptelemetry.TriggerRevisionMigration) {
+		t.Errorf("trigger = %q, want %q", migration.trigger, steptelemetry.TriggerRevisionMigration)
+	}
+	if migration.fromWorkflowStepID == nil || *migration.fromWorkflowStepID != "old" ||
+		migration.toWorkflowStepID == nil || *migration.toWorkflowStepID != "new" {
+		t.Errorf("migration row = %+v, want old -> new", migration)
+	}
+	if got := len(stepTransitionRowsForTask(t, repo, "t3")); got != 1 {
+		t.Errorf("t3 rows = %d, want only genesis: a re-pin without a step change writes nothing", got)
+	}
+}
@@ -47,6 +47,7 @@ func finalTaskEnvironmentsDDL(tableName string) string {
 			container_id TEXT DEFAULT '',
 			sandbox_id TEXT DEFAULT '',
 			task_dir_name TEXT DEFAULT '',
+			session_id TEXT NOT NULL DEFAULT '',
 			created_at TIMESTAMP NOT NULL,
 			updated_at TIMESTAMP NOT NULL,
 			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
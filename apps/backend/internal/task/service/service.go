@@ -209,6 +209,13 @@ type StartStepResolver interface {
 	ResolveFirstStep(ctx context.Context, workflowID string) (string, error)
 }
 
+// WorkflowRevisionPinner pins a task to the current revision of its
+// workflow. Optional — when unset, tasks are pinned lazily the next time the
+// workflow cuts a revision. Errors are logged and swallowed by the caller.
+type WorkflowRevisionPinner interface {
+	PinTaskRevision(ctx context.Context, taskID, workflowID string) error
+}
+
 // StepHistoryRecorder persists an ADR 0015 session-step transition audit
 // row. Optional — when unset, MoveTaskWithOptions records nothing. Errors
 // are logged and swallowed by the caller: the audit trail must never fail
@@ -337,6 +344,7 @@ type Service struct {
 	workspaceBootstrapper           WorkspaceBootstrapper
 	workflowStepGetter              WorkflowStepGetter
 	startStepResolver               StartStepResolver
+	workflowRevisionPinner          WorkflowRevisionPinner
 	stepHistoryRecorder             StepHistoryRecorder
 	contributionDestinationPreparer ContributionDestinationPreparer
 	prTaskResolver                  PRTaskResolver
@@ -585,6 +593,12 @@ func (s *Service) SetStartStepResolver(resolver StartStepResolver) {
 	s.startStepResolver = resolver
 }
 
+// SetWorkflowRevisionPinner wires the revision pinner called when a task
+// enters a workflow. Optional.
+func (s *Service) SetWorkflowRevisionPinner(pinner WorkflowRevisionPinner) {
+	s.workflowRevisionPinner = pinner
+}
+
 // SetStepHistoryRecorder wires the ADR 0015 audit-trail writer for manual
 // step transitions (MoveTaskWithOptions). Optional.
 func (s *Service) SetStepHistoryRecorder(recorder StepHistoryRecorder) {
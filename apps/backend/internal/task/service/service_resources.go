@@ -26,6 +26,7 @@ import (
 
 const (
 	spritesTokenEnvKey                = "SPRITES_API_TOKEN"
+	warmPoolSizeConfigKey             = "warm_pool_size"
 	workspaceDeletePageSize           = 500
 	workspaceDeleteCleanupConcurrency = 8
 )
@@ -1608,6 +1609,9 @@ func (s *Service) CreateExecutorProfile(ctx context.Context, req *CreateExecutor
 	if executor.Type == models.ExecutorTypeSprites && !hasSpritesToken(req.EnvVars) {
 		return nil, fmt.Errorf("sprites profiles require %s env var", spritesTokenEnvKey)
 	}
+	if err := validateWarmPoolConfig(executor.Type, req.Config); err != nil {
+		return nil, err
+	}
 	profile := &models.ExecutorProfile{
 		ExecutorID:    req.ExecutorID,
 		Name:          req.Name,
@@ -1644,6 +1648,9 @@ func (s *Service) UpdateExecutorProfile(ctx context.Context, id string, req *Upd
 		profile.McpPolicy = *req.McpPolicy
 	}
 	if req.Config != nil {
+		if err := validateWarmPoolConfig(executor.Type, req.Config); err != nil {
+			return nil, err
+		}
 		profile.Config = req.Config
 	}
 	if req.PrepareScript != nil {
@@ -1721,6 +1728,21 @@ func mergeSpritesTokenEnvVars(existing, incoming []models.ProfileEnvVar) []model
 	return merged
 }
 
+// validateWarmPoolConfig rejects a non-zero warm_pool_size on executor types
+// whose runtime has no warm pool, so the setting is never silently ignored.
+func validateWarmPoolConfig(executorType models.ExecutorType, config map[string]string) error {
+	size := strings.TrimSpace(config[warmPoolSizeConfigKey])
+	if size == "" || size == "0" {
+		return nil
+	}
+	switch executorType {
+	case models.ExecutorTypeLocalDocker, models.ExecutorTypePodman, models.ExecutorTypeSSH, models.ExecutorTypeSprites:
+		return nil
+	}
+	return fmt.Errorf("%s is only supported by %s, %s, %s and %s executors, not %s", warmPoolSizeConfigKey,
+		models.ExecutorTypeLocalDocker, models.ExecutorTypePodman, models.ExecutorTypeSSH, models.ExecutorTypeSprites, executorType)
+}
+
 func hasSpritesToken(envVars []models.ProfileEnvVar) bool {
 	for _, ev := range envVars {
 		if strings.TrimSpace(ev.Key) != spritesTokenEnvKey {
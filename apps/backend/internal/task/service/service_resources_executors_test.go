@@ -342,6 +342,36 @@ func TestCreateExecutorProfileRequiresSpritesToken(t *testing.T) {
 	}
 }
 
+func TestExecutorProfileRejectsWarmPoolOnUnsupportedExecutors(t *testing.T) {
+	svc, _, _ := createTestService(t)
+	ctx := context.Background()
+	worktree := createTestExecutor(t, svc, "Worktree", models.ExecutorTypeWorktree)
+	ssh := createTestExecutor(t, svc, "SSH", models.ExecutorTypeSSH)
+
+	if _, err := svc.CreateExecutorProfile(ctx, &CreateExecutorProfileRequest{
+		ExecutorID: worktree.ID, Name: "worktree profile", Config: map[string]string{warmPoolSizeConfigKey: "2"},
+	}); err == nil || !strings.Contains(err.Error(), warmPoolSizeConfigKey) {
+		t.Fatalf("worktree warm pool error = %v, want a %s rejection", err, warmPoolSizeConfigKey)
+	}
+	profile, err := svc.CreateExecutorProfile(ctx, &CreateExecutorProfileRequest{
+		ExecutorID: worktree.ID, Name: "worktree profile", Config: map[string]string{warmPoolSizeConfigKey: "0"},
+	})
+	if err != nil {
+		t.Fatalf("a zero warm_pool_size must be accepted: %v", err)
+	}
+	if _, err := svc.UpdateExecutorProfile(ctx, profile.ID, &UpdateExecutorProfileRequest{
+		Config: map[string]string{warmPoolSizeConfigKey: "1"},
+	}); err == nil {
+		t.Fatal("updating a worktree profile to a warm pool must be rejected")
+	}
+
+	if _, err := svc.CreateExecutorProfile(ctx, &CreateExecutorProfileRequest{
+		ExecutorID: ssh.ID, Name: "ssh profile", Config: map[string]string{warmPoolSizeConfigKey: "2"},
+	}); err != nil {
+		t.Fatalf("ssh warm pool profile: %v", err)
+	}
+}
+
 func TestHasSpritesTokenAcceptsSecretReference(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kandev/kandev/internal/task/models"
+)
+
+// MarkTurnsRewound flags the given turns of a session, and every message in
+// them, as discarded by a workspace rewind. Messages are updated one by one
+// so clients receive a message.updated event for each.
+func (s *Service) MarkTurnsRewound(ctx context.Context, sessionID string, turnIDs []string) error {
+	for _, turnID := range turnIDs {
+		if err := s.PatchTurnMetadata(ctx, sessionID, turnID, map[string]interface{}{
+			models.TurnMetaKeyRewound: true,
+		}); err != nil {
+			return err
+		}
+		messages, err := s.messages.ListMessagesByTurnID(ctx, turnID)
+		if err != nil {
+			return fmt.Errorf("list messages of turn %s: %w", turnID, err)
+		}
+		for _, message := range messages {
+			if rewound, _ := message.Metadata[models.MessageMetaKeyRewound].(bool); rewound {
+				continue
+			}
+			if message.Metadata == nil {
+				message.Metadata = make(map[string]interface{})
+			}
+			message.Metadata[models.MessageMetaKeyRewound] = true
+			if err := s.UpdateMessage(ctx, message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -343,6 +343,7 @@ func (s *Service) finalizeCreatedTask(ctx context.Context, task *models.Task, re
 		task.Repositories = repos
 	}
 
+	s.pinWorkflowRevision(ctx, task.ID, task.WorkflowID)
 	s.publishTaskEvent(ctx, events.TaskCreated, task, nil)
 	s.pullTasksFromNewFeederWork(ctx, task.WorkflowID, task.WorkflowStepID)
 	if refreshed, err := s.tasks.GetTask(ctx, task.ID); err != nil {
@@ -356,6 +357,20 @@ func (s *Service) finalizeCreatedTask(ctx context.Context, task *models.Task, re
 	return CreateTaskResult{Task: task, Outcome: CreateTaskOutcomeCreated}, nil
 }
 
+// pinWorkflowRevision pins taskID to workflowID's current revision when a
+// pinner is wired.
+func (s *Service) pinWorkflowRevision(ctx context.Context, taskID, workflowID string) {
+	if s.workflowRevisionPinner == nil || workflowID == "" {
+		return
+	}
+	if err := s.workflowRevisionPinner.PinTaskRevision(ctx, taskID, workflowID); err != nil {
+		s.logger.Warn("failed to pin task to workflow revision",
+			zap.String("task_id", taskID),
+			zap.String("workflow_id", workflowID),
+			zap.Error(err))
+	}
+}
+
 // findTaskByExternalIDIfPresent is CreateTask's step-3 lookup: when the
 // request carries an external_id already held by a task, the caller MUST
 // return that task (found=true) instead of continuing the create. found is
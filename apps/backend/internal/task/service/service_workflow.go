@@ -568,6 +568,9 @@ func (s *Service) MoveTaskWithOptions(
 		s.logger.Error("failed to move task", zap.String("task_id", id), zap.Error(err))
 		return nil, err
 	}
+	if workflowID != oldWorkflowID {
+		s.pinWorkflowRevision(ctx, task.ID, workflowID)
+	}
 
 	s.publishTaskEvent(ctx, events.TaskUpdated, task, nil, oldWorkflowID)
 	if oldState != task.State {
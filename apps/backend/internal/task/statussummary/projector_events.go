@@ -116,6 +116,10 @@ func applyPermissionEventLocked(state *projectionState, data map[string]interfac
 	if sessionID == "" {
 		return false
 	}
+	// A request answered by the permission policy never waits for input.
+	if data["permission_policy"] != nil {
+		return false
+	}
 	identity := pendingRequestIdentity{
 		messageType: messageTypePermissionRequest,
 		pendingID:   stringField(data, "pending_id"),
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kandev/kandev/internal/workflow/models"
 	"github.com/kandev/kandev/internal/workflow/service"
+	"github.com/kandev/kandev/internal/workflow/simulator"
 )
 
 // Controller handles workflow-related requests
@@ -331,8 +333,28 @@ type ListHistoryRequest struct {
 	SessionID string `json:"session_id"`
 }
 
+// ListHistoryResponse is a session's step history with the run_check gate
+// results evaluated along the way, both oldest first. A check whose
+// TargetStepID matches a history entry's to_step_id routed that transition.
 type ListHistoryResponse struct {
 	History []*models.SessionStepHistory `json:"history"`
+	Checks  []StepCheckResultDTO         `json:"checks"`
+}
+
+// StepCheckResultDTO is one run_check gate evaluation.
+type StepCheckResultDTO struct {
+	ID           int64     `json:"id"`
+	StepID       string    `json:"step_id"`
+	Trigger      string    `json:"trigger"`
+	Command      string    `json:"command"`
+	Passed       bool      `json:"passed"`
+	ExitCode     int       `json:"exit_code"`
+	TimedOut     bool      `json:"timed_out"`
+	Output       string    `json:"output,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	TargetStepID string    `json:"target_step_id,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
 }
 
 func (c *Controller) ListHistoryBySession(ctx context.Context, req ListHistoryRequest) (*ListHistoryResponse, error) {
@@ -340,7 +362,28 @@ func (c *Controller) ListHistoryBySession(ctx context.Context, req ListHistoryRe
 	if err != nil {
 		return nil, err
 	}
-	return &ListHistoryResponse{History: history}, nil
+	results, err := c.svc.ListCheckResultsBySession(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]StepCheckResultDTO, 0, len(results))
+	for _, r := range results {
+		checks = append(checks, StepCheckResultDTO{
+			ID:           r.ID,
+			StepID:       r.StepID,
+			Trigger:      r.Trigger,
+			Command:      r.Command,
+			Passed:       r.Passed,
+			ExitCode:     r.ExitCode,
+			TimedOut:     r.TimedOut,
+			Output:       r.Output,
+			Error:        r.Error,
+			DurationMs:   r.DurationMs,
+			TargetStepID: r.TargetStepID,
+			OccurredAt:   r.OccurredAt,
+		})
+	}
+	return &ListHistoryResponse{History: history, Checks: checks}, nil
 }
 
 // Export/Import types and methods
@@ -366,3 +409,108 @@ func (c *Controller) ExportWorkflows(ctx context.Context, workspaceID string, wo
 func (c *Controller) ImportWorkflows(ctx context.Context, req ImportWorkflowsRequest) (*service.ImportResult, error) {
 	return c.svc.ImportWorkflows(ctx, req.WorkspaceID, req.Data)
 }
+
+// Revision types and methods
+
+// ListRevisionsResponse lists a workflow's revisions, newest first.
+type ListRevisionsResponse struct {
+	Revisions []*models.WorkflowRevisionSummary `json:"revisions"`
+}
+
+// ListRevisions lists a workflow's revisions.
+func (c *Controller) ListRevisions(ctx context.Context, workflowID string) (*ListRevisionsResponse, error) {
+	revisions, err := c.svc.ListRevisions(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if revisions == nil {
+		revisions = []*models.WorkflowRevisionSummary{}
+	}
+	return &ListRevisionsResponse{Revisions: revisions}, nil
+}
+
+// GetRevision returns one revision with its step snapshot.
+func (c *Controller) GetRevision(ctx context.Context, workflowID string, revision int) (*models.WorkflowRevision, error) {
+	return c.svc.GetRevision(ctx, workflowID, revision)
+}
+
+// PublishRevision cuts a revision from the workflow's live steps, or returns
+// the current one when nothing changed.
+func (c *Controller) PublishRevision(ctx context.Context, workflowID string) (*models.WorkflowRevision, error) {
+	rev, err := c.svc.EnsureCurrentRevision(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, models.ErrNoRevisionSteps
+	}
+	return rev, nil
+}
+
+// MigrateRevisionsRequest moves the tasks pinned to FromRevision onto
+// ToRevision (0 = current). StepMapping maps old step IDs to new ones.
+type MigrateRevisionsRequest struct {
+	WorkflowID   string            `json:"workflow_id"`
+	FromRevision int               `json:"from_revision"`
+	ToRevision   int               `json:"to_revision"`
+	StepMapping  map[string]string `json:"step_mapping"`
+	DryRun       bool              `json:"dry_run"`
+}
+
+// MigrateRevisions previews (dry run) or applies a revision migration.
+func (c *Controller) MigrateRevisions(ctx context.Context, req MigrateRevisionsRequest) (*models.RevisionMigrationPlan, error) {
+	return c.svc.MigrateTaskRevisions(ctx, service.MigrateRevisionRequest{
+		WorkflowID:   req.WorkflowID,
+		FromRevision: req.FromRevision,
+		ToRevision:   req.ToRevision,
+		StepMapping:  req.StepMapping,
+		DryRun:       req.DryRun,
+	})
+}
+
+// Simulation types and methods
+
+// SimulateWorkflowRequest dry-runs a stored workflow. Revision selects a
+// revision snapshot; 0 simulates the live steps.
+type SimulateWorkflowRequest struct {
+	WorkflowID string             `json:"workflow_id"`
+	Revision   int                `json:"revision"`
+	Scenario   simulator.Scenario `json:"scenario"`
+}
+
+// SimulateWorkflow runs the scenario against a stored workflow.
+func (c *Controller) SimulateWorkflow(ctx context.Context, req SimulateWorkflowRequest) (*simulator.Result, error) {
+	var steps []*models.WorkflowStep
+	if req.Revision > 0 {
+		rev, err := c.svc.GetRevision(ctx, req.WorkflowID, req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		steps = rev.Steps
+	} else {
+		var err error
+		if steps, err = c.svc.ListStepsByWorkflow(ctx, req.WorkflowID); err != nil {
+			return nil, err
+		}
+	}
+	def := simulator.NewDefinition(req.WorkflowID, req.WorkflowID, steps)
+	return simulator.Run(ctx, def, req.Scenario)
+}
+
+// SimulateYAMLRequest dry-runs a workflow given as portable YAML, the
+// format the import endpoint takes. Workflow names the workflow to run when
+// the YAML holds several.
+type SimulateYAMLRequest struct {
+	YAML     string             `json:"yaml"`
+	Workflow string             `json:"workflow"`
+	Scenario simulator.Scenario `json:"scenario"`
+}
+
+// SimulateYAML runs the scenario against a workflow that was never imported.
+func (c *Controller) SimulateYAML(ctx context.Context, req SimulateYAMLRequest) (*simulator.Result, error) {
+	def, err := simulator.DefinitionFromYAML([]byte(req.YAML), req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	return simulator.Run(ctx, def, req.Scenario)
+}
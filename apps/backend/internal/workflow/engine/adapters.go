@@ -162,3 +162,94 @@ type TaskCreator interface {
 type WorkflowSwitcher interface {
 	SwitchTaskWorkflow(ctx context.Context, taskID, newWorkflowID, newStepID string) (resolvedStepID string, err error)
 }
+
+// CheckRequest is the typed payload the engine hands to CheckRunner for a
+// run_check action. Timeout is always positive — the engine applies
+// DefaultCheckTimeout when the action leaves it unset.
+type CheckRequest struct {
+	TaskID    string
+	SessionID string
+	StepID    string
+	Command   string
+	Timeout   time.Duration
+}
+
+// CheckResult is what a CheckRunner observed. ExitCode is meaningful only
+// when TimedOut is false; Output is the combined stdout/stderr, possibly
+// truncated by the implementation.
+type CheckResult struct {
+	ExitCode int
+	Output   string
+	Duration time.Duration
+	TimedOut bool
+}
+
+// CheckRunner executes run_check commands inside the task session's
+// workspace (the agentctl process runner in production). Implementations
+// block until the command exits or the timeout elapses; a non-zero exit is
+// reported through CheckResult, not as an error. Errors mean the command
+// could not be run at all (no workspace, agentctl unreachable, …).
+type CheckRunner interface {
+	RunCheck(ctx context.Context, req CheckRequest) (CheckResult, error)
+}
+
+// CheckRecord is one persisted run_check gate result. TargetStepID is the
+// step the gate routed to ("" when it kept the task in place).
+type CheckRecord struct {
+	TaskID       string
+	SessionID    string
+	StepID       string
+	Trigger      string
+	Command      string
+	Passed       bool
+	ExitCode     int
+	TimedOut     bool
+	Output       string
+	Error        string
+	Duration     time.Duration
+	TargetStepID string
+	OccurredAt   time.Time
+}
+
+// CheckRecorder persists run_check gate results next to the step transition
+// ledger. Optional: without it, results are only surfaced on HandleResult.
+type CheckRecorder interface {
+	RecordCheckResult(ctx context.Context, rec CheckRecord) error
+}
+
+// GuardFacts is the read-only snapshot an expression guard evaluates
+// against besides the workflow data bag. Each map becomes one root of the
+// expression (task.*, pr.*, review.*); a nil map — no PR linked, no review
+// run yet — makes every field under that root evaluate to null.
+type GuardFacts struct {
+	Task   map[string]any
+	PR     map[string]any
+	Review map[string]any
+}
+
+// GuardFactsProvider loads GuardFacts for a task. The engine only calls it
+// when a guard expression reads task, pr or review; data-only guards never
+// touch it.
+type GuardFactsProvider interface {
+	LoadGuardFacts(ctx context.Context, taskID string) (GuardFacts, error)
+}
+
+// Notification is the typed payload the engine hands to Notifier for a
+// notify action. OperationID is the triggering operation's id; together
+// with StepID it lets the implementation dedupe redeliveries.
+type Notification struct {
+	TaskID      string
+	SessionID   string
+	StepID      string
+	StepName    string
+	Trigger     Trigger
+	OperationID string
+	Title       string
+	Message     string
+}
+
+// Notifier delivers workflow notify actions to the user (the notifications
+// service in production).
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
@@ -0,0 +1,38 @@
+package engine
+
+// Data bag keys for on_agent_error retry counting. The orchestrator bumps
+// the count before it dispatches TriggerOnAgentError, so a step can route
+// on it with an expression guard:
+//
+//	on_agent_error:
+//	  - type: move_to_step
+//	    config: {step_id: triage, if: "data.agent_error_count >= 3"}
+//
+// The count is scoped to the step the errors happened in: the first error
+// in a different step starts again from one.
+const (
+	AgentErrorCountDataKey = "agent_error_count"
+	AgentErrorStepDataKey  = "agent_error_step_id"
+)
+
+// NextAgentErrorAttempt returns the attempt number for a new agent error
+// in stepID given the session's current data bag.
+func NextAgentErrorAttempt(data map[string]any, stepID string) int {
+	if prev, _ := data[AgentErrorStepDataKey].(string); prev != stepID {
+		return 1
+	}
+	n, ok := toPositiveInt(data[AgentErrorCountDataKey])
+	if !ok {
+		return 1
+	}
+	return n + 1
+}
+
+// AgentErrorDataPatch is the data bag patch recording attempt as the
+// latest agent error count for stepID.
+func AgentErrorDataPatch(stepID string, attempt int) map[string]any {
+	return map[string]any{
+		AgentErrorCountDataKey: attempt,
+		AgentErrorStepDataKey:  stepID,
+	}
+}
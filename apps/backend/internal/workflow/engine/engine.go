@@ -76,6 +76,39 @@ type TransitionStore interface {
 	MarkOperationApplied(ctx context.Context, operationID string) error
 }
 
+// TaskStepLoader is an optional TransitionStore extension that loads steps as
+// a particular task runs them. A task pinned to a workflow revision keeps
+// that revision's step definitions after the live workflow is edited, until
+// it is migrated. Stores without it serve LoadStep, LoadNextStep and
+// LoadPreviousStep to every task alike.
+type TaskStepLoader interface {
+	LoadTaskStep(ctx context.Context, taskID, workflowID, stepID string) (StepSpec, error)
+	LoadTaskNextStep(ctx context.Context, taskID, workflowID string, currentPosition int) (StepSpec, error)
+	LoadTaskPreviousStep(ctx context.Context, taskID, workflowID string, currentPosition int) (StepSpec, error)
+}
+
+// loadStep loads stepID as state's task runs it.
+func (e *Engine) loadStep(ctx context.Context, state MachineState, stepID string) (StepSpec, error) {
+	if loader, ok := e.store.(TaskStepLoader); ok && state.TaskID != "" {
+		return loader.LoadTaskStep(ctx, state.TaskID, state.WorkflowID, stepID)
+	}
+	return e.store.LoadStep(ctx, state.WorkflowID, stepID)
+}
+
+func (e *Engine) loadNextStep(ctx context.Context, state MachineState, currentPosition int) (StepSpec, error) {
+	if loader, ok := e.store.(TaskStepLoader); ok && state.TaskID != "" {
+		return loader.LoadTaskNextStep(ctx, state.TaskID, state.WorkflowID, currentPosition)
+	}
+	return e.store.LoadNextStep(ctx, state.WorkflowID, currentPosition)
+}
+
+func (e *Engine) loadPreviousStep(ctx context.Context, state MachineState, currentPosition int) (StepSpec, error) {
+	if loader, ok := e.store.(TaskStepLoader); ok && state.TaskID != "" {
+		return loader.LoadTaskPreviousStep(ctx, state.TaskID, state.WorkflowID, currentPosition)
+	}
+	return e.store.LoadPreviousStep(ctx, state.WorkflowID, currentPosition)
+}
+
 // HandleInput is the input envelope for handling a workflow trigger.
 type HandleInput struct {
 	TaskID       string
@@ -112,6 +145,16 @@ type HandleResult struct {
 	// abandoning is an expected outcome of concurrent re-evaluation, not a
 	// failure.
 	TransitionAbandoned bool
+
+	// Check is the run_check gate evaluated for this trigger, if any. A
+	// failed check that keeps the task in place still reports here so the
+	// caller can feed Check.FeedbackPrompt() back to the agent.
+	Check *CheckOutcome
+
+	// IterationLimit is set when the routed target's max_iterations was
+	// reached and its on_iteration_limit actions decided the outcome
+	// instead; ToStepID is then where they routed, if anywhere.
+	IterationLimit *IterationLimit
 }
 
 // Option configures an Engine at construction time. Use With* helpers below.
@@ -175,6 +218,14 @@ type Engine struct {
 	// Phase 8 (ADR-0004) dependencies — also nil-safe.
 	taskCreator      TaskCreator
 	workflowSwitcher WorkflowSwitcher
+	// run_check dependencies — nil-safe until a step uses run_check.
+	checkRunner   CheckRunner
+	checkRecorder CheckRecorder
+	// guardFacts feeds task/pr/review facts to expression guards — nil-safe
+	// until a guard reads one of those roots.
+	guardFacts GuardFactsProvider
+	// guardObserver is nil-safe; only the workflow simulator wires one.
+	guardObserver GuardObserver
 	// logger is nil-safe (AC-24): *logger.Logger methods are not nil-safe
 	// themselves, so every use is guarded by an explicit nil check.
 	logger *logger.Logger
@@ -228,6 +279,10 @@ func (e *Engine) HandleTrigger(ctx context.Context, in HandleInput) (HandleResul
 	if err != nil {
 		return HandleResult{}, err
 	}
+	if result.Check != nil && result.Check.Pending {
+		// CompletePendingCheck marks the operation once the gate ran.
+		return result, nil
+	}
 
 	return result, e.markOperationApplied(ctx, in.OperationID)
 }
@@ -240,10 +295,23 @@ func (e *Engine) processActions(
 	step StepSpec,
 	actions []Action,
 ) (HandleResult, error) {
-	targetStepID, dataPatch, err := e.evaluateActions(ctx, in, state, step, actions)
+	eval, err := e.evaluateActions(ctx, in, state, step, actions)
 	if err != nil {
 		return HandleResult{}, err
 	}
+	return e.commitEvaluation(ctx, in, state, eval, len(actions))
+}
+
+// commitEvaluation persists the evaluation's data and applies its
+// transition, unless the trigger is evaluate-only.
+func (e *Engine) commitEvaluation(
+	ctx context.Context,
+	in HandleInput,
+	state MachineState,
+	eval actionEvaluation,
+	actionCount int,
+) (HandleResult, error) {
+	targetStepID, dataPatch := eval.targetStepID, eval.dataPatch
 
 	if len(dataPatch) > 0 && !in.EvaluateOnly {
 		if err := e.store.PersistData(ctx, in.SessionID, dataPatch); err != nil {
@@ -251,7 +319,7 @@ func (e *Engine) processActions(
 		}
 	}
 
-	result := HandleResult{DataPatch: dataPatch, ActionCount: len(actions)}
+	result := HandleResult{DataPatch: dataPatch, ActionCount: actionCount, Check: eval.check, IterationLimit: eval.iterationLimit}
 	if targetStepID != "" && targetStepID != state.CurrentStepID {
 		if !in.EvaluateOnly {
 			if err := e.applyTransition(ctx, in, state, targetStepID); err != nil {
@@ -291,41 +359,78 @@ func (e *Engine) loadExecutionContext(ctx context.Context, in HandleInput) (Mach
 			return MachineState{}, StepSpec{}, err
 		}
 	}
-	step, err := e.store.LoadStep(ctx, state.WorkflowID, state.CurrentStepID)
+	step, err := e.loadStep(ctx, state, state.CurrentStepID)
 	if err != nil {
 		return MachineState{}, StepSpec{}, err
 	}
 	return state, step, nil
 }
 
+// actionEvaluation is what evaluateActions decided for one trigger.
+type actionEvaluation struct {
+	targetStepID   string
+	dataPatch      map[string]any
+	check          *CheckOutcome
+	iterationLimit *IterationLimit
+}
+
+// evaluateActions walks the trigger's actions in order. The first routing
+// action — an eligible transition or a run_check gate — claims the routing
+// decision; later routing actions are skipped. A run_check that keeps the
+// task in place still claims it, so a failing gate followed by
+// move_to_next does not advance. The routed target is then subject to its
+// max_iterations bound.
 func (e *Engine) evaluateActions(
 	ctx context.Context,
 	in HandleInput,
 	state MachineState,
 	step StepSpec,
 	actions []Action,
-) (string, map[string]any, error) {
-	var targetStepID string
-	dataPatch := map[string]any{}
+) (actionEvaluation, error) {
+	eval := actionEvaluation{dataPatch: map[string]any{}}
+	routed := false
 	for _, action := range actions {
-		if targetStepID == "" && isTransitionAction(action.Kind) && !action.RequiresApproval {
-			outcome := e.evaluateTransitionGuard(ctx, state, action)
+		if action.Kind == ActionRunCheck {
+			if routed {
+				continue
+			}
+			check, err := e.evaluateRunCheck(ctx, in, state, step, action)
+			if err != nil {
+				return actionEvaluation{}, err
+			}
+			routed = true
+			eval.check = check
+			eval.targetStepID = check.TargetStepID
+			if !check.Pending {
+				eval.dataPatch[LastCheckDataKey] = checkDataPatch(*check)
+			}
+			continue
+		}
+		if !routed && isTransitionAction(action.Kind) && !action.RequiresApproval {
+			outcome := e.evaluateTransitionGuard(ctx, withDataPatch(state, eval.dataPatch), action)
+			e.observeGuard(ctx, state, in.Trigger, action, outcome)
 			if !outcome.Satisfied {
 				e.recordGuardNotFired(state, action, outcome)
 				continue
 			}
 			resolvedTarget, err := e.resolveTransitionTarget(ctx, state, step, action)
 			if err != nil {
-				return "", nil, err
+				return actionEvaluation{}, err
 			}
-			targetStepID = resolvedTarget
+			routed = true
+			eval.targetStepID = resolvedTarget
 			continue
 		}
-		if err := e.executeCallback(ctx, in, state, step, action, dataPatch); err != nil {
-			return "", nil, err
+		if err := e.executeCallback(ctx, in, state, step, action, eval.dataPatch); err != nil {
+			return actionEvaluation{}, err
 		}
 	}
-	return targetStepID, dataPatch, nil
+	target, limit, err := e.boundTransition(ctx, in, state, eval.targetStepID, eval.dataPatch)
+	if err != nil {
+		return actionEvaluation{}, err
+	}
+	eval.targetStepID, eval.iterationLimit = target, limit
+	return eval, nil
 }
 
 func (e *Engine) applyTransition(ctx context.Context, in HandleInput, state MachineState, targetStepID string) error {
@@ -362,13 +467,13 @@ func (e *Engine) executeCallback(
 func (e *Engine) resolveTransitionTarget(ctx context.Context, state MachineState, step StepSpec, action Action) (string, error) {
 	switch action.Kind {
 	case ActionMoveToNext:
-		next, err := e.store.LoadNextStep(ctx, state.WorkflowID, step.Position)
+		next, err := e.loadNextStep(ctx, state, step.Position)
 		if err != nil {
 			return "", err
 		}
 		return next.ID, nil
 	case ActionMoveToPrevious:
-		prev, err := e.store.LoadPreviousStep(ctx, state.WorkflowID, step.Position)
+		prev, err := e.loadPreviousStep(ctx, state, step.Position)
 		if err != nil {
 			return "", err
 		}
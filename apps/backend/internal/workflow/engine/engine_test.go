@@ -394,3 +394,68 @@ func TestHandleTrigger_LoadPreviousStep(t *testing.T) {
 		t.Fatalf("expected target step-1, got %q", result.ToStepID)
 	}
 }
+
+// pinnedStore serves task t1 the step definitions of its pinned revision and
+// every other caller the live ones.
+type pinnedStore struct {
+	*fakeStore
+	pinnedSteps map[string]StepSpec
+	pinnedNext  map[int]StepSpec
+}
+
+func (s *pinnedStore) LoadTaskStep(ctx context.Context, taskID, workflowID, stepID string) (StepSpec, error) {
+	if step, ok := s.pinnedSteps[stepID]; ok && taskID == "t1" {
+		return step, nil
+	}
+	return s.LoadStep(ctx, workflowID, stepID)
+}
+
+func (s *pinnedStore) LoadTaskNextStep(ctx context.Context, taskID, workflowID string, currentPosition int) (StepSpec, error) {
+	if step, ok := s.pinnedNext[currentPosition]; ok && taskID == "t1" {
+		return step, nil
+	}
+	return s.LoadNextStep(ctx, workflowID, currentPosition)
+}
+
+func (s *pinnedStore) LoadTaskPreviousStep(ctx context.Context, _, workflowID string, currentPosition int) (StepSpec, error) {
+	return s.LoadPreviousStep(ctx, workflowID, currentPosition)
+}
+
+func TestHandleTrigger_TaskStepLoaderServesPinnedRevision(t *testing.T) {
+	store := &pinnedStore{
+		fakeStore: &fakeStore{
+			state: MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf1", CurrentStepID: "step-1"},
+			// The live step-1 was edited to jump straight to done.
+			stepsByID: map[string]StepSpec{
+				"step-1": {
+					ID:       "step-1",
+					Position: 1,
+					Events: map[Trigger][]Action{
+						TriggerOnTurnComplete: {{Kind: ActionMoveToStep, MoveToStep: &MoveToStepAction{StepID: "done"}}},
+					},
+				},
+			},
+			nextSteps: map[int]StepSpec{1: {ID: "done", Position: 3}},
+			applied:   map[string]bool{},
+		},
+		pinnedSteps: map[string]StepSpec{
+			"step-1": {
+				ID:       "step-1",
+				Position: 1,
+				Events:   map[Trigger][]Action{TriggerOnTurnComplete: {{Kind: ActionMoveToNext}}},
+			},
+		},
+		pinnedNext: map[int]StepSpec{1: {ID: "review", Position: 2}},
+	}
+
+	eng := New(store, MapRegistry{})
+	result, err := eng.HandleTrigger(context.Background(), HandleInput{
+		TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Transitioned || result.ToStepID != "review" {
+		t.Fatalf("expected the pinned move_to_next to review, got %+v", result)
+	}
+}
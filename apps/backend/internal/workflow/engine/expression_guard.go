@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/kandev/kandev/internal/workflow/guardexpr"
+	"go.uber.org/zap"
+)
+
+// Reason codes specific to expression guards. They share GuardOutcome.Reason
+// with the AC-23 quorum codes (compile and evaluation failures reuse
+// ReasonEvaluationError) but are not counted by quorummetrics.
+const (
+	ReasonExpressionFalse   = "expression_false"
+	ReasonGuardFactsUnwired = "guard_facts_unwired"
+)
+
+// WithGuardFactsProvider wires the task/pr/review facts expression guards
+// read. Guards that only read the data bag work without it.
+func WithGuardFactsProvider(provider GuardFactsProvider) Option {
+	return func(e *Engine) { e.guardFacts = provider }
+}
+
+// evaluateExpressionGuard evaluates an expression guard, failing closed on
+// compile errors, missing facts and type errors.
+func (e *Engine) evaluateExpressionGuard(ctx context.Context, state MachineState, guard *ExpressionGuard) GuardOutcome {
+	if guard.Program == nil {
+		err := guard.CompileErr
+		if err == nil {
+			err = errors.New("guard expression not compiled")
+		}
+		return GuardOutcome{Reason: ReasonEvaluationError, Err: fmt.Errorf("compile guard expression: %w", err)}
+	}
+	env := map[string]any{guardexpr.RootData: state.Data}
+	if needsGuardFacts(guard.Program) {
+		if e.guardFacts == nil {
+			return GuardOutcome{Reason: ReasonGuardFactsUnwired}
+		}
+		facts, err := e.guardFacts.LoadGuardFacts(ctx, state.TaskID)
+		if err != nil {
+			return GuardOutcome{Reason: ReasonEvaluationError, Err: fmt.Errorf("load guard facts: %w", err)}
+		}
+		env[guardexpr.RootTask] = facts.Task
+		env[guardexpr.RootPR] = facts.PR
+		env[guardexpr.RootReview] = facts.Review
+	}
+	ok, err := guard.Program.Eval(env)
+	if err != nil {
+		return GuardOutcome{Reason: ReasonEvaluationError, Err: fmt.Errorf("evaluate guard expression: %w", err)}
+	}
+	if !ok {
+		return GuardOutcome{Reason: ReasonExpressionFalse}
+	}
+	return GuardOutcome{Satisfied: true}
+}
+
+func needsGuardFacts(program *guardexpr.Program) bool {
+	return program.Uses(guardexpr.RootTask) || program.Uses(guardexpr.RootPR) || program.Uses(guardexpr.RootReview)
+}
+
+// recordExpressionGuardNotFired logs an expression guard that did not fire.
+// A false expression is routine (the guard is doing its job) and logs at
+// debug; anything else means the guard cannot be evaluated and warns.
+func (e *Engine) recordExpressionGuardNotFired(state MachineState, guard *ExpressionGuard, outcome GuardOutcome) {
+	if e.logger == nil {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("task_id", state.TaskID),
+		zap.String("step_id", state.CurrentStepID),
+		zap.String("guard_expression", guard.Source),
+		zap.String("reason", outcome.Reason),
+	}
+	if outcome.Reason == ReasonExpressionFalse {
+		e.logger.Debug("workflow expression guard did not fire", fields...)
+		return
+	}
+	if outcome.Err != nil {
+		fields = append(fields, zap.Error(outcome.Err))
+	}
+	e.logger.Warn("workflow expression guard could not be evaluated", fields...)
+}
+
+// withDataPatch returns state with patch overlaid on its data bag, so a
+// guard sees values written by set_workflow_data earlier in the same
+// trigger. The original map is not mutated.
+func withDataPatch(state MachineState, patch map[string]any) MachineState {
+	if len(patch) == 0 {
+		return state
+	}
+	data := make(map[string]any, len(state.Data)+len(patch))
+	maps.Copy(data, state.Data)
+	maps.Copy(data, patch)
+	state.Data = data
+	return state
+}
@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+type fakeGuardFacts struct {
+	facts GuardFacts
+	err   error
+	calls int
+}
+
+func (f *fakeGuardFacts) LoadGuardFacts(_ context.Context, _ string) (GuardFacts, error) {
+	f.calls++
+	return f.facts, f.err
+}
+
+func guardedStore(data map[string]any, actions ...Action) *fakeStore {
+	return &fakeStore{
+		state: MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf", CurrentStepID: "review", Data: data},
+		stepsByID: map[string]StepSpec{
+			"review": {
+				ID: "review", WorkflowID: "wf", Position: 2,
+				Events: map[Trigger][]Action{TriggerOnTurnComplete: actions},
+			},
+		},
+		nextSteps: map[int]StepSpec{2: {ID: "ready", Position: 3}},
+		applied:   map[string]bool{},
+	}
+}
+
+func exprAction(kind ActionKind, expr, target string) Action {
+	a := Action{Kind: kind, Guard: ConfigTransitionGuard(map[string]any{"if": expr})}
+	if target != "" {
+		a.MoveToStep = &MoveToStepAction{StepID: target}
+	}
+	return a
+}
+
+func TestConfigTransitionGuard_Expression(t *testing.T) {
+	guard := ConfigTransitionGuard(map[string]any{"if": `pr.checks == "success"`})
+	if guard == nil || guard.Expression == nil || guard.WaitForQuorum != nil {
+		t.Fatalf("expected expression guard, got %+v", guard)
+	}
+	if guard.Expression.Program == nil || guard.Expression.CompileErr != nil {
+		t.Fatalf("expected compiled program, got %+v", guard.Expression)
+	}
+
+	broken := ConfigTransitionGuard(map[string]any{"if": `pr.checks ==`})
+	if broken == nil || broken.Expression == nil || broken.Expression.CompileErr == nil {
+		t.Fatalf("malformed expressions must still produce a (failing) guard, got %+v", broken)
+	}
+}
+
+func TestCompileStep_ExpressionGuard(t *testing.T) {
+	compiled := CompileStep(&wfmodels.WorkflowStep{
+		ID: "review",
+		Events: wfmodels.StepEvents{
+			OnTurnComplete: []wfmodels.OnTurnCompleteAction{{
+				Type:   wfmodels.OnTurnCompleteMoveToStep,
+				Config: map[string]any{"step_id": "ready", "if": "review.blocking_findings == 0"},
+			}},
+		},
+	})
+	actions := compiled.Events[TriggerOnTurnComplete]
+	if len(actions) != 1 || actions[0].Guard == nil || actions[0].Guard.Expression == nil {
+		t.Fatalf("expected guarded move_to_step, got %+v", actions)
+	}
+}
+
+func TestExpressionGuard_RoutesOnFacts(t *testing.T) {
+	store := guardedStore(nil,
+		exprAction(ActionMoveToStep, `review.blocking_findings == 0 && pr.checks == "success"`, "merge"),
+		exprAction(ActionMoveToStep, `review.blocking_findings > 0`, "fix"),
+	)
+	facts := &fakeGuardFacts{facts: GuardFacts{
+		PR:     map[string]any{"checks": "success"},
+		Review: map[string]any{"blocking_findings": 2},
+	}}
+	eng := New(store, MapRegistry{}, WithGuardFactsProvider(facts))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "fix" {
+		t.Fatalf("expected transition to fix, got %+v", res)
+	}
+
+	facts.facts.Review["blocking_findings"] = 0
+	store.state.CurrentStepID = "review"
+	res, err = eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ToStepID != "merge" {
+		t.Fatalf("expected transition to merge, got %+v", res)
+	}
+}
+
+func TestExpressionGuard_FalseKeepsTaskInPlace(t *testing.T) {
+	store := guardedStore(map[string]any{"approved": false},
+		exprAction(ActionMoveToNext, "data.approved", ""),
+	)
+	eng := New(store, MapRegistry{})
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Transitioned {
+		t.Fatalf("false guard must not transition, got %+v", res)
+	}
+}
+
+func TestExpressionGuard_SeesDataWrittenEarlierInTrigger(t *testing.T) {
+	store := guardedStore(nil,
+		Action{Kind: ActionSetWorkflowData},
+		exprAction(ActionMoveToNext, `data.verdict == "ship"`, ""),
+	)
+	registry := MapRegistry{
+		ActionSetWorkflowData: &fakeCallback{result: ActionResult{DataPatch: map[string]any{"verdict": "ship"}}},
+	}
+	eng := New(store, registry)
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ToStepID != "ready" {
+		t.Fatalf("expected guard to see the pending data patch, got %+v", res)
+	}
+}
+
+func TestEvaluateExpressionGuard_FailsClosed(t *testing.T) {
+	state := MachineState{TaskID: "t1", Data: map[string]any{"count": "three"}}
+	tests := []struct {
+		name       string
+		expr       string
+		facts      GuardFactsProvider
+		wantReason string
+	}{
+		{"compile error", "data.count ==", nil, ReasonEvaluationError},
+		{"facts unwired", "pr.checks == \"success\"", nil, ReasonGuardFactsUnwired},
+		{"facts error", "task.priority == \"high\"", &fakeGuardFacts{err: errors.New("db down")}, ReasonEvaluationError},
+		{"type error", "data.count > 2", nil, ReasonEvaluationError},
+		{"false", "data.count == \"four\"", nil, ReasonExpressionFalse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.facts != nil {
+				opts = append(opts, WithGuardFactsProvider(tt.facts))
+			}
+			eng := New(&fakeStore{}, MapRegistry{}, opts...)
+			got := eng.evaluateTransitionGuard(context.Background(), state, exprAction(ActionMoveToNext, tt.expr, ""))
+			if got.Satisfied || got.Reason != tt.wantReason {
+				t.Fatalf("got %+v, want reason %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestEvaluateExpressionGuard_DataOnlySkipsFacts(t *testing.T) {
+	facts := &fakeGuardFacts{}
+	eng := New(&fakeStore{}, MapRegistry{}, WithGuardFactsProvider(facts))
+	state := MachineState{TaskID: "t1", Data: map[string]any{"ok": true}}
+	got := eng.evaluateTransitionGuard(context.Background(), state, exprAction(ActionMoveToNext, "data.ok", ""))
+	if !got.Satisfied {
+		t.Fatalf("expected satisfied guard, got %+v", got)
+	}
+	if facts.calls != 0 {
+		t.Fatalf("data-only guards must not load facts, got %d calls", facts.calls)
+	}
+}
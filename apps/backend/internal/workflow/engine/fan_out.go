@@ -0,0 +1,305 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+// FanOutAction runs the step's prompt once per agent profile, each in its
+// own child task (and so its own worktree and branch), then joins the
+// results with Join — one of the wfmodels.FanOutJoin* strategies.
+//
+// BranchWorkflowID / BranchStepID pin where the branch tasks run; empty
+// values fall back to the parent's workflow and its first runnable step,
+// exactly like create_child_task.
+type FanOutAction struct {
+	AgentProfileIDs     []string
+	Join                string
+	JudgeAgentProfileID string
+	BranchWorkflowID    string
+	BranchStepID        string
+}
+
+// readFanOutConfig reads a fan_out action's config map. Returns nil when
+// the config fails wfmodels.ValidateFanOutConfig so a half-configured
+// fan-out is skipped rather than launching a single branch.
+func readFanOutConfig(config map[string]any) *FanOutAction {
+	if wfmodels.ValidateFanOutConfig(config) != nil {
+		return nil
+	}
+	judge, _ := config[wfmodels.FanOutJudgeProfileConfigKey].(string)
+	workflowID, _ := config[wfmodels.FanOutBranchWorkflowConfigKey].(string)
+	stepID, _ := config[wfmodels.FanOutBranchStepConfigKey].(string)
+	return &FanOutAction{
+		AgentProfileIDs:     wfmodels.FanOutAgentProfileIDs(config),
+		Join:                wfmodels.FanOutJoin(config),
+		JudgeAgentProfileID: strings.TrimSpace(judge),
+		BranchWorkflowID:    workflowID,
+		BranchStepID:        stepID,
+	}
+}
+
+// FanOutRequest is the typed payload the engine hands to FanOutLauncher.
+type FanOutRequest struct {
+	TaskID              string
+	SessionID           string
+	StepID              string
+	OperationID         string
+	AgentProfileIDs     []string
+	Join                string
+	JudgeAgentProfileID string
+	BranchWorkflowID    string
+	BranchStepID        string
+}
+
+// FanOutLauncher creates and starts the branch tasks of a fan-out and
+// records the FanOutGroup the join is tracked by (the orchestrator in
+// production). Implementations must treat a second launch for a step whose
+// group is still unsettled as a no-op.
+type FanOutLauncher interface {
+	LaunchFanOut(ctx context.Context, req FanOutRequest) error
+}
+
+// FanOutCallback executes the fan_out action through the wired
+// FanOutLauncher.
+type FanOutCallback struct {
+	Launcher FanOutLauncher
+}
+
+// Execute satisfies ActionCallback.
+func (c FanOutCallback) Execute(ctx context.Context, in ActionInput) (ActionResult, error) {
+	if c.Launcher == nil {
+		return ActionResult{}, fmt.Errorf("%w: fan_out requires FanOutLauncher", ErrActionNotYetWired)
+	}
+	cfg := in.Action.FanOut
+	if cfg == nil {
+		return ActionResult{}, fmt.Errorf("fan_out action missing FanOut config")
+	}
+	if in.State.TaskID == "" || in.State.SessionID == "" {
+		return ActionResult{}, fmt.Errorf("fan_out: trigger has no task/session id")
+	}
+	err := c.Launcher.LaunchFanOut(ctx, FanOutRequest{
+		TaskID:              in.State.TaskID,
+		SessionID:           in.State.SessionID,
+		StepID:              in.Step.ID,
+		OperationID:         in.OperationID,
+		AgentProfileIDs:     cfg.AgentProfileIDs,
+		Join:                cfg.Join,
+		JudgeAgentProfileID: cfg.JudgeAgentProfileID,
+		BranchWorkflowID:    cfg.BranchWorkflowID,
+		BranchStepID:        cfg.BranchStepID,
+	})
+	if err != nil {
+		return ActionResult{}, fmt.Errorf("fan_out: %w", err)
+	}
+	return ActionResult{}, nil
+}
+
+var _ ActionCallback = FanOutCallback{}
+
+// FanOutDataKey is the workflow data key holding the parent's FanOutGroup.
+// Guards read it as data.fan_out.status, data.fan_out.winner_task_id, …
+const FanOutDataKey = "fan_out"
+
+// FanOutStatus is the lifecycle of a fan-out join.
+type FanOutStatus string
+
+const (
+	// FanOutStatusRunning: branches are still working.
+	FanOutStatusRunning FanOutStatus = "running"
+	// FanOutStatusJudging: every branch finished; the judge task is picking.
+	FanOutStatusJudging FanOutStatus = "judging"
+	// FanOutStatusAwaitingSelection: every branch finished; a user picks.
+	FanOutStatusAwaitingSelection FanOutStatus = "awaiting_selection"
+	// FanOutStatusJoined: a winner was chosen.
+	FanOutStatusJoined FanOutStatus = "joined"
+	// FanOutStatusFailed: every branch failed; there is nothing to pick.
+	FanOutStatusFailed FanOutStatus = "failed"
+)
+
+// Settled reports whether the join has reached a final outcome.
+func (s FanOutStatus) Settled() bool {
+	return s == FanOutStatusJoined || s == FanOutStatusFailed
+}
+
+// FanOutBranchOutcome is what a branch task's state means to the join.
+type FanOutBranchOutcome string
+
+const (
+	FanOutBranchPending   FanOutBranchOutcome = "pending"
+	FanOutBranchSucceeded FanOutBranchOutcome = "succeeded"
+	FanOutBranchFailed    FanOutBranchOutcome = "failed"
+)
+
+// FanOutBranch is one branch task of a fan-out. Outcome is derived from the
+// task's live state and is not persisted.
+type FanOutBranch struct {
+	TaskID         string
+	AgentProfileID string
+	Outcome        FanOutBranchOutcome
+}
+
+// FanOutGroup is the join state of a fan-out, persisted in the parent
+// session's workflow data under FanOutDataKey.
+//
+// SelectedBy records who chose the winner: "auto" for first_success and
+// single-success joins, "judge" or "user" otherwise. Reason is the
+// judge's or user's explanation, when one was given. WinnerMerged reports
+// whether the winner's branch was merged into the parent's workspace;
+// MergeError says why not, when the merge was attempted and failed.
+type FanOutGroup struct {
+	StepID              string
+	Join                string
+	JudgeAgentProfileID string
+	BranchWorkflowID    string
+	BranchStepID        string
+	Status              FanOutStatus
+	Branches            []FanOutBranch
+	WinnerTaskID        string
+	JudgeTaskID         string
+	SelectedBy          string
+	Reason              string
+	WinnerMerged        bool
+	MergeError          string
+}
+
+// Branch returns the branch running as taskID.
+func (g *FanOutGroup) Branch(taskID string) (FanOutBranch, bool) {
+	for _, b := range g.Branches {
+		if b.TaskID == taskID {
+			return b, true
+		}
+	}
+	return FanOutBranch{}, false
+}
+
+// ToData renders the group as a workflow data value.
+func (g *FanOutGroup) ToData() map[string]any {
+	branches := make([]any, 0, len(g.Branches))
+	winnerProfile := ""
+	for _, b := range g.Branches {
+		branches = append(branches, map[string]any{
+			"task_id":          b.TaskID,
+			"agent_profile_id": b.AgentProfileID,
+		})
+		if b.TaskID == g.WinnerTaskID {
+			winnerProfile = b.AgentProfileID
+		}
+	}
+	return map[string]any{
+		"step_id":                 g.StepID,
+		"join":                    g.Join,
+		"judge_agent_profile_id":  g.JudgeAgentProfileID,
+		"branch_workflow_id":      g.BranchWorkflowID,
+		"branch_step_id":          g.BranchStepID,
+		"status":                  string(g.Status),
+		"branches":                branches,
+		"winner_task_id":          g.WinnerTaskID,
+		"winner_agent_profile_id": winnerProfile,
+		"judge_task_id":           g.JudgeTaskID,
+		"selected_by":             g.SelectedBy,
+		"selection_reason":        g.Reason,
+		"winner_merged":           g.WinnerMerged,
+		"merge_error":             g.MergeError,
+	}
+}
+
+// FanOutGroupFromData reads the group stored under FanOutDataKey in a
+// workflow data bag. It accepts the JSON-decoded shape as well as ToData's.
+func FanOutGroupFromData(data map[string]any) (*FanOutGroup, bool) {
+	raw, ok := data[FanOutDataKey].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	str := func(key string) string {
+		v, _ := raw[key].(string)
+		return v
+	}
+	g := &FanOutGroup{
+		StepID:              str("step_id"),
+		Join:                str("join"),
+		JudgeAgentProfileID: str("judge_agent_profile_id"),
+		BranchWorkflowID:    str("branch_workflow_id"),
+		BranchStepID:        str("branch_step_id"),
+		Status:              FanOutStatus(str("status")),
+		WinnerTaskID:        str("winner_task_id"),
+		JudgeTaskID:         str("judge_task_id"),
+		SelectedBy:          str("selected_by"),
+		Reason:              str("selection_reason"),
+		MergeError:          str("merge_error"),
+	}
+	g.WinnerMerged, _ = raw["winner_merged"].(bool)
+	items, _ := raw["branches"].([]any)
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		taskID, _ := m["task_id"].(string)
+		profileID, _ := m["agent_profile_id"].(string)
+		if taskID == "" {
+			continue
+		}
+		g.Branches = append(g.Branches, FanOutBranch{TaskID: taskID, AgentProfileID: profileID})
+	}
+	if g.StepID == "" || len(g.Branches) == 0 {
+		return nil, false
+	}
+	return g, true
+}
+
+// FanOutJoinDecision is DecideFanOutJoin's verdict. CancelTaskIDs lists
+// branches still running that lost a first_success race.
+type FanOutJoinDecision struct {
+	Status        FanOutStatus
+	WinnerTaskID  string
+	CancelTaskIDs []string
+}
+
+// DecideFanOutJoin applies a join strategy to the branches' outcomes.
+//
+//   - first_success joins on the first succeeded branch (in branch order
+//     when several finished together) and cancels the pending ones.
+//   - judge and human wait for every branch. With two or more successes
+//     they hand over to the judge or user; with exactly one it joins
+//     straight away, since there is nothing to choose between.
+//
+// Every strategy fails once all branches failed.
+func DecideFanOutJoin(join string, branches []FanOutBranch) FanOutJoinDecision {
+	var succeeded []string
+	pending := 0
+	for _, b := range branches {
+		switch b.Outcome {
+		case FanOutBranchSucceeded:
+			succeeded = append(succeeded, b.TaskID)
+		case FanOutBranchFailed:
+		default:
+			pending++
+		}
+	}
+	if join == wfmodels.FanOutJoinFirstSuccess && len(succeeded) > 0 {
+		d := FanOutJoinDecision{Status: FanOutStatusJoined, WinnerTaskID: succeeded[0]}
+		for _, b := range branches {
+			if b.Outcome != FanOutBranchSucceeded && b.Outcome != FanOutBranchFailed {
+				d.CancelTaskIDs = append(d.CancelTaskIDs, b.TaskID)
+			}
+		}
+		return d
+	}
+	if pending > 0 {
+		return FanOutJoinDecision{Status: FanOutStatusRunning}
+	}
+	switch len(succeeded) {
+	case 0:
+		return FanOutJoinDecision{Status: FanOutStatusFailed}
+	case 1:
+		return FanOutJoinDecision{Status: FanOutStatusJoined, WinnerTaskID: succeeded[0]}
+	}
+	if join == wfmodels.FanOutJoinJudge {
+		return FanOutJoinDecision{Status: FanOutStatusJudging}
+	}
+	return FanOutJoinDecision{Status: FanOutStatusAwaitingSelection}
+}
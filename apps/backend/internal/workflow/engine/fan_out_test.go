@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+type fakeFanOutLauncher struct {
+	calls []FanOutRequest
+}
+
+func (l *fakeFanOutLauncher) LaunchFanOut(_ context.Context, req FanOutRequest) error {
+	l.calls = append(l.calls, req)
+	return nil
+}
+
+func TestCompileStep_FanOut(t *testing.T) {
+	compiled := CompileStep(&wfmodels.WorkflowStep{
+		ID: "implement",
+		Events: wfmodels.StepEvents{
+			OnEnter: []wfmodels.OnEnterAction{
+				{Type: wfmodels.OnEnterFanOut, Config: map[string]any{
+					"agent_profile_ids":      []any{"claude", "codex", "claude", " "},
+					"join":                   "judge",
+					"judge_agent_profile_id": "gemini",
+					"branch_step_id":         "solo",
+				}},
+				// Dropped: a single branch is not a fan-out.
+				{Type: wfmodels.OnEnterFanOut, Config: map[string]any{"agent_profile_ids": []any{"claude"}}},
+				// Dropped: judge join without a judge.
+				{Type: wfmodels.OnEnterFanOut, Config: map[string]any{
+					"agent_profile_ids": []any{"claude", "codex"}, "join": "judge",
+				}},
+			},
+		},
+	})
+	actions := compiled.Events[TriggerOnEnter]
+	if len(actions) != 1 {
+		t.Fatalf("expected invalid fan_out actions to be dropped, got %d actions", len(actions))
+	}
+	want := &FanOutAction{
+		AgentProfileIDs:     []string{"claude", "codex"},
+		Join:                wfmodels.FanOutJoinJudge,
+		JudgeAgentProfileID: "gemini",
+		BranchStepID:        "solo",
+	}
+	if !reflect.DeepEqual(actions[0].FanOut, want) {
+		t.Fatalf("compiled fan_out = %+v, want %+v", actions[0].FanOut, want)
+	}
+}
+
+func TestFanOutCallback_DelegatesToLauncher(t *testing.T) {
+	launcher := &fakeFanOutLauncher{}
+	store := &fakeStore{
+		state: MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf", CurrentStepID: "race"},
+		stepsByID: map[string]StepSpec{
+			"race": {ID: "race", WorkflowID: "wf", Events: map[Trigger][]Action{
+				TriggerOnEnter: {{Kind: ActionFanOut, FanOut: &FanOutAction{
+					AgentProfileIDs: []string{"claude", "codex"},
+					Join:            wfmodels.FanOutJoinFirstSuccess,
+				}}},
+			}},
+		},
+		applied: map[string]bool{},
+	}
+	eng := New(store, MapRegistry{ActionFanOut: FanOutCallback{Launcher: launcher}})
+	if _, err := eng.HandleTrigger(context.Background(), HandleInput{
+		TaskID: "t1", SessionID: "s1", Trigger: TriggerOnEnter, OperationID: "enter:1",
+	}); err != nil {
+		t.Fatalf("HandleTrigger: %v", err)
+	}
+	if len(launcher.calls) != 1 {
+		t.Fatalf("got %d launches, want 1", len(launcher.calls))
+	}
+	got := launcher.calls[0]
+	if got.TaskID != "t1" || got.SessionID != "s1" || got.StepID != "race" || got.OperationID != "enter:1" ||
+		!reflect.DeepEqual(got.AgentProfileIDs, []string{"claude", "codex"}) {
+		t.Fatalf("unexpected launch request: %+v", got)
+	}
+
+	_, err := FanOutCallback{}.Execute(context.Background(), ActionInput{})
+	if !errors.Is(err, ErrActionNotYetWired) {
+		t.Fatalf("expected ErrActionNotYetWired without a launcher, got %v", err)
+	}
+}
+
+func TestDecideFanOutJoin(t *testing.T) {
+	branches := func(outcomes ...FanOutBranchOutcome) []FanOutBranch {
+		out := make([]FanOutBranch, len(outcomes))
+		for i, o := range outcomes {
+			out[i] = FanOutBranch{TaskID: string(rune('a' + i)), Outcome: o}
+		}
+		return out
+	}
+	cases := []struct {
+		name     string
+		join     string
+		branches []FanOutBranch
+		want     FanOutJoinDecision
+	}{
+		{
+			name:     "first_success waits while nothing succeeded",
+			join:     wfmodels.FanOutJoinFirstSuccess,
+			branches: branches(FanOutBranchFailed, FanOutBranchPending),
+			want:     FanOutJoinDecision{Status: FanOutStatusRunning},
+		},
+		{
+			name:     "first_success cancels the pending branches",
+			join:     wfmodels.FanOutJoinFirstSuccess,
+			branches: branches(FanOutBranchPending, FanOutBranchSucceeded, FanOutBranchFailed, FanOutBranchPending),
+			want:     FanOutJoinDecision{Status: FanOutStatusJoined, WinnerTaskID: "b", CancelTaskIDs: []string{"a", "d"}},
+		},
+		{
+			name:     "judge waits for every branch",
+			join:     wfmodels.FanOutJoinJudge,
+			branches: branches(FanOutBranchSucceeded, FanOutBranchPending),
+			want:     FanOutJoinDecision{Status: FanOutStatusRunning},
+		},
+		{
+			name:     "judge picks between several successes",
+			join:     wfmodels.FanOutJoinJudge,
+			branches: branches(FanOutBranchSucceeded, FanOutBranchSucceeded, FanOutBranchFailed),
+			want:     FanOutJoinDecision{Status: FanOutStatusJudging},
+		},
+		{
+			name:     "human picks between several successes",
+			join:     wfmodels.FanOutJoinHuman,
+			branches: branches(FanOutBranchSucceeded, FanOutBranchSucceeded),
+			want:     FanOutJoinDecision{Status: FanOutStatusAwaitingSelection},
+		},
+		{
+			name:     "a single success needs no choice",
+			join:     wfmodels.FanOutJoinHuman,
+			branches: branches(FanOutBranchFailed, FanOutBranchSucceeded),
+			want:     FanOutJoinDecision{Status: FanOutStatusJoined, WinnerTaskID: "b"},
+		},
+		{
+			name:     "all branches failed",
+			join:     wfmodels.FanOutJoinJudge,
+			branches: branches(FanOutBranchFailed, FanOutBranchFailed),
+			want:     FanOutJoinDecision{Status: FanOutStatusFailed},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DecideFanOutJoin(tc.join, tc.branches); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFanOutGroupDataRoundTrip(t *testing.T) {
+	group := &FanOutGroup{
+		StepID: "race",
+		Join:   wfmodels.FanOutJoinHuman,
+		Status: FanOutStatusJoined,
+		Branches: []FanOutBranch{
+			{TaskID: "c1", AgentProfileID: "claude"},
+			{TaskID: "c2", AgentProfileID: "codex"},
+		},
+		WinnerTaskID: "c2",
+		SelectedBy:   "user",
+		Reason:       "smaller diff",
+		WinnerMerged: true,
+	}
+	data := map[string]any{FanOutDataKey: group.ToData()}
+	if got := data[FanOutDataKey].(map[string]any)["winner_agent_profile_id"]; got != "codex" {
+		t.Fatalf("winner_agent_profile_id = %v, want codex", got)
+	}
+	decoded, ok := FanOutGroupFromData(data)
+	if !ok {
+		t.Fatal("expected group to decode")
+	}
+	if !reflect.DeepEqual(decoded, group) {
+		t.Fatalf("round trip = %+v, want %+v", decoded, group)
+	}
+	if _, ok := FanOutGroupFromData(map[string]any{}); ok {
+		t.Fatal("expected empty bag to have no group")
+	}
+}
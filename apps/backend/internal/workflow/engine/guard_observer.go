@@ -0,0 +1,51 @@
+package engine
+
+import "context"
+
+// GuardEvaluation is one transition guard the engine evaluated while
+// handling a trigger or re-evaluating quorum after a decision, whether or
+// not it fired.
+type GuardEvaluation struct {
+	TaskID    string
+	StepID    string
+	Trigger   Trigger
+	Action    ActionKind
+	Guard     *TransitionGuard
+	Satisfied bool
+	Reason    string
+	Err       error
+	// RequiredCount and ReceivedCount are set for wait_for_quorum guards.
+	RequiredCount int
+	ReceivedCount int
+}
+
+// GuardObserver receives every evaluated transition guard. The workflow
+// simulator uses it to report guard outcomes; production wires none.
+// Implementations must not block: they run inline on the trigger path.
+type GuardObserver interface {
+	ObserveGuard(ctx context.Context, ev GuardEvaluation)
+}
+
+// WithGuardObserver wires a GuardObserver. The read-only EvaluateStepQuorum
+// snapshot never reports to it.
+func WithGuardObserver(observer GuardObserver) Option {
+	return func(e *Engine) { e.guardObserver = observer }
+}
+
+func (e *Engine) observeGuard(ctx context.Context, state MachineState, trigger Trigger, action Action, outcome GuardOutcome) {
+	if e.guardObserver == nil || action.Guard == nil {
+		return
+	}
+	e.guardObserver.ObserveGuard(ctx, GuardEvaluation{
+		TaskID:        state.TaskID,
+		StepID:        state.CurrentStepID,
+		Trigger:       trigger,
+		Action:        action.Kind,
+		Guard:         action.Guard,
+		Satisfied:     outcome.Satisfied,
+		Reason:        outcome.Reason,
+		Err:           outcome.Err,
+		RequiredCount: outcome.RequiredCount,
+		ReceivedCount: outcome.ReceivedCount,
+	})
+}
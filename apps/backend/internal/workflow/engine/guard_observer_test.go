@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingGuardObserver struct {
+	evaluations []GuardEvaluation
+}
+
+func (o *recordingGuardObserver) ObserveGuard(_ context.Context, ev GuardEvaluation) {
+	o.evaluations = append(o.evaluations, ev)
+}
+
+func TestGuardObserver_ReportsEveryEvaluatedGuard(t *testing.T) {
+	store := guardedStore(map[string]any{"score": 3},
+		exprAction(ActionMoveToStep, `data.score > 5`, "merge"),
+		Action{Kind: ActionMoveToNext},
+		exprAction(ActionMoveToStep, `data.score > 1`, "fix"),
+	)
+	observer := &recordingGuardObserver{}
+	eng := New(store, MapRegistry{}, WithGuardObserver(observer))
+
+	result, err := eng.HandleTrigger(context.Background(), HandleInput{
+		TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete,
+	})
+	if err != nil {
+		t.Fatalf("HandleTrigger: %v", err)
+	}
+	if result.ToStepID != "ready" {
+		t.Fatalf("expected the unguarded move_to_next to win, got %q", result.ToStepID)
+	}
+	// Unguarded actions are not reported, and the guard after the routing
+	// decision is never evaluated.
+	if len(observer.evaluations) != 1 {
+		t.Fatalf("expected 1 guard evaluation, got %+v", observer.evaluations)
+	}
+	ev := observer.evaluations[0]
+	if ev.Satisfied || ev.Reason != ReasonExpressionFalse || ev.StepID != "review" ||
+		ev.Trigger != TriggerOnTurnComplete || ev.Guard.Expression.Source != `data.score > 5` {
+		t.Fatalf("unexpected evaluation: %+v", ev)
+	}
+}
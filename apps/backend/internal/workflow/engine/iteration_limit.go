@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"maps"
+)
+
+// IterationsDataKey is the data bag key holding the max_iterations
+// counters: a map from step ID to the number of automatic transitions that
+// entered the step. Only steps with max_iterations are counted, so a guard
+// can read e.g. `data.iterations["<step id>"] >= 2`. Manual moves are not
+// counted, and clear_decisions with reset_iterations empties the map.
+const IterationsDataKey = "iterations"
+
+// IterationLimit describes a transition that was refused because its
+// target step's max_iterations was reached. The target's
+// on_iteration_limit actions ran in its place.
+type IterationLimit struct {
+	StepID        string
+	MaxIterations int
+	Count         int
+}
+
+// IterationCount returns how many automatic transitions entered stepID
+// according to the data bag.
+func IterationCount(data map[string]any, stepID string) int {
+	counts, _ := data[IterationsDataKey].(map[string]any)
+	n, _ := toPositiveInt(counts[stepID])
+	return n
+}
+
+// iterationsPatch is the data bag patch recording count entries into
+// stepID, keeping the other steps' counts.
+func iterationsPatch(data map[string]any, stepID string, count int) map[string]any {
+	counts := map[string]any{}
+	if prev, ok := data[IterationsDataKey].(map[string]any); ok {
+		maps.Copy(counts, prev)
+	}
+	counts[stepID] = count
+	return map[string]any{IterationsDataKey: counts}
+}
+
+// ResetIterationsDataPatch is the data bag patch that zeroes every
+// max_iterations counter of a task.
+func ResetIterationsDataPatch() map[string]any {
+	return map[string]any{IterationsDataKey: map[string]any{}}
+}
+
+// boundTransition applies the target step's max_iterations to a transition
+// the trigger routed to targetStepID. Below the limit it counts the entry
+// into dataPatch and keeps the target. At the limit it evaluates the
+// target's on_iteration_limit actions instead and returns where they route,
+// "" when they route nowhere. Transitions routed by on_iteration_limit are
+// counted but never bounded again, so a limit cannot chain into another.
+func (e *Engine) boundTransition(
+	ctx context.Context,
+	in HandleInput,
+	state MachineState,
+	targetStepID string,
+	dataPatch map[string]any,
+) (string, *IterationLimit, error) {
+	if targetStepID == "" || targetStepID == state.CurrentStepID {
+		return targetStepID, nil, nil
+	}
+	target, err := e.loadStep(ctx, state, targetStepID)
+	if err != nil || target.MaxIterations <= 0 {
+		// A target the store cannot load is left for the transition commit
+		// to reject; bounding it is not this function's call to make.
+		return targetStepID, nil, nil
+	}
+	data := withDataPatch(state, dataPatch).Data
+	count := IterationCount(data, targetStepID)
+	if count < target.MaxIterations || in.Trigger == TriggerOnIterationLimit {
+		maps.Copy(dataPatch, iterationsPatch(data, targetStepID, count+1))
+		return targetStepID, nil, nil
+	}
+
+	limit := &IterationLimit{StepID: targetStepID, MaxIterations: target.MaxIterations, Count: count}
+	limitIn := in
+	limitIn.Trigger = TriggerOnIterationLimit
+	eval, err := e.evaluateActions(ctx, limitIn, withDataPatch(state, dataPatch), target, target.Events[TriggerOnIterationLimit])
+	if err != nil {
+		return "", nil, err
+	}
+	maps.Copy(dataPatch, eval.dataPatch)
+	return eval.targetStepID, limit, nil
+}
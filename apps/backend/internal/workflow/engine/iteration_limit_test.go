@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+func TestCompileStep_IterationLimit(t *testing.T) {
+	compiled := CompileStep(&wfmodels.WorkflowStep{
+		ID: "build",
+		Events: wfmodels.StepEvents{
+			MaxIterations: 3,
+			OnIterationLimit: []wfmodels.GenericAction{
+				{Type: wfmodels.GenericActionMoveToStep, Config: map[string]any{"step_id": "human"}},
+			},
+			OnComment: []wfmodels.GenericAction{
+				{Type: wfmodels.GenericActionClearDecisions, Config: map[string]any{"reset_iterations": true}},
+			},
+		},
+	})
+	if compiled.MaxIterations != 3 {
+		t.Fatalf("MaxIterations = %d, want 3", compiled.MaxIterations)
+	}
+	actions := compiled.Events[TriggerOnIterationLimit]
+	if len(actions) != 1 || actions[0].MoveToStep.StepID != "human" {
+		t.Fatalf("unexpected on_iteration_limit actions: %+v", actions)
+	}
+	if clear := compiled.Events[TriggerOnComment][0].ClearDecisions; clear == nil || !clear.ResetIterations {
+		t.Fatalf("reset_iterations not compiled: %+v", compiled.Events[TriggerOnComment])
+	}
+}
+
+// boundedLoopStore is a review → build loop where build allows two entries
+// and hands over to a human step once they are used up.
+func boundedLoopStore(iterations map[string]any, limitActions ...Action) *fakeStore {
+	data := map[string]any{}
+	if iterations != nil {
+		data[IterationsDataKey] = iterations
+	}
+	return &fakeStore{
+		state: MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf", CurrentStepID: "review", Data: data},
+		stepsByID: map[string]StepSpec{
+			"build": {
+				ID: "build", WorkflowID: "wf", Position: 1, MaxIterations: 2,
+				Events: map[Trigger][]Action{TriggerOnIterationLimit: limitActions},
+			},
+			"review": {
+				ID: "review", WorkflowID: "wf", Position: 2,
+				Events: map[Trigger][]Action{TriggerOnTurnComplete: {{Kind: ActionMoveToPrevious}}},
+			},
+			"human": {ID: "human", WorkflowID: "wf", Position: 3},
+		},
+		prevSteps: map[int]StepSpec{2: {ID: "build", WorkflowID: "wf", Position: 1}},
+		applied:   map[string]bool{},
+	}
+}
+
+func TestHandleTrigger_CountsEntriesBelowLimit(t *testing.T) {
+	store := boundedLoopStore(map[string]any{"build": float64(1), "other": float64(4)})
+	eng := New(store, MapRegistry{})
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "build" || res.IterationLimit != nil {
+		t.Fatalf("expected an ordinary move to build, got %+v", res)
+	}
+	if got := IterationCount(store.persistedData, "build"); got != 2 {
+		t.Fatalf("build count = %d, want 2", got)
+	}
+	if got := IterationCount(store.persistedData, "other"); got != 4 {
+		t.Fatalf("other steps' counts must be kept, got %d", got)
+	}
+}
+
+func TestHandleTrigger_IterationLimitRoutesToHuman(t *testing.T) {
+	store := boundedLoopStore(map[string]any{"build": 2},
+		Action{Kind: ActionNotify, Notify: &NotifyAction{Title: "Loop limit"}},
+		Action{Kind: ActionMoveToStep, MoveToStep: &MoveToStepAction{StepID: "human"}},
+	)
+	notify := &recordingCallback{}
+	eng := New(store, MapRegistry{ActionNotify: notify})
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "human" || store.transitionTo != "human" {
+		t.Fatalf("expected the limit to route to human, got %+v", res)
+	}
+	want := IterationLimit{StepID: "build", MaxIterations: 2, Count: 2}
+	if res.IterationLimit == nil || *res.IterationLimit != want {
+		t.Fatalf("IterationLimit = %+v, want %+v", res.IterationLimit, want)
+	}
+	if len(notify.calls) != 1 || notify.calls[0].Trigger != TriggerOnIterationLimit || notify.calls[0].Step.ID != "build" {
+		t.Fatalf("on_iteration_limit actions must run as the bounded step's trigger, got %+v", notify.calls)
+	}
+	if _, ok := store.persistedData[IterationsDataKey]; ok {
+		t.Fatalf("a refused entry must not be counted, got %+v", store.persistedData)
+	}
+}
+
+func TestHandleTrigger_IterationLimitWithoutActionsStays(t *testing.T) {
+	store := boundedLoopStore(map[string]any{"build": 2})
+	eng := New(store, MapRegistry{})
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Transitioned || store.transitionTo != "" {
+		t.Fatalf("expected the task to stay in review, got %+v", res)
+	}
+	if res.IterationLimit == nil || res.IterationLimit.StepID != "build" {
+		t.Fatalf("expected the limit to be reported, got %+v", res.IterationLimit)
+	}
+}
+
+func TestHandleTrigger_IterationLimitRouteIsNotBoundedAgain(t *testing.T) {
+	store := boundedLoopStore(map[string]any{"build": 2},
+		Action{Kind: ActionMoveToStep, MoveToStep: &MoveToStepAction{StepID: "human"}},
+	)
+	human := store.stepsByID["human"]
+	human.MaxIterations = 1
+	store.stepsByID["human"] = human
+	store.state.Data[IterationsDataKey] = map[string]any{"build": 2, "human": 1}
+	eng := New(store, MapRegistry{})
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.ToStepID != "human" {
+		t.Fatalf("expected the limit route to reach human, got %+v", res)
+	}
+	if got := IterationCount(store.persistedData, "human"); got != 2 {
+		t.Fatalf("the limit route is still counted, got %d", got)
+	}
+}
+
+func TestClearDecisionsCallback_ResetIterations(t *testing.T) {
+	decisions := newFakeDecisionStore()
+	cb := ClearDecisionsCallback{Decisions: decisions}
+	in := ActionInput{
+		State:  MachineState{TaskID: "t1"},
+		Step:   StepSpec{ID: "human"},
+		Action: Action{Kind: ActionClearDecisions, ClearDecisions: &ClearDecisionsAction{}},
+	}
+	res, err := cb.Execute(context.Background(), in)
+	if err != nil || res.DataPatch != nil {
+		t.Fatalf("plain clear_decisions must leave counters alone, got %+v, %v", res, err)
+	}
+
+	in.Action.ClearDecisions.ResetIterations = true
+	res, err = cb.Execute(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts, ok := res.DataPatch[IterationsDataKey].(map[string]any)
+	if !ok || len(counts) != 0 {
+		t.Fatalf("expected an empty iterations map, got %+v", res.DataPatch)
+	}
+}
+
+func TestRecordParticipantDecision_RejectionLoopIsBounded(t *testing.T) {
+	store := boundedLoopStore(map[string]any{"build": 2},
+		Action{Kind: ActionMoveToStep, MoveToStep: &MoveToStepAction{StepID: "human"}},
+	)
+	review := store.stepsByID["review"]
+	review.Events = map[Trigger][]Action{TriggerOnTurnComplete: {{
+		Kind:       ActionMoveToStep,
+		Guard:      &TransitionGuard{WaitForQuorum: &WaitForQuorumGuard{Role: "reviewer", Threshold: QuorumAnyReject}},
+		MoveToStep: &MoveToStepAction{StepID: "build"},
+	}}}
+	store.stepsByID["review"] = review
+	parts := fakeParticipants{list: []ParticipantInfo{{ID: "p1", Role: "reviewer", DecisionRequired: true}}}
+	eng := New(store, MapRegistry{}, WithDecisionStore(newFakeDecisionStore()), WithParticipantStore(parts))
+
+	res, err := eng.RecordParticipantDecision(context.Background(), "s1", DecisionInfo{
+		TaskID: "t1", StepID: "review", ParticipantID: "p1",
+		DeciderType: DeciderTypeAgent, DeciderID: "rev-A", Role: "reviewer",
+		Decision: DecisionRejected,
+	})
+	if err != nil {
+		t.Fatalf("RecordParticipantDecision: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "human" || res.IterationLimit == nil {
+		t.Fatalf("expected the rejection to hit build's limit and go to human, got %+v", res)
+	}
+}
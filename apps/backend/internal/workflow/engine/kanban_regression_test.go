@@ -3,6 +3,8 @@ package engine
 import (
 	"context"
 	"testing"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
 )
 
 // TestKanbanRegression_OnlyOnEnterAutoStart pins that a step compiled from
@@ -86,3 +88,123 @@ func TestKanbanRegression_NoGuard_TransitionsImmediately(t *testing.T) {
 		t.Fatalf("expected transition without guard")
 	}
 }
+
+// kanbanEventStore compiles a kanban-style step from the workflow model, so
+// these tests cover the YAML/DB shape rather than a hand-built StepSpec.
+func kanbanEventStore(events wfmodels.StepEvents) *fakeStore {
+	step := CompileStep(&wfmodels.WorkflowStep{
+		ID: "implement", WorkflowID: "wf", Name: "Implement", Position: 1, Events: events,
+	})
+	return &fakeStore{
+		state:     MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf", CurrentStepID: "implement"},
+		stepsByID: map[string]StepSpec{"implement": step},
+		nextSteps: map[int]StepSpec{1: {ID: "done", Position: 2}},
+		applied:   map[string]bool{},
+	}
+}
+
+// TestKanbanRegression_EventTriggersTransition pins that each event-driven
+// trigger routes a kanban step the same way on_turn_complete does, in the
+// evaluate-only mode the orchestrator uses before applying the move through
+// its step lifecycle.
+func TestKanbanRegression_EventTriggersTransition(t *testing.T) {
+	moveNext := []wfmodels.GenericAction{{Type: wfmodels.GenericActionMoveToNext}}
+	cases := []struct {
+		trigger Trigger
+		events  wfmodels.StepEvents
+		payload any
+	}{
+		{TriggerOnComment, wfmodels.StepEvents{OnComment: moveNext}, OnCommentPayload{CommentID: "c1"}},
+		{TriggerOnBlockerResolved, wfmodels.StepEvents{OnBlockerResolved: moveNext}, OnBlockerResolvedPayload{ResolvedBlockerIDs: []string{"b1"}}},
+		{TriggerOnChildrenCompleted, wfmodels.StepEvents{OnChildrenCompleted: moveNext}, OnChildrenCompletedPayload{
+			ChildSummaries: []ChildSummary{{TaskID: "c1", Status: "COMPLETED"}},
+		}},
+		{TriggerOnAgentError, wfmodels.StepEvents{OnAgentError: moveNext}, OnAgentErrorPayload{StepID: "implement", Attempt: 1}},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.trigger), func(t *testing.T) {
+			store := kanbanEventStore(tc.events)
+			eng := New(store, MapRegistry{})
+			res, err := eng.HandleTrigger(context.Background(), HandleInput{
+				TaskID: "t1", SessionID: "s1", Trigger: tc.trigger, Payload: tc.payload,
+				EvaluateOnly: true, OperationID: "op-" + string(tc.trigger),
+			})
+			if err != nil {
+				t.Fatalf("HandleTrigger: %v", err)
+			}
+			if !res.Transitioned || res.FromStepID != "implement" || res.ToStepID != "done" {
+				t.Fatalf("unexpected result: %+v", res)
+			}
+			if store.transitionTo != "" {
+				t.Fatalf("evaluate-only must leave the move to the caller, store moved to %q", store.transitionTo)
+			}
+
+			// A redelivered event is a no-op.
+			res, err = eng.HandleTrigger(context.Background(), HandleInput{
+				TaskID: "t1", SessionID: "s1", Trigger: tc.trigger, Payload: tc.payload,
+				EvaluateOnly: true, OperationID: "op-" + string(tc.trigger),
+			})
+			if err != nil || !res.Idempotent || res.Transitioned {
+				t.Fatalf("redelivery = %+v, %v; want idempotent no-op", res, err)
+			}
+		})
+	}
+}
+
+// TestKanbanRegression_OnAgentErrorRetryCounting pins the retry-count
+// routing pattern: the orchestrator bumps agent_error_count before each
+// dispatch and the guard holds the task in place until the limit.
+func TestKanbanRegression_OnAgentErrorRetryCounting(t *testing.T) {
+	store := kanbanEventStore(wfmodels.StepEvents{
+		OnAgentError: []wfmodels.GenericAction{{
+			Type:   wfmodels.GenericActionMoveToStep,
+			Config: map[string]any{"step_id": "triage", "if": "data.agent_error_count >= 3"},
+		}},
+	})
+	eng := New(store, MapRegistry{})
+
+	var data map[string]any
+	for attempt := 1; attempt <= 3; attempt++ {
+		if got := NextAgentErrorAttempt(data, "implement"); got != attempt {
+			t.Fatalf("NextAgentErrorAttempt = %d, want %d", got, attempt)
+		}
+		data = AgentErrorDataPatch("implement", attempt)
+		state := store.state
+		state.Data = data
+		res, err := eng.HandleTrigger(context.Background(), HandleInput{
+			TaskID: "t1", SessionID: "s1", Trigger: TriggerOnAgentError,
+			EvaluateOnly: true, PreloadedState: &state,
+			Payload: OnAgentErrorPayload{StepID: "implement", Attempt: attempt},
+		})
+		if err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if wantMove := attempt == 3; res.Transitioned != wantMove {
+			t.Fatalf("attempt %d: transitioned = %v, want %v", attempt, res.Transitioned, wantMove)
+		}
+		if res.Transitioned && res.ToStepID != "triage" {
+			t.Fatalf("attempt %d: moved to %q, want triage", attempt, res.ToStepID)
+		}
+	}
+}
+
+func TestNextAgentErrorAttempt(t *testing.T) {
+	cases := []struct {
+		name string
+		data map[string]any
+		want int
+	}{
+		{"empty bag", nil, 1},
+		{"same step", map[string]any{AgentErrorStepDataKey: "implement", AgentErrorCountDataKey: 2}, 3},
+		{"json-decoded count", map[string]any{AgentErrorStepDataKey: "implement", AgentErrorCountDataKey: float64(4)}, 5},
+		{"other step resets", map[string]any{AgentErrorStepDataKey: "review", AgentErrorCountDataKey: 5}, 1},
+		{"malformed count", map[string]any{AgentErrorStepDataKey: "implement", AgentErrorCountDataKey: "x"}, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NextAgentErrorAttempt(tc.data, "implement"); got != tc.want {
+				t.Fatalf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NotifyCallback executes the notify action through the wired Notifier.
+// Blank titles and messages are filled from the step and trigger so an
+// on_timeout notify with no config still says which task is stuck where.
+type NotifyCallback struct {
+	Notifier Notifier
+}
+
+// Execute satisfies ActionCallback.
+func (c NotifyCallback) Execute(ctx context.Context, in ActionInput) (ActionResult, error) {
+	if c.Notifier == nil {
+		return ActionResult{}, fmt.Errorf("%w: notify requires Notifier", ErrActionNotYetWired)
+	}
+	var cfg NotifyAction
+	if in.Action.Notify != nil {
+		cfg = *in.Action.Notify
+	}
+	if cfg.Title == "" {
+		cfg.Title = notifyDefaultTitle(in)
+	}
+	if cfg.Message == "" {
+		cfg.Message = notifyDefaultMessage(in)
+	}
+	err := c.Notifier.Notify(ctx, Notification{
+		TaskID:      in.State.TaskID,
+		SessionID:   in.State.SessionID,
+		StepID:      in.Step.ID,
+		StepName:    in.Step.Name,
+		Trigger:     in.Trigger,
+		OperationID: in.OperationID,
+		Title:       cfg.Title,
+		Message:     cfg.Message,
+	})
+	if err != nil {
+		return ActionResult{}, fmt.Errorf("notify: %w", err)
+	}
+	return ActionResult{}, nil
+}
+
+func notifyDefaultTitle(in ActionInput) string {
+	if in.Trigger == TriggerOnTimeout {
+		return fmt.Sprintf("Task overdue in %s", in.Step.Name)
+	}
+	return fmt.Sprintf("Workflow update in %s", in.Step.Name)
+}
+
+func notifyDefaultMessage(in ActionInput) string {
+	if p, ok := in.Payload.(OnTimeoutPayload); ok {
+		if p.Kind == TimeoutKindAgentActive {
+			return fmt.Sprintf("Agents have worked on this task for %s in %s (limit %s).",
+				p.Elapsed.Round(time.Minute), in.Step.Name, p.Limit)
+		}
+		return fmt.Sprintf("This task has been in %s for %s (limit %s).",
+			in.Step.Name, p.Elapsed.Round(time.Minute), p.Limit)
+	}
+	return fmt.Sprintf("The %s trigger fired in %s.", in.Trigger, in.Step.Name)
+}
+
+var _ ActionCallback = NotifyCallback{}
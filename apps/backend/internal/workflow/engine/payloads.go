@@ -1,5 +1,7 @@
 package engine
 
+import "time"
+
 // Phase 2 (ADR-0004) trigger payload types. Each new trigger type carries a
 // typed payload struct so callbacks can read the trigger's context (comment
 // id, blocker ids, etc.). Payloads are passed via HandleInput.Payload and
@@ -56,9 +58,29 @@ type OnBudgetAlertPayload struct {
 	Scope     string
 }
 
-// OnAgentErrorPayload accompanies TriggerOnAgentError.
+// OnAgentErrorPayload accompanies TriggerOnAgentError. Attempt counts the
+// agent errors in StepID, including this one (see AgentErrorCountDataKey).
 type OnAgentErrorPayload struct {
 	FailedAgentID   string
 	FailedSessionID string
 	ErrorMessage    string
+	StepID          string
+	Attempt         int
+}
+
+// Step timeout kinds carried on OnTimeoutPayload.Kind.
+const (
+	TimeoutKindWallClock   = "wall_clock"
+	TimeoutKindAgentActive = "agent_active"
+)
+
+// OnTimeoutPayload accompanies TriggerOnTimeout. Kind says which of the
+// step's limits elapsed; Elapsed is the measured wall-clock or agent-active
+// time when the timer fired and Limit the configured threshold.
+type OnTimeoutPayload struct {
+	Kind      string
+	StepID    string
+	EnteredAt time.Time
+	Elapsed   time.Duration
+	Limit     time.Duration
 }
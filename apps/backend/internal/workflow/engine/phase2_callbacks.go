@@ -315,7 +315,9 @@ func commentPayload(payload any) (OnCommentPayload, bool) {
 }
 
 // ClearDecisionsCallback executes the clear_decisions action by deleting all
-// recorded decisions for the trigger's (task, step) pair.
+// recorded decisions for the trigger's (task, step) pair. With
+// reset_iterations it also returns the patch zeroing the task's
+// max_iterations counters.
 type ClearDecisionsCallback struct {
 	Decisions DecisionStore
 }
@@ -328,6 +330,9 @@ func (c ClearDecisionsCallback) Execute(ctx context.Context, in ActionInput) (Ac
 	if _, err := c.Decisions.ClearStepDecisions(ctx, in.State.TaskID, in.Step.ID); err != nil {
 		return ActionResult{}, fmt.Errorf("clear_decisions: %w", err)
 	}
+	if in.Action.ClearDecisions != nil && in.Action.ClearDecisions.ResetIterations {
+		return ActionResult{DataPatch: ResetIterationsDataPatch()}, nil
+	}
 	return ActionResult{}, nil
 }
 
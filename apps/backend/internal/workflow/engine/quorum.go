@@ -86,6 +86,9 @@ func (e *Engine) evaluateTransitionGuard(ctx context.Context, state MachineState
 	if action.Guard == nil {
 		return GuardOutcome{Satisfied: true}
 	}
+	if action.Guard.Expression != nil {
+		return e.evaluateExpressionGuard(ctx, state, action.Guard.Expression)
+	}
 	if action.Guard.WaitForQuorum == nil {
 		// Unknown guard variant — fail closed (AC-23/AC-53's sibling code).
 		return GuardOutcome{Reason: ReasonGuardVariantUnrecognized}
@@ -101,6 +104,10 @@ func (e *Engine) evaluateTransitionGuard(ctx context.Context, state MachineState
 // evaluators AC-24 covers. The AC-24b/57d read-only diagnostic snapshot
 // (EvaluateStepQuorum) must never call this.
 func (e *Engine) recordGuardNotFired(state MachineState, action Action, outcome GuardOutcome) {
+	if action.Guard != nil && action.Guard.Expression != nil {
+		e.recordExpressionGuardNotFired(state, action.Guard.Expression, outcome)
+		return
+	}
 	quorummetrics.RecordGuardNotFired(outcome.Reason)
 	if e.logger == nil {
 		return
@@ -457,6 +464,9 @@ type RecordDecisionResult struct {
 	// Guards is the pre-transition quorum snapshot used by the decision
 	// response. It remains available after a successful CAS move.
 	Guards []QuorumGuardState
+	// IterationLimit is set when the satisfied transition's target had
+	// reached its max_iterations; see HandleResult.IterationLimit.
+	IterationLimit *IterationLimit
 }
 
 // RecordParticipantDecision is the engine's single decision-recording entry
@@ -514,6 +524,7 @@ func (e *Engine) RecordParticipantDecision(
 	result.FromStepID = handled.FromStepID
 	result.ToStepID = handled.ToStepID
 	result.Guards = handled.Guards
+	result.IterationLimit = handled.IterationLimit
 	return result, nil
 }
 
@@ -548,7 +559,7 @@ func (e *Engine) reevaluateGuardedTransitions(
 		return HandleResult{}, err
 	}
 	state.CurrentStepID = stepID
-	step, err := e.store.LoadStep(ctx, state.WorkflowID, stepID)
+	step, err := e.loadStep(ctx, state, stepID)
 	if err != nil {
 		return HandleResult{}, err
 	}
@@ -584,6 +595,10 @@ func (e *Engine) applyFirstSatisfiedGuardedTransition(
 		}
 		entry, targetStepID, targetErr, satisfied := e.evaluateGuardForTransition(ctx, state, step, action)
 		guards = append(guards, entry)
+		e.observeGuard(ctx, state, TriggerOnTurnComplete, action, GuardOutcome{
+			Satisfied: satisfied && targetErr == nil, Reason: entry.Reason, Err: entry.Error,
+			RequiredCount: entry.RequiredCount, ReceivedCount: entry.ReceivedCount,
+		})
 		if targetErr != nil {
 			// Preserve the old write-side behavior for the first satisfied
 			// guard, which returned this target-resolution error. A malformed
@@ -609,16 +624,42 @@ func (e *Engine) applyFirstSatisfiedGuardedTransition(
 	if selectedTarget == "" {
 		return HandleResult{Guards: guards}, nil
 	}
+	return e.applyGuardedTransition(ctx, taskID, sessionID, state, selectedTarget, guards)
+}
+
+// applyGuardedTransition commits a satisfied guarded transition via the
+// AC-46/48 compare-and-swap, after the target's max_iterations bound had
+// its say. The bound's counters are persisted only once the move applied.
+func (e *Engine) applyGuardedTransition(
+	ctx context.Context, taskID, sessionID string, state MachineState, target string, guards []QuorumGuardState,
+) (HandleResult, error) {
+	dataPatch := map[string]any{}
+	in := HandleInput{TaskID: taskID, SessionID: sessionID, Trigger: TriggerOnTurnComplete}
+	target, limit, err := e.boundTransition(ctx, in, state, target, dataPatch)
+	if err != nil {
+		return HandleResult{}, err
+	}
+	if target == "" || target == state.CurrentStepID {
+		return HandleResult{Guards: guards, IterationLimit: limit}, nil
+	}
 	applied, err := e.store.ApplyTransitionIfAtStep(
-		ctx, taskID, sessionID, state.CurrentStepID, selectedTarget, TriggerOnTurnComplete,
+		ctx, taskID, sessionID, state.CurrentStepID, target, TriggerOnTurnComplete,
 	)
 	if err != nil {
 		return HandleResult{}, err
 	}
 	if !applied {
-		return HandleResult{Guards: guards, TransitionAbandoned: true, FromStepID: state.CurrentStepID, ToStepID: selectedTarget}, nil
+		return HandleResult{Guards: guards, TransitionAbandoned: true, FromStepID: state.CurrentStepID, ToStepID: target, IterationLimit: limit}, nil
+	}
+	if len(dataPatch) > 0 {
+		if err := e.store.PersistData(ctx, sessionID, dataPatch); err != nil {
+			return HandleResult{}, err
+		}
 	}
-	return HandleResult{Guards: guards, Transitioned: true, FromStepID: state.CurrentStepID, ToStepID: selectedTarget}, nil
+	return HandleResult{
+		Guards: guards, Transitioned: true, FromStepID: state.CurrentStepID, ToStepID: target,
+		DataPatch: dataPatch, IterationLimit: limit,
+	}, nil
 }
 
 func (e *Engine) evaluateGuardForTransition(
@@ -687,7 +728,7 @@ type QuorumSnapshot struct {
 // implementations). Applies no transition, writes no row, executes no
 // action callback, emits no AC-24 log, and increments no AC-24a counter — a
 // diagnostic read never fails on, or mutates, the state it exists to
-// diagnose.
+// diagnose. Expression guards are not quorum guards and are left out.
 //
 // A store error while evaluating one guard surfaces as that guard's
 // ReasonEvaluationError entry, not a method-level error, so one failing
@@ -706,14 +747,14 @@ func (e *Engine) EvaluateStepQuorum(ctx context.Context, taskID, sessionID strin
 	if state.CurrentStepID == "" {
 		return QuorumSnapshot{}, nil
 	}
-	step, err := e.store.LoadStep(ctx, state.WorkflowID, state.CurrentStepID)
+	step, err := e.loadStep(ctx, state, state.CurrentStepID)
 	if err != nil {
 		return QuorumSnapshot{}, err
 	}
 
 	snapshot := QuorumSnapshot{StepID: state.CurrentStepID}
 	for _, action := range step.Events[TriggerOnTurnComplete] {
-		if !isTransitionAction(action.Kind) || action.Guard == nil {
+		if !isTransitionAction(action.Kind) || action.Guard == nil || action.Guard.Expression != nil {
 			continue
 		}
 		snapshot.Guards = append(snapshot.Guards, e.evaluateGuardStateReadOnly(ctx, state, step, action))
@@ -0,0 +1,292 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultCheckTimeout bounds a run_check command whose action leaves
+// timeout_seconds unset.
+const DefaultCheckTimeout = 10 * time.Minute
+
+// LastCheckDataKey is the workflow data bag key under which the engine
+// records a summary of the most recent run_check gate. Output is left out
+// on purpose: the bag lives on session metadata and must stay small.
+const LastCheckDataKey = "last_check"
+
+// maxCheckFeedbackBytes caps how much command output is fed back to the
+// agent. The tail is kept — test runners and compilers print the failure
+// summary last.
+const maxCheckFeedbackBytes = 16 * 1024
+
+// CheckOutcome summarizes a run_check gate evaluated during HandleTrigger.
+//
+// Passed is true only for exit code 0 without timeout or runner error.
+// TargetStepID is "" when the gate kept the task on its current step.
+//
+// Pending marks the outcome of an evaluate-only trigger, which never runs
+// the command: the gate would run with Timeout, the task stays in place,
+// and the caller finishes the trigger with CompletePendingCheck once it ran
+// Request itself.
+type CheckOutcome struct {
+	StepID       string
+	Command      string
+	Passed       bool
+	ExitCode     int
+	TimedOut     bool
+	Output       string
+	Error        string
+	Duration     time.Duration
+	TargetStepID string
+	Pending      bool
+	Timeout      time.Duration
+}
+
+// Request is the CheckRunner request that runs a pending gate.
+func (o CheckOutcome) Request(taskID, sessionID string) CheckRequest {
+	return CheckRequest{TaskID: taskID, SessionID: sessionID, StepID: o.StepID, Command: o.Command, Timeout: o.Timeout}
+}
+
+// FeedbackPrompt renders the outcome as the prompt handed back to the agent
+// after a failed gate. Returns "" for passing and pending checks.
+func (o CheckOutcome) FeedbackPrompt() string {
+	if o.Passed || o.Pending {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("The workflow quality gate failed.\n\n")
+	fmt.Fprintf(&b, "Command: `%s`\n", o.Command)
+	switch {
+	case o.Error != "":
+		fmt.Fprintf(&b, "The check could not be run: %s\n", o.Error)
+	case o.TimedOut:
+		fmt.Fprintf(&b, "The command timed out after %s.\n", o.Duration.Round(time.Second))
+	default:
+		fmt.Fprintf(&b, "Exit code: %d\n", o.ExitCode)
+	}
+	if output := truncateCheckOutput(o.Output); output != "" {
+		b.WriteString("\nOutput:\n```\n")
+		b.WriteString(output)
+		if !strings.HasSuffix(output, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("```\n")
+	}
+	b.WriteString("\nFix the problems above so the check passes.")
+	return b.String()
+}
+
+func truncateCheckOutput(output string) string {
+	if len(output) <= maxCheckFeedbackBytes {
+		return output
+	}
+	return "…(truncated)\n" + output[len(output)-maxCheckFeedbackBytes:]
+}
+
+// WithCheckRunner wires the engine's CheckRunner for run_check actions.
+// When unset, a run_check action fails the trigger with
+// ErrActionNotYetWired — a quality gate must never be silently skipped.
+func WithCheckRunner(runner CheckRunner) Option {
+	return func(e *Engine) { e.checkRunner = runner }
+}
+
+// WithCheckRecorder wires persistence for run_check gate results.
+func WithCheckRecorder(recorder CheckRecorder) Option {
+	return func(e *Engine) { e.checkRecorder = recorder }
+}
+
+// evaluateRunCheck runs the gate and resolves where it routes. Runner errors
+// and timeouts count as failures (fail closed); only a missing runner or an
+// unresolvable pass target surfaces as an error. An evaluate-only trigger
+// does not run the command and returns a pending outcome instead.
+func (e *Engine) evaluateRunCheck(
+	ctx context.Context,
+	in HandleInput,
+	state MachineState,
+	step StepSpec,
+	action Action,
+) (*CheckOutcome, error) {
+	if action.RunCheck == nil || action.RunCheck.Command == "" {
+		return nil, fmt.Errorf("run_check action missing command")
+	}
+	cfg := action.RunCheck
+	timeout := checkTimeout(*cfg)
+	if in.EvaluateOnly {
+		return &CheckOutcome{StepID: step.ID, Command: cfg.Command, Pending: true, Timeout: timeout}, nil
+	}
+	if e.checkRunner == nil {
+		return nil, fmt.Errorf("%w: run_check requires CheckRunner", ErrActionNotYetWired)
+	}
+
+	started := time.Now()
+	res, runErr := e.checkRunner.RunCheck(ctx, CheckRequest{
+		TaskID:    state.TaskID,
+		SessionID: state.SessionID,
+		StepID:    step.ID,
+		Command:   cfg.Command,
+		Timeout:   timeout,
+	})
+	return e.finishRunCheck(ctx, in, state, step, *cfg, started, res, runErr)
+}
+
+func checkTimeout(cfg RunCheckAction) time.Duration {
+	if cfg.TimeoutSeconds > 0 {
+		return time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return DefaultCheckTimeout
+}
+
+// finishRunCheck turns what the runner observed into the gate's outcome,
+// resolves its route and records it.
+func (e *Engine) finishRunCheck(
+	ctx context.Context,
+	in HandleInput,
+	state MachineState,
+	step StepSpec,
+	cfg RunCheckAction,
+	started time.Time,
+	res CheckResult,
+	runErr error,
+) (*CheckOutcome, error) {
+	outcome := &CheckOutcome{
+		StepID:   step.ID,
+		Command:  cfg.Command,
+		ExitCode: res.ExitCode,
+		TimedOut: res.TimedOut,
+		Output:   res.Output,
+		Duration: res.Duration,
+	}
+	if outcome.Duration == 0 {
+		outcome.Duration = time.Since(started)
+	}
+	if runErr != nil {
+		outcome.Error = runErr.Error()
+	}
+	outcome.Passed = runErr == nil && !res.TimedOut && res.ExitCode == 0
+
+	target, err := e.resolveCheckTarget(ctx, state, step, cfg, outcome.Passed)
+	if err != nil {
+		return nil, err
+	}
+	outcome.TargetStepID = target
+	e.recordCheck(ctx, in, state, *outcome)
+	return outcome, nil
+}
+
+// CompletePendingCheck finishes a trigger whose evaluate-only HandleTrigger
+// returned a pending run_check, given what the caller observed running
+// pending.Request; started is when the command was launched. The gate
+// routes, counts against max_iterations and is recorded as if HandleTrigger
+// had run it, and the operation is marked applied. Like HandleTrigger, an
+// evaluate-only in leaves persisting the result to the caller.
+//
+// A task that left the gate's step while the command ran, or whose step no
+// longer has that gate, is not moved: the result comes back without a Check.
+// So is an operation another delivery completed first.
+func (e *Engine) CompletePendingCheck(
+	ctx context.Context,
+	in HandleInput,
+	pending CheckOutcome,
+	started time.Time,
+	res CheckResult,
+	runErr error,
+) (HandleResult, error) {
+	applied, err := e.isOperationAlreadyApplied(ctx, in.OperationID)
+	if err != nil || applied {
+		return HandleResult{Idempotent: applied}, err
+	}
+	state, step, err := e.loadExecutionContext(ctx, in)
+	if err != nil {
+		return HandleResult{}, err
+	}
+	cfg, ok := pendingRunCheck(step, in.Trigger, pending)
+	if !ok {
+		return HandleResult{}, e.markOperationApplied(ctx, in.OperationID)
+	}
+	outcome, err := e.finishRunCheck(ctx, in, state, step, cfg, started, res, runErr)
+	if err != nil {
+		return HandleResult{}, err
+	}
+	eval := actionEvaluation{
+		targetStepID: outcome.TargetStepID,
+		dataPatch:    map[string]any{LastCheckDataKey: checkDataPatch(*outcome)},
+		check:        outcome,
+	}
+	eval.targetStepID, eval.iterationLimit, err = e.boundTransition(ctx, in, state, eval.targetStepID, eval.dataPatch)
+	if err != nil {
+		return HandleResult{}, err
+	}
+	result, err := e.commitEvaluation(ctx, in, state, eval, len(step.Events[in.Trigger]))
+	if err != nil {
+		return HandleResult{}, err
+	}
+	return result, e.markOperationApplied(ctx, in.OperationID)
+}
+
+// pendingRunCheck finds the run_check a pending outcome stands for on the
+// task's current step.
+func pendingRunCheck(step StepSpec, trigger Trigger, pending CheckOutcome) (RunCheckAction, bool) {
+	if step.ID != pending.StepID {
+		return RunCheckAction{}, false
+	}
+	for _, action := range step.Events[trigger] {
+		if action.Kind == ActionRunCheck && action.RunCheck != nil && action.RunCheck.Command == pending.Command {
+			return *action.RunCheck, true
+		}
+	}
+	return RunCheckAction{}, false
+}
+
+func (e *Engine) resolveCheckTarget(ctx context.Context, state MachineState, step StepSpec, cfg RunCheckAction, passed bool) (string, error) {
+	if !passed {
+		return cfg.FailStepID, nil
+	}
+	if cfg.PassStepID != "" {
+		return cfg.PassStepID, nil
+	}
+	return e.resolveTransitionTarget(ctx, state, step, Action{Kind: ActionMoveToNext})
+}
+
+// recordCheck persists the gate result. Failures are logged, not returned:
+// the recorder is an audit trail and must not block routing.
+func (e *Engine) recordCheck(ctx context.Context, in HandleInput, state MachineState, o CheckOutcome) {
+	if e.checkRecorder == nil {
+		return
+	}
+	err := e.checkRecorder.RecordCheckResult(ctx, CheckRecord{
+		TaskID:       state.TaskID,
+		SessionID:    state.SessionID,
+		StepID:       o.StepID,
+		Trigger:      string(in.Trigger),
+		Command:      o.Command,
+		Passed:       o.Passed,
+		ExitCode:     o.ExitCode,
+		TimedOut:     o.TimedOut,
+		Output:       truncateCheckOutput(o.Output),
+		Error:        o.Error,
+		Duration:     o.Duration,
+		TargetStepID: o.TargetStepID,
+		OccurredAt:   time.Now().UTC(),
+	})
+	if err != nil && e.logger != nil {
+		e.logger.Warn("failed to record run_check result",
+			zap.String("task_id", state.TaskID),
+			zap.String("step_id", o.StepID),
+			zap.Error(err))
+	}
+}
+
+// checkDataPatch is the LastCheckDataKey summary merged into the data bag.
+func checkDataPatch(o CheckOutcome) map[string]any {
+	return map[string]any{
+		"step_id":   o.StepID,
+		"command":   o.Command,
+		"passed":    o.Passed,
+		"exit_code": o.ExitCode,
+		"timed_out": o.TimedOut,
+	}
+}
@@ -0,0 +1,291 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+type fakeCheckRunner struct {
+	result CheckResult
+	err    error
+	calls  []CheckRequest
+}
+
+func (r *fakeCheckRunner) RunCheck(_ context.Context, req CheckRequest) (CheckResult, error) {
+	r.calls = append(r.calls, req)
+	return r.result, r.err
+}
+
+type fakeCheckRecorder struct {
+	records []CheckRecord
+}
+
+func (r *fakeCheckRecorder) RecordCheckResult(_ context.Context, rec CheckRecord) error {
+	r.records = append(r.records, rec)
+	return nil
+}
+
+func runCheckStore(actions ...Action) *fakeStore {
+	return &fakeStore{
+		state: MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf", CurrentStepID: "implement"},
+		stepsByID: map[string]StepSpec{
+			"implement": {
+				ID: "implement", WorkflowID: "wf", Position: 1,
+				Events: map[Trigger][]Action{TriggerOnTurnComplete: actions},
+			},
+		},
+		nextSteps: map[int]StepSpec{1: {ID: "review", Position: 2}},
+		applied:   map[string]bool{},
+	}
+}
+
+func TestCompileStep_RunCheck(t *testing.T) {
+	compiled := CompileStep(&wfmodels.WorkflowStep{
+		ID: "implement",
+		Events: wfmodels.StepEvents{
+			OnTurnComplete: []wfmodels.OnTurnCompleteAction{
+				{Type: wfmodels.OnTurnCompleteRunCheck, Config: map[string]any{
+					"command":         "make test",
+					"timeout_seconds": float64(90),
+					"fail_step_id":    "fix",
+				}},
+				{Type: wfmodels.OnTurnCompleteRunCheck, Config: map[string]any{}},
+			},
+		},
+	})
+	actions := compiled.Events[TriggerOnTurnComplete]
+	if len(actions) != 1 {
+		t.Fatalf("expected run_check without command to be dropped, got %d actions", len(actions))
+	}
+	got := actions[0].RunCheck
+	if got == nil || got.Command != "make test" || got.TimeoutSeconds != 90 || got.FailStepID != "fix" || got.PassStepID != "" {
+		t.Fatalf("unexpected compiled run_check: %+v", got)
+	}
+}
+
+func TestRunCheck_PassMovesToNextStep(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "go test ./..."}})
+	runner := &fakeCheckRunner{result: CheckResult{ExitCode: 0, Output: "ok"}}
+	recorder := &fakeCheckRecorder{}
+	eng := New(store, MapRegistry{}, WithCheckRunner(runner), WithCheckRecorder(recorder))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "review" {
+		t.Fatalf("expected transition to review, got %+v", res)
+	}
+	if res.Check == nil || !res.Check.Passed {
+		t.Fatalf("expected passing check outcome, got %+v", res.Check)
+	}
+	if len(runner.calls) != 1 || runner.calls[0].Timeout != DefaultCheckTimeout || runner.calls[0].StepID != "implement" {
+		t.Fatalf("unexpected runner calls: %+v", runner.calls)
+	}
+	if len(recorder.records) != 1 || !recorder.records[0].Passed || recorder.records[0].TargetStepID != "review" {
+		t.Fatalf("unexpected recorded results: %+v", recorder.records)
+	}
+	summary, _ := store.persistedData[LastCheckDataKey].(map[string]any)
+	if summary["passed"] != true {
+		t.Fatalf("expected last_check summary in persisted data, got %v", store.persistedData)
+	}
+}
+
+func TestRunCheck_FailRoutesToFailStep(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{
+		Command: "make lint", TimeoutSeconds: 5, FailStepID: "fix",
+	}})
+	runner := &fakeCheckRunner{result: CheckResult{ExitCode: 2, Output: "lint: unused variable x"}}
+	eng := New(store, MapRegistry{}, WithCheckRunner(runner))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "fix" {
+		t.Fatalf("expected transition to fix, got %+v", res)
+	}
+	if runner.calls[0].Timeout != 5*time.Second {
+		t.Fatalf("expected configured timeout, got %s", runner.calls[0].Timeout)
+	}
+	prompt := res.Check.FeedbackPrompt()
+	if !strings.Contains(prompt, "make lint") || !strings.Contains(prompt, "Exit code: 2") || !strings.Contains(prompt, "unused variable x") {
+		t.Fatalf("feedback prompt missing details:\n%s", prompt)
+	}
+}
+
+func TestRunCheck_FailWithoutFailStepStaysAndBlocksLaterTransitions(t *testing.T) {
+	store := runCheckStore(
+		Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test"}},
+		Action{Kind: ActionMoveToNext},
+	)
+	runner := &fakeCheckRunner{result: CheckResult{ExitCode: 1}}
+	eng := New(store, MapRegistry{}, WithCheckRunner(runner))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Transitioned {
+		t.Fatalf("failed gate must keep the task in place, got %+v", res)
+	}
+	if res.Check == nil || res.Check.Passed {
+		t.Fatalf("expected failing check outcome, got %+v", res.Check)
+	}
+}
+
+func TestRunCheck_RunnerErrorFailsClosed(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test", FailStepID: "fix"}})
+	runner := &fakeCheckRunner{err: errors.New("agentctl unreachable")}
+	eng := New(store, MapRegistry{}, WithCheckRunner(runner))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("runner errors must surface as a failed check, got error: %v", err)
+	}
+	if res.ToStepID != "fix" || res.Check.Error == "" {
+		t.Fatalf("expected failed check routed to fix, got %+v", res)
+	}
+	if !strings.Contains(res.Check.FeedbackPrompt(), "agentctl unreachable") {
+		t.Fatalf("feedback prompt should carry the runner error")
+	}
+}
+
+func TestRunCheck_EarlierTransitionSkipsCheck(t *testing.T) {
+	store := runCheckStore(
+		Action{Kind: ActionMoveToNext},
+		Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test"}},
+	)
+	runner := &fakeCheckRunner{}
+	eng := New(store, MapRegistry{}, WithCheckRunner(runner))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runner.calls) != 0 || res.Check != nil {
+		t.Fatalf("run_check after a claimed transition must not run")
+	}
+}
+
+func TestRunCheck_EvaluateOnlyLeavesCheckPending(t *testing.T) {
+	store := runCheckStore(
+		Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test", FailStepID: "fix"}},
+		Action{Kind: ActionMoveToNext},
+	)
+	runner := &fakeCheckRunner{}
+	recorder := &fakeCheckRecorder{}
+	eng := New(store, MapRegistry{}, WithCheckRunner(runner), WithCheckRecorder(recorder))
+
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{
+		TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete, OperationID: "op-1", EvaluateOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runner.calls) != 0 || len(recorder.records) != 0 {
+		t.Fatalf("evaluate-only must not run or record the check: calls %+v, records %+v", runner.calls, recorder.records)
+	}
+	if res.Check == nil || !res.Check.Pending || res.Check.Timeout != DefaultCheckTimeout || res.Transitioned {
+		t.Fatalf("expected a pending check that keeps the task in place, got %+v", res)
+	}
+	if _, ok := res.DataPatch[LastCheckDataKey]; ok || res.Check.FeedbackPrompt() != "" {
+		t.Fatalf("a pending check has no summary or feedback, got %+v", res.DataPatch)
+	}
+	if store.applied["op-1"] {
+		t.Fatal("the operation must stay open until the check completes")
+	}
+}
+
+func TestCompletePendingCheck_RoutesRecordsAndMarksOperation(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test", FailStepID: "fix"}})
+	recorder := &fakeCheckRecorder{}
+	eng := New(store, MapRegistry{}, WithCheckRecorder(recorder))
+	in := HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete, OperationID: "op-1", EvaluateOnly: true}
+
+	pending, err := eng.HandleTrigger(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := eng.CompletePendingCheck(context.Background(), in, *pending.Check, time.Now(), CheckResult{ExitCode: 1, Output: "FAIL"}, nil)
+	if err != nil {
+		t.Fatalf("CompletePendingCheck: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "fix" || res.Check == nil || res.Check.Passed {
+		t.Fatalf("expected the failed gate routed to fix, got %+v", res)
+	}
+	if store.transitionTo != "" {
+		t.Fatalf("evaluate-only completion must leave the transition to the caller, applied %q", store.transitionTo)
+	}
+	summary, _ := res.DataPatch[LastCheckDataKey].(map[string]any)
+	if summary["passed"] != false || len(recorder.records) != 1 || !store.applied["op-1"] {
+		t.Fatalf("expected summary, record and applied operation; got %v, %+v, %v", res.DataPatch, recorder.records, store.applied)
+	}
+}
+
+func TestCompletePendingCheck_IgnoresTaskThatLeftTheStep(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test", FailStepID: "fix"}})
+	store.stepsByID["review"] = StepSpec{ID: "review", WorkflowID: "wf", Position: 2}
+	recorder := &fakeCheckRecorder{}
+	eng := New(store, MapRegistry{}, WithCheckRecorder(recorder))
+	in := HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete, EvaluateOnly: true}
+
+	pending, err := eng.HandleTrigger(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.state.CurrentStepID = "review"
+	res, err := eng.CompletePendingCheck(context.Background(), in, *pending.Check, time.Now(), CheckResult{ExitCode: 1}, nil)
+	if err != nil {
+		t.Fatalf("CompletePendingCheck: %v", err)
+	}
+	if res.Transitioned || res.Check != nil || len(recorder.records) != 0 {
+		t.Fatalf("a task that moved on must not be routed by the stale gate, got %+v", res)
+	}
+}
+
+func TestCompletePendingCheck_SkipsAppliedOperation(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test", FailStepID: "fix"}})
+	recorder := &fakeCheckRecorder{}
+	eng := New(store, MapRegistry{}, WithCheckRecorder(recorder))
+	in := HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete, OperationID: "op-1", EvaluateOnly: true}
+
+	pending, err := eng.HandleTrigger(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 2 {
+		if _, err := eng.CompletePendingCheck(context.Background(), in, *pending.Check, time.Now(), CheckResult{ExitCode: 1}, nil); err != nil {
+			t.Fatalf("CompletePendingCheck: %v", err)
+		}
+	}
+	if len(recorder.records) != 1 {
+		t.Fatalf("a redelivered trigger must not complete its gate twice, got %d records", len(recorder.records))
+	}
+}
+
+func TestRunCheck_UnwiredRunnerErrors(t *testing.T) {
+	store := runCheckStore(Action{Kind: ActionRunCheck, RunCheck: &RunCheckAction{Command: "make test"}})
+	eng := New(store, MapRegistry{})
+
+	_, err := eng.HandleTrigger(context.Background(), HandleInput{TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTurnComplete})
+	if !errors.Is(err, ErrActionNotYetWired) {
+		t.Fatalf("expected ErrActionNotYetWired, got %v", err)
+	}
+}
+
+func TestCheckOutcome_FeedbackPromptTruncatesHead(t *testing.T) {
+	output := strings.Repeat("x", maxCheckFeedbackBytes) + "FINAL SUMMARY"
+	prompt := CheckOutcome{Command: "make test", ExitCode: 1, Output: output}.FeedbackPrompt()
+	if !strings.Contains(prompt, "FINAL SUMMARY") || !strings.Contains(prompt, "(truncated)") {
+		t.Fatalf("expected tail-truncated output in prompt")
+	}
+	if (CheckOutcome{Passed: true}).FeedbackPrompt() != "" {
+		t.Fatalf("passing checks produce no feedback")
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	wfmodels "github.com/kandev/kandev/internal/workflow/models"
+)
+
+type fakeNotifier struct {
+	sent []Notification
+	err  error
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, n Notification) error {
+	f.sent = append(f.sent, n)
+	return f.err
+}
+
+func TestCompileStep_OnTimeout(t *testing.T) {
+	compiled := CompileStep(&wfmodels.WorkflowStep{
+		ID: "review",
+		Events: wfmodels.StepEvents{
+			Timeout: &wfmodels.StepTimeout{AfterMinutes: 2880},
+			OnTimeout: []wfmodels.GenericAction{
+				{Type: wfmodels.GenericActionNotify, Config: map[string]any{"title": "Stale review"}},
+				{Type: wfmodels.GenericActionCreateChildTask, Config: map[string]any{"title": "Chase reviewer", "workflow_id": "wf-2"}},
+				{Type: wfmodels.GenericActionCreateChildTask}, // no title: skipped
+				{Type: wfmodels.GenericActionMoveToStep, Config: map[string]any{"step_id": "triage"}},
+			},
+		},
+	})
+	actions := compiled.Events[TriggerOnTimeout]
+	if len(actions) != 3 {
+		t.Fatalf("got %d on_timeout actions, want 3: %+v", len(actions), actions)
+	}
+	if actions[0].Kind != ActionNotify || actions[0].Notify.Title != "Stale review" {
+		t.Fatalf("unexpected notify action: %+v", actions[0])
+	}
+	if actions[1].Kind != ActionCreateChildTask || actions[1].CreateChildTask.WorkflowID != "wf-2" {
+		t.Fatalf("unexpected create_child_task action: %+v", actions[1])
+	}
+	if actions[2].Kind != ActionMoveToStep || actions[2].MoveToStep.StepID != "triage" {
+		t.Fatalf("unexpected move_to_step action: %+v", actions[2])
+	}
+}
+
+func TestHandleTrigger_OnTimeoutEscalates(t *testing.T) {
+	store := &fakeStore{
+		state: MachineState{TaskID: "t1", SessionID: "s1", WorkflowID: "wf", CurrentStepID: "review"},
+		stepsByID: map[string]StepSpec{
+			"review": {
+				ID: "review", Name: "Review", WorkflowID: "wf", Position: 2,
+				Events: map[Trigger][]Action{TriggerOnTimeout: {
+					{Kind: ActionNotify, Notify: &NotifyAction{}},
+					{Kind: ActionMoveToStep, MoveToStep: &MoveToStepAction{StepID: "triage"}},
+				}},
+			},
+			"triage": {ID: "triage", WorkflowID: "wf", Position: 1},
+		},
+		applied: map[string]bool{},
+	}
+	notifier := &fakeNotifier{}
+	eng := New(store, MapRegistry{ActionNotify: NotifyCallback{Notifier: notifier}})
+
+	payload := OnTimeoutPayload{Kind: TimeoutKindWallClock, StepID: "review", Elapsed: 49 * time.Hour, Limit: 48 * time.Hour}
+	res, err := eng.HandleTrigger(context.Background(), HandleInput{
+		TaskID: "t1", SessionID: "s1", Trigger: TriggerOnTimeout, Payload: payload, OperationID: "timeout:t1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Transitioned || res.ToStepID != "triage" {
+		t.Fatalf("expected escalation to triage, got %+v", res)
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifier.sent))
+	}
+	n := notifier.sent[0]
+	if n.Title != "Task overdue in Review" || !strings.Contains(n.Message, "49h0m0s") || n.OperationID != "timeout:t1" {
+		t.Fatalf("unexpected notification: %+v", n)
+	}
+}
+
+func TestNotifyCallback_Errors(t *testing.T) {
+	if _, err := (NotifyCallback{}).Execute(context.Background(), ActionInput{}); !errors.Is(err, ErrActionNotYetWired) {
+		t.Fatalf("unwired notifier: err = %v, want ErrActionNotYetWired", err)
+	}
+	boom := errors.New("smtp down")
+	cb := NotifyCallback{Notifier: &fakeNotifier{err: boom}}
+	if _, err := cb.Execute(context.Background(), ActionInput{}); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want wrapped notifier error", err)
+	}
+}
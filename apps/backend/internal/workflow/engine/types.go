@@ -3,6 +3,7 @@ package engine
 import (
 	"fmt"
 
+	"github.com/kandev/kandev/internal/workflow/guardexpr"
 	wfmodels "github.com/kandev/kandev/internal/workflow/models"
 )
 
@@ -16,11 +17,12 @@ const (
 	TriggerOnTurnComplete Trigger = "on_turn_complete"
 	TriggerOnExit         Trigger = "on_exit"
 
-	// New triggers for Phase 2 of the task model unification (ADR-0004).
-	// Declared here so payloads and step compilation can reference them;
-	// they are NOT yet handled by Engine.HandleTrigger. Wiring lands once
-	// Phase 1 (agent runtime extraction) merges and the engine integration
-	// slice of Phase 2 begins.
+	// Event-driven triggers from Phase 2 of the task model unification
+	// (ADR-0004). HandleTrigger evaluates them like any other trigger; the
+	// event sources live outside the engine (office subscribers, the
+	// orchestrator's dependency, child-completion and agent-failure
+	// handlers, the cron loop), and kanban tasks apply the resulting
+	// transitions through the orchestrator's step lifecycle.
 	TriggerOnComment           Trigger = "on_comment"
 	TriggerOnBlockerResolved   Trigger = "on_blocker_resolved"
 	TriggerOnChildrenCompleted Trigger = "on_children_completed"
@@ -28,6 +30,16 @@ const (
 	TriggerOnHeartbeat         Trigger = "on_heartbeat"
 	TriggerOnBudgetAlert       Trigger = "on_budget_alert"
 	TriggerOnAgentError        Trigger = "on_agent_error"
+
+	// TriggerOnTimeout fires when a task has sat in a step longer than the
+	// step's timeout. The cron scheduler owns the timer and dispatches it
+	// with an OnTimeoutPayload.
+	TriggerOnTimeout Trigger = "on_timeout"
+
+	// TriggerOnIterationLimit runs in place of entering a step whose
+	// max_iterations has been reached. The engine fires it itself while
+	// evaluating another trigger's transition; see boundTransition.
+	TriggerOnIterationLimit Trigger = "on_iteration_limit"
 )
 
 // ActionKind identifies a typed workflow action.
@@ -68,6 +80,21 @@ const (
 	// on the new step.
 	ActionCreateChildTask ActionKind = "create_child_task"
 	ActionSwitchWorkflow  ActionKind = "switch_workflow"
+
+	// ActionRunCheck runs a shell command in the task's agentctl workspace
+	// and routes on its exit status: exit 0 moves to the pass step, any
+	// other outcome moves to the fail step (or stays put). The captured
+	// output is surfaced on HandleResult.Check so the orchestrator can feed
+	// it back to the agent as its next prompt.
+	ActionRunCheck ActionKind = "run_check"
+
+	// ActionNotify sends a user notification through the wired Notifier.
+	ActionNotify ActionKind = "notify"
+
+	// ActionFanOut launches the step's prompt across several agent
+	// profiles as parallel child tasks (best-of-N). The join is driven by
+	// the FanOutLauncher; see DecideFanOutJoin.
+	ActionFanOut ActionKind = "fan_out"
 )
 
 // Action is the typed internal representation of workflow actions.
@@ -76,8 +103,9 @@ type Action struct {
 	RequiresApproval bool
 
 	// Guard, when non-nil, gates a transition action on a condition that the
-	// engine evaluates before resolving the transition target. Today only
-	// wait_for_quorum is supported. Non-transition actions ignore Guard.
+	// engine evaluates before resolving the transition target: either
+	// wait_for_quorum or a guard expression. Non-transition actions ignore
+	// Guard.
 	Guard *TransitionGuard
 
 	MoveToStep                 *MoveToStepAction
@@ -90,6 +118,9 @@ type Action struct {
 	QueueRunForEachParticipant *QueueRunForEachParticipantAction
 	CreateChildTask            *CreateChildTaskAction
 	SwitchWorkflow             *SwitchWorkflowAction
+	RunCheck                   *RunCheckAction
+	Notify                     *NotifyAction
+	FanOut                     *FanOutAction
 }
 
 // TransitionGuard is the typed `if:` clause attached to a transition action.
@@ -97,6 +128,19 @@ type Action struct {
 // permit the transition" (preserving today's kanban semantics).
 type TransitionGuard struct {
 	WaitForQuorum *WaitForQuorumGuard
+	Expression    *ExpressionGuard
+}
+
+// ExpressionGuard gates a transition on a guardexpr expression evaluated
+// against the workflow data bag and the GuardFactsProvider's task, pr and
+// review facts, e.g. `review.blocking_findings == 0 && pr.checks ==
+// "success"`. Program is nil when Source failed to compile; the guard then
+// fails closed with CompileErr (imports reject such expressions up front,
+// see wfmodels.ValidateTransitionGuards).
+type ExpressionGuard struct {
+	Source     string
+	Program    *guardexpr.Program
+	CompileErr error
 }
 
 // WaitForQuorumGuard gates a transition on the state of recorded decisions
@@ -166,8 +210,15 @@ type QueueRunAction struct {
 
 // ClearDecisionsAction clears workflow_step_decisions rows for the trigger's
 // (task, step) pair. Used by Review.on_enter so quorum starts fresh after a
-// rejection round. Declared but not yet wired.
-type ClearDecisionsAction struct{}
+// rejection round.
+//
+// ResetIterations also zeroes the task's max_iterations counters. It is
+// opt-in because the Review.on_enter clear runs on every lap of the very
+// loop a bound is meant to stop; a human approval step sets it to hand the
+// loop a fresh budget.
+type ClearDecisionsAction struct {
+	ResetIterations bool
+}
 
 // QueueRunForEachParticipantAction fans out QueueRun against every step
 // participant matching the configured role. Declared but not yet wired.
@@ -208,6 +259,30 @@ type SwitchWorkflowAction struct {
 	StepID     string
 }
 
+// RunCheckAction runs Command in the session's workspace as a quality gate.
+//
+// Routing:
+//   - PassStepID: target on exit 0. Empty means "move to the next step".
+//   - FailStepID: target on a non-zero exit, timeout or runner error.
+//     Empty means "stay on the current step" so the agent can iterate on
+//     the fed-back output.
+//
+// TimeoutSeconds <= 0 falls back to DefaultCheckTimeout.
+type RunCheckAction struct {
+	Command        string
+	TimeoutSeconds int
+	PassStepID     string
+	FailStepID     string
+}
+
+// NotifyAction sends a notification about the trigger's task. Empty Title
+// or Message fall back to defaults derived from the step and trigger (see
+// NotifyCallback).
+type NotifyAction struct {
+	Title   string
+	Message string
+}
+
 // ParticipantRole, StageType and WorkflowStyle are defined in
 // internal/workflow/models. The engine surfaces small validators here so
 // callers (templates, engine integration code) can sanity-check
@@ -260,6 +335,8 @@ type StepSpec struct {
 	Position   int
 	Prompt     string
 	Events     map[Trigger][]Action
+	// MaxIterations bounds automatic entries into the step; 0 is unbounded.
+	MaxIterations int
 }
 
 // CompileStep translates workflow models into typed step specs for the engine.
@@ -278,14 +355,17 @@ func CompileStep(step *wfmodels.WorkflowStep) StepSpec {
 		TriggerOnHeartbeat:         compileGenericActions(step.Events.OnHeartbeat),
 		TriggerOnBudgetAlert:       compileGenericActions(step.Events.OnBudgetAlert),
 		TriggerOnAgentError:        compileGenericActions(step.Events.OnAgentError),
+		TriggerOnTimeout:           compileGenericActions(step.Events.OnTimeout),
+		TriggerOnIterationLimit:    compileGenericActions(step.Events.OnIterationLimit),
 	}
 	return StepSpec{
-		ID:         step.ID,
-		WorkflowID: step.WorkflowID,
-		Name:       step.Name,
-		Position:   step.Position,
-		Prompt:     step.Prompt,
-		Events:     events,
+		ID:            step.ID,
+		WorkflowID:    step.WorkflowID,
+		Name:          step.Name,
+		Position:      step.Position,
+		Prompt:        step.Prompt,
+		Events:        events,
+		MaxIterations: step.Events.MaxIterations,
 	}
 }
 
@@ -313,7 +393,7 @@ func compileOnEnter(step *wfmodels.WorkflowStep) []Action {
 		case wfmodels.OnEnterClearDecisions:
 			actions = append(actions, Action{
 				Kind:           ActionClearDecisions,
-				ClearDecisions: &ClearDecisionsAction{},
+				ClearDecisions: readClearDecisionsConfig(action.Config),
 			})
 		case wfmodels.OnEnterQueueRunForEachParticipant:
 			actions = append(actions, Action{
@@ -325,6 +405,14 @@ func compileOnEnter(step *wfmodels.WorkflowStep) []Action {
 				Kind:     ActionQueueRun,
 				QueueRun: readQueueRunConfig(action.Config),
 			})
+		case wfmodels.OnEnterRunCheck:
+			if check := readRunCheckConfig(action.Config); check != nil {
+				actions = append(actions, Action{Kind: ActionRunCheck, RunCheck: check})
+			}
+		case wfmodels.OnEnterFanOut:
+			if fanOut := readFanOutConfig(action.Config); fanOut != nil {
+				actions = append(actions, Action{Kind: ActionFanOut, FanOut: fanOut})
+			}
 		}
 	}
 	return actions
@@ -367,6 +455,10 @@ func compileOnTurnComplete(step *wfmodels.WorkflowStep) []Action {
 			actions = append(actions, Action{Kind: ActionMoveToStep, RequiresApproval: ra, Guard: guard, MoveToStep: &MoveToStepAction{StepID: stepID}})
 		case wfmodels.OnTurnCompleteDisablePlanMode:
 			actions = append(actions, Action{Kind: ActionDisablePlanMode})
+		case wfmodels.OnTurnCompleteRunCheck:
+			if check := readRunCheckConfig(action.Config); check != nil {
+				actions = append(actions, Action{Kind: ActionRunCheck, RunCheck: check})
+			}
 		}
 	}
 	return actions
@@ -416,18 +508,64 @@ func compileGenericActions(actions []wfmodels.GenericAction) []Action {
 		case wfmodels.GenericActionClearDecisions:
 			out = append(out, Action{
 				Kind:           ActionClearDecisions,
-				ClearDecisions: &ClearDecisionsAction{},
+				ClearDecisions: readClearDecisionsConfig(a.Config),
 			})
 		case wfmodels.GenericActionQueueRunForEachParticipant:
 			out = append(out, Action{
 				Kind:                       ActionQueueRunForEachParticipant,
 				QueueRunForEachParticipant: readQueueRunForEachParticipantConfig(a.Config),
 			})
+		case wfmodels.GenericActionRunCheck:
+			if check := readRunCheckConfig(a.Config); check != nil {
+				out = append(out, Action{Kind: ActionRunCheck, RunCheck: check})
+			}
+		case wfmodels.GenericActionNotify:
+			out = append(out, Action{Kind: ActionNotify, Notify: readNotifyConfig(a.Config)})
+		case wfmodels.GenericActionCreateChildTask:
+			if child := readCreateChildTaskConfig(a.Config); child != nil {
+				out = append(out, Action{Kind: ActionCreateChildTask, CreateChildTask: child})
+			}
 		}
 	}
 	return out
 }
 
+// readClearDecisionsConfig reads a clear_decisions action's optional
+// reset_iterations flag.
+func readClearDecisionsConfig(config map[string]any) *ClearDecisionsAction {
+	reset, _ := config[wfmodels.ClearDecisionsResetIterationsConfigKey].(bool)
+	return &ClearDecisionsAction{ResetIterations: reset}
+}
+
+// readNotifyConfig reads a notify action's title and message. Both are
+// optional.
+func readNotifyConfig(config map[string]any) *NotifyAction {
+	title, _ := config["title"].(string)
+	message, _ := config["message"].(string)
+	return &NotifyAction{Title: title, Message: message}
+}
+
+// readCreateChildTaskConfig reads a create_child_task action's config map.
+// Returns nil when the title is missing, mirroring the callback's own
+// requirement so a half-configured action is skipped at compile time.
+func readCreateChildTaskConfig(config map[string]any) *CreateChildTaskAction {
+	title, _ := config["title"].(string)
+	if title == "" {
+		return nil
+	}
+	description, _ := config["description"].(string)
+	workflowID, _ := config["workflow_id"].(string)
+	stepID, _ := config["step_id"].(string)
+	agentProfileID, _ := config["agent_profile_id"].(string)
+	return &CreateChildTaskAction{
+		Title:          title,
+		Description:    description,
+		WorkflowID:     workflowID,
+		StepID:         stepID,
+		AgentProfileID: agentProfileID,
+	}
+}
+
 // readQueueRunConfig reads a `queue_run` action's config map. Missing keys
 // fall back to engine defaults ("primary" target, "this" task id).
 func readQueueRunConfig(config map[string]any) *QueueRunAction {
@@ -468,6 +606,48 @@ func readQueueRunForEachParticipantConfig(config map[string]any) *QueueRunForEac
 	}
 }
 
+// readRunCheckConfig reads a run_check action's config map. Returns nil when
+// the command is missing so a half-configured gate is skipped rather than
+// routing every turn to the fail step.
+func readRunCheckConfig(config map[string]any) *RunCheckAction {
+	if config == nil {
+		return nil
+	}
+	command, _ := config[wfmodels.RunCheckCommandConfigKey].(string)
+	if command == "" {
+		return nil
+	}
+	timeout, _ := toPositiveInt(config[wfmodels.RunCheckTimeoutConfigKey])
+	passStepID, _ := config[wfmodels.RunCheckPassStepConfigKey].(string)
+	failStepID, _ := config[wfmodels.RunCheckFailStepConfigKey].(string)
+	return &RunCheckAction{
+		Command:        command,
+		TimeoutSeconds: timeout,
+		PassStepID:     passStepID,
+		FailStepID:     failStepID,
+	}
+}
+
+// toPositiveInt accepts the numeric shapes a JSON/YAML-decoded config map
+// can carry. Non-positive and non-numeric values report false.
+func toPositiveInt(v any) (int, bool) {
+	var n int
+	switch x := v.(type) {
+	case int:
+		n = x
+	case int64:
+		n = int(x)
+	case float64:
+		n = int(x)
+	default:
+		return 0, false
+	}
+	if n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
 // readSessionMode reads the target mode for a set_session_mode action from its
 // config map. Returns "" when the config is missing or the mode key is unset.
 func readSessionMode(config map[string]any) string {
@@ -509,12 +689,16 @@ func ConfigRequiresApproval(config map[string]any) bool {
 	return ok && ra
 }
 
-// ConfigTransitionGuard reads the `wait_for_quorum` guard from an action's
-// config map, if present. Returns nil when the config is missing, malformed,
-// or has no guard — preserving today's "always allow" semantics for kanban
-// steps that never set the key.
+// ConfigTransitionGuard reads the transition guard from an action's config
+// map, if present. Returns nil when the config is missing, malformed, or has
+// no guard — preserving today's "always allow" semantics for kanban steps
+// that never set the key.
+//
+// A string `if` is an expression guard:
 //
-// Expected shape:
+//	{"if": "review.blocking_findings == 0 && pr.checks == \"success\""}
+//
+// The wait_for_quorum shape is:
 //
 //	{
 //	    "if": {
@@ -532,7 +716,10 @@ func ConfigTransitionGuard(config map[string]any) *TransitionGuard {
 	}
 	raw, ok := config["wait_for_quorum"].(map[string]any)
 	if !ok {
-		guard, ok := config["if"].(map[string]any)
+		if expr, isExpr := config[wfmodels.TransitionGuardConfigKey].(string); isExpr {
+			return compileExpressionGuard(expr)
+		}
+		guard, ok := config[wfmodels.TransitionGuardConfigKey].(map[string]any)
 		if !ok {
 			return nil
 		}
@@ -550,3 +737,10 @@ func ConfigTransitionGuard(config map[string]any) *TransitionGuard {
 		WaitForQuorum: &WaitForQuorumGuard{Role: role, Threshold: threshold},
 	}
 }
+
+func compileExpressionGuard(source string) *TransitionGuard {
+	program, err := guardexpr.Compile(source)
+	return &TransitionGuard{
+		Expression: &ExpressionGuard{Source: source, Program: program, CompileErr: err},
+	}
+}
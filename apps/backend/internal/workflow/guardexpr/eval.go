@@ -0,0 +1,261 @@
+package guardexpr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type node interface {
+	eval(env map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(map[string]any) (any, error) { return n.value, nil }
+
+type pathNode struct {
+	path []string
+}
+
+func (n pathNode) eval(env map[string]any) (any, error) {
+	var cur any = env
+	for _, field := range n.path {
+		m, ok := asMap(cur)
+		if !ok {
+			return nil, nil
+		}
+		cur = m[field]
+	}
+	return normalize(cur), nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(env map[string]any) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truthy(v)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) eval(env map[string]any) (any, error) {
+	lv, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	l, err := truthy(lv)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&" && !l) || (n.op == "||" && l) {
+		return l, nil
+	}
+	rv, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(rv)
+}
+
+type compareNode struct {
+	op          string
+	pos         int
+	left, right node
+}
+
+func (n *compareNode) eval(env map[string]any) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		return contains(r, l), nil
+	}
+	return n.order(l, r)
+}
+
+func (n *compareNode) order(l, r any) (any, error) {
+	var cmp int
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return nil, n.typeError(l, r)
+		}
+		cmp = compareFloats(lv, rv)
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return nil, n.typeError(l, r)
+		}
+		cmp = strings.Compare(lv, rv)
+	default:
+		return nil, n.typeError(l, r)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func (n *compareNode) typeError(l, r any) error {
+	return errorAt(n.pos, "operator %s cannot compare %s and %s", n.op, typeName(l), typeName(r))
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// truthy maps a value to a boolean. Null is false so an unset data-bag flag
+// reads naturally; every other non-boolean is a type error.
+func truthy(v any) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %s", typeName(v))
+}
+
+func equal(a, b any) bool {
+	switch a.(type) {
+	case nil:
+		return b == nil
+	case float64, string, bool:
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// contains implements `needle in haystack`: list membership, map key
+// presence, or substring match. Anything else is false.
+func contains(haystack, needle any) bool {
+	switch h := haystack.(type) {
+	case []any:
+		for _, item := range h {
+			if equal(normalize(item), needle) {
+				return true
+			}
+		}
+	case map[string]any:
+		key, ok := needle.(string)
+		if !ok {
+			return false
+		}
+		_, found := h[key]
+		return found
+	case string:
+		s, ok := needle.(string)
+		return ok && strings.Contains(h, s)
+	}
+	return false
+}
+
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[string]string:
+		out := make(map[string]any, len(m))
+		for k, s := range m {
+			out[k] = s
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// normalize folds the value shapes a JSON-backed data bag or a Go caller
+// can produce into the evaluator's closed set: nil, bool, float64, string,
+// []any and map[string]any.
+func normalize(v any) any {
+	switch x := v.(type) {
+	case nil, bool, float64, string, []any, map[string]any:
+		return v
+	case int:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		if f, err := x.Float64(); err == nil {
+			return f
+		}
+		return x.String()
+	case []string:
+		out := make([]any, len(x))
+		for i, s := range x {
+			out[i] = s
+		}
+		return out
+	case map[string]string:
+		m, _ := asMap(x)
+		return m
+	}
+	return v
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
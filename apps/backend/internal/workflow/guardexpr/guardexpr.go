@@ -0,0 +1,107 @@
+// Package guardexpr implements the small, side-effect-free expression
+// language used by workflow transition guards (`if: <expr>` on a transition
+// action). It is a neutral package so the workflow models package can
+// validate expressions at import time and the engine can evaluate them
+// without either depending on the other.
+//
+// Grammar, lowest precedence first:
+//
+//	expr       = or
+//	or         = and { "||" and }
+//	and        = comparison { "&&" comparison }
+//	comparison = unary [ ("==" | "!=" | "<" | "<=" | ">" | ">=" | "in") unary ]
+//	unary      = "!" unary | primary
+//	primary    = number | string | "true" | "false" | "null" | path | "(" expr ")"
+//	path       = root { "." ident }
+//
+// Roots are restricted to data, task, pr and review. A path that does not
+// resolve evaluates to null, so `pr.checks == "success"` is simply false for
+// a task without a pull request. Comparisons never coerce across types:
+// `==` between different types is false and ordering operators require two
+// numbers or two strings.
+package guardexpr
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Recognised root identifiers.
+const (
+	RootData   = "data"
+	RootTask   = "task"
+	RootPR     = "pr"
+	RootReview = "review"
+)
+
+// Limits that keep a hostile or accidental expression cheap to parse and
+// evaluate.
+const (
+	MaxSourceLength = 2048
+	maxDepth        = 32
+)
+
+var validRoots = map[string]bool{
+	RootData:   true,
+	RootTask:   true,
+	RootPR:     true,
+	RootReview: true,
+}
+
+// Program is a compiled guard expression. It is immutable and safe for
+// concurrent use.
+type Program struct {
+	source string
+	root   node
+	roots  []string
+}
+
+// Compile parses src into a Program. Syntax errors and unknown roots are
+// reported with the byte offset at which they were detected.
+func Compile(src string) (*Program, error) {
+	if len(src) > MaxSourceLength {
+		return nil, fmt.Errorf("expression is longer than %d bytes", MaxSourceLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorAt(tok.pos, "unexpected %s", tok)
+	}
+	roots := make([]string, 0, len(p.roots))
+	for r := range p.roots {
+		roots = append(roots, r)
+	}
+	slices.Sort(roots)
+	return &Program{source: src, root: root, roots: roots}, nil
+}
+
+// Source returns the expression text the program was compiled from.
+func (p *Program) Source() string { return p.source }
+
+// Roots returns the sorted set of root identifiers the expression reads, so
+// callers can skip loading facts the expression never touches.
+func (p *Program) Roots() []string { return slices.Clone(p.roots) }
+
+// Uses reports whether the expression reads the given root.
+func (p *Program) Uses(root string) bool { return slices.Contains(p.roots, root) }
+
+// Eval evaluates the program against env, keyed by root name. A null result
+// counts as false; any other non-boolean result is an error.
+func (p *Program) Eval(env map[string]any) (bool, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v)
+}
+
+func errorAt(pos int, format string, args ...any) error {
+	return fmt.Errorf("position %d: %s", pos, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,142 @@
+package guardexpr
+
+import (
+	"strings"
+	"testing"
+)
+
+func testEnv() map[string]any {
+	return map[string]any{
+		RootData: map[string]any{
+			"approved": true,
+			"attempts": 3,
+			"owner":    "alice",
+		},
+		RootTask: map[string]any{
+			"priority":         "high",
+			"labels":           []string{"bug", "backend"},
+			"repository_count": 2,
+		},
+		RootPR: map[string]any{
+			"checks": "success",
+			"state":  "open",
+		},
+		RootReview: map[string]any{
+			"blocking_findings": 0,
+			"findings":          4,
+		},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`review.blocking_findings == 0 && pr.checks == "success"`, true},
+		{`review.findings > 3`, true},
+		{`review.findings >= 5`, false},
+		{`task.priority == 'high' || task.priority == "urgent"`, true},
+		{`"bug" in task.labels`, true},
+		{`"frontend" in task.labels`, false},
+		{`task.repository_count <= 1`, false},
+		{`data.approved`, true},
+		{`!data.approved`, false},
+		{`data.missing`, false},
+		{`data.missing == null`, true},
+		{`data.attempts < 5 && !(data.owner != "alice")`, true},
+		{`pr.checks == "success" && review.blocking_findings == 0 || false`, true},
+		{`data.attempts == 3.0`, true},
+		{`data.attempts == "3"`, false},
+		{`"checks" in pr`, true},
+		{`"ali" in data.owner`, true},
+		{`pr.merged_at.day == null`, true},
+		{`data.attempts > -1`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			prog, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			got, err := prog.Eval(testEnv())
+			if err != nil {
+				t.Fatalf("eval: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval_ShortCircuitSkipsRightHandErrors(t *testing.T) {
+	prog, err := Compile(`data.missing && data.owner > 1`)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	got, err := prog.Eval(testEnv())
+	if err != nil || got {
+		t.Fatalf("expected false without error, got %v, %v", got, err)
+	}
+}
+
+func TestEval_TypeErrors(t *testing.T) {
+	for _, expr := range []string{
+		`data.owner > 1`,
+		`data.missing < 3`,
+		`data.attempts && true`,
+		`task.priority`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			prog, err := Compile(expr)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			if _, err := prog.Eval(testEnv()); err == nil {
+				t.Fatalf("expected a type error")
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{``, "unexpected end of expression"},
+		{`review.findings ==`, "unexpected end of expression"},
+		{`session.id == "x"`, `unknown identifier "session"`},
+		{`(pr.checks == "success"`, `expected ")"`},
+		{`pr.checks = "success"`, `unexpected character '='`},
+		{`"unterminated`, "unterminated string literal"},
+		{`1 < review.findings < 5`, "cannot be chained"},
+		{`pr. == 1`, "expected field name"},
+		{`pr.checks == "success" extra`, `unexpected "extra"`},
+		{strings.Repeat("(", 40) + "true" + strings.Repeat(")", 40), "nested deeper"},
+		{strings.Repeat("x", MaxSourceLength+1), "longer than"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProgramRoots(t *testing.T) {
+	prog, err := Compile(`review.findings == 0 && data.ok && review.blocking_findings == 0`)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	roots := prog.Roots()
+	if len(roots) != 2 || roots[0] != RootData || roots[1] != RootReview {
+		t.Fatalf("unexpected roots: %v", roots)
+	}
+	if prog.Uses(RootPR) || !prog.Uses(RootReview) {
+		t.Fatalf("Uses disagrees with Roots")
+	}
+}
@@ -0,0 +1,156 @@
+package guardexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokDot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// twoCharOps must be checked before single-character operators so "<="
+// does not lex as "<" followed by "=".
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '.':
+			tokens = append(tokens, token{kind: tokDot, text: ".", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			tok, next, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			tok, next, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			op := lexOperator(src, i)
+			if op == "" {
+				return nil, errorAt(i, "unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func lexOperator(src string, i int) string {
+	for _, op := range twoCharOps {
+		if strings.HasPrefix(src[i:], op) {
+			return op
+		}
+	}
+	switch src[i] {
+	case '<', '>', '!':
+		return src[i : i+1]
+	}
+	return ""
+}
+
+func lexString(src string, start int) (token, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return token{kind: tokString, text: b.String(), pos: start}, i + 1, nil
+		case c == '\\':
+			if i+1 >= len(src) {
+				return token{}, 0, errorAt(i, "unterminated escape sequence")
+			}
+			i++
+			switch src[i] {
+			case '\\', '"', '\'':
+				b.WriteByte(src[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				return token{}, 0, errorAt(i-1, "unknown escape sequence \\%c", src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, 0, errorAt(start, "unterminated string literal")
+}
+
+func lexNumber(src string, start int) (token, int, error) {
+	i := start
+	if src[i] == '-' {
+		i++
+	}
+	for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+		i++
+	}
+	n, err := strconv.ParseFloat(src[start:i], 64)
+	if err != nil {
+		return token{}, 0, errorAt(start, "invalid number %q", src[start:i])
+	}
+	return token{kind: tokNumber, text: src[start:i], num: n, pos: start}, i, nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool { return isIdentStart(c) || isDigit(c) }
@@ -0,0 +1,157 @@
+package guardexpr
+
+type parser struct {
+	tokens []token
+	pos    int
+	roots  map[string]bool
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseExpr(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, errorAt(p.peek().pos, "expression nested deeper than %d levels", maxDepth)
+	}
+	return p.parseOr(depth)
+}
+
+func (p *parser) parseOr(depth int) (node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseComparison(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseComparison(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison(depth int) (node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if !isComparisonOperator(tok) {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); isComparisonOperator(next) {
+		return nil, errorAt(next.pos, "comparisons cannot be chained; use && or parentheses")
+	}
+	return &compareNode{op: tok.text, pos: tok.pos, left: left, right: right}, nil
+}
+
+func isComparisonOperator(tok token) bool {
+	switch {
+	case tok.kind == tokIdent:
+		return tok.text == "in"
+	case tok.kind != tokOp:
+		return false
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseUnary(depth int) (node, error) {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "!" {
+		p.next()
+		if depth+1 > maxDepth {
+			return nil, errorAt(tok.pos, "expression nested deeper than %d levels", maxDepth)
+		}
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parsePrimary(depth)
+}
+
+func (p *parser) parsePrimary(depth int) (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return literalNode{value: tok.num}, nil
+	case tokString:
+		return literalNode{value: tok.text}, nil
+	case tokLParen:
+		inner, err := p.parseExpr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorAt(closing.pos, "expected \")\", got %s", closing)
+		}
+		return inner, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		case "in":
+			return nil, errorAt(tok.pos, "unexpected %s", tok)
+		}
+		return p.parsePath(tok)
+	}
+	return nil, errorAt(tok.pos, "unexpected %s", tok)
+}
+
+func (p *parser) parsePath(root token) (node, error) {
+	if !validRoots[root.text] {
+		return nil, errorAt(root.pos, "unknown identifier %q (expected one of data, task, pr, review)", root.text)
+	}
+	if p.roots == nil {
+		p.roots = map[string]bool{}
+	}
+	p.roots[root.text] = true
+	path := []string{root.text}
+	for p.peek().kind == tokDot {
+		p.next()
+		field := p.next()
+		if field.kind != tokIdent {
+			return nil, errorAt(field.pos, "expected field name after \".\", got %s", field)
+		}
+		path = append(path, field.text)
+	}
+	return pathNode{path: path}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,7 @@ import (
 	"github.com/kandev/kandev/internal/workflow/controller"
 	"github.com/kandev/kandev/internal/workflow/models"
 	"github.com/kandev/kandev/internal/workflow/service"
+	"github.com/kandev/kandev/internal/workflow/simulator"
 	"github.com/kandev/kandev/internal/workflow/stepevents"
 	ws "github.com/kandev/kandev/pkg/websocket"
 )
@@ -74,6 +76,16 @@ func (h *Handlers) registerHTTP(router *gin.Engine) {
 	api.GET("/workspaces/:id/workflows/export", h.httpExportWorkflows)
 	api.POST("/workspaces/:id/workflows/import", h.httpImportWorkflows)
 
+	// Revision routes
+	api.GET("/workflows/:id/revisions", h.httpListRevisions)
+	api.POST("/workflows/:id/revisions", h.httpPublishRevision)
+	api.GET("/workflows/:id/revisions/:revision", h.httpGetRevision)
+	api.POST("/workflows/:id/revisions/migrate", h.httpMigrateRevisions)
+
+	// Simulation routes
+	api.POST("/workflows/:id/simulate", h.httpSimulateWorkflow)
+	api.POST("/workflow/simulate", h.httpSimulateYAML)
+
 	// History routes
 	api.GET("/sessions/:id/workflow/history", h.httpListHistoryBySession)
 }
@@ -363,6 +375,112 @@ func (h *Handlers) httpImportWorkflows(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// HTTP handlers - Revisions
+
+func (h *Handlers) httpListRevisions(c *gin.Context) {
+	resp, err := h.controller.ListRevisions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("failed to list workflow revisions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list revisions"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handlers) httpPublishRevision(c *gin.Context) {
+	rev, err := h.controller.PublishRevision(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeRevisionError(c, err, "Failed to publish revision")
+		return
+	}
+	c.JSON(http.StatusOK, rev)
+}
+
+func (h *Handlers) httpGetRevision(c *gin.Context) {
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive integer"})
+		return
+	}
+	rev, err := h.controller.GetRevision(c.Request.Context(), c.Param("id"), revision)
+	if err != nil {
+		h.writeRevisionError(c, err, "Failed to get revision")
+		return
+	}
+	c.JSON(http.StatusOK, rev)
+}
+
+func (h *Handlers) httpMigrateRevisions(c *gin.Context) {
+	var req controller.MigrateRevisionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	req.WorkflowID = c.Param("id")
+	plan, err := h.controller.MigrateRevisions(c.Request.Context(), req)
+	if errors.Is(err, models.ErrUnmappedRevisionSteps) {
+		// The plan names the tasks that still need a step mapping.
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "plan": plan})
+		return
+	}
+	if err != nil {
+		h.writeRevisionError(c, err, "Failed to migrate tasks")
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *Handlers) writeRevisionError(c *gin.Context, err error, clientErrMsg string) {
+	switch {
+	case errors.Is(err, models.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrInvalidRevisionMigration), errors.Is(err, models.ErrNoRevisionSteps):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(strings.ToLower(clientErrMsg), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": clientErrMsg})
+	}
+}
+
+// HTTP handlers - Simulation
+
+func (h *Handlers) httpSimulateWorkflow(c *gin.Context) {
+	var req controller.SimulateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	req.WorkflowID = c.Param("id")
+	result, err := h.controller.SimulateWorkflow(c.Request.Context(), req)
+	switch {
+	case errors.Is(err, simulator.ErrInvalidScenario):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, models.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		h.logger.Error("failed to simulate workflow", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to simulate workflow"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// httpSimulateYAML simulates a workflow that is not stored. Every failure is
+// a problem with the submitted YAML or scenario.
+func (h *Handlers) httpSimulateYAML(c *gin.Context) {
+	var req controller.SimulateYAMLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	result, err := h.controller.SimulateYAML(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // respondYAML marshals the value as YAML and writes it to the response.
 func (h *Handlers) respondYAML(c *gin.Context, v any) {
 	data, err := yaml.Marshal(v)
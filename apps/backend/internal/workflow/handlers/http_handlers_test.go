@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -428,6 +430,47 @@ func TestListHistoryEndpointSeparatesDeniedFromBroken(t *testing.T) {
 	})
 }
 
+type fakeCheckResultLister struct {
+	results map[string][]*taskmodels.StepCheckResult
+}
+
+func (f fakeCheckResultLister) ListStepCheckResults(_ context.Context, sessionID string) ([]*taskmodels.StepCheckResult, error) {
+	return f.results[sessionID], nil
+}
+
+// TestListHistoryEndpointReportsGateResults shows why a task bounced: the
+// history carries the failed run_check that routed it back.
+func TestListHistoryEndpointReportsGateResults(t *testing.T) {
+	h := setupStepRouter(t)
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	h.service.SetStepCheckResultLister(fakeCheckResultLister{results: map[string][]*taskmodels.StepCheckResult{
+		"session-1": {{
+			ID: 7, TaskID: "task-1", SessionID: "session-1", StepID: "review", Trigger: "on_turn_complete",
+			Command: "make test", ExitCode: 2, Output: "FAIL", DurationMs: 1200, TargetStepID: "build", OccurredAt: at,
+		}},
+	}})
+
+	rec := doJSON(t, h.router, http.MethodGet, "/api/v1/sessions/session-1/workflow/history", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var got controller.ListHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []controller.StepCheckResultDTO{{
+		ID: 7, StepID: "review", Trigger: "on_turn_complete", Command: "make test",
+		ExitCode: 2, Output: "FAIL", DurationMs: 1200, TargetStepID: "build", OccurredAt: at,
+	}}
+	if !reflect.DeepEqual(got.Checks, want) {
+		t.Fatalf("checks = %#v, want %#v", got.Checks, want)
+	}
+
+	h.service.SetSessionAccessChecker(func(context.Context, string) error { return taskmodels.ErrTaskSessionNotFound })
+	rec = doJSON(t, h.router, http.MethodGet, "/api/v1/sessions/session-1/workflow/history", nil)
+	requireJSONError(t, rec, http.StatusNotFound, "session not found")
+}
+
 // TestExportWorkflowEndpoints pins the YAML content type and the exported
 // document, plus the `ids` filter semantics: absent means "everything
 // non-hidden", present restricts to that set and may name a hidden workflow.
@@ -613,3 +656,74 @@ workflows:
 		}
 	})
 }
+
+// TestSimulateEndpoints pins both simulation surfaces: a stored workflow
+// simulated from its live steps, and inline YAML that was never imported.
+func TestSimulateEndpoints(t *testing.T) {
+	h := setupStepRouter(t)
+	if _, err := h.db.Exec(
+		`INSERT INTO workflows (id, workspace_id, name, created_at, updated_at)
+		 VALUES ('workflow-1','ws-1','Main',CURRENT_TIMESTAMP,CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("seed workflows: %v", err)
+	}
+	createStepViaHTTP(t, h.router, map[string]interface{}{
+		"workflow_id": "workflow-1", "name": "Work", "position": 0,
+		"events": map[string]interface{}{
+			"on_turn_complete": []map[string]interface{}{{"type": "move_to_next"}},
+		},
+	})
+	createStepViaHTTP(t, h.router, map[string]interface{}{
+		"workflow_id": "workflow-1", "name": "Done", "position": 1,
+	})
+
+	decodePath := func(t *testing.T, rec *httptest.ResponseRecorder) []string {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+		var got struct {
+			Path []string `json:"path"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return got.Path
+	}
+
+	t.Run("stored workflow", func(t *testing.T) {
+		rec := doJSON(t, h.router, http.MethodPost, "/api/v1/workflows/workflow-1/simulate", map[string]interface{}{
+			"scenario": map[string]interface{}{"events": []map[string]string{{"type": "turn_complete"}}},
+		})
+		if path := decodePath(t, rec); strings.Join(path, ",") != "Work,Done" {
+			t.Fatalf("path = %v, want Work,Done", path)
+		}
+	})
+
+	t.Run("inline yaml", func(t *testing.T) {
+		rec := doJSON(t, h.router, http.MethodPost, "/api/v1/workflow/simulate", map[string]interface{}{
+			"yaml":     "version: 1\ntype: kandev_workflow\nworkflows:\n  - name: Solo\n    steps:\n      - name: Only\n        position: 0\n",
+			"scenario": map[string]interface{}{"events": []map[string]string{{"type": "turn_complete"}}},
+		})
+		if path := decodePath(t, rec); strings.Join(path, ",") != "Only" {
+			t.Fatalf("path = %v, want Only", path)
+		}
+	})
+
+	t.Run("scenario that does not fit the workflow is 400", func(t *testing.T) {
+		rec := doJSON(t, h.router, http.MethodPost, "/api/v1/workflows/workflow-1/simulate", map[string]interface{}{
+			"scenario": map[string]interface{}{"start_step": "Deploy"},
+		})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400; body = %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("unknown revision is 404", func(t *testing.T) {
+		rec := doJSON(t, h.router, http.MethodPost, "/api/v1/workflows/workflow-1/simulate", map[string]interface{}{
+			"revision": 9,
+		})
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404; body = %s", rec.Code, rec.Body.String())
+		}
+	})
+}
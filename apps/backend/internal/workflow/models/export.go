@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"maps"
+	"strings"
 
 	taskmodels "github.com/kandev/kandev/internal/task/models"
 )
@@ -38,8 +39,12 @@ type AgentProfileResolver func(profileID string) *AgentProfilePortable
 type AgentProfileMatcher func(agentName, model, mode, currentID string) string
 
 // WorkflowPortable is a workflow without instance-specific fields (IDs, timestamps).
+// Revision is the exporting instance's revision number of the definition;
+// imports and syncs record it on the revision they cut but never number
+// their own revisions after it.
 type WorkflowPortable struct {
 	Name         string                `json:"name" yaml:"name"`
+	Revision     int                   `json:"revision,omitempty" yaml:"revision,omitempty"`
 	Description  string                `json:"description,omitempty" yaml:"description,omitempty"`
 	Prompt       string                `json:"prompt,omitempty" yaml:"prompt,omitempty"`
 	AgentProfile *AgentProfilePortable `json:"agent_profile,omitempty" yaml:"agent_profile,omitempty"`
@@ -150,6 +155,15 @@ func (e *WorkflowExport) Validate() error {
 			if err := validateOnEnterActions(step); err != nil {
 				return fmt.Errorf("workflow %d step %d: %w", i, j, err)
 			}
+			if err := ValidateTransitionGuards(step.Events); err != nil {
+				return fmt.Errorf("workflow %d step %d: step %q %w", i, j, step.Name, err)
+			}
+			if err := ValidateStepTimeout(step.Events); err != nil {
+				return fmt.Errorf("workflow %d step %d: step %q %w", i, j, step.Name, err)
+			}
+			if err := ValidateIterationLimit(step.Events); err != nil {
+				return fmt.Errorf("workflow %d step %d: step %q %w", i, j, step.Name, err)
+			}
 			if step.WIPLimit < 0 {
 				return fmt.Errorf("workflow %d step %d: wip_limit must be non-negative", i, j)
 			}
@@ -248,16 +262,90 @@ func validateStepPositionRefs(steps []StepPortable, validPositions map[int]bool)
 			}
 		}
 		for _, a := range step.Events.OnTurnComplete {
-			if a.Type == OnTurnCompleteMoveToStep {
+			switch a.Type {
+			case OnTurnCompleteMoveToStep:
 				if err := checkPositionRef(a.Config, validPositions); err != nil {
 					return fmt.Errorf("step %q on_turn_complete: %w", step.Name, err)
 				}
+			case OnTurnCompleteRunCheck:
+				if err := checkRunCheckConfig(a.Config, validPositions); err != nil {
+					return fmt.Errorf("step %q on_turn_complete: %w", step.Name, err)
+				}
+			}
+		}
+		for _, a := range step.Events.OnEnter {
+			var err error
+			switch a.Type {
+			case OnEnterRunCheck:
+				err = checkRunCheckConfig(a.Config, validPositions)
+			case OnEnterFanOut:
+				err = checkFanOutConfig(a.Config, validPositions)
+			}
+			if err != nil {
+				return fmt.Errorf("step %q on_enter: %w", step.Name, err)
+			}
+		}
+		for _, a := range step.Events.OnTimeout {
+			if a.Type == GenericActionMoveToStep {
+				if err := checkPositionRef(a.Config, validPositions); err != nil {
+					return fmt.Errorf("step %q on_timeout: %w", step.Name, err)
+				}
+			}
+		}
+		for _, a := range step.Events.OnIterationLimit {
+			if a.Type == GenericActionMoveToStep {
+				if err := checkPositionRef(a.Config, validPositions); err != nil {
+					return fmt.Errorf("step %q on_iteration_limit: %w", step.Name, err)
+				}
 			}
 		}
 	}
 	return nil
 }
 
+// checkRunCheckConfig validates a portable run_check action: a command is
+// required and the optional pass/fail positions must point at real steps.
+func checkRunCheckConfig(config map[string]any, validPositions map[int]bool) error {
+	if command, _ := config[RunCheckCommandConfigKey].(string); strings.TrimSpace(command) == "" {
+		return fmt.Errorf("run_check action requires a non-empty string \"command\" config")
+	}
+	for _, key := range []string{"pass_step_position", "fail_step_position"} {
+		pos, exists := config[key]
+		if !exists {
+			continue
+		}
+		posInt, ok := toInt(pos)
+		if !ok {
+			return fmt.Errorf("%s has unexpected type %T", key, pos)
+		}
+		if !validPositions[posInt] {
+			return fmt.Errorf("%s %d does not match any step", key, posInt)
+		}
+	}
+	return nil
+}
+
+// checkFanOutConfig validates a portable fan_out action. A branch step in
+// the same workflow travels as branch_step_position and must point at a
+// real step; one in another workflow keeps its id.
+func checkFanOutConfig(config map[string]any, validPositions map[int]bool) error {
+	if err := ValidateFanOutConfig(config); err != nil {
+		return err
+	}
+	pos, exists := config["branch_step_position"]
+	if !exists {
+		return nil
+	}
+	posInt, ok := toInt(pos)
+	if !ok {
+		return fmt.Errorf("branch_step_position has unexpected type %T", pos)
+	}
+	if !validPositions[posInt] {
+		return fmt.Errorf("branch_step_position %d does not match any step", posInt)
+	}
+	return nil
+}
+
 func checkPositionRef(config map[string]any, validPositions map[int]bool) error {
 	if config == nil {
 		return fmt.Errorf("move_to_step action missing config")
@@ -371,12 +459,24 @@ func ConvertPositionToStepID(events StepEvents, posToID map[int]string) StepEven
 	})
 }
 
-// remapStepEvents rewrites move_to_step config in OnTurnStart and OnTurnComplete actions,
-// replacing fromKey with toKey using the provided lookup function.
+// remapStepEvents rewrites move_to_step config in OnTurnStart, OnTurnComplete,
+// OnTimeout and OnIterationLimit actions, replacing fromKey with toKey using
+// the provided lookup function. The step timeout and iteration limit are the
+// only non-kanban triggers carried across the portable format.
 func remapStepEvents(events StepEvents, fromKey, toKey string, lookup func(any) (any, bool)) StepEvents {
 	result := StepEvents{
-		OnEnter: append([]OnEnterAction{}, events.OnEnter...),
-		OnExit:  append([]OnExitAction{}, events.OnExit...),
+		OnExit:        append([]OnExitAction{}, events.OnExit...),
+		Timeout:       events.Timeout,
+		MaxIterations: events.MaxIterations,
+	}
+	for _, a := range events.OnEnter {
+		switch a.Type {
+		case OnEnterRunCheck:
+			a = OnEnterAction{Type: a.Type, Config: remapRunCheckTargets(a.Config, fromKey, toKey, lookup)}
+		case OnEnterFanOut:
+			a = OnEnterAction{Type: a.Type, Config: remapFanOutBranchStep(a.Config, fromKey, toKey, lookup)}
+		}
+		result.OnEnter = append(result.OnEnter, a)
 	}
 	for _, a := range events.OnTurnStart {
 		if a.Type == OnTurnStartMoveToStep {
@@ -387,16 +487,60 @@ func remapStepEvents(events StepEvents, fromKey, toKey string, lookup func(any)
 		result.OnTurnStart = append(result.OnTurnStart, a)
 	}
 	for _, a := range events.OnTurnComplete {
-		if a.Type == OnTurnCompleteMoveToStep {
+		switch a.Type {
+		case OnTurnCompleteMoveToStep:
 			if cfg, ok := remapConfigKey(a.Config, fromKey, toKey, lookup); ok {
 				a = OnTurnCompleteAction{Type: a.Type, Config: cfg}
 			}
+		case OnTurnCompleteRunCheck:
+			a = OnTurnCompleteAction{Type: a.Type, Config: remapRunCheckTargets(a.Config, fromKey, toKey, lookup)}
 		}
 		result.OnTurnComplete = append(result.OnTurnComplete, a)
 	}
+	result.OnTimeout = remapGenericMoveToStep(events.OnTimeout, fromKey, toKey, lookup)
+	result.OnIterationLimit = remapGenericMoveToStep(events.OnIterationLimit, fromKey, toKey, lookup)
+	return result
+}
+
+// remapGenericMoveToStep rewrites the move_to_step targets of generic
+// trigger actions.
+func remapGenericMoveToStep(actions []GenericAction, fromKey, toKey string, lookup func(any) (any, bool)) []GenericAction {
+	var result []GenericAction
+	for _, a := range actions {
+		if a.Type == GenericActionMoveToStep {
+			if cfg, ok := remapConfigKey(a.Config, fromKey, toKey, lookup); ok {
+				a = GenericAction{Type: a.Type, Config: cfg}
+			}
+		}
+		result = append(result, a)
+	}
 	return result
 }
 
+// remapRunCheckTargets rewrites the pass_/fail_-prefixed step references of
+// a run_check config (pass_step_id ↔ pass_step_position, same for fail).
+func remapRunCheckTargets(config map[string]any, fromKey, toKey string, lookup func(any) (any, bool)) map[string]any {
+	for _, prefix := range []string{"pass_", "fail_"} {
+		if cfg, ok := remapConfigKey(config, prefix+fromKey, prefix+toKey, lookup); ok {
+			config = cfg
+		}
+	}
+	return config
+}
+
+// remapFanOutBranchStep rewrites a fan_out's branch step reference
+// (branch_step_id ↔ branch_step_position). A branch step in another
+// workflow (branch_workflow_id set) is not ours to remap.
+func remapFanOutBranchStep(config map[string]any, fromKey, toKey string, lookup func(any) (any, bool)) map[string]any {
+	if workflowID, _ := config[FanOutBranchWorkflowConfigKey].(string); workflowID != "" {
+		return config
+	}
+	if cfg, ok := remapConfigKey(config, "branch_"+fromKey, "branch_"+toKey, lookup); ok {
+		return cfg
+	}
+	return config
+}
+
 // remapConfigKey copies config, replaces fromKey with toKey using lookup.
 func remapConfigKey(config map[string]any, fromKey, toKey string, lookup func(any) (any, bool)) (map[string]any, bool) {
 	if config == nil {
@@ -615,3 +615,193 @@ func TestToInt(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func TestRunCheckExportRoundTrip(t *testing.T) {
+	steps := []*WorkflowStep{
+		{
+			ID: "orig-a", Name: "Implement", Position: 0,
+			Events: StepEvents{
+				OnTurnComplete: []OnTurnCompleteAction{
+					{Type: OnTurnCompleteRunCheck, Config: map[string]any{
+						"command":      "make test",
+						"pass_step_id": "orig-b",
+						"fail_step_id": "orig-a",
+					}},
+				},
+			},
+		},
+		{ID: "orig-b", Name: "Review", Position: 1},
+	}
+	wf := &taskmodels.Workflow{ID: "wf-1", Name: "Gated"}
+	export := BuildWorkflowExport([]*taskmodels.Workflow{wf}, map[string][]*WorkflowStep{"wf-1": steps}, nil)
+	require.NoError(t, export.Validate())
+
+	cfg := export.Workflows[0].Steps[0].Events.OnTurnComplete[0].Config
+	assert.Equal(t, 1, cfg["pass_step_position"])
+	assert.Equal(t, 0, cfg["fail_step_position"])
+	assert.Nil(t, cfg["pass_step_id"])
+
+	imported := ConvertPositionToStepID(export.Workflows[0].Steps[0].Events, map[int]string{0: "new-a", 1: "new-b"})
+	got := imported.OnTurnComplete[0].Config
+	assert.Equal(t, "new-b", got[RunCheckPassStepConfigKey])
+	assert.Equal(t, "new-a", got[RunCheckFailStepConfigKey])
+	assert.Equal(t, "make test", got[RunCheckCommandConfigKey])
+}
+
+func TestStepTimeoutExportRoundTrip(t *testing.T) {
+	steps := []*WorkflowStep{
+		{ID: "orig-a", Name: "Triage", Position: 0},
+		{
+			ID: "orig-b", Name: "Review", Position: 1,
+			Events: StepEvents{
+				Timeout: &StepTimeout{AfterMinutes: 2880},
+				OnTimeout: []GenericAction{
+					{Type: GenericActionNotify},
+					{Type: GenericActionMoveToStep, Config: map[string]any{"step_id": "orig-a"}},
+				},
+			},
+		},
+	}
+	wf := &taskmodels.Workflow{ID: "wf-1", Name: "SLA"}
+	export := BuildWorkflowExport([]*taskmodels.Workflow{wf}, map[string][]*WorkflowStep{"wf-1": steps}, nil)
+	require.NoError(t, export.Validate())
+
+	events := export.Workflows[0].Steps[1].Events
+	require.NotNil(t, events.Timeout)
+	assert.Equal(t, 2880, events.Timeout.AfterMinutes)
+	require.Len(t, events.OnTimeout, 2)
+	assert.Equal(t, 0, events.OnTimeout[1].Config["step_position"])
+
+	imported := ConvertPositionToStepID(events, map[int]string{0: "new-a", 1: "new-b"})
+	assert.Equal(t, "new-a", imported.OnTimeout[1].Config["step_id"])
+	assert.Equal(t, 2880, imported.Timeout.AfterMinutes)
+}
+
+func TestIterationLimitExportRoundTrip(t *testing.T) {
+	steps := []*WorkflowStep{
+		{ID: "orig-a", Name: "Build", Position: 0, Events: StepEvents{
+			MaxIterations: 3,
+			OnIterationLimit: []GenericAction{
+				{Type: GenericActionMoveToStep, Config: map[string]any{"step_id": "orig-b"}},
+			},
+		}},
+		{ID: "orig-b", Name: "Human approval", Position: 1},
+	}
+	wf := &taskmodels.Workflow{ID: "wf-1", Name: "Bounded"}
+	export := BuildWorkflowExport([]*taskmodels.Workflow{wf}, map[string][]*WorkflowStep{"wf-1": steps}, nil)
+	require.NoError(t, export.Validate())
+
+	events := export.Workflows[0].Steps[0].Events
+	assert.Equal(t, 3, events.MaxIterations)
+	require.Len(t, events.OnIterationLimit, 1)
+	assert.Equal(t, 1, events.OnIterationLimit[0].Config["step_position"])
+
+	imported := ConvertPositionToStepID(events, map[int]string{0: "new-a", 1: "new-b"})
+	assert.Equal(t, 3, imported.MaxIterations)
+	assert.Equal(t, "new-b", imported.OnIterationLimit[0].Config["step_id"])
+
+	events.OnIterationLimit[0].Config["step_position"] = 9
+	require.ErrorContains(t, export.Validate(), "on_iteration_limit")
+}
+
+func TestValidateRunCheck(t *testing.T) {
+	build := func(cfg map[string]any) *WorkflowExport {
+		return &WorkflowExport{
+			Version: ExportVersion,
+			Type:    ExportType,
+			Workflows: []WorkflowPortable{{
+				Name: "Gated",
+				Steps: []StepPortable{{
+					Name: "Implement", Position: 0,
+					Events: StepEvents{OnTurnComplete: []OnTurnCompleteAction{{Type: OnTurnCompleteRunCheck, Config: cfg}}},
+				}},
+			}},
+		}
+	}
+	require.NoError(t, build(map[string]any{"command": "make test"}).Validate())
+	require.ErrorContains(t, build(map[string]any{"command": " "}).Validate(), "command")
+	require.ErrorContains(t, build(map[string]any{"command": "make test", "fail_step_position": 7}).Validate(), "fail_step_position 7")
+}
+
+func TestRemapStepEventsRunCheck(t *testing.T) {
+	events := StepEvents{
+		OnEnter: []OnEnterAction{{Type: OnEnterRunCheck, Config: map[string]any{"command": "make", "pass_step_id": "tpl-b"}}},
+	}
+	remapped := RemapStepEvents(events, map[string]string{"tpl-b": "step-b"})
+	assert.Equal(t, "step-b", remapped.OnEnter[0].Config[RunCheckPassStepConfigKey])
+	assert.Equal(t, "tpl-b", events.OnEnter[0].Config[RunCheckPassStepConfigKey], "input config must not be mutated")
+}
+
+func TestFanOutExportRoundTrip(t *testing.T) {
+	fanOut := func(cfg map[string]any) []*WorkflowStep {
+		cfg["agent_profile_ids"] = []any{"claude", "codex"}
+		return []*WorkflowStep{
+			{
+				ID: "orig-a", Name: "Race", Position: 0,
+				Events: StepEvents{OnEnter: []OnEnterAction{{Type: OnEnterFanOut, Config: cfg}}},
+			},
+			{ID: "orig-b", Name: "Solo", Position: 1},
+		}
+	}
+	wf := &taskmodels.Workflow{ID: "wf-1", Name: "Best of N"}
+
+	export := BuildWorkflowExport([]*taskmodels.Workflow{wf}, map[string][]*WorkflowStep{"wf-1": fanOut(map[string]any{"branch_step_id": "orig-b"})}, nil)
+	require.NoError(t, export.Validate())
+	cfg := export.Workflows[0].Steps[0].Events.OnEnter[0].Config
+	assert.Equal(t, 1, cfg["branch_step_position"])
+	assert.Nil(t, cfg["branch_step_id"])
+	imported := ConvertPositionToStepID(export.Workflows[0].Steps[0].Events, map[int]string{0: "new-a", 1: "new-b"})
+	assert.Equal(t, "new-b", imported.OnEnter[0].Config[FanOutBranchStepConfigKey])
+
+	// A branch step in another workflow keeps its id.
+	export = BuildWorkflowExport([]*taskmodels.Workflow{wf}, map[string][]*WorkflowStep{"wf-1": fanOut(map[string]any{
+		"branch_workflow_id": "wf-solo", "branch_step_id": "solo-1",
+	})}, nil)
+	require.NoError(t, export.Validate())
+	assert.Equal(t, "solo-1", export.Workflows[0].Steps[0].Events.OnEnter[0].Config[FanOutBranchStepConfigKey])
+}
+
+func TestValidateFanOutConfig(t *testing.T) {
+	require.NoError(t, ValidateFanOutConfig(map[string]any{"agent_profile_ids": []any{"claude", "codex"}}))
+	require.ErrorContains(t, ValidateFanOutConfig(map[string]any{"agent_profile_ids": []any{"claude", "claude"}}), "at least two")
+	require.ErrorContains(t, ValidateFanOutConfig(map[string]any{
+		"agent_profile_ids": []string{"claude", "codex"}, "join": "vote",
+	}), "unknown join")
+	require.ErrorContains(t, ValidateFanOutConfig(map[string]any{
+		"agent_profile_ids": []string{"claude", "codex"}, "join": FanOutJoinJudge,
+	}), FanOutJudgeProfileConfigKey)
+}
+
+func TestValidateTransitionGuardExpression(t *testing.T) {
+	build := func(cfg map[string]any) *WorkflowExport {
+		cfg["step_position"] = 1
+		return &WorkflowExport{
+			Version: ExportVersion,
+			Type:    ExportType,
+			Workflows: []WorkflowPortable{{
+				Name: "Guarded",
+				Steps: []StepPortable{
+					{
+						Name: "Review", Position: 0,
+						Events: StepEvents{OnTurnComplete: []OnTurnCompleteAction{{Type: OnTurnCompleteMoveToStep, Config: cfg}}},
+					},
+					{Name: "Ready to merge", Position: 1},
+				},
+			}},
+		}
+	}
+	require.NoError(t, build(map[string]any{"if": `review.blocking_findings == 0 && pr.checks == "success"`}).Validate())
+	require.ErrorContains(t, build(map[string]any{"if": `review.blocking_findings = 0`}).Validate(), `step "Review" on_turn_complete move_to_step: invalid if expression`)
+	require.ErrorContains(t, build(map[string]any{"if": `reviewer.approved`}).Validate(), `unknown identifier "reviewer"`)
+	require.ErrorContains(t, build(map[string]any{
+		"if":              "data.ok",
+		"wait_for_quorum": map[string]any{"role": "reviewer", "threshold": "all_approve"},
+	}).Validate(), "cannot be combined")
+}
+
+func TestValidateWorkflowStepGuardExpression(t *testing.T) {
+	step := &WorkflowStep{
+		Events: StepEvents{OnComment: []GenericAction{{Type: GenericActionMoveToNext, Config: map[string]any{"if": "task.priority =="}}}},
+	}
+	require.ErrorContains(t, ValidateWorkflowStep(step), "on_comment move_to_next: invalid if expression")
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fan_out action config keys. The branch workflow/step keys are optional
+// and default to the parent task's workflow and its first runnable step.
+const (
+	FanOutAgentProfilesConfigKey  = "agent_profile_ids"
+	FanOutJoinConfigKey           = "join"
+	FanOutJudgeProfileConfigKey   = "judge_agent_profile_id"
+	FanOutBranchWorkflowConfigKey = "branch_workflow_id"
+	FanOutBranchStepConfigKey     = "branch_step_id"
+)
+
+// Join strategies understood by the fan_out action.
+//
+//   - first_success: the first branch to complete wins; the others are
+//     cancelled.
+//   - judge: once every branch has finished, a judge agent picks the best
+//     result.
+//   - human: once every branch has finished, the user picks.
+const (
+	FanOutJoinFirstSuccess = "first_success"
+	FanOutJoinJudge        = "judge"
+	FanOutJoinHuman        = "human"
+)
+
+// ValidFanOutJoin reports whether join is a supported fan_out strategy.
+func ValidFanOutJoin(join string) bool {
+	switch join {
+	case FanOutJoinFirstSuccess, FanOutJoinJudge, FanOutJoinHuman:
+		return true
+	}
+	return false
+}
+
+// FanOutAgentProfileIDs reads the branch agent profiles from a fan_out
+// config. It accepts both []string and the []any shape JSON/YAML decoding
+// produces; blank and duplicate entries are dropped.
+func FanOutAgentProfileIDs(config map[string]any) []string {
+	var raw []any
+	switch v := config[FanOutAgentProfilesConfigKey].(type) {
+	case []string:
+		for _, id := range v {
+			raw = append(raw, id)
+		}
+	case []any:
+		raw = v
+	}
+	seen := make(map[string]bool, len(raw))
+	ids := make([]string, 0, len(raw))
+	for _, item := range raw {
+		id, _ := item.(string)
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// FanOutJoin reads the join strategy from a fan_out config, defaulting to
+// first_success.
+func FanOutJoin(config map[string]any) string {
+	join, _ := config[FanOutJoinConfigKey].(string)
+	join = strings.TrimSpace(join)
+	if join == "" {
+		return FanOutJoinFirstSuccess
+	}
+	return join
+}
+
+// ValidateFanOutConfig rejects fan_out actions the engine would skip at
+// compile time: fewer than two branches, an unknown join strategy, or a
+// judge join without a judge profile.
+func ValidateFanOutConfig(config map[string]any) error {
+	if n := len(FanOutAgentProfileIDs(config)); n < 2 {
+		return fmt.Errorf("fan_out requires at least two distinct %q, got %d", FanOutAgentProfilesConfigKey, n)
+	}
+	join := FanOutJoin(config)
+	if !ValidFanOutJoin(join) {
+		return fmt.Errorf("fan_out has unknown join %q", join)
+	}
+	if join == FanOutJoinJudge {
+		if judge, _ := config[FanOutJudgeProfileConfigKey].(string); strings.TrimSpace(judge) == "" {
+			return fmt.Errorf("fan_out join %q requires %q", join, FanOutJudgeProfileConfigKey)
+		}
+	}
+	return nil
+}
@@ -31,12 +31,49 @@ const (
 	// how a different model can review than implemented. A failed review does
 	// not block the transition.
 	OnEnterRunCodeReview OnEnterActionType = "run_code_review"
+
+	// OnEnterRunCheck runs a shell command in the task's workspace as a
+	// quality gate when the task enters the step. See RunCheckCommandConfigKey
+	// and friends for the config shape.
+	OnEnterRunCheck OnEnterActionType = "run_check"
+
+	// OnEnterFanOut launches the step's prompt across several agent profiles
+	// as parallel child tasks and joins their results. See FanOutConfigKey
+	// constants for the config shape.
+	OnEnterFanOut OnEnterActionType = "fan_out"
+
+	// OnEnterSetPermissionPolicy applies allow/deny/ask permission rules to
+	// the session while the task is in the step. The rules are evaluated by
+	// agentctl ahead of the agent profile's rules. See
+	// PermissionPolicyRulesConfigKey for the config shape.
+	OnEnterSetPermissionPolicy OnEnterActionType = "set_permission_policy"
 )
 
 // ReviewAgentProfileConfigKey is the on_enter action config key naming the
 // agent profile that should perform a run_code_review pass.
 const ReviewAgentProfileConfigKey = "agent_profile_id"
 
+// run_check action config keys. The pass/fail keys hold step IDs on the
+// instance and are swapped for pass_step_position/fail_step_position on
+// export.
+const (
+	RunCheckCommandConfigKey  = "command"
+	RunCheckTimeoutConfigKey  = "timeout_seconds"
+	RunCheckPassStepConfigKey = "pass_step_id"
+	RunCheckFailStepConfigKey = "fail_step_id"
+)
+
+// ClearDecisionsResetIterationsConfigKey is the clear_decisions action
+// config key that also resets the task's max_iterations counters, so a
+// human approval step can grant a bounded loop a fresh budget.
+const ClearDecisionsResetIterationsConfigKey = "reset_iterations"
+
+// TransitionGuardConfigKey is the transition action config key holding the
+// guard. A map value carries wait_for_quorum; a string value is a guard
+// expression (see package guardexpr), e.g.
+// `review.blocking_findings == 0 && pr.checks == "success"`.
+const TransitionGuardConfigKey = "if"
+
 // OnTurnStartActionType represents the type of action to execute when a user sends a message.
 type OnTurnStartActionType string
 
@@ -60,6 +97,10 @@ const (
 	OnTurnCompleteMoveToPrevious  OnTurnCompleteActionType = "move_to_previous"
 	OnTurnCompleteMoveToStep      OnTurnCompleteActionType = "move_to_step"
 	OnTurnCompleteDisablePlanMode OnTurnCompleteActionType = "disable_plan_mode"
+	// OnTurnCompleteRunCheck gates the end of a turn on a shell command (tests,
+	// lint, build). Exit 0 routes to the pass step, anything else routes to
+	// the fail step (or stays) and the output is fed back to the agent.
+	OnTurnCompleteRunCheck OnTurnCompleteActionType = "run_check"
 )
 
 // OnEnterAction represents an action to execute when entering a step.
@@ -90,7 +131,8 @@ type OnExitAction struct {
 // GenericActionType represents the type of a Phase 2 (ADR-0004) action that
 // can appear under any of the new event-driven triggers (on_comment,
 // on_blocker_resolved, on_children_completed, on_approval_resolved,
-// on_heartbeat, on_budget_alert, on_agent_error). Actions are compiled into
+// on_heartbeat, on_budget_alert, on_agent_error, on_timeout,
+// on_iteration_limit). Actions are compiled into
 // the engine's typed Action structs by engine.CompileStep.
 type GenericActionType string
 
@@ -112,6 +154,15 @@ const (
 	// GenericActionQueueRunForEachParticipant fans out queue_run over
 	// every participant of the step matching a configured role.
 	GenericActionQueueRunForEachParticipant GenericActionType = "queue_run_for_each_participant"
+	// GenericActionRunCheck runs a shell command in the task's workspace
+	// and routes on its exit status.
+	GenericActionRunCheck GenericActionType = "run_check"
+	// GenericActionNotify sends a user notification (title, message)
+	// through the notification providers subscribed to workflow events.
+	GenericActionNotify GenericActionType = "notify"
+	// GenericActionCreateChildTask spawns a child task (title, description,
+	// workflow_id, step_id, agent_profile_id) under the trigger's task.
+	GenericActionCreateChildTask GenericActionType = "create_child_task"
 )
 
 // GenericAction is the persisted shape of a Phase 2 action used in the
@@ -146,6 +197,35 @@ type StepEvents struct {
 	OnHeartbeat         []GenericAction `json:"on_heartbeat,omitempty" yaml:"on_heartbeat,omitempty"`
 	OnBudgetAlert       []GenericAction `json:"on_budget_alert,omitempty" yaml:"on_budget_alert,omitempty"`
 	OnAgentError        []GenericAction `json:"on_agent_error,omitempty" yaml:"on_agent_error,omitempty"`
+
+	// Timeout is the step's SLA; OnTimeout runs when it elapses. The timer
+	// itself lives in the cron scheduler (see scheduler/cron.StepTimeoutHandler).
+	Timeout   *StepTimeout    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	OnTimeout []GenericAction `json:"on_timeout,omitempty" yaml:"on_timeout,omitempty"`
+
+	// MaxIterations bounds how many times automatic transitions may enter
+	// the step. Once the task has entered it MaxIterations times, the next
+	// automatic transition into it runs OnIterationLimit instead; with no
+	// OnIterationLimit actions the task stays where it is. Zero means
+	// unbounded. The engine keeps the count in the workflow data bag (see
+	// engine.IterationsDataKey).
+	MaxIterations    int             `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+	OnIterationLimit []GenericAction `json:"on_iteration_limit,omitempty" yaml:"on_iteration_limit,omitempty"`
+}
+
+// StepTimeout bounds how long a task may sit in a step before the step's
+// on_timeout actions fire. AfterMinutes counts wall-clock time since the
+// task entered the step; ActiveAfterMinutes counts only the time an agent
+// turn was running. Either or both may be set, and each fires at most once
+// per visit to the step.
+type StepTimeout struct {
+	AfterMinutes       int `json:"after_minutes,omitempty" yaml:"after_minutes,omitempty"`
+	ActiveAfterMinutes int `json:"active_after_minutes,omitempty" yaml:"active_after_minutes,omitempty"`
+}
+
+// Enabled reports whether any timeout is configured.
+func (t *StepTimeout) Enabled() bool {
+	return t != nil && (t.AfterMinutes > 0 || t.ActiveAfterMinutes > 0)
 }
 
 // ReviewStatus represents the review state of a session
@@ -285,6 +365,16 @@ const (
 	StepTransitionTriggerChildrenCompleted StepTransitionTrigger = "on_children_completed"
 	StepTransitionTriggerTaskUpdate        StepTransitionTrigger = "task_update"
 	StepTransitionTriggerQueuePromotion    StepTransitionTrigger = "queue_promotion"
+
+	// Automatic transitions caused by the other event-driven workflow
+	// triggers.
+	StepTransitionTriggerComment         StepTransitionTrigger = "on_comment"
+	StepTransitionTriggerBlockerResolved StepTransitionTrigger = "on_blocker_resolved"
+	StepTransitionTriggerAgentError      StepTransitionTrigger = "on_agent_error"
+	StepTransitionTriggerTimeout         StepTransitionTrigger = "on_timeout"
+	// StepTransitionTriggerIterationLimit identifies a transition routed by
+	// on_iteration_limit after a step's max_iterations was reached.
+	StepTransitionTriggerIterationLimit StepTransitionTrigger = "on_iteration_limit"
 )
 
 // StepTransitionActor identifies the source of a move. Human identity is
@@ -311,10 +401,20 @@ type SessionStepHistory struct {
 }
 
 // RemapStepEvents returns a copy of events with all step_id references
-// in move_to_step actions replaced using the provided ID mapping.
+// in move_to_step actions (and the pass/fail targets of run_check actions,
+// and same-workflow fan_out branch steps) replaced using the provided ID
+// mapping.
 func RemapStepEvents(events StepEvents, idMap map[string]string) StepEvents {
 	result := StepEvents{}
-	result.OnEnter = append(result.OnEnter, events.OnEnter...)
+	for _, a := range events.OnEnter {
+		switch a.Type {
+		case OnEnterRunCheck:
+			a.Config = remapRunCheckStepIDs(a.Config, idMap)
+		case OnEnterFanOut:
+			a.Config = remapFanOutBranchStepID(a.Config, idMap)
+		}
+		result.OnEnter = append(result.OnEnter, a)
+	}
 	for _, a := range events.OnTurnStart {
 		if a.Type == OnTurnStartMoveToStep {
 			a.Config = remapActionStepID(a.Config, idMap)
@@ -322,8 +422,11 @@ func RemapStepEvents(events StepEvents, idMap map[string]string) StepEvents {
 		result.OnTurnStart = append(result.OnTurnStart, a)
 	}
 	for _, a := range events.OnTurnComplete {
-		if a.Type == OnTurnCompleteMoveToStep {
+		switch a.Type {
+		case OnTurnCompleteMoveToStep:
 			a.Config = remapActionStepID(a.Config, idMap)
+		case OnTurnCompleteRunCheck:
+			a.Config = remapRunCheckStepIDs(a.Config, idMap)
 		}
 		result.OnTurnComplete = append(result.OnTurnComplete, a)
 	}
@@ -335,14 +438,21 @@ func RemapStepEvents(events StepEvents, idMap map[string]string) StepEvents {
 	result.OnHeartbeat = remapGenericStepEvents(events.OnHeartbeat, idMap)
 	result.OnBudgetAlert = remapGenericStepEvents(events.OnBudgetAlert, idMap)
 	result.OnAgentError = remapGenericStepEvents(events.OnAgentError, idMap)
+	result.Timeout = events.Timeout
+	result.OnTimeout = remapGenericStepEvents(events.OnTimeout, idMap)
+	result.MaxIterations = events.MaxIterations
+	result.OnIterationLimit = remapGenericStepEvents(events.OnIterationLimit, idMap)
 	return result
 }
 
 func remapGenericStepEvents(actions []GenericAction, idMap map[string]string) []GenericAction {
 	result := make([]GenericAction, 0, len(actions))
 	for _, action := range actions {
-		if action.Type == GenericActionMoveToStep {
+		switch action.Type {
+		case GenericActionMoveToStep:
 			action.Config = remapActionStepID(action.Config, idMap)
+		case GenericActionRunCheck:
+			action.Config = remapRunCheckStepIDs(action.Config, idMap)
 		}
 		result = append(result, action)
 	}
@@ -366,3 +476,51 @@ func remapActionStepID(config map[string]any, idMap map[string]string) map[strin
 	cfg["step_id"] = newID
 	return cfg
 }
+
+// remapRunCheckStepIDs rewrites the pass/fail step references of a run_check
+// action config. Unmapped or absent keys are left untouched.
+func remapRunCheckStepIDs(config map[string]any, idMap map[string]string) map[string]any {
+	if config == nil {
+		return nil
+	}
+	var cfg map[string]any
+	for _, key := range []string{RunCheckPassStepConfigKey, RunCheckFailStepConfigKey} {
+		stepID, ok := config[key].(string)
+		if !ok {
+			continue
+		}
+		newID, found := idMap[stepID]
+		if !found {
+			continue
+		}
+		if cfg == nil {
+			cfg = make(map[string]any, len(config))
+			maps.Copy(cfg, config)
+		}
+		cfg[key] = newID
+	}
+	if cfg == nil {
+		return config
+	}
+	return cfg
+}
+
+// remapFanOutBranchStepID rewrites a fan_out's same-workflow branch step.
+// Unmapped references and branch steps of another workflow are left as is.
+func remapFanOutBranchStepID(config map[string]any, idMap map[string]string) map[string]any {
+	if config == nil {
+		return nil
+	}
+	if workflowID, _ := config[FanOutBranchWorkflowConfigKey].(string); workflowID != "" {
+		return config
+	}
+	stepID, _ := config[FanOutBranchStepConfigKey].(string)
+	newID, found := idMap[stepID]
+	if !found {
+		return config
+	}
+	cfg := make(map[string]any, len(config))
+	maps.Copy(cfg, config)
+	cfg[FanOutBranchStepConfigKey] = newID
+	return cfg
+}
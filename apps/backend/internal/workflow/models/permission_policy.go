@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+)
+
+// PermissionPolicyRulesConfigKey is the set_permission_policy action config
+// key holding the rule list, in the same shape as an agent profile's
+// permission_policy.rules.
+const PermissionPolicyRulesConfigKey = "rules"
+
+// ParsePermissionPolicy decodes and validates a set_permission_policy config.
+// An empty rule list is valid and leaves only the profile's rules in effect.
+func ParsePermissionPolicy(config map[string]any) (*permpolicy.Policy, error) {
+	rules, ok := config[PermissionPolicyRulesConfigKey]
+	if !ok {
+		return nil, fmt.Errorf("set_permission_policy requires a %q list", PermissionPolicyRulesConfigKey)
+	}
+	raw, err := json.Marshal(map[string]any{PermissionPolicyRulesConfigKey: rules})
+	if err != nil {
+		return nil, fmt.Errorf("set_permission_policy: %w", err)
+	}
+	var policy permpolicy.Policy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return nil, fmt.Errorf("set_permission_policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("set_permission_policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// StepPermissionPolicy returns the rules of the step's set_permission_policy
+// action, or nil when the step has none.
+func StepPermissionPolicy(step *WorkflowStep) (*permpolicy.Policy, error) {
+	if step == nil {
+		return nil, nil
+	}
+	for _, action := range step.Events.OnEnter {
+		if action.Type == OnEnterSetPermissionPolicy {
+			return ParsePermissionPolicy(action.Config)
+		}
+	}
+	return nil, nil
+}
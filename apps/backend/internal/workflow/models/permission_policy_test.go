@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kandev/kandev/internal/agentctl/permpolicy"
+)
+
+func permissionPolicyStep(configs ...map[string]interface{}) *WorkflowStep {
+	step := &WorkflowStep{}
+	for _, config := range configs {
+		step.Events.OnEnter = append(step.Events.OnEnter, OnEnterAction{Type: OnEnterSetPermissionPolicy, Config: config})
+	}
+	return step
+}
+
+func TestValidateWorkflowStepPermissionPolicy(t *testing.T) {
+	valid := map[string]interface{}{"rules": []interface{}{
+		map[string]interface{}{"name": "deploy", "decision": "ask", "paths": []interface{}{"deploy/**"}},
+		map[string]interface{}{"decision": "deny", "command": `\brm\s+-rf\b`},
+	}}
+	tests := []struct {
+		name    string
+		step    *WorkflowStep
+		wantErr string
+	}{
+		{name: "accepts rules", step: permissionPolicyStep(valid)},
+		{name: "accepts empty rules", step: permissionPolicyStep(map[string]interface{}{"rules": []interface{}{}})},
+		{name: "rejects missing rules", step: permissionPolicyStep(map[string]interface{}{}), wantErr: `requires a "rules" list`},
+		{
+			name: "rejects unknown decision",
+			step: permissionPolicyStep(map[string]interface{}{"rules": []interface{}{
+				map[string]interface{}{"decision": "maybe"},
+			}}),
+			wantErr: "rule 1: unknown decision",
+		},
+		{name: "rejects two actions", step: permissionPolicyStep(valid, valid), wantErr: "at most one set_permission_policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWorkflowStep(tt.step)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestStepPermissionPolicy(t *testing.T) {
+	policy, err := StepPermissionPolicy(&WorkflowStep{})
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	policy, err = StepPermissionPolicy(permissionPolicyStep(map[string]interface{}{"rules": []interface{}{
+		map[string]interface{}{"name": "go test", "decision": "allow", "action_types": []interface{}{"command"}, "command": "^go test"},
+	}}))
+	require.NoError(t, err)
+	require.Len(t, policy.Rules, 1)
+	require.Equal(t, permpolicy.DecisionAllow, policy.Rules[0].Decision)
+	require.Equal(t, "^go test", policy.Rules[0].Command)
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Revision sources record what cut a workflow revision.
+const (
+	// RevisionSourceEdit: the live definition changed through the editor
+	// and a task was pinned, a revision was published, or tasks migrated.
+	RevisionSourceEdit = "edit"
+	// RevisionSourceImport: the workflow was created by a YAML import.
+	RevisionSourceImport = "import"
+	// RevisionSourceSync: workflow sync created or updated the workflow.
+	RevisionSourceSync = "sync"
+)
+
+var (
+	// ErrRevisionNotFound marks a lookup of a revision that was never cut.
+	ErrRevisionNotFound = errors.New("workflow revision not found")
+	// ErrNoRevisionSteps marks a revision request for a workflow without
+	// steps; there is nothing to snapshot.
+	ErrNoRevisionSteps = errors.New("workflow has no steps to snapshot")
+	// ErrInvalidRevisionMigration marks a migration request that names
+	// revisions or step mappings the workflow cannot satisfy.
+	ErrInvalidRevisionMigration = errors.New("invalid workflow revision migration")
+	// ErrUnmappedRevisionSteps is returned by a non-dry-run migration when
+	// at least one task sits on a step the target revision has no mapping
+	// for. Nothing is migrated; the returned plan lists the offenders.
+	ErrUnmappedRevisionSteps = errors.New("tasks sit on steps with no mapping in the target revision")
+)
+
+// WorkflowRevision is an immutable snapshot of a workflow's steps. Revisions
+// are numbered from 1 per workflow and never rewritten; the live
+// workflow_steps rows stay the editable working copy.
+//
+// SourceRevision is the revision number the definition carried in the YAML
+// it was imported or synced from, or 0.
+type WorkflowRevision struct {
+	WorkflowID     string          `json:"workflow_id"`
+	Revision       int             `json:"revision"`
+	Digest         string          `json:"digest"`
+	Source         string          `json:"source"`
+	SourceRevision int             `json:"source_revision,omitempty"`
+	Steps          []*WorkflowStep `json:"steps,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// Step returns the revision's step with the given ID.
+func (r *WorkflowRevision) Step(stepID string) (*WorkflowStep, bool) {
+	for _, s := range r.Steps {
+		if s.ID == stepID {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// RevisionTaskMove is one task of an applied revision migration.
+type RevisionTaskMove struct {
+	TaskID     string
+	FromStepID string
+	ToStepID   string
+}
+
+// WorkflowRevisionSummary is a revision without its steps, plus how many
+// tasks are pinned to it.
+type WorkflowRevisionSummary struct {
+	Revision       int       `json:"revision"`
+	Source         string    `json:"source"`
+	SourceRevision int       `json:"source_revision,omitempty"`
+	StepCount      int       `json:"step_count"`
+	TaskCount      int       `json:"task_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// RevisionStepsDigest fingerprints a step list so an unchanged definition
+// does not cut a new revision. Timestamps are left out: saving a step
+// without changing it only bumps updated_at.
+func RevisionStepsDigest(steps []*WorkflowStep) string {
+	stripped := make([]WorkflowStep, 0, len(steps))
+	for _, s := range steps {
+		c := *s
+		c.CreatedAt = time.Time{}
+		c.UpdatedAt = time.Time{}
+		stripped = append(stripped, c)
+	}
+	sort.Slice(stripped, func(i, j int) bool {
+		if stripped[i].Position != stripped[j].Position {
+			return stripped[i].Position < stripped[j].Position
+		}
+		return stripped[i].ID < stripped[j].ID
+	})
+	// WorkflowStep only holds JSON-safe values; Marshal cannot fail.
+	data, _ := json.Marshal(stripped)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// TaskRevisionPin is a task pinned to a workflow revision, with the step it
+// currently sits on.
+type TaskRevisionPin struct {
+	TaskID         string
+	Title          string
+	WorkflowStepID string
+	Revision       int
+}
+
+// Ways a migration resolved a task's target step.
+const (
+	RevisionMappedByExplicit = "explicit"
+	RevisionMappedByID       = "id"
+	RevisionMappedByName     = "name"
+)
+
+// RevisionTaskMigration is one task's line in a migration plan. MappedBy is
+// empty when no target step was found; ToStepID is then empty too. Applied
+// reports whether the task was moved (never set on a dry run); Skipped
+// explains why an otherwise mapped task was left alone.
+type RevisionTaskMigration struct {
+	TaskID       string `json:"task_id"`
+	Title        string `json:"title"`
+	FromStepID   string `json:"from_step_id"`
+	FromStepName string `json:"from_step_name,omitempty"`
+	ToStepID     string `json:"to_step_id,omitempty"`
+	ToStepName   string `json:"to_step_name,omitempty"`
+	MappedBy     string `json:"mapped_by,omitempty"`
+	Applied      bool   `json:"applied"`
+	Skipped      string `json:"skipped,omitempty"`
+}
+
+// RevisionMigrationPlan is the preview (dry run) or result of moving the
+// tasks pinned to FromRevision onto ToRevision.
+type RevisionMigrationPlan struct {
+	WorkflowID   string                  `json:"workflow_id"`
+	FromRevision int                     `json:"from_revision"`
+	ToRevision   int                     `json:"to_revision"`
+	DryRun       bool                    `json:"dry_run"`
+	Tasks        []RevisionTaskMigration `json:"tasks"`
+	Unmapped     int                     `json:"unmapped"`
+}
@@ -79,19 +79,25 @@ func ParseConfigureSessionRules(action OnEnterAction) ([]ConfigureSessionRule, e
 	return rules, nil
 }
 
-// ValidateWorkflowStep validates all on-enter invariants that must hold for
-// both API writes and workflow imports.
+// ValidateWorkflowStep validates all on-enter and transition-guard invariants
+// that must hold for both API writes and workflow imports.
 func ValidateWorkflowStep(step *WorkflowStep) error {
 	if step == nil {
 		return fmt.Errorf("workflow step is required")
 	}
-	return ValidateStepEvents(step.Events, step.AgentProfileID != "")
+	if err := ValidateStepEvents(step.Events, step.AgentProfileID != ""); err != nil {
+		return err
+	}
+	if err := ValidateTransitionGuards(step.Events); err != nil {
+		return err
+	}
+	return ValidateStepTimeout(step.Events)
 }
 
 // ValidateStepEvents validates on-enter action invariants for a step shape
 // that does not carry a full WorkflowStep, such as a portable export.
 func ValidateStepEvents(events StepEvents, hasAgentProfile bool) error {
-	configureCount := 0
+	configureCount, policyCount := 0, 0
 	for _, action := range events.OnEnter {
 		switch action.Type {
 		case OnEnterConfigureSession:
@@ -106,11 +112,23 @@ func ValidateStepEvents(events StepEvents, hasAgentProfile bool) error {
 			if mode, _ := action.Config["mode"].(string); strings.TrimSpace(mode) == "" {
 				return fmt.Errorf("set_session_mode requires a non-empty string \"mode\" config")
 			}
+		case OnEnterFanOut:
+			if err := ValidateFanOutConfig(action.Config); err != nil {
+				return err
+			}
+		case OnEnterSetPermissionPolicy:
+			policyCount++
+			if _, err := ParsePermissionPolicy(action.Config); err != nil {
+				return err
+			}
 		}
 	}
 	if configureCount > 1 {
 		return fmt.Errorf("workflow step may contain at most one configure_session action")
 	}
+	if policyCount > 1 {
+		return fmt.Errorf("workflow step may contain at most one set_permission_policy action")
+	}
 	return nil
 }
 
@@ -0,0 +1,17 @@
+package models
+
+import "fmt"
+
+// ValidateIterationLimit rejects loop bounds that could never apply: a
+// negative max_iterations and on_iteration_limit actions with no
+// max_iterations. A bound without actions is valid; the task then stays put
+// when the limit is reached.
+func ValidateIterationLimit(events StepEvents) error {
+	switch {
+	case events.MaxIterations < 0:
+		return fmt.Errorf("max_iterations must not be negative")
+	case events.MaxIterations == 0 && len(events.OnIterationLimit) > 0:
+		return fmt.Errorf("on_iteration_limit requires max_iterations")
+	}
+	return nil
+}
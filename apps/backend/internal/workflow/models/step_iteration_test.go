@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestValidateIterationLimit(t *testing.T) {
+	route := []GenericAction{{Type: GenericActionMoveToStep, Config: map[string]any{"step_id": "human"}}}
+	tests := []struct {
+		name    string
+		events  StepEvents
+		wantErr bool
+	}{
+		{"none", StepEvents{}, false},
+		{"bound with route", StepEvents{MaxIterations: 3, OnIterationLimit: route}, false},
+		{"bound without actions", StepEvents{MaxIterations: 3}, false},
+		{"negative", StepEvents{MaxIterations: -1}, true},
+		{"actions without bound", StepEvents{OnIterationLimit: route}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIterationLimit(tt.events)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateIterationLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemapStepEvents_OnIterationLimit(t *testing.T) {
+	events := StepEvents{
+		MaxIterations: 3,
+		OnIterationLimit: []GenericAction{
+			{Type: GenericActionMoveToStep, Config: map[string]any{"step_id": "old"}},
+		},
+	}
+	got := RemapStepEvents(events, map[string]string{"old": "new"})
+	if got.MaxIterations != 3 {
+		t.Fatalf("max_iterations not carried over: %d", got.MaxIterations)
+	}
+	if got.OnIterationLimit[0].Config["step_id"] != "new" {
+		t.Fatalf("on_iteration_limit step_id not remapped: %+v", got.OnIterationLimit)
+	}
+}
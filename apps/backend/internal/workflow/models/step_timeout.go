@@ -0,0 +1,20 @@
+package models
+
+import "fmt"
+
+// ValidateStepTimeout rejects step SLA configs that could never fire or
+// would fire into nothing: negative durations, a timeout with no
+// on_timeout actions, and on_timeout actions with no timeout.
+func ValidateStepTimeout(events StepEvents) error {
+	t := events.Timeout
+	if t != nil && (t.AfterMinutes < 0 || t.ActiveAfterMinutes < 0) {
+		return fmt.Errorf("timeout: minutes must not be negative")
+	}
+	switch {
+	case t.Enabled() && len(events.OnTimeout) == 0:
+		return fmt.Errorf("timeout requires at least one on_timeout action")
+	case !t.Enabled() && len(events.OnTimeout) > 0:
+		return fmt.Errorf("on_timeout requires timeout.after_minutes or timeout.active_after_minutes")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestValidateStepTimeout(t *testing.T) {
+	notify := []GenericAction{{Type: GenericActionNotify}}
+	tests := []struct {
+		name    string
+		events  StepEvents
+		wantErr bool
+	}{
+		{"none", StepEvents{}, false},
+		{"wall clock", StepEvents{Timeout: &StepTimeout{AfterMinutes: 60}, OnTimeout: notify}, false},
+		{"agent active", StepEvents{Timeout: &StepTimeout{ActiveAfterMinutes: 30}, OnTimeout: notify}, false},
+		{"negative", StepEvents{Timeout: &StepTimeout{AfterMinutes: -1}, OnTimeout: notify}, true},
+		{"no actions", StepEvents{Timeout: &StepTimeout{AfterMinutes: 60}}, true},
+		{"no timeout", StepEvents{OnTimeout: notify}, true},
+		{"zero timeout", StepEvents{Timeout: &StepTimeout{}, OnTimeout: notify}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStepTimeout(tt.events)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStepTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemapStepEvents_OnTimeout(t *testing.T) {
+	events := StepEvents{
+		Timeout: &StepTimeout{AfterMinutes: 120},
+		OnTimeout: []GenericAction{
+			{Type: GenericActionMoveToStep, Config: map[string]any{"step_id": "old"}},
+		},
+	}
+	got := RemapStepEvents(events, map[string]string{"old": "new"})
+	if got.Timeout == nil || got.Timeout.AfterMinutes != 120 {
+		t.Fatalf("timeout not carried over: %+v", got.Timeout)
+	}
+	if got.OnTimeout[0].Config["step_id"] != "new" {
+		t.Fatalf("on_timeout step_id not remapped: %+v", got.OnTimeout)
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/kandev/kandev/internal/workflow/guardexpr"
+)
+
+// ValidateTransitionGuards rejects malformed `if:` guard expressions on the
+// transition actions the engine guards (on_turn_complete and the generic
+// event-driven triggers). An expression that fails to compile would
+// otherwise fail closed at runtime and silently pin the task to its step.
+func ValidateTransitionGuards(events StepEvents) error {
+	for _, a := range events.OnTurnComplete {
+		if err := validateGuardConfig(a.Config); err != nil {
+			return fmt.Errorf("on_turn_complete %s: %w", a.Type, err)
+		}
+	}
+	for _, g := range genericTriggerLists(events) {
+		for _, a := range g.actions {
+			if err := validateGuardConfig(a.Config); err != nil {
+				return fmt.Errorf("%s %s: %w", g.name, a.Type, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateGuardConfig(config map[string]any) error {
+	raw, ok := config[TransitionGuardConfigKey]
+	if !ok {
+		return nil
+	}
+	expr, ok := raw.(string)
+	if !ok {
+		// The map form carries wait_for_quorum, validated by the engine's
+		// compile step.
+		return nil
+	}
+	if _, hasQuorum := config["wait_for_quorum"]; hasQuorum {
+		return fmt.Errorf("if expression cannot be combined with wait_for_quorum")
+	}
+	if _, err := guardexpr.Compile(expr); err != nil {
+		return fmt.Errorf("invalid if expression %q: %w", expr, err)
+	}
+	return nil
+}
+
+type namedGenericActions struct {
+	name    string
+	actions []GenericAction
+}
+
+func genericTriggerLists(events StepEvents) []namedGenericActions {
+	return []namedGenericActions{
+		{"on_comment", events.OnComment},
+		{"on_blocker_resolved", events.OnBlockerResolved},
+		{"on_children_completed", events.OnChildrenCompleted},
+		{"on_approval_resolved", events.OnApprovalResolved},
+		{"on_heartbeat", events.OnHeartbeat},
+		{"on_budget_alert", events.OnBudgetAlert},
+		{"on_agent_error", events.OnAgentError},
+		{"on_timeout", events.OnTimeout},
+	}
+}
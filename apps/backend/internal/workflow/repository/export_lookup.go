@@ -30,3 +30,16 @@ func (r *Repository) GetStepTx(ctx context.Context, tx *sqlx.Tx, id string) (*mo
 	}
 	return step, true, nil
 }
+
+// GetLatestRevisionTx returns the workflow's latest revision number, 0 when
+// none was cut, inside the automations YAML export's read transaction.
+func (r *Repository) GetLatestRevisionTx(ctx context.Context, tx *sqlx.Tx, workflowID string) (int, error) {
+	var revision sql.NullInt64
+	err := tx.QueryRowContext(ctx, tx.Rebind(`
+		SELECT MAX(revision) FROM workflow_revisions WHERE workflow_id = ?
+	`), workflowID).Scan(&revision)
+	if err != nil {
+		return 0, err
+	}
+	return int(revision.Int64), nil
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kandev/kandev/internal/db/dialect"
+	"github.com/kandev/kandev/internal/workflow/models"
+)
+
+// initRevisionSchema creates the workflow revision tables.
+//
+// workflow_revisions holds immutable step snapshots; rows are only ever
+// inserted. workflow_task_revisions pins each task to the revision of its
+// workflow it runs against. The pin table deliberately has no foreign key
+// to tasks: the task repository owns that table, and a pin left behind by
+// a deleted task is never read (every pin query joins tasks).
+func (r *Repository) initRevisionSchema() error {
+	revisionsSchema := `
+	CREATE TABLE IF NOT EXISTS workflow_revisions (
+		workflow_id TEXT NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+		revision INTEGER NOT NULL,
+		digest TEXT NOT NULL,
+		source TEXT NOT NULL DEFAULT 'edit',
+		source_revision INTEGER NOT NULL DEFAULT 0,
+		step_count INTEGER NOT NULL DEFAULT 0,
+		steps TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (workflow_id, revision)
+	);
+	`
+	if _, err := r.db.Exec(revisionsSchema); err != nil {
+		return fmt.Errorf("failed to create workflow_revisions table: %w", err)
+	}
+
+	pinsSchema := `
+	CREATE TABLE IF NOT EXISTS workflow_task_revisions (
+		task_id TEXT PRIMARY KEY,
+		workflow_id TEXT NOT NULL,
+		revision INTEGER NOT NULL,
+		pinned_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_workflow_task_revisions_revision
+		ON workflow_task_revisions(workflow_id, revision);
+	`
+	if _, err := r.db.Exec(pinsSchema); err != nil {
+		return fmt.Errorf("failed to create workflow_task_revisions table: %w", err)
+	}
+	return nil
+}
+
+const revisionSelectColumns = `workflow_id, revision, digest, source, source_revision, steps, created_at`
+
+func scanRevision(row interface{ Scan(dest ...any) error }) (*models.WorkflowRevision, error) {
+	rev := &models.WorkflowRevision{}
+	var stepsJSON string
+	if err := row.Scan(&rev.WorkflowID, &rev.Revision, &rev.Digest, &rev.Source, &rev.SourceRevision, &stepsJSON, &rev.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(stepsJSON), &rev.Steps); err != nil {
+		return nil, fmt.Errorf("decode steps of workflow %s revision %d: %w", rev.WorkflowID, rev.Revision, err)
+	}
+	return rev, nil
+}
+
+// CreateRevision inserts rev as the workflow's next revision and sets
+// rev.Revision to the number it was given.
+func (r *Repository) CreateRevision(ctx context.Context, rev *models.WorkflowRevision) error {
+	stepsJSON, err := json.Marshal(rev.Steps)
+	if err != nil {
+		return fmt.Errorf("marshal revision steps: %w", err)
+	}
+	if rev.CreatedAt.IsZero() {
+		rev.CreatedAt = time.Now().UTC()
+	}
+	if rev.Source == "" {
+		rev.Source = models.RevisionSourceEdit
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var latest int
+	if err := tx.QueryRowContext(ctx, tx.Rebind(`
+		SELECT COALESCE(MAX(revision), 0) FROM workflow_revisions WHERE workflow_id = ?
+	`), rev.WorkflowID).Scan(&latest); err != nil {
+		return fmt.Errorf("read latest revision: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`
+		INSERT INTO workflow_revisions (`+revisionSelectColumns+`, step_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`), rev.WorkflowID, latest+1, rev.Digest, rev.Source, rev.SourceRevision, string(stepsJSON), rev.CreatedAt, len(rev.Steps)); err != nil {
+		return fmt.Errorf("insert workflow revision: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	rev.Revision = latest + 1
+	return nil
+}
+
+// GetLatestRevision returns the workflow's newest revision, or nil when none
+// was cut yet.
+func (r *Repository) GetLatestRevision(ctx context.Context, workflowID string) (*models.WorkflowRevision, error) {
+	rev, err := scanRevision(r.ro.QueryRowContext(ctx, r.ro.Rebind(`
+		SELECT `+revisionSelectColumns+`
+		FROM workflow_revisions
+		WHERE workflow_id = ?
+		ORDER BY revision DESC
+		LIMIT 1
+	`), workflowID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rev, err
+}
+
+// GetRevision returns one revision of a workflow.
+func (r *Repository) GetRevision(ctx context.Context, workflowID string, revision int) (*models.WorkflowRevision, error) {
+	rev, err := scanRevision(r.ro.QueryRowContext(ctx, r.ro.Rebind(`
+		SELECT `+revisionSelectColumns+`
+		FROM workflow_revisions
+		WHERE workflow_id = ? AND revision = ?
+	`), workflowID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: workflow %s revision %d", models.ErrRevisionNotFound, workflowID, revision)
+	}
+	return rev, err
+}
+
+// ListRevisionSummaries returns every revision of a workflow, newest first,
+// with the number of live tasks pinned to each.
+func (r *Repository) ListRevisionSummaries(ctx context.Context, workflowID string) ([]*models.WorkflowRevisionSummary, error) {
+	counts, err := r.pinnedTaskCounts(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.ro.QueryContext(ctx, r.ro.Rebind(`
+		SELECT revision, source, source_revision, step_count, created_at
+		FROM workflow_revisions
+		WHERE workflow_id = ?
+		ORDER BY revision DESC
+	`), workflowID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []*models.WorkflowRevisionSummary
+	for rows.Next() {
+		s := &models.WorkflowRevisionSummary{}
+		if err := rows.Scan(&s.Revision, &s.Source, &s.SourceRevision, &s.StepCount, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.TaskCount = counts[s.Revision]
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repository) pinnedTaskCounts(ctx context.Context, workflowID string) (map[int]int, error) {
+	counts := map[int]int{}
+	if !r.tasksTableExists(ctx, r.ro) {
+		return counts, nil
+	}
+	rows, err := r.ro.QueryContext(ctx, r.ro.Rebind(`
+		SELECT p.revision, COUNT(*)
+		FROM workflow_task_revisions p
+		JOIN tasks t ON t.id = p.task_id AND t.workflow_id = p.workflow_id
+		WHERE p.workflow_id = ?
+		GROUP BY p.revision
+	`), workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("count pinned tasks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var revision, n int
+		if err := rows.Scan(&revision, &n); err != nil {
+			return nil, err
+		}
+		counts[revision] = n
+	}
+	return counts, rows.Err()
+}
+
+// PinTaskRevision pins a task to a workflow revision, replacing any earlier
+// pin (including one for a workflow the task has since left).
+func (r *Repository) PinTaskRevision(ctx context.Context, taskID, workflowID string, revision int) error {
+	_, err := r.db.ExecContext(ctx, r.db.Rebind(`
+		INSERT INTO workflow_task_revisions (task_id, workflow_id, revision, pinned_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (task_id) DO UPDATE SET
+			workflow_id = excluded.workflow_id,
+			revision = excluded.revision,
+			pinned_at = excluded.pinned_at
+	`), taskID, workflowID, revision, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("pin task %s to workflow revision: %w", taskID, err)
+	}
+	return nil
+}
+
+// GetTaskRevisionPin returns the revision a task is pinned to within
+// workflowID. found is false when the task has no pin for that workflow.
+func (r *Repository) GetTaskRevisionPin(ctx context.Context, taskID, workflowID string) (revision int, found bool, err error) {
+	err = r.ro.QueryRowContext(ctx, r.ro.Rebind(`
+		SELECT revision FROM workflow_task_revisions WHERE task_id = ? AND workflow_id = ?
+	`), taskID, workflowID).Scan(&revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read task revision pin: %w", err)
+	}
+	return revision, true, nil
+}
+
+// GetTaskRevision returns the revision a task is pinned to, steps included,
+// or nil when the task has no pin.
+func (r *Repository) GetTaskRevision(ctx context.Context, taskID string) (*models.WorkflowRevision, error) {
+	rev, err := scanRevision(r.ro.QueryRowContext(ctx, r.ro.Rebind(`
+		SELECT r.workflow_id, r.revision, r.digest, r.source, r.source_revision, r.steps, r.created_at
+		FROM workflow_task_revisions p
+		JOIN workflow_revisions r ON r.workflow_id = p.workflow_id AND r.revision = p.revision
+		WHERE p.task_id = ?
+	`), taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rev, err
+}
+
+// PinUnpinnedTasks pins every task of the workflow that has no pin for it
+// yet — tasks created before the workflow had revisions, or moved in from
+// another workflow — to revision. Returns how many tasks were pinned.
+func (r *Repository) PinUnpinnedTasks(ctx context.Context, workflowID string, revision int) (int64, error) {
+	if !r.tasksTableExists(ctx, r.db) {
+		return 0, nil
+	}
+	result, err := r.db.ExecContext(ctx, r.db.Rebind(`
+		INSERT INTO workflow_task_revisions (task_id, workflow_id, revision, pinned_at)
+		SELECT t.id, t.workflow_id, ?, ?
+		FROM tasks t
+		WHERE t.workflow_id = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM workflow_task_revisions p
+				WHERE p.task_id = t.id AND p.workflow_id = t.workflow_id
+			)
+		ON CONFLICT (task_id) DO UPDATE SET
+			workflow_id = excluded.workflow_id,
+			revision = excluded.revision,
+			pinned_at = excluded.pinned_at
+	`), revision, time.Now().UTC(), workflowID)
+	if err != nil {
+		return 0, fmt.Errorf("pin unpinned tasks: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n, nil
+}
+
+// ListTaskRevisionPins returns the live tasks of the workflow pinned to
+// revision, with the step each one sits on.
+func (r *Repository) ListTaskRevisionPins(ctx context.Context, workflowID string, revision int) ([]models.TaskRevisionPin, error) {
+	if !r.tasksTableExists(ctx, r.ro) {
+		return nil, nil
+	}
+	rows, err := r.ro.QueryContext(ctx, r.ro.Rebind(`
+		SELECT t.id, COALESCE(t.title, ''), COALESCE(t.workflow_step_id, ''), p.revision
+		FROM workflow_task_revisions p
+		JOIN tasks t ON t.id = p.task_id AND t.workflow_id = p.workflow_id
+		WHERE p.workflow_id = ? AND p.revision = ?
+		ORDER BY t.id
+	`), workflowID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("list pinned tasks: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []models.TaskRevisionPin
+	for rows.Next() {
+		var p models.TaskRevisionPin
+		if err := rows.Scan(&p.TaskID, &p.Title, &p.WorkflowStepID, &p.Revision); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// tasksTableExists reports whether the task repository's tasks table is
+// present. The workflow repository can be opened on its own (tests, early
+// startup), so queries touching tasks check first.
+func (r *Repository) tasksTableExists(ctx context.Context, q queryRower) bool {
+	var exists bool
+	if dialect.IsPostgres(r.db.DriverName()) {
+		_ = q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'tasks')`).Scan(&exists)
+	} else {
+		_ = q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'tasks')`).Scan(&exists)
+	}
+	return exists
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kandev/kandev/internal/workflow/models"
+)
+
+func setupRevisionTestRepo(t *testing.T) (*Repository, *sqlx.DB) {
+	t.Helper()
+	repo, db := setupTestRepoWithDB(t)
+	if _, err := db.Exec(`CREATE TABLE tasks (
+		id TEXT PRIMARY KEY, workflow_id TEXT, workflow_step_id TEXT,
+		title TEXT, updated_at TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("create tasks table: %v", err)
+	}
+	return repo, db
+}
+
+func insertRevisionTestTask(t *testing.T, db *sqlx.DB, id, stepID string) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO tasks (id, workflow_id, workflow_step_id, title) VALUES (?, 'wf-test', ?, ?)`, id, stepID, "Task "+id); err != nil {
+		t.Fatalf("insert task %s: %v", id, err)
+	}
+}
+
+func createTestRevision(t *testing.T, repo *Repository, steps ...*models.WorkflowStep) *models.WorkflowRevision {
+	t.Helper()
+	rev := &models.WorkflowRevision{
+		WorkflowID: "wf-test",
+		Digest:     models.RevisionStepsDigest(steps),
+		Steps:      steps,
+	}
+	if err := repo.CreateRevision(context.Background(), rev); err != nil {
+		t.Fatalf("CreateRevision: %v", err)
+	}
+	return rev
+}
+
+func TestCreateRevision_NumbersSequentiallyAndRoundTripsSteps(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	latest, err := repo.GetLatestRevision(ctx, "wf-test")
+	if err != nil || latest != nil {
+		t.Fatalf("GetLatestRevision before any revision = %+v, %v; want nil, nil", latest, err)
+	}
+
+	first := createTestRevision(t, repo, &models.WorkflowStep{ID: "s1", WorkflowID: "wf-test", Name: "Todo"})
+	second := createTestRevision(t, repo,
+		&models.WorkflowStep{ID: "s1", WorkflowID: "wf-test", Name: "Todo"},
+		&models.WorkflowStep{ID: "s2", WorkflowID: "wf-test", Name: "Done", Position: 1})
+	if first.Revision != 1 || second.Revision != 2 {
+		t.Fatalf("revisions = %d, %d; want 1, 2", first.Revision, second.Revision)
+	}
+	if first.Source != models.RevisionSourceEdit {
+		t.Errorf("source = %q, want default %q", first.Source, models.RevisionSourceEdit)
+	}
+
+	latest, err = repo.GetLatestRevision(ctx, "wf-test")
+	if err != nil {
+		t.Fatalf("GetLatestRevision: %v", err)
+	}
+	if latest.Revision != 2 || len(latest.Steps) != 2 || latest.Digest != second.Digest {
+		t.Errorf("latest = %+v, want revision 2 with 2 steps", latest)
+	}
+	if st, ok := latest.Step("s2"); !ok || st.Name != "Done" {
+		t.Errorf("latest.Step(s2) = %+v, %v", st, ok)
+	}
+
+	if _, err := repo.GetRevision(ctx, "wf-test", 7); !errors.Is(err, models.ErrRevisionNotFound) {
+		t.Errorf("GetRevision(7) error = %v, want ErrRevisionNotFound", err)
+	}
+	summaries, err := repo.ListRevisionSummaries(ctx, "wf-test")
+	if err != nil {
+		t.Fatalf("ListRevisionSummaries: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Revision != 2 || summaries[0].StepCount != 2 || summaries[1].StepCount != 1 {
+		t.Errorf("summaries = %+v, want revisions 2 then 1", summaries)
+	}
+}
+
+func TestPinUnpinnedTasks_LeavesExistingPinsAlone(t *testing.T) {
+	repo, db := setupRevisionTestRepo(t)
+	ctx := context.Background()
+	insertRevisionTestTask(t, db, "t1", "s1")
+	insertRevisionTestTask(t, db, "t2", "s1")
+
+	if err := repo.PinTaskRevision(ctx, "t1", "wf-test", 2); err != nil {
+		t.Fatalf("PinTaskRevision: %v", err)
+	}
+	n, err := repo.PinUnpinnedTasks(ctx, "wf-test", 1)
+	if err != nil {
+		t.Fatalf("PinUnpinnedTasks: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("pinned %d tasks, want 1", n)
+	}
+	for id, want := range map[string]int{"t1": 2, "t2": 1} {
+		got, found, err := repo.GetTaskRevisionPin(ctx, id, "wf-test")
+		if err != nil || !found || got != want {
+			t.Errorf("pin of %s = %d, %v, %v; want %d", id, got, found, err, want)
+		}
+	}
+	if _, found, _ := repo.GetTaskRevisionPin(ctx, "t1", "wf-other"); found {
+		t.Error("expected no pin for another workflow")
+	}
+}
+
+func TestGetTaskRevision_ReturnsPinnedSnapshot(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+	createTestRevision(t, repo, &models.WorkflowStep{ID: "s1", WorkflowID: "wf-test", Name: "Todo"})
+	createTestRevision(t, repo, &models.WorkflowStep{ID: "s1", WorkflowID: "wf-test", Name: "Renamed"})
+
+	if rev, err := repo.GetTaskRevision(ctx, "t1"); err != nil || rev != nil {
+		t.Fatalf("GetTaskRevision of an unpinned task = %+v, %v; want nil, nil", rev, err)
+	}
+	if err := repo.PinTaskRevision(ctx, "t1", "wf-test", 1); err != nil {
+		t.Fatalf("PinTaskRevision: %v", err)
+	}
+	rev, err := repo.GetTaskRevision(ctx, "t1")
+	if err != nil {
+		t.Fatalf("GetTaskRevision: %v", err)
+	}
+	if st, ok := rev.Step("s1"); rev.Revision != 1 || !ok || st.Name != "Todo" {
+		t.Errorf("GetTaskRevision = revision %d with %+v, want revision 1 with Todo", rev.Revision, st)
+	}
+}
@@ -138,6 +138,10 @@ func (r *Repository) initSchema() error {
 		return err
 	}
 
+	if err := r.initRevisionSchema(); err != nil {
+		return err
+	}
+
 	// Seed system templates
 	if err := r.seedSystemTemplates(); err != nil {
 		return fmt.Errorf("failed to seed system templates: %w", err)
@@ -887,13 +891,7 @@ func (r *Repository) DeleteStep(ctx context.Context, id string) error {
 		cleanupQuery += `json_remove(CASE WHEN metadata IS NULL OR metadata = 'null' OR metadata = '' THEN '{}' ELSE metadata END, '$.deferred_launch')`
 	}
 	cleanupQuery += `, updated_at = ? WHERE queued_for_step_id = ?`
-	var taskTableExists bool
-	if dialect.IsPostgres(r.db.DriverName()) {
-		_ = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'tasks')`).Scan(&taskTableExists)
-	} else {
-		_ = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'tasks')`).Scan(&taskTableExists)
-	}
-	if taskTableExists {
+	if r.tasksTableExists(ctx, tx) {
 		if _, err := tx.ExecContext(ctx, r.db.Rebind(cleanupQuery), time.Now().UTC(), id); err != nil {
 			return err
 		}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/kandev/kandev/internal/workflow/models"
+)
+
+// ============================================================================
+// Revision Operations
+// ============================================================================
+
+// RevisionMigrationApplier commits a planned revision migration: it re-pins
+// the tasks and moves each onto its mapped step, writing the step-transition
+// ledger. The task repository implements it, since it owns task moves.
+type RevisionMigrationApplier interface {
+	ApplyWorkflowRevisionMigration(ctx context.Context, workflowID string, fromRevision, toRevision int, moves []models.RevisionTaskMove) ([]bool, error)
+}
+
+// SetRevisionMigrationApplier wires the applier MigrateTaskRevisions commits
+// through. Without one only dry runs succeed.
+func (s *Service) SetRevisionMigrationApplier(applier RevisionMigrationApplier) {
+	s.revisionMigrator = applier
+}
+
+// EnsureCurrentRevision returns the revision matching the workflow's live
+// steps, cutting a new one when the steps changed since the latest. Returns
+// nil for a workflow without steps.
+func (s *Service) EnsureCurrentRevision(ctx context.Context, workflowID string) (*models.WorkflowRevision, error) {
+	s.revisionMu.Lock()
+	defer s.revisionMu.Unlock()
+	return s.ensureRevision(ctx, workflowID, models.RevisionSourceEdit, 0)
+}
+
+// ensureRevision is EnsureCurrentRevision with an explicit source; the
+// caller holds revisionMu. Tasks of the workflow without a pin are pinned to
+// the revision that was current before this call (or to the new one when
+// the workflow had none), since that is the definition they ran against.
+func (s *Service) ensureRevision(ctx context.Context, workflowID, source string, sourceRevision int) (*models.WorkflowRevision, error) {
+	steps, err := s.repo.ListStepsByWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("list steps: %w", err)
+	}
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	latest, err := s.repo.GetLatestRevision(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("load latest revision: %w", err)
+	}
+	digest := models.RevisionStepsDigest(steps)
+	current := latest
+	if latest == nil || latest.Digest != digest {
+		current = &models.WorkflowRevision{
+			WorkflowID:     workflowID,
+			Digest:         digest,
+			Source:         source,
+			SourceRevision: sourceRevision,
+			Steps:          steps,
+		}
+		if err := s.repo.CreateRevision(ctx, current); err != nil {
+			return nil, err
+		}
+		s.logger.Info("cut workflow revision",
+			zap.String("workflow_id", workflowID),
+			zap.Int("revision", current.Revision),
+			zap.String("source", source))
+	}
+	backfill := current
+	if latest != nil {
+		backfill = latest
+	}
+	if _, err := s.repo.PinUnpinnedTasks(ctx, workflowID, backfill.Revision); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// recordRevision cuts a revision after an import or sync wrote the
+// workflow's steps. Failures are logged: the definition is already saved
+// and the next pin cuts the revision anyway.
+func (s *Service) recordRevision(ctx context.Context, workflowID, source string, sourceRevision int) {
+	s.revisionMu.Lock()
+	defer s.revisionMu.Unlock()
+	if _, err := s.ensureRevision(ctx, workflowID, source, sourceRevision); err != nil {
+		s.logger.Warn("failed to record workflow revision",
+			zap.String("workflow_id", workflowID),
+			zap.String("source", source),
+			zap.Error(err))
+	}
+}
+
+// PinTaskRevision pins a task to the workflow's current revision. Called when
+// a task enters a workflow.
+func (s *Service) PinTaskRevision(ctx context.Context, taskID, workflowID string) error {
+	if taskID == "" || workflowID == "" {
+		return nil
+	}
+	s.revisionMu.Lock()
+	defer s.revisionMu.Unlock()
+	rev, err := s.ensureRevision(ctx, workflowID, models.RevisionSourceEdit, 0)
+	if err != nil || rev == nil {
+		return err
+	}
+	return s.repo.PinTaskRevision(ctx, taskID, workflowID, rev.Revision)
+}
+
+// taskRevision returns the revision taskID is pinned to, or nil when it has
+// none or the pin belongs to a workflow other than workflowID (the task
+// moved and was not re-pinned yet). An empty workflowID accepts any pin.
+func (s *Service) taskRevision(ctx context.Context, taskID, workflowID string) (*models.WorkflowRevision, error) {
+	if taskID == "" {
+		return nil, nil
+	}
+	rev, err := s.repo.GetTaskRevision(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("load revision of task %s: %w", taskID, err)
+	}
+	if rev == nil || (workflowID != "" && rev.WorkflowID != workflowID) {
+		return nil, nil
+	}
+	return rev, nil
+}
+
+// GetTaskStep returns stepID as taskID runs it: from the snapshot of the
+// task's pinned revision, so edits to the live workflow do not reach a task
+// in flight until it is migrated. Steps the revision does not know (or
+// unpinned tasks) resolve against the live workflow like GetStep.
+func (s *Service) GetTaskStep(ctx context.Context, taskID, stepID string) (*models.WorkflowStep, error) {
+	rev, err := s.taskRevision(ctx, taskID, "")
+	if err != nil {
+		return nil, err
+	}
+	if rev != nil {
+		if step, ok := rev.Step(stepID); ok {
+			return step, nil
+		}
+	}
+	return s.GetStep(ctx, stepID)
+}
+
+// GetTaskNextStepByPosition is GetNextStepByPosition over the steps of
+// taskID's pinned revision.
+func (s *Service) GetTaskNextStepByPosition(ctx context.Context, taskID, workflowID string, currentPosition int) (*models.WorkflowStep, error) {
+	rev, err := s.taskRevision(ctx, taskID, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return s.GetNextStepByPosition(ctx, workflowID, currentPosition)
+	}
+	return nextStepByPosition(rev.Steps, currentPosition), nil
+}
+
+// GetTaskPreviousStepByPosition is GetPreviousStepByPosition over the steps
+// of taskID's pinned revision.
+func (s *Service) GetTaskPreviousStepByPosition(ctx context.Context, taskID, workflowID string, currentPosition int) (*models.WorkflowStep, error) {
+	rev, err := s.taskRevision(ctx, taskID, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return s.GetPreviousStepByPosition(ctx, workflowID, currentPosition)
+	}
+	return previousStepByPosition(rev.Steps, currentPosition), nil
+}
+
+// ListRevisions returns a workflow's revisions, newest first.
+func (s *Service) ListRevisions(ctx context.Context, workflowID string) ([]*models.WorkflowRevisionSummary, error) {
+	return s.repo.ListRevisionSummaries(ctx, workflowID)
+}
+
+// GetRevision returns one revision of a workflow, steps included.
+func (s *Service) GetRevision(ctx context.Context, workflowID string, revision int) (*models.WorkflowRevision, error) {
+	return s.repo.GetRevision(ctx, workflowID, revision)
+}
+
+// MigrateRevisionRequest asks to move the tasks pinned to FromRevision onto
+// ToRevision (0 means the current revision, which is the only allowed
+// target: its steps are the live ones tasks can be moved onto).
+//
+// StepMapping maps a step ID of FromRevision to a step ID of ToRevision.
+// Steps without an entry keep their ID when the target still has it, else
+// map to the target step with the same name.
+type MigrateRevisionRequest struct {
+	WorkflowID   string
+	FromRevision int
+	ToRevision   int
+	StepMapping  map[string]string
+	DryRun       bool
+}
+
+// MigrateTaskRevisions plans, and unless DryRun applies, a revision
+// migration. A non-dry run refuses (ErrUnmappedRevisionSteps) while any task
+// lacks a target step, and then migrates nothing.
+func (s *Service) MigrateTaskRevisions(ctx context.Context, req MigrateRevisionRequest) (*models.RevisionMigrationPlan, error) {
+	s.revisionMu.Lock()
+	defer s.revisionMu.Unlock()
+
+	target, err := s.ensureRevision(ctx, req.WorkflowID, models.RevisionSourceEdit, 0)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidRevisionMigration, models.ErrNoRevisionSteps)
+	}
+	if req.ToRevision != 0 && req.ToRevision != target.Revision {
+		return nil, fmt.Errorf("%w: to_revision must be the current revision %d", models.ErrInvalidRevisionMigration, target.Revision)
+	}
+	if req.FromRevision <= 0 || req.FromRevision >= target.Revision {
+		return nil, fmt.Errorf("%w: from_revision must be between 1 and %d", models.ErrInvalidRevisionMigration, target.Revision-1)
+	}
+	source, err := s.repo.GetRevision(ctx, req.WorkflowID, req.FromRevision)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRevisionStepMapping(req.StepMapping, source, target); err != nil {
+		return nil, err
+	}
+	pins, err := s.repo.ListTaskRevisionPins(ctx, req.WorkflowID, source.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := planRevisionMigration(source, target, pins, req.StepMapping)
+	plan.DryRun = req.DryRun
+	if req.DryRun {
+		return plan, nil
+	}
+	if plan.Unmapped > 0 {
+		return plan, fmt.Errorf("%w: %d task(s)", models.ErrUnmappedRevisionSteps, plan.Unmapped)
+	}
+	if s.revisionMigrator == nil {
+		return nil, fmt.Errorf("workflow revision migration is not wired")
+	}
+	moves := make([]models.RevisionTaskMove, len(plan.Tasks))
+	for i, t := range plan.Tasks {
+		moves[i] = models.RevisionTaskMove{TaskID: t.TaskID, FromStepID: t.FromStepID, ToStepID: t.ToStepID}
+	}
+	applied, err := s.revisionMigrator.ApplyWorkflowRevisionMigration(ctx, req.WorkflowID, source.Revision, target.Revision, moves)
+	if err != nil {
+		return nil, err
+	}
+	migrated := 0
+	for i := range plan.Tasks {
+		if applied[i] {
+			plan.Tasks[i].Applied = true
+			migrated++
+		} else {
+			plan.Tasks[i].Skipped = "task changed step or revision during the migration"
+		}
+	}
+	s.logger.Info("migrated workflow tasks between revisions",
+		zap.String("workflow_id", req.WorkflowID),
+		zap.Int("from_revision", source.Revision),
+		zap.Int("to_revision", target.Revision),
+		zap.Int("migrated", migrated),
+		zap.Int("skipped", len(plan.Tasks)-migrated))
+	return plan, nil
+}
+
+func validateRevisionStepMapping(mapping map[string]string, source, target *models.WorkflowRevision) error {
+	for from, to := range mapping {
+		if _, ok := source.Step(from); !ok {
+			return fmt.Errorf("%w: step %q is not in revision %d", models.ErrInvalidRevisionMigration, from, source.Revision)
+		}
+		if _, ok := target.Step(to); !ok {
+			return fmt.Errorf("%w: step %q is not in revision %d", models.ErrInvalidRevisionMigration, to, target.Revision)
+		}
+	}
+	return nil
+}
+
+// planRevisionMigration resolves each pinned task's target step: an explicit
+// mapping first, then the same step ID, then a uniquely named step.
+func planRevisionMigration(source, target *models.WorkflowRevision, pins []models.TaskRevisionPin, mapping map[string]string) *models.RevisionMigrationPlan {
+	byName := make(map[string]*models.WorkflowStep, len(target.Steps))
+	ambiguous := make(map[string]bool)
+	for _, st := range target.Steps {
+		key := revisionStepNameKey(st.Name)
+		if _, dup := byName[key]; dup {
+			ambiguous[key] = true
+		}
+		byName[key] = st
+	}
+
+	plan := &models.RevisionMigrationPlan{
+		WorkflowID:   source.WorkflowID,
+		FromRevision: source.Revision,
+		ToRevision:   target.Revision,
+		Tasks:        make([]models.RevisionTaskMigration, 0, len(pins)),
+	}
+	for _, pin := range pins {
+		line := models.RevisionTaskMigration{TaskID: pin.TaskID, Title: pin.Title, FromStepID: pin.WorkflowStepID}
+		fromStep, inSource := source.Step(pin.WorkflowStepID)
+		if inSource {
+			line.FromStepName = fromStep.Name
+		}
+		var to *models.WorkflowStep
+		switch {
+		case pin.WorkflowStepID == "":
+			line.MappedBy = models.RevisionMappedByID
+		case mapping[pin.WorkflowStepID] != "":
+			to, _ = target.Step(mapping[pin.WorkflowStepID])
+			line.MappedBy = models.RevisionMappedByExplicit
+		default:
+			if st, ok := target.Step(pin.WorkflowStepID); ok {
+				to = st
+				line.MappedBy = models.RevisionMappedByID
+			} else if key := revisionStepNameKey(line.FromStepName); inSource && !ambiguous[key] && byName[key] != nil {
+				to = byName[key]
+				line.MappedBy = models.RevisionMappedByName
+			}
+		}
+		if to != nil {
+			line.ToStepID = to.ID
+			line.ToStepName = to.Name
+		}
+		if line.MappedBy == "" {
+			plan.Unmapped++
+		}
+		plan.Tasks = append(plan.Tasks, line)
+	}
+	return plan
+}
+
+func revisionStepNameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kandev/kandev/internal/workflow/models"
+)
+
+func setupRevisionTestService(t *testing.T) (*Service, *sqlx.DB) {
+	t.Helper()
+	svc, db := setupTestService(t)
+	insertWorkflow(t, db, "wf-1", "Pipeline")
+	_, err := db.Exec(`CREATE TABLE tasks (
+		id TEXT PRIMARY KEY, workflow_id TEXT, workflow_step_id TEXT, title TEXT,
+		queued_for_step_id TEXT, queued_at TIMESTAMP, wip_admitted BOOLEAN,
+		metadata TEXT, updated_at TIMESTAMP
+	)`)
+	require.NoError(t, err)
+	svc.SetRevisionMigrationApplier(&sqlRevisionMigrator{db: db})
+	return svc, db
+}
+
+// sqlRevisionMigrator stands in for the task repository's
+// ApplyWorkflowRevisionMigration against the minimal tasks table above.
+type sqlRevisionMigrator struct {
+	db *sqlx.DB
+}
+
+func (m *sqlRevisionMigrator) ApplyWorkflowRevisionMigration(
+	ctx context.Context, workflowID string, fromRevision, toRevision int, moves []models.RevisionTaskMove,
+) ([]bool, error) {
+	applied := make([]bool, len(moves))
+	for i, mv := range moves {
+		res, err := m.db.ExecContext(ctx, `
+			UPDATE workflow_task_revisions SET revision = ?
+			WHERE task_id = ? AND workflow_id = ? AND revision = ?
+			AND EXISTS (SELECT 1 FROM tasks WHERE id = ? AND COALESCE(workflow_step_id, '') = ?)
+		`, toRevision, mv.TaskID, workflowID, fromRevision, mv.TaskID, mv.FromStepID)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		if _, err := m.db.ExecContext(ctx, `UPDATE tasks SET workflow_step_id = ? WHERE id = ?`, mv.ToStepID, mv.TaskID); err != nil {
+			return nil, err
+		}
+		applied[i] = true
+	}
+	return applied, nil
+}
+
+func insertRevisionTask(t *testing.T, db *sqlx.DB, id, stepID string) {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO tasks (id, workflow_id, workflow_step_id, title) VALUES (?, 'wf-1', ?, ?)`, id, stepID, "Task "+id)
+	require.NoError(t, err)
+}
+
+func taskStep(t *testing.T, db *sqlx.DB, id string) string {
+	t.Helper()
+	var stepID string
+	require.NoError(t, db.QueryRow(`SELECT workflow_step_id FROM tasks WHERE id = ?`, id).Scan(&stepID))
+	return stepID
+}
+
+func TestEnsureCurrentRevision_CutsOnlyWhenStepsChange(t *testing.T) {
+	svc, _ := setupRevisionTestService(t)
+	ctx := context.Background()
+
+	rev, err := svc.EnsureCurrentRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Nil(t, rev, "a workflow without steps has nothing to snapshot")
+
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "Todo", Position: 0})
+	first, err := svc.EnsureCurrentRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, 1, first.Revision)
+
+	again, err := svc.EnsureCurrentRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, again.Revision, "an unchanged definition must not cut a revision")
+
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "Done", Position: 1})
+	second, err := svc.EnsureCurrentRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.Revision)
+	assert.Len(t, second.Steps, 2)
+}
+
+func TestPinTaskRevision_BackfillsExistingTasksToPreviousRevision(t *testing.T) {
+	svc, db := setupRevisionTestService(t)
+	ctx := context.Background()
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "Todo", Position: 0})
+	steps, err := svc.ListStepsByWorkflow(ctx, "wf-1")
+	require.NoError(t, err)
+	insertRevisionTask(t, db, "old", steps[0].ID)
+
+	require.NoError(t, svc.PinTaskRevision(ctx, "old", "wf-1"))
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "Done", Position: 1})
+	insertRevisionTask(t, db, "new", steps[0].ID)
+	require.NoError(t, svc.PinTaskRevision(ctx, "new", "wf-1"))
+
+	oldRev, _, err := svc.repo.GetTaskRevisionPin(ctx, "old", "wf-1")
+	require.NoError(t, err)
+	newRev, _, err := svc.repo.GetTaskRevisionPin(ctx, "new", "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, oldRev)
+	assert.Equal(t, 2, newRev)
+
+	summaries, err := svc.ListRevisions(ctx, "wf-1")
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, 1, summaries[0].TaskCount)
+	assert.Equal(t, 1, summaries[1].TaskCount)
+}
+
+// setupRevisionMigration builds revision 1 (Todo, Review, QA) with one task
+// on each step, then edits the live steps into revision 2: Todo is kept,
+// Review is deleted and re-created under the same name, and QA is deleted.
+func setupRevisionMigration(t *testing.T) (*Service, *sqlx.DB, map[string]*models.WorkflowStep) {
+	t.Helper()
+	svc, db := setupRevisionTestService(t)
+	ctx := context.Background()
+	for i, name := range []string{"Todo", "Review", "QA"} {
+		createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: name, Position: i})
+	}
+	steps, err := svc.ListStepsByWorkflow(ctx, "wf-1")
+	require.NoError(t, err)
+	old := map[string]*models.WorkflowStep{}
+	for _, st := range steps {
+		old[st.Name] = st
+		insertRevisionTask(t, db, "task-"+st.Name, st.ID)
+	}
+	rev, err := svc.EnsureCurrentRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	require.Equal(t, 1, rev.Revision)
+
+	require.NoError(t, svc.DeleteStep(ctx, old["Review"].ID))
+	require.NoError(t, svc.DeleteStep(ctx, old["QA"].ID))
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "review", Position: 1})
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "Ship", Position: 2})
+	return svc, db, old
+}
+
+func TestMigrateTaskRevisions_DryRunPlansEachMappingKind(t *testing.T) {
+	svc, db, old := setupRevisionMigration(t)
+	ctx := context.Background()
+
+	plan, err := svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{WorkflowID: "wf-1", FromRevision: 1, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, plan.DryRun)
+	assert.Equal(t, 2, plan.ToRevision)
+	assert.Equal(t, 1, plan.Unmapped)
+
+	byTask := map[string]models.RevisionTaskMigration{}
+	for _, line := range plan.Tasks {
+		byTask[line.TaskID] = line
+		assert.False(t, line.Applied)
+	}
+	assert.Equal(t, models.RevisionMappedByID, byTask["task-Todo"].MappedBy)
+	assert.Equal(t, old["Todo"].ID, byTask["task-Todo"].ToStepID)
+	assert.Equal(t, models.RevisionMappedByName, byTask["task-Review"].MappedBy)
+	assert.Equal(t, "review", byTask["task-Review"].ToStepName)
+	assert.Empty(t, byTask["task-QA"].MappedBy)
+	assert.Empty(t, byTask["task-QA"].ToStepID)
+	assert.Equal(t, old["QA"].ID, taskStep(t, db, "task-QA"), "a dry run must not move tasks")
+}
+
+func TestMigrateTaskRevisions_RefusesUnmappedThenApplies(t *testing.T) {
+	svc, db, old := setupRevisionMigration(t)
+	ctx := context.Background()
+
+	plan, err := svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{WorkflowID: "wf-1", FromRevision: 1})
+	require.ErrorIs(t, err, models.ErrUnmappedRevisionSteps)
+	require.NotNil(t, plan)
+	assert.Equal(t, old["Todo"].ID, taskStep(t, db, "task-Todo"))
+	revision, _, err := svc.repo.GetTaskRevisionPin(ctx, "task-Todo", "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, revision, "a refused migration must not re-pin any task")
+
+	target, err := svc.GetRevision(ctx, "wf-1", 2)
+	require.NoError(t, err)
+	var shipID string
+	for _, st := range target.Steps {
+		if st.Name == "Ship" {
+			shipID = st.ID
+		}
+	}
+	_, err = svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{
+		WorkflowID: "wf-1", FromRevision: 1, StepMapping: map[string]string{old["QA"].ID: "missing"},
+	})
+	require.ErrorIs(t, err, models.ErrInvalidRevisionMigration)
+
+	plan, err = svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{
+		WorkflowID: "wf-1", FromRevision: 1, StepMapping: map[string]string{old["QA"].ID: shipID},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, plan.Unmapped)
+	for _, line := range plan.Tasks {
+		assert.True(t, line.Applied, "task %s", line.TaskID)
+	}
+	assert.Equal(t, shipID, taskStep(t, db, "task-QA"))
+	assert.Equal(t, old["Todo"].ID, taskStep(t, db, "task-Todo"))
+
+	pins, err := svc.repo.ListTaskRevisionPins(ctx, "wf-1", 2)
+	require.NoError(t, err)
+	assert.Len(t, pins, 3)
+}
+
+func TestMigrateTaskRevisions_RejectsNonCurrentTarget(t *testing.T) {
+	svc, _, _ := setupRevisionMigration(t)
+	ctx := context.Background()
+
+	_, err := svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{WorkflowID: "wf-1", FromRevision: 1, ToRevision: 5, DryRun: true})
+	require.ErrorIs(t, err, models.ErrInvalidRevisionMigration)
+	_, err = svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{WorkflowID: "wf-1", FromRevision: 2, DryRun: true})
+	require.ErrorIs(t, err, models.ErrInvalidRevisionMigration)
+}
+
+func TestExportWorkflow_ReportsLatestRevisionWithoutCuttingOne(t *testing.T) {
+	svc, _, mock := setupTestServiceWithProvider(t)
+	ctx := context.Background()
+	mock.addWorkflow("wf-1", "", "Pipeline")
+	step := &models.WorkflowStep{WorkflowID: "wf-1", Name: "Todo", Position: 0}
+	createStep(t, svc, step)
+
+	export, err := svc.ExportWorkflow(ctx, "wf-1")
+	require.NoError(t, err)
+	require.Len(t, export.Workflows, 1)
+	assert.Zero(t, export.Workflows[0].Revision, "a workflow without revisions is exported without one")
+	latest, err := svc.repo.GetLatestRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Nil(t, latest, "exporting must not cut a revision")
+
+	_, err = svc.EnsureCurrentRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	export, err = svc.ExportWorkflow(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, export.Workflows[0].Revision)
+
+	step.Name = "Backlog"
+	require.NoError(t, svc.UpdateStep(ctx, step))
+	export, err = svc.ExportWorkflows(ctx, "", []string{"wf-1"})
+	require.NoError(t, err)
+	require.Len(t, export.Workflows, 1)
+	assert.Zero(t, export.Workflows[0].Revision, "edited steps are no recorded revision")
+	latest, err = svc.repo.GetLatestRevision(ctx, "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, latest.Revision, "exporting must not cut a revision")
+}
+
+func TestTaskStepLookups_ServePinnedRevisionUntilMigrated(t *testing.T) {
+	svc, db := setupRevisionTestService(t)
+	ctx := context.Background()
+	for i, name := range []string{"Todo", "Review", "Done"} {
+		createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: name, Position: i * 2})
+	}
+	steps, err := svc.ListStepsByWorkflow(ctx, "wf-1")
+	require.NoError(t, err)
+	todo, review, done := steps[0], steps[1], steps[2]
+	todo.Events.OnTurnComplete = []models.OnTurnCompleteAction{{Type: models.OnTurnCompleteMoveToNext}}
+	require.NoError(t, svc.UpdateStep(ctx, todo))
+	insertRevisionTask(t, db, "inflight", todo.ID)
+	require.NoError(t, svc.PinTaskRevision(ctx, "inflight", "wf-1"))
+
+	// Edit the live workflow: Todo now skips to Done, and QA is inserted
+	// right after Todo.
+	todo.Events.OnTurnComplete = []models.OnTurnCompleteAction{
+		{Type: models.OnTurnCompleteMoveToStep, Config: map[string]any{"step_id": done.ID}},
+	}
+	require.NoError(t, svc.UpdateStep(ctx, todo))
+	createStep(t, svc, &models.WorkflowStep{WorkflowID: "wf-1", Name: "QA", Position: 1})
+
+	pinned, err := svc.GetTaskStep(ctx, "inflight", todo.ID)
+	require.NoError(t, err)
+	require.Len(t, pinned.Events.OnTurnComplete, 1)
+	assert.Equal(t, models.OnTurnCompleteMoveToNext, pinned.Events.OnTurnComplete[0].Type,
+		"an in-flight task must keep its revision's transitions")
+	next, err := svc.GetTaskNextStepByPosition(ctx, "inflight", "wf-1", todo.Position)
+	require.NoError(t, err)
+	assert.Equal(t, review.ID, next.ID)
+	prev, err := svc.GetTaskPreviousStepByPosition(ctx, "inflight", "wf-1", review.Position)
+	require.NoError(t, err)
+	assert.Equal(t, todo.ID, prev.ID)
+
+	insertRevisionTask(t, db, "fresh", todo.ID)
+	require.NoError(t, svc.PinTaskRevision(ctx, "fresh", "wf-1"))
+	live, err := svc.GetTaskStep(ctx, "fresh", todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OnTurnCompleteMoveToStep, live.Events.OnTurnComplete[0].Type)
+	next, err = svc.GetTaskNextStepByPosition(ctx, "fresh", "wf-1", todo.Position)
+	require.NoError(t, err)
+	assert.Equal(t, "QA", next.Name)
+
+	_, err = svc.MigrateTaskRevisions(ctx, MigrateRevisionRequest{WorkflowID: "wf-1", FromRevision: 1})
+	require.NoError(t, err)
+	migrated, err := svc.GetTaskStep(ctx, "inflight", todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OnTurnCompleteMoveToStep, migrated.Events.OnTurnComplete[0].Type,
+		"a migrated task runs the new revision")
+}
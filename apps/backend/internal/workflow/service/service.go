@@ -35,12 +35,17 @@ type Service struct {
 	matchProfile         models.AgentProfileMatcher
 	syncOps              SyncWorkflowOps
 	sessionAccessChecker func(context.Context, string) error
+	checkResults         StepCheckResultLister
 	historyQueue         chan historyWrite
 	historyStop          chan struct{}
 	historyDone          chan struct{}
 	historyCloseOnce     sync.Once
 	historyMu            sync.RWMutex
 	historyClosed        bool
+	// revisionMu serialises revision cuts, pins and migrations so two
+	// callers never number the same revision or migrate a task twice.
+	revisionMu       sync.Mutex
+	revisionMigrator RevisionMigrationApplier
 }
 
 type historyWrite struct {
@@ -57,6 +62,19 @@ func (s *Service) SetSessionAccessChecker(checker func(context.Context, string)
 	s.sessionAccessChecker = checker
 }
 
+// StepCheckResultLister lists a session's run_check gate results. The task
+// repository records them; the workflow service serves them with the
+// session's step history.
+type StepCheckResultLister interface {
+	ListStepCheckResults(ctx context.Context, sessionID string) ([]*taskmodels.StepCheckResult, error)
+}
+
+// SetStepCheckResultLister wires where ListCheckResultsBySession reads from.
+// Without one, no gate results are reported.
+func (s *Service) SetStepCheckResultLister(lister StepCheckResultLister) {
+	s.checkResults = lister
+}
+
 // WorkspaceProvider resolves a workspace by ID so the read-only guard can tell
 // whether a workflow lives in the dedicated Improve Kandev workspace.
 type WorkspaceProvider interface {
@@ -270,14 +288,18 @@ func (s *Service) GetNextStepByPosition(ctx context.Context, workflowID string,
 		return nil, err
 	}
 
-	// Steps are already ordered by position from ListStepsByWorkflow
+	return nextStepByPosition(steps, currentPosition), nil
+}
+
+// nextStepByPosition returns the first of the position-ordered steps after
+// currentPosition, or nil when the current step is the last one.
+func nextStepByPosition(steps []*models.WorkflowStep, currentPosition int) *models.WorkflowStep {
 	for _, step := range steps {
 		if step.Position > currentPosition {
-			return step, nil
+			return step
 		}
 	}
-
-	return nil, nil // No next step found (current step is the last one)
+	return nil
 }
 
 // WorkflowMeta is the subset of workflow fields needed at step entry
@@ -331,7 +353,12 @@ func (s *Service) GetPreviousStepByPosition(ctx context.Context, workflowID stri
 		return nil, err
 	}
 
-	// Steps are ordered by position ascending. Walk backwards to find the step just before current.
+	return previousStepByPosition(steps, currentPosition), nil
+}
+
+// previousStepByPosition returns the last of the position-ordered steps
+// before currentPosition, or nil when the current step is the first one.
+func previousStepByPosition(steps []*models.WorkflowStep, currentPosition int) *models.WorkflowStep {
 	var prev *models.WorkflowStep
 	for _, step := range steps {
 		if step.Position >= currentPosition {
@@ -339,8 +366,7 @@ func (s *Service) GetPreviousStepByPosition(ctx context.Context, workflowID stri
 		}
 		prev = step
 	}
-
-	return prev, nil
+	return prev
 }
 
 // CreateStepsFromTemplate creates workflow steps for a workflow from a template.
@@ -590,6 +616,25 @@ func (s *Service) ListHistoryBySession(ctx context.Context, sessionID string) ([
 	return history, nil
 }
 
+// ListCheckResultsBySession returns the run_check gate results of a
+// session, oldest first.
+func (s *Service) ListCheckResultsBySession(ctx context.Context, sessionID string) ([]*taskmodels.StepCheckResult, error) {
+	if s.checkResults == nil {
+		return nil, nil
+	}
+	if s.sessionAccessChecker != nil {
+		if err := s.sessionAccessChecker(ctx, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	results, err := s.checkResults.ListStepCheckResults(ctx, sessionID)
+	if err != nil {
+		s.logger.Error("failed to list check results by session", zap.String("session_id", sessionID), zap.Error(err))
+		return nil, err
+	}
+	return results, nil
+}
+
 // ============================================================================
 // Export/Import Operations
 // ============================================================================
@@ -611,7 +656,11 @@ func (s *Service) ExportWorkflow(ctx context.Context, workflowID string) (*model
 		return nil, fmt.Errorf("failed to list steps: %w", err)
 	}
 	stepMap := map[string][]*models.WorkflowStep{wf.ID: steps}
-	return models.BuildWorkflowExport([]*taskmodels.Workflow{wf}, stepMap, s.resolveProfile), nil
+	export := models.BuildWorkflowExport([]*taskmodels.Workflow{wf}, stepMap, s.resolveProfile)
+	if err := s.stampExportRevisions(ctx, []*taskmodels.Workflow{wf}, export); err != nil {
+		return nil, err
+	}
+	return export, nil
 }
 
 // ExportWorkflows exports workflows for a workspace. When workflowIDs is nil,
@@ -642,7 +691,36 @@ func (s *Service) ExportWorkflows(ctx context.Context, workspaceID string, workf
 		}
 		stepMap[wf.ID] = steps
 	}
-	return models.BuildWorkflowExport(workflows, stepMap, s.resolveProfile), nil
+	export := models.BuildWorkflowExport(workflows, stepMap, s.resolveProfile)
+	if err := s.stampExportRevisions(ctx, workflows, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// stampExportRevisions sets each exported workflow's revision to its latest
+// one, when that still matches the live steps. An export only reads: a
+// workflow whose steps changed since (or that has none) is exported without
+// a revision, and the next pin cuts one. BuildWorkflowExport keeps the order
+// of workflows.
+func (s *Service) stampExportRevisions(ctx context.Context, workflows []*taskmodels.Workflow, export *models.WorkflowExport) error {
+	for i, wf := range workflows {
+		latest, err := s.repo.GetLatestRevision(ctx, wf.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load revision of workflow %s: %w", wf.ID, err)
+		}
+		if latest == nil {
+			continue
+		}
+		steps, err := s.repo.ListStepsByWorkflow(ctx, wf.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list steps for workflow %s: %w", wf.ID, err)
+		}
+		if latest.Digest == models.RevisionStepsDigest(steps) {
+			export.Workflows[i].Revision = latest.Revision
+		}
+	}
+	return nil
 }
 
 // filterWorkflowsByID returns the subset of workflows whose ID is in ids,
@@ -682,9 +760,11 @@ func (s *Service) ImportWorkflows(ctx context.Context, workspaceID string, expor
 			result.Skipped = append(result.Skipped, pw.Name)
 			continue
 		}
-		if _, err := s.importSingleWorkflow(ctx, workspaceID, pw); err != nil {
+		wf, err := s.importSingleWorkflow(ctx, workspaceID, pw)
+		if err != nil {
 			return nil, fmt.Errorf("failed to import workflow %q: %w", pw.Name, err)
 		}
+		s.recordRevision(ctx, wf.ID, models.RevisionSourceImport, pw.Revision)
 		result.Created = append(result.Created, pw.Name)
 	}
 
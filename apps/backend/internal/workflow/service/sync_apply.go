@@ -126,6 +126,7 @@ func (s *Service) createSyncedWorkflow(ctx context.Context, workspaceID string,
 		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: failed to mark workflow %q as synced: %v", path, pw.Name, err))
 		return
 	}
+	s.recordRevision(ctx, wf.ID, models.RevisionSourceSync, pw.Revision)
 	result.Created = append(result.Created, pw.Name)
 }
 
@@ -207,6 +208,7 @@ func (s *Service) updateSyncedWorkflow(ctx context.Context, wf *taskmodels.Workf
 		changed = true
 	}
 	if changed {
+		s.recordRevision(ctx, wf.ID, models.RevisionSourceSync, pw.Revision)
 		result.Updated = append(result.Updated, wf.Name)
 	}
 }
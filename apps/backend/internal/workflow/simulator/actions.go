@@ -0,0 +1,136 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+// recordedKinds are the side-effect actions a simulation records instead of
+// executing. Transition actions and run_check are routed by the engine
+// itself and reported as transitions and checks.
+var recordedKinds = []engine.ActionKind{
+	engine.ActionEnablePlanMode,
+	engine.ActionDisablePlanMode,
+	engine.ActionAutoStartAgent,
+	engine.ActionResetAgentContext,
+	engine.ActionSetWorkflowData,
+	engine.ActionSetSessionMode,
+	engine.ActionRunCodeReview,
+	engine.ActionQueueRun,
+	engine.ActionClearDecisions,
+	engine.ActionQueueRunForEachParticipant,
+	engine.ActionCreateChildTask,
+	engine.ActionSwitchWorkflow,
+	engine.ActionNotify,
+	engine.ActionFanOut,
+}
+
+func (s *simulation) callbacks(decisions engine.DecisionStore) engine.MapRegistry {
+	registry := make(engine.MapRegistry, len(recordedKinds))
+	for _, kind := range recordedKinds {
+		registry[kind] = actionRecorder{sim: s}
+	}
+	// Clearing decisions changes what later quorum guards see, so it runs
+	// for real against the in-memory decision store.
+	registry[engine.ActionClearDecisions] = actionRecorder{sim: s, next: engine.ClearDecisionsCallback{Decisions: decisions}}
+	return registry
+}
+
+// actionRecorder records a dispatched action on the event being handled.
+// set_workflow_data returns its patch like the orchestrator's callback, so
+// later guards read the written value; auto_start_agent starts a turn.
+type actionRecorder struct {
+	sim  *simulation
+	next engine.ActionCallback
+}
+
+func (r actionRecorder) Execute(ctx context.Context, in engine.ActionInput) (engine.ActionResult, error) {
+	r.sim.out.Actions = append(r.sim.out.Actions, FiredAction{
+		Step:    in.Step.Name,
+		Trigger: string(in.Trigger),
+		Kind:    string(in.Action.Kind),
+		Detail:  describeAction(in.Action),
+	})
+	switch in.Action.Kind {
+	case engine.ActionAutoStartAgent:
+		r.sim.waiting = false
+	case engine.ActionSetWorkflowData:
+		if in.Action.SetWorkflowData != nil {
+			return engine.ActionResult{DataPatch: map[string]any{
+				in.Action.SetWorkflowData.Key: in.Action.SetWorkflowData.Value,
+			}}, nil
+		}
+	}
+	if r.next != nil {
+		return r.next.Execute(ctx, in)
+	}
+	return engine.ActionResult{}, nil
+}
+
+// describeAction summarizes the configuration that matters when reading a
+// simulation, e.g. "target=participant_role:reviewer".
+func describeAction(a engine.Action) string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	switch {
+	case a.SetWorkflowData != nil:
+		add("key", a.SetWorkflowData.Key)
+		add("value", fmt.Sprint(a.SetWorkflowData.Value))
+	case a.SetSessionMode != nil:
+		add("mode", a.SetSessionMode.Mode)
+	case a.RunCodeReview != nil:
+		add("agent_profile_id", a.RunCodeReview.AgentProfileID)
+	case a.QueueRun != nil:
+		add("target", a.QueueRun.Target)
+		add("reason", a.QueueRun.Reason)
+	case a.QueueRunForEachParticipant != nil:
+		add("role", a.QueueRunForEachParticipant.Role)
+		add("reason", a.QueueRunForEachParticipant.Reason)
+	case a.CreateChildTask != nil:
+		add("title", a.CreateChildTask.Title)
+	case a.SwitchWorkflow != nil:
+		add("workflow_id", a.SwitchWorkflow.WorkflowID)
+	case a.Notify != nil:
+		add("title", a.Notify.Title)
+	case a.FanOut != nil:
+		add("profiles", strings.Join(a.FanOut.AgentProfileIDs, ","))
+		add("join", a.FanOut.Join)
+	}
+	return strings.Join(parts, " ")
+}
+
+// guardObserver reports every guard the engine evaluates on the event being
+// handled.
+type guardObserver struct {
+	sim *simulation
+}
+
+func (o guardObserver) ObserveGuard(_ context.Context, ev engine.GuardEvaluation) {
+	out := GuardResult{
+		Step:      o.sim.def.stepName(ev.StepID),
+		Trigger:   string(ev.Trigger),
+		Action:    string(ev.Action),
+		Satisfied: ev.Satisfied,
+		Reason:    ev.Reason,
+		Required:  ev.RequiredCount,
+		Received:  ev.ReceivedCount,
+	}
+	if ev.Err != nil {
+		out.Error = ev.Err.Error()
+	}
+	switch {
+	case ev.Guard.Expression != nil:
+		out.Expression = ev.Guard.Expression.Source
+	case ev.Guard.WaitForQuorum != nil:
+		out.Role = ev.Guard.WaitForQuorum.Role
+		out.Threshold = ev.Guard.WaitForQuorum.Threshold
+	}
+	o.sim.out.Guards = append(o.sim.out.Guards, out)
+}
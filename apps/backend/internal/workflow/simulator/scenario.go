@@ -0,0 +1,194 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kandev/kandev/internal/workflow/engine"
+	"github.com/kandev/kandev/internal/workflow/models"
+)
+
+// ErrInvalidScenario marks a scenario that does not fit the workflow it is
+// run against: an unknown step or participant, an unknown event type, or a
+// decision without a verdict.
+var ErrInvalidScenario = errors.New("invalid workflow simulation scenario")
+
+// EventType names a scripted simulation event.
+type EventType string
+
+const (
+	// EventTurnStart starts an agent turn and fires on_turn_start.
+	EventTurnStart EventType = "turn_start"
+	// EventTurnComplete ends an agent turn and fires on_turn_complete,
+	// subject to the step's auto_advance_requires_signal gate.
+	EventTurnComplete EventType = "turn_complete"
+	// EventStepComplete is the agent's explicit step_complete signal. It
+	// only matters on signal-gated steps.
+	EventStepComplete EventType = "step_complete"
+	// EventDecision records a participant decision and re-evaluates the
+	// step's quorum guards.
+	EventDecision EventType = "decision"
+	// EventComment fires on_comment.
+	EventComment EventType = "comment"
+	// EventTimeout fires on_timeout as if the step's SLA elapsed.
+	EventTimeout EventType = "timeout"
+)
+
+// Scenario scripts a simulation run.
+//
+// StartStep names the step the task is created in; empty means the
+// workflow's start step, or its first step when none is marked. Data seeds
+// the workflow data bag and Facts the task/pr/review facts expression
+// guards read.
+type Scenario struct {
+	StartStep    string         `json:"start_step,omitempty" yaml:"start_step,omitempty"`
+	Participants []Participant  `json:"participants,omitempty" yaml:"participants,omitempty"`
+	Facts        Facts          `json:"facts,omitempty" yaml:"facts,omitempty"`
+	Data         map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+	Events       []Event        `json:"events" yaml:"events"`
+}
+
+// Participant seats a reviewer or approver for quorum guards. Step names the
+// step whose template row it is; empty makes it a per-task participant that
+// counts at every step. ID defaults to a name derived from step, role and
+// index, and is what decision events refer to. DecisionRequired defaults to
+// true.
+type Participant struct {
+	ID               string `json:"id,omitempty" yaml:"id,omitempty"`
+	Step             string `json:"step,omitempty" yaml:"step,omitempty"`
+	Role             string `json:"role" yaml:"role"`
+	AgentProfileID   string `json:"agent_profile_id,omitempty" yaml:"agent_profile_id,omitempty"`
+	DecisionRequired *bool  `json:"decision_required,omitempty" yaml:"decision_required,omitempty"`
+}
+
+// Facts are the guard facts expression guards read as task.*, pr.* and
+// review.*.
+type Facts struct {
+	Task   map[string]any `json:"task,omitempty" yaml:"task,omitempty"`
+	PR     map[string]any `json:"pr,omitempty" yaml:"pr,omitempty"`
+	Review map[string]any `json:"review,omitempty" yaml:"review,omitempty"`
+}
+
+// CheckScript is the scripted result of a run_check command.
+type CheckScript struct {
+	ExitCode int    `json:"exit_code" yaml:"exit_code"`
+	Output   string `json:"output,omitempty" yaml:"output,omitempty"`
+	TimedOut bool   `json:"timed_out,omitempty" yaml:"timed_out,omitempty"`
+}
+
+// Event is one scripted occurrence. Data is merged into the workflow data
+// bag and Facts, when set, replace the guard facts before the event is
+// handled. Check scripts every run_check the event triggers; without it
+// checks pass with exit code 0.
+//
+// Per type: decision uses Participant, Decision and Comment; comment uses
+// Comment and Author; timeout uses Timeout ("wall_clock", the default, or
+// "agent_active").
+type Event struct {
+	Type        EventType      `json:"type" yaml:"type"`
+	Data        map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+	Facts       *Facts         `json:"facts,omitempty" yaml:"facts,omitempty"`
+	Check       *CheckScript   `json:"check,omitempty" yaml:"check,omitempty"`
+	Participant string         `json:"participant,omitempty" yaml:"participant,omitempty"`
+	Decision    string         `json:"decision,omitempty" yaml:"decision,omitempty"`
+	Comment     string         `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Author      string         `json:"author,omitempty" yaml:"author,omitempty"`
+	Timeout     string         `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// validate checks the scenario against the definition and returns the
+// participants with their IDs and step IDs resolved.
+func (s *Scenario) validate(def *Definition) ([]engine.ParticipantInfo, error) {
+	if s.StartStep != "" {
+		if _, ok := def.stepByName(s.StartStep); !ok {
+			return nil, fmt.Errorf("%w: start_step %q is not a step of the workflow", ErrInvalidScenario, s.StartStep)
+		}
+	}
+	participants := make([]engine.ParticipantInfo, 0, len(s.Participants))
+	seen := make(map[string]bool, len(s.Participants))
+	for i, p := range s.Participants {
+		info, err := resolveParticipant(def, i, p)
+		if err != nil {
+			return nil, err
+		}
+		if seen[info.ID] {
+			return nil, fmt.Errorf("%w: duplicate participant id %q", ErrInvalidScenario, info.ID)
+		}
+		seen[info.ID] = true
+		participants = append(participants, info)
+	}
+	for i, ev := range s.Events {
+		if err := validateEvent(ev, seen); err != nil {
+			return nil, fmt.Errorf("%w: event %d: %w", ErrInvalidScenario, i, err)
+		}
+	}
+	return participants, nil
+}
+
+func resolveParticipant(def *Definition, index int, p Participant) (engine.ParticipantInfo, error) {
+	if !engine.ValidParticipantRole(p.Role) {
+		return engine.ParticipantInfo{}, fmt.Errorf("%w: participant %d: unknown role %q", ErrInvalidScenario, index, p.Role)
+	}
+	info := engine.ParticipantInfo{
+		ID:               p.ID,
+		TaskID:           simulatedTaskID,
+		Role:             p.Role,
+		AgentProfileID:   p.AgentProfileID,
+		DecisionRequired: p.DecisionRequired == nil || *p.DecisionRequired,
+		Position:         index,
+	}
+	if p.Step != "" {
+		step, ok := def.stepByName(p.Step)
+		if !ok {
+			return engine.ParticipantInfo{}, fmt.Errorf("%w: participant %d: step %q is not a step of the workflow", ErrInvalidScenario, index, p.Step)
+		}
+		info.StepID = step.ID
+		// Template rows are not bound to a task; see engine.requiredSeats.
+		info.TaskID = ""
+	}
+	if info.ID == "" {
+		scope := p.Step
+		if scope == "" {
+			scope = "task"
+		}
+		info.ID = fmt.Sprintf("%s/%s/%d", strings.ToLower(scope), p.Role, index)
+	}
+	return info, nil
+}
+
+func validateEvent(ev Event, participants map[string]bool) error {
+	switch ev.Type {
+	case EventTurnStart, EventTurnComplete, EventStepComplete, EventComment:
+		return nil
+	case EventDecision:
+		if ev.Decision == "" {
+			return errors.New("decision is required")
+		}
+		if !participants[ev.Participant] {
+			return fmt.Errorf("unknown participant %q", ev.Participant)
+		}
+		return nil
+	case EventTimeout:
+		switch ev.Timeout {
+		case "", engine.TimeoutKindWallClock, engine.TimeoutKindAgentActive:
+			return nil
+		}
+		return fmt.Errorf("unknown timeout kind %q", ev.Timeout)
+	case "":
+		return errors.New("type is required")
+	}
+	return fmt.Errorf("unknown event type %q", ev.Type)
+}
+
+// stepTimeoutLimit returns the limit a timeout event of the given kind
+// reports for the step, in minutes; 0 when the step sets none.
+func stepTimeoutLimit(step *models.WorkflowStep, kind string) int {
+	if step.Events.Timeout == nil {
+		return 0
+	}
+	if kind == engine.TimeoutKindAgentActive {
+		return step.Events.Timeout.ActiveAfterMinutes
+	}
+	return step.Events.Timeout.AfterMinutes
+}
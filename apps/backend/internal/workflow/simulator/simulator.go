@@ -0,0 +1,571 @@
+// Package simulator dry-runs a workflow definition: it feeds a scripted
+// sequence of events (turns, step_complete signals, quorum decisions,
+// comments, timeouts) through the workflow engine against in-memory stores
+// and reports the step path, the actions that fired and every guard the
+// engine evaluated. Nothing is persisted and no agent is started; side-effect
+// actions are recorded instead of executed.
+//
+// The step lifecycle mirrors the orchestrator's: on_turn_complete is
+// evaluated first and committed as on_exit, move, on_enter; signal-gated
+// steps only advance on a turn that ends with a pending step_complete
+// signal; a decision re-evaluates only the step's wait_for_quorum
+// transitions.
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kandev/kandev/internal/workflow/engine"
+	"github.com/kandev/kandev/internal/workflow/models"
+)
+
+const (
+	simulatedTaskID     = "sim-task"
+	simulatedSessionID  = "sim-session"
+	simulatedWorkflowID = "sim-workflow"
+
+	// maxTransitionsPerEvent bounds the on_enter chain one event may cause,
+	// so a definition whose steps route into each other on entry reports
+	// the loop instead of spinning.
+	maxTransitionsPerEvent = 50
+
+	eventStart EventType = "start"
+)
+
+// Event outcomes reported on EventResult.Outcome.
+const (
+	// OutcomeTransitioned: the task left the step it was on.
+	OutcomeTransitioned = "transitioned"
+	// OutcomeStayed: the event was handled and the task did not move.
+	OutcomeStayed = "stayed"
+	// OutcomeAwaitingSignal: a turn ended on a signal-gated step without a
+	// pending step_complete signal, so on_turn_complete did not run.
+	OutcomeAwaitingSignal = "awaiting_signal"
+	// OutcomeSignalPending: a step_complete signal was recorded while a turn
+	// is running; the end of the turn consumes it.
+	OutcomeSignalPending = "signal_pending"
+	// OutcomeIgnored: the event has no effect on the step, e.g. a
+	// step_complete signal on a step that is not signal-gated.
+	OutcomeIgnored = "ignored"
+	// OutcomeError: the engine failed; the task stays where it was.
+	OutcomeError = "error"
+)
+
+// Definition is the workflow a simulation runs against. Steps are ordered
+// by position.
+type Definition struct {
+	WorkflowID string
+	Name       string
+	Steps      []*models.WorkflowStep
+}
+
+// NewDefinition builds a Definition from stored workflow steps.
+func NewDefinition(workflowID, name string, steps []*models.WorkflowStep) *Definition {
+	sorted := append([]*models.WorkflowStep(nil), steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+	return &Definition{WorkflowID: workflowID, Name: name, Steps: sorted}
+}
+
+// DefinitionFromExport builds a Definition from portable workflow YAML the
+// way an import would, without touching a database. name selects the
+// workflow when the export holds several; empty selects the first.
+func DefinitionFromExport(export *models.WorkflowExport, name string) (*Definition, error) {
+	if err := export.Validate(); err != nil {
+		return nil, err
+	}
+	wf := &export.Workflows[0]
+	if name != "" {
+		wf = nil
+		for i := range export.Workflows {
+			if export.Workflows[i].Name == name {
+				wf = &export.Workflows[i]
+				break
+			}
+		}
+		if wf == nil {
+			return nil, fmt.Errorf("%w: export has no workflow named %q", ErrInvalidScenario, name)
+		}
+	}
+
+	posToID := make(map[int]string, len(wf.Steps))
+	for _, sp := range wf.Steps {
+		posToID[sp.Position] = fmt.Sprintf("step-%d", sp.Position)
+	}
+	steps := make([]*models.WorkflowStep, 0, len(wf.Steps))
+	for _, sp := range wf.Steps {
+		steps = append(steps, &models.WorkflowStep{
+			ID:                         posToID[sp.Position],
+			WorkflowID:                 simulatedWorkflowID,
+			Name:                       sp.Name,
+			Position:                   sp.Position,
+			Color:                      sp.Color,
+			Prompt:                     sp.Prompt,
+			Events:                     models.ConvertPositionToStepID(sp.Events, posToID),
+			IsStartStep:                sp.IsStartStep,
+			AutoAdvanceRequiresSignal:  sp.AutoAdvanceRequiresSignal,
+			CancelTriggersTurnComplete: sp.CancelTriggersTurnComplete,
+			WIPLimit:                   sp.WIPLimit,
+			PullFromStepID:             sp.PullFromStepID(posToID),
+		})
+	}
+	return NewDefinition(simulatedWorkflowID, wf.Name, steps), nil
+}
+
+// DefinitionFromYAML parses portable workflow YAML and builds a Definition
+// from it; see DefinitionFromExport.
+func DefinitionFromYAML(data []byte, name string) (*Definition, error) {
+	var export models.WorkflowExport
+	if err := yaml.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid workflow YAML: %w", err)
+	}
+	return DefinitionFromExport(&export, name)
+}
+
+func (d *Definition) stepByID(id string) (*models.WorkflowStep, bool) {
+	for _, s := range d.Steps {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// stepByName matches case-insensitively, like revision migration does.
+func (d *Definition) stepByName(name string) (*models.WorkflowStep, bool) {
+	for _, s := range d.Steps {
+		if strings.EqualFold(strings.TrimSpace(s.Name), strings.TrimSpace(name)) {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (d *Definition) startStep(name string) *models.WorkflowStep {
+	if step, ok := d.stepByName(name); ok && name != "" {
+		return step
+	}
+	for _, s := range d.Steps {
+		if s.IsStartStep {
+			return s
+		}
+	}
+	return d.Steps[0]
+}
+
+func (d *Definition) stepName(id string) string {
+	if step, ok := d.stepByID(id); ok {
+		return step.Name
+	}
+	return id
+}
+
+// Result is the outcome of a simulation. Path lists the steps the task
+// visited, starting with the start step; Start reports what entering the
+// start step did.
+type Result struct {
+	Workflow  string         `json:"workflow"`
+	Path      []string       `json:"path"`
+	Start     EventResult    `json:"start"`
+	Events    []EventResult  `json:"events"`
+	FinalStep string         `json:"final_step"`
+	Data      map[string]any `json:"data"`
+}
+
+// EventResult reports one handled event. Step is where the task was when
+// the event arrived.
+type EventResult struct {
+	Index       int           `json:"index"`
+	Type        EventType     `json:"type"`
+	Step        string        `json:"step"`
+	Outcome     string        `json:"outcome"`
+	Transitions []Transition  `json:"transitions,omitempty"`
+	Actions     []FiredAction `json:"actions,omitempty"`
+	Guards      []GuardResult `json:"guards,omitempty"`
+	Checks      []CheckResult `json:"checks,omitempty"`
+	Limits      []LimitResult `json:"iteration_limits,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Transition is one step change, named by step names.
+type Transition struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Trigger string `json:"trigger"`
+}
+
+// FiredAction is a side-effect action the engine dispatched. Detail
+// summarizes its configuration.
+type FiredAction struct {
+	Step    string `json:"step"`
+	Trigger string `json:"trigger"`
+	Kind    string `json:"kind"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// GuardResult is one evaluated transition guard: an expression guard
+// (Expression set) or a wait_for_quorum guard (Role and Threshold set, with
+// the required and received decision counts).
+type GuardResult struct {
+	Step       string `json:"step"`
+	Trigger    string `json:"trigger"`
+	Action     string `json:"action"`
+	Expression string `json:"expression,omitempty"`
+	Role       string `json:"role,omitempty"`
+	Threshold  string `json:"threshold,omitempty"`
+	Satisfied  bool   `json:"satisfied"`
+	Reason     string `json:"reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Required   int    `json:"required,omitempty"`
+	Received   int    `json:"received,omitempty"`
+}
+
+// CheckResult is one run_check gate. Target is the step it routed to, empty
+// when it kept the task in place.
+type CheckResult struct {
+	Step     string `json:"step"`
+	Command  string `json:"command"`
+	Passed   bool   `json:"passed"`
+	ExitCode int    `json:"exit_code"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Target   string `json:"target,omitempty"`
+}
+
+// LimitResult is a transition refused because Step had already been
+// entered MaxIterations times; the step's on_iteration_limit actions ran
+// instead.
+type LimitResult struct {
+	Step          string `json:"step"`
+	MaxIterations int    `json:"max_iterations"`
+}
+
+// Run simulates the scenario against the definition. It fails only when the
+// scenario does not fit the definition (ErrInvalidScenario); engine errors
+// are reported on the event they happened in and the run continues with the
+// task where it was.
+func Run(ctx context.Context, def *Definition, scenario Scenario) (*Result, error) {
+	if def == nil || len(def.Steps) == 0 {
+		return nil, fmt.Errorf("%w: workflow has no steps", ErrInvalidScenario)
+	}
+	participants, err := scenario.validate(def)
+	if err != nil {
+		return nil, err
+	}
+	start := def.startStep(scenario.StartStep)
+	sim := newSimulation(def, scenario, participants, start.ID)
+
+	sim.result.Start = EventResult{Index: -1, Type: eventStart, Step: start.Name}
+	sim.begin(&sim.result.Start, nil)
+	sim.finish("", sim.fire(ctx, engine.TriggerOnEnter, nil))
+
+	sim.result.Events = make([]EventResult, len(scenario.Events))
+	for i, ev := range scenario.Events {
+		sim.result.Events[i] = EventResult{Index: i, Type: ev.Type, Step: def.stepName(sim.store.current)}
+		sim.begin(&sim.result.Events[i], &ev)
+		sim.finish(sim.handle(ctx, i, ev))
+	}
+
+	sim.result.FinalStep = def.stepName(sim.store.current)
+	sim.result.Data = sim.store.data
+	return sim.result, nil
+}
+
+// simulation is the state of one run: a single task with a single session.
+type simulation struct {
+	def          *Definition
+	engine       *engine.Engine
+	store        *memoryStore
+	participants *participantStore
+	facts        *factsProvider
+	checks       *checkRunner
+	result       *Result
+
+	// out is the event being handled; hops counts its transitions.
+	out  *EventResult
+	hops int
+	// waiting mirrors the session's WAITING_FOR_INPUT state: a turn ended
+	// and no agent has been started since.
+	waiting bool
+	// signalStepID is the step a pending step_complete signal was sent on.
+	signalStepID string
+}
+
+func newSimulation(def *Definition, scenario Scenario, participants []engine.ParticipantInfo, startStepID string) *simulation {
+	sim := &simulation{
+		def: def,
+		store: &memoryStore{
+			def:     def,
+			current: startStepID,
+			data:    maps.Clone(scenario.Data),
+			applied: map[string]bool{},
+		},
+		participants: &participantStore{rows: participants},
+		facts:        &factsProvider{facts: scenario.Facts},
+		checks:       &checkRunner{},
+		result:       &Result{Workflow: def.Name, Path: []string{def.stepName(startStepID)}},
+	}
+	if sim.store.data == nil {
+		sim.store.data = map[string]any{}
+	}
+	decisions := &decisionStore{}
+	sim.engine = engine.New(sim.store, sim.callbacks(decisions),
+		engine.WithParticipantStore(sim.participants),
+		engine.WithDecisionStore(decisions),
+		engine.WithCheckRunner(sim.checks),
+		engine.WithGuardFactsProvider(sim.facts),
+		engine.WithGuardObserver(guardObserver{sim: sim}),
+	)
+	return sim
+}
+
+// begin makes out the event being handled and applies the event's data,
+// facts and check script.
+func (s *simulation) begin(out *EventResult, ev *Event) {
+	s.out = out
+	s.hops = 0
+	s.checks.script = nil
+	if ev == nil {
+		return
+	}
+	maps.Copy(s.store.data, ev.Data)
+	if ev.Facts != nil {
+		s.facts.facts = *ev.Facts
+	}
+	s.checks.script = ev.Check
+}
+
+func (s *simulation) finish(outcome string, err error) {
+	switch {
+	case err != nil:
+		s.out.Outcome = OutcomeError
+		s.out.Error = err.Error()
+	case outcome != "":
+		s.out.Outcome = outcome
+	case len(s.out.Transitions) > 0:
+		s.out.Outcome = OutcomeTransitioned
+	default:
+		s.out.Outcome = OutcomeStayed
+	}
+}
+
+func (s *simulation) handle(ctx context.Context, index int, ev Event) (string, error) {
+	switch ev.Type {
+	case EventTurnStart:
+		s.waiting = false
+		return "", s.fire(ctx, engine.TriggerOnTurnStart, nil)
+	case EventTurnComplete:
+		return s.turnComplete(ctx)
+	case EventStepComplete:
+		return s.stepComplete(ctx)
+	case EventDecision:
+		return "", s.decide(ctx, ev)
+	case EventComment:
+		return "", s.fire(ctx, engine.TriggerOnComment, engine.OnCommentPayload{
+			CommentID: fmt.Sprintf("sim-comment-%d", index),
+			AuthorID:  ev.Author,
+			Body:      ev.Comment,
+		})
+	case EventTimeout:
+		return "", s.timeout(ctx, ev)
+	}
+	// Scenario.validate rejects every other type up front.
+	return "", fmt.Errorf("unhandled event type %q", ev.Type)
+}
+
+// turnComplete mirrors the orchestrator's turn-end handling: the session
+// waits for input, a signal-gated step needs a pending signal for the
+// current step, and on_turn_complete is evaluated before the transition is
+// committed through on_exit/on_enter.
+func (s *simulation) turnComplete(ctx context.Context) (string, error) {
+	s.waiting = true
+	step, _ := s.def.stepByID(s.store.current)
+	if len(engine.CompileStep(step).Events[engine.TriggerOnTurnComplete]) == 0 {
+		return "", nil
+	}
+	if step.AutoAdvanceRequiresSignal && s.signalStepID != step.ID {
+		return OutcomeAwaitingSignal, nil
+	}
+	in := engine.HandleInput{
+		TaskID:       simulatedTaskID,
+		SessionID:    simulatedSessionID,
+		Trigger:      engine.TriggerOnTurnComplete,
+		EvaluateOnly: true,
+	}
+	res, err := s.engine.HandleTrigger(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	if res.Check != nil && res.Check.Pending {
+		// Evaluate-only never runs a gate; answer it from the event's
+		// script as the orchestrator answers it from the workspace.
+		started := time.Now()
+		result, runErr := s.checks.RunCheck(ctx, res.Check.Request(simulatedTaskID, simulatedSessionID))
+		if res, err = s.engine.CompletePendingCheck(ctx, in, *res.Check, started, result, runErr); err != nil {
+			return "", err
+		}
+	}
+	s.recordCheck(res.Check)
+	s.recordLimit(res.IterationLimit)
+	if len(res.DataPatch) > 0 {
+		maps.Copy(s.store.data, res.DataPatch)
+	}
+	if !res.Transitioned {
+		return "", nil
+	}
+	s.store.current = res.ToStepID
+	return "", s.afterMove(ctx, res.FromStepID, res.ToStepID, moveTrigger(engine.TriggerOnTurnComplete, res.IterationLimit))
+}
+
+// stepComplete records the signal; like the orchestrator's subscriber it
+// drives on_turn_complete right away only when the session is already
+// waiting, otherwise the end of the running turn picks it up.
+func (s *simulation) stepComplete(ctx context.Context) (string, error) {
+	step, _ := s.def.stepByID(s.store.current)
+	if !step.AutoAdvanceRequiresSignal {
+		return OutcomeIgnored, nil
+	}
+	s.signalStepID = step.ID
+	if !s.waiting {
+		return OutcomeSignalPending, nil
+	}
+	return s.turnComplete(ctx)
+}
+
+func (s *simulation) decide(ctx context.Context, ev Event) error {
+	p, _ := s.participants.byID(ev.Participant)
+	deciderType, deciderID := engine.DeciderTypeUser, p.ID
+	if p.AgentProfileID != "" {
+		deciderType, deciderID = engine.DeciderTypeAgent, p.AgentProfileID
+	}
+	res, err := s.engine.RecordParticipantDecision(ctx, simulatedSessionID, engine.DecisionInfo{
+		TaskID:        simulatedTaskID,
+		StepID:        s.store.current,
+		ParticipantID: p.ID,
+		Decision:      ev.Decision,
+		Role:          p.Role,
+		DeciderType:   deciderType,
+		DeciderID:     deciderID,
+		Comment:       ev.Comment,
+	})
+	if err != nil {
+		return err
+	}
+	s.recordLimit(res.IterationLimit)
+	if !res.Transitioned {
+		return nil
+	}
+	return s.afterMove(ctx, res.FromStepID, res.ToStepID, moveTrigger(engine.TriggerOnTurnComplete, res.IterationLimit))
+}
+
+func (s *simulation) timeout(ctx context.Context, ev Event) error {
+	kind := ev.Timeout
+	if kind == "" {
+		kind = engine.TimeoutKindWallClock
+	}
+	step, _ := s.def.stepByID(s.store.current)
+	limit := time.Duration(stepTimeoutLimit(step, kind)) * time.Minute
+	return s.fire(ctx, engine.TriggerOnTimeout, engine.OnTimeoutPayload{
+		Kind:      kind,
+		StepID:    step.ID,
+		EnteredAt: time.Now().UTC().Add(-limit),
+		Elapsed:   limit,
+		Limit:     limit,
+	})
+}
+
+// fire handles a trigger the engine commits itself and, when it moved the
+// task, runs the rest of the step lifecycle.
+func (s *simulation) fire(ctx context.Context, trigger engine.Trigger, payload any) error {
+	res, err := s.engine.HandleTrigger(ctx, engine.HandleInput{
+		TaskID:    simulatedTaskID,
+		SessionID: simulatedSessionID,
+		Trigger:   trigger,
+		Payload:   payload,
+	})
+	if err != nil {
+		return err
+	}
+	s.recordCheck(res.Check)
+	s.recordLimit(res.IterationLimit)
+	if !res.Transitioned {
+		return nil
+	}
+	return s.afterMove(ctx, res.FromStepID, res.ToStepID, moveTrigger(trigger, res.IterationLimit))
+}
+
+// afterMove completes a transition the store already applied: on_exit of
+// the step that was left, signal cleanup, then on_enter of the new step.
+func (s *simulation) afterMove(ctx context.Context, fromStepID, toStepID string, trigger engine.Trigger) error {
+	s.hops++
+	if s.hops > maxTransitionsPerEvent {
+		return fmt.Errorf("transition loop: more than %d transitions for one event", maxTransitionsPerEvent)
+	}
+	s.out.Transitions = append(s.out.Transitions, Transition{
+		From:    s.def.stepName(fromStepID),
+		To:      s.def.stepName(toStepID),
+		Trigger: string(trigger),
+	})
+	s.result.Path = append(s.result.Path, s.def.stepName(toStepID))
+
+	exitState := s.store.state()
+	exitState.CurrentStepID = fromStepID
+	res, err := s.engine.HandleTrigger(ctx, engine.HandleInput{
+		TaskID:         simulatedTaskID,
+		SessionID:      simulatedSessionID,
+		Trigger:        engine.TriggerOnExit,
+		EvaluateOnly:   true,
+		PreloadedState: &exitState,
+	})
+	if err != nil {
+		return err
+	}
+	maps.Copy(s.store.data, res.DataPatch)
+	s.signalStepID = ""
+	return s.fire(ctx, engine.TriggerOnEnter, nil)
+}
+
+// moveTrigger labels a transition with on_iteration_limit when the trigger's
+// own route was refused by a max_iterations bound.
+func moveTrigger(trigger engine.Trigger, limit *engine.IterationLimit) engine.Trigger {
+	if limit != nil {
+		return engine.TriggerOnIterationLimit
+	}
+	return trigger
+}
+
+func (s *simulation) recordLimit(limit *engine.IterationLimit) {
+	if limit == nil {
+		return
+	}
+	s.out.Limits = append(s.out.Limits, LimitResult{
+		Step:          s.def.stepName(limit.StepID),
+		MaxIterations: limit.MaxIterations,
+	})
+}
+
+func (s *simulation) recordCheck(check *engine.CheckOutcome) {
+	if check == nil {
+		return
+	}
+	out := CheckResult{
+		Step:     s.def.stepName(check.StepID),
+		Command:  check.Command,
+		Passed:   check.Passed,
+		ExitCode: check.ExitCode,
+		TimedOut: check.TimedOut,
+		Output:   check.Output,
+		Error:    check.Error,
+	}
+	if check.TargetStepID != "" {
+		out.Target = s.def.stepName(check.TargetStepID)
+	}
+	s.out.Checks = append(s.out.Checks, out)
+}
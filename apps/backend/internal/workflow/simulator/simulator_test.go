@@ -0,0 +1,356 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipelineYAML is a plan → build → review → done workflow: Build is
+// signal-gated behind a run_check, Review waits for a reviewer quorum and
+// sends rejections back to Build.
+const pipelineYAML = `
+version: 1
+type: kandev_workflow
+workflows:
+  - name: Pipeline
+    steps:
+      - name: Plan
+        position: 0
+        is_start_step: true
+        events:
+          on_enter:
+            - type: enable_plan_mode
+            - type: auto_start_agent
+          on_turn_complete:
+            - type: move_to_next
+          on_exit:
+            - type: disable_plan_mode
+      - name: Build
+        position: 1
+        auto_advance_requires_signal: true
+        events:
+          on_enter:
+            - type: auto_start_agent
+          on_turn_complete:
+            - type: run_check
+              config:
+                command: make test
+                pass_step_position: 2
+      - name: Review
+        position: 2
+        events:
+          on_enter:
+            - type: clear_decisions
+          on_turn_complete:
+            - type: move_to_step
+              config:
+                step_position: 3
+                wait_for_quorum:
+                  role: reviewer
+                  threshold: all_approve
+            - type: move_to_step
+              config:
+                step_position: 1
+                wait_for_quorum:
+                  role: reviewer
+                  threshold: any_reject
+      - name: Done
+        position: 3
+`
+
+func loadDefinition(t *testing.T, src string) *Definition {
+	t.Helper()
+	def, err := DefinitionFromYAML([]byte(src), "")
+	require.NoError(t, err)
+	return def
+}
+
+func pipelineScenario(events ...Event) Scenario {
+	return Scenario{
+		Participants: []Participant{
+			{ID: "alice", Step: "Review", Role: "reviewer"},
+			{ID: "bob", Step: "Review", Role: "reviewer", AgentProfileID: "profile-bob"},
+		},
+		Events: events,
+	}
+}
+
+func actionKinds(ev EventResult) []string {
+	kinds := make([]string, 0, len(ev.Actions))
+	for _, a := range ev.Actions {
+		kinds = append(kinds, a.Kind)
+	}
+	return kinds
+}
+
+func TestRun_WalksPipelineToDone(t *testing.T) {
+	def := loadDefinition(t, pipelineYAML)
+
+	res, err := Run(context.Background(), def, pipelineScenario(
+		Event{Type: EventTurnComplete},
+		Event{Type: EventStepComplete},
+		Event{Type: EventTurnComplete},
+		Event{Type: EventDecision, Participant: "alice", Decision: "approved"},
+		Event{Type: EventDecision, Participant: "bob", Decision: "approved"},
+	))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Plan", "Build", "Review", "Done"}, res.Path)
+	assert.Equal(t, "Done", res.FinalStep)
+	assert.Equal(t, []string{"enable_plan_mode", "auto_start_agent"}, actionKinds(res.Start))
+
+	planDone := res.Events[0]
+	assert.Equal(t, OutcomeTransitioned, planDone.Outcome)
+	assert.Equal(t, []Transition{{From: "Plan", To: "Build", Trigger: "on_turn_complete"}}, planDone.Transitions)
+	assert.Equal(t, []string{"disable_plan_mode", "auto_start_agent"}, actionKinds(planDone),
+		"on_exit of the old step runs before on_enter of the new one")
+
+	assert.Equal(t, OutcomeSignalPending, res.Events[1].Outcome, "the agent is still running its turn")
+
+	build := res.Events[2]
+	require.Len(t, build.Checks, 1)
+	assert.True(t, build.Checks[0].Passed)
+	assert.Equal(t, "Review", build.Checks[0].Target)
+	assert.Equal(t, []string{"clear_decisions"}, actionKinds(build))
+
+	firstVote := res.Events[3]
+	assert.Equal(t, OutcomeStayed, firstVote.Outcome)
+	require.Len(t, firstVote.Guards, 2)
+	assert.Equal(t, GuardResult{
+		Step: "Review", Trigger: "on_turn_complete", Action: "move_to_step",
+		Role: "reviewer", Threshold: "all_approve", Reason: "threshold_not_met", Required: 2, Received: 1,
+	}, firstVote.Guards[0])
+
+	assert.Equal(t, OutcomeTransitioned, res.Events[4].Outcome)
+	assert.True(t, res.Events[4].Guards[0].Satisfied)
+	assert.Contains(t, res.Data, "last_check")
+}
+
+func TestRun_SignalGatedStepWaitsForSignal(t *testing.T) {
+	def := loadDefinition(t, pipelineYAML)
+
+	res, err := Run(context.Background(), def, Scenario{
+		StartStep: "build",
+		Events: []Event{
+			{Type: EventTurnComplete},
+			{Type: EventStepComplete},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, OutcomeAwaitingSignal, res.Events[0].Outcome)
+	assert.Empty(t, res.Events[0].Checks, "on_turn_complete must not run without the signal")
+	assert.Equal(t, OutcomeTransitioned, res.Events[1].Outcome,
+		"a signal arriving while the session waits drives on_turn_complete")
+	assert.Equal(t, "Review", res.FinalStep)
+}
+
+func TestRun_FailingCheckKeepsTaskAndRejectionLoopsBack(t *testing.T) {
+	def := loadDefinition(t, pipelineYAML)
+
+	res, err := Run(context.Background(), def, pipelineScenario(
+		Event{Type: EventTurnComplete},
+		Event{Type: EventStepComplete},
+		Event{Type: EventTurnComplete, Check: &CheckScript{ExitCode: 2, Output: "FAIL: TestFoo"}},
+		Event{Type: EventTurnStart},
+		Event{Type: EventTurnComplete},
+		Event{Type: EventDecision, Participant: "bob", Decision: "rejected", Comment: "needs tests"},
+	))
+	require.NoError(t, err)
+
+	failed := res.Events[2]
+	assert.Equal(t, OutcomeStayed, failed.Outcome)
+	require.Len(t, failed.Checks, 1)
+	assert.False(t, failed.Checks[0].Passed)
+	assert.Equal(t, 2, failed.Checks[0].ExitCode)
+	assert.Empty(t, failed.Checks[0].Target)
+
+	assert.Equal(t, OutcomeTransitioned, res.Events[4].Outcome, "the pending signal survives a failed gate")
+	reject := res.Events[5]
+	assert.Equal(t, []Transition{{From: "Review", To: "Build", Trigger: "on_turn_complete"}}, reject.Transitions)
+	assert.Equal(t, []string{"Plan", "Build", "Review", "Build"}, res.Path)
+}
+
+const guardedYAML = `
+version: 1
+type: kandev_workflow
+workflows:
+  - name: Guarded
+    steps:
+      - name: Work
+        position: 0
+        events:
+          on_turn_complete:
+            - type: move_to_step
+              config:
+                step_position: 2
+                if: "data.skip_review == true"
+            - type: move_to_next
+              config:
+                if: "pr.checks == 'success' && review.blocking_findings == 0"
+          on_timeout:
+            - type: move_to_step
+              config:
+                step_position: 2
+          timeout:
+            after_minutes: 30
+      - name: Review
+        position: 1
+      - name: Done
+        position: 2
+`
+
+func TestRun_ExpressionGuardsReadDataAndFacts(t *testing.T) {
+	def := loadDefinition(t, guardedYAML)
+
+	res, err := Run(context.Background(), def, Scenario{
+		Facts: Facts{PR: map[string]any{"checks": "pending"}, Review: map[string]any{"blocking_findings": 0}},
+		Events: []Event{
+			{Type: EventTurnComplete},
+			{Type: EventTurnComplete, Facts: &Facts{PR: map[string]any{"checks": "success"}, Review: map[string]any{"blocking_findings": 0}}},
+		},
+	})
+	require.NoError(t, err)
+
+	first := res.Events[0]
+	assert.Equal(t, OutcomeStayed, first.Outcome)
+	require.Len(t, first.Guards, 2)
+	assert.Equal(t, "data.skip_review == true", first.Guards[0].Expression)
+	assert.False(t, first.Guards[0].Satisfied)
+	assert.False(t, first.Guards[1].Satisfied)
+
+	assert.Equal(t, "Review", res.FinalStep)
+	assert.True(t, res.Events[1].Guards[1].Satisfied)
+}
+
+func TestRun_EventDataAndTimeout(t *testing.T) {
+	def := loadDefinition(t, guardedYAML)
+
+	res, err := Run(context.Background(), def, Scenario{
+		Events: []Event{
+			{Type: EventTurnComplete, Data: map[string]any{"skip_review": true}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Work", "Done"}, res.Path)
+	assert.Equal(t, true, res.Data["skip_review"])
+
+	res, err = Run(context.Background(), def, Scenario{Events: []Event{{Type: EventTimeout}}})
+	require.NoError(t, err)
+	assert.Equal(t, []Transition{{From: "Work", To: "Done", Trigger: "on_timeout"}}, res.Events[0].Transitions)
+}
+
+func TestRun_ReportsEngineErrorsAndContinues(t *testing.T) {
+	def := loadDefinition(t, `
+version: 1
+type: kandev_workflow
+workflows:
+  - name: Last
+    steps:
+      - name: Only
+        position: 0
+        events:
+          on_turn_complete:
+            - type: move_to_next
+`)
+	res, err := Run(context.Background(), def, Scenario{Events: []Event{{Type: EventTurnComplete}, {Type: EventStepComplete}}})
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeError, res.Events[0].Outcome)
+	assert.Contains(t, res.Events[0].Error, "no next step")
+	assert.Equal(t, OutcomeIgnored, res.Events[1].Outcome)
+	assert.Equal(t, "Only", res.FinalStep)
+}
+
+func TestRun_RejectsScenarioThatDoesNotFit(t *testing.T) {
+	def := loadDefinition(t, pipelineYAML)
+	cases := map[string]Scenario{
+		"unknown start step":  {StartStep: "Deploy"},
+		"unknown role":        {Participants: []Participant{{Role: "owner"}}},
+		"unknown participant": {Events: []Event{{Type: EventDecision, Participant: "carol", Decision: "approved"}}},
+		"missing verdict":     pipelineScenario(Event{Type: EventDecision, Participant: "alice"}),
+		"unknown event":       {Events: []Event{{Type: "deploy"}}},
+	}
+	for name, scenario := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Run(context.Background(), def, scenario)
+			assert.True(t, errors.Is(err, ErrInvalidScenario), "got %v", err)
+		})
+	}
+}
+
+const boundedLoopYAML = `
+version: 1
+type: kandev_workflow
+workflows:
+  - name: Bounded
+    steps:
+      - name: Build
+        position: 0
+        is_start_step: true
+        events:
+          max_iterations: 2
+          on_iteration_limit:
+            - type: move_to_step
+              config:
+                step_position: 2
+          on_turn_complete:
+            - type: move_to_next
+      - name: Review
+        position: 1
+        events:
+          on_turn_complete:
+            - type: move_to_previous
+      - name: Human
+        position: 2
+`
+
+func TestRun_IterationLimitEndsLoop(t *testing.T) {
+	def := loadDefinition(t, boundedLoopYAML)
+
+	res, err := Run(context.Background(), def, Scenario{Events: []Event{
+		{Type: EventTurnComplete},
+		{Type: EventTurnComplete},
+		{Type: EventTurnComplete},
+		{Type: EventTurnComplete},
+		{Type: EventTurnComplete},
+		{Type: EventTurnComplete},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Build", "Review", "Build", "Review", "Build", "Review", "Human"}, res.Path)
+	last := res.Events[5]
+	assert.Equal(t, []Transition{{From: "Review", To: "Human", Trigger: "on_iteration_limit"}}, last.Transitions)
+	assert.Equal(t, []LimitResult{{Step: "Build", MaxIterations: 2}}, last.Limits)
+	assert.Equal(t, map[string]any{"step-0": 2}, res.Data["iterations"],
+		"the start step is not counted and the refused entry is not either")
+}
+
+// TestRun_IterationLimitSurvivesReviewClearDecisions runs the bounded loop
+// with the clear_decisions every review step runs on entry. A plain clear
+// keeps the counters, so the loop still stops at the limit.
+func TestRun_IterationLimitSurvivesReviewClearDecisions(t *testing.T) {
+	src := strings.Replace(boundedLoopYAML, `        events:
+          on_turn_complete:
+            - type: move_to_previous`, `        events:
+          on_enter:
+            - type: clear_decisions
+          on_turn_complete:
+            - type: move_to_previous`, 1)
+	require.NotEqual(t, boundedLoopYAML, src)
+	def := loadDefinition(t, src)
+
+	events := make([]Event, 6)
+	for i := range events {
+		events[i] = Event{Type: EventTurnComplete}
+	}
+	res, err := Run(context.Background(), def, Scenario{Events: events})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Build", "Review", "Build", "Review", "Build", "Review", "Human"}, res.Path)
+	assert.Equal(t, map[string]any{"step-0": 2}, res.Data["iterations"])
+}
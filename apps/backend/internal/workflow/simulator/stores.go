@@ -0,0 +1,183 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/kandev/kandev/internal/workflow/engine"
+)
+
+// memoryStore is the engine.TransitionStore of a simulation: one task with
+// one session, held in memory.
+type memoryStore struct {
+	def     *Definition
+	current string
+	data    map[string]any
+	applied map[string]bool
+}
+
+func (s *memoryStore) state() engine.MachineState {
+	return engine.MachineState{
+		TaskID:        simulatedTaskID,
+		SessionID:     simulatedSessionID,
+		WorkflowID:    s.def.WorkflowID,
+		CurrentStepID: s.current,
+		Data:          maps.Clone(s.data),
+	}
+}
+
+func (s *memoryStore) LoadState(_ context.Context, _, _ string) (engine.MachineState, error) {
+	return s.state(), nil
+}
+
+func (s *memoryStore) LoadStep(_ context.Context, _, stepID string) (engine.StepSpec, error) {
+	step, ok := s.def.stepByID(stepID)
+	if !ok {
+		return engine.StepSpec{}, fmt.Errorf("step %s not found", stepID)
+	}
+	return engine.CompileStep(step), nil
+}
+
+func (s *memoryStore) LoadNextStep(_ context.Context, _ string, currentPosition int) (engine.StepSpec, error) {
+	for _, step := range s.def.Steps {
+		if step.Position > currentPosition {
+			return engine.CompileStep(step), nil
+		}
+	}
+	return engine.StepSpec{}, fmt.Errorf("no next step after position %d", currentPosition)
+}
+
+func (s *memoryStore) LoadPreviousStep(_ context.Context, _ string, currentPosition int) (engine.StepSpec, error) {
+	for i := len(s.def.Steps) - 1; i >= 0; i-- {
+		if step := s.def.Steps[i]; step.Position < currentPosition {
+			return engine.CompileStep(step), nil
+		}
+	}
+	return engine.StepSpec{}, fmt.Errorf("no previous step before position %d", currentPosition)
+}
+
+func (s *memoryStore) ApplyTransition(_ context.Context, _, _, _, toStepID string, _ engine.Trigger) error {
+	s.current = toStepID
+	return nil
+}
+
+func (s *memoryStore) ApplyTransitionIfAtStep(
+	_ context.Context, _, _, expectedStepID, toStepID string, _ engine.Trigger,
+) (bool, error) {
+	if s.current != expectedStepID {
+		return false, nil
+	}
+	s.current = toStepID
+	return true, nil
+}
+
+func (s *memoryStore) PersistData(_ context.Context, _ string, data map[string]any) error {
+	maps.Copy(s.data, data)
+	return nil
+}
+
+func (s *memoryStore) IsOperationApplied(_ context.Context, operationID string) (bool, error) {
+	return s.applied[operationID], nil
+}
+
+func (s *memoryStore) MarkOperationApplied(_ context.Context, operationID string) error {
+	s.applied[operationID] = true
+	return nil
+}
+
+// participantStore serves the scenario's participants.
+type participantStore struct {
+	rows []engine.ParticipantInfo
+}
+
+func (s *participantStore) ListStepParticipants(_ context.Context, stepID, taskID string) ([]engine.ParticipantInfo, error) {
+	var out []engine.ParticipantInfo
+	for _, p := range s.rows {
+		if p.StepID == stepID && p.TaskID == taskID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *participantStore) ListTaskParticipants(_ context.Context, taskID string) ([]engine.ParticipantInfo, error) {
+	var out []engine.ParticipantInfo
+	for _, p := range s.rows {
+		if p.TaskID == taskID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *participantStore) byID(id string) (engine.ParticipantInfo, bool) {
+	for _, p := range s.rows {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return engine.ParticipantInfo{}, false
+}
+
+// decisionStore keeps recorded decisions in arrival order, which is the
+// order the engine expects ListStepDecisions to return.
+type decisionStore struct {
+	rows []engine.DecisionInfo
+}
+
+func (s *decisionStore) ListStepDecisions(_ context.Context, taskID, stepID string) ([]engine.DecisionInfo, error) {
+	var out []engine.DecisionInfo
+	for _, d := range s.rows {
+		if d.TaskID == taskID && d.StepID == stepID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (s *decisionStore) RecordStepDecision(_ context.Context, d engine.DecisionInfo) error {
+	s.rows = append(s.rows, d)
+	return nil
+}
+
+func (s *decisionStore) ClearStepDecisions(_ context.Context, taskID, stepID string) (int64, error) {
+	kept := s.rows[:0]
+	var cleared int64
+	for _, d := range s.rows {
+		if d.TaskID == taskID && d.StepID == stepID {
+			cleared++
+			continue
+		}
+		kept = append(kept, d)
+	}
+	s.rows = kept
+	return cleared, nil
+}
+
+// factsProvider serves the scenario's guard facts; events may replace them.
+type factsProvider struct {
+	facts Facts
+}
+
+func (p *factsProvider) LoadGuardFacts(_ context.Context, _ string) (engine.GuardFacts, error) {
+	return engine.GuardFacts{Task: p.facts.Task, PR: p.facts.PR, Review: p.facts.Review}, nil
+}
+
+// checkRunner answers run_check with the current event's script, or a pass.
+type checkRunner struct {
+	script *CheckScript
+}
+
+func (r *checkRunner) RunCheck(_ context.Context, _ engine.CheckRequest) (engine.CheckResult, error) {
+	if r.script == nil {
+		return engine.CheckResult{Duration: time.Millisecond}, nil
+	}
+	return engine.CheckResult{
+		ExitCode: r.script.ExitCode,
+		Output:   r.script.Output,
+		TimedOut: r.script.TimedOut,
+		Duration: time.Millisecond,
+	}, nil
+}
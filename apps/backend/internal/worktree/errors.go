@@ -88,6 +88,10 @@ var (
 	// the just-created physical worktree instead of admitting it after
 	// cleanup inventory was captured.
 	ErrTaskCleanupInProgress = errors.New("task cleanup in progress")
+
+	// ErrMergeConflict is returned by MergeBranch when the branch does not
+	// merge cleanly. The merge is aborted, leaving the worktree as it was.
+	ErrMergeConflict = errors.New("merge conflict")
 )
 
 // containsAuthFailure checks if git output indicates an authentication failure.
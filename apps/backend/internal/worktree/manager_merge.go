@@ -0,0 +1,51 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MergeBranch merges branch into the branch checked out at worktreePath with
+// a merge commit carrying message. Worktrees of one repository share its
+// refs, so branch may be checked out in a sibling worktree. A conflicting
+// merge is aborted and reported as ErrMergeConflict.
+func (m *Manager) MergeBranch(ctx context.Context, worktreePath, branch, message string) error {
+	if strings.TrimSpace(branch) == "" || strings.HasPrefix(branch, "-") {
+		return fmt.Errorf("%w: invalid branch %q", ErrGitCommandFailed, branch)
+	}
+	cmd := m.newNonInteractiveGitCmd(ctx, worktreePath, "merge", "--no-ff", "--no-edit", "-m", message, branch)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if strings.Contains(string(output), "CONFLICT") {
+		abort := m.newNonInteractiveGitCmd(ctx, worktreePath, "merge", "--abort")
+		if abortOut, abortErr := abort.CombinedOutput(); abortErr != nil {
+			return fmt.Errorf("%w: merge of %s conflicted and could not be aborted: %s",
+				ErrMergeConflict, branch, strings.TrimSpace(string(abortOut)))
+		}
+		return fmt.Errorf("%w: %s", ErrMergeConflict, branch)
+	}
+	return fmt.Errorf("%w: merge %s: %s", ErrGitCommandFailed, branch, strings.TrimSpace(string(output)))
+}
+
+// CommitPendingChanges commits everything uncommitted at worktreePath,
+// untracked files included, with message, and reports whether there was
+// anything to commit.
+func (m *Manager) CommitPendingChanges(ctx context.Context, worktreePath, message string) (bool, error) {
+	status, err := m.newNonInteractiveGitCmd(ctx, worktreePath, "status", "--porcelain").CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("%w: status: %s", ErrGitCommandFailed, strings.TrimSpace(string(status)))
+	}
+	if strings.TrimSpace(string(status)) == "" {
+		return false, nil
+	}
+	if output, err := m.newNonInteractiveGitCmd(ctx, worktreePath, "add", "--all").CombinedOutput(); err != nil {
+		return false, fmt.Errorf("%w: add: %s", ErrGitCommandFailed, strings.TrimSpace(string(output)))
+	}
+	if output, err := m.newNonInteractiveGitCmd(ctx, worktreePath, "commit", "--no-verify", "-m", message).CombinedOutput(); err != nil {
+		return false, fmt.Errorf("%w: commit: %s", ErrGitCommandFailed, strings.TrimSpace(string(output)))
+	}
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeBranchMergesSiblingWorktreeBranch(t *testing.T) {
+	repoPath := initGitRepoForWorktreeTest(t)
+	winnerPath := filepath.Join(t.TempDir(), "winner")
+	runGit(t, repoPath, "worktree", "add", "-b", "fan-out/winner", winnerPath, "main")
+	if err := os.WriteFile(filepath.Join(winnerPath, "winner.txt"), []byte("from the winner\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, winnerPath, "add", "winner.txt")
+	runGit(t, winnerPath, "commit", "-m", "winner change")
+
+	m := &Manager{}
+	if err := m.MergeBranch(context.Background(), repoPath, "fan-out/winner", "Merge fan-out winner"); err != nil {
+		t.Fatalf("MergeBranch: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(repoPath, "winner.txt"))
+	if err != nil || string(got) != "from the winner\n" {
+		t.Fatalf("winner.txt = %q, %v; want the winner's content", got, err)
+	}
+	if subject := runGit(t, repoPath, "log", "-1", "--format=%s"); strings.TrimSpace(subject) != "Merge fan-out winner" {
+		t.Errorf("merge commit subject = %q", subject)
+	}
+}
+
+func TestMergeBranchAbortsOnConflict(t *testing.T) {
+	repoPath := initGitRepoForWorktreeTest(t)
+	otherPath := filepath.Join(t.TempDir(), "other")
+	runGit(t, repoPath, "worktree", "add", "-b", "other", otherPath, "main")
+	for path, content := range map[string]string{repoPath: "parent\n", otherPath: "other\n"} {
+		if err := os.WriteFile(filepath.Join(path, "README.md"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, path, "commit", "-am", "edit readme")
+	}
+
+	err := (&Manager{}).MergeBranch(context.Background(), repoPath, "other", "Merge other")
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("MergeBranch error = %v, want ErrMergeConflict", err)
+	}
+	if status := runGit(t, repoPath, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+		t.Errorf("worktree left dirty after an aborted merge:\n%s", status)
+	}
+}
+
+func TestCommitPendingChangesCommitsUntrackedAndModifiedFiles(t *testing.T) {
+	repoPath := initGitRepoForWorktreeTest(t)
+	m := &Manager{}
+	if committed, err := m.CommitPendingChanges(context.Background(), repoPath, "nothing"); err != nil || committed {
+		t.Fatalf("clean worktree: committed = %v, err = %v", committed, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "README.md"), []byte("edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	committed, err := m.CommitPendingChanges(context.Background(), repoPath, "Commit pending changes")
+	if err != nil || !committed {
+		t.Fatalf("committed = %v, err = %v", committed, err)
+	}
+	if status := runGit(t, repoPath, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+		t.Errorf("worktree still dirty:\n%s", status)
+	}
+	if subject := runGit(t, repoPath, "log", "-1", "--format=%s"); strings.TrimSpace(subject) != "Commit pending changes" {
+		t.Errorf("commit subject = %q", subject)
+	}
+}
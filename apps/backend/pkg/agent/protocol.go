@@ -11,6 +11,9 @@ const (
 	ProtocolREST Protocol = "rest"
 	// ProtocolMCP is the Model Context Protocol.
 	ProtocolMCP Protocol = "mcp"
+	// ProtocolOpenAI is the built-in agent that calls an OpenAI-compatible
+	// chat completions API directly from agentctl.
+	ProtocolOpenAI Protocol = "openai"
 )
 
 // String returns the string representation of the protocol.
@@ -21,7 +24,7 @@ func (p Protocol) String() string {
 // IsValid returns true if the protocol is a known valid protocol.
 func (p Protocol) IsValid() bool {
 	switch p {
-	case ProtocolACP, ProtocolREST, ProtocolMCP:
+	case ProtocolACP, ProtocolREST, ProtocolMCP, ProtocolOpenAI:
 		return true
 	default:
 		return false
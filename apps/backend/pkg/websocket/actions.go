@@ -97,6 +97,9 @@ const (
 	ActionSessionSetPlanMode  = "session.set_plan_mode"
 	ActionSessionRename       = "session.rename"
 	ActionSessionRouteAction  = "session.route_action"
+	ActionSessionCheckpoints  = "session.checkpoints"
+	ActionSessionRewind       = "session.rewind"
+	ActionSessionFork         = "session.fork"
 
 	// Agent actions
 	ActionAgentList   = "agent.list"
@@ -140,6 +143,7 @@ const (
 	ActionWorkflowStepGet      = "workflow.step.get"
 	ActionWorkflowStepCreate   = "workflow.step.create"
 	ActionWorkflowHistoryList  = "workflow.history.list"
+	ActionWorkflowFanOutSelect = "workflow.fan_out.select" // Pick the winning branch of a fan-out step
 
 	// Subscription actions
 	ActionTaskSubscribe      = "task.subscribe"
@@ -441,6 +445,9 @@ const (
 	// Office quorum decision recording.
 	ActionMCPRecordStepDecision = "mcp.record_step_decision"
 
+	// Fan-out judge selection.
+	ActionMCPSelectFanOutWinner = "mcp.select_fan_out_winner"
+
 	// Config-mode MCP actions (agent-native configuration)
 	ActionMCPCreateWorkflow = "mcp.create_workflow"
 	ActionMCPUpdateWorkflow = "mcp.update_workflow"
@@ -670,6 +677,8 @@ const (
 	ActionAutomationWebhookRevealSecret = "automation.webhook.reveal_secret"
 	ActionAutomationRunDelete           = "automation.run.delete"
 	ActionAutomationRunsDeleteAll       = "automation.runs.delete_all"
+	ActionAutomationDryRun              = "automation.dry_run"
+	ActionAutomationRunReplay           = "automation.run.replay"
 )
 
 // Error codes
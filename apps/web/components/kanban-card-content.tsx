@@ -8,6 +8,7 @@ import {
   IconAlertCircle,
   IconArrowsMaximize,
   IconDots,
+  IconHourglass,
   IconLoader2,
   IconLock,
   IconSubtask,
@@ -208,6 +209,17 @@ function KanbanCardBadges({ task }: { task: Task }) {
   return (
     <div className="flex flex-wrap items-center justify-end gap-2 mt-1 min-w-0">
       {task.blocked && <BlockedBadge task={task} />}
+      {task.admissionWaitReason && (
+        <Badge
+          variant="secondary"
+          className="text-xs h-5 gap-1"
+          title={t("kanban:waitingForResourcesTitle", { reason: task.admissionWaitReason })}
+          data-testid="kanban-card-admission-wait-badge"
+        >
+          <IconHourglass className="h-3 w-3" />
+          {t("kanban:waitingForResources")}
+        </Badge>
+      )}
       {task.queuedForStepId && (
         <Badge
           variant="secondary"
@@ -291,6 +303,7 @@ function hasCardBadges(task: Task): boolean {
     task.reviewStatus === "changes_requested" ||
     task.reviewStatus === "pending" ||
     task.queuedForStepId ||
+    task.admissionWaitReason ||
     task.blocked,
   );
 }
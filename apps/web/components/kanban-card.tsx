@@ -95,6 +95,8 @@ export interface Task {
   queuedAt?: string;
   issueUrl?: string;
   issueNumber?: number;
+  /** Why the agent launch is waiting on resource admission control. */
+  admissionWaitReason?: string;
 }
 
 export type RepositoryChip = {
@@ -31,6 +31,7 @@ import {
   KandevToolMessage,
   hasKandevRenderer,
 } from "@/components/task/chat/messages/kandev-tool-message";
+import { isRewoundMessage } from "@/components/task/chat/messages/rewind-turn-button";
 import { useTranslation } from "react-i18next";
 import { t } from "@/lib/i18n";
 
@@ -480,5 +481,12 @@ export const MessageRenderer = memo(function MessageRenderer({
   };
   const adapter =
     adapters.find((entry) => entry.matches(comment, ctx)) ?? adapters[adapters.length - 1];
-  return adapter.render(comment, ctx);
+  const rendered = adapter.render(comment, ctx);
+  if (!isRewoundMessage(comment)) return rendered;
+  // Turns discarded by a rewind stay visible for reference but read as inactive.
+  return (
+    <div data-testid="rewound-message" className="opacity-50" title={t("task:rewoundTurn")}>
+      {rendered}
+    </div>
+  );
 });
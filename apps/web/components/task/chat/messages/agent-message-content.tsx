@@ -74,6 +74,7 @@ export const AgentMessageContent = memo(function AgentMessageContent({
           onToggleRaw={onToggleRaw}
           isFavorite={isFavorite}
           onToggleFavorite={toggleFavorite}
+          showFork={true}
         />
       </div>
     </div>
@@ -508,6 +508,8 @@ function UserMessageContent({
           onToggleRaw={onToggleRaw}
           isFavorite={isFavorite}
           onToggleFavorite={toggleFavorite}
+          showRewind={true}
+          showFork={true}
           onNavigatePrev={() => {
             if (userNavigation.previousId && onScrollToMessage)
               onScrollToMessage(userNavigation.previousId);
@@ -0,0 +1,181 @@
+"use client";
+
+import { useCallback, useState } from "react";
+import { useRouter } from "next/navigation";
+import { IconGitFork } from "@tabler/icons-react";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from "@kandev/ui/dialog";
+import { Button } from "@kandev/ui/button";
+import { Label } from "@kandev/ui/label";
+import { RadioGroup, RadioGroupItem } from "@kandev/ui/radio-group";
+import { Textarea } from "@kandev/ui/textarea";
+import { cn } from "@/lib/utils";
+import { linkToTask } from "@/lib/links";
+import { useAppStore } from "@/components/state-provider";
+import { useToast } from "@/components/toast-provider";
+import { useDockviewStore } from "@/lib/state/dockview-store";
+import { addSessionPanel } from "@/lib/state/dockview-panel-actions";
+import { getWebSocketClient } from "@/lib/ws/connection";
+import { isRewoundMessage } from "@/components/task/chat/messages/rewind-turn-button";
+import type { Message } from "@/lib/types/http";
+import { useTranslation } from "react-i18next";
+
+const ACTION_BUTTON_CLASS = "h-5 w-5 p-1 hover:bg-muted rounded transition-colors duration-200";
+
+type ForkTarget = "child" | "sibling" | "same_task";
+
+type ForkSessionResponse = {
+  task: { id: string };
+  task_session_id?: string;
+};
+
+/**
+ * Forks the conversation at a message into a new task, or a new session of
+ * the same task, with its own worktree.
+ * Forking at a user message stops just before it and offers the message as
+ * the new session's first prompt, so it can be retried differently.
+ */
+export function ForkSessionButton({ message }: { message: Message }) {
+  const { t } = useTranslation();
+  const [open, setOpen] = useState(false);
+
+  if (!message.session_id || !message.turn_id || isRewoundMessage(message)) return null;
+
+  return (
+    <>
+      <button
+        type="button"
+        onClick={() => setOpen(true)}
+        className={cn(ACTION_BUTTON_CLASS, "cursor-pointer")}
+        title={t("task:forkFromHere")}
+        aria-label={t("task:forkFromHere")}
+        data-testid="fork-session-button"
+      >
+        <IconGitFork className="h-full w-full" />
+      </button>
+      {open && <ForkSessionDialog message={message} onClose={() => setOpen(false)} />}
+    </>
+  );
+}
+
+/** Collects the fork target and first prompt, then opens the new session. */
+function ForkSessionDialog({ message, onClose }: { message: Message; onClose: () => void }) {
+  const { t } = useTranslation();
+  const { toast } = useToast();
+  const router = useRouter();
+  const setActiveSession = useAppStore((state) => state.setActiveSession);
+  const isUserMessage = message.author_type === "user";
+  const [target, setTarget] = useState<ForkTarget>("child");
+  const [prompt, setPrompt] = useState(isUserMessage ? message.content : "");
+  const [isForking, setIsForking] = useState(false);
+
+  const handleFork = useCallback(async () => {
+    const client = getWebSocketClient();
+    if (!client) return;
+    setIsForking(true);
+    try {
+      const response = await client.request<ForkSessionResponse>(
+        "session.fork",
+        { session_id: message.session_id, message_id: message.id, target, prompt: prompt.trim() },
+        60000,
+      );
+      onClose();
+      if (target === "same_task" && response.task_session_id) {
+        openSessionPanel(
+          response.task.id,
+          response.task_session_id,
+          t("task:fork"),
+          setActiveSession,
+        );
+      } else {
+        router.push(linkToTask(response.task.id));
+      }
+    } catch (error) {
+      console.error("Failed to fork session:", error);
+      toast({
+        title: t("task:forkFailed"),
+        description: error instanceof Error ? error.message : String(error),
+        variant: "error",
+      });
+    } finally {
+      setIsForking(false);
+    }
+  }, [message.session_id, message.id, target, prompt, onClose, router, setActiveSession, t, toast]);
+
+  return (
+    <Dialog open onOpenChange={(open) => !open && onClose()}>
+      <DialogContent className="sm:max-w-lg" data-testid="fork-session-dialog">
+        <DialogHeader>
+          <DialogTitle>{t("task:forkSessionTitle")}</DialogTitle>
+          <DialogDescription>
+            {isUserMessage
+              ? t("task:forkSessionBeforeMessageDescription")
+              : t("task:forkSessionAfterMessageDescription")}
+          </DialogDescription>
+        </DialogHeader>
+        <RadioGroup
+          value={target}
+          onValueChange={(value) => setTarget(value as ForkTarget)}
+          className="space-y-2"
+        >
+          <div className="flex items-center space-x-3">
+            <RadioGroupItem value="child" id="fork-target-child" />
+            <Label htmlFor="fork-target-child" className="cursor-pointer">
+              {t("task:forkAsSubtask")}
+            </Label>
+          </div>
+          <div className="flex items-center space-x-3">
+            <RadioGroupItem value="sibling" id="fork-target-sibling" />
+            <Label htmlFor="fork-target-sibling" className="cursor-pointer">
+              {t("task:forkAsSiblingTask")}
+            </Label>
+          </div>
+          <div className="flex items-center space-x-3">
+            <RadioGroupItem value="same_task" id="fork-target-same-task" />
+            <Label htmlFor="fork-target-same-task" className="cursor-pointer">
+              {t("task:forkAsSameTaskSession")}
+            </Label>
+          </div>
+        </RadioGroup>
+        <Textarea
+          value={prompt}
+          onChange={(event) => setPrompt(event.target.value)}
+          placeholder={t("task:forkPromptPlaceholder")}
+          rows={4}
+          data-testid="fork-session-prompt"
+        />
+        <DialogFooter>
+          <Button variant="outline" onClick={onClose} className="cursor-pointer">
+            {t("common:cancel")}
+          </Button>
+          <Button
+            onClick={handleFork}
+            disabled={isForking || !prompt.trim()}
+            className="cursor-pointer"
+            data-testid="fork-session-confirm"
+          >
+            {t("task:fork")}
+          </Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  );
+}
+
+/** Activates a fork started in the current task and opens its chat panel. */
+function openSessionPanel(
+  taskId: string,
+  sessionId: string,
+  title: string,
+  setActiveSession: (taskId: string, sessionId: string) => void,
+) {
+  setActiveSession(taskId, sessionId);
+  const { api, centerGroupId } = useDockviewStore.getState();
+  if (api) addSessionPanel(api, centerGroupId, sessionId, title);
+}
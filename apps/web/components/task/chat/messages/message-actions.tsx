@@ -34,6 +34,8 @@ import {
   DrawerTrigger,
 } from "@kandev/ui/drawer";
 import { useTouchDrawer } from "@/hooks/use-compact-task-chrome";
+import { RewindTurnButton } from "@/components/task/chat/messages/rewind-turn-button";
+import { ForkSessionButton } from "@/components/task/chat/messages/fork-session-button";
 import { useTranslation } from "react-i18next";
 
 const ACTION_BUTTON_SIZE = "h-5 w-5 p-1";
@@ -58,6 +60,8 @@ type MessageActionsProps = {
   hasNext?: boolean;
   isFavorite?: boolean;
   onToggleFavorite?: () => void;
+  showRewind?: boolean;
+  showFork?: boolean;
 };
 
 /** Renders the accessible favorite toggle in a message action row. */
@@ -350,6 +354,8 @@ type ResolvedMessageActionsProps = {
   hasNext: boolean;
   isFavorite: boolean;
   onToggleFavorite?: () => void;
+  showRewind: boolean;
+  showFork: boolean;
 };
 
 /**
@@ -372,6 +378,8 @@ function resolveMessageActionsProps(props: MessageActionsProps): ResolvedMessage
     hasNext: props.hasNext ?? false,
     isFavorite: props.isFavorite ?? false,
     onToggleFavorite: props.onToggleFavorite,
+    showRewind: props.showRewind ?? false,
+    showFork: props.showFork ?? false,
   };
 }
 
@@ -393,6 +401,8 @@ export function MessageActions(props: MessageActionsProps) {
     hasNext,
     isFavorite,
     onToggleFavorite,
+    showRewind,
+    showFork,
   } = resolveMessageActionsProps(props);
   const { t } = useTranslation();
   const { copied, copy } = useCopyToClipboard();
@@ -432,6 +442,8 @@ export function MessageActions(props: MessageActionsProps) {
       )}
       <MessageDebugDialog message={message} turn={turn} usageMultiplier={usageMultiplier} />
       {onToggleFavorite && <FavoriteButton isFavorite={isFavorite} onToggle={onToggleFavorite} />}
+      {showRewind && <RewindTurnButton message={message} />}
+      {showFork && <ForkSessionButton message={message} />}
       <MessageMetaInfo
         showModel={showModel}
         sessionConfigText={sessionConfigText}
@@ -0,0 +1,86 @@
+"use client";
+
+import { useCallback, useRef, useState } from "react";
+import { IconArrowBackUp } from "@tabler/icons-react";
+import { cn } from "@/lib/utils";
+import { useAppStore } from "@/components/state-provider";
+import { useToast } from "@/components/toast-provider";
+import { ActionConfirmPopover } from "@/components/confirmation/action-confirm-popover";
+import { getWebSocketClient } from "@/lib/ws/connection";
+import type { Message } from "@/lib/types/http";
+import { useTranslation } from "react-i18next";
+
+const ACTION_BUTTON_CLASS = "h-5 w-5 p-1 hover:bg-muted rounded transition-colors duration-200";
+
+/** Whether a message belongs to a turn discarded by a workspace rewind. */
+export function isRewoundMessage(message: Message): boolean {
+  return message.metadata?.rewound === true;
+}
+
+/**
+ * Rewinds the workspace and conversation to before the turn a user message
+ * started. Only offered while the agent is idle, since a rewind cannot
+ * interrupt a running turn.
+ */
+export function RewindTurnButton({ message }: { message: Message }) {
+  const { t } = useTranslation();
+  const { toast } = useToast();
+  const [confirmOpen, setConfirmOpen] = useState(false);
+  const [isRewinding, setIsRewinding] = useState(false);
+  const actionRef = useRef<HTMLButtonElement>(null);
+  const sessionId = message.session_id;
+  const turnId = message.turn_id;
+  const isIdle = useAppStore((state) =>
+    sessionId ? state.taskSessions.items[sessionId]?.state === "WAITING_FOR_INPUT" : false,
+  );
+
+  const handleRewind = useCallback(async () => {
+    const client = getWebSocketClient();
+    if (!client || !sessionId || !turnId) return;
+    setIsRewinding(true);
+    try {
+      await client.request("session.rewind", { session_id: sessionId, turn_id: turnId }, 60000);
+    } catch (error) {
+      console.error("Failed to rewind session:", error);
+      toast({
+        title: t("task:rewindFailed"),
+        description: error instanceof Error ? error.message : String(error),
+        variant: "error",
+      });
+    } finally {
+      setIsRewinding(false);
+    }
+  }, [sessionId, turnId, t, toast]);
+
+  if (!turnId || !isIdle || isRewoundMessage(message)) return null;
+
+  return (
+    <>
+      <button
+        ref={actionRef}
+        type="button"
+        onClick={() => setConfirmOpen(true)}
+        disabled={isRewinding}
+        className={cn(ACTION_BUTTON_CLASS, "cursor-pointer disabled:opacity-30")}
+        title={t("task:rewindToBeforeTurn")}
+        aria-label={t("task:rewindToBeforeTurn")}
+        data-testid="rewind-turn-button"
+      >
+        <IconArrowBackUp className="h-full w-full" />
+      </button>
+      <ActionConfirmPopover
+        open={confirmOpen}
+        anchorRef={actionRef}
+        title={t("task:rewindToBeforeTurnTitle")}
+        description={t("task:rewindToBeforeTurnDescription")}
+        cancelLabel={t("common:cancel")}
+        confirmLabel={t("task:rewind")}
+        confirmAriaLabel={t("task:rewindToBeforeTurn")}
+        confirmTestId="rewind-turn-confirm"
+        testId="rewind-turn-confirm-popover"
+        onOpenChange={setConfirmOpen}
+        onConfirm={handleRewind}
+      />
+    </>
+  );
+}
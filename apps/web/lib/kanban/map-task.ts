@@ -2,6 +2,7 @@ import {
   isPRReviewFromMetadata,
   isIssueWatchFromMetadata,
   issueFieldsFromMetadata,
+  admissionWaitReasonFromMetadata,
 } from "@/lib/metadata-utils";
 import type { KanbanState, TaskDependencyRef } from "@/lib/state/slices/kanban/types";
 import type {
@@ -237,5 +238,6 @@ export function toKanbanTask(source: TaskLike): KanbanTask {
     isPRReview: isPRReviewFromMetadata(source.metadata),
     isIssueWatch: isIssueWatchFromMetadata(source.metadata),
     ...issueFieldsFromMetadata(source.metadata),
+    admissionWaitReason: admissionWaitReasonFromMetadata(source.metadata),
   } as KanbanTask;
 }
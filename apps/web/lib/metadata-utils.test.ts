@@ -3,6 +3,7 @@ import {
   isPRReviewFromMetadata,
   isIssueWatchFromMetadata,
   issueFieldsFromMetadata,
+  admissionWaitReasonFromMetadata,
 } from "./metadata-utils";
 
 describe("isPRReviewFromMetadata", () => {
@@ -79,3 +80,22 @@ describe("issueFieldsFromMetadata", () => {
     expect(issueFieldsFromMetadata(null)).toEqual({});
   });
 });
+
+describe("admissionWaitReasonFromMetadata", () => {
+  it("returns the reason of an admission wait record", () => {
+    const metadata = {
+      admission_wait: { reason: "host memory at 91% (limit 85%)", since: "2026-01-01T00:00:00Z" },
+    };
+    expect(admissionWaitReasonFromMetadata(metadata)).toBe("host memory at 91% (limit 85%)");
+  });
+
+  it("returns undefined when the task is not waiting", () => {
+    expect(admissionWaitReasonFromMetadata({ other: "val" })).toBeUndefined();
+    expect(admissionWaitReasonFromMetadata(null)).toBeUndefined();
+  });
+
+  it("ignores a malformed record", () => {
+    expect(admissionWaitReasonFromMetadata({ admission_wait: "memory" })).toBeUndefined();
+    expect(admissionWaitReasonFromMetadata({ admission_wait: { reason: "" } })).toBeUndefined();
+  });
+});
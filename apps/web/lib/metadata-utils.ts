@@ -17,6 +17,18 @@ export function isIssueWatchFromMetadata(metadata: Task["metadata"]): boolean {
   return hasWatchId(metadata, "issue_watch_id");
 }
 
+/**
+ * Reason the task's agent launch is held back by resource admission control,
+ * from the backend's `admission_wait` metadata record.
+ */
+export function admissionWaitReasonFromMetadata(metadata: Task["metadata"]): string | undefined {
+  if (!metadata || typeof metadata !== "object") return undefined;
+  const wait = (metadata as Record<string, unknown>)["admission_wait"];
+  if (!wait || typeof wait !== "object") return undefined;
+  const reason = (wait as Record<string, unknown>)["reason"];
+  return typeof reason === "string" && reason.length > 0 ? reason : undefined;
+}
+
 /** Extract issue-specific fields from task metadata. */
 export function issueFieldsFromMetadata(metadata: Task["metadata"]): {
   issueUrl?: string;
@@ -143,6 +143,8 @@ export type KanbanState = {
     isArchived?: boolean;
     issueUrl?: string;
     issueNumber?: number;
+    /** Why the agent launch is waiting on resource admission control. */
+    admissionWaitReason?: string;
     statusSummary?: TaskStatusSummary | null;
   }>;
   isLoading?: boolean;
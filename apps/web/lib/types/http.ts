@@ -177,8 +177,26 @@ export type ListWorkflowStepsResponse = {
   total: number;
 };
 
+// One run_check quality-gate evaluation. target_step_id names the step the
+// gate routed the task to, matching a history entry's to_step_id.
+export type StepCheckResult = {
+  id: number;
+  step_id: string;
+  trigger: string;
+  command: string;
+  passed: boolean;
+  exit_code: number;
+  timed_out: boolean;
+  output?: string;
+  error?: string;
+  duration_ms: number;
+  target_step_id?: string;
+  occurred_at: string;
+};
+
 export type ListSessionStepHistoryResponse = {
   history: SessionStepHistory[];
+  checks?: StepCheckResult[];
   total: number;
 };
 
@@ -110,7 +110,10 @@ function mergeTaskUpdate(
     merged.primarySessionPendingAction = existing.primarySessionPendingAction;
   }
   preservePrimaryExecutorFields(existing, merged, payload);
-  if (!hasPayloadField(payload, "metadata")) merged.metadata = existing.metadata;
+  if (!hasPayloadField(payload, "metadata")) {
+    merged.metadata = existing.metadata;
+    merged.admissionWaitReason = existing.admissionWaitReason;
+  }
   if (
     !hasPayloadField(payload, "task_pending_action") &&
     nextTask.taskPendingAction === undefined